@@ -0,0 +1,139 @@
+package semanticcache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// windowBuckets is how many buckets a sliding window is split into. Events
+// age out one bucket at a time, so a window covers between 9/10 and all of
+// its nominal length.
+const windowBuckets = 10
+
+// window counts events, and how many of them were flagged, over a sliding
+// time window.
+type window struct {
+	width   time.Duration // of one bucket
+	buckets [windowBuckets]windowBucket
+}
+
+type windowBucket struct {
+	slot    int64 // now / width when the bucket was last reset
+	n, hits int
+}
+
+func newWindow(d time.Duration) *window {
+	return &window{width: max(d/windowBuckets, 1)}
+}
+
+// add counts one event at now and returns the events and flagged events in
+// the window ending at now.
+func (w *window) add(now time.Time, flagged bool) (n, hits int) {
+	slot := now.UnixNano() / int64(w.width)
+	b := &w.buckets[slot%windowBuckets]
+	if b.slot != slot {
+		*b = windowBucket{slot: slot}
+	}
+	b.n++
+	if flagged {
+		b.hits++
+	}
+	for _, b := range w.buckets {
+		if slot-b.slot < windowBuckets {
+			n += b.n
+			hits += b.hits
+		}
+	}
+	return n, hits
+}
+
+// alerts evaluates the hooks set by options.OnMissRateAbove and
+// options.OnProviderErrorBurst. Each hook fires when its condition starts
+// to hold and is re-armed once it stops. A nil *alerts is disabled.
+type alerts struct {
+	mu sync.Mutex
+
+	missRate   float64
+	minLookups int
+	onMiss     func(rate float64, lookups int)
+	lookups    *window // flagged: missed
+	missing    bool
+
+	burst    int
+	onErrors func(failures int, last error)
+	calls    *window // flagged: failed
+	bursting bool
+}
+
+func newAlerts(cfg alertConfig) *alerts {
+	if cfg.onMiss == nil && cfg.onErrors == nil {
+		return nil
+	}
+	a := &alerts{
+		missRate:   cfg.missRate,
+		minLookups: cfg.minLookups,
+		onMiss:     cfg.onMiss,
+		burst:      cfg.burst,
+		onErrors:   cfg.onErrors,
+	}
+	if a.onMiss != nil {
+		a.lookups = newWindow(cfg.missWindow)
+	}
+	if a.onErrors != nil {
+		a.calls = newWindow(cfg.errorWindow)
+	}
+	return a
+}
+
+// alertConfig carries the alerting options into newAlerts.
+type alertConfig struct {
+	missRate    float64
+	missWindow  time.Duration
+	minLookups  int
+	onMiss      func(rate float64, lookups int)
+	burst       int
+	errorWindow time.Duration
+	onErrors    func(failures int, last error)
+}
+
+// lookup records whether a lookup found a match.
+func (a *alerts) lookup(hit bool) {
+	if a == nil || a.onMiss == nil {
+		return
+	}
+	a.mu.Lock()
+	n, misses := a.lookups.add(time.Now(), !hit)
+	rate := float64(misses) / float64(n)
+	above := n >= a.minLookups && rate > a.missRate
+	fire := above && !a.missing
+	a.missing = above
+	a.mu.Unlock()
+	if fire {
+		a.onMiss(rate, n)
+	}
+}
+
+// providerCall records the outcome of a provider call. Calls the caller
+// cancelled are ignored, as by the circuit breaker.
+func (a *alerts) providerCall(ctx context.Context, err error) {
+	if a == nil || a.onErrors == nil || err != nil && ctx.Err() != nil {
+		return
+	}
+	a.mu.Lock()
+	_, failures := a.calls.add(time.Now(), err != nil)
+	above := failures >= a.burst
+	fire := above && !a.bursting && err != nil
+	a.bursting = above
+	a.mu.Unlock()
+	if fire {
+		a.onErrors(failures, err)
+	}
+}
+
+// providerDone records the outcome of a provider call that the breaker
+// let through.
+func (c *Cache[K, V]) providerDone(ctx context.Context, err error) {
+	c.breaker.record(ctx, err)
+	c.alerts.providerCall(ctx, err)
+}
@@ -2,27 +2,79 @@
 package backends
 
 import (
+	"time"
+
+	"github.com/botirk38/semanticcache/backends/composite"
 	"github.com/botirk38/semanticcache/backends/inmemory"
 	"github.com/botirk38/semanticcache/backends/remote"
 	"github.com/botirk38/semanticcache/types"
 )
 
 // NewLRUBackend creates a new LRU in-memory backend.
-func NewLRUBackend[K comparable, V any](capacity int) (types.Backend[K, V], error) {
-	return inmemory.NewLRUBackend[K, V](capacity)
+func NewLRUBackend[K comparable, V any](capacity int, opts ...inmemory.Option) (types.Backend[K, V], error) {
+	return inmemory.NewLRUBackend[K, V](capacity, opts...)
 }
 
 // NewFIFOBackend creates a new FIFO in-memory backend.
-func NewFIFOBackend[K comparable, V any](capacity int) (types.Backend[K, V], error) {
-	return inmemory.NewFIFOBackend[K, V](capacity)
+func NewFIFOBackend[K comparable, V any](capacity int, opts ...inmemory.Option) (types.Backend[K, V], error) {
+	return inmemory.NewFIFOBackend[K, V](capacity, opts...)
 }
 
 // NewLFUBackend creates a new LFU in-memory backend.
-func NewLFUBackend[K comparable, V any](capacity int) (types.Backend[K, V], error) {
-	return inmemory.NewLFUBackend[K, V](capacity)
+func NewLFUBackend[K comparable, V any](capacity int, opts ...inmemory.Option) (types.Backend[K, V], error) {
+	return inmemory.NewLFUBackend[K, V](capacity, opts...)
+}
+
+// NewTinyLFUBackend creates a new W-TinyLFU in-memory backend.
+func NewTinyLFUBackend[K comparable, V any](capacity int) (types.Backend[K, V], error) {
+	return inmemory.NewTinyLFUBackend[K, V](capacity)
+}
+
+// NewTTLBackend creates a new expiring in-memory backend.
+func NewTTLBackend[K comparable, V any](capacity int, ttl time.Duration) (types.Backend[K, V], error) {
+	return inmemory.NewTTLBackend[K, V](capacity, ttl)
+}
+
+// NewShardedBackend creates a new sharded LRU in-memory backend.
+func NewShardedBackend[K comparable, V any](shards, capacity int) (types.Backend[K, V], error) {
+	return inmemory.NewShardedBackend[K, V](shards, capacity)
+}
+
+// NewHNSWBackend creates a new in-memory backend with an HNSW vector index.
+func NewHNSWBackend[K comparable, V any](opts ...inmemory.Option) (types.Backend[K, V], error) {
+	return inmemory.NewHNSWBackend[K, V](opts...)
+}
+
+// NewBinaryBackend creates a new in-memory backend with binary-quantized
+// embeddings.
+func NewBinaryBackend[K comparable, V any](opts ...inmemory.Option) (types.Backend[K, V], error) {
+	return inmemory.NewBinaryBackend[K, V](opts...)
 }
 
 // NewRedisBackend creates a new Redis backend.
 func NewRedisBackend[K comparable, V any](addr string, opts ...remote.RedisOption) (types.Backend[K, V], error) {
 	return remote.NewRedisBackend[K, V](addr, opts...)
 }
+
+// NewTieredBackend creates a write-through L1/L2 backend.
+func NewTieredBackend[K comparable, V any](l1, l2 types.Backend[K, V], opts ...composite.TieredOption) (types.Backend[K, V], error) {
+	return composite.NewTieredBackend(l1, l2, opts...)
+}
+
+// NewWriteBehindBackend creates a backend that replicates writes to a
+// secondary asynchronously.
+func NewWriteBehindBackend[K comparable, V any](primary, secondary types.Backend[K, V], opts ...composite.WriteBehindOption) (types.Backend[K, V], error) {
+	return composite.NewWriteBehindBackend(primary, secondary, opts...)
+}
+
+// NewOffloadBackend creates a backend that moves large values to an object
+// store and keeps references in inner.
+func NewOffloadBackend[K comparable, V any](inner types.Backend[K, composite.OffloadedValue], store composite.ObjectStore, opts ...composite.OffloadOption) (types.Backend[K, V], error) {
+	return composite.NewOffloadBackend[K, V](inner, store, opts...)
+}
+
+// Instrumented wraps inner so that the latency, outcome, and payload size
+// of every call are reported to hooks and aggregated in Stats.
+func Instrumented[K comparable, V any](inner types.Backend[K, V], hooks composite.InstrumentHooks[V]) (*composite.InstrumentedBackend[K, V], error) {
+	return composite.NewInstrumentedBackend(inner, hooks)
+}
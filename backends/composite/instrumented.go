@@ -0,0 +1,202 @@
+package composite
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// Op names a backend method recorded by InstrumentedBackend.
+type Op string
+
+// Recorded operations, one per types.Backend method.
+const (
+	OpSet          Op = "Set"
+	OpGet          Op = "Get"
+	OpDelete       Op = "Delete"
+	OpContains     Op = "Contains"
+	OpGetEmbedding Op = "GetEmbedding"
+	OpKeys         Op = "Keys"
+	OpLen          Op = "Len"
+	OpFlush        Op = "Flush"
+	OpClose        Op = "Close"
+)
+
+// OpStats describes one call to the wrapped backend.
+type OpStats struct {
+	Op       Op
+	Duration time.Duration
+	Err      error
+
+	// Found reports whether Get, Contains, or GetEmbedding found the key.
+	Found bool
+
+	// Keys is the number of keys the call touched or returned.
+	Keys int
+
+	// Bytes approximates the payload moved: 8 bytes per embedding
+	// dimension plus InstrumentHooks.Size of each value written or read.
+	Bytes int
+}
+
+// OpTotals aggregates the OpStats of one operation.
+type OpTotals struct {
+	Calls    int64
+	Errors   int64
+	Duration time.Duration // summed over all calls
+	Bytes    int64
+}
+
+// InstrumentHooks configures an InstrumentedBackend. The zero value only
+// aggregates totals for Stats.
+type InstrumentHooks[V any] struct {
+	// OnOp, if set, is called after every call to the wrapped backend, on
+	// the caller's goroutine. Use it to feed a metrics library.
+	OnOp func(ctx context.Context, s OpStats)
+
+	// Size, if set, returns a value's payload size in bytes. Without it
+	// Bytes counts embeddings only.
+	Size func(V) int
+}
+
+// InstrumentedBackend wraps a backend and records the latency, outcome,
+// and payload size of every call, so different backends can be monitored
+// the same way without changing them.
+//
+// It implements types.Backend only: optional capabilities of the wrapped
+// backend, such as types.EntryBackend or types.VectorSearcher, are hidden,
+// and the cache falls back to its generic paths for them.
+type InstrumentedBackend[K comparable, V any] struct {
+	inner types.Backend[K, V]
+	hooks InstrumentHooks[V]
+
+	mu     sync.Mutex
+	totals map[Op]OpTotals
+}
+
+var _ types.Backend[string, string] = (*InstrumentedBackend[string, string])(nil)
+
+// NewInstrumentedBackend wraps inner so that every call is reported to
+// hooks and aggregated for Stats.
+func NewInstrumentedBackend[K comparable, V any](inner types.Backend[K, V], hooks InstrumentHooks[V]) (*InstrumentedBackend[K, V], error) {
+	if inner == nil {
+		return nil, ErrNilBackend
+	}
+	return &InstrumentedBackend[K, V]{inner: inner, hooks: hooks, totals: make(map[Op]OpTotals)}, nil
+}
+
+// Stats returns the totals recorded so far for each operation that has
+// been called.
+func (b *InstrumentedBackend[K, V]) Stats() map[Op]OpTotals {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make(map[Op]OpTotals, len(b.totals))
+	for op, t := range b.totals {
+		out[op] = t
+	}
+	return out
+}
+
+func (b *InstrumentedBackend[K, V]) record(ctx context.Context, s OpStats, start time.Time) {
+	s.Duration = time.Since(start)
+
+	b.mu.Lock()
+	t := b.totals[s.Op]
+	t.Calls++
+	if s.Err != nil {
+		t.Errors++
+	}
+	t.Duration += s.Duration
+	t.Bytes += int64(s.Bytes)
+	b.totals[s.Op] = t
+	b.mu.Unlock()
+
+	if b.hooks.OnOp != nil {
+		b.hooks.OnOp(ctx, s)
+	}
+}
+
+func (b *InstrumentedBackend[K, V]) valueSize(v V) int {
+	if b.hooks.Size == nil {
+		return 0
+	}
+	return b.hooks.Size(v)
+}
+
+// Set implements types.Backend.
+func (b *InstrumentedBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	start := time.Now()
+	err := b.inner.Set(ctx, key, embedding, value)
+	b.record(ctx, OpStats{Op: OpSet, Err: err, Keys: 1, Bytes: 8*len(embedding) + b.valueSize(value)}, start)
+	return err
+}
+
+// Get implements types.Backend.
+func (b *InstrumentedBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	start := time.Now()
+	v, ok, err := b.inner.Get(ctx, key)
+	s := OpStats{Op: OpGet, Err: err, Found: ok, Keys: 1}
+	if ok {
+		s.Bytes = b.valueSize(v)
+	}
+	b.record(ctx, s, start)
+	return v, ok, err
+}
+
+// Delete implements types.Backend.
+func (b *InstrumentedBackend[K, V]) Delete(ctx context.Context, key K) error {
+	start := time.Now()
+	err := b.inner.Delete(ctx, key)
+	b.record(ctx, OpStats{Op: OpDelete, Err: err, Keys: 1}, start)
+	return err
+}
+
+// Contains implements types.Backend.
+func (b *InstrumentedBackend[K, V]) Contains(ctx context.Context, key K) (bool, error) {
+	start := time.Now()
+	ok, err := b.inner.Contains(ctx, key)
+	b.record(ctx, OpStats{Op: OpContains, Err: err, Found: ok, Keys: 1}, start)
+	return ok, err
+}
+
+// GetEmbedding implements types.Backend.
+func (b *InstrumentedBackend[K, V]) GetEmbedding(ctx context.Context, key K) ([]float64, bool, error) {
+	start := time.Now()
+	emb, ok, err := b.inner.GetEmbedding(ctx, key)
+	b.record(ctx, OpStats{Op: OpGetEmbedding, Err: err, Found: ok, Keys: 1, Bytes: 8 * len(emb)}, start)
+	return emb, ok, err
+}
+
+// Keys implements types.Backend.
+func (b *InstrumentedBackend[K, V]) Keys(ctx context.Context) ([]K, error) {
+	start := time.Now()
+	keys, err := b.inner.Keys(ctx)
+	b.record(ctx, OpStats{Op: OpKeys, Err: err, Keys: len(keys)}, start)
+	return keys, err
+}
+
+// Len implements types.Backend.
+func (b *InstrumentedBackend[K, V]) Len(ctx context.Context) (int, error) {
+	start := time.Now()
+	n, err := b.inner.Len(ctx)
+	b.record(ctx, OpStats{Op: OpLen, Err: err}, start)
+	return n, err
+}
+
+// Flush implements types.Backend.
+func (b *InstrumentedBackend[K, V]) Flush(ctx context.Context) error {
+	start := time.Now()
+	err := b.inner.Flush(ctx)
+	b.record(ctx, OpStats{Op: OpFlush, Err: err}, start)
+	return err
+}
+
+// Close implements types.Backend and closes the wrapped backend.
+func (b *InstrumentedBackend[K, V]) Close() error {
+	start := time.Now()
+	err := b.inner.Close()
+	b.record(context.Background(), OpStats{Op: OpClose, Err: err}, start)
+	return err
+}
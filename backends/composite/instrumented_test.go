@@ -0,0 +1,102 @@
+package composite
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/botirk38/semanticcache/backends/inmemory"
+)
+
+func TestInstrumented_Validation(t *testing.T) {
+	if _, err := NewInstrumentedBackend[string, string](nil, InstrumentHooks[string]{}); err != ErrNilBackend {
+		t.Errorf("expected ErrNilBackend, got %v", err)
+	}
+}
+
+func TestInstrumented_RecordsOperations(t *testing.T) {
+	ctx := context.Background()
+	inner, _ := inmemory.NewLRUBackend[string, string](10)
+
+	var mu sync.Mutex
+	var seen []OpStats
+	b, _ := NewInstrumentedBackend(inner, InstrumentHooks[string]{
+		OnOp: func(_ context.Context, s OpStats) {
+			mu.Lock()
+			seen = append(seen, s)
+			mu.Unlock()
+		},
+		Size: func(v string) int { return len(v) },
+	})
+
+	if err := b.Set(ctx, "k", []float64{1, 2, 3}, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, err := b.Get(ctx, "k"); err != nil || !ok || v != "hello" {
+		t.Fatalf("expected passthrough Get, got %q ok=%v err=%v", v, ok, err)
+	}
+	_, _, _ = b.Get(ctx, "missing")
+	_, _, _ = b.GetEmbedding(ctx, "k")
+	_, _ = b.Keys(ctx)
+
+	want := []OpStats{
+		{Op: OpSet, Keys: 1, Bytes: 24 + 5},
+		{Op: OpGet, Keys: 1, Found: true, Bytes: 5},
+		{Op: OpGet, Keys: 1},
+		{Op: OpGetEmbedding, Keys: 1, Found: true, Bytes: 24},
+		{Op: OpKeys, Keys: 1},
+	}
+	if len(seen) != len(want) {
+		t.Fatalf("expected %d hook calls, got %d", len(want), len(seen))
+	}
+	for i, w := range want {
+		s := seen[i]
+		s.Duration = 0
+		if s != w {
+			t.Errorf("call %d: expected %+v, got %+v", i, w, s)
+		}
+	}
+
+	stats := b.Stats()
+	if got := stats[OpGet]; got.Calls != 2 || got.Errors != 0 || got.Bytes != 5 {
+		t.Errorf("unexpected Get totals: %+v", got)
+	}
+	if got := stats[OpSet]; got.Calls != 1 || got.Bytes != 29 {
+		t.Errorf("unexpected Set totals: %+v", got)
+	}
+	if _, ok := stats[OpDelete]; ok {
+		t.Error("expected no totals for an operation never called")
+	}
+}
+
+type failingBackend struct {
+	*inmemory.LRUBackend[string, string]
+}
+
+var errBackendDown = errors.New("backend down")
+
+func (failingBackend) Get(context.Context, string) (string, bool, error) {
+	return "", false, errBackendDown
+}
+
+func TestInstrumented_CountsErrors(t *testing.T) {
+	ctx := context.Background()
+	inner, _ := inmemory.NewLRUBackend[string, string](10)
+	b, _ := NewInstrumentedBackend[string, string](failingBackend{inner}, InstrumentHooks[string]{})
+
+	for range 3 {
+		if _, _, err := b.Get(ctx, "k"); !errors.Is(err, errBackendDown) {
+			t.Fatalf("expected the inner error, got %v", err)
+		}
+	}
+	_, _ = b.Contains(ctx, "k")
+
+	stats := b.Stats()
+	if got := stats[OpGet]; got.Calls != 3 || got.Errors != 3 {
+		t.Errorf("expected 3 failed Gets, got %+v", got)
+	}
+	if got := stats[OpContains]; got.Calls != 1 || got.Errors != 0 {
+		t.Errorf("expected 1 successful Contains, got %+v", got)
+	}
+}
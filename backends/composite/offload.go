@@ -0,0 +1,256 @@
+package composite
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+var (
+	// ErrNilObjectStore is returned when an OffloadBackend is given a nil
+	// object store.
+	ErrNilObjectStore = errors.New("composite: object store cannot be nil")
+
+	// ErrObjectNotFound must be returned (or wrapped) by ObjectStore.Get when
+	// the named object does not exist.
+	ErrObjectNotFound = errors.New("composite: object not found")
+)
+
+// ObjectStore is the blob store an OffloadBackend writes large values to.
+// Adapters for S3, GCS, or Azure Blob need only these three calls.
+type ObjectStore interface {
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+	Delete(ctx context.Context, name string) error
+}
+
+// Codec encodes values for an OffloadBackend. remote.ValueCodec
+// implementations satisfy it.
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v any) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// OffloadedValue is what an OffloadBackend stores in its inner backend:
+// either the encoded value itself or the name of the object holding it.
+type OffloadedValue struct {
+	Inline []byte `json:"inline,omitempty"`
+	Ref    string `json:"ref,omitempty"`
+}
+
+// OffloadOption configures an OffloadBackend.
+type OffloadOption func(*offloadConfig)
+
+type offloadConfig struct {
+	threshold int
+	prefix    string
+	codec     Codec
+}
+
+// WithOffloadThreshold sets the encoded size, in bytes, above which values
+// are written to the object store. Defaults to 64 KiB.
+func WithOffloadThreshold(n int) OffloadOption {
+	return func(c *offloadConfig) { c.threshold = n }
+}
+
+// WithObjectPrefix sets the prefix for object names. Defaults to
+// "semanticcache/".
+func WithObjectPrefix(prefix string) OffloadOption {
+	return func(c *offloadConfig) { c.prefix = prefix }
+}
+
+// WithOffloadCodec sets how values are encoded. Defaults to encoding/json.
+func WithOffloadCodec(codec Codec) OffloadOption {
+	return func(c *offloadConfig) { c.codec = codec }
+}
+
+// OffloadBackend keeps embeddings and small values in an inner backend and
+// moves values whose encoding exceeds a threshold to an object store,
+// leaving only a reference behind. This bounds the memory a large LLM
+// output or document costs in Redis.
+//
+// Each offloaded value gets a fresh object name; the previous object is
+// deleted after an overwrite or Delete. A crash between the two steps can
+// leave an orphaned object, so pair the bucket with a lifecycle rule. A
+// reference whose object has gone is reported as a miss.
+type OffloadBackend[K comparable, V any] struct {
+	inner     types.Backend[K, OffloadedValue]
+	store     ObjectStore
+	threshold int
+	prefix    string
+	codec     Codec
+}
+
+var _ types.Backend[string, string] = (*OffloadBackend[string, string])(nil)
+
+// NewOffloadBackend wraps inner, offloading large values to store.
+func NewOffloadBackend[K comparable, V any](inner types.Backend[K, OffloadedValue], store ObjectStore, opts ...OffloadOption) (*OffloadBackend[K, V], error) {
+	if inner == nil {
+		return nil, ErrNilBackend
+	}
+	if store == nil {
+		return nil, ErrNilObjectStore
+	}
+	cfg := &offloadConfig{
+		threshold: 64 << 10,
+		prefix:    "semanticcache/",
+		codec:     jsonCodec{},
+	}
+	for _, o := range opts {
+		o(cfg)
+	}
+	return &OffloadBackend[K, V]{
+		inner:     inner,
+		store:     store,
+		threshold: cfg.threshold,
+		prefix:    cfg.prefix,
+		codec:     cfg.codec,
+	}, nil
+}
+
+// Set stores value inline or in the object store, depending on its size.
+func (b *OffloadBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	data, err := b.codec.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+	old, _, err := b.inner.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	stored := OffloadedValue{Inline: data}
+	if len(data) > b.threshold {
+		name, err := b.objectName()
+		if err != nil {
+			return err
+		}
+		if err := b.store.Put(ctx, name, data); err != nil {
+			return fmt.Errorf("failed to offload value: %w", err)
+		}
+		stored = OffloadedValue{Ref: name}
+	}
+	if err := b.inner.Set(ctx, key, embedding, stored); err != nil {
+		if stored.Ref != "" {
+			_ = b.store.Delete(ctx, stored.Ref)
+		}
+		return err
+	}
+	return b.deleteObject(ctx, old)
+}
+
+// Get retrieves the value for a key, fetching it from the object store if
+// it was offloaded.
+func (b *OffloadBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	var v V
+	stored, ok, err := b.inner.Get(ctx, key)
+	if err != nil || !ok {
+		return v, false, err
+	}
+	data := stored.Inline
+	if stored.Ref != "" {
+		data, err = b.store.Get(ctx, stored.Ref)
+		if errors.Is(err, ErrObjectNotFound) {
+			return v, false, nil
+		}
+		if err != nil {
+			return v, false, fmt.Errorf("failed to fetch offloaded value: %w", err)
+		}
+	}
+	if err := b.codec.Unmarshal(data, &v); err != nil {
+		return v, false, fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+	return v, true, nil
+}
+
+// Delete removes an entry and its offloaded object, if any.
+func (b *OffloadBackend[K, V]) Delete(ctx context.Context, key K) error {
+	stored, _, err := b.inner.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := b.inner.Delete(ctx, key); err != nil {
+		return err
+	}
+	return b.deleteObject(ctx, stored)
+}
+
+// Contains checks whether a key exists in the inner backend.
+func (b *OffloadBackend[K, V]) Contains(ctx context.Context, key K) (bool, error) {
+	return b.inner.Contains(ctx, key)
+}
+
+// Flush deletes every offloaded object, then flushes the inner backend.
+func (b *OffloadBackend[K, V]) Flush(ctx context.Context) error {
+	keys, err := b.inner.Keys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		stored, _, err := b.inner.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if err := b.deleteObject(ctx, stored); err != nil {
+			return err
+		}
+	}
+	return b.inner.Flush(ctx)
+}
+
+// Len returns the number of entries in the inner backend.
+func (b *OffloadBackend[K, V]) Len(ctx context.Context) (int, error) {
+	return b.inner.Len(ctx)
+}
+
+// Keys returns all keys in the inner backend.
+func (b *OffloadBackend[K, V]) Keys(ctx context.Context) ([]K, error) {
+	return b.inner.Keys(ctx)
+}
+
+// GetEmbedding retrieves the embedding from the inner backend without
+// touching the object store.
+func (b *OffloadBackend[K, V]) GetEmbedding(ctx context.Context, key K) ([]float64, bool, error) {
+	return b.inner.GetEmbedding(ctx, key)
+}
+
+// Close closes the inner backend, and the object store if it implements
+// io.Closer.
+func (b *OffloadBackend[K, V]) Close() error {
+	err := b.inner.Close()
+	if c, ok := b.store.(io.Closer); ok {
+		err = errors.Join(err, c.Close())
+	}
+	return err
+}
+
+func (b *OffloadBackend[K, V]) objectName() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate object name: %w", err)
+	}
+	return b.prefix + hex.EncodeToString(id), nil
+}
+
+// deleteObject removes the object stored refers to. A missing object is
+// not an error.
+func (b *OffloadBackend[K, V]) deleteObject(ctx context.Context, stored OffloadedValue) error {
+	if stored.Ref == "" {
+		return nil
+	}
+	if err := b.store.Delete(ctx, stored.Ref); err != nil && !errors.Is(err, ErrObjectNotFound) {
+		return fmt.Errorf("failed to delete offloaded value: %w", err)
+	}
+	return nil
+}
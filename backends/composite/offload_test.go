@@ -0,0 +1,156 @@
+package composite
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/botirk38/semanticcache/backends/inmemory"
+)
+
+type memStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newMemStore() *memStore { return &memStore{objects: make(map[string][]byte)} }
+
+func (s *memStore) Put(_ context.Context, name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[name] = data
+	return nil
+}
+
+func (s *memStore) Get(_ context.Context, name string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[name]
+	if !ok {
+		return nil, ErrObjectNotFound
+	}
+	return data, nil
+}
+
+func (s *memStore) Delete(_ context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.objects, name)
+	return nil
+}
+
+func (s *memStore) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.objects)
+}
+
+func TestOffload_Validation(t *testing.T) {
+	inner, _ := inmemory.NewLRUBackend[string, OffloadedValue](10)
+	if _, err := NewOffloadBackend[string, string](nil, newMemStore()); err != ErrNilBackend {
+		t.Errorf("expected ErrNilBackend, got %v", err)
+	}
+	if _, err := NewOffloadBackend[string, string](inner, nil); err != ErrNilObjectStore {
+		t.Errorf("expected ErrNilObjectStore, got %v", err)
+	}
+}
+
+func TestOffload_SplitsBySize(t *testing.T) {
+	ctx := context.Background()
+	inner, _ := inmemory.NewLRUBackend[string, OffloadedValue](10)
+	store := newMemStore()
+	b, _ := NewOffloadBackend[string, string](inner, store, WithOffloadThreshold(16), WithObjectPrefix("test/"))
+
+	big := strings.Repeat("x", 100)
+	if err := b.Set(ctx, "small", []float64{1}, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Set(ctx, "big", []float64{2}, big); err != nil {
+		t.Fatal(err)
+	}
+
+	if store.len() != 1 {
+		t.Fatalf("expected one offloaded object, got %d", store.len())
+	}
+	raw, _, _ := inner.Get(ctx, "big")
+	if raw.Ref == "" || !strings.HasPrefix(raw.Ref, "test/") || raw.Inline != nil {
+		t.Errorf("expected a prefixed reference for the large value, got %+v", raw)
+	}
+	for key, want := range map[string]string{"small": "hi", "big": big} {
+		if v, ok, err := b.Get(ctx, key); err != nil || !ok || v != want {
+			t.Errorf("Get(%q) = %q ok=%v err=%v", key, v, ok, err)
+		}
+	}
+	if emb, ok, _ := b.GetEmbedding(ctx, "big"); !ok || emb[0] != 2 {
+		t.Errorf("expected embedding from inner backend, got %v", emb)
+	}
+}
+
+func TestOffload_CleansUpObjects(t *testing.T) {
+	ctx := context.Background()
+	inner, _ := inmemory.NewLRUBackend[string, OffloadedValue](10)
+	store := newMemStore()
+	b, _ := NewOffloadBackend[string, string](inner, store, WithOffloadThreshold(4))
+
+	_ = b.Set(ctx, "k", nil, "first large value")
+	_ = b.Set(ctx, "k", nil, "second large value")
+	if store.len() != 1 {
+		t.Errorf("overwrite should delete the old object, have %d", store.len())
+	}
+	_ = b.Set(ctx, "k", nil, "s")
+	if store.len() != 0 {
+		t.Errorf("inline overwrite should delete the old object, have %d", store.len())
+	}
+
+	_ = b.Set(ctx, "a", nil, "large value a")
+	_ = b.Delete(ctx, "a")
+	if store.len() != 0 {
+		t.Errorf("Delete should remove the object, have %d", store.len())
+	}
+
+	_ = b.Set(ctx, "b", nil, "large value b")
+	_ = b.Set(ctx, "c", nil, "large value c")
+	if err := b.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if store.len() != 0 {
+		t.Errorf("Flush should remove every object, have %d", store.len())
+	}
+}
+
+func TestOffload_MissingObjectIsMiss(t *testing.T) {
+	ctx := context.Background()
+	inner, _ := inmemory.NewLRUBackend[string, OffloadedValue](10)
+	store := newMemStore()
+	b, _ := NewOffloadBackend[string, string](inner, store, WithOffloadThreshold(4))
+
+	_ = b.Set(ctx, "k", nil, "large value")
+	raw, _, _ := inner.Get(ctx, "k")
+	_ = store.Delete(ctx, raw.Ref)
+
+	if v, ok, err := b.Get(ctx, "k"); ok || err != nil {
+		t.Errorf("expected miss for expired object, got %q ok=%v err=%v", v, ok, err)
+	}
+}
+
+type failingStore struct{ *memStore }
+
+func (failingStore) Put(context.Context, string, []byte) error {
+	return errors.New("bucket unavailable")
+}
+
+func TestOffload_PutErrorLeavesEntryUntouched(t *testing.T) {
+	ctx := context.Background()
+	inner, _ := inmemory.NewLRUBackend[string, OffloadedValue](10)
+	b, _ := NewOffloadBackend[string, string](inner, failingStore{newMemStore()}, WithOffloadThreshold(4))
+
+	_ = b.Set(ctx, "k", nil, "ok")
+	if err := b.Set(ctx, "k", nil, "too large"); err == nil {
+		t.Fatal("expected Put error")
+	}
+	if v, _, _ := b.Get(ctx, "k"); v != "ok" {
+		t.Errorf("failed Set should keep the old value, got %q", v)
+	}
+}
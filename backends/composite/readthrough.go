@@ -0,0 +1,138 @@
+package composite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+var (
+	// ErrNilBackend is returned when a wrapper is given a nil backend.
+	ErrNilBackend = errors.New("composite: backend cannot be nil")
+
+	// ErrNilProvider is returned when a wrapper that embeds values is given
+	// a nil embedding provider.
+	ErrNilProvider = errors.New("composite: embedding provider cannot be nil")
+
+	// ErrNilLoader is returned when a ReadThroughBackend is given a nil loader.
+	ErrNilLoader = errors.New("composite: loader cannot be nil")
+
+	// ErrLoaderPanicked is returned to callers sharing a load whose loader
+	// panicked. The panic itself propagates to the caller that ran it.
+	ErrLoaderPanicked = errors.New("composite: loader panicked")
+)
+
+// Loader fetches the value for a key from the system of record, along with
+// the text its embedding should be computed from. found is false when the
+// key does not exist there either.
+type Loader[K comparable, V any] func(ctx context.Context, key K) (inputText string, value V, found bool, err error)
+
+// loadCall is an in-progress load shared by concurrent Get misses.
+type loadCall[V any] struct {
+	done  chan struct{}
+	value V
+	found bool
+	err   error
+}
+
+// ReadThroughBackend wraps a backend so that a Get miss invokes a loader,
+// embeds the loaded text, stores the entry, and returns the value.
+// Concurrent misses for the same key share a single load. A caller whose
+// ctx ends while waiting returns ctx.Err(); one whose load was shared with
+// a caller that gave up loads again itself.
+//
+// Only Get loads; Contains, GetEmbedding, Keys, and Len report what is
+// currently stored. It implements types.Backend only: optional capabilities
+// of the wrapped backend are hidden, and loaded entries have no input text.
+// With a semanticcache.Cache, use options.WithReadThrough instead, which
+// loads in Cache.Get and keeps them.
+type ReadThroughBackend[K comparable, V any] struct {
+	types.Backend[K, V]
+	provider types.EmbeddingProvider
+	loader   Loader[K, V]
+
+	mu    sync.Mutex
+	calls map[K]*loadCall[V]
+}
+
+var _ types.Backend[string, string] = (*ReadThroughBackend[string, string])(nil)
+
+// NewReadThroughBackend wraps backend with loader. provider embeds the text
+// returned by the loader.
+func NewReadThroughBackend[K comparable, V any](backend types.Backend[K, V], provider types.EmbeddingProvider, loader Loader[K, V]) (*ReadThroughBackend[K, V], error) {
+	if backend == nil {
+		return nil, ErrNilBackend
+	}
+	if provider == nil {
+		return nil, ErrNilProvider
+	}
+	if loader == nil {
+		return nil, ErrNilLoader
+	}
+	return &ReadThroughBackend[K, V]{
+		Backend:  backend,
+		provider: provider,
+		loader:   loader,
+		calls:    make(map[K]*loadCall[V]),
+	}, nil
+}
+
+// Get returns the stored value, loading it on a miss.
+func (b *ReadThroughBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	v, ok, err := b.Backend.Get(ctx, key)
+	if err != nil || ok {
+		return v, ok, err
+	}
+
+	for {
+		b.mu.Lock()
+		c, ok := b.calls[key]
+		if !ok {
+			break
+		}
+		b.mu.Unlock()
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return v, false, ctx.Err()
+		}
+		if c.err != nil && (errors.Is(c.err, context.Canceled) || errors.Is(c.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+			continue
+		}
+		return c.value, c.found, c.err
+	}
+	c := &loadCall[V]{done: make(chan struct{}), err: ErrLoaderPanicked}
+	b.calls[key] = c
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.calls, key)
+		b.mu.Unlock()
+		close(c.done)
+	}()
+	c.value, c.found, c.err = b.load(ctx, key)
+	return c.value, c.found, c.err
+}
+
+func (b *ReadThroughBackend[K, V]) load(ctx context.Context, key K) (V, bool, error) {
+	var zero V
+	text, value, found, err := b.loader(ctx, key)
+	if err != nil {
+		return zero, false, fmt.Errorf("failed to load key: %w", err)
+	}
+	if !found {
+		return zero, false, nil
+	}
+	embedding, err := b.provider.EmbedText(ctx, text)
+	if err != nil {
+		return zero, false, fmt.Errorf("failed to embed loaded value: %w", err)
+	}
+	if err := b.Backend.Set(ctx, key, embedding, value); err != nil {
+		return zero, false, err
+	}
+	return value, true, nil
+}
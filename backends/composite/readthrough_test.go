@@ -0,0 +1,163 @@
+package composite
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/botirk38/semanticcache/backends/inmemory"
+)
+
+type lengthProvider struct{}
+
+func (lengthProvider) EmbedText(_ context.Context, text string) ([]float64, error) {
+	return []float64{float64(len(text))}, nil
+}
+
+func (lengthProvider) Close() error { return nil }
+
+func TestReadThrough_Validation(t *testing.T) {
+	inner, _ := inmemory.NewLRUBackend[string, string](10)
+	loader := func(context.Context, string) (string, string, bool, error) { return "", "", false, nil }
+
+	if _, err := NewReadThroughBackend[string, string](nil, lengthProvider{}, loader); err != ErrNilBackend {
+		t.Errorf("expected ErrNilBackend, got %v", err)
+	}
+	if _, err := NewReadThroughBackend[string, string](inner, nil, loader); err != ErrNilProvider {
+		t.Errorf("expected ErrNilProvider, got %v", err)
+	}
+	if _, err := NewReadThroughBackend[string, string](inner, lengthProvider{}, nil); err != ErrNilLoader {
+		t.Errorf("expected ErrNilLoader, got %v", err)
+	}
+}
+
+func TestReadThrough_LoadsOnMiss(t *testing.T) {
+	ctx := context.Background()
+	inner, _ := inmemory.NewLRUBackend[string, string](10)
+	var loads atomic.Int32
+	b, _ := NewReadThroughBackend(inner, lengthProvider{}, func(_ context.Context, key string) (string, string, bool, error) {
+		loads.Add(1)
+		if key == "missing" {
+			return "", "", false, nil
+		}
+		return "text for " + key, "value for " + key, true, nil
+	})
+
+	v, ok, err := b.Get(ctx, "k")
+	if err != nil || !ok || v != "value for k" {
+		t.Fatalf("expected loaded value, got %q ok=%v err=%v", v, ok, err)
+	}
+	emb, ok, _ := inner.GetEmbedding(ctx, "k")
+	if !ok || emb[0] != float64(len("text for k")) {
+		t.Fatalf("expected embedding of loaded text to be stored, got %v", emb)
+	}
+
+	_, _, _ = b.Get(ctx, "k")
+	if n := loads.Load(); n != 1 {
+		t.Errorf("expected hit to skip the loader, got %d loads", n)
+	}
+
+	if _, ok, err := b.Get(ctx, "missing"); ok || err != nil {
+		t.Errorf("expected clean miss, got ok=%v err=%v", ok, err)
+	}
+	if ok, _ := inner.Contains(ctx, "missing"); ok {
+		t.Error("missing key should not be stored")
+	}
+}
+
+func TestReadThrough_LoaderError(t *testing.T) {
+	inner, _ := inmemory.NewLRUBackend[string, string](10)
+	boom := errors.New("boom")
+	b, _ := NewReadThroughBackend(inner, lengthProvider{}, func(context.Context, string) (string, string, bool, error) {
+		return "", "", false, boom
+	})
+	if _, _, err := b.Get(context.Background(), "k"); !errors.Is(err, boom) {
+		t.Fatalf("expected loader error, got %v", err)
+	}
+}
+
+func TestReadThrough_CoalescesConcurrentMisses(t *testing.T) {
+	ctx := context.Background()
+	inner, _ := inmemory.NewLRUBackend[string, string](10)
+	release := make(chan struct{})
+	var loads atomic.Int32
+	b, _ := NewReadThroughBackend(inner, lengthProvider{}, func(context.Context, string) (string, string, bool, error) {
+		loads.Add(1)
+		<-release
+		return "t", "v", true, nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, ok, _ := b.Get(ctx, "k"); !ok || v != "v" {
+				t.Errorf("expected v, got %q (ok=%v)", v, ok)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := loads.Load(); n != 1 {
+		t.Errorf("expected a single load, got %d", n)
+	}
+}
+
+func TestReadThrough_WaiterHonoursContext(t *testing.T) {
+	inner, _ := inmemory.NewLRUBackend[string, string](10)
+	started, release := make(chan struct{}), make(chan struct{})
+	b, _ := NewReadThroughBackend(inner, lengthProvider{}, func(context.Context, string) (string, string, bool, error) {
+		close(started)
+		<-release
+		return "t", "v", true, nil
+	})
+	defer close(release)
+
+	go func() { _, _, _ = b.Get(context.Background(), "k") }()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := b.Get(ctx, "k"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the waiter's deadline, got %v", err)
+	}
+}
+
+func TestReadThrough_LoaderPanic(t *testing.T) {
+	inner, _ := inmemory.NewLRUBackend[string, string](10)
+	started, release := make(chan struct{}), make(chan struct{})
+	b, _ := NewReadThroughBackend(inner, lengthProvider{}, func(context.Context, string) (string, string, bool, error) {
+		close(started)
+		<-release
+		panic("boom")
+	})
+
+	go func() {
+		defer func() { _ = recover() }()
+		_, _, _ = b.Get(context.Background(), "k")
+	}()
+	<-started
+
+	errc := make(chan error, 1)
+	go func() {
+		_, _, err := b.Get(context.Background(), "k")
+		errc <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	select {
+	case err := <-errc:
+		if !errors.Is(err, ErrLoaderPanicked) {
+			t.Errorf("expected ErrLoaderPanicked, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter blocked after the loader panicked")
+	}
+}
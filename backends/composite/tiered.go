@@ -0,0 +1,378 @@
+// Package composite provides backends that layer or wrap other backends.
+package composite
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"sync"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+var (
+	// ErrNilTier is returned when a nil L1 or L2 backend is provided.
+	ErrNilTier = errors.New("composite: tier backend cannot be nil")
+
+	// ErrMetadataUnsupported is returned by TieredBackend.SetEntry when an
+	// entry has metadata and a tier does not implement types.EntryBackend.
+	ErrMetadataUnsupported = errors.New("composite: tier does not store metadata")
+)
+
+// Invalidator broadcasts invalidation messages between TieredBackend
+// instances that share the same L2 backend.
+type Invalidator interface {
+	// Publish sends a message to every subscriber.
+	Publish(ctx context.Context, msg []byte) error
+
+	// Subscribe calls handler for every received message until ctx is
+	// cancelled.
+	Subscribe(ctx context.Context, handler func(msg []byte)) error
+
+	// Close releases any resources held by the invalidator.
+	Close() error
+}
+
+// TieredOption configures a TieredBackend.
+type TieredOption func(*tieredConfig)
+
+type tieredConfig struct {
+	invalidator Invalidator
+}
+
+// WithInvalidator enables cross-instance invalidation. Writes, deletes, and
+// flushes are published through inv, and messages from other instances
+// evict the affected keys from the local L1.
+func WithInvalidator(inv Invalidator) TieredOption {
+	return func(c *tieredConfig) { c.invalidator = inv }
+}
+
+// TieredBackend serves hot keys from a fast L1 backend (typically in-memory)
+// and persists every write to a durable L2 backend (typically Redis).
+//
+// Writes go to L2 first and then L1 (write-through). Reads check L1 and, on
+// a miss, load the entry from L2 and promote it into L1. L2 is the source
+// of truth for Keys, IterKeys, and Len.
+//
+// Entries keep their metadata and input text in each tier that implements
+// types.EntryBackend. GetEmbeddings serves what it can from L1 without
+// promoting the rest, so a lookup scan does not churn L1. TieredBackend
+// does not implement types.VectorSearcher, even over tiers that do: a
+// search of L1 alone would miss entries only L2 holds, so lookups scan
+// L2's keys instead.
+type TieredBackend[K comparable, V any] struct {
+	l1 types.Backend[K, V]
+	l2 types.Backend[K, V]
+
+	invalidator Invalidator
+	instanceID  string
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+var (
+	_ types.EntryBackend[string, string]          = (*TieredBackend[string, string])(nil)
+	_ types.EmbeddingBatchBackend[string, string] = (*TieredBackend[string, string])(nil)
+	_ types.KeyIterator[string, string]           = (*TieredBackend[string, string])(nil)
+)
+
+type invalidation[K comparable] struct {
+	Source string `json:"source"`
+	Flush  bool   `json:"flush,omitempty"`
+	Key    K      `json:"key"`
+}
+
+// NewTieredBackend creates a TieredBackend over l1 and l2.
+func NewTieredBackend[K comparable, V any](l1, l2 types.Backend[K, V], opts ...TieredOption) (*TieredBackend[K, V], error) {
+	if l1 == nil || l2 == nil {
+		return nil, ErrNilTier
+	}
+	cfg := &tieredConfig{}
+	for _, o := range opts {
+		o(cfg)
+	}
+
+	b := &TieredBackend[K, V]{l1: l1, l2: l2, invalidator: cfg.invalidator}
+	if b.invalidator == nil {
+		return b, nil
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate instance id: %w", err)
+	}
+	b.instanceID = hex.EncodeToString(id)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		_ = b.invalidator.Subscribe(ctx, b.handleInvalidation)
+	}()
+	return b, nil
+}
+
+func (b *TieredBackend[K, V]) handleInvalidation(msg []byte) {
+	var inv invalidation[K]
+	if err := json.Unmarshal(msg, &inv); err != nil || inv.Source == b.instanceID {
+		return
+	}
+	ctx := context.Background()
+	if inv.Flush {
+		_ = b.l1.Flush(ctx)
+		return
+	}
+	_ = b.l1.Delete(ctx, inv.Key)
+}
+
+func (b *TieredBackend[K, V]) publish(ctx context.Context, inv invalidation[K]) error {
+	if b.invalidator == nil {
+		return nil
+	}
+	inv.Source = b.instanceID
+	msg, err := json.Marshal(inv)
+	if err != nil {
+		return fmt.Errorf("failed to encode invalidation: %w", err)
+	}
+	return b.invalidator.Publish(ctx, msg)
+}
+
+// Set writes the entry to L2 and then to L1.
+func (b *TieredBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	if err := b.l2.Set(ctx, key, embedding, value); err != nil {
+		return err
+	}
+	if err := b.l1.Set(ctx, key, embedding, value); err != nil {
+		return err
+	}
+	return b.publish(ctx, invalidation[K]{Key: key})
+}
+
+// SetEntry writes the entry to L2 and then to L1.
+func (b *TieredBackend[K, V]) SetEntry(ctx context.Context, key K, entry types.Entry[V]) error {
+	if err := setEntry(ctx, b.l2, key, entry); err != nil {
+		return err
+	}
+	if err := setEntry(ctx, b.l1, key, entry); err != nil {
+		return err
+	}
+	return b.publish(ctx, invalidation[K]{Key: key})
+}
+
+// Get returns the value from L1, loading it from L2 on a miss.
+func (b *TieredBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	if v, ok, err := b.l1.Get(ctx, key); err == nil && ok {
+		return v, true, nil
+	}
+	entry, ok, err := b.promote(ctx, key)
+	return entry.Value, ok, err
+}
+
+// GetEntry returns the entry from L1, loading it from L2 on a miss.
+func (b *TieredBackend[K, V]) GetEntry(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	if entry, ok, err := getEntry(ctx, b.l1, key); err == nil && ok {
+		return entry, true, nil
+	}
+	return b.promote(ctx, key)
+}
+
+// PeekEntry returns the entry from L1, or else from L2, without promoting
+// it or counting an access in a tier that implements
+// types.PeekingBackend.
+func (b *TieredBackend[K, V]) PeekEntry(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	if entry, ok, err := peekEntry(ctx, b.l1, key); err == nil && ok {
+		return entry, true, nil
+	}
+	return peekEntry(ctx, b.l2, key)
+}
+
+// promote loads key's entry from L2 and copies it into L1 when it has an
+// embedding. A failure to write L1 is ignored, since L2 has already
+// answered.
+func (b *TieredBackend[K, V]) promote(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	entry, ok, err := getEntry(ctx, b.l2, key)
+	if err != nil || !ok {
+		return entry, ok, err
+	}
+	if entry.Embedding != nil {
+		_ = setEntry(ctx, b.l1, key, entry)
+	}
+	return entry, true, nil
+}
+
+// Delete removes the entry from both tiers.
+func (b *TieredBackend[K, V]) Delete(ctx context.Context, key K) error {
+	if err := b.l2.Delete(ctx, key); err != nil {
+		return err
+	}
+	if err := b.l1.Delete(ctx, key); err != nil {
+		return err
+	}
+	return b.publish(ctx, invalidation[K]{Key: key})
+}
+
+// Contains checks L1 and then L2.
+func (b *TieredBackend[K, V]) Contains(ctx context.Context, key K) (bool, error) {
+	if ok, err := b.l1.Contains(ctx, key); err == nil && ok {
+		return true, nil
+	}
+	return b.l2.Contains(ctx, key)
+}
+
+// Keys returns all keys stored in L2.
+func (b *TieredBackend[K, V]) Keys(ctx context.Context) ([]K, error) {
+	return b.l2.Keys(ctx)
+}
+
+// IterKeys yields the keys stored in L2, streaming them when L2 implements
+// types.KeyIterator.
+func (b *TieredBackend[K, V]) IterKeys(ctx context.Context) iter.Seq2[K, error] {
+	if it, ok := b.l2.(types.KeyIterator[K, V]); ok {
+		return it.IterKeys(ctx)
+	}
+	return func(yield func(K, error) bool) {
+		keys, err := b.l2.Keys(ctx)
+		if err != nil {
+			var zero K
+			yield(zero, err)
+			return
+		}
+		for _, key := range keys {
+			if !yield(key, nil) {
+				return
+			}
+		}
+	}
+}
+
+// GetEmbedding returns the embedding from L1. On a miss it loads the entry
+// from L2 and promotes it into L1.
+func (b *TieredBackend[K, V]) GetEmbedding(ctx context.Context, key K) ([]float64, bool, error) {
+	if emb, ok, err := b.l1.GetEmbedding(ctx, key); err == nil && ok {
+		return emb, true, nil
+	}
+	entry, ok, err := b.promote(ctx, key)
+	return entry.Embedding, ok && entry.Embedding != nil, err
+}
+
+// GetEmbeddings returns the embeddings held by L1 and reads the rest from
+// L2 without promoting them.
+func (b *TieredBackend[K, V]) GetEmbeddings(ctx context.Context, keys []K) (map[K][]float64, error) {
+	out, err := getEmbeddings(ctx, b.l1, keys)
+	if err != nil || out == nil {
+		out = make(map[K][]float64, len(keys))
+	}
+	missing := make([]K, 0, len(keys)-len(out))
+	for _, key := range keys {
+		if _, ok := out[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return out, nil
+	}
+	fromL2, err := getEmbeddings(ctx, b.l2, missing)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(out, fromL2)
+	return out, nil
+}
+
+// Flush removes all entries from both tiers.
+func (b *TieredBackend[K, V]) Flush(ctx context.Context) error {
+	if err := b.l2.Flush(ctx); err != nil {
+		return err
+	}
+	if err := b.l1.Flush(ctx); err != nil {
+		return err
+	}
+	return b.publish(ctx, invalidation[K]{Flush: true})
+}
+
+// Len returns the number of entries in L2.
+func (b *TieredBackend[K, V]) Len(ctx context.Context) (int, error) {
+	return b.l2.Len(ctx)
+}
+
+// Close stops the invalidation subscriber and closes both tiers and the
+// invalidator.
+func (b *TieredBackend[K, V]) Close() error {
+	var errs []error
+	if b.cancel != nil {
+		b.cancel()
+		b.wg.Wait()
+		errs = append(errs, b.invalidator.Close())
+	}
+	errs = append(errs, b.l1.Close(), b.l2.Close())
+	return errors.Join(errs...)
+}
+
+// setEntry stores entry in tier, falling back to Set when tier does not
+// implement types.EntryBackend and the entry has no metadata.
+func setEntry[K comparable, V any](ctx context.Context, tier types.Backend[K, V], key K, entry types.Entry[V]) error {
+	if eb, ok := tier.(types.EntryBackend[K, V]); ok {
+		return eb.SetEntry(ctx, key, entry)
+	}
+	if len(entry.Metadata) > 0 {
+		return ErrMetadataUnsupported
+	}
+	return tier.Set(ctx, key, entry.Embedding, entry.Value)
+}
+
+// getEntry reads key's entry from tier, assembling it from Get and
+// GetEmbedding when tier does not implement types.EntryBackend. The entry
+// has no embedding if tier holds the value without one.
+func getEntry[K comparable, V any](ctx context.Context, tier types.Backend[K, V], key K) (types.Entry[V], bool, error) {
+	if eb, ok := tier.(types.EntryBackend[K, V]); ok {
+		return eb.GetEntry(ctx, key)
+	}
+	var entry types.Entry[V]
+	v, ok, err := tier.Get(ctx, key)
+	if err != nil || !ok {
+		return entry, ok, err
+	}
+	entry.Value = v
+	emb, found, err := tier.GetEmbedding(ctx, key)
+	if err != nil {
+		return entry, false, err
+	}
+	if found {
+		entry.Embedding = emb
+	}
+	return entry, true, nil
+}
+
+// peekEntry is getEntry through types.PeekingBackend when tier
+// implements it.
+func peekEntry[K comparable, V any](ctx context.Context, tier types.Backend[K, V], key K) (types.Entry[V], bool, error) {
+	if pb, ok := tier.(types.PeekingBackend[K, V]); ok {
+		return pb.PeekEntry(ctx, key)
+	}
+	return getEntry(ctx, tier, key)
+}
+
+// getEmbeddings reads the embeddings of keys from tier in one call when it
+// implements types.EmbeddingBatchBackend. Missing keys are omitted.
+func getEmbeddings[K comparable, V any](ctx context.Context, tier types.Backend[K, V], keys []K) (map[K][]float64, error) {
+	if eb, ok := tier.(types.EmbeddingBatchBackend[K, V]); ok {
+		return eb.GetEmbeddings(ctx, keys)
+	}
+	out := make(map[K][]float64, len(keys))
+	for _, key := range keys {
+		emb, ok, err := tier.GetEmbedding(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out[key] = emb
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,208 @@
+package composite
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/botirk38/semanticcache/backends/inmemory"
+	"github.com/botirk38/semanticcache/types"
+)
+
+func newTiers(t *testing.T) (types.Backend[string, string], types.Backend[string, string]) {
+	t.Helper()
+	l1, err := inmemory.NewLRUBackend[string, string](10)
+	if err != nil {
+		t.Fatalf("NewLRUBackend: %v", err)
+	}
+	l2, _ := inmemory.NewFIFOBackend[string, string](100)
+	return l1, l2
+}
+
+// memInvalidator is an in-process Invalidator shared between instances.
+type memInvalidator struct {
+	mu   sync.Mutex
+	subs []func([]byte)
+}
+
+func (m *memInvalidator) Publish(_ context.Context, msg []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.subs {
+		s(msg)
+	}
+	return nil
+}
+
+func (m *memInvalidator) Subscribe(ctx context.Context, handler func([]byte)) error {
+	m.mu.Lock()
+	m.subs = append(m.subs, handler)
+	m.mu.Unlock()
+	<-ctx.Done()
+	return nil
+}
+
+func (m *memInvalidator) Close() error { return nil }
+
+func TestTiered_NilTier(t *testing.T) {
+	l1, _ := newTiers(t)
+	if _, err := NewTieredBackend[string, string](l1, nil); err != ErrNilTier {
+		t.Fatalf("expected ErrNilTier, got %v", err)
+	}
+}
+
+func TestTiered_WriteThrough(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := newTiers(t)
+	b, _ := NewTieredBackend(l1, l2)
+
+	if err := b.Set(ctx, "k", []float64{1, 2}, "v"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	for name, tier := range map[string]types.Backend[string, string]{"L1": l1, "L2": l2} {
+		if v, ok, _ := tier.Get(ctx, "k"); !ok || v != "v" {
+			t.Errorf("%s: expected v, got %q (ok=%v)", name, v, ok)
+		}
+	}
+
+	if err := b.Delete(ctx, "k"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if ok, _ := b.Contains(ctx, "k"); ok {
+		t.Error("expected key to be deleted from both tiers")
+	}
+}
+
+func TestTiered_PromotesOnMiss(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := newTiers(t)
+	b, _ := NewTieredBackend(l1, l2)
+
+	_ = l2.Set(ctx, "cold", []float64{3, 4}, "v")
+
+	v, ok, err := b.Get(ctx, "cold")
+	if err != nil || !ok || v != "v" {
+		t.Fatalf("Get: v=%q ok=%v err=%v", v, ok, err)
+	}
+	emb, ok, _ := l1.GetEmbedding(ctx, "cold")
+	if !ok || len(emb) != 2 || emb[0] != 3 {
+		t.Fatalf("expected entry promoted into L1, got %v (ok=%v)", emb, ok)
+	}
+
+	n, _ := b.Len(ctx)
+	if n != 1 {
+		t.Errorf("expected Len from L2 = 1, got %d", n)
+	}
+}
+
+func TestTiered_Entries(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := newTiers(t)
+	b, _ := NewTieredBackend(l1, l2)
+
+	entry := types.Entry[string]{
+		Embedding: []float64{1, 0},
+		Value:     "v",
+		InputText: "hello",
+		Metadata:  map[string]string{"tenant": "a"},
+	}
+	if err := b.SetEntry(ctx, "k", entry); err != nil {
+		t.Fatalf("SetEntry: %v", err)
+	}
+	for name, tier := range map[string]types.Backend[string, string]{"L1": l1, "L2": l2} {
+		got, ok, err := tier.(types.EntryBackend[string, string]).GetEntry(ctx, "k")
+		if err != nil || !ok || got.InputText != "hello" || got.Metadata["tenant"] != "a" {
+			t.Errorf("%s entry = %+v ok=%v err=%v", name, got, ok, err)
+		}
+	}
+
+	// A cold entry is promoted with its input text and metadata.
+	_ = l1.Delete(ctx, "k")
+	got, ok, err := b.GetEntry(ctx, "k")
+	if err != nil || !ok || got.InputText != "hello" {
+		t.Fatalf("GetEntry: %+v ok=%v err=%v", got, ok, err)
+	}
+	if promoted, ok, _ := l1.(types.EntryBackend[string, string]).GetEntry(ctx, "k"); !ok || promoted.Metadata["tenant"] != "a" {
+		t.Errorf("expected the entry promoted with metadata, got %+v (ok=%v)", promoted, ok)
+	}
+
+	_ = l1.Delete(ctx, "k")
+	if emb, ok, err := b.GetEmbedding(ctx, "k"); err != nil || !ok || emb[0] != 1 {
+		t.Fatalf("GetEmbedding: %v ok=%v err=%v", emb, ok, err)
+	}
+	if ok, _ := l1.Contains(ctx, "k"); !ok {
+		t.Error("expected GetEmbedding to promote into L1")
+	}
+}
+
+func TestTiered_GetEmbeddingsAndIterKeys(t *testing.T) {
+	ctx := context.Background()
+	l1, l2 := newTiers(t)
+	b, _ := NewTieredBackend(l1, l2)
+
+	_ = b.Set(ctx, "hot", []float64{1}, "v")
+	_ = l2.Set(ctx, "cold", []float64{2}, "v")
+
+	embs, err := b.GetEmbeddings(ctx, []string{"hot", "cold", "missing"})
+	if err != nil || len(embs) != 2 || embs["hot"][0] != 1 || embs["cold"][0] != 2 {
+		t.Fatalf("GetEmbeddings = %v, err=%v", embs, err)
+	}
+	if ok, _ := l1.Contains(ctx, "cold"); ok {
+		t.Error("GetEmbeddings should not promote into L1")
+	}
+
+	var keys []string
+	for key, err := range b.IterKeys(ctx) {
+		if err != nil {
+			t.Fatalf("IterKeys: %v", err)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) != 2 {
+		t.Errorf("IterKeys = %v, want the two keys in L2", keys)
+	}
+}
+
+func TestTiered_Invalidation(t *testing.T) {
+	ctx := context.Background()
+	inv := &memInvalidator{}
+
+	sharedL2, _ := inmemory.NewFIFOBackend[string, string](100)
+	l1a, _ := inmemory.NewLRUBackend[string, string](10)
+	l1b, _ := inmemory.NewLRUBackend[string, string](10)
+	a, _ := NewTieredBackend[string, string](l1a, sharedL2, WithInvalidator(inv))
+	b, _ := NewTieredBackend[string, string](l1b, sharedL2, WithInvalidator(inv))
+	defer func() { _ = a.Close(); _ = b.Close() }()
+
+	waitFor(t, func() bool {
+		inv.mu.Lock()
+		defer inv.mu.Unlock()
+		return len(inv.subs) == 2
+	})
+
+	_ = a.Set(ctx, "k", []float64{1}, "old")
+	_, _, _ = b.Get(ctx, "k") // promote into b's L1
+
+	_ = a.Set(ctx, "k", []float64{1}, "new")
+	if ok, _ := l1b.Contains(ctx, "k"); ok {
+		t.Fatal("expected stale L1 entry on the other instance to be evicted")
+	}
+	if v, _, _ := b.Get(ctx, "k"); v != "new" {
+		t.Errorf("expected new, got %q", v)
+	}
+	if ok, _ := l1a.Contains(ctx, "k"); !ok {
+		t.Error("expected writer to keep its own L1 entry")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,308 @@
+package composite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// ErrBackendClosed is returned by write operations after Close.
+var ErrBackendClosed = errors.New("composite: backend is closed")
+
+// WriteBehindOption configures a WriteBehindBackend.
+type WriteBehindOption func(*writeBehindConfig)
+
+type writeBehindConfig struct {
+	queueSize  int
+	maxRetries int
+	backoff    time.Duration
+	onError    func(error)
+}
+
+// WithQueueSize sets how many writes may wait for replication. When the
+// queue is full, writes block until there is room or their context ends.
+// Defaults to 1024.
+func WithQueueSize(n int) WriteBehindOption {
+	return func(c *writeBehindConfig) { c.queueSize = n }
+}
+
+// WithRetry sets how many times a failed replication is retried and the
+// initial delay between attempts, which doubles after each failure.
+// Defaults to 3 retries starting at 100ms.
+func WithRetry(maxRetries int, backoff time.Duration) WriteBehindOption {
+	return func(c *writeBehindConfig) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithReplicationErrorHandler sets a function called with the final error
+// of every write that could not be replicated after all retries.
+func WithReplicationErrorHandler(fn func(error)) WriteBehindOption {
+	return func(c *writeBehindConfig) { c.onError = fn }
+}
+
+type opKind uint8
+
+const (
+	opSet opKind = iota
+	opDelete
+	opFlush
+)
+
+func (k opKind) String() string {
+	switch k {
+	case opSet:
+		return "set"
+	case opDelete:
+		return "delete"
+	default:
+		return "flush"
+	}
+}
+
+type writeOp[K comparable, V any] struct {
+	kind      opKind
+	key       K
+	embedding []float64
+	value     V
+}
+
+// WriteBehindBackend acknowledges writes once they reach a fast primary
+// backend (typically in-memory) and replicates them to a durable secondary
+// (typically Redis) in the background.
+//
+// Replication runs on a single goroutine in write order. Reads are served by
+// the primary and fall back to the secondary on a miss. Keys and Len reflect
+// the primary.
+type WriteBehindBackend[K comparable, V any] struct {
+	primary   types.Backend[K, V]
+	secondary types.Backend[K, V]
+	cfg       writeBehindConfig
+
+	queue  chan writeOp[K, V]
+	done   chan struct{}
+	ctx    context.Context // cancelled when Shutdown gives up on the queue
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex // guards closed against in-flight enqueues
+	closed bool
+
+	pendingMu sync.Mutex
+	pending   int
+	idle      chan struct{} // closed whenever pending is zero
+}
+
+var _ types.Shutdowner[string, string] = (*WriteBehindBackend[string, string])(nil)
+
+// NewWriteBehindBackend creates a WriteBehindBackend and starts its
+// replication goroutine.
+func NewWriteBehindBackend[K comparable, V any](primary, secondary types.Backend[K, V], opts ...WriteBehindOption) (*WriteBehindBackend[K, V], error) {
+	if primary == nil || secondary == nil {
+		return nil, ErrNilTier
+	}
+	cfg := writeBehindConfig{queueSize: 1024, maxRetries: 3, backoff: 100 * time.Millisecond}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	idle := make(chan struct{})
+	close(idle)
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &WriteBehindBackend[K, V]{
+		primary:   primary,
+		secondary: secondary,
+		cfg:       cfg,
+		queue:     make(chan writeOp[K, V], max(cfg.queueSize, 1)),
+		done:      make(chan struct{}),
+		ctx:       ctx,
+		cancel:    cancel,
+		idle:      idle,
+	}
+	go b.replicate()
+	return b, nil
+}
+
+// write applies op to the primary via local and then queues it for
+// replication.
+func (b *WriteBehindBackend[K, V]) write(ctx context.Context, op writeOp[K, V], local func() error) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrBackendClosed
+	}
+	if err := local(); err != nil {
+		return err
+	}
+
+	b.pendingMu.Lock()
+	if b.pending == 0 {
+		b.idle = make(chan struct{})
+	}
+	b.pending++
+	b.pendingMu.Unlock()
+
+	select {
+	case b.queue <- op:
+		return nil
+	case <-ctx.Done():
+		b.finish()
+		return ctx.Err()
+	}
+}
+
+func (b *WriteBehindBackend[K, V]) finish() {
+	b.pendingMu.Lock()
+	defer b.pendingMu.Unlock()
+	b.pending--
+	if b.pending == 0 {
+		close(b.idle)
+	}
+}
+
+func (b *WriteBehindBackend[K, V]) replicate() {
+	defer close(b.done)
+	for op := range b.queue {
+		if err := b.apply(op); err != nil && b.cfg.onError != nil {
+			b.cfg.onError(err)
+		}
+		b.finish()
+	}
+}
+
+func (b *WriteBehindBackend[K, V]) apply(op writeOp[K, V]) error {
+	ctx := b.ctx
+	delay := b.cfg.backoff
+	var err error
+	for attempt := 0; attempt <= b.cfg.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+			}
+			delay *= 2
+		}
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
+		}
+		switch op.kind {
+		case opSet:
+			err = b.secondary.Set(ctx, op.key, op.embedding, op.value)
+		case opDelete:
+			err = b.secondary.Delete(ctx, op.key)
+		case opFlush:
+			err = b.secondary.Flush(ctx)
+		}
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to replicate %s: %w", op.kind, err)
+}
+
+// Drain blocks until every write queued so far has been replicated or has
+// exhausted its retries, or until ctx ends.
+func (b *WriteBehindBackend[K, V]) Drain(ctx context.Context) error {
+	b.pendingMu.Lock()
+	idle := b.idle
+	b.pendingMu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Set writes the entry to the primary and queues it for replication.
+func (b *WriteBehindBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.write(ctx, writeOp[K, V]{kind: opSet, key: key, embedding: embedding, value: value}, func() error {
+		return b.primary.Set(ctx, key, embedding, value)
+	})
+}
+
+// Get returns the value from the primary, falling back to the secondary.
+func (b *WriteBehindBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	if v, ok, err := b.primary.Get(ctx, key); err == nil && ok {
+		return v, true, nil
+	}
+	return b.secondary.Get(ctx, key)
+}
+
+// Delete removes the entry from the primary and queues the delete for
+// replication.
+func (b *WriteBehindBackend[K, V]) Delete(ctx context.Context, key K) error {
+	return b.write(ctx, writeOp[K, V]{kind: opDelete, key: key}, func() error {
+		return b.primary.Delete(ctx, key)
+	})
+}
+
+// Contains checks the primary and then the secondary.
+func (b *WriteBehindBackend[K, V]) Contains(ctx context.Context, key K) (bool, error) {
+	if ok, err := b.primary.Contains(ctx, key); err == nil && ok {
+		return true, nil
+	}
+	return b.secondary.Contains(ctx, key)
+}
+
+// Keys returns the keys held by the primary.
+func (b *WriteBehindBackend[K, V]) Keys(ctx context.Context) ([]K, error) {
+	return b.primary.Keys(ctx)
+}
+
+// GetEmbedding returns the embedding from the primary, falling back to the
+// secondary.
+func (b *WriteBehindBackend[K, V]) GetEmbedding(ctx context.Context, key K) ([]float64, bool, error) {
+	if emb, ok, err := b.primary.GetEmbedding(ctx, key); err == nil && ok {
+		return emb, true, nil
+	}
+	return b.secondary.GetEmbedding(ctx, key)
+}
+
+// Flush clears the primary and queues a flush of the secondary.
+func (b *WriteBehindBackend[K, V]) Flush(ctx context.Context) error {
+	return b.write(ctx, writeOp[K, V]{kind: opFlush}, func() error {
+		return b.primary.Flush(ctx)
+	})
+}
+
+// Len returns the number of entries in the primary.
+func (b *WriteBehindBackend[K, V]) Len(ctx context.Context) (int, error) {
+	return b.primary.Len(ctx)
+}
+
+// Close rejects further writes, drains the replication queue, and closes
+// both backends.
+func (b *WriteBehindBackend[K, V]) Close() error {
+	return b.Shutdown(context.Background())
+}
+
+// Shutdown is Close bounded by ctx. If the queue has not drained when ctx
+// ends, the remaining writes are dropped and reported to the replication
+// error handler, and Shutdown returns ctx.Err() after closing both backends.
+func (b *WriteBehindBackend[K, V]) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.queue)
+	b.mu.Unlock()
+
+	var err error
+	select {
+	case <-b.done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		b.cancel()
+		<-b.done
+	}
+	b.cancel()
+	return errors.Join(err, b.primary.Close(), b.secondary.Close())
+}
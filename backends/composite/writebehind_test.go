@@ -0,0 +1,232 @@
+package composite
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// flakyBackend fails the first failures calls to Set.
+type flakyBackend struct {
+	types.Backend[string, string]
+	mu       sync.Mutex
+	failures int
+	attempts int
+}
+
+func (f *flakyBackend) Set(ctx context.Context, key string, embedding []float64, value string) error {
+	f.mu.Lock()
+	f.attempts++
+	fail := f.attempts <= f.failures
+	f.mu.Unlock()
+	if fail {
+		return errors.New("unavailable")
+	}
+	return f.Backend.Set(ctx, key, embedding, value)
+}
+
+func TestWriteBehind_Replicates(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newTiers(t)
+	b, err := NewWriteBehindBackend(primary, secondary)
+	if err != nil {
+		t.Fatalf("NewWriteBehindBackend: %v", err)
+	}
+	defer func() { _ = b.Close() }()
+
+	_ = b.Set(ctx, "a", []float64{1}, "va")
+	_ = b.Set(ctx, "b", []float64{1}, "vb")
+	_ = b.Delete(ctx, "a")
+	if err := b.Drain(ctx); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+
+	if ok, _ := secondary.Contains(ctx, "a"); ok {
+		t.Error("expected delete to be replicated")
+	}
+	if v, ok, _ := secondary.Get(ctx, "b"); !ok || v != "vb" {
+		t.Errorf("expected vb in secondary, got %q (ok=%v)", v, ok)
+	}
+
+	_ = b.Flush(ctx)
+	_ = b.Drain(ctx)
+	if n, _ := secondary.Len(ctx); n != 0 {
+		t.Errorf("expected flush to be replicated, got %d entries", n)
+	}
+}
+
+func TestWriteBehind_ReadFallback(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newTiers(t)
+	b, _ := NewWriteBehindBackend(primary, secondary)
+	defer func() { _ = b.Close() }()
+
+	_ = secondary.Set(ctx, "cold", []float64{3}, "vc")
+	if v, ok, _ := b.Get(ctx, "cold"); !ok || v != "vc" {
+		t.Fatalf("expected fallback to secondary, got %q (ok=%v)", v, ok)
+	}
+	if emb, ok, _ := b.GetEmbedding(ctx, "cold"); !ok || emb[0] != 3 {
+		t.Fatal("expected embedding from secondary")
+	}
+}
+
+func TestWriteBehind_Retry(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newTiers(t)
+	flaky := &flakyBackend{Backend: secondary, failures: 2}
+	b, _ := NewWriteBehindBackend[string, string](primary, flaky, WithRetry(3, time.Millisecond))
+	defer func() { _ = b.Close() }()
+
+	_ = b.Set(ctx, "k", []float64{1}, "v")
+	_ = b.Drain(ctx)
+	if v, ok, _ := secondary.Get(ctx, "k"); !ok || v != "v" {
+		t.Fatalf("expected write to succeed after retries, got %q (ok=%v)", v, ok)
+	}
+	if flaky.attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", flaky.attempts)
+	}
+}
+
+func TestWriteBehind_ErrorHandler(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newTiers(t)
+	flaky := &flakyBackend{Backend: secondary, failures: 100}
+
+	var mu sync.Mutex
+	var errs []error
+	b, _ := NewWriteBehindBackend[string, string](primary, flaky,
+		WithRetry(1, time.Millisecond),
+		WithReplicationErrorHandler(func(err error) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}),
+	)
+	defer func() { _ = b.Close() }()
+
+	_ = b.Set(ctx, "k", []float64{1}, "v")
+	_ = b.Drain(ctx)
+
+	if v, ok, _ := b.Get(ctx, "k"); !ok || v != "v" {
+		t.Fatalf("primary should hold the write, got %q (ok=%v)", v, ok)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 1 || flaky.attempts != 2 {
+		t.Fatalf("expected 1 reported error after 2 attempts, got %v after %d", errs, flaky.attempts)
+	}
+}
+
+// blockingBackend blocks Set until release is closed or ctx ends.
+type blockingBackend struct {
+	types.Backend[string, string]
+	release chan struct{}
+}
+
+func (bb *blockingBackend) Set(ctx context.Context, key string, embedding []float64, value string) error {
+	select {
+	case <-bb.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return bb.Backend.Set(ctx, key, embedding, value)
+}
+
+func TestWriteBehind_BoundedQueue(t *testing.T) {
+	primary, secondary := newTiers(t)
+	blocked := &blockingBackend{Backend: secondary, release: make(chan struct{})}
+	b, _ := NewWriteBehindBackend[string, string](primary, blocked, WithQueueSize(1))
+
+	// One write is held by the replicator and one fills the queue.
+	_ = b.Set(context.Background(), "a", []float64{1}, "va")
+	_ = b.Set(context.Background(), "b", []float64{1}, "vb")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Set(ctx, "c", []float64{1}, "vc"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected full queue to block until deadline, got %v", err)
+	}
+	if err := b.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected Drain to time out, got %v", err)
+	}
+
+	close(blocked.release)
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestWriteBehind_CloseDrains(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newTiers(t)
+	spy := &closeSpy{Backend: secondary}
+	b, _ := NewWriteBehindBackend[string, string](primary, spy)
+
+	for _, k := range []string{"a", "b", "c"} {
+		_ = b.Set(ctx, k, []float64{1}, k)
+	}
+	if err := b.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if n, _ := secondary.Len(ctx); n != 3 {
+		t.Errorf("expected 3 replicated entries after Close, got %d", n)
+	}
+	if !spy.closed {
+		t.Error("expected secondary to be closed")
+	}
+	if err := b.Set(ctx, "d", []float64{1}, "d"); err != ErrBackendClosed {
+		t.Errorf("expected ErrBackendClosed, got %v", err)
+	}
+}
+
+func TestWriteBehind_ShutdownDeadline(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newTiers(t)
+	blocked := &blockingBackend{Backend: secondary, release: make(chan struct{})}
+	var mu sync.Mutex
+	var errs []error
+	b, _ := NewWriteBehindBackend[string, string](primary, blocked, WithReplicationErrorHandler(func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}))
+
+	_ = b.Set(ctx, "a", []float64{1}, "va")
+	_ = b.Set(ctx, "b", []float64{1}, "vb")
+
+	sctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := b.Shutdown(sctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected Shutdown to time out, got %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 2 {
+		t.Errorf("expected both abandoned writes reported, got %v", errs)
+	}
+	for _, err := range errs {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	}
+	if err := b.Set(ctx, "c", []float64{1}, "vc"); err != ErrBackendClosed {
+		t.Errorf("expected ErrBackendClosed, got %v", err)
+	}
+	if err := b.Shutdown(ctx); err != nil {
+		t.Errorf("second Shutdown should be a no-op, got %v", err)
+	}
+}
+
+type closeSpy struct {
+	types.Backend[string, string]
+	closed bool
+}
+
+func (c *closeSpy) Close() error {
+	c.closed = true
+	return c.Backend.Close()
+}
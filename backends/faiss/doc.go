@@ -0,0 +1,8 @@
+// Package faiss provides a cache backend over a FAISS index, through
+// blevesearch/go-faiss. Backend is only built with the "faiss" build tag
+// and cgo, and links against libfaiss_c; without them the package is
+// empty.
+//
+// The package is its own module, so only programs that use it depend on
+// go-faiss.
+package faiss
@@ -0,0 +1,406 @@
+//go:build faiss && cgo
+
+package faiss
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	gofaiss "github.com/blevesearch/go-faiss"
+	"github.com/botirk38/semanticcache/backends/inmemory"
+	"github.com/botirk38/semanticcache/similarity"
+	"github.com/botirk38/semanticcache/types"
+)
+
+// minRebuild is the number of tombstones below which the index is never
+// rebuilt, as for inmemory.HNSWBackend.
+const minRebuild = 64
+
+// Option configures a Backend.
+type Option func(*config)
+
+type config struct {
+	trainSize int
+}
+
+// WithTrainSize sets how many vectors are buffered before an index that
+// needs training (such as IVF) is trained. Buffered vectors are searched by
+// brute force until then. Defaults to 39 times the number of IVF lists, or
+// 10000 when the description has no IVF component.
+func WithTrainSize(n int) Option {
+	return func(c *config) { c.trainSize = n }
+}
+
+// Backend implements types.Backend with a FAISS index over the stored
+// embeddings, and implements types.VectorSearcher. It is only built with
+// the "faiss" build tag and cgo, and links against libfaiss_c.
+//
+// Vectors are normalized and indexed by inner product, so scores are cosine
+// similarities. Deletes and overwrites leave tombstones that searches skip;
+// the index is rebuilt once tombstones outnumber live entries.
+type Backend[K comparable, V any] struct {
+	mu    sync.RWMutex
+	index *gofaiss.IndexImpl
+	dim   int
+
+	entries map[K]types.Entry[V]
+	ids     map[K]int64
+	keys    map[int64]K
+	dead    map[int64]struct{}
+	nextID  int64
+
+	trainSize int
+	pending   []int64 // ids buffered until the index is trained
+	vecs      map[int64][]float32
+}
+
+var (
+	_ types.VectorSearcher[string, string]        = (*Backend[string, string])(nil)
+	_ types.EntryBackend[string, string]          = (*Backend[string, string])(nil)
+	_ types.EmbeddingBatchBackend[string, string] = (*Backend[string, string])(nil)
+	_ types.ConditionalBackend[string, string]    = (*Backend[string, string])(nil)
+)
+
+// NewBackend creates a FAISS backend for dim-dimensional embeddings.
+// description is a FAISS index factory string such as "Flat",
+// "IVF1024,Flat", or "HNSW32". Descriptions without an IVF component are
+// wrapped in an IDMap so entries can be addressed by id.
+func NewBackend[K comparable, V any](dim int, description string, opts ...Option) (*Backend[K, V], error) {
+	if dim <= 0 {
+		return nil, inmemory.ErrDimensionMismatch
+	}
+	var cfg config
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	factory := description
+	if !strings.HasPrefix(description, "IVF") {
+		factory = "IDMap," + description
+	}
+	index, err := gofaiss.IndexFactory(dim, factory, gofaiss.MetricInnerProduct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create FAISS index %q: %w", factory, err)
+	}
+
+	trainSize := cfg.trainSize
+	if trainSize <= 0 {
+		trainSize = defaultTrainSize(description)
+	}
+	return &Backend[K, V]{
+		index:     index,
+		dim:       dim,
+		entries:   make(map[K]types.Entry[V]),
+		ids:       make(map[K]int64),
+		keys:      make(map[int64]K),
+		dead:      make(map[int64]struct{}),
+		trainSize: trainSize,
+		vecs:      make(map[int64][]float32),
+	}, nil
+}
+
+// defaultTrainSize follows the FAISS guideline of at least 39 training
+// points per IVF list.
+func defaultTrainSize(description string) int {
+	var lists int
+	if _, err := fmt.Sscanf(description, "IVF%d", &lists); err == nil && lists > 0 {
+		return 39 * lists
+	}
+	return 10000
+}
+
+// toUnitFloat32 returns v scaled to unit length as float32s. The zero
+// vector stays zero.
+func toUnitFloat32(v []float64) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	if norm == 0 {
+		return make([]float32, len(v))
+	}
+	norm = math.Sqrt(norm)
+	unit := make([]float64, len(v))
+	for i, x := range v {
+		unit[i] = x / norm
+	}
+	return similarity.ToFloat32(unit)
+}
+
+// Set stores a value with its embedding and indexes the embedding.
+func (b *Backend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.SetEntry(ctx, key, types.Entry[V]{Embedding: embedding, Value: value})
+}
+
+// SetEntry stores an entry, including its input text and creation time,
+// and indexes its embedding.
+func (b *Backend[K, V]) SetEntry(_ context.Context, key K, entry types.Entry[V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.set(key, entry)
+}
+
+// set stores and indexes an entry. Callers must hold the write lock.
+func (b *Backend[K, V]) set(key K, entry types.Entry[V]) error {
+	if len(entry.Embedding) != b.dim {
+		return inmemory.ErrDimensionMismatch
+	}
+	if old, ok := b.ids[key]; ok {
+		b.kill(old)
+	}
+	id := b.nextID
+	b.nextID++
+	b.ids[key] = id
+	b.keys[id] = key
+	b.entries[key] = entry
+	b.vecs[id] = toUnitFloat32(entry.Embedding)
+
+	if !b.index.IsTrained() {
+		b.pending = append(b.pending, id)
+		if len(b.pending) < b.trainSize {
+			return nil
+		}
+		return b.trainPending()
+	}
+	if err := b.index.AddWithIDs(b.vecs[id], []int64{id}); err != nil {
+		return fmt.Errorf("failed to add vector to FAISS: %w", err)
+	}
+	return b.maybeRebuild()
+}
+
+// trainPending trains the index on the buffered vectors and adds them.
+// Callers must hold the write lock.
+func (b *Backend[K, V]) trainPending() error {
+	ids := make([]int64, 0, len(b.pending))
+	for _, id := range b.pending {
+		if _, dead := b.dead[id]; !dead {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		b.pending = nil
+		return nil
+	}
+	flat := make([]float32, 0, len(ids)*b.dim)
+	for _, id := range ids {
+		flat = append(flat, b.vecs[id]...)
+	}
+	if err := b.index.Train(flat); err != nil {
+		return fmt.Errorf("failed to train FAISS index: %w", err)
+	}
+	if err := b.index.AddWithIDs(flat, ids); err != nil {
+		return fmt.Errorf("failed to add vectors to FAISS: %w", err)
+	}
+	b.pending = nil
+	return nil
+}
+
+// kill tombstones id. Callers must hold the write lock.
+func (b *Backend[K, V]) kill(id int64) {
+	b.dead[id] = struct{}{}
+	delete(b.keys, id)
+	delete(b.vecs, id)
+}
+
+// maybeRebuild re-adds live vectors once tombstones outnumber them.
+// Callers must hold the write lock.
+func (b *Backend[K, V]) maybeRebuild() error {
+	if len(b.dead) < minRebuild || len(b.dead) <= len(b.ids) || !b.index.IsTrained() {
+		return nil
+	}
+	if err := b.index.Reset(); err != nil {
+		return fmt.Errorf("failed to reset FAISS index: %w", err)
+	}
+	b.dead = make(map[int64]struct{})
+	b.pending = nil
+	if len(b.ids) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(b.ids))
+	flat := make([]float32, 0, len(b.ids)*b.dim)
+	for _, id := range b.ids {
+		ids = append(ids, id)
+		flat = append(flat, b.vecs[id]...)
+	}
+	if err := b.index.AddWithIDs(flat, ids); err != nil {
+		return fmt.Errorf("failed to rebuild FAISS index: %w", err)
+	}
+	return nil
+}
+
+// VectorSearch returns up to k entries most similar to query.
+func (b *Backend[K, V]) VectorSearch(_ context.Context, query []float64, k int) ([]types.SearchResult[K, V], error) {
+	if len(query) != b.dim {
+		return nil, inmemory.ErrDimensionMismatch
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	q := toUnitFloat32(query)
+	var results []types.SearchResult[K, V]
+	if n := b.index.Ntotal(); n > 0 {
+		want := min(int64(k+len(b.dead)), n)
+		scores, labels, err := b.index.Search(q, want)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search FAISS index: %w", err)
+		}
+		for i, id := range labels {
+			key, live := b.keys[id]
+			if id < 0 || !live {
+				continue
+			}
+			results = append(results, types.SearchResult[K, V]{Key: key, Value: b.entries[key].Value, Score: float64(scores[i])})
+		}
+	}
+
+	// Vectors waiting for training are not in the index yet.
+	for _, id := range b.pending {
+		key, live := b.keys[id]
+		if !live {
+			continue
+		}
+		var score float32
+		for i, x := range b.vecs[id] {
+			score += x * q[i]
+		}
+		results = append(results, types.SearchResult[K, V]{Key: key, Value: b.entries[key].Value, Score: float64(score)})
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
+
+// Get retrieves the value for a key.
+func (b *Backend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	entry, ok, err := b.GetEntry(ctx, key)
+	return entry.Value, ok, err
+}
+
+// GetEntry retrieves the entry for a key.
+func (b *Backend[K, V]) GetEntry(_ context.Context, key K) (types.Entry[V], bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	e, ok := b.entries[key]
+	return e, ok, nil
+}
+
+// Delete removes an entry by key.
+func (b *Backend[K, V]) Delete(_ context.Context, key K) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id, ok := b.ids[key]
+	if !ok {
+		return nil
+	}
+	b.kill(id)
+	delete(b.ids, key)
+	delete(b.entries, key)
+	return b.maybeRebuild()
+}
+
+// Contains checks whether a key exists.
+func (b *Backend[K, V]) Contains(_ context.Context, key K) (bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.entries[key]
+	return ok, nil
+}
+
+// Flush removes all entries and clears the index. Training is kept.
+func (b *Backend[K, V]) Flush(_ context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.index.Reset(); err != nil {
+		return fmt.Errorf("failed to reset FAISS index: %w", err)
+	}
+	b.entries = make(map[K]types.Entry[V])
+	b.ids = make(map[K]int64)
+	b.keys = make(map[int64]K)
+	b.dead = make(map[int64]struct{})
+	b.vecs = make(map[int64][]float32)
+	b.pending = nil
+	return nil
+}
+
+// Len returns the number of stored entries.
+func (b *Backend[K, V]) Len(_ context.Context) (int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.entries), nil
+}
+
+// Close frees the FAISS index.
+func (b *Backend[K, V]) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.index != nil {
+		b.index.Close()
+		b.index = nil
+	}
+	return nil
+}
+
+// Keys returns all keys in the cache.
+func (b *Backend[K, V]) Keys(_ context.Context) ([]K, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	keys := make([]K, 0, len(b.entries))
+	for k := range b.entries {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// GetEmbedding retrieves the embedding for a key.
+func (b *Backend[K, V]) GetEmbedding(_ context.Context, key K) ([]float64, bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	e, ok := b.entries[key]
+	return e.Embedding, ok, nil
+}
+
+// GetEmbeddings retrieves the embeddings for multiple keys under one lock.
+func (b *Backend[K, V]) GetEmbeddings(_ context.Context, keys []K) (map[K][]float64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	result := make(map[K][]float64, len(keys))
+	for _, key := range keys {
+		if e, ok := b.entries[key]; ok {
+			result[key] = e.Embedding
+		}
+	}
+	return result, nil
+}
+
+// SetIfAbsent stores entry only if key is not already present.
+func (b *Backend[K, V]) SetIfAbsent(_ context.Context, key K, entry types.Entry[V]) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.entries[key]; ok {
+		return false, nil
+	}
+	return true, b.set(key, entry)
+}
+
+// CompareAndSwap stores entry only if key's current value is deeply equal
+// to old.
+func (b *Backend[K, V]) CompareAndSwap(_ context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cur, ok := b.entries[key]
+	if !ok || !reflect.DeepEqual(cur.Value, old) {
+		return false, nil
+	}
+	return true, b.set(key, entry)
+}
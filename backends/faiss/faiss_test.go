@@ -0,0 +1,130 @@
+//go:build faiss && cgo
+
+package faiss
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"testing"
+
+	"github.com/botirk38/semanticcache/backends/inmemory"
+)
+
+func TestBackend_FlatMatchesBruteForce(t *testing.T) {
+	ctx := context.Background()
+	b, err := NewBackend[string, string](16, "Flat")
+	if err != nil {
+		t.Fatalf("NewBackend: %v", err)
+	}
+	defer func() { _ = b.Close() }()
+
+	vecs := randomVectors(500, 16, 1)
+	for i, v := range vecs {
+		if err := b.Set(ctx, fmt.Sprintf("k%d", i), v, fmt.Sprintf("v%d", i)); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+	}
+	live := make(map[int]bool, len(vecs))
+	for i := range vecs {
+		live[i] = i%3 != 0
+		if !live[i] {
+			_ = b.Delete(ctx, fmt.Sprintf("k%d", i))
+		}
+	}
+
+	for _, q := range randomVectors(20, 16, 2) {
+		want := bruteForceTopK(vecs, live, q, 5)
+		got, err := b.VectorSearch(ctx, q, 5)
+		if err != nil {
+			t.Fatalf("VectorSearch: %v", err)
+		}
+		if len(got) != 5 {
+			t.Fatalf("expected 5 results, got %d", len(got))
+		}
+		for _, r := range got {
+			if !want[r.Key] {
+				t.Errorf("unexpected result %q", r.Key)
+			}
+		}
+	}
+}
+
+func TestBackend_IVFSearchesBeforeTraining(t *testing.T) {
+	ctx := context.Background()
+	b, err := NewBackend[string, string](8, "IVF4,Flat", WithTrainSize(200))
+	if err != nil {
+		t.Fatalf("NewBackend: %v", err)
+	}
+	defer func() { _ = b.Close() }()
+
+	vecs := randomVectors(300, 8, 3)
+	for i, v := range vecs[:100] {
+		_ = b.Set(ctx, fmt.Sprintf("k%d", i), v, "v")
+	}
+	got, err := b.VectorSearch(ctx, vecs[7], 1)
+	if err != nil || len(got) != 1 || got[0].Key != "k7" {
+		t.Fatalf("expected k7 from untrained buffer, got %v (err=%v)", got, err)
+	}
+
+	for i, v := range vecs[100:] {
+		_ = b.Set(ctx, fmt.Sprintf("k%d", i+100), v, "v")
+	}
+	if !b.index.IsTrained() {
+		t.Fatal("expected index to be trained")
+	}
+	if n, _ := b.Len(ctx); n != 300 {
+		t.Errorf("expected 300 entries, got %d", n)
+	}
+}
+
+func TestBackend_DimensionMismatch(t *testing.T) {
+	b, err := NewBackend[string, string](4, "Flat")
+	if err != nil {
+		t.Fatalf("NewBackend: %v", err)
+	}
+	defer func() { _ = b.Close() }()
+	if err := b.Set(context.Background(), "k", []float64{1, 2}, "v"); err != inmemory.ErrDimensionMismatch {
+		t.Errorf("expected ErrDimensionMismatch, got %v", err)
+	}
+}
+
+func randomVectors(n, dim int, seed uint64) [][]float64 {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	vecs := make([][]float64, n)
+	for i := range vecs {
+		vecs[i] = make([]float64, dim)
+		for j := range vecs[i] {
+			vecs[i][j] = rng.NormFloat64()
+		}
+	}
+	return vecs
+}
+
+// bruteForceTopK returns the keys of the k live vectors most cosine-similar
+// to query.
+func bruteForceTopK(vecs [][]float64, live map[int]bool, query []float64, k int) map[string]bool {
+	type scored struct {
+		key   string
+		score float64
+	}
+	q := toUnitFloat32(query)
+	all := make([]scored, 0, len(vecs))
+	for i, v := range vecs {
+		if live != nil && !live[i] {
+			continue
+		}
+		var dot float64
+		for j, x := range toUnitFloat32(v) {
+			dot += float64(x * q[j])
+		}
+		all = append(all, scored{fmt.Sprintf("k%d", i), dot})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].score > all[j].score })
+	top := make(map[string]bool, k)
+	for _, s := range all[:min(k, len(all))] {
+		top[s.key] = true
+	}
+	return top
+}
@@ -0,0 +1,12 @@
+module github.com/botirk38/semanticcache/backends/faiss
+
+go 1.25.3
+
+replace github.com/botirk38/semanticcache => ../..
+
+require (
+	github.com/blevesearch/go-faiss v1.0.25
+	github.com/botirk38/semanticcache v0.0.0-00010101000000-000000000000
+)
+
+require github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
@@ -2,8 +2,15 @@ package inmemory
 
 import (
 	"context"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/botirk38/semanticcache/similarity"
 	"github.com/botirk38/semanticcache/types"
 )
 
@@ -30,6 +37,44 @@ func factories() map[string]backendFactory {
 			b, _ := NewFIFOBackend[string, string](100)
 			return b
 		},
+		"TinyLFU": func(t *testing.T) types.Backend[string, string] {
+			t.Helper()
+			b, err := NewTinyLFUBackend[string, string](100)
+			if err != nil {
+				t.Fatalf("NewTinyLFUBackend: %v", err)
+			}
+			return b
+		},
+		"TTL": func(t *testing.T) types.Backend[string, string] {
+			t.Helper()
+			b, err := NewTTLBackend[string, string](100, time.Hour)
+			if err != nil {
+				t.Fatalf("NewTTLBackend: %v", err)
+			}
+			t.Cleanup(func() { _ = b.Close() })
+			return b
+		},
+		"Sharded": func(t *testing.T) types.Backend[string, string] {
+			t.Helper()
+			b, err := NewShardedBackend[string, string](4, 100)
+			if err != nil {
+				t.Fatalf("NewShardedBackend: %v", err)
+			}
+			return b
+		},
+		"Binary": func(t *testing.T) types.Backend[string, string] {
+			t.Helper()
+			b, _ := NewBinaryBackend[string, string]()
+			return b
+		},
+		"HNSW": func(t *testing.T) types.Backend[string, string] {
+			t.Helper()
+			b, err := NewHNSWBackend[string, string]()
+			if err != nil {
+				t.Fatalf("NewHNSWBackend: %v", err)
+			}
+			return b
+		},
 	}
 }
 
@@ -180,6 +225,30 @@ func TestBackend_Eviction(t *testing.T) {
 		}
 	})
 
+	t.Run("LFUOrder", func(t *testing.T) {
+		b, _ := NewLFUBackend[string, string](3)
+		_ = b.Set(ctx, "a", nil, "1")
+		_ = b.Set(ctx, "b", nil, "2")
+		_ = b.Set(ctx, "c", nil, "3")
+		for range 2 {
+			_, _, _ = b.Get(ctx, "a")
+		}
+		_, _, _ = b.Get(ctx, "c")
+		_, _, _ = b.Get(ctx, "b")
+
+		// b and c tie at 2 accesses; c was accessed less recently.
+		_ = b.Set(ctx, "d", nil, "4")
+		for key, want := range map[string]bool{"a": true, "b": true, "c": false, "d": true} {
+			if ok, _ := b.Contains(ctx, key); ok != want {
+				t.Errorf("expected Contains(%q) = %v", key, want)
+			}
+		}
+		stats, _ := b.EntryStats(ctx, []string{"a"})
+		if stats["a"].Accesses != 3 {
+			t.Errorf("expected 3 accesses for a, got %d", stats["a"].Accesses)
+		}
+	})
+
 	t.Run("FIFO", func(t *testing.T) {
 		b, _ := NewFIFOBackend[string, string](2)
 		_ = b.Set(ctx, "a", nil, "1")
@@ -196,6 +265,825 @@ func TestBackend_Eviction(t *testing.T) {
 			t.Fatal("expected 'a' to be evicted (FIFO)")
 		}
 	})
+
+	t.Run("Sharded", func(t *testing.T) {
+		b, _ := NewShardedBackend[string, string](2, 4)
+		for i := 0; i < 20; i++ {
+			_ = b.Set(ctx, fmt.Sprintf("k%d", i), nil, "v")
+		}
+
+		n, _ := b.Len(ctx)
+		if n > 4 {
+			t.Fatalf("expected at most 4 after eviction, got %d", n)
+		}
+	})
+}
+
+func TestTinyLFUBackend_ScanResistance(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewTinyLFUBackend[string, string](100)
+
+	hot := make([]string, 10)
+	for i := range hot {
+		hot[i] = fmt.Sprintf("hot%d", i)
+		_ = b.Set(ctx, hot[i], nil, "v")
+	}
+	// Push the last hot key out of the admission window.
+	_ = b.Set(ctx, "filler", nil, "v")
+	for i := 0; i < 10; i++ {
+		for _, k := range hot {
+			_, _, _ = b.Get(ctx, k)
+		}
+	}
+
+	// A burst of one-hit wonders must not flush out the popular keys.
+	for i := 0; i < 300; i++ {
+		_ = b.Set(ctx, fmt.Sprintf("scan%d", i), nil, "v")
+	}
+
+	n, _ := b.Len(ctx)
+	if n > 100 {
+		t.Fatalf("expected at most 100 entries, got %d", n)
+	}
+	for _, k := range hot {
+		if ok, _ := b.Contains(ctx, k); !ok {
+			t.Errorf("expected hot key %s to survive the scan", k)
+		}
+	}
+}
+
+func TestTinyLFUBackend_InvalidCapacity(t *testing.T) {
+	if _, err := NewTinyLFUBackend[string, string](0); err != ErrInvalidCapacity {
+		t.Fatalf("expected ErrInvalidCapacity, got %v", err)
+	}
+}
+
+func TestTTLBackend_Expiry(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewTTLBackend[string, string](0, time.Minute)
+	defer func() { _ = b.Close() }()
+
+	now := time.Now()
+	b.now = func() time.Time { return now }
+
+	_ = b.Set(ctx, "a", []float64{1}, "va")
+	now = now.Add(30 * time.Second)
+	_ = b.Set(ctx, "b", []float64{1}, "vb")
+
+	now = now.Add(31 * time.Second)
+	if _, ok, _ := b.Get(ctx, "a"); ok {
+		t.Fatal("expected 'a' to be expired")
+	}
+	if ok, _ := b.Contains(ctx, "a"); ok {
+		t.Fatal("expected Contains to ignore expired entry")
+	}
+	if _, ok, _ := b.GetEmbedding(ctx, "a"); ok {
+		t.Fatal("expected GetEmbedding to ignore expired entry")
+	}
+	if v, ok, _ := b.Get(ctx, "b"); !ok || v != "vb" {
+		t.Fatalf("expected 'b' to be live, got %q (ok=%v)", v, ok)
+	}
+
+	keys, _ := b.Keys(ctx)
+	if len(keys) != 1 || keys[0] != "b" {
+		t.Fatalf("expected only 'b', got %v", keys)
+	}
+
+	// Re-setting refreshes the expiry.
+	_ = b.Set(ctx, "b", []float64{1}, "vb2")
+	now = now.Add(45 * time.Second)
+	if _, ok, _ := b.Get(ctx, "b"); !ok {
+		t.Fatal("expected refreshed 'b' to be live")
+	}
+}
+
+func TestTTLBackend_OnEvictExpiry(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewTTLBackend[string, string](0, time.Minute)
+	defer func() { _ = b.Close() }()
+
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	var evicted []string
+	b.OnEvict(func(key string, _ types.Entry[string]) { evicted = append(evicted, key) })
+
+	_ = b.Set(ctx, "a", []float64{1}, "va")
+	now = now.Add(2 * time.Minute)
+	if n, _ := b.Len(ctx); n != 0 {
+		t.Fatalf("expected 0 live entries, got %d", n)
+	}
+	if len(evicted) != 1 || evicted[0] != "a" {
+		t.Fatalf("expected expired 'a' reported, got %v", evicted)
+	}
+}
+
+func TestTTLBackend_Sweeper(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewTTLBackend[string, string](0, 20*time.Millisecond)
+	defer func() { _ = b.Close() }()
+
+	_ = b.Set(ctx, "a", nil, "v")
+	deadline := time.Now().Add(time.Second)
+	for {
+		b.mu.RLock()
+		n := len(b.entries)
+		b.mu.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected sweeper to remove expired entry")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTTLBackend_Capacity(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewTTLBackend[string, string](2, time.Hour)
+	defer func() { _ = b.Close() }()
+
+	_ = b.Set(ctx, "a", nil, "1")
+	_ = b.Set(ctx, "b", nil, "2")
+	_ = b.Set(ctx, "c", nil, "3")
+
+	if ok, _ := b.Contains(ctx, "a"); ok {
+		t.Fatal("expected 'a' (closest to expiry) to be evicted")
+	}
+	if n, _ := b.Len(ctx); n != 2 {
+		t.Fatalf("expected 2, got %d", n)
+	}
+}
+
+func TestTTLBackend_ExpirePersistTouch(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewTTLBackend[string, string](0, time.Minute)
+	defer func() { _ = b.Close() }()
+
+	now := time.Now()
+	b.now = func() time.Time { return now }
+	_ = b.Set(ctx, "short", nil, "1")
+	_ = b.Set(ctx, "pinned", nil, "2")
+	_ = b.Set(ctx, "touched", nil, "3")
+
+	if ok, _ := b.Expire(ctx, "short", 10*time.Second); !ok {
+		t.Fatal("expected Expire to find the key")
+	}
+	if ok, _ := b.Persist(ctx, "pinned"); !ok {
+		t.Fatal("expected Persist to find the key")
+	}
+	now = now.Add(45 * time.Second)
+	if ok, _ := b.Touch(ctx, "touched"); !ok {
+		t.Fatal("expected Touch to find the key")
+	}
+
+	now = now.Add(30 * time.Second)
+	if n, _ := b.Len(ctx); n != 2 {
+		t.Errorf("expected 2 live entries, got %d", n)
+	}
+	for key, want := range map[string]bool{"short": false, "pinned": true, "touched": true} {
+		if ok, _ := b.Contains(ctx, key); ok != want {
+			t.Errorf("Contains(%q) = %v, want %v", key, ok, want)
+		}
+	}
+
+	if ok, _ := b.Expire(ctx, "pinned", 0); !ok {
+		t.Error("expected Expire(0) to find the key")
+	}
+	if ok, _ := b.Contains(ctx, "pinned"); ok {
+		t.Error("expected Expire(0) to delete the key")
+	}
+	if ok, _ := b.Touch(ctx, "missing"); ok {
+		t.Error("expected Touch to report a missing key")
+	}
+}
+
+func TestTTLBackend_InvalidTTL(t *testing.T) {
+	if _, err := NewTTLBackend[string, string](10, 0); err != ErrInvalidTTL {
+		t.Fatalf("expected ErrInvalidTTL, got %v", err)
+	}
+}
+
+func TestShardedBackend_InvalidCapacity(t *testing.T) {
+	if _, err := NewShardedBackend[string, string](8, 4); err != ErrInvalidShardCapacity {
+		t.Fatalf("expected ErrInvalidShardCapacity, got %v", err)
+	}
+}
+
+func TestShardedBackend_Concurrent(t *testing.T) {
+	b, _ := NewShardedBackend[string, string](8, 8000)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				key := fmt.Sprintf("w%d-k%d", w, i)
+				_ = b.Set(ctx, key, []float64{1}, key)
+				if v, ok, _ := b.Get(ctx, key); !ok || v != key {
+					t.Errorf("expected %s, got %q (ok=%v)", key, v, ok)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	n, _ := b.Len(ctx)
+	if n != 800 {
+		t.Fatalf("expected 800 entries, got %d", n)
+	}
+}
+
+func TestBackend_Txn(t *testing.T) {
+	for name, factory := range factories() {
+		t.Run(name, func(t *testing.T) {
+			b := factory(t).(types.Transactional[string, string])
+			ctx := context.Background()
+			_ = b.Set(ctx, "old", []float64{1, 0}, "x")
+
+			err := b.Txn(ctx, []types.TxnOp[string, string]{
+				{Key: "a", Entry: types.Entry[string]{Embedding: []float64{1, 0}, Value: "va"}},
+				{Key: "b", Entry: types.Entry[string]{Embedding: []float64{0, 1}, Value: "vb"}},
+				{Key: "old", Delete: true},
+				{Key: "a", Entry: types.Entry[string]{Embedding: []float64{1, 1}, Value: "va2"}},
+			})
+			if err != nil {
+				t.Fatalf("Txn: %v", err)
+			}
+			if v, _, _ := b.Get(ctx, "a"); v != "va2" {
+				t.Errorf("expected later op to win, got %q", v)
+			}
+			if ok, _ := b.Contains(ctx, "old"); ok {
+				t.Error("expected old to be deleted")
+			}
+			if n, _ := b.Len(ctx); n != 2 {
+				t.Errorf("expected 2 entries, got %d", n)
+			}
+		})
+	}
+}
+
+func TestBackend_Entry(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for name, factory := range factories() {
+		t.Run(name, func(t *testing.T) {
+			b := factory(t).(types.EntryBackend[string, string])
+			ctx := context.Background()
+			in := types.Entry[string]{Embedding: []float64{1, 0}, Value: "v", InputText: "hello", CreatedAt: created}
+			if err := b.SetEntry(ctx, "k", in); err != nil {
+				t.Fatalf("SetEntry: %v", err)
+			}
+			got, ok, err := b.GetEntry(ctx, "k")
+			if err != nil || !ok || got.Value != "v" || got.InputText != "hello" || !got.CreatedAt.Equal(created) {
+				t.Errorf("GetEntry = %+v ok=%v err=%v", got, ok, err)
+			}
+			if v, _, _ := b.Get(ctx, "k"); v != "v" {
+				t.Errorf("Get after SetEntry = %q", v)
+			}
+			if _, ok, _ := b.GetEntry(ctx, "missing"); ok {
+				t.Error("expected miss")
+			}
+		})
+	}
+}
+
+func TestBackend_GetEmbeddings(t *testing.T) {
+	for name, factory := range factories() {
+		t.Run(name, func(t *testing.T) {
+			b := factory(t).(types.EmbeddingBatchBackend[string, string])
+			ctx := context.Background()
+			_ = b.Set(ctx, "a", []float64{1, 0}, "va")
+			_ = b.Set(ctx, "b", []float64{0, 1}, "vb")
+			got, err := b.GetEmbeddings(ctx, []string{"a", "b", "missing"})
+			if err != nil || len(got) != 2 || got["a"][0] != 1 || got["b"][1] != 1 {
+				t.Errorf("GetEmbeddings = %v err=%v", got, err)
+			}
+		})
+	}
+}
+
+func TestBackend_GetVectors(t *testing.T) {
+	for name, factory := range factories() {
+		t.Run(name, func(t *testing.T) {
+			b, ok := factory(t).(types.MultiVectorBackend[string, string])
+			if !ok {
+				t.Skip("vector index covers Embedding only")
+			}
+			ctx := context.Background()
+			_ = b.SetEntry(ctx, "a", types.Entry[string]{Embedding: []float64{1, 0}, Value: "va", Vectors: [][]float64{{0, 1}}})
+			_ = b.Set(ctx, "b", []float64{0, 1}, "vb")
+			got, err := b.GetVectors(ctx, []string{"a", "b", "missing"})
+			if err != nil || len(got) != 2 || len(got["a"]) != 2 || got["a"][1][1] != 1 || len(got["b"]) != 1 {
+				t.Errorf("GetVectors = %v err=%v", got, err)
+			}
+		})
+	}
+}
+
+func TestBackend_EntryStats(t *testing.T) {
+	for name, factory := range factories() {
+		t.Run(name, func(t *testing.T) {
+			b, ok := factory(t).(types.StatsBackend[string, string])
+			if !ok {
+				t.Skip("backend does not report entry stats")
+			}
+			ctx := context.Background()
+			created := time.Now().Add(-time.Hour)
+			_ = b.(types.EntryBackend[string, string]).SetEntry(ctx, "a", types.Entry[string]{Embedding: []float64{1, 0}, Value: "va", CreatedAt: created})
+			_, _, _ = b.Get(ctx, "a")
+			got, err := b.EntryStats(ctx, []string{"a", "missing"})
+			if err != nil || len(got) != 1 || !got["a"].CreatedAt.Equal(created) {
+				t.Fatalf("EntryStats = %v err=%v", got, err)
+			}
+			if _, counts := b.(*LFUBackend[string, string]); counts && got["a"].Accesses != 2 {
+				t.Errorf("expected the write and read to count, got %d accesses", got["a"].Accesses)
+			}
+			again, _ := b.EntryStats(ctx, []string{"a"})
+			if again["a"].Accesses != got["a"].Accesses {
+				t.Error("EntryStats must not count an access")
+			}
+		})
+	}
+}
+
+func TestBackend_MemoryUsage(t *testing.T) {
+	for name, factory := range factories() {
+		t.Run(name, func(t *testing.T) {
+			b, ok := factory(t).(types.MemoryReporter[string, string])
+			if !ok {
+				t.Fatal("expected in-memory backends to report memory usage")
+			}
+			ctx := context.Background()
+			_ = b.Set(ctx, "a", []float64{1, 0, 0, 0}, "value a")
+			_ = b.Set(ctx, "b", []float64{0, 1, 0, 0}, "value b")
+			usage, err := b.MemoryUsage(ctx)
+			if err != nil || usage.Entries != 2 {
+				t.Fatalf("MemoryUsage = %+v err=%v", usage, err)
+			}
+			if usage.Embeddings < 2*4*8 || usage.Values <= 0 || usage.Index <= 0 {
+				t.Errorf("expected every kind to be counted, got %+v", usage)
+			}
+			if usage.Total() != usage.Embeddings+usage.Values+usage.Index {
+				t.Errorf("Total = %d for %+v", usage.Total(), usage)
+			}
+
+			_ = b.Delete(ctx, "a")
+			after, _ := b.MemoryUsage(ctx)
+			if after.Entries != 1 || after.Embeddings >= usage.Embeddings {
+				t.Errorf("expected a delete to shrink the estimate, got %+v then %+v", usage, after)
+			}
+		})
+	}
+}
+
+func TestBackend_Conditional(t *testing.T) {
+	for name, factory := range factories() {
+		t.Run(name, func(t *testing.T) {
+			b := factory(t).(types.ConditionalBackend[string, string])
+			ctx := context.Background()
+			entry := func(v string) types.Entry[string] {
+				return types.Entry[string]{Embedding: []float64{1, 0}, Value: v}
+			}
+			if ok, err := b.SetIfAbsent(ctx, "k", entry("v1")); err != nil || !ok {
+				t.Fatalf("SetIfAbsent on missing key = %v, %v", ok, err)
+			}
+			if ok, _ := b.SetIfAbsent(ctx, "k", entry("v2")); ok {
+				t.Error("SetIfAbsent must not overwrite")
+			}
+			if ok, _ := b.CompareAndSwap(ctx, "k", "stale", entry("v3")); ok {
+				t.Error("CompareAndSwap must fail on a mismatched value")
+			}
+			if ok, err := b.CompareAndSwap(ctx, "k", "v1", entry("v3")); err != nil || !ok {
+				t.Errorf("CompareAndSwap = %v, %v", ok, err)
+			}
+			if ok, _ := b.CompareAndSwap(ctx, "missing", "", entry("v")); ok {
+				t.Error("CompareAndSwap must fail on a missing key")
+			}
+			if v, _, _ := b.Get(ctx, "k"); v != "v3" {
+				t.Errorf("expected v3, got %q", v)
+			}
+		})
+	}
+}
+
+func TestBackend_Pin(t *testing.T) {
+	small := map[string]func() types.PinningBackend[string, string]{
+		"LRU":     func() types.PinningBackend[string, string] { b, _ := NewLRUBackend[string, string](2); return b },
+		"LFU":     func() types.PinningBackend[string, string] { b, _ := NewLFUBackend[string, string](2); return b },
+		"FIFO":    func() types.PinningBackend[string, string] { b, _ := NewFIFOBackend[string, string](2); return b },
+		"Sharded": func() types.PinningBackend[string, string] { b, _ := NewShardedBackend[string, string](1, 2); return b },
+		"TinyLFU": func() types.PinningBackend[string, string] { b, _ := NewTinyLFUBackend[string, string](2); return b },
+		"TTL": func() types.PinningBackend[string, string] {
+			b, _ := NewTTLBackend[string, string](2, time.Hour)
+			return b
+		},
+	}
+	for name, factory := range small {
+		t.Run(name, func(t *testing.T) {
+			b := factory()
+			defer b.Close()
+			ctx := context.Background()
+			if ok, err := b.Pin(ctx, "missing"); err != nil || ok {
+				t.Errorf("Pin on a missing key = %v, %v", ok, err)
+			}
+			_ = b.Set(ctx, "golden", []float64{1, 0}, "v")
+			if ok, err := b.Pin(ctx, "golden"); err != nil || !ok {
+				t.Fatalf("Pin = %v, %v", ok, err)
+			}
+			for i := range 20 {
+				_ = b.Set(ctx, fmt.Sprintf("k%d", i), []float64{0, 1}, "v")
+			}
+			if ok, _ := b.Contains(ctx, "golden"); !ok {
+				t.Error("pinned entry was evicted")
+			}
+			if n, _ := b.Len(ctx); n > 3 {
+				t.Errorf("expected capacity plus the pinned entry, got %d entries", n)
+			}
+			if ok, err := b.Unpin(ctx, "golden"); err != nil || !ok {
+				t.Errorf("Unpin = %v, %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestHNSWBackend_TxnAllOrNothing(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewHNSWBackend[string, string]()
+	_ = b.Set(ctx, "a", []float64{1, 0}, "va")
+
+	err := b.Txn(ctx, []types.TxnOp[string, string]{
+		{Key: "a", Delete: true},
+		{Key: "b", Entry: types.Entry[string]{Embedding: []float64{1, 0, 0}, Value: "vb"}},
+	})
+	if err != ErrDimensionMismatch {
+		t.Fatalf("expected ErrDimensionMismatch, got %v", err)
+	}
+	if ok, _ := b.Contains(ctx, "a"); !ok {
+		t.Error("failed transaction must not apply earlier ops")
+	}
+}
+
+func TestBackend_OnEvict(t *testing.T) {
+	for name, factory := range factories() {
+		t.Run(name, func(t *testing.T) {
+			b, ok := factory(t).(types.EvictionNotifier[string, string])
+			if !ok {
+				t.Skip("backend never evicts")
+			}
+			ctx := context.Background()
+
+			var mu sync.Mutex
+			evicted := map[string]string{}
+			b.OnEvict(func(key string, e types.Entry[string]) {
+				mu.Lock()
+				evicted[key] = e.Value
+				mu.Unlock()
+			})
+
+			_ = b.Set(ctx, "gone", []float64{1}, "v")
+			_ = b.Delete(ctx, "gone")
+			_ = b.Set(ctx, "flushed", []float64{1}, "v")
+			_ = b.Flush(ctx)
+			if len(evicted) != 0 {
+				t.Fatalf("delete and flush should not report evictions, got %v", evicted)
+			}
+
+			for i := 0; i < 300; i++ {
+				key := fmt.Sprintf("k%d", i)
+				_ = b.Set(ctx, key, []float64{1}, key)
+			}
+			n, _ := b.Len(ctx)
+			if len(evicted)+n != 300 {
+				t.Fatalf("expected %d evictions, got %d", 300-n, len(evicted))
+			}
+			for k, v := range evicted {
+				if k != v {
+					t.Fatalf("evicted entry %s has value %s", k, v)
+				}
+				if ok, _ := b.Contains(ctx, k); ok {
+					t.Fatalf("evicted key %s still present", k)
+				}
+			}
+		})
+	}
+}
+
+func TestBackend_MaxBytes(t *testing.T) {
+	value := strings.Repeat("x", 100)
+	entry := entrySize("k0", types.Entry[string]{Embedding: []float64{1}, Value: value})
+
+	sized := map[string]func() (types.Backend[string, string], error){
+		"LRU": func() (types.Backend[string, string], error) {
+			return NewLRUBackend[string, string](100, WithMaxBytes(3*entry))
+		},
+		"LFU": func() (types.Backend[string, string], error) {
+			return NewLFUBackend[string, string](100, WithMaxBytes(3*entry))
+		},
+		"FIFO": func() (types.Backend[string, string], error) {
+			return NewFIFOBackend[string, string](100, WithMaxBytes(3*entry))
+		},
+	}
+	for name, newBackend := range sized {
+		t.Run(name, func(t *testing.T) {
+			b, err := newBackend()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+
+			for i := 0; i < 10; i++ {
+				_ = b.Set(ctx, fmt.Sprintf("k%d", i), []float64{1}, value)
+			}
+			if n, _ := b.Len(ctx); n != 3 {
+				t.Fatalf("expected 3 entries within byte budget, got %d", n)
+			}
+			if ok, _ := b.Contains(ctx, "k9"); !ok {
+				t.Fatal("newest entry should be kept")
+			}
+
+			_ = b.Set(ctx, "big", []float64{1}, strings.Repeat("x", 1000))
+			if n, _ := b.Len(ctx); n != 1 {
+				t.Fatalf("oversized entry should evict everything else, got %d entries", n)
+			}
+
+			_ = b.Delete(ctx, "big")
+			for i := 0; i < 3; i++ {
+				_ = b.Set(ctx, fmt.Sprintf("n%d", i), []float64{1}, value)
+			}
+			if n, _ := b.Len(ctx); n != 3 {
+				t.Fatalf("delete should release its bytes, got %d entries", n)
+			}
+		})
+	}
+}
+
+func TestBackend_Float16(t *testing.T) {
+	type float16Backend interface {
+		types.EntryBackend[string, string]
+		types.EvictionNotifier[string, string]
+		types.MemoryReporter[string, string]
+	}
+	halved := map[string]func(opts ...Option) float16Backend{
+		"LRU": func(opts ...Option) float16Backend {
+			b, _ := NewLRUBackend[string, string](2, opts...)
+			return b
+		},
+		"LFU": func(opts ...Option) float16Backend {
+			b, _ := NewLFUBackend[string, string](2, opts...)
+			return b
+		},
+		"FIFO": func(opts ...Option) float16Backend {
+			b, _ := NewFIFOBackend[string, string](2, opts...)
+			return b
+		},
+	}
+	near := func(got, want []float64) bool {
+		if len(got) != len(want) {
+			return false
+		}
+		for i := range got {
+			if d := got[i] - want[i]; d > 1e-3 || d < -1e-3 {
+				return false
+			}
+		}
+		return true
+	}
+	emb, extra := []float64{0.1, -0.25, 0.7}, []float64{0.3, 0.3, -0.9}
+	for name, newBackend := range halved {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			b := newBackend(WithFloat16())
+			var evicted []float64
+			b.OnEvict(func(_ string, e types.Entry[string]) { evicted = e.Embedding })
+
+			_ = b.SetEntry(ctx, "a", types.Entry[string]{Embedding: emb, Vectors: [][]float64{extra}, Value: "va", InputText: "a"})
+			got, ok, _ := b.GetEntry(ctx, "a")
+			if !ok || got.Value != "va" || got.InputText != "a" || !near(got.Embedding, emb) || len(got.Vectors) != 1 || !near(got.Vectors[0], extra) {
+				t.Fatalf("GetEntry = %+v", got)
+			}
+			if got.Embedding[0] == emb[0] {
+				t.Error("expected the embedding to be rounded to float16")
+			}
+			if e, _, _ := b.GetEmbedding(ctx, "a"); !near(e, emb) {
+				t.Errorf("GetEmbedding = %v", e)
+			}
+
+			full := newBackend()
+			_ = full.SetEntry(ctx, "a", types.Entry[string]{Embedding: emb, Vectors: [][]float64{extra}, Value: "va"})
+			small, _ := b.MemoryUsage(ctx)
+			large, _ := full.MemoryUsage(ctx)
+			if small.Embeddings >= large.Embeddings {
+				t.Errorf("expected float16 to shrink embeddings, got %d vs %d bytes", small.Embeddings, large.Embeddings)
+			}
+
+			_ = b.Set(ctx, "b", []float64{1, 0, 0}, "vb")
+			_ = b.Set(ctx, "c", []float64{0, 1, 0}, "vc")
+			if len(evicted) != 3 {
+				t.Errorf("expected evictions to see the widened embedding, got %v", evicted)
+			}
+		})
+	}
+}
+
+func TestEstimateSize(t *testing.T) {
+	type doc struct {
+		Title string
+		Tags  []string
+	}
+	small := entrySize("k", types.Entry[doc]{Value: doc{Title: "a"}})
+	large := entrySize("k", types.Entry[doc]{Value: doc{Title: strings.Repeat("a", 1000), Tags: []string{"x", "y"}}})
+	if large-small < 1000 {
+		t.Fatalf("expected estimate to grow with content, got %d vs %d", small, large)
+	}
+	withEmb := entrySize("k", types.Entry[doc]{Embedding: make([]float64, 10), Value: doc{Title: "a"}})
+	if withEmb-small != 80 {
+		t.Fatalf("expected 8 bytes per embedding dimension, got %d", withEmb-small)
+	}
+}
+
+func randomVectors(n, dim int, seed uint64) [][]float64 {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	vecs := make([][]float64, n)
+	for i := range vecs {
+		vecs[i] = make([]float64, dim)
+		for j := range vecs[i] {
+			vecs[i][j] = rng.NormFloat64()
+		}
+	}
+	return vecs
+}
+
+func bruteForceTopK(vecs [][]float64, live map[int]bool, query []float64, k int) map[string]bool {
+	type scored struct {
+		key   string
+		score float64
+	}
+	q := normalize(query)
+	all := make([]scored, 0, len(vecs))
+	for i, v := range vecs {
+		if live != nil && !live[i] {
+			continue
+		}
+		all = append(all, scored{fmt.Sprintf("k%d", i), 1 - distance(q, normalize(v))})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].score > all[j].score })
+	top := make(map[string]bool, k)
+	for _, s := range all[:min(k, len(all))] {
+		top[s.key] = true
+	}
+	return top
+}
+
+func TestHNSWBackend_Recall(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewHNSWBackend[string, string]()
+	vecs := randomVectors(2000, 32, 1)
+	for i, v := range vecs {
+		_ = b.Set(ctx, fmt.Sprintf("k%d", i), v, fmt.Sprintf("v%d", i))
+	}
+
+	const k = 10
+	hits, total := 0, 0
+	for _, q := range randomVectors(50, 32, 2) {
+		want := bruteForceTopK(vecs, nil, q, k)
+		got, err := b.VectorSearch(ctx, q, k)
+		if err != nil {
+			t.Fatalf("VectorSearch: %v", err)
+		}
+		if len(got) != k {
+			t.Fatalf("expected %d results, got %d", k, len(got))
+		}
+		for i, r := range got {
+			if i > 0 && r.Score > got[i-1].Score {
+				t.Fatal("results not sorted by descending score")
+			}
+			if r.Value != "v"+r.Key[1:] {
+				t.Fatalf("value %q does not match key %q", r.Value, r.Key)
+			}
+			if want[r.Key] {
+				hits++
+			}
+		}
+		total += k
+	}
+	if recall := float64(hits) / float64(total); recall < 0.9 {
+		t.Fatalf("recall %.2f below 0.9", recall)
+	}
+}
+
+func TestHNSWBackend_DeleteAndRebuild(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewHNSWBackend[string, string]()
+	vecs := randomVectors(500, 16, 3)
+	live := make(map[int]bool, len(vecs))
+	for i, v := range vecs {
+		_ = b.Set(ctx, fmt.Sprintf("k%d", i), v, "v")
+		live[i] = true
+	}
+	// Delete enough to trigger a rebuild, then overwrite a few more.
+	for i := 0; i < 400; i++ {
+		_ = b.Delete(ctx, fmt.Sprintf("k%d", i))
+		delete(live, i)
+	}
+	for i := 400; i < 410; i++ {
+		_ = b.Set(ctx, fmt.Sprintf("k%d", i), vecs[i], "updated")
+	}
+
+	if n, _ := b.Len(ctx); n != 100 {
+		t.Fatalf("expected 100 entries, got %d", n)
+	}
+	if b.deleted > len(b.ids) {
+		t.Fatalf("expected rebuild to clear tombstones, got %d deleted for %d live", b.deleted, len(b.ids))
+	}
+
+	for _, q := range randomVectors(20, 16, 4) {
+		got, _ := b.VectorSearch(ctx, q, 5)
+		if len(got) != 5 {
+			t.Fatalf("expected 5 results, got %d", len(got))
+		}
+		for _, r := range got {
+			var i int
+			_, _ = fmt.Sscanf(r.Key, "k%d", &i)
+			if !live[i] {
+				t.Fatalf("deleted key %s returned", r.Key)
+			}
+			if i < 410 && r.Value != "updated" {
+				t.Fatalf("stale value for overwritten key %s", r.Key)
+			}
+		}
+	}
+}
+
+func TestBinaryBackend_Recall(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewBinaryBackend[string, string]()
+	vecs := randomVectors(2000, 128, 1)
+	for i, v := range vecs {
+		_ = b.Set(ctx, fmt.Sprintf("k%d", i), v, fmt.Sprintf("v%d", i))
+	}
+
+	// Queries are paraphrases of stored vectors: the same vector plus noise.
+	found := 0
+	noise := randomVectors(100, 128, 2)
+	for i, n := range noise {
+		src := i * 17
+		q := make([]float64, len(n))
+		for j := range q {
+			q[j] = vecs[src][j] + 0.5*n[j]
+		}
+		got, err := b.VectorSearch(ctx, q, 5)
+		if err != nil {
+			t.Fatalf("VectorSearch: %v", err)
+		}
+		if len(got) != 5 {
+			t.Fatalf("expected 5 results, got %d", len(got))
+		}
+		for i, r := range got {
+			if i > 0 && r.Score > got[i-1].Score {
+				t.Fatal("results not sorted by descending score")
+			}
+		}
+		if want := similarity.CosineSimilarity(q, vecs[src]); got[0].Key == fmt.Sprintf("k%d", src) && got[0].Score == want {
+			found++
+		}
+	}
+	if recall := float64(found) / float64(len(noise)); recall < 0.95 {
+		t.Fatalf("recall %.2f below 0.95", recall)
+	}
+}
+
+func TestBinaryBackend_BinaryOnly(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewBinaryBackend[string, string](WithBinaryOnly())
+	_ = b.Set(ctx, "a", []float64{0.9, -0.1, 0.3, -0.5}, "va")
+	_ = b.Set(ctx, "b", []float64{-0.2, 0.7, 0.1, -0.4}, "vb")
+
+	emb, ok, _ := b.GetEmbedding(ctx, "a")
+	if !ok || len(emb) != 4 || emb[0] != 0.5 || emb[1] != -0.5 {
+		t.Errorf("expected the unit sign vector, got %v", emb)
+	}
+	got, err := b.VectorSearch(ctx, []float64{1, -1, 1, -1}, 2)
+	if err != nil || len(got) != 2 || got[0].Key != "a" || got[0].Score != 1 || got[1].Score != 0 {
+		t.Errorf("VectorSearch = %+v, %v", got, err)
+	}
+}
+
+func TestHNSWBackend_DimensionMismatch(t *testing.T) {
+	ctx := context.Background()
+	b, _ := NewHNSWBackend[string, string]()
+	_ = b.Set(ctx, "a", []float64{1, 0}, "a")
+	if err := b.Set(ctx, "b", []float64{1, 0, 0}, "b"); err != ErrDimensionMismatch {
+		t.Fatalf("expected ErrDimensionMismatch on Set, got %v", err)
+	}
+	if _, err := b.VectorSearch(ctx, []float64{1}, 1); err != ErrDimensionMismatch {
+		t.Fatalf("expected ErrDimensionMismatch on search, got %v", err)
+	}
+	_ = b.Flush(ctx)
+	if err := b.Set(ctx, "b", []float64{1, 0, 0}, "b"); err != nil {
+		t.Fatalf("Flush should reset the dimension: %v", err)
+	}
 }
 
 func TestBackend_Overwrite(t *testing.T) {
@@ -241,4 +1129,56 @@ var (
 	_ types.Backend[string, string] = (*LRUBackend[string, string])(nil)
 	_ types.Backend[string, string] = (*LFUBackend[string, string])(nil)
 	_ types.Backend[string, string] = (*FIFOBackend[string, string])(nil)
+	_ types.Backend[string, string] = (*ShardedBackend[string, string])(nil)
+	_ types.Backend[string, string] = (*TinyLFUBackend[string, string])(nil)
+	_ types.Backend[string, string] = (*TTLBackend[string, string])(nil)
+	_ types.Backend[string, string] = (*HNSWBackend[string, string])(nil)
+
+	_ types.EvictionNotifier[string, string] = (*LRUBackend[string, string])(nil)
+	_ types.EvictionNotifier[string, string] = (*LFUBackend[string, string])(nil)
+	_ types.EvictionNotifier[string, string] = (*FIFOBackend[string, string])(nil)
+	_ types.EvictionNotifier[string, string] = (*ShardedBackend[string, string])(nil)
+	_ types.EvictionNotifier[string, string] = (*TinyLFUBackend[string, string])(nil)
+	_ types.EvictionNotifier[string, string] = (*TTLBackend[string, string])(nil)
+
+	_ types.Transactional[string, string] = (*LRUBackend[string, string])(nil)
+	_ types.Transactional[string, string] = (*LFUBackend[string, string])(nil)
+	_ types.Transactional[string, string] = (*FIFOBackend[string, string])(nil)
+	_ types.Transactional[string, string] = (*ShardedBackend[string, string])(nil)
+	_ types.Transactional[string, string] = (*TinyLFUBackend[string, string])(nil)
+	_ types.Transactional[string, string] = (*TTLBackend[string, string])(nil)
+	_ types.Transactional[string, string] = (*HNSWBackend[string, string])(nil)
+
+	_ types.EntryBackend[string, string] = (*LRUBackend[string, string])(nil)
+	_ types.EntryBackend[string, string] = (*LFUBackend[string, string])(nil)
+	_ types.EntryBackend[string, string] = (*FIFOBackend[string, string])(nil)
+	_ types.EntryBackend[string, string] = (*ShardedBackend[string, string])(nil)
+	_ types.EntryBackend[string, string] = (*TinyLFUBackend[string, string])(nil)
+	_ types.EntryBackend[string, string] = (*TTLBackend[string, string])(nil)
+	_ types.EntryBackend[string, string] = (*HNSWBackend[string, string])(nil)
+
+	_ types.EmbeddingBatchBackend[string, string] = (*LRUBackend[string, string])(nil)
+	_ types.EmbeddingBatchBackend[string, string] = (*LFUBackend[string, string])(nil)
+	_ types.EmbeddingBatchBackend[string, string] = (*FIFOBackend[string, string])(nil)
+	_ types.EmbeddingBatchBackend[string, string] = (*ShardedBackend[string, string])(nil)
+	_ types.EmbeddingBatchBackend[string, string] = (*TinyLFUBackend[string, string])(nil)
+	_ types.EmbeddingBatchBackend[string, string] = (*TTLBackend[string, string])(nil)
+	_ types.EmbeddingBatchBackend[string, string] = (*HNSWBackend[string, string])(nil)
+
+	_ types.ConditionalBackend[string, string] = (*LRUBackend[string, string])(nil)
+	_ types.ConditionalBackend[string, string] = (*LFUBackend[string, string])(nil)
+	_ types.ConditionalBackend[string, string] = (*FIFOBackend[string, string])(nil)
+	_ types.ConditionalBackend[string, string] = (*ShardedBackend[string, string])(nil)
+	_ types.ConditionalBackend[string, string] = (*TinyLFUBackend[string, string])(nil)
+	_ types.ConditionalBackend[string, string] = (*TTLBackend[string, string])(nil)
+	_ types.ConditionalBackend[string, string] = (*HNSWBackend[string, string])(nil)
+
+	_ types.ExpiringBackend[string, string] = (*TTLBackend[string, string])(nil)
+
+	_ types.PinningBackend[string, string] = (*LRUBackend[string, string])(nil)
+	_ types.PinningBackend[string, string] = (*LFUBackend[string, string])(nil)
+	_ types.PinningBackend[string, string] = (*FIFOBackend[string, string])(nil)
+	_ types.PinningBackend[string, string] = (*ShardedBackend[string, string])(nil)
+	_ types.PinningBackend[string, string] = (*TinyLFUBackend[string, string])(nil)
+	_ types.PinningBackend[string, string] = (*TTLBackend[string, string])(nil)
 )
@@ -85,3 +85,140 @@ func BenchmarkFIFO_Keys(b *testing.B) {
 	backend, _ := NewFIFOBackend[string, string](1000)
 	benchKeys(b, backend)
 }
+
+func BenchmarkTinyLFU_Set(b *testing.B) {
+	backend, _ := NewTinyLFUBackend[string, string](1000)
+	benchSet(b, backend)
+}
+
+func BenchmarkTinyLFU_Get(b *testing.B) {
+	backend, _ := NewTinyLFUBackend[string, string](1000)
+	benchGet(b, backend)
+}
+
+func BenchmarkTinyLFU_Keys(b *testing.B) {
+	backend, _ := NewTinyLFUBackend[string, string](1000)
+	benchKeys(b, backend)
+}
+
+// benchEvictingSet writes 10x more distinct keys than the backend holds,
+// so every Set past warm-up triggers an eviction.
+func benchEvictingSet(b *testing.B, backend types.Backend[string, string]) {
+	ctx := context.Background()
+	emb := make([]float64, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = backend.Set(ctx, fmt.Sprintf("k%d", i%100000), emb, "v")
+	}
+}
+
+func BenchmarkLFU_EvictingSet(b *testing.B) {
+	backend, _ := NewLFUBackend[string, string](10000)
+	benchEvictingSet(b, backend)
+}
+
+// BenchmarkLFU_EvictingSetScaling shows that an evicting Set costs the
+// same at any capacity.
+func BenchmarkLFU_EvictingSetScaling(b *testing.B) {
+	for _, capacity := range []int{1_000, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("capacity=%d", capacity), func(b *testing.B) {
+			backend, _ := NewLFUBackend[string, string](capacity)
+			ctx := context.Background()
+			emb := make([]float64, 128)
+			for i := range capacity {
+				_ = backend.Set(ctx, fmt.Sprintf("k%d", i), emb, "v")
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = backend.Set(ctx, fmt.Sprintf("n%d", i), emb, "v")
+			}
+		})
+	}
+}
+
+func BenchmarkTinyLFU_EvictingSet(b *testing.B) {
+	backend, _ := NewTinyLFUBackend[string, string](10000)
+	benchEvictingSet(b, backend)
+}
+
+func BenchmarkSharded_Set(b *testing.B) {
+	backend, _ := NewShardedBackend[string, string](0, 1000)
+	benchSet(b, backend)
+}
+
+func BenchmarkSharded_Get(b *testing.B) {
+	backend, _ := NewShardedBackend[string, string](0, 1000)
+	benchGet(b, backend)
+}
+
+func BenchmarkSharded_Keys(b *testing.B) {
+	backend, _ := NewShardedBackend[string, string](0, 1000)
+	benchKeys(b, backend)
+}
+
+func benchParallelSetGet(b *testing.B, backend types.Backend[string, string]) {
+	ctx := context.Background()
+	emb := make([]float64, 128)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := fmt.Sprintf("k%d", i%1000)
+			if i%4 == 0 {
+				_ = backend.Set(ctx, key, emb, "v")
+			} else {
+				_, _, _ = backend.Get(ctx, key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkLRU_ParallelSetGet(b *testing.B) {
+	backend, _ := NewLRUBackend[string, string](1000)
+	benchParallelSetGet(b, backend)
+}
+
+func BenchmarkSharded_ParallelSetGet(b *testing.B) {
+	backend, _ := NewShardedBackend[string, string](0, 1000)
+	benchParallelSetGet(b, backend)
+}
+
+func BenchmarkHNSW_Get(b *testing.B) {
+	backend, _ := NewHNSWBackend[string, string]()
+	benchGet(b, backend)
+}
+
+// benchSearchSetup fills an HNSW backend with n random 128-dimensional
+// vectors and returns it with a query vector.
+func benchSearchSetup(b *testing.B, n int) (*HNSWBackend[string, string], []float64) {
+	ctx := context.Background()
+	backend, _ := NewHNSWBackend[string, string]()
+	for i, v := range randomVectors(n, 128, 1) {
+		_ = backend.Set(ctx, fmt.Sprintf("k%d", i), v, "v")
+	}
+	return backend, randomVectors(1, 128, 2)[0]
+}
+
+func BenchmarkHNSW_VectorSearch10k(b *testing.B) {
+	backend, query := benchSearchSetup(b, 10_000)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = backend.VectorSearch(ctx, query, 10)
+	}
+}
+
+func BenchmarkHNSW_BruteForce10k(b *testing.B) {
+	backend, query := benchSearchSetup(b, 10_000)
+	ctx := context.Background()
+	q := normalize(query)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		keys, _ := backend.Keys(ctx)
+		for _, k := range keys {
+			emb, _, _ := backend.GetEmbedding(ctx, k)
+			_ = distance(q, normalize(emb))
+		}
+	}
+}
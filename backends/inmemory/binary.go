@@ -0,0 +1,295 @@
+package inmemory
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/botirk38/semanticcache/similarity"
+	"github.com/botirk38/semanticcache/types"
+)
+
+type binaryEntry[V any] struct {
+	entry types.Entry[V] // Embedding is nil in binary-only mode
+	bits  []uint64
+}
+
+// BinaryBackend implements Backend with binary-quantized embeddings, one
+// sign bit per dimension, and implements types.VectorSearcher with a
+// Hamming-distance scan whose best candidates are reranked by cosine
+// similarity at full precision.
+//
+// With WithBinaryOnly the full-precision embeddings are dropped, shrinking
+// their memory 64 times; results are then ranked and scored on the sign
+// bits alone, and GetEmbedding returns the unit vector of signs. The
+// backend is unbounded and never evicts.
+type BinaryBackend[K comparable, V any] struct {
+	mu      sync.RWMutex
+	entries map[K]*binaryEntry[V]
+	dim     int
+
+	rerank int // Hamming candidates per result; 0 in binary-only mode
+}
+
+var _ types.VectorSearcher[string, string] = (*BinaryBackend[string, string])(nil)
+
+// NewBinaryBackend creates an empty binary-quantized backend. Use
+// WithBinaryRerank to tune the rerank depth, or WithBinaryOnly to drop
+// full-precision embeddings.
+func NewBinaryBackend[K comparable, V any](opts ...Option) (*BinaryBackend[K, V], error) {
+	cfg := newConfig(opts)
+	b := &BinaryBackend[K, V]{entries: make(map[K]*binaryEntry[V]), rerank: 4}
+	if cfg.binaryRerank > 0 {
+		b.rerank = cfg.binaryRerank
+	}
+	if cfg.binaryOnly {
+		b.rerank = 0
+	}
+	return b, nil
+}
+
+// Set stores a value with its quantized embedding.
+func (b *BinaryBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.SetEntry(ctx, key, types.Entry[V]{Embedding: embedding, Value: value})
+}
+
+// SetEntry stores an entry, including its input text and creation time.
+func (b *BinaryBackend[K, V]) SetEntry(_ context.Context, key K, entry types.Entry[V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.setChecked(key, entry)
+}
+
+// setChecked checks the embedding's dimension and stores an entry. Callers
+// must hold the write lock.
+func (b *BinaryBackend[K, V]) setChecked(key K, entry types.Entry[V]) error {
+	if b.dim != 0 && len(entry.Embedding) != b.dim {
+		return ErrDimensionMismatch
+	}
+	b.set(key, entry)
+	return nil
+}
+
+// set quantizes and stores an entry. Callers must hold the write lock and
+// have checked the embedding's dimension.
+func (b *BinaryBackend[K, V]) set(key K, entry types.Entry[V]) {
+	if b.dim == 0 {
+		b.dim = len(entry.Embedding)
+	}
+	e := &binaryEntry[V]{entry: entry, bits: similarity.Binarize(entry.Embedding)}
+	if b.rerank == 0 {
+		e.entry.Embedding = nil
+	}
+	b.entries[key] = e
+}
+
+// embedding returns e's full-precision embedding, or in binary-only mode
+// the unit vector of its signs.
+func (b *BinaryBackend[K, V]) embedding(e *binaryEntry[V]) []float64 {
+	if e.entry.Embedding != nil {
+		return e.entry.Embedding
+	}
+	out := make([]float64, b.dim)
+	unit := 1 / math.Sqrt(float64(b.dim))
+	for i := range out {
+		if e.bits[i/64]&(1<<(i%64)) != 0 {
+			out[i] = unit
+		} else {
+			out[i] = -unit
+		}
+	}
+	return out
+}
+
+// VectorSearch returns up to k entries most similar to query. It ranks
+// every entry by Hamming distance and reranks the best k times the rerank
+// depth by cosine similarity. In binary-only mode the score is the cosine
+// similarity of the sign vectors, 1 - 2*distance/dimensions.
+func (b *BinaryBackend[K, V]) VectorSearch(_ context.Context, query []float64, k int) ([]types.SearchResult[K, V], error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.entries) == 0 || k <= 0 {
+		return nil, nil
+	}
+	if len(query) != b.dim {
+		return nil, ErrDimensionMismatch
+	}
+
+	type hit struct {
+		key  K
+		e    *binaryEntry[V]
+		dist int
+	}
+	q := similarity.Binarize(query)
+	hits := make([]hit, 0, len(b.entries))
+	for key, e := range b.entries {
+		hits = append(hits, hit{key, e, similarity.HammingDistance(q, e.bits)})
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].dist < hits[j].dist })
+	hits = hits[:min(k*max(b.rerank, 1), len(hits))]
+
+	results := make([]types.SearchResult[K, V], len(hits))
+	for i, h := range hits {
+		score := 1 - 2*float64(h.dist)/float64(b.dim)
+		if b.rerank > 0 {
+			score = similarity.CosineSimilarity(query, h.e.entry.Embedding)
+		}
+		results[i] = types.SearchResult[K, V]{Key: h.key, Value: h.e.entry.Value, Score: score}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results[:min(k, len(results))], nil
+}
+
+// Get retrieves the value for a key.
+func (b *BinaryBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if e, ok := b.entries[key]; ok {
+		return e.entry.Value, true, nil
+	}
+	var zero V
+	return zero, false, nil
+}
+
+// GetEntry retrieves the entry for a key.
+func (b *BinaryBackend[K, V]) GetEntry(_ context.Context, key K) (types.Entry[V], bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	entry, ok := b.peek(key)
+	return entry, ok, nil
+}
+
+// Delete removes an entry by key.
+func (b *BinaryBackend[K, V]) Delete(_ context.Context, key K) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.entries, key)
+	return nil
+}
+
+// Txn applies ops in order while holding the backend's lock. Every
+// embedding is checked before anything is applied, so a dimension mismatch
+// leaves the backend untouched.
+func (b *BinaryBackend[K, V]) Txn(_ context.Context, ops []types.TxnOp[K, V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	dim := b.dim
+	for _, op := range ops {
+		if op.Delete {
+			continue
+		}
+		if dim == 0 {
+			dim = len(op.Entry.Embedding)
+		} else if len(op.Entry.Embedding) != dim {
+			return ErrDimensionMismatch
+		}
+	}
+	for _, op := range ops {
+		if op.Delete {
+			delete(b.entries, op.Key)
+		} else {
+			b.set(op.Key, op.Entry)
+		}
+	}
+	return nil
+}
+
+// Contains checks whether a key exists.
+func (b *BinaryBackend[K, V]) Contains(_ context.Context, key K) (bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.entries[key]
+	return ok, nil
+}
+
+// Flush removes all entries.
+func (b *BinaryBackend[K, V]) Flush(_ context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = make(map[K]*binaryEntry[V])
+	b.dim = 0
+	return nil
+}
+
+// Len returns the number of stored entries.
+func (b *BinaryBackend[K, V]) Len(_ context.Context) (int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.entries), nil
+}
+
+// Close is a no-op for in-memory backends.
+func (b *BinaryBackend[K, V]) Close() error { return nil }
+
+// Keys returns all keys in the cache.
+func (b *BinaryBackend[K, V]) Keys(_ context.Context) ([]K, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	keys := make([]K, 0, len(b.entries))
+	for k := range b.entries {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// MemoryUsage estimates the bytes held by entries. The sign bits count as
+// embeddings.
+func (b *BinaryBackend[K, V]) MemoryUsage(_ context.Context) (types.MemoryUsage, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var usage types.MemoryUsage
+	for key, e := range b.entries {
+		entry := entryUsage(key, e.entry, mapSlotSize+8)
+		entry.Embeddings += 8 * int64(len(e.bits))
+		entry.Index += 24
+		usage = usage.Add(entry)
+	}
+	return usage, nil
+}
+
+// GetEmbedding retrieves the embedding for a key.
+func (b *BinaryBackend[K, V]) GetEmbedding(_ context.Context, key K) ([]float64, bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if e, ok := b.entries[key]; ok {
+		return b.embedding(e), true, nil
+	}
+	return nil, false, nil
+}
+
+// GetEmbeddings retrieves the embeddings for multiple keys under one lock.
+func (b *BinaryBackend[K, V]) GetEmbeddings(_ context.Context, keys []K) (map[K][]float64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	result := make(map[K][]float64, len(keys))
+	for _, key := range keys {
+		if e, ok := b.entries[key]; ok {
+			result[key] = b.embedding(e)
+		}
+	}
+	return result, nil
+}
+
+// SetIfAbsent stores entry only if key is not already present.
+func (b *BinaryBackend[K, V]) SetIfAbsent(_ context.Context, key K, entry types.Entry[V]) (bool, error) {
+	return setIfAbsent(&b.mu, b.peek, b.setChecked, key, entry)
+}
+
+// CompareAndSwap stores entry only if key's current value is deeply equal
+// to old.
+func (b *BinaryBackend[K, V]) CompareAndSwap(_ context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	return compareAndSwap(&b.mu, b.peek, b.setChecked, key, old, entry)
+}
+
+// peek returns key's entry, with its embedding as GetEmbedding reports it.
+// Callers must hold the lock.
+func (b *BinaryBackend[K, V]) peek(key K) (types.Entry[V], bool) {
+	e, ok := b.entries[key]
+	if !ok {
+		return types.Entry[V]{}, false
+	}
+	entry := e.entry
+	entry.Embedding = b.embedding(e)
+	return entry, true
+}
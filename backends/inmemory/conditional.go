@@ -0,0 +1,38 @@
+package inmemory
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// noErr adapts a backend's set method to the helpers below.
+func noErr[K comparable, V any](set func(K, types.Entry[V])) func(K, types.Entry[V]) error {
+	return func(key K, entry types.Entry[V]) error {
+		set(key, entry)
+		return nil
+	}
+}
+
+// setIfAbsent stores entry under the write lock unless peek finds key.
+func setIfAbsent[K comparable, V any](mu *sync.RWMutex, peek func(K) (types.Entry[V], bool), set func(K, types.Entry[V]) error, key K, entry types.Entry[V]) (bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := peek(key); ok {
+		return false, nil
+	}
+	return true, set(key, entry)
+}
+
+// compareAndSwap stores entry under the write lock if the current value of
+// key is deeply equal to old.
+func compareAndSwap[K comparable, V any](mu *sync.RWMutex, peek func(K) (types.Entry[V], bool), set func(K, types.Entry[V]) error, key K, old V, entry types.Entry[V]) (bool, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	cur, ok := peek(key)
+	if !ok || !reflect.DeepEqual(cur.Value, old) {
+		return false, nil
+	}
+	return true, set(key, entry)
+}
@@ -2,6 +2,7 @@ package inmemory
 
 import (
 	"context"
+	"reflect"
 	"sync"
 
 	"github.com/botirk38/semanticcache/types"
@@ -10,63 +11,143 @@ import (
 // FIFOBackend implements Backend using FIFO eviction.
 type FIFOBackend[K comparable, V any] struct {
 	mu       sync.RWMutex
-	entries  map[K]types.Entry[V]
+	entries  map[K]storedEntry[V]
 	queue    []K
+	float16  bool
 	capacity int
+	maxBytes int64
+	bytes    int64
+	onEvict  func(K, types.Entry[V])
+	pins     pinSet[K]
 }
 
 // NewFIFOBackend creates a new FIFO backend with the given capacity.
-func NewFIFOBackend[K comparable, V any](capacity int) (*FIFOBackend[K, V], error) {
+func NewFIFOBackend[K comparable, V any](capacity int, opts ...Option) (*FIFOBackend[K, V], error) {
+	cfg := newConfig(opts)
 	return &FIFOBackend[K, V]{
-		entries:  make(map[K]types.Entry[V]),
-		queue:    make([]K, 0, capacity),
+		entries:  make(map[K]storedEntry[V]),
+		queue:    make([]K, 0, max(capacity, 0)),
+		float16:  cfg.float16,
 		capacity: capacity,
+		maxBytes: cfg.maxBytes,
+		pins:     make(pinSet[K]),
 	}, nil
 }
 
-// Set stores a value with its embedding.
-func (b *FIFOBackend[K, V]) Set(_ context.Context, key K, embedding []float64, value V) error {
+func (b *FIFOBackend[K, V]) size(key K, s storedEntry[V]) int64 {
+	if b.maxBytes <= 0 {
+		return 0
+	}
+	return storedSize(key, s)
+}
+
+// evictOldest removes the oldest unpinned entry other than keep. It
+// reports whether there was one.
+func (b *FIFOBackend[K, V]) evictOldest(keep K) bool {
+	for i, k := range b.queue {
+		if k != keep && !b.pins.has(k) {
+			b.evictAt(i)
+			return true
+		}
+	}
+	return false
+}
+
+func (b *FIFOBackend[K, V]) evictAt(i int) {
+	victim := b.queue[i]
+	e := b.entries[victim]
+	b.queue = append(b.queue[:i], b.queue[i+1:]...)
+	b.bytes -= b.size(victim, e)
+	delete(b.entries, victim)
+	if b.onEvict != nil {
+		b.onEvict(victim, e.unpack())
+	}
+}
+
+// OnEvict registers fn to be called for every entry evicted by capacity or
+// the byte limit.
+func (b *FIFOBackend[K, V]) OnEvict(fn func(key K, entry types.Entry[V])) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.onEvict = fn
+}
+
+// Set stores a value with its embedding.
+func (b *FIFOBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.SetEntry(ctx, key, types.Entry[V]{Embedding: embedding, Value: value})
+}
 
-	entry := types.Entry[V]{Embedding: embedding, Value: value}
+// SetEntry stores an entry, including its input text and creation time.
+func (b *FIFOBackend[K, V]) SetEntry(_ context.Context, key K, entry types.Entry[V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.set(key, entry)
+	return nil
+}
 
-	if _, ok := b.entries[key]; ok {
-		b.entries[key] = entry
-		return nil
+// set stores an entry. Callers must hold the write lock.
+func (b *FIFOBackend[K, V]) set(key K, entry types.Entry[V]) {
+	s := pack(entry, b.float16)
+	if old, ok := b.entries[key]; ok {
+		b.bytes += b.size(key, s) - b.size(key, old)
+		b.entries[key] = s
+		b.evictBytes(key)
+		return
 	}
 
-	if len(b.entries) >= b.capacity && b.capacity > 0 {
-		oldest := b.queue[0]
-		b.queue = b.queue[1:]
-		delete(b.entries, oldest)
+	for b.capacity > 0 && len(b.entries)-len(b.pins) >= b.capacity {
+		if !b.evictOldest(key) {
+			break
+		}
 	}
 
-	b.entries[key] = entry
+	b.entries[key] = s
 	b.queue = append(b.queue, key)
-	return nil
+	b.bytes += b.size(key, s)
+	b.evictBytes(key)
+}
+
+// evictBytes evicts the oldest unpinned entries other than keep until the
+// byte budget is met.
+func (b *FIFOBackend[K, V]) evictBytes(keep K) {
+	for b.maxBytes > 0 && b.bytes > b.maxBytes {
+		if !b.evictOldest(keep) {
+			return
+		}
+	}
 }
 
 // Get retrieves the value for a key.
-func (b *FIFOBackend[K, V]) Get(_ context.Context, key K) (V, bool, error) {
+func (b *FIFOBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	entry, ok, err := b.GetEntry(ctx, key)
+	return entry.Value, ok, err
+}
+
+// GetEntry retrieves the entry for a key.
+func (b *FIFOBackend[K, V]) GetEntry(_ context.Context, key K) (types.Entry[V], bool, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	if e, ok := b.entries[key]; ok {
-		return e.Value, true, nil
-	}
-	var zero V
-	return zero, false, nil
+	e, ok := b.entries[key]
+	return e.unpack(), ok, nil
 }
 
 // Delete removes an entry by key.
 func (b *FIFOBackend[K, V]) Delete(_ context.Context, key K) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.remove(key)
+	return nil
+}
 
-	if _, ok := b.entries[key]; !ok {
-		return nil
+// remove deletes an entry. Callers must hold the write lock.
+func (b *FIFOBackend[K, V]) remove(key K) {
+	e, ok := b.entries[key]
+	if !ok {
+		return
 	}
+	b.bytes -= b.size(key, e)
 	delete(b.entries, key)
+	delete(b.pins, key)
 
 	for i, k := range b.queue {
 		if k == key {
@@ -74,6 +155,20 @@ func (b *FIFOBackend[K, V]) Delete(_ context.Context, key K) error {
 			break
 		}
 	}
+}
+
+// Txn applies ops in order while holding the backend's lock, so readers
+// never observe a partial transaction.
+func (b *FIFOBackend[K, V]) Txn(_ context.Context, ops []types.TxnOp[K, V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, op := range ops {
+		if op.Delete {
+			b.remove(op.Key)
+		} else {
+			b.set(op.Key, op.Entry)
+		}
+	}
 	return nil
 }
 
@@ -89,8 +184,10 @@ func (b *FIFOBackend[K, V]) Contains(_ context.Context, key K) (bool, error) {
 func (b *FIFOBackend[K, V]) Flush(_ context.Context) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.entries = make(map[K]types.Entry[V])
-	b.queue = make([]K, 0, b.capacity)
+	b.entries = make(map[K]storedEntry[V])
+	b.queue = make([]K, 0, max(b.capacity, 0))
+	b.bytes = 0
+	clear(b.pins)
 	return nil
 }
 
@@ -115,12 +212,79 @@ func (b *FIFOBackend[K, V]) Keys(_ context.Context) ([]K, error) {
 	return keys, nil
 }
 
+// MemoryUsage estimates the bytes held by entries and the insertion queue.
+func (b *FIFOBackend[K, V]) MemoryUsage(_ context.Context) (types.MemoryUsage, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	keySize := int64(reflect.TypeFor[K]().Size())
+	usage := types.MemoryUsage{Index: int64(cap(b.queue))*keySize + int64(len(b.pins))*mapSlotSize}
+	for key, e := range b.entries {
+		usage = usage.Add(storedUsage(key, e, mapSlotSize))
+	}
+	return usage, nil
+}
+
 // GetEmbedding retrieves the embedding for a key.
 func (b *FIFOBackend[K, V]) GetEmbedding(_ context.Context, key K) ([]float64, bool, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	if e, ok := b.entries[key]; ok {
-		return e.Embedding, true, nil
+		return e.embedding(), true, nil
 	}
 	return nil, false, nil
 }
+
+// GetEmbeddings retrieves the embeddings for multiple keys under one lock.
+func (b *FIFOBackend[K, V]) GetEmbeddings(_ context.Context, keys []K) (map[K][]float64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	result := make(map[K][]float64, len(keys))
+	for _, key := range keys {
+		if e, ok := b.entries[key]; ok {
+			result[key] = e.embedding()
+		}
+	}
+	return result, nil
+}
+
+// GetVectors returns each stored key's embedding followed by its extra
+// vectors.
+func (b *FIFOBackend[K, V]) GetVectors(_ context.Context, keys []K) (map[K][][]float64, error) {
+	return getVectors(&b.mu, b.peek, keys), nil
+}
+
+// EntryStats returns each stored key's creation time, without
+// counting an access.
+func (b *FIFOBackend[K, V]) EntryStats(_ context.Context, keys []K) (map[K]types.EntryStats, error) {
+	return getStats(&b.mu, b.peek, nil, keys), nil
+}
+
+// SetIfAbsent stores entry only if key is not already present.
+func (b *FIFOBackend[K, V]) SetIfAbsent(_ context.Context, key K, entry types.Entry[V]) (bool, error) {
+	return setIfAbsent(&b.mu, b.peek, noErr(b.set), key, entry)
+}
+
+// CompareAndSwap stores entry only if key's current value is deeply equal
+// to old.
+func (b *FIFOBackend[K, V]) CompareAndSwap(_ context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	return compareAndSwap(&b.mu, b.peek, noErr(b.set), key, old, entry)
+}
+
+// peek returns key's entry without counting an access. Callers must hold
+// the lock.
+func (b *FIFOBackend[K, V]) peek(key K) (types.Entry[V], bool) {
+	e, ok := b.entries[key]
+	return e.unpack(), ok
+}
+
+// Pin keeps key from being evicted until it is unpinned or deleted. Pinned
+// entries do not count toward capacity.
+func (b *FIFOBackend[K, V]) Pin(_ context.Context, key K) (bool, error) {
+	return setPinned(&b.mu, b.peek, b.pins, key, true), nil
+}
+
+// Unpin makes key evictable again. If that puts the backend over capacity,
+// the next insert evicts the excess.
+func (b *FIFOBackend[K, V]) Unpin(_ context.Context, key K) (bool, error) {
+	return setPinned(&b.mu, b.peek, b.pins, key, false), nil
+}
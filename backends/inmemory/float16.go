@@ -0,0 +1,72 @@
+package inmemory
+
+import (
+	"github.com/botirk38/semanticcache/similarity"
+	"github.com/botirk38/semanticcache/types"
+)
+
+// storedEntry is an entry as the LRU, LFU, and FIFO backends hold it. With
+// WithFloat16 its embedding and extra vectors are packed into half and
+// removed from Entry, and every read widens them again.
+type storedEntry[V any] struct {
+	types.Entry[V]
+	half [][]uint16 // embedding, then Vectors; nil without WithFloat16
+}
+
+// pack returns the stored form of e, in float16 if float16 is set.
+func pack[V any](e types.Entry[V], float16 bool) storedEntry[V] {
+	if !float16 {
+		return storedEntry[V]{Entry: e}
+	}
+	half := make([][]uint16, 1+len(e.Vectors))
+	half[0] = similarity.ToFloat16(e.Embedding)
+	for i, v := range e.Vectors {
+		half[i+1] = similarity.ToFloat16(v)
+	}
+	e.Embedding, e.Vectors = nil, nil
+	return storedEntry[V]{Entry: e, half: half}
+}
+
+// unpack returns the entry with its vectors widened.
+func (s storedEntry[V]) unpack() types.Entry[V] {
+	if s.half == nil {
+		return s.Entry
+	}
+	e := s.Entry
+	e.Embedding = similarity.FromFloat16(s.half[0])
+	if len(s.half) > 1 {
+		e.Vectors = make([][]float64, len(s.half)-1)
+		for i, h := range s.half[1:] {
+			e.Vectors[i] = similarity.FromFloat16(h)
+		}
+	}
+	return e
+}
+
+// embedding returns the widened embedding alone.
+func (s storedEntry[V]) embedding() []float64 {
+	if s.half == nil {
+		return s.Embedding
+	}
+	return similarity.FromFloat16(s.half[0])
+}
+
+// storedSize is entrySize for the stored form, counting 2 bytes per packed
+// dimension.
+func storedSize[K comparable, V any](key K, s storedEntry[V]) int64 {
+	size := entrySize(key, s.Entry)
+	if s.half != nil {
+		size += 2 * int64(len(s.half[0]))
+	}
+	return size
+}
+
+// storedUsage is entryUsage for the stored form.
+func storedUsage[K comparable, V any](key K, s storedEntry[V], overhead int64) types.MemoryUsage {
+	usage := entryUsage(key, s.Entry, overhead)
+	for _, h := range s.half {
+		usage.Embeddings += 2 * int64(len(h))
+		usage.Values += 24
+	}
+	return usage
+}
@@ -0,0 +1,494 @@
+package inmemory
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"sync"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// ErrDimensionMismatch is returned when an embedding's length differs from
+// the dimension of the embeddings already stored in an index.
+var ErrDimensionMismatch = errors.New("inmemory: embedding dimension mismatch")
+
+// minRebuild is the number of deleted nodes below which the HNSW graph is
+// never rebuilt, so small caches do not rebuild on every other delete.
+const minRebuild = 64
+
+type hnswNode[K comparable, V any] struct {
+	key     K
+	entry   types.Entry[V]
+	vec     []float64 // unit-normalized embedding
+	friends [][]int   // neighbor ids per layer
+	deleted bool
+}
+
+// HNSWBackend implements Backend with a Hierarchical Navigable Small World
+// graph over the stored embeddings, and implements types.VectorSearcher so
+// nearest-neighbor queries take O(log n) instead of a full scan.
+//
+// Similarity is cosine. Deletes and overwrites leave tombstones in the
+// graph; once tombstones outnumber live entries the graph is rebuilt. The
+// backend is unbounded and never evicts.
+type HNSWBackend[K comparable, V any] struct {
+	mu         sync.RWMutex
+	nodes      []*hnswNode[K, V]
+	ids        map[K]int
+	entryPoint int // -1 when empty
+	maxLevel   int
+	deleted    int
+	dim        int
+
+	m              int
+	efConstruction int
+	efSearch       int
+	levelMult      float64
+}
+
+var _ types.VectorSearcher[string, string] = (*HNSWBackend[string, string])(nil)
+
+// NewHNSWBackend creates an empty HNSW backend. Use WithHNSWM,
+// WithHNSWEfConstruction, and WithHNSWEfSearch to tune the index.
+func NewHNSWBackend[K comparable, V any](opts ...Option) (*HNSWBackend[K, V], error) {
+	cfg := newConfig(opts)
+	b := &HNSWBackend[K, V]{
+		ids:            make(map[K]int),
+		entryPoint:     -1,
+		m:              16,
+		efConstruction: 200,
+		efSearch:       64,
+	}
+	if cfg.hnswM > 1 {
+		b.m = cfg.hnswM
+	}
+	if cfg.hnswEfConstruction > 0 {
+		b.efConstruction = cfg.hnswEfConstruction
+	}
+	if cfg.hnswEfSearch > 0 {
+		b.efSearch = cfg.hnswEfSearch
+	}
+	b.levelMult = 1 / math.Log(float64(b.m))
+	return b, nil
+}
+
+func normalize(v []float64) []float64 {
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	out := make([]float64, len(v))
+	if norm == 0 {
+		return out
+	}
+	norm = math.Sqrt(norm)
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+// distance is the cosine distance between two unit vectors.
+func distance(a, b []float64) float64 {
+	var dot float64
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return 1 - dot
+}
+
+func (b *HNSWBackend[K, V]) randomLevel() int {
+	return int(-math.Log(1-rand.Float64()) * b.levelMult)
+}
+
+// Set stores a value with its embedding and indexes the embedding.
+func (b *HNSWBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.SetEntry(ctx, key, types.Entry[V]{Embedding: embedding, Value: value})
+}
+
+// SetEntry stores an entry, including its input text and creation time.
+func (b *HNSWBackend[K, V]) SetEntry(_ context.Context, key K, entry types.Entry[V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.setChecked(key, entry)
+}
+
+// setChecked checks the embedding's dimension and stores an entry. Callers
+// must hold the write lock.
+func (b *HNSWBackend[K, V]) setChecked(key K, entry types.Entry[V]) error {
+	if b.dim != 0 && len(entry.Embedding) != b.dim {
+		return ErrDimensionMismatch
+	}
+	b.set(key, entry)
+	return nil
+}
+
+// set stores an entry. Callers must hold the write lock and have checked
+// the embedding's dimension.
+func (b *HNSWBackend[K, V]) set(key K, entry types.Entry[V]) {
+	if b.dim == 0 {
+		b.dim = len(entry.Embedding)
+	}
+	if id, ok := b.ids[key]; ok {
+		b.nodes[id].deleted = true
+		b.deleted++
+	}
+	b.insert(&hnswNode[K, V]{
+		key:   key,
+		entry: entry,
+		vec:   normalize(entry.Embedding),
+	})
+	b.maybeRebuild()
+}
+
+// insert links node into the graph. Callers must hold the write lock.
+func (b *HNSWBackend[K, V]) insert(node *hnswNode[K, V]) {
+	id := len(b.nodes)
+	level := b.randomLevel()
+	node.friends = make([][]int, level+1)
+	b.nodes = append(b.nodes, node)
+	b.ids[node.key] = id
+
+	if b.entryPoint < 0 {
+		b.entryPoint = id
+		b.maxLevel = level
+		return
+	}
+
+	ep := b.entryPoint
+	for l := b.maxLevel; l > level; l-- {
+		ep = b.searchLayer(node.vec, ep, 1, l)[0].id
+	}
+	for l := min(level, b.maxLevel); l >= 0; l-- {
+		candidates := b.searchLayer(node.vec, ep, b.efConstruction, l)
+		maxConn := b.m
+		if l == 0 {
+			maxConn = 2 * b.m
+		}
+		neighbors := candidates[:min(b.m, len(candidates))]
+		node.friends[l] = make([]int, 0, len(neighbors))
+		for _, nb := range neighbors {
+			node.friends[l] = append(node.friends[l], nb.id)
+			b.link(nb.id, id, l, maxConn)
+		}
+		ep = candidates[0].id
+	}
+	if level > b.maxLevel {
+		b.maxLevel = level
+		b.entryPoint = id
+	}
+}
+
+// link adds to as a neighbor of from on layer l, keeping only the maxConn
+// closest neighbors.
+func (b *HNSWBackend[K, V]) link(from, to, l, maxConn int) {
+	n := b.nodes[from]
+	n.friends[l] = append(n.friends[l], to)
+	if len(n.friends[l]) <= maxConn {
+		return
+	}
+	sort.Slice(n.friends[l], func(i, j int) bool {
+		return distance(n.vec, b.nodes[n.friends[l][i]].vec) < distance(n.vec, b.nodes[n.friends[l][j]].vec)
+	})
+	n.friends[l] = n.friends[l][:maxConn]
+}
+
+// searchLayer returns up to ef nodes closest to q on layer l, nearest
+// first, starting the greedy search from ep.
+func (b *HNSWBackend[K, V]) searchLayer(q []float64, ep, ef, l int) []hnswItem {
+	visited := map[int]struct{}{ep: {}}
+	start := hnswItem{id: ep, dist: distance(q, b.nodes[ep].vec)}
+	candidates := &nearHeap{start}
+	results := &farHeap{start}
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswItem)
+		if results.Len() >= ef && c.dist > (*results)[0].dist {
+			break
+		}
+		friends := b.nodes[c.id].friends
+		if l >= len(friends) {
+			continue
+		}
+		for _, n := range friends[l] {
+			if _, seen := visited[n]; seen {
+				continue
+			}
+			visited[n] = struct{}{}
+			d := distance(q, b.nodes[n].vec)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(candidates, hnswItem{id: n, dist: d})
+				heap.Push(results, hnswItem{id: n, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := make([]hnswItem, results.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(results).(hnswItem)
+	}
+	return out
+}
+
+// maybeRebuild reinserts the live nodes once tombstones outnumber them.
+// Callers must hold the write lock.
+func (b *HNSWBackend[K, V]) maybeRebuild() {
+	if b.deleted < minRebuild || b.deleted <= len(b.ids) {
+		return
+	}
+	live := make([]*hnswNode[K, V], 0, len(b.ids))
+	for _, id := range b.ids {
+		live = append(live, b.nodes[id])
+	}
+	b.reset()
+	for _, n := range live {
+		b.insert(&hnswNode[K, V]{key: n.key, entry: n.entry, vec: n.vec})
+	}
+}
+
+func (b *HNSWBackend[K, V]) reset() {
+	b.nodes = nil
+	b.ids = make(map[K]int)
+	b.entryPoint = -1
+	b.maxLevel = 0
+	b.deleted = 0
+}
+
+// VectorSearch returns up to k entries most similar to query.
+func (b *HNSWBackend[K, V]) VectorSearch(_ context.Context, query []float64, k int) ([]types.SearchResult[K, V], error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.entryPoint < 0 || k <= 0 {
+		return nil, nil
+	}
+	if len(query) != b.dim {
+		return nil, ErrDimensionMismatch
+	}
+
+	q := normalize(query)
+	ep := b.entryPoint
+	for l := b.maxLevel; l > 0; l-- {
+		ep = b.searchLayer(q, ep, 1, l)[0].id
+	}
+	// Widen the search by the share of tombstones so deletes do not starve
+	// the result set.
+	ef := max(b.efSearch, k) + b.deleted*max(b.efSearch, k)/max(len(b.nodes), 1)
+	candidates := b.searchLayer(q, ep, ef, 0)
+
+	results := make([]types.SearchResult[K, V], 0, k)
+	for _, c := range candidates {
+		n := b.nodes[c.id]
+		if n.deleted {
+			continue
+		}
+		results = append(results, types.SearchResult[K, V]{Key: n.key, Value: n.entry.Value, Score: 1 - c.dist})
+		if len(results) == k {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Get retrieves the value for a key.
+func (b *HNSWBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	entry, ok, err := b.GetEntry(ctx, key)
+	return entry.Value, ok, err
+}
+
+// GetEntry retrieves the entry for a key.
+func (b *HNSWBackend[K, V]) GetEntry(_ context.Context, key K) (types.Entry[V], bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if id, ok := b.ids[key]; ok {
+		return b.nodes[id].entry, true, nil
+	}
+	return types.Entry[V]{}, false, nil
+}
+
+// Delete removes an entry by key.
+func (b *HNSWBackend[K, V]) Delete(_ context.Context, key K) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(key)
+	return nil
+}
+
+// remove deletes an entry. Callers must hold the write lock.
+func (b *HNSWBackend[K, V]) remove(key K) {
+	if id, ok := b.ids[key]; ok {
+		b.nodes[id].deleted = true
+		b.deleted++
+		delete(b.ids, key)
+		b.maybeRebuild()
+	}
+}
+
+// Txn applies ops in order while holding the backend's lock. Every
+// embedding is checked before anything is applied, so a dimension mismatch
+// leaves the index untouched.
+func (b *HNSWBackend[K, V]) Txn(_ context.Context, ops []types.TxnOp[K, V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	dim := b.dim
+	for _, op := range ops {
+		if op.Delete {
+			continue
+		}
+		if dim == 0 {
+			dim = len(op.Entry.Embedding)
+		} else if len(op.Entry.Embedding) != dim {
+			return ErrDimensionMismatch
+		}
+	}
+	for _, op := range ops {
+		if op.Delete {
+			b.remove(op.Key)
+		} else {
+			b.set(op.Key, op.Entry)
+		}
+	}
+	return nil
+}
+
+// Contains checks whether a key exists.
+func (b *HNSWBackend[K, V]) Contains(_ context.Context, key K) (bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.ids[key]
+	return ok, nil
+}
+
+// Flush removes all entries and clears the index.
+func (b *HNSWBackend[K, V]) Flush(_ context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reset()
+	b.dim = 0
+	return nil
+}
+
+// Len returns the number of stored entries.
+func (b *HNSWBackend[K, V]) Len(_ context.Context) (int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.ids), nil
+}
+
+// Close is a no-op for in-memory backends.
+func (b *HNSWBackend[K, V]) Close() error { return nil }
+
+// Keys returns all keys in the cache.
+func (b *HNSWBackend[K, V]) Keys(_ context.Context) ([]K, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	keys := make([]K, 0, len(b.ids))
+	for k := range b.ids {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// MemoryUsage estimates the bytes held by entries and the graph. The
+// normalized vector copies count as embeddings, and tombstoned nodes count
+// as index until the next rebuild drops them.
+func (b *HNSWBackend[K, V]) MemoryUsage(_ context.Context) (types.MemoryUsage, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	usage := types.MemoryUsage{Index: int64(cap(b.nodes)) * 8}
+	for _, n := range b.nodes {
+		node := entryUsage(n.key, n.entry, mapSlotSize)
+		node.Embeddings += 8 * int64(len(n.vec))
+		node.Index += 24
+		for _, layer := range n.friends {
+			node.Index += 24 + 8*int64(cap(layer))
+		}
+		if n.deleted {
+			node = types.MemoryUsage{Index: node.Total()}
+		}
+		usage = usage.Add(node)
+	}
+	return usage, nil
+}
+
+// GetEmbedding retrieves the embedding for a key.
+func (b *HNSWBackend[K, V]) GetEmbedding(_ context.Context, key K) ([]float64, bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if id, ok := b.ids[key]; ok {
+		return b.nodes[id].entry.Embedding, true, nil
+	}
+	return nil, false, nil
+}
+
+// GetEmbeddings retrieves the embeddings for multiple keys under one lock.
+func (b *HNSWBackend[K, V]) GetEmbeddings(_ context.Context, keys []K) (map[K][]float64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	result := make(map[K][]float64, len(keys))
+	for _, key := range keys {
+		if id, ok := b.ids[key]; ok {
+			result[key] = b.nodes[id].entry.Embedding
+		}
+	}
+	return result, nil
+}
+
+// SetIfAbsent stores entry only if key is not already present.
+func (b *HNSWBackend[K, V]) SetIfAbsent(_ context.Context, key K, entry types.Entry[V]) (bool, error) {
+	return setIfAbsent(&b.mu, b.peek, b.setChecked, key, entry)
+}
+
+// CompareAndSwap stores entry only if key's current value is deeply equal
+// to old.
+func (b *HNSWBackend[K, V]) CompareAndSwap(_ context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	return compareAndSwap(&b.mu, b.peek, b.setChecked, key, old, entry)
+}
+
+// peek returns key's entry without counting an access. Callers must hold
+// the lock.
+func (b *HNSWBackend[K, V]) peek(key K) (types.Entry[V], bool) {
+	if id, ok := b.ids[key]; ok {
+		return b.nodes[id].entry, true
+	}
+	return types.Entry[V]{}, false
+}
+
+type hnswItem struct {
+	id   int
+	dist float64
+}
+
+// nearHeap pops the closest item first.
+type nearHeap []hnswItem
+
+func (h nearHeap) Len() int           { return len(h) }
+func (h nearHeap) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h nearHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *nearHeap) Push(x any)        { *h = append(*h, x.(hnswItem)) }
+func (h *nearHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// farHeap pops the farthest item first.
+type farHeap []hnswItem
+
+func (h farHeap) Len() int           { return len(h) }
+func (h farHeap) Less(i, j int) bool { return h[i].dist > h[j].dist }
+func (h farHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *farHeap) Push(x any)        { *h = append(*h, x.(hnswItem)) }
+func (h *farHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -1,83 +1,234 @@
 package inmemory
 
 import (
+	"container/list"
 	"context"
+	"reflect"
 	"sync"
 
 	"github.com/botirk38/semanticcache/types"
 )
 
-type lfuEntry[V any] struct {
-	entry     types.Entry[V]
+type lfuEntry[K comparable, V any] struct {
+	key    K
+	entry  storedEntry[V]
+	size   int64
+	bucket *list.Element // in LFUBackend.buckets
+	elem   *list.Element // in the bucket's entries
+}
+
+// lfuBucket holds the entries accessed frequency times, least recently
+// accessed first.
+type lfuBucket struct {
 	frequency int
+	entries   list.List
 }
 
-// LFUBackend implements Backend using LFU eviction.
+func (e *lfuEntry[K, V]) frequency() int {
+	return e.bucket.Value.(*lfuBucket).frequency
+}
+
+// LFUBackend implements Backend using LFU eviction. Entries sit in
+// buckets ordered by access count, so accesses and evictions are O(1);
+// ties go to the least recently accessed entry.
 type LFUBackend[K comparable, V any] struct {
 	mu       sync.RWMutex
-	entries  map[K]*lfuEntry[V]
+	entries  map[K]*lfuEntry[K, V]
+	buckets  list.List // *lfuBucket, by ascending frequency; never empty buckets
+	float16  bool
 	capacity int
+	maxBytes int64
+	bytes    int64
+	onEvict  func(K, types.Entry[V])
+	pins     pinSet[K]
 }
 
 // NewLFUBackend creates a new LFU backend with the given capacity.
-func NewLFUBackend[K comparable, V any](capacity int) (*LFUBackend[K, V], error) {
+func NewLFUBackend[K comparable, V any](capacity int, opts ...Option) (*LFUBackend[K, V], error) {
+	cfg := newConfig(opts)
 	return &LFUBackend[K, V]{
-		entries:  make(map[K]*lfuEntry[V]),
+		entries:  make(map[K]*lfuEntry[K, V]),
+		float16:  cfg.float16,
 		capacity: capacity,
+		maxBytes: cfg.maxBytes,
+		pins:     make(pinSet[K]),
 	}, nil
 }
 
+// link adds e to the bucket for frequency, which follows after in the
+// bucket list, or is first when after is nil. Callers must hold the write
+// lock.
+func (b *LFUBackend[K, V]) link(e *lfuEntry[K, V], frequency int, after *list.Element) {
+	next := b.buckets.Front()
+	if after != nil {
+		next = after.Next()
+	}
+	if next == nil || next.Value.(*lfuBucket).frequency != frequency {
+		if after == nil {
+			next = b.buckets.PushFront(&lfuBucket{frequency: frequency})
+		} else {
+			next = b.buckets.InsertAfter(&lfuBucket{frequency: frequency}, after)
+		}
+	}
+	e.bucket = next
+	e.elem = next.Value.(*lfuBucket).entries.PushBack(e)
+}
+
+// unlink removes e from its bucket, dropping the bucket if it empties, and
+// returns the bucket's predecessor. Callers must hold the write lock.
+func (b *LFUBackend[K, V]) unlink(e *lfuEntry[K, V]) *list.Element {
+	bucket := e.bucket.Value.(*lfuBucket)
+	bucket.entries.Remove(e.elem)
+	prev := e.bucket.Prev()
+	if bucket.entries.Len() == 0 {
+		b.buckets.Remove(e.bucket)
+	} else {
+		prev = e.bucket
+	}
+	return prev
+}
+
+// touch counts an access to e. Callers must hold the write lock.
+func (b *LFUBackend[K, V]) touch(e *lfuEntry[K, V]) {
+	frequency := e.frequency() + 1
+	b.link(e, frequency, b.unlink(e))
+}
+
 // Set stores a value with its embedding.
-func (b *LFUBackend[K, V]) Set(_ context.Context, key K, embedding []float64, value V) error {
+func (b *LFUBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.SetEntry(ctx, key, types.Entry[V]{Embedding: embedding, Value: value})
+}
+
+// SetEntry stores an entry, including its input text and creation time.
+func (b *LFUBackend[K, V]) SetEntry(_ context.Context, key K, entry types.Entry[V]) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.set(key, entry)
+	return nil
+}
+
+// set stores an entry. Callers must hold the write lock.
+func (b *LFUBackend[K, V]) set(key K, entry types.Entry[V]) {
+	s := pack(entry, b.float16)
+	var size int64
+	if b.maxBytes > 0 {
+		size = storedSize(key, s)
+	}
 
 	if e, ok := b.entries[key]; ok {
-		e.entry = types.Entry[V]{Embedding: embedding, Value: value}
-		e.frequency++
-		return nil
+		b.bytes += size - e.size
+		e.entry = s
+		e.size = size
+		b.touch(e)
+		b.evictBytes(key)
+		return
 	}
 
-	if len(b.entries) >= b.capacity && b.capacity > 0 {
-		b.evict()
+	for b.capacity > 0 && len(b.entries)-len(b.pins) >= b.capacity {
+		if !b.evict(key) {
+			break
+		}
 	}
 
-	b.entries[key] = &lfuEntry[V]{
-		entry:     types.Entry[V]{Embedding: embedding, Value: value},
-		frequency: 1,
+	e := &lfuEntry[K, V]{key: key, entry: s, size: size}
+	b.link(e, 1, nil)
+	b.entries[key] = e
+	b.bytes += size
+	b.evictBytes(key)
+}
+
+// evictBytes evicts unpinned entries other than keep until the byte budget
+// is met.
+func (b *LFUBackend[K, V]) evictBytes(keep K) {
+	for b.maxBytes > 0 && b.bytes > b.maxBytes {
+		if !b.evict(keep) {
+			return
+		}
 	}
-	return nil
 }
 
-func (b *LFUBackend[K, V]) evict() {
-	var victim K
-	minFreq := int(^uint(0) >> 1)
-	for k, e := range b.entries {
-		if e.frequency < minFreq {
-			minFreq = e.frequency
-			victim = k
+// evict removes the least frequently used unpinned entry other than keep.
+// It reports whether there was one. Only pinned entries and keep are
+// skipped, so it is O(1) without pins.
+func (b *LFUBackend[K, V]) evict(keep K) bool {
+	for be := b.buckets.Front(); be != nil; be = be.Next() {
+		for el := be.Value.(*lfuBucket).entries.Front(); el != nil; el = el.Next() {
+			e := el.Value.(*lfuEntry[K, V])
+			if e.key == keep || b.pins.has(e.key) {
+				continue
+			}
+			b.unlink(e)
+			b.bytes -= e.size
+			delete(b.entries, e.key)
+			if b.onEvict != nil {
+				b.onEvict(e.key, e.entry.unpack())
+			}
+			return true
 		}
 	}
-	delete(b.entries, victim)
+	return false
+}
+
+// OnEvict registers fn to be called for every entry evicted by capacity or
+// the byte limit.
+func (b *LFUBackend[K, V]) OnEvict(fn func(key K, entry types.Entry[V])) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onEvict = fn
 }
 
 // Get retrieves the value for a key and increments its frequency.
-func (b *LFUBackend[K, V]) Get(_ context.Context, key K) (V, bool, error) {
+func (b *LFUBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	entry, ok, err := b.GetEntry(ctx, key)
+	return entry.Value, ok, err
+}
+
+// GetEntry retrieves the entry for a key and counts the access.
+func (b *LFUBackend[K, V]) GetEntry(_ context.Context, key K) (types.Entry[V], bool, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	if e, ok := b.entries[key]; ok {
-		e.frequency++
-		return e.entry.Value, true, nil
+		b.touch(e)
+		return e.entry.unpack(), true, nil
 	}
-	var zero V
-	return zero, false, nil
+	return types.Entry[V]{}, false, nil
+}
+
+// PeekEntry retrieves the entry for a key without counting an access.
+func (b *LFUBackend[K, V]) PeekEntry(_ context.Context, key K) (types.Entry[V], bool, error) {
+	return peekEntry(&b.mu, b.peek, key)
 }
 
 // Delete removes an entry by key.
 func (b *LFUBackend[K, V]) Delete(_ context.Context, key K) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	delete(b.entries, key)
+	b.remove(key)
+	return nil
+}
+
+// remove deletes an entry. Callers must hold the write lock.
+func (b *LFUBackend[K, V]) remove(key K) {
+	if e, ok := b.entries[key]; ok {
+		b.unlink(e)
+		b.bytes -= e.size
+		delete(b.entries, key)
+		delete(b.pins, key)
+	}
+}
+
+// Txn applies ops in order while holding the backend's lock, so readers
+// never observe a partial transaction.
+func (b *LFUBackend[K, V]) Txn(_ context.Context, ops []types.TxnOp[K, V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, op := range ops {
+		if op.Delete {
+			b.remove(op.Key)
+		} else {
+			b.set(op.Key, op.Entry)
+		}
+	}
 	return nil
 }
 
@@ -93,7 +244,10 @@ func (b *LFUBackend[K, V]) Contains(_ context.Context, key K) (bool, error) {
 func (b *LFUBackend[K, V]) Flush(_ context.Context) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.entries = make(map[K]*lfuEntry[V])
+	b.entries = make(map[K]*lfuEntry[K, V])
+	b.buckets.Init()
+	b.bytes = 0
+	clear(b.pins)
 	return nil
 }
 
@@ -118,12 +272,87 @@ func (b *LFUBackend[K, V]) Keys(_ context.Context) ([]K, error) {
 	return keys, nil
 }
 
+// MemoryUsage estimates the bytes held by entries and the frequency
+// buckets.
+func (b *LFUBackend[K, V]) MemoryUsage(_ context.Context) (types.MemoryUsage, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	bucketSize := listElementSize + int64(reflect.TypeFor[lfuBucket]().Size())
+	usage := types.MemoryUsage{Index: int64(b.buckets.Len())*bucketSize + int64(len(b.pins))*mapSlotSize}
+	for key, e := range b.entries {
+		usage = usage.Add(storedUsage(key, e.entry, mapSlotSize+listElementSize+24))
+	}
+	return usage, nil
+}
+
 // GetEmbedding retrieves the embedding for a key without incrementing frequency.
 func (b *LFUBackend[K, V]) GetEmbedding(_ context.Context, key K) ([]float64, bool, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 	if e, ok := b.entries[key]; ok {
-		return e.entry.Embedding, true, nil
+		return e.entry.embedding(), true, nil
 	}
 	return nil, false, nil
 }
+
+// GetEmbeddings retrieves the embeddings for multiple keys under one lock.
+func (b *LFUBackend[K, V]) GetEmbeddings(_ context.Context, keys []K) (map[K][]float64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	result := make(map[K][]float64, len(keys))
+	for _, key := range keys {
+		if e, ok := b.entries[key]; ok {
+			result[key] = e.entry.embedding()
+		}
+	}
+	return result, nil
+}
+
+// GetVectors returns each stored key's embedding followed by its extra
+// vectors.
+func (b *LFUBackend[K, V]) GetVectors(_ context.Context, keys []K) (map[K][][]float64, error) {
+	return getVectors(&b.mu, b.peek, keys), nil
+}
+
+// EntryStats returns each stored key's creation time and access count, without
+// counting an access.
+func (b *LFUBackend[K, V]) EntryStats(_ context.Context, keys []K) (map[K]types.EntryStats, error) {
+	return getStats(&b.mu, b.peek, b.frequency, keys), nil
+}
+
+// SetIfAbsent stores entry only if key is not already present.
+func (b *LFUBackend[K, V]) SetIfAbsent(_ context.Context, key K, entry types.Entry[V]) (bool, error) {
+	return setIfAbsent(&b.mu, b.peek, noErr(b.set), key, entry)
+}
+
+// CompareAndSwap stores entry only if key's current value is deeply equal
+// to old.
+func (b *LFUBackend[K, V]) CompareAndSwap(_ context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	return compareAndSwap(&b.mu, b.peek, noErr(b.set), key, old, entry)
+}
+
+// peek returns key's entry without counting an access. Callers must hold
+// the lock.
+func (b *LFUBackend[K, V]) peek(key K) (types.Entry[V], bool) {
+	if e, ok := b.entries[key]; ok {
+		return e.entry.unpack(), true
+	}
+	return types.Entry[V]{}, false
+}
+
+// frequency returns key's access count. Callers must hold b.mu.
+func (b *LFUBackend[K, V]) frequency(key K) int {
+	return b.entries[key].frequency()
+}
+
+// Pin keeps key from being evicted until it is unpinned or deleted. Pinned
+// entries do not count toward capacity.
+func (b *LFUBackend[K, V]) Pin(_ context.Context, key K) (bool, error) {
+	return setPinned(&b.mu, b.peek, b.pins, key, true), nil
+}
+
+// Unpin makes key evictable again. If that puts the backend over capacity,
+// the next insert evicts the excess.
+func (b *LFUBackend[K, V]) Unpin(_ context.Context, key K) (bool, error) {
+	return setPinned(&b.mu, b.peek, b.pins, key, false), nil
+}
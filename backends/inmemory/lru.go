@@ -10,43 +10,169 @@ import (
 
 // LRUBackend implements Backend using LRU eviction.
 type LRUBackend[K comparable, V any] struct {
-	mu    sync.RWMutex
-	cache *lru.Cache[K, types.Entry[V]]
+	mu       sync.RWMutex
+	cache    *lru.Cache[K, storedEntry[V]]
+	float16  bool
+	maxBytes int64
+	bytes    int64
+	onEvict  func(K, types.Entry[V])
+	removing bool // set during Delete and Flush so removals are not reported as evictions
+
+	// The underlying LRU holds capacity entries plus the pinned ones.
+	pins     pinSet[K]
+	capacity int
+	size     int
 }
 
 // NewLRUBackend creates a new LRU backend with the given capacity.
-func NewLRUBackend[K comparable, V any](capacity int) (*LRUBackend[K, V], error) {
-	c, err := lru.New[K, types.Entry[V]](capacity)
+func NewLRUBackend[K comparable, V any](capacity int, opts ...Option) (*LRUBackend[K, V], error) {
+	cfg := newConfig(opts)
+	b := &LRUBackend[K, V]{
+		maxBytes: cfg.maxBytes,
+		float16:  cfg.float16,
+		pins:     make(pinSet[K]),
+		capacity: capacity,
+		size:     capacity,
+	}
+	c, err := lru.NewWithEvict(capacity, b.onRemove)
 	if err != nil {
 		return nil, err
 	}
-	return &LRUBackend[K, V]{cache: c}, nil
+	b.cache = c
+	return b, nil
+}
+
+// onRemove is called by the underlying LRU whenever an entry leaves the
+// cache, including explicit removals. Callers hold b.mu.
+func (b *LRUBackend[K, V]) onRemove(key K, s storedEntry[V]) {
+	if b.maxBytes > 0 {
+		b.bytes -= storedSize(key, s)
+	}
+	if b.onEvict != nil && !b.removing {
+		b.onEvict(key, s.unpack())
+	}
+}
+
+// OnEvict registers fn to be called for every entry evicted by capacity or
+// the byte limit.
+func (b *LRUBackend[K, V]) OnEvict(fn func(key K, entry types.Entry[V])) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onEvict = fn
 }
 
 // Set stores a value with its embedding.
-func (b *LRUBackend[K, V]) Set(_ context.Context, key K, embedding []float64, value V) error {
+func (b *LRUBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.SetEntry(ctx, key, types.Entry[V]{Embedding: embedding, Value: value})
+}
+
+// SetEntry stores an entry, including its input text and creation time.
+func (b *LRUBackend[K, V]) SetEntry(_ context.Context, key K, entry types.Entry[V]) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.cache.Add(key, types.Entry[V]{Embedding: embedding, Value: value})
+	b.set(key, entry)
 	return nil
 }
 
+// set stores an entry. Callers must hold the write lock.
+func (b *LRUBackend[K, V]) set(key K, entry types.Entry[V]) {
+	if !b.cache.Contains(key) {
+		b.makeRoom(key)
+	}
+	s := pack(entry, b.float16)
+	if b.maxBytes <= 0 {
+		b.cache.Add(key, s)
+		return
+	}
+
+	if old, ok := b.cache.Peek(key); ok {
+		b.bytes -= storedSize(key, old)
+	}
+	b.cache.Add(key, s)
+	b.bytes += storedSize(key, s)
+	b.evict(key, func() bool { return b.bytes > b.maxBytes })
+}
+
+// makeRoom evicts so that key fits within capacity, which pinned entries
+// do not count toward, and resizes the underlying LRU to match. Callers
+// must hold the write lock.
+func (b *LRUBackend[K, V]) makeRoom(key K) {
+	b.evict(key, func() bool { return b.cache.Len()-len(b.pins) >= b.capacity })
+	if size := b.capacity + len(b.pins); size != b.size {
+		b.size = size
+		b.cache.Resize(size)
+	}
+}
+
+// evict removes the least recently used unpinned entries other than keep
+// while over reports true. Callers must hold the write lock.
+func (b *LRUBackend[K, V]) evict(keep K, over func() bool) {
+	if len(b.pins) == 0 {
+		for over() && b.cache.Len() > 0 {
+			if oldest, _, _ := b.cache.GetOldest(); oldest == keep {
+				return
+			}
+			b.cache.RemoveOldest()
+		}
+		return
+	}
+	for _, k := range b.cache.Keys() {
+		if !over() {
+			return
+		}
+		if k != keep && !b.pins.has(k) {
+			b.cache.Remove(k)
+		}
+	}
+}
+
 // Get retrieves the value for a key.
-func (b *LRUBackend[K, V]) Get(_ context.Context, key K) (V, bool, error) {
+func (b *LRUBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	entry, ok, err := b.GetEntry(ctx, key)
+	return entry.Value, ok, err
+}
+
+// GetEntry retrieves the entry for a key and marks it as recently used.
+func (b *LRUBackend[K, V]) GetEntry(_ context.Context, key K) (types.Entry[V], bool, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	if entry, ok := b.cache.Get(key); ok {
-		return entry.Value, true, nil
-	}
-	var zero V
-	return zero, false, nil
+	s, ok := b.cache.Get(key)
+	return s.unpack(), ok, nil
+}
+
+// PeekEntry retrieves the entry for a key without counting an access.
+func (b *LRUBackend[K, V]) PeekEntry(_ context.Context, key K) (types.Entry[V], bool, error) {
+	return peekEntry(&b.mu, b.peek, key)
 }
 
 // Delete removes an entry by key.
 func (b *LRUBackend[K, V]) Delete(_ context.Context, key K) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.remove(key)
+	return nil
+}
+
+// remove deletes an entry. Callers must hold the write lock.
+func (b *LRUBackend[K, V]) remove(key K) {
+	b.removing = true
 	b.cache.Remove(key)
+	b.removing = false
+	delete(b.pins, key)
+}
+
+// Txn applies ops in order while holding the backend's lock, so readers
+// never observe a partial transaction.
+func (b *LRUBackend[K, V]) Txn(_ context.Context, ops []types.TxnOp[K, V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, op := range ops {
+		if op.Delete {
+			b.remove(op.Key)
+		} else {
+			b.set(op.Key, op.Entry)
+		}
+	}
 	return nil
 }
 
@@ -61,7 +187,10 @@ func (b *LRUBackend[K, V]) Contains(_ context.Context, key K) (bool, error) {
 func (b *LRUBackend[K, V]) Flush(_ context.Context) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
+	b.removing = true
 	b.cache.Purge()
+	b.removing = false
+	clear(b.pins)
 	return nil
 }
 
@@ -82,12 +211,80 @@ func (b *LRUBackend[K, V]) Keys(_ context.Context) ([]K, error) {
 	return b.cache.Keys(), nil
 }
 
+// MemoryUsage estimates the bytes held by entries and the LRU list.
+func (b *LRUBackend[K, V]) MemoryUsage(_ context.Context) (types.MemoryUsage, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	usage := types.MemoryUsage{Index: int64(len(b.pins)) * mapSlotSize}
+	for _, key := range b.cache.Keys() {
+		if s, ok := b.cache.Peek(key); ok {
+			usage = usage.Add(storedUsage(key, s, mapSlotSize+listElementSize))
+		}
+	}
+	return usage, nil
+}
+
 // GetEmbedding retrieves the embedding for a key.
 func (b *LRUBackend[K, V]) GetEmbedding(_ context.Context, key K) ([]float64, bool, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	if entry, ok := b.cache.Peek(key); ok {
-		return entry.Embedding, true, nil
+	if s, ok := b.cache.Peek(key); ok {
+		return s.embedding(), true, nil
 	}
 	return nil, false, nil
 }
+
+// GetEmbeddings retrieves the embeddings for multiple keys under one lock.
+func (b *LRUBackend[K, V]) GetEmbeddings(_ context.Context, keys []K) (map[K][]float64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	result := make(map[K][]float64, len(keys))
+	for _, key := range keys {
+		if s, ok := b.cache.Peek(key); ok {
+			result[key] = s.embedding()
+		}
+	}
+	return result, nil
+}
+
+// GetVectors returns each stored key's embedding followed by its extra
+// vectors.
+func (b *LRUBackend[K, V]) GetVectors(_ context.Context, keys []K) (map[K][][]float64, error) {
+	return getVectors(&b.mu, b.peek, keys), nil
+}
+
+// EntryStats returns each stored key's creation time, without
+// counting an access.
+func (b *LRUBackend[K, V]) EntryStats(_ context.Context, keys []K) (map[K]types.EntryStats, error) {
+	return getStats(&b.mu, b.peek, nil, keys), nil
+}
+
+// SetIfAbsent stores entry only if key is not already present.
+func (b *LRUBackend[K, V]) SetIfAbsent(_ context.Context, key K, entry types.Entry[V]) (bool, error) {
+	return setIfAbsent(&b.mu, b.peek, noErr(b.set), key, entry)
+}
+
+// CompareAndSwap stores entry only if key's current value is deeply equal
+// to old.
+func (b *LRUBackend[K, V]) CompareAndSwap(_ context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	return compareAndSwap(&b.mu, b.peek, noErr(b.set), key, old, entry)
+}
+
+// peek returns key's entry without counting an access. Callers must hold
+// the lock.
+func (b *LRUBackend[K, V]) peek(key K) (types.Entry[V], bool) {
+	s, ok := b.cache.Peek(key)
+	return s.unpack(), ok
+}
+
+// Pin keeps key from being evicted until it is unpinned or deleted. Pinned
+// entries do not count toward capacity.
+func (b *LRUBackend[K, V]) Pin(_ context.Context, key K) (bool, error) {
+	return setPinned(&b.mu, b.peek, b.pins, key, true), nil
+}
+
+// Unpin makes key evictable again. If that puts the backend over capacity,
+// the next insert evicts the excess.
+func (b *LRUBackend[K, V]) Unpin(_ context.Context, key K) (bool, error) {
+	return setPinned(&b.mu, b.peek, b.pins, key, false), nil
+}
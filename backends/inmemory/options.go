@@ -0,0 +1,74 @@
+package inmemory
+
+// Option configures an in-memory backend.
+type Option func(*config)
+
+type config struct {
+	maxBytes int64
+	float16  bool
+
+	hnswM              int
+	hnswEfConstruction int
+	hnswEfSearch       int
+
+	binaryRerank int
+	binaryOnly   bool
+}
+
+func newConfig(opts []Option) config {
+	var c config
+	for _, o := range opts {
+		o(&c)
+	}
+	return c
+}
+
+// WithMaxBytes bounds the backend by the approximate memory footprint of its
+// entries in addition to its entry count. When the estimate exceeds n, the
+// backend evicts entries in its usual order until it fits again. The most
+// recently written entry is never evicted to make room for itself.
+// n <= 0 disables byte accounting.
+func WithMaxBytes(n int64) Option {
+	return func(c *config) { c.maxBytes = n }
+}
+
+// WithFloat16 makes the LRU, LFU, and FIFO backends store embeddings and
+// extra vectors as IEEE float16, a quarter of their float64 size, widening
+// them on every read. Components are rounded to about three significant
+// digits, which barely moves cosine rankings; values beyond ±65504 become
+// infinite, so normalize embeddings that can be that large.
+func WithFloat16() Option {
+	return func(c *config) { c.float16 = true }
+}
+
+// WithHNSWM sets the number of neighbors each HNSW node links to per layer
+// (twice that on the bottom layer). Higher values improve recall at the cost
+// of memory and insert time. Defaults to 16.
+func WithHNSWM(m int) Option {
+	return func(c *config) { c.hnswM = m }
+}
+
+// WithHNSWEfConstruction sets the candidate list size used while inserting
+// into an HNSW graph. Defaults to 200.
+func WithHNSWEfConstruction(ef int) Option {
+	return func(c *config) { c.hnswEfConstruction = ef }
+}
+
+// WithHNSWEfSearch sets the minimum candidate list size used by HNSW
+// queries. Defaults to 64; queries for more results use k instead.
+func WithHNSWEfSearch(ef int) Option {
+	return func(c *config) { c.hnswEfSearch = ef }
+}
+
+// WithBinaryRerank sets how many Hamming-distance candidates a
+// BinaryBackend reranks at full precision per requested result. Higher
+// values improve recall at the cost of latency. Defaults to 4.
+func WithBinaryRerank(factor int) Option {
+	return func(c *config) { c.binaryRerank = factor }
+}
+
+// WithBinaryOnly makes a BinaryBackend keep only the sign bits of each
+// embedding, dropping the full-precision vector and the rerank step.
+func WithBinaryOnly() Option {
+	return func(c *config) { c.binaryOnly = true }
+}
@@ -0,0 +1,33 @@
+package inmemory
+
+import (
+	"sync"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// pinSet holds the keys pinned with Pin. Eviction policies never choose a
+// pinned key, and pinned entries do not count toward capacity. Callers
+// must hold the backend's lock.
+type pinSet[K comparable] map[K]struct{}
+
+func (p pinSet[K]) has(key K) bool {
+	_, ok := p[key]
+	return ok
+}
+
+// setPinned pins or unpins key under mu if peek finds it. It reports
+// whether key exists.
+func setPinned[K comparable, V any](mu *sync.RWMutex, peek func(K) (types.Entry[V], bool), pins pinSet[K], key K, pinned bool) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := peek(key); !ok {
+		return false
+	}
+	if pinned {
+		pins[key] = struct{}{}
+	} else {
+		delete(pins, key)
+	}
+	return true
+}
@@ -0,0 +1,262 @@
+package inmemory
+
+import (
+	"context"
+	"errors"
+	"hash/maphash"
+	"maps"
+	"runtime"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// ErrInvalidShardCapacity is returned when the total capacity is smaller
+// than the number of shards.
+var ErrInvalidShardCapacity = errors.New("inmemory: capacity must be at least the number of shards")
+
+// ShardedBackend implements Backend by spreading keys across independent
+// LRU shards. Each shard has its own lock, so concurrent operations on
+// different keys rarely contend.
+type ShardedBackend[K comparable, V any] struct {
+	seed   maphash.Seed
+	shards []*LRUBackend[K, V]
+}
+
+// NewShardedBackend creates a sharded LRU backend holding up to capacity
+// entries split evenly across shards. shards <= 0 uses runtime.GOMAXPROCS(0).
+// Eviction is per shard, so the least recently used key overall is not
+// always the first to go.
+func NewShardedBackend[K comparable, V any](shards, capacity int) (*ShardedBackend[K, V], error) {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	if capacity < shards {
+		return nil, ErrInvalidShardCapacity
+	}
+	perShard := (capacity + shards - 1) / shards
+
+	b := &ShardedBackend[K, V]{
+		seed:   maphash.MakeSeed(),
+		shards: make([]*LRUBackend[K, V], shards),
+	}
+	for i := range b.shards {
+		s, err := NewLRUBackend[K, V](perShard)
+		if err != nil {
+			return nil, err
+		}
+		b.shards[i] = s
+	}
+	return b, nil
+}
+
+func (b *ShardedBackend[K, V]) shard(key K) *LRUBackend[K, V] {
+	return b.shards[b.shardIndex(key)]
+}
+
+func (b *ShardedBackend[K, V]) shardIndex(key K) int {
+	h := maphash.Comparable(b.seed, key)
+	return int(h % uint64(len(b.shards)))
+}
+
+// Txn applies ops atomically by locking every shard they touch, in shard
+// order so concurrent transactions cannot deadlock.
+func (b *ShardedBackend[K, V]) Txn(_ context.Context, ops []types.TxnOp[K, V]) error {
+	touched := make([]bool, len(b.shards))
+	for _, op := range ops {
+		touched[b.shardIndex(op.Key)] = true
+	}
+	for i, s := range b.shards {
+		if touched[i] {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+		}
+	}
+	for _, op := range ops {
+		s := b.shard(op.Key)
+		if op.Delete {
+			s.remove(op.Key)
+		} else {
+			s.set(op.Key, op.Entry)
+		}
+	}
+	return nil
+}
+
+// OnEvict registers fn on every shard. It may be called concurrently from
+// different shards.
+func (b *ShardedBackend[K, V]) OnEvict(fn func(key K, entry types.Entry[V])) {
+	for _, s := range b.shards {
+		s.OnEvict(fn)
+	}
+}
+
+// Set stores a value with its embedding.
+func (b *ShardedBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.shard(key).Set(ctx, key, embedding, value)
+}
+
+// SetEntry stores an entry, including its input text and creation time.
+func (b *ShardedBackend[K, V]) SetEntry(ctx context.Context, key K, entry types.Entry[V]) error {
+	return b.shard(key).SetEntry(ctx, key, entry)
+}
+
+// Get retrieves the value for a key.
+func (b *ShardedBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	return b.shard(key).Get(ctx, key)
+}
+
+// GetEntry retrieves the entry for a key.
+func (b *ShardedBackend[K, V]) GetEntry(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	return b.shard(key).GetEntry(ctx, key)
+}
+
+// PeekEntry retrieves the entry for a key without counting an access.
+func (b *ShardedBackend[K, V]) PeekEntry(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	return b.shard(key).PeekEntry(ctx, key)
+}
+
+// Delete removes an entry by key.
+func (b *ShardedBackend[K, V]) Delete(ctx context.Context, key K) error {
+	return b.shard(key).Delete(ctx, key)
+}
+
+// Contains checks whether a key exists.
+func (b *ShardedBackend[K, V]) Contains(ctx context.Context, key K) (bool, error) {
+	return b.shard(key).Contains(ctx, key)
+}
+
+// Flush removes all entries.
+func (b *ShardedBackend[K, V]) Flush(ctx context.Context) error {
+	for _, s := range b.shards {
+		if err := s.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of stored entries across all shards.
+func (b *ShardedBackend[K, V]) Len(ctx context.Context) (int, error) {
+	var total int
+	for _, s := range b.shards {
+		n, err := s.Len(ctx)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// Close is a no-op for in-memory backends.
+func (b *ShardedBackend[K, V]) Close() error { return nil }
+
+// Keys returns all keys across all shards.
+func (b *ShardedBackend[K, V]) Keys(ctx context.Context) ([]K, error) {
+	var keys []K
+	for _, s := range b.shards {
+		k, err := s.Keys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k...)
+	}
+	return keys, nil
+}
+
+// MemoryUsage sums the estimates of every shard.
+func (b *ShardedBackend[K, V]) MemoryUsage(ctx context.Context) (types.MemoryUsage, error) {
+	var usage types.MemoryUsage
+	for _, s := range b.shards {
+		u, err := s.MemoryUsage(ctx)
+		if err != nil {
+			return types.MemoryUsage{}, err
+		}
+		usage = usage.Add(u)
+	}
+	return usage, nil
+}
+
+// GetEmbedding retrieves the embedding for a key.
+func (b *ShardedBackend[K, V]) GetEmbedding(ctx context.Context, key K) ([]float64, bool, error) {
+	return b.shard(key).GetEmbedding(ctx, key)
+}
+
+// SetIfAbsent stores entry only if key is not already present.
+func (b *ShardedBackend[K, V]) SetIfAbsent(ctx context.Context, key K, entry types.Entry[V]) (bool, error) {
+	return b.shard(key).SetIfAbsent(ctx, key, entry)
+}
+
+// CompareAndSwap stores entry only if key's current value is deeply equal
+// to old.
+func (b *ShardedBackend[K, V]) CompareAndSwap(ctx context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	return b.shard(key).CompareAndSwap(ctx, key, old, entry)
+}
+
+// GetEmbeddings retrieves the embeddings for multiple keys, locking each
+// shard once.
+func (b *ShardedBackend[K, V]) GetEmbeddings(ctx context.Context, keys []K) (map[K][]float64, error) {
+	byShard := make([][]K, len(b.shards))
+	for _, key := range keys {
+		i := b.shardIndex(key)
+		byShard[i] = append(byShard[i], key)
+	}
+	result := make(map[K][]float64, len(keys))
+	for i, shardKeys := range byShard {
+		if len(shardKeys) == 0 {
+			continue
+		}
+		embeddings, _ := b.shards[i].GetEmbeddings(ctx, shardKeys)
+		maps.Copy(result, embeddings)
+	}
+	return result, nil
+}
+
+// GetVectors returns each stored key's embedding followed by its extra
+// vectors, locking each shard once.
+func (b *ShardedBackend[K, V]) GetVectors(ctx context.Context, keys []K) (map[K][][]float64, error) {
+	byShard := make([][]K, len(b.shards))
+	for _, key := range keys {
+		i := b.shardIndex(key)
+		byShard[i] = append(byShard[i], key)
+	}
+	result := make(map[K][][]float64, len(keys))
+	for i, shardKeys := range byShard {
+		if len(shardKeys) == 0 {
+			continue
+		}
+		vectors, _ := b.shards[i].GetVectors(ctx, shardKeys)
+		maps.Copy(result, vectors)
+	}
+	return result, nil
+}
+
+// EntryStats returns each stored key's creation time, locking each shard
+// once.
+func (b *ShardedBackend[K, V]) EntryStats(ctx context.Context, keys []K) (map[K]types.EntryStats, error) {
+	byShard := make([][]K, len(b.shards))
+	for _, key := range keys {
+		i := b.shardIndex(key)
+		byShard[i] = append(byShard[i], key)
+	}
+	result := make(map[K]types.EntryStats, len(keys))
+	for i, shardKeys := range byShard {
+		if len(shardKeys) == 0 {
+			continue
+		}
+		stats, _ := b.shards[i].EntryStats(ctx, shardKeys)
+		maps.Copy(result, stats)
+	}
+	return result, nil
+}
+
+// Pin keeps key from being evicted by its shard until it is unpinned or
+// deleted.
+func (b *ShardedBackend[K, V]) Pin(ctx context.Context, key K) (bool, error) {
+	return b.shard(key).Pin(ctx, key)
+}
+
+// Unpin makes key evictable again.
+func (b *ShardedBackend[K, V]) Unpin(ctx context.Context, key K) (bool, error) {
+	return b.shard(key).Unpin(ctx, key)
+}
@@ -0,0 +1,102 @@
+package inmemory
+
+import (
+	"reflect"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// maxSizeDepth bounds recursion through pointers and nested containers.
+const maxSizeDepth = 16
+
+// Per-entry bookkeeping estimates for MemoryUsage: a map slot with its
+// hash byte, key, and pointer, and a container/list element.
+const (
+	mapSlotSize     = 48
+	listElementSize = 48
+)
+
+// entryUsage estimates the footprint of key and e, counting overhead bytes
+// of per-entry bookkeeping as index.
+func entryUsage[K comparable, V any](key K, e types.Entry[V], overhead int64) types.MemoryUsage {
+	floats := len(e.Embedding)
+	for _, v := range e.Vectors {
+		floats += len(v)
+	}
+	embeddings := 8 * int64(floats)
+	return types.MemoryUsage{
+		Entries:    1,
+		Embeddings: embeddings,
+		Values:     estimateSize(reflect.ValueOf(key), 0) + estimateSize(reflect.ValueOf(e), 0) - embeddings,
+		Index:      overhead,
+	}
+}
+
+// entrySize estimates the bytes held by a key and its entry.
+func entrySize[K comparable, V any](key K, e types.Entry[V]) int64 {
+	size := int64(24 + 8*len(e.Embedding))
+	size += estimateSize(reflect.ValueOf(key), 0)
+	size += estimateSize(reflect.ValueOf(e.Value), 0)
+	return size
+}
+
+// estimateSize approximates the memory referenced by v. It counts headers
+// and backing arrays for strings, slices, and maps, follows pointers and
+// interfaces, and ignores sharing, so the result is an upper-bound estimate
+// rather than an exact heap measurement.
+func estimateSize(v reflect.Value, depth int) int64 {
+	if !v.IsValid() || depth > maxSizeDepth {
+		return 0
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return int64(v.Type().Size()) + int64(v.Len())
+	case reflect.Slice:
+		size := int64(v.Type().Size())
+		if v.IsNil() {
+			return size
+		}
+		return size + elementsSize(v, depth)
+	case reflect.Array:
+		return elementsSize(v, depth)
+	case reflect.Map:
+		size := int64(v.Type().Size())
+		if v.IsNil() {
+			return size
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			size += estimateSize(iter.Key(), depth+1) + estimateSize(iter.Value(), depth+1)
+		}
+		return size
+	case reflect.Pointer, reflect.Interface:
+		size := int64(v.Type().Size())
+		if v.IsNil() {
+			return size
+		}
+		return size + estimateSize(v.Elem(), depth+1)
+	case reflect.Struct:
+		var size int64
+		for i := 0; i < v.NumField(); i++ {
+			size += estimateSize(v.Field(i), depth+1)
+		}
+		return size
+	default:
+		return int64(v.Type().Size())
+	}
+}
+
+func elementsSize(v reflect.Value, depth int) int64 {
+	elem := v.Type().Elem()
+	switch elem.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return int64(v.Len()) * int64(elem.Size())
+	}
+	var size int64
+	for i := 0; i < v.Len(); i++ {
+		size += estimateSize(v.Index(i), depth+1)
+	}
+	return size
+}
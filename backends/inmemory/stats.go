@@ -0,0 +1,34 @@
+package inmemory
+
+import (
+	"sync"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// getStats returns each found key's stats, reading under mu. accesses
+// reports a key's access count, or is nil when the backend keeps none.
+func getStats[K comparable, V any](mu *sync.RWMutex, peek func(K) (types.Entry[V], bool), accesses func(K) int, keys []K) map[K]types.EntryStats {
+	mu.RLock()
+	defer mu.RUnlock()
+	result := make(map[K]types.EntryStats, len(keys))
+	for _, key := range keys {
+		if entry, ok := peek(key); ok {
+			stats := types.EntryStats{CreatedAt: entry.CreatedAt}
+			if accesses != nil {
+				stats.Accesses = accesses(key)
+			}
+			result[key] = stats
+		}
+	}
+	return result
+}
+
+// peekEntry returns key's entry, reading under mu without counting an
+// access.
+func peekEntry[K comparable, V any](mu *sync.RWMutex, peek func(K) (types.Entry[V], bool), key K) (types.Entry[V], bool, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	entry, ok := peek(key)
+	return entry, ok, nil
+}
@@ -0,0 +1,455 @@
+package inmemory
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"hash/maphash"
+	"sync"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// ErrInvalidCapacity is returned when a backend that requires a bounded size
+// is given a non-positive capacity.
+var ErrInvalidCapacity = errors.New("inmemory: capacity must be positive")
+
+const (
+	segmentWindow uint8 = iota
+	segmentProbation
+	segmentProtected
+	segmentPinned
+)
+
+type tinyLFUEntry[K comparable, V any] struct {
+	key     K
+	entry   types.Entry[V]
+	segment uint8
+}
+
+// TinyLFUBackend implements Backend using the W-TinyLFU admission policy.
+//
+// New entries land in a small LRU window (1% of capacity). When the window
+// overflows, its oldest entry competes with the main cache's eviction
+// candidate and only the one with the higher estimated access frequency is
+// kept. The main cache is a segmented LRU (20% probation, 80% protected).
+// Frequencies are tracked in a count-min sketch that is periodically halved
+// so old popularity fades. Every operation is O(1).
+//
+// Pinned entries move to a segment of their own, outside the window and
+// main cache, where they neither count toward capacity nor compete for
+// admission.
+type TinyLFUBackend[K comparable, V any] struct {
+	mu        sync.RWMutex
+	entries   map[K]*list.Element
+	window    *list.List
+	probation *list.List
+	protected *list.List
+	pinned    *list.List
+	sketch    *countMinSketch
+	seed      maphash.Seed
+
+	windowCap    int
+	protectedCap int
+	mainCap      int
+
+	onEvict func(K, types.Entry[V])
+}
+
+// NewTinyLFUBackend creates a new W-TinyLFU backend with the given capacity.
+func NewTinyLFUBackend[K comparable, V any](capacity int) (*TinyLFUBackend[K, V], error) {
+	if capacity <= 0 {
+		return nil, ErrInvalidCapacity
+	}
+	windowCap := max(1, capacity/100)
+	mainCap := capacity - windowCap
+	return &TinyLFUBackend[K, V]{
+		entries:      make(map[K]*list.Element, capacity),
+		window:       list.New(),
+		probation:    list.New(),
+		protected:    list.New(),
+		pinned:       list.New(),
+		sketch:       newCountMinSketch(capacity),
+		seed:         maphash.MakeSeed(),
+		windowCap:    windowCap,
+		mainCap:      mainCap,
+		protectedCap: mainCap * 8 / 10,
+	}, nil
+}
+
+func (b *TinyLFUBackend[K, V]) hash(key K) uint64 {
+	return maphash.Comparable(b.seed, key)
+}
+
+// Set stores a value with its embedding.
+func (b *TinyLFUBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.SetEntry(ctx, key, types.Entry[V]{Embedding: embedding, Value: value})
+}
+
+// SetEntry stores an entry, including its input text and creation time.
+func (b *TinyLFUBackend[K, V]) SetEntry(_ context.Context, key K, entry types.Entry[V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.set(key, entry)
+	return nil
+}
+
+// set stores an entry. Callers must hold the write lock.
+func (b *TinyLFUBackend[K, V]) set(key K, entry types.Entry[V]) {
+	b.sketch.increment(b.hash(key))
+
+	if el, ok := b.entries[key]; ok {
+		el.Value.(*tinyLFUEntry[K, V]).entry = entry
+		b.touch(el)
+		return
+	}
+
+	b.entries[key] = b.window.PushFront(&tinyLFUEntry[K, V]{key: key, entry: entry, segment: segmentWindow})
+	if b.window.Len() > b.windowCap {
+		b.admit(b.window.Back())
+	}
+}
+
+// admit moves the window's oldest entry into the main cache if it is more
+// popular than the main cache's eviction candidate, and evicts the loser.
+func (b *TinyLFUBackend[K, V]) admit(candidate *list.Element) {
+	ce := b.window.Remove(candidate).(*tinyLFUEntry[K, V])
+	if b.probation.Len()+b.protected.Len() < b.mainCap {
+		ce.segment = segmentProbation
+		b.entries[ce.key] = b.probation.PushFront(ce)
+		return
+	}
+
+	victim := b.probation.Back()
+	victimList := b.probation
+	if victim == nil {
+		victim = b.protected.Back()
+		victimList = b.protected
+	}
+	if victim == nil {
+		b.evict(ce)
+		return
+	}
+
+	ve := victim.Value.(*tinyLFUEntry[K, V])
+	if b.sketch.estimate(b.hash(ce.key)) > b.sketch.estimate(b.hash(ve.key)) {
+		victimList.Remove(victim)
+		b.evict(ve)
+		ce.segment = segmentProbation
+		b.entries[ce.key] = b.probation.PushFront(ce)
+		return
+	}
+	b.evict(ce)
+}
+
+// evict drops an entry that has already been unlinked from its segment.
+func (b *TinyLFUBackend[K, V]) evict(e *tinyLFUEntry[K, V]) {
+	delete(b.entries, e.key)
+	if b.onEvict != nil {
+		b.onEvict(e.key, e.entry)
+	}
+}
+
+// OnEvict registers fn to be called for every entry evicted or rejected by
+// the admission policy.
+func (b *TinyLFUBackend[K, V]) OnEvict(fn func(key K, entry types.Entry[V])) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onEvict = fn
+}
+
+// touch records a hit on an existing element, promoting probation entries
+// into the protected segment.
+func (b *TinyLFUBackend[K, V]) touch(el *list.Element) {
+	e := el.Value.(*tinyLFUEntry[K, V])
+	switch e.segment {
+	case segmentWindow:
+		b.window.MoveToFront(el)
+	case segmentProtected:
+		b.protected.MoveToFront(el)
+	case segmentPinned:
+	case segmentProbation:
+		b.probation.Remove(el)
+		e.segment = segmentProtected
+		b.entries[e.key] = b.protected.PushFront(e)
+		if b.protected.Len() > b.protectedCap {
+			demoted := b.protected.Remove(b.protected.Back()).(*tinyLFUEntry[K, V])
+			demoted.segment = segmentProbation
+			b.entries[demoted.key] = b.probation.PushFront(demoted)
+		}
+	}
+}
+
+func (b *TinyLFUBackend[K, V]) listFor(segment uint8) *list.List {
+	switch segment {
+	case segmentWindow:
+		return b.window
+	case segmentProbation:
+		return b.probation
+	case segmentPinned:
+		return b.pinned
+	default:
+		return b.protected
+	}
+}
+
+// Get retrieves the value for a key and records the access.
+func (b *TinyLFUBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	entry, ok, err := b.GetEntry(ctx, key)
+	return entry.Value, ok, err
+}
+
+// GetEntry retrieves the entry for a key and counts the access.
+func (b *TinyLFUBackend[K, V]) GetEntry(_ context.Context, key K) (types.Entry[V], bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sketch.increment(b.hash(key))
+	if el, ok := b.entries[key]; ok {
+		b.touch(el)
+		return el.Value.(*tinyLFUEntry[K, V]).entry, true, nil
+	}
+	return types.Entry[V]{}, false, nil
+}
+
+// PeekEntry retrieves the entry for a key without counting an access.
+func (b *TinyLFUBackend[K, V]) PeekEntry(_ context.Context, key K) (types.Entry[V], bool, error) {
+	return peekEntry(&b.mu, b.peek, key)
+}
+
+// Delete removes an entry by key.
+func (b *TinyLFUBackend[K, V]) Delete(_ context.Context, key K) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(key)
+	return nil
+}
+
+// remove deletes an entry. Callers must hold the write lock.
+func (b *TinyLFUBackend[K, V]) remove(key K) {
+	if el, ok := b.entries[key]; ok {
+		b.listFor(el.Value.(*tinyLFUEntry[K, V]).segment).Remove(el)
+		delete(b.entries, key)
+	}
+}
+
+// Txn applies ops in order while holding the backend's lock, so readers
+// never observe a partial transaction.
+func (b *TinyLFUBackend[K, V]) Txn(_ context.Context, ops []types.TxnOp[K, V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, op := range ops {
+		if op.Delete {
+			b.remove(op.Key)
+		} else {
+			b.set(op.Key, op.Entry)
+		}
+	}
+	return nil
+}
+
+// Contains checks whether a key exists without recording an access.
+func (b *TinyLFUBackend[K, V]) Contains(_ context.Context, key K) (bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.entries[key]
+	return ok, nil
+}
+
+// Flush removes all entries and resets the frequency sketch.
+func (b *TinyLFUBackend[K, V]) Flush(_ context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = make(map[K]*list.Element, b.windowCap+b.mainCap)
+	b.window.Init()
+	b.probation.Init()
+	b.protected.Init()
+	b.pinned.Init()
+	b.sketch.clear()
+	return nil
+}
+
+// Len returns the number of stored entries.
+func (b *TinyLFUBackend[K, V]) Len(_ context.Context) (int, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.entries), nil
+}
+
+// Close is a no-op for in-memory backends.
+func (b *TinyLFUBackend[K, V]) Close() error { return nil }
+
+// Keys returns all keys in the cache.
+func (b *TinyLFUBackend[K, V]) Keys(_ context.Context) ([]K, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	keys := make([]K, 0, len(b.entries))
+	for k := range b.entries {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// MemoryUsage estimates the bytes held by entries, the segment lists, and
+// the frequency sketch.
+func (b *TinyLFUBackend[K, V]) MemoryUsage(_ context.Context) (types.MemoryUsage, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var usage types.MemoryUsage
+	for _, row := range b.sketch.rows {
+		usage.Index += int64(len(row))
+	}
+	for _, el := range b.entries {
+		e := el.Value.(*tinyLFUEntry[K, V])
+		usage = usage.Add(entryUsage(e.key, e.entry, mapSlotSize+listElementSize+8))
+	}
+	return usage, nil
+}
+
+// GetEmbedding retrieves the embedding for a key without recording an access.
+func (b *TinyLFUBackend[K, V]) GetEmbedding(_ context.Context, key K) ([]float64, bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if el, ok := b.entries[key]; ok {
+		return el.Value.(*tinyLFUEntry[K, V]).entry.Embedding, true, nil
+	}
+	return nil, false, nil
+}
+
+// GetEmbeddings retrieves the embeddings for multiple keys under one lock.
+func (b *TinyLFUBackend[K, V]) GetEmbeddings(_ context.Context, keys []K) (map[K][]float64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	result := make(map[K][]float64, len(keys))
+	for _, key := range keys {
+		if el, ok := b.entries[key]; ok {
+			result[key] = el.Value.(*tinyLFUEntry[K, V]).entry.Embedding
+		}
+	}
+	return result, nil
+}
+
+// GetVectors returns each stored key's embedding followed by its extra
+// vectors.
+func (b *TinyLFUBackend[K, V]) GetVectors(_ context.Context, keys []K) (map[K][][]float64, error) {
+	return getVectors(&b.mu, b.peek, keys), nil
+}
+
+// SetIfAbsent stores entry only if key is not already present.
+func (b *TinyLFUBackend[K, V]) SetIfAbsent(_ context.Context, key K, entry types.Entry[V]) (bool, error) {
+	return setIfAbsent(&b.mu, b.peek, noErr(b.set), key, entry)
+}
+
+// CompareAndSwap stores entry only if key's current value is deeply equal
+// to old.
+func (b *TinyLFUBackend[K, V]) CompareAndSwap(_ context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	return compareAndSwap(&b.mu, b.peek, noErr(b.set), key, old, entry)
+}
+
+// peek returns key's entry without counting an access. Callers must hold
+// the lock.
+func (b *TinyLFUBackend[K, V]) peek(key K) (types.Entry[V], bool) {
+	if el, ok := b.entries[key]; ok {
+		return el.Value.(*tinyLFUEntry[K, V]).entry, true
+	}
+	return types.Entry[V]{}, false
+}
+
+// Pin moves key into the pinned segment, where it is never evicted until it
+// is unpinned or deleted.
+func (b *TinyLFUBackend[K, V]) Pin(_ context.Context, key K) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	el, ok := b.entries[key]
+	if !ok {
+		return false, nil
+	}
+	if e := el.Value.(*tinyLFUEntry[K, V]); e.segment != segmentPinned {
+		b.listFor(e.segment).Remove(el)
+		e.segment = segmentPinned
+		b.entries[key] = b.pinned.PushFront(e)
+	}
+	return true, nil
+}
+
+// Unpin returns key to the admission window as if it had just been
+// written, so it competes for a place in the main cache again.
+func (b *TinyLFUBackend[K, V]) Unpin(_ context.Context, key K) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	el, ok := b.entries[key]
+	if !ok {
+		return false, nil
+	}
+	if e := el.Value.(*tinyLFUEntry[K, V]); e.segment == segmentPinned {
+		b.pinned.Remove(el)
+		e.segment = segmentWindow
+		b.entries[key] = b.window.PushFront(e)
+		if b.window.Len() > b.windowCap {
+			b.admit(b.window.Back())
+		}
+	}
+	return true, nil
+}
+
+// countMinSketch estimates access frequencies with 4-bit saturating counters.
+// After sampleSize increments every counter is halved.
+type countMinSketch struct {
+	rows       [4][]uint8
+	mask       uint64
+	additions  int
+	sampleSize int
+}
+
+func newCountMinSketch(capacity int) *countMinSketch {
+	width := 16
+	for width < capacity {
+		width <<= 1
+	}
+	s := &countMinSketch{mask: uint64(width - 1), sampleSize: 10 * capacity}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+func (s *countMinSketch) index(h uint64, row int) uint64 {
+	h += uint64(row) * (h>>32 | 1) * 0x9e3779b97f4a7c15
+	return (h ^ h>>29) & s.mask
+}
+
+func (s *countMinSketch) increment(h uint64) {
+	for i := range s.rows {
+		idx := s.index(h, i)
+		if s.rows[i][idx] < 15 {
+			s.rows[i][idx]++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+func (s *countMinSketch) estimate(h uint64) uint8 {
+	est := uint8(15)
+	for i := range s.rows {
+		est = min(est, s.rows[i][s.index(h, i)])
+	}
+	return est
+}
+
+func (s *countMinSketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
+
+func (s *countMinSketch) clear() {
+	for i := range s.rows {
+		clear(s.rows[i])
+	}
+	s.additions = 0
+}
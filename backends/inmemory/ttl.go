@@ -0,0 +1,411 @@
+package inmemory
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// ErrInvalidTTL is returned when a non-positive TTL is provided.
+var ErrInvalidTTL = errors.New("inmemory: ttl must be positive")
+
+type ttlEntry[K comparable, V any] struct {
+	key       K
+	entry     types.Entry[V]
+	expiresAt time.Time // zero once persisted
+}
+
+// expired reports whether e has expired at now. Persisted entries never do.
+func (e *ttlEntry[K, V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// TTLBackend implements Backend with per-entry expiration. Every entry lives
+// for ttl after its last Set, unless Expire or Persist changes that. Expired
+// entries are invisible to reads as soon as they expire and are removed by
+// the next write or by a background sweeper that runs until Close.
+//
+// With a positive capacity the backend also evicts the unpinned entry
+// closest to expiring when full. Pinned entries still expire.
+type TTLBackend[K comparable, V any] struct {
+	mu       sync.RWMutex
+	entries  map[K]*list.Element
+	order    *list.List // front expires first, persisted entries last
+	ttl      time.Duration
+	capacity int
+	now      func() time.Time
+	onEvict  func(K, types.Entry[V])
+	pins     pinSet[K]
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewTTLBackend creates a TTL backend. capacity <= 0 means unbounded.
+// The sweeper runs every ttl/2.
+func NewTTLBackend[K comparable, V any](capacity int, ttl time.Duration) (*TTLBackend[K, V], error) {
+	if ttl <= 0 {
+		return nil, ErrInvalidTTL
+	}
+	b := &TTLBackend[K, V]{
+		entries:  make(map[K]*list.Element),
+		order:    list.New(),
+		ttl:      ttl,
+		capacity: capacity,
+		now:      time.Now,
+		pins:     make(pinSet[K]),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go b.sweep(max(ttl/2, time.Millisecond))
+	return b, nil
+}
+
+func (b *TTLBackend[K, V]) sweep(interval time.Duration) {
+	defer close(b.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			b.removeExpired()
+			b.mu.Unlock()
+		}
+	}
+}
+
+// removeExpired drops expired entries from the front of the order list.
+// Callers must hold the write lock.
+func (b *TTLBackend[K, V]) removeExpired() {
+	now := b.now()
+	for el := b.order.Front(); el != nil; el = b.order.Front() {
+		if !el.Value.(*ttlEntry[K, V]).expired(now) {
+			return
+		}
+		b.evict(el)
+	}
+}
+
+// evict removes an element and reports it to the eviction callback.
+// Callers must hold the write lock.
+func (b *TTLBackend[K, V]) evict(el *list.Element) {
+	e := b.order.Remove(el).(*ttlEntry[K, V])
+	delete(b.entries, e.key)
+	delete(b.pins, e.key)
+	if b.onEvict != nil {
+		b.onEvict(e.key, e.entry)
+	}
+}
+
+// OnEvict registers fn to be called for every entry that expires or is
+// evicted by capacity. Expired entries are reported when they are removed,
+// which may be some time after they became invisible to reads.
+func (b *TTLBackend[K, V]) OnEvict(fn func(key K, entry types.Entry[V])) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onEvict = fn
+}
+
+// lookup returns the live entry for key. Callers must hold at least the
+// read lock.
+func (b *TTLBackend[K, V]) lookup(key K) (*ttlEntry[K, V], bool) {
+	el, ok := b.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*ttlEntry[K, V])
+	if e.expired(b.now()) {
+		return nil, false
+	}
+	return e, true
+}
+
+// insert adds e to the order list, keeping it sorted by expiry with
+// persisted entries last. Callers must hold the write lock.
+func (b *TTLBackend[K, V]) insert(e *ttlEntry[K, V]) *list.Element {
+	if e.expiresAt.IsZero() {
+		return b.order.PushBack(e)
+	}
+	for el := b.order.Back(); el != nil; el = el.Prev() {
+		o := el.Value.(*ttlEntry[K, V])
+		if !o.expiresAt.IsZero() && !e.expiresAt.Before(o.expiresAt) {
+			return b.order.InsertAfter(e, el)
+		}
+	}
+	return b.order.PushFront(e)
+}
+
+// Set stores a value with its embedding and resets its expiry.
+func (b *TTLBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.SetEntry(ctx, key, types.Entry[V]{Embedding: embedding, Value: value})
+}
+
+// SetEntry stores an entry, including its input text and creation time.
+func (b *TTLBackend[K, V]) SetEntry(_ context.Context, key K, entry types.Entry[V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.set(key, entry)
+	return nil
+}
+
+// set stores an entry. Callers must hold the write lock.
+func (b *TTLBackend[K, V]) set(key K, entry types.Entry[V]) {
+	e := &ttlEntry[K, V]{
+		key:       key,
+		entry:     entry,
+		expiresAt: b.now().Add(b.ttl),
+	}
+	if el, ok := b.entries[key]; ok {
+		b.order.Remove(el)
+	} else {
+		b.removeExpired()
+		b.evictUnpinned()
+	}
+	b.entries[key] = b.insert(e)
+}
+
+// evictUnpinned evicts the unpinned entries closest to expiring until a new
+// entry fits. Callers must hold the write lock.
+func (b *TTLBackend[K, V]) evictUnpinned() {
+	el := b.order.Front()
+	for b.capacity > 0 && len(b.entries)-len(b.pins) >= b.capacity && el != nil {
+		next := el.Next()
+		if !b.pins.has(el.Value.(*ttlEntry[K, V]).key) {
+			b.evict(el)
+		}
+		el = next
+	}
+}
+
+// Expire sets key to expire after ttl, replacing its current expiry. A
+// non-positive ttl deletes the entry. It reports whether the key existed.
+func (b *TTLBackend[K, V]) Expire(_ context.Context, key K, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.lookup(key)
+	if !ok {
+		return false, nil
+	}
+	if ttl <= 0 {
+		b.remove(key)
+		return true, nil
+	}
+	b.reschedule(e, b.now().Add(ttl))
+	return true, nil
+}
+
+// Persist removes key's expiry so it lives until deleted or evicted by
+// capacity, which evicts persisted entries last. It reports whether the
+// key existed.
+func (b *TTLBackend[K, V]) Persist(_ context.Context, key K) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.lookup(key)
+	if ok {
+		b.reschedule(e, time.Time{})
+	}
+	return ok, nil
+}
+
+// Touch restarts key's ttl without rewriting it. Persisted entries stay
+// persisted. It reports whether the key existed.
+func (b *TTLBackend[K, V]) Touch(_ context.Context, key K) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.lookup(key)
+	if ok && !e.expiresAt.IsZero() {
+		b.reschedule(e, b.now().Add(b.ttl))
+	}
+	return ok, nil
+}
+
+// reschedule moves e to its place for a new expiry. Callers must hold the
+// write lock.
+func (b *TTLBackend[K, V]) reschedule(e *ttlEntry[K, V], expiresAt time.Time) {
+	b.order.Remove(b.entries[e.key])
+	e.expiresAt = expiresAt
+	b.entries[e.key] = b.insert(e)
+}
+
+// Get retrieves the value for a key.
+func (b *TTLBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	entry, ok, err := b.GetEntry(ctx, key)
+	return entry.Value, ok, err
+}
+
+// GetEntry retrieves the entry for an unexpired key.
+func (b *TTLBackend[K, V]) GetEntry(_ context.Context, key K) (types.Entry[V], bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if e, ok := b.lookup(key); ok {
+		return e.entry, true, nil
+	}
+	return types.Entry[V]{}, false, nil
+}
+
+// Delete removes an entry by key.
+func (b *TTLBackend[K, V]) Delete(_ context.Context, key K) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(key)
+	return nil
+}
+
+// remove deletes an entry. Callers must hold the write lock.
+func (b *TTLBackend[K, V]) remove(key K) {
+	if el, ok := b.entries[key]; ok {
+		b.order.Remove(el)
+		delete(b.entries, key)
+		delete(b.pins, key)
+	}
+}
+
+// Txn applies ops in order while holding the backend's lock, so readers
+// never observe a partial transaction.
+func (b *TTLBackend[K, V]) Txn(_ context.Context, ops []types.TxnOp[K, V]) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, op := range ops {
+		if op.Delete {
+			b.remove(op.Key)
+		} else {
+			b.set(op.Key, op.Entry)
+		}
+	}
+	return nil
+}
+
+// Contains checks whether a live key exists.
+func (b *TTLBackend[K, V]) Contains(_ context.Context, key K) (bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.lookup(key)
+	return ok, nil
+}
+
+// Flush removes all entries.
+func (b *TTLBackend[K, V]) Flush(_ context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = make(map[K]*list.Element)
+	b.order.Init()
+	clear(b.pins)
+	return nil
+}
+
+// Len returns the number of live entries.
+func (b *TTLBackend[K, V]) Len(_ context.Context) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeExpired()
+	return len(b.entries), nil
+}
+
+// Close stops the background sweeper. It is safe to call more than once.
+func (b *TTLBackend[K, V]) Close() error {
+	b.stopOnce.Do(func() {
+		close(b.stop)
+		<-b.done
+	})
+	return nil
+}
+
+// Keys returns all live keys in the cache.
+func (b *TTLBackend[K, V]) Keys(_ context.Context) ([]K, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.removeExpired()
+	keys := make([]K, 0, len(b.entries))
+	for k := range b.entries {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// MemoryUsage estimates the bytes held by entries and the expiry list.
+func (b *TTLBackend[K, V]) MemoryUsage(_ context.Context) (types.MemoryUsage, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	usage := types.MemoryUsage{Index: int64(len(b.pins)) * mapSlotSize}
+	for el := b.order.Front(); el != nil; el = el.Next() {
+		e := el.Value.(*ttlEntry[K, V])
+		usage = usage.Add(entryUsage(e.key, e.entry, mapSlotSize+listElementSize+24))
+	}
+	return usage, nil
+}
+
+// GetEmbedding retrieves the embedding for a key.
+func (b *TTLBackend[K, V]) GetEmbedding(_ context.Context, key K) ([]float64, bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if e, ok := b.lookup(key); ok {
+		return e.entry.Embedding, true, nil
+	}
+	return nil, false, nil
+}
+
+// GetEmbeddings retrieves the embeddings for multiple keys under one lock.
+func (b *TTLBackend[K, V]) GetEmbeddings(_ context.Context, keys []K) (map[K][]float64, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	result := make(map[K][]float64, len(keys))
+	for _, key := range keys {
+		if e, ok := b.lookup(key); ok {
+			result[key] = e.entry.Embedding
+		}
+	}
+	return result, nil
+}
+
+// GetVectors returns each stored key's embedding followed by its extra
+// vectors.
+func (b *TTLBackend[K, V]) GetVectors(_ context.Context, keys []K) (map[K][][]float64, error) {
+	return getVectors(&b.mu, b.peek, keys), nil
+}
+
+// EntryStats returns each stored key's creation time, without
+// counting an access.
+func (b *TTLBackend[K, V]) EntryStats(_ context.Context, keys []K) (map[K]types.EntryStats, error) {
+	return getStats(&b.mu, b.peek, nil, keys), nil
+}
+
+// SetIfAbsent stores entry only if key is not already present.
+func (b *TTLBackend[K, V]) SetIfAbsent(_ context.Context, key K, entry types.Entry[V]) (bool, error) {
+	return setIfAbsent(&b.mu, b.peek, noErr(b.set), key, entry)
+}
+
+// CompareAndSwap stores entry only if key's current value is deeply equal
+// to old.
+func (b *TTLBackend[K, V]) CompareAndSwap(_ context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	return compareAndSwap(&b.mu, b.peek, noErr(b.set), key, old, entry)
+}
+
+// peek returns key's entry without counting an access. Callers must hold
+// the lock.
+func (b *TTLBackend[K, V]) peek(key K) (types.Entry[V], bool) {
+	if e, ok := b.lookup(key); ok {
+		return e.entry, true
+	}
+	return types.Entry[V]{}, false
+}
+
+// Pin keeps key from being evicted by capacity until it is unpinned or
+// deleted. Pinned entries do not count toward capacity and still expire;
+// Persist them to keep them indefinitely.
+func (b *TTLBackend[K, V]) Pin(_ context.Context, key K) (bool, error) {
+	return setPinned(&b.mu, b.peek, b.pins, key, true), nil
+}
+
+// Unpin makes key evictable again. If that puts the backend over capacity,
+// the next insert evicts the excess.
+func (b *TTLBackend[K, V]) Unpin(_ context.Context, key K) (bool, error) {
+	return setPinned(&b.mu, b.peek, b.pins, key, false), nil
+}
@@ -0,0 +1,21 @@
+package inmemory
+
+import (
+	"sync"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// getVectors returns each found key's embedding followed by its extra
+// vectors, reading under mu.
+func getVectors[K comparable, V any](mu *sync.RWMutex, peek func(K) (types.Entry[V], bool), keys []K) map[K][][]float64 {
+	mu.RLock()
+	defer mu.RUnlock()
+	result := make(map[K][][]float64, len(keys))
+	for _, key := range keys {
+		if entry, ok := peek(key); ok {
+			result[key] = append([][]float64{entry.Embedding}, entry.Vectors...)
+		}
+	}
+	return result
+}
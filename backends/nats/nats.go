@@ -0,0 +1,498 @@
+// Package nats provides a cache backend on a NATS JetStream KV bucket, for
+// teams already running NATS. It is its own module, so only programs that
+// use it depend on the NATS client.
+package nats
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"iter"
+	"sync"
+	"time"
+
+	"github.com/botirk38/semanticcache/backends/remote"
+	"github.com/botirk38/semanticcache/types"
+	lru "github.com/hashicorp/golang-lru/v2"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Option configures a Backend.
+type Option func(*config)
+
+type config struct {
+	bucket      string
+	ttl         time.Duration
+	localSize   int
+	connOptions []natsgo.Option
+	codec       remote.ValueCodec
+	keyCodec    any // remote.KeyCodec[K]; checked by NewBackend
+}
+
+// WithBucket sets the KV bucket name. Defaults to "semanticcache".
+// The bucket is created if it does not exist.
+func WithBucket(bucket string) Option {
+	return func(c *config) { c.bucket = bucket }
+}
+
+// WithTTL sets the bucket-wide TTL used when the bucket is created.
+// Existing buckets keep their configuration.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) { c.ttl = ttl }
+}
+
+// WithLocalCache sets how many decoded entries each instance keeps in
+// memory. Entries are invalidated by a bucket watcher whenever any instance
+// writes or deletes the key. Defaults to 10000; zero disables the local
+// cache and the watcher.
+func WithLocalCache(size int) Option {
+	return func(c *config) { c.localSize = size }
+}
+
+// WithValueCodec sets how values are encoded. Defaults to remote.MsgpackCodec,
+// which stores the value inline in the msgpack document. Other codecs store
+// it as a msgpack binary field.
+func WithValueCodec(codec remote.ValueCodec) Option {
+	return func(c *config) { c.codec = codec }
+}
+
+// WithKeyCodec sets how keys are turned into strings before base64url
+// encoding. Defaults to remote.DefaultKeyCodec.
+func WithKeyCodec[K comparable](codec remote.KeyCodec[K]) Option {
+	return func(c *config) { c.keyCodec = codec }
+}
+
+// WithConnOptions passes options such as credentials or TLS settings to
+// nats.Connect.
+func WithConnOptions(opts ...natsgo.Option) Option {
+	return func(c *config) { c.connOptions = append(c.connOptions, opts...) }
+}
+
+// Backend implements types.Backend on a NATS JetStream KV bucket. Entries are
+// stored as msgpack blobs holding the key, value, and embedding.
+type Backend[K comparable, V any] struct {
+	conn  *natsgo.Conn
+	kv    jetstream.KeyValue
+	codec remote.ValueCodec
+	keys  remote.KeyCodec[K]
+
+	local   *lru.Cache[string, cached[V]]
+	watcher jetstream.KeyWatcher
+	wg      sync.WaitGroup
+}
+
+var (
+	_ types.KeyIterator[string, string]  = (*Backend[string, string])(nil)
+	_ types.EntryBackend[string, string] = (*Backend[string, string])(nil)
+
+	_ types.ConditionalBackend[string, string] = (*Backend[string, string])(nil)
+)
+
+// document is the stored msgpack layout. Value holds the value's
+// msgpack encoding, or a binary field of the codec's bytes for other codecs.
+type document struct {
+	Key       string             `msgpack:"key"`
+	Value     msgpack.RawMessage `msgpack:"value"`
+	Embedding []float64          `msgpack:"embedding"`
+	InputText string             `msgpack:"input_text,omitempty"`
+	CreatedAt time.Time          `msgpack:"created_at,omitempty"`
+	Metadata  map[string]string  `msgpack:"metadata,omitempty"`
+	Version   string             `msgpack:"embedding_version,omitempty"`
+}
+
+type cached[V any] struct {
+	revision uint64
+	entry    types.Entry[V]
+}
+
+// NewBackend connects to the NATS server at url and opens (or creates)
+// the KV bucket.
+func NewBackend[K comparable, V any](url string, opts ...Option) (*Backend[K, V], error) {
+	cfg := &config{bucket: "semanticcache", localSize: 10000, codec: remote.MsgpackCodec{}}
+	for _, o := range opts {
+		o(cfg)
+	}
+	if cfg.codec == nil {
+		return nil, errors.New("nats: value codec is nil")
+	}
+	keys, err := keyCodecFor[K](cfg.keyCodec)
+	if err != nil {
+		return nil, err
+	}
+
+	nc, err := natsgo.Connect(url, cfg.connOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+
+	ctx := context.Background()
+	kv, err := js.KeyValue(ctx, cfg.bucket)
+	if errors.Is(err, jetstream.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(ctx, jetstream.KeyValueConfig{Bucket: cfg.bucket, TTL: cfg.ttl})
+	}
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to open NATS KV bucket: %w", err)
+	}
+
+	b := &Backend[K, V]{conn: nc, kv: kv, codec: cfg.codec, keys: keys}
+	if cfg.localSize <= 0 {
+		return b, nil
+	}
+
+	b.local, err = lru.New[string, cached[V]](cfg.localSize)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	b.watcher, err = kv.WatchAll(ctx, jetstream.UpdatesOnly(), jetstream.MetaOnly())
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("failed to watch NATS KV bucket: %w", err)
+	}
+	b.wg.Add(1)
+	go b.invalidate()
+	return b, nil
+}
+
+// invalidate drops local copies of keys changed by any instance.
+func (b *Backend[K, V]) invalidate() {
+	defer b.wg.Done()
+	for e := range b.watcher.Updates() {
+		if e == nil {
+			continue
+		}
+		if c, ok := b.local.Peek(e.Key()); ok && c.revision < e.Revision() {
+			b.local.Remove(e.Key())
+		}
+	}
+}
+
+// keyString encodes a key with the key codec, then base64url so any key is
+// a valid NATS subject token.
+func (b *Backend[K, V]) keyString(key K) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(b.keys.EncodeKey(key)))
+}
+
+func (b *Backend[K, V]) load(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	k := b.keyString(key)
+	if b.local != nil {
+		if c, ok := b.local.Get(k); ok {
+			return c.entry, true, nil
+		}
+	}
+
+	kve, err := b.kv.Get(ctx, k)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return types.Entry[V]{}, false, nil
+	}
+	if err != nil {
+		return types.Entry[V]{}, false, fmt.Errorf("failed to get entry from NATS: %w", err)
+	}
+	var doc document
+	if err := msgpack.Unmarshal(kve.Value(), &doc); err != nil {
+		return types.Entry[V]{}, false, fmt.Errorf("failed to unmarshal entry: %w", err)
+	}
+	value, err := b.decodeValue(doc.Value)
+	if err != nil {
+		return types.Entry[V]{}, false, err
+	}
+
+	entry := types.Entry[V]{
+		Embedding: doc.Embedding,
+		Value:     value,
+		InputText: doc.InputText,
+		CreatedAt: doc.CreatedAt,
+		Metadata:  doc.Metadata,
+
+		EmbeddingVersion: doc.Version,
+	}
+	if b.local != nil {
+		b.local.Add(k, cached[V]{revision: kve.Revision(), entry: entry})
+	}
+	return entry, true, nil
+}
+
+// decodeValue reverses the value encoding done by Set.
+func (b *Backend[K, V]) decodeValue(raw msgpack.RawMessage) (V, error) {
+	var v V
+	data := []byte(raw)
+	if _, inline := b.codec.(remote.MsgpackCodec); !inline {
+		if err := msgpack.Unmarshal(raw, &data); err != nil {
+			return v, fmt.Errorf("failed to unmarshal value: %w", err)
+		}
+	}
+	if err := b.codec.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+	return v, nil
+}
+
+// Set stores a value with its embedding in the bucket.
+func (b *Backend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.SetEntry(ctx, key, types.Entry[V]{Embedding: embedding, Value: value})
+}
+
+// SetEntry stores an entry, including its input text, creation time, and
+// metadata.
+func (b *Backend[K, V]) SetEntry(ctx context.Context, key K, entry types.Entry[V]) error {
+	data, err := b.encode(key, entry)
+	if err != nil {
+		return err
+	}
+	k := b.keyString(key)
+	if _, err := b.kv.Put(ctx, k, data); err != nil {
+		return fmt.Errorf("failed to set entry in NATS: %w", err)
+	}
+	if b.local != nil {
+		b.local.Remove(k)
+	}
+	return nil
+}
+
+// encode builds the stored msgpack document for an entry.
+func (b *Backend[K, V]) encode(key K, entry types.Entry[V]) ([]byte, error) {
+	raw, err := b.codec.Marshal(entry.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	if _, inline := b.codec.(remote.MsgpackCodec); !inline {
+		if raw, err = msgpack.Marshal(raw); err != nil {
+			return nil, fmt.Errorf("failed to marshal value: %w", err)
+		}
+	}
+	data, err := msgpack.Marshal(document{
+		Key:       b.keys.EncodeKey(key),
+		Value:     raw,
+		Embedding: entry.Embedding,
+		InputText: entry.InputText,
+		CreatedAt: entry.CreatedAt,
+		Metadata:  entry.Metadata,
+		Version:   entry.EmbeddingVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal entry: %w", err)
+	}
+	return data, nil
+}
+
+// SetIfAbsent stores entry only if key does not exist, using the bucket's
+// create-only write.
+func (b *Backend[K, V]) SetIfAbsent(ctx context.Context, key K, entry types.Entry[V]) (bool, error) {
+	data, err := b.encode(key, entry)
+	if err != nil {
+		return false, err
+	}
+	k := b.keyString(key)
+	if _, err := b.kv.Create(ctx, k, data); err != nil {
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to set entry in NATS: %w", err)
+	}
+	if b.local != nil {
+		b.local.Remove(k)
+	}
+	return true, nil
+}
+
+// CompareAndSwap stores entry only if key exists and its value encodes to
+// the same bytes as old under the backend's codec. The write is tied to the
+// revision that was compared, so a concurrent write makes it report false.
+func (b *Backend[K, V]) CompareAndSwap(ctx context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	want, err := b.codec.Marshal(old)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	k := b.keyString(key)
+	kve, err := b.kv.Get(ctx, k)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get entry from NATS: %w", err)
+	}
+	var doc document
+	if err := msgpack.Unmarshal(kve.Value(), &doc); err != nil {
+		return false, fmt.Errorf("failed to unmarshal entry: %w", err)
+	}
+	cur, err := b.decodeValue(doc.Value)
+	if err != nil {
+		return false, err
+	}
+	got, err := b.codec.Marshal(cur)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	if !bytes.Equal(got, want) {
+		return false, nil
+	}
+
+	data, err := b.encode(key, entry)
+	if err != nil {
+		return false, err
+	}
+	if _, err := b.kv.Update(ctx, k, data, kve.Revision()); err != nil {
+		if errors.Is(err, jetstream.ErrKeyExists) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to set entry in NATS: %w", err)
+	}
+	if b.local != nil {
+		b.local.Remove(k)
+	}
+	return true, nil
+}
+
+// Get retrieves the value for a key.
+func (b *Backend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	e, ok, err := b.load(ctx, key)
+	return e.Value, ok, err
+}
+
+// GetEntry retrieves the entry for a key, including its input text,
+// creation time, and metadata.
+func (b *Backend[K, V]) GetEntry(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	return b.load(ctx, key)
+}
+
+// Delete removes an entry by key.
+func (b *Backend[K, V]) Delete(ctx context.Context, key K) error {
+	k := b.keyString(key)
+	if err := b.kv.Delete(ctx, k); err != nil {
+		return fmt.Errorf("failed to delete entry from NATS: %w", err)
+	}
+	if b.local != nil {
+		b.local.Remove(k)
+	}
+	return nil
+}
+
+// Contains checks whether a key exists.
+func (b *Backend[K, V]) Contains(ctx context.Context, key K) (bool, error) {
+	_, ok, err := b.load(ctx, key)
+	return ok, err
+}
+
+func (b *Backend[K, V]) rawKeys(ctx context.Context) ([]string, error) {
+	keys, err := b.kv.Keys(ctx)
+	if errors.Is(err, jetstream.ErrNoKeysFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys from NATS: %w", err)
+	}
+	return keys, nil
+}
+
+// decodeKey reverses keyString.
+func (b *Backend[K, V]) decodeKey(raw string) (K, bool) {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return *new(K), false
+	}
+	key, err := b.keys.DecodeKey(string(decoded))
+	return key, err == nil
+}
+
+// Keys returns all keys in the bucket.
+func (b *Backend[K, V]) Keys(ctx context.Context) ([]K, error) {
+	raw, err := b.rawKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]K, 0, len(raw))
+	for _, rk := range raw {
+		if key, ok := b.decodeKey(rk); ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// IterKeys streams the keys in the bucket from a key lister instead of
+// collecting them first.
+func (b *Backend[K, V]) IterKeys(ctx context.Context) iter.Seq2[K, error] {
+	return func(yield func(K, error) bool) {
+		lister, err := b.kv.ListKeys(ctx)
+		if err != nil {
+			yield(*new(K), fmt.Errorf("failed to list keys from NATS: %w", err))
+			return
+		}
+		defer func() { _ = lister.Stop() }()
+		for rk := range lister.Keys() {
+			key, ok := b.decodeKey(rk)
+			if !ok {
+				continue
+			}
+			if !yield(key, nil) {
+				return
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			yield(*new(K), err)
+		}
+	}
+}
+
+// GetEmbedding retrieves the embedding vector for a key.
+func (b *Backend[K, V]) GetEmbedding(ctx context.Context, key K) ([]float64, bool, error) {
+	e, ok, err := b.load(ctx, key)
+	return e.Embedding, ok, err
+}
+
+// Flush purges every key in the bucket.
+func (b *Backend[K, V]) Flush(ctx context.Context) error {
+	keys, err := b.rawKeys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		if err := b.kv.Purge(ctx, k); err != nil {
+			return fmt.Errorf("failed to flush NATS: %w", err)
+		}
+	}
+	if b.local != nil {
+		b.local.Purge()
+	}
+	return nil
+}
+
+// Len returns the number of keys in the bucket.
+func (b *Backend[K, V]) Len(ctx context.Context) (int, error) {
+	keys, err := b.rawKeys(ctx)
+	return len(keys), err
+}
+
+// Close stops the watcher and closes the NATS connection.
+func (b *Backend[K, V]) Close() error {
+	var err error
+	if b.watcher != nil {
+		err = b.watcher.Stop()
+	}
+	b.conn.Close()
+	b.wg.Wait()
+	return err
+}
+
+// keyCodecFor returns the configured codec, or the default when c is nil.
+func keyCodecFor[K comparable](c any) (remote.KeyCodec[K], error) {
+	if c == nil {
+		return remote.DefaultKeyCodec[K](), nil
+	}
+	kc, ok := c.(remote.KeyCodec[K])
+	if !ok {
+		var zero K
+		return nil, fmt.Errorf("nats: key codec %T does not handle %T keys", c, zero)
+	}
+	return kc, nil
+}
@@ -0,0 +1,88 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// ValueCodec encodes cached values for storage in a remote backend.
+// Unmarshal receives a pointer to the value type.
+type ValueCodec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// JSONCodec encodes values with encoding/json. It is the Redis default.
+type JSONCodec struct{}
+
+// Marshal implements ValueCodec.
+func (JSONCodec) Marshal(v any) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal implements ValueCodec.
+func (JSONCodec) Unmarshal(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+// MsgpackCodec encodes values with msgpack, which keeps time.Time and
+// []byte intact. It is the NATS default.
+type MsgpackCodec struct{}
+
+// Marshal implements ValueCodec.
+func (MsgpackCodec) Marshal(v any) ([]byte, error) { return msgpack.Marshal(v) }
+
+// Unmarshal implements ValueCodec.
+func (MsgpackCodec) Unmarshal(data []byte, v any) error { return msgpack.Unmarshal(data, v) }
+
+// GobCodec encodes values with encoding/gob. Interface-typed values must
+// have their concrete types registered with gob.Register.
+type GobCodec struct{}
+
+// Marshal implements ValueCodec.
+func (GobCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal implements ValueCodec.
+func (GobCodec) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// ProtoCodec encodes values with protobuf. The value type must be a
+// generated message pointer such as *pb.Answer.
+type ProtoCodec struct{}
+
+// Marshal implements ValueCodec.
+func (ProtoCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("remote: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal implements ValueCodec. v must be a pointer to a message
+// pointer; a new message is allocated for it.
+func (ProtoCodec) Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Pointer {
+		return fmt.Errorf("remote: cannot decode protobuf into %T", v)
+	}
+	msg := reflect.New(rv.Elem().Type().Elem())
+	m, ok := msg.Interface().(proto.Message)
+	if !ok {
+		return fmt.Errorf("remote: %s is not a proto.Message", rv.Elem().Type())
+	}
+	if err := proto.Unmarshal(data, m); err != nil {
+		return err
+	}
+	rv.Elem().Set(msg)
+	return nil
+}
@@ -0,0 +1,54 @@
+package remote
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type codecValue struct {
+	At   time.Time
+	Blob []byte
+}
+
+func TestValueCodecs_RoundTrip(t *testing.T) {
+	in := codecValue{At: time.Date(2024, 5, 1, 12, 0, 0, 123, time.UTC), Blob: []byte{0, 1, 2}}
+	for name, codec := range map[string]ValueCodec{
+		"json":    JSONCodec{},
+		"msgpack": MsgpackCodec{},
+		"gob":     GobCodec{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := codec.Marshal(in)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var out codecValue
+			if err := codec.Unmarshal(data, &out); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !out.At.Equal(in.At) || string(out.Blob) != string(in.Blob) {
+				t.Errorf("round trip changed value: %+v", out)
+			}
+		})
+	}
+}
+
+func TestProtoCodec(t *testing.T) {
+	data, err := ProtoCodec{}.Marshal(wrapperspb.String("hello"))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var out *wrapperspb.StringValue
+	if err := (ProtoCodec{}).Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if out.GetValue() != "hello" {
+		t.Errorf("expected hello, got %q", out.GetValue())
+	}
+
+	if _, err := (ProtoCodec{}).Marshal("not a message"); err == nil {
+		t.Error("expected error for non-message value")
+	}
+}
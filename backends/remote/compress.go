@@ -0,0 +1,110 @@
+package remote
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression selects how a remote backend compresses encoded values; see
+// WithCompression.
+type Compression int
+
+const (
+	// CompressionNone stores values as the codec encodes them. It is the
+	// default.
+	CompressionNone Compression = iota
+	// CompressionSnappy favors speed over ratio.
+	CompressionSnappy
+	// CompressionZstd compresses better at a higher CPU cost.
+	CompressionZstd
+)
+
+// String returns the compression's name.
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionSnappy:
+		return "snappy"
+	case CompressionZstd:
+		return "zstd"
+	}
+	return fmt.Sprintf("Compression(%d)", int(c))
+}
+
+// compressedMagic starts every compressed value, followed by one byte
+// naming the Compression. No built-in codec's output starts with it: it is
+// not JSON, 0xff is a complete msgpack value, protobuf has no wire type 7,
+// and gob never writes a zero length.
+var compressedMagic = []byte{0xff, 0x00}
+
+// errCorruptValue is returned for compressed values that fail to
+// decompress.
+var errCorruptValue = errors.New("remote: corrupt compressed value")
+
+var (
+	zstdEncoder = sync.OnceValue(func() *zstd.Encoder {
+		e, _ := zstd.NewWriter(nil)
+		return e
+	})
+	zstdDecoder = sync.OnceValue(func() *zstd.Decoder {
+		d, _ := zstd.NewReader(nil)
+		return d
+	})
+)
+
+// compressValue returns data compressed with c behind its header when data
+// is longer than threshold and compression makes it smaller, and data
+// unchanged otherwise.
+func compressValue(data []byte, c Compression, threshold int) []byte {
+	if c == CompressionNone || len(data) <= threshold {
+		return data
+	}
+	out := append(bytes.Clone(compressedMagic), byte(c))
+	switch c {
+	case CompressionSnappy:
+		out = append(out, snappy.Encode(nil, data)...)
+	case CompressionZstd:
+		out = zstdEncoder().EncodeAll(data, out)
+	}
+	if len(out) >= len(data) {
+		return data
+	}
+	return out
+}
+
+// isCompressed reports whether data starts with a compression header.
+func isCompressed(data []byte) bool {
+	return len(data) > len(compressedMagic) && bytes.HasPrefix(data, compressedMagic)
+}
+
+// decompressValue reverses compressValue, whichever compression wrote data,
+// and returns data without a header unchanged, so values written before
+// compression was enabled stay readable.
+func decompressValue(data []byte) ([]byte, error) {
+	if !isCompressed(data) {
+		return data, nil
+	}
+	body := data[len(compressedMagic)+1:]
+	var (
+		out []byte
+		err error
+	)
+	switch Compression(data[len(compressedMagic)]) {
+	case CompressionSnappy:
+		out, err = snappy.Decode(nil, body)
+	case CompressionZstd:
+		out, err = zstdDecoder().DecodeAll(body, nil)
+	default:
+		return nil, fmt.Errorf("%w: unknown compression %d", errCorruptValue, data[len(compressedMagic)])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errCorruptValue, err)
+	}
+	return out, nil
+}
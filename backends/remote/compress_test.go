@@ -0,0 +1,84 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+func TestCompressValue(t *testing.T) {
+	data := []byte(strings.Repeat("the quick brown fox ", 50))
+	for _, c := range []Compression{CompressionSnappy, CompressionZstd} {
+		packed := compressValue(data, c, 64)
+		if !isCompressed(packed) || len(packed) >= len(data) || Compression(packed[2]) != c {
+			t.Errorf("%v: expected a smaller value behind a header, got %d bytes", c, len(packed))
+		}
+		if out, err := decompressValue(packed); err != nil || !bytes.Equal(out, data) {
+			t.Errorf("%v: round trip failed: %v", c, err)
+		}
+	}
+	if packed := compressValue(data, CompressionZstd, len(data)); !bytes.Equal(packed, data) {
+		t.Error("expected values at the threshold to stay uncompressed")
+	}
+	if packed := compressValue([]byte("0123456789"), CompressionSnappy, 0); !bytes.Equal(packed, []byte("0123456789")) {
+		t.Error("expected values compression would grow to stay uncompressed")
+	}
+	if out, err := decompressValue(data); err != nil || !bytes.Equal(out, data) {
+		t.Errorf("expected plain values to pass through, err=%v", err)
+	}
+	if _, err := decompressValue([]byte{0xff, 0x00, byte(CompressionZstd), 1, 2, 3}); !errors.Is(err, errCorruptValue) {
+		t.Errorf("expected errCorruptValue, got %v", err)
+	}
+	if _, err := decompressValue([]byte{0xff, 0x00, 9, 1}); !errors.Is(err, errCorruptValue) {
+		t.Errorf("expected errCorruptValue for an unknown compression, got %v", err)
+	}
+}
+
+func TestCompressedDocuments(t *testing.T) {
+	long := strings.Repeat("cached answer ", 40)
+	for _, codec := range []ValueCodec{JSONCodec{}, MsgpackCodec{}} {
+		plain := &RedisBackend[string, string]{codec: codec, keys: DefaultKeyCodec[string]()}
+		packed := &RedisBackend[string, string]{codec: codec, keys: DefaultKeyCodec[string](), compression: CompressionZstd, compressMin: 64}
+		for _, value := range []string{long, "short", "/wAC"} {
+			for _, writer := range []*RedisBackend[string, string]{plain, packed} {
+				doc, err := writer.document("k", types.Entry[string]{Value: value})
+				if err != nil {
+					t.Fatal(err)
+				}
+				data, _ := json.Marshal(doc)
+				var stored redisDocument
+				_ = json.Unmarshal(data, &stored)
+				// Both backends read what either wrote.
+				for _, reader := range []*RedisBackend[string, string]{plain, packed} {
+					if got, err := reader.decodeValue(stored.Value); err != nil || got != value {
+						t.Errorf("%T: read %q back as %q (err=%v)", codec, value, got, err)
+					}
+				}
+			}
+		}
+		if doc, _ := packed.document("k", types.Entry[string]{Value: long}); len(doc.Value) >= len(long) {
+			t.Errorf("%T: expected the long value to be stored compressed, got %d bytes", codec, len(doc.Value))
+		}
+
+		fields, _ := packed.hashFields("k", types.Entry[string]{Value: long})
+		var got string
+		if err := plain.unmarshalValue(fields[3].([]byte), &got); err != nil || got != long {
+			t.Errorf("%T: hash value round trip failed: %v", codec, err)
+		}
+	}
+}
+
+func TestCompressionOptions(t *testing.T) {
+	for _, opt := range []RedisOption{WithCompression(Compression(7), 0), WithCompression(CompressionSnappy, -1)} {
+		if _, err := NewRedisBackend[string, string]("localhost:0", opt); !errors.Is(err, ErrInvalidRedisOption) {
+			t.Errorf("expected ErrInvalidRedisOption, got %v", err)
+		}
+	}
+	if CompressionZstd.String() != "zstd" || Compression(7).String() != "Compression(7)" {
+		t.Error("unexpected Compression names")
+	}
+}
@@ -0,0 +1,68 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultInvalidationChannel is the Pub/Sub channel used when none is given.
+const DefaultInvalidationChannel = "semanticcache:invalidate"
+
+// RedisInvalidator publishes and receives cache invalidation messages over
+// Redis Pub/Sub. It satisfies composite.Invalidator.
+type RedisInvalidator struct {
+	client  *redis.Client
+	channel string
+}
+
+// NewRedisInvalidator connects to Redis and returns an invalidator bound to
+// channel. addr accepts the same formats as NewRedisBackend; an empty
+// channel uses DefaultInvalidationChannel.
+func NewRedisInvalidator(addr, channel string, opts ...RedisOption) (*RedisInvalidator, error) {
+	client, _, err := newRedisClient(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if channel == "" {
+		channel = DefaultInvalidationChannel
+	}
+	return &RedisInvalidator{client: client, channel: channel}, nil
+}
+
+// Publish sends msg to every subscriber on the channel.
+func (i *RedisInvalidator) Publish(ctx context.Context, msg []byte) error {
+	if err := i.client.Publish(ctx, i.channel, msg).Err(); err != nil {
+		return fmt.Errorf("failed to publish invalidation: %w", err)
+	}
+	return nil
+}
+
+// Subscribe calls handler for every message received on the channel until
+// ctx is cancelled.
+func (i *RedisInvalidator) Subscribe(ctx context.Context, handler func(msg []byte)) error {
+	sub := i.client.Subscribe(ctx, i.channel)
+	defer func() { _ = sub.Close() }()
+
+	if _, err := sub.Receive(ctx); err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", i.channel, err)
+	}
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case m, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			handler([]byte(m.Payload))
+		}
+	}
+}
+
+// Close closes the Redis connection.
+func (i *RedisInvalidator) Close() error {
+	return i.client.Close()
+}
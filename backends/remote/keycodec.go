@@ -0,0 +1,100 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// KeyCodec converts cache keys to and from the strings stored in a remote
+// backend. DecodeKey must invert EncodeKey.
+type KeyCodec[K comparable] interface {
+	EncodeKey(key K) string
+	DecodeKey(s string) (K, error)
+}
+
+// DefaultKeyCodec returns the codec used when none is configured. Keys of
+// string kind are stored verbatim and integer kinds in decimal, matching
+// the historical "%v" layout. Every other key type (structs, floats, bools)
+// is stored as JSON.
+func DefaultKeyCodec[K comparable]() KeyCodec[K] { return defaultKeyCodec[K]{} }
+
+type defaultKeyCodec[K comparable] struct{}
+
+func (defaultKeyCodec[K]) EncodeKey(key K) string {
+	rv := reflect.ValueOf(&key).Elem()
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10)
+	}
+	data, err := json.Marshal(key)
+	if err != nil {
+		// Only keys holding channels or funcs get here; they cannot be
+		// decoded, so Keys skips them.
+		return fmt.Sprintf("%v", key)
+	}
+	return string(data)
+}
+
+func (defaultKeyCodec[K]) DecodeKey(s string) (K, error) {
+	var key K
+	rv := reflect.ValueOf(&key).Elem()
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(s)
+		return key, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, rv.Type().Bits())
+		if err != nil {
+			return key, fmt.Errorf("remote: invalid key %q: %w", s, err)
+		}
+		rv.SetInt(n)
+		return key, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, rv.Type().Bits())
+		if err != nil {
+			return key, fmt.Errorf("remote: invalid key %q: %w", s, err)
+		}
+		rv.SetUint(n)
+		return key, nil
+	}
+	if err := json.Unmarshal([]byte(s), &key); err != nil {
+		return key, fmt.Errorf("remote: invalid key %q: %w", s, err)
+	}
+	return key, nil
+}
+
+// keyCodecFor returns the configured codec, or the default when c is nil.
+// c comes from an untyped option, so a codec for a different key type is
+// reported as an error.
+func keyCodecFor[K comparable](c any) (KeyCodec[K], error) {
+	if c == nil {
+		return DefaultKeyCodec[K](), nil
+	}
+	kc, ok := c.(KeyCodec[K])
+	if !ok {
+		var zero K
+		return nil, fmt.Errorf("remote: key codec %T does not handle %T keys", c, zero)
+	}
+	return kc, nil
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as wildcards, so a
+// prefix such as "app[1]:" matches only itself.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package remote
+
+import "testing"
+
+type structKey struct {
+	Tenant string
+	ID     int
+}
+
+func roundTrip[K comparable](t *testing.T, key K, want string) {
+	t.Helper()
+	c := DefaultKeyCodec[K]()
+	s := c.EncodeKey(key)
+	if want != "" && s != want {
+		t.Errorf("EncodeKey(%v) = %q, want %q", key, s, want)
+	}
+	got, err := c.DecodeKey(s)
+	if err != nil {
+		t.Fatalf("DecodeKey(%q): %v", s, err)
+	}
+	if got != key {
+		t.Errorf("round trip of %v gave %v", key, got)
+	}
+}
+
+func TestDefaultKeyCodec(t *testing.T) {
+	roundTrip(t, "plain", "plain")
+	roundTrip(t, `has "quotes" and \ slashes`, `has "quotes" and \ slashes`)
+	roundTrip(t, 42, "42")
+	roundTrip(t, int8(-7), "-7")
+	roundTrip(t, uint64(1<<63), "9223372036854775808")
+	roundTrip(t, structKey{Tenant: "a", ID: 1}, `{"Tenant":"a","ID":1}`)
+	roundTrip(t, 1.5, "")
+
+	type named string
+	roundTrip(t, named("x"), "x")
+
+	if _, err := DefaultKeyCodec[int]().DecodeKey("nope"); err == nil {
+		t.Error("expected error decoding non-numeric int key")
+	}
+}
+
+func TestKeyCodecFor(t *testing.T) {
+	if _, err := keyCodecFor[string](nil); err != nil {
+		t.Errorf("nil codec should use the default, got %v", err)
+	}
+	if _, err := keyCodecFor[string](DefaultKeyCodec[int]()); err == nil {
+		t.Error("expected error for mismatched key type")
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	if got := escapeGlob(`app[1]:*?\`); got != `app\[1\]:\*\?\\` {
+		t.Errorf("escapeGlob = %q", got)
+	}
+}
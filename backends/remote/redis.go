@@ -4,23 +4,48 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"iter"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/botirk38/semanticcache/types"
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrInvalidRedisOption is returned by NewRedisBackend and
+// NewRedisInvalidator when an option value is out of range.
+var ErrInvalidRedisOption = errors.New("remote: invalid Redis option")
+
 // RedisOption configures a RedisBackend.
 type RedisOption func(*redisConfig)
 
 type redisConfig struct {
-	username  string
-	password  string
-	db        int
-	prefix    string
-	tlsConfig *tls.Config
+	username     string
+	password     string
+	db           int
+	prefix       string
+	tlsConfig    *tls.Config
+	clientName   string
+	poolSize     int
+	minIdleConns int
+	dialTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	codec        ValueCodec
+	keyCodec     any // KeyCodec[K]; checked by NewRedisBackend
+	storage      Storage
+	lenKey       string
+	float16      bool
+	compression  Compression
+	compressMin  int
+
+	bufferInterval time.Duration
+	bufferMax      int
+	bufferOnError  func(error)
 }
 
 // WithUsername sets the Redis username.
@@ -48,16 +73,121 @@ func WithTLS(cfg *tls.Config) RedisOption {
 	return func(c *redisConfig) { c.tlsConfig = cfg }
 }
 
-// RedisBackend implements Backend using Redis with JSON storage.
+// batchSize is the number of keys requested per SCAN page and per DEL
+// command in pipelined batch operations.
+const batchSize = 100
+
+// WithValueCodec sets how values are encoded. Defaults to JSONCodec, which
+// stores the value as nested JSON. Other codecs store it as a base64 string
+// in the same document, so embeddings stay readable by JSON.GET.
+func WithValueCodec(codec ValueCodec) RedisOption {
+	return func(c *redisConfig) { c.codec = codec }
+}
+
+// WithCompression compresses encoded values longer than threshold bytes
+// with c, behind a header naming c, when that makes them smaller. Reads
+// detect the header, so values written before compression was enabled, or
+// with another Compression, stay readable. Embeddings and the other entry
+// fields are not compressed.
+func WithCompression(c Compression, threshold int) RedisOption {
+	return func(cfg *redisConfig) { cfg.compression, cfg.compressMin = c, threshold }
+}
+
+// WithKeyCodec sets how keys are turned into Redis key names after the
+// prefix. Defaults to DefaultKeyCodec. The codec's key type must match the
+// backend's, or NewRedisBackend returns an error.
+func WithKeyCodec[K comparable](codec KeyCodec[K]) RedisOption {
+	return func(c *redisConfig) { c.keyCodec = codec }
+}
+
+// WithStorage selects how entries are laid out. Defaults to StorageAuto,
+// which uses JSON documents when the server has RedisJSON and plain hashes
+// otherwise.
+func WithStorage(s Storage) RedisOption {
+	return func(c *redisConfig) { c.storage = s }
+}
+
+// WithFloat16 stores embeddings as IEEE float16, a quarter of the hash
+// layout's float64s and far smaller than JSON numbers, rounding each
+// component to about three significant digits. Reads accept both
+// precisions, so existing entries stay readable after enabling it.
+func WithFloat16() RedisOption {
+	return func(c *redisConfig) { c.float16 = true }
+}
+
+// WithClientName sets the name reported by CLIENT LIST for each connection.
+func WithClientName(name string) RedisOption {
+	return func(c *redisConfig) { c.clientName = name }
+}
+
+// WithPoolSize sets the maximum number of socket connections.
+// Zero keeps the go-redis default of 10 per CPU.
+func WithPoolSize(n int) RedisOption {
+	return func(c *redisConfig) { c.poolSize = n }
+}
+
+// WithMinIdleConns sets the minimum number of idle connections kept open.
+func WithMinIdleConns(n int) RedisOption {
+	return func(c *redisConfig) { c.minIdleConns = n }
+}
+
+// WithDialTimeout sets the timeout for establishing new connections.
+func WithDialTimeout(d time.Duration) RedisOption {
+	return func(c *redisConfig) { c.dialTimeout = d }
+}
+
+// WithReadTimeout sets the timeout for socket reads. As in go-redis, -1
+// means no timeout and -2 disables read deadlines entirely.
+func WithReadTimeout(d time.Duration) RedisOption {
+	return func(c *redisConfig) { c.readTimeout = d }
+}
+
+// WithWriteTimeout sets the timeout for socket writes. As in go-redis, -1
+// means no timeout and -2 disables write deadlines entirely.
+func WithWriteTimeout(d time.Duration) RedisOption {
+	return func(c *redisConfig) { c.writeTimeout = d }
+}
+
+// RedisBackend implements Backend using Redis or a compatible server
+// (Valkey, Dragonfly), storing entries as JSON documents or hashes.
 type RedisBackend[K comparable, V any] struct {
 	client *redis.Client
 	prefix string
+	match  string // SCAN pattern for prefix
+	codec  ValueCodec
+	keys   KeyCodec[K]
+
+	storage Storage
+	lenKey  string // see WithLenCounter; empty keeps no counter
+	float16 bool
+
+	compression Compression
+	compressMin int // see WithCompression
+
+	writes *writeBuffer[K, V] // see WithWriteBuffer; nil writes through
 }
 
-type redisDocument[V any] struct {
-	Key       string    `json:"key"`
-	Value     V         `json:"value"`
-	Embedding []float64 `json:"embedding"`
+var (
+	_ types.BatchBackend[string, string]  = (*RedisBackend[string, string])(nil)
+	_ types.KeyIterator[string, string]   = (*RedisBackend[string, string])(nil)
+	_ types.Transactional[string, string] = (*RedisBackend[string, string])(nil)
+	_ types.EntryBackend[string, string]  = (*RedisBackend[string, string])(nil)
+
+	_ types.EmbeddingBatchBackend[string, string] = (*RedisBackend[string, string])(nil)
+	_ types.ConditionalBackend[string, string]    = (*RedisBackend[string, string])(nil)
+	_ types.ExpiringBackend[string, string]       = (*RedisBackend[string, string])(nil)
+)
+
+// redisDocument is the stored JSON layout. Value holds the value's JSON
+// encoding, or a base64 string of the codec's bytes for non-JSON codecs.
+type redisDocument struct {
+	Key       string            `json:"key"`
+	Value     json.RawMessage   `json:"value"`
+	Embedding redisEmbedding    `json:"embedding"`
+	InputText string            `json:"input_text,omitempty"`
+	CreatedAt time.Time         `json:"created_at,omitzero"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Version   string            `json:"embedding_version,omitempty"`
 }
 
 func parseRedisURL(connectionString string) (*redis.Options, error) {
@@ -94,13 +224,112 @@ func parseRedisURL(connectionString string) (*redis.Options, error) {
 // NewRedisBackend creates a new Redis backend. addr can be "host:port" or a
 // redis:// / rediss:// URL.
 func NewRedisBackend[K comparable, V any](addr string, opts ...RedisOption) (*RedisBackend[K, V], error) {
+	client, cfg, err := newRedisClient(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := keyCodecFor[K](cfg.keyCodec)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	storage := cfg.storage
+	if storage == StorageAuto {
+		if storage, err = detectStorage(client, cfg.prefix); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+	}
+	b := &RedisBackend[K, V]{
+		client:  client,
+		prefix:  cfg.prefix,
+		match:   escapeGlob(cfg.prefix) + "*",
+		codec:   cfg.codec,
+		keys:    keys,
+		storage: storage,
+		lenKey:  cfg.lenKey,
+		float16: cfg.float16,
+
+		compression: cfg.compression,
+		compressMin: cfg.compressMin,
+	}
+	if b.lenKey != "" {
+		if err := b.initLen(context.Background()); err != nil {
+			_ = client.Close()
+			return nil, err
+		}
+	}
+	if cfg.bufferInterval > 0 {
+		b.writes = newWriteBuffer[K, V](cfg.bufferMax, cfg.bufferOnError)
+		go b.runWriteBuffer(cfg.bufferInterval)
+	}
+	return b, nil
+}
+
+// validate rejects option values go-redis would silently misinterpret.
+// Negative read and write timeouts are allowed; go-redis uses them to
+// disable deadlines.
+func (c *redisConfig) validate() error {
+	switch {
+	case c.codec == nil:
+		return fmt.Errorf("%w: value codec is nil", ErrInvalidRedisOption)
+	case c.dialTimeout < 0:
+		return fmt.Errorf("%w: dial timeout %v is negative", ErrInvalidRedisOption, c.dialTimeout)
+	case c.db < 0:
+		return fmt.Errorf("%w: database %d is negative", ErrInvalidRedisOption, c.db)
+	case c.poolSize < 0:
+		return fmt.Errorf("%w: pool size %d is negative", ErrInvalidRedisOption, c.poolSize)
+	case c.minIdleConns < 0:
+		return fmt.Errorf("%w: min idle connections %d is negative", ErrInvalidRedisOption, c.minIdleConns)
+	case c.poolSize > 0 && c.minIdleConns > c.poolSize:
+		return fmt.Errorf("%w: min idle connections %d exceed pool size %d", ErrInvalidRedisOption, c.minIdleConns, c.poolSize)
+	case c.storage < StorageAuto || c.storage > StorageHash:
+		return fmt.Errorf("%w: unknown storage %v", ErrInvalidRedisOption, c.storage)
+	case c.compression < CompressionNone || c.compression > CompressionZstd:
+		return fmt.Errorf("%w: unknown compression %v", ErrInvalidRedisOption, c.compression)
+	case c.compressMin < 0:
+		return fmt.Errorf("%w: compression threshold %d is negative", ErrInvalidRedisOption, c.compressMin)
+	case c.bufferInterval < 0:
+		return fmt.Errorf("%w: write buffer interval %v is negative", ErrInvalidRedisOption, c.bufferInterval)
+	case c.bufferMax < 0:
+		return fmt.Errorf("%w: write buffer size %d is negative", ErrInvalidRedisOption, c.bufferMax)
+	case c.lenKey != "" && strings.HasPrefix(c.lenKey, c.prefix):
+		return fmt.Errorf("%w: length counter key %q is under prefix %q", ErrInvalidRedisOption, c.lenKey, c.prefix)
+	}
+	return nil
+}
+
+// newRedisClient applies opts on top of the parsed address and returns a
+// connected client.
+func newRedisClient(addr string, opts ...RedisOption) (*redis.Client, *redisConfig, error) {
 	cfg := &redisConfig{
 		prefix: "semanticcache:",
+		codec:  JSONCodec{},
 	}
 	for _, o := range opts {
 		o(cfg)
 	}
+	if err := cfg.validate(); err != nil {
+		return nil, nil, err
+	}
+
+	redisOpts, err := redisOptions(addr, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := redis.NewClient(redisOpts)
 
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return client, cfg, nil
+}
+
+// redisOptions applies cfg on top of the parsed address. Zero values keep
+// the go-redis defaults; negative read and write timeouts pass through.
+func redisOptions(addr string, cfg *redisConfig) (*redis.Options, error) {
 	redisOpts, err := parseRedisURL(addr)
 	if err != nil {
 		return nil, err
@@ -117,33 +346,165 @@ func NewRedisBackend[K comparable, V any](addr string, opts ...RedisOption) (*Re
 	if cfg.tlsConfig != nil {
 		redisOpts.TLSConfig = cfg.tlsConfig
 	}
+	if cfg.clientName != "" {
+		redisOpts.ClientName = cfg.clientName
+	}
+	if cfg.poolSize > 0 {
+		redisOpts.PoolSize = cfg.poolSize
+	}
+	if cfg.minIdleConns > 0 {
+		redisOpts.MinIdleConns = cfg.minIdleConns
+	}
+	if cfg.dialTimeout > 0 {
+		redisOpts.DialTimeout = cfg.dialTimeout
+	}
+	if cfg.readTimeout != 0 {
+		redisOpts.ReadTimeout = cfg.readTimeout
+	}
+	if cfg.writeTimeout != 0 {
+		redisOpts.WriteTimeout = cfg.writeTimeout
+	}
+	return redisOpts, nil
+}
 
-	client := redis.NewClient(redisOpts)
+func (b *RedisBackend[K, V]) keyString(key K) string {
+	return b.prefix + b.keys.EncodeKey(key)
+}
 
-	ctx := context.Background()
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+// redisEmbedding is a document's embedding: a JSON array of numbers, or
+// with WithFloat16 the base64 string of encodeFloat16Embedding. Either form
+// decodes.
+type redisEmbedding struct {
+	values []float64
+	half   bool
+}
+
+func (e redisEmbedding) MarshalJSON() ([]byte, error) {
+	if e.half && e.values != nil {
+		return json.Marshal(encodeFloat16Embedding(e.values))
+	}
+	return json.Marshal(e.values)
+}
+
+func (e *redisEmbedding) UnmarshalJSON(data []byte) error {
+	if len(data) == 0 || data[0] != '"' {
+		return json.Unmarshal(data, &e.values)
+	}
+	var raw []byte
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	values, err := decodeEmbedding(raw)
+	if err != nil {
+		return err
+	}
+	e.values, e.half = values, true
+	return nil
+}
+
+// marshalValue encodes v with the codec and compresses the result as
+// configured by WithCompression.
+func (b *RedisBackend[K, V]) marshalValue(v V) ([]byte, error) {
+	data, err := b.codec.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	return compressValue(data, b.compression, b.compressMin), nil
+}
+
+// unmarshalValue reverses marshalValue.
+func (b *RedisBackend[K, V]) unmarshalValue(data []byte, v *V) error {
+	data, err := decompressValue(data)
+	if err == nil {
+		err = b.codec.Unmarshal(data, v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal value: %w", err)
 	}
+	return nil
+}
 
-	return &RedisBackend[K, V]{
-		client: client,
-		prefix: cfg.prefix,
+// document builds the stored form of an entry.
+func (b *RedisBackend[K, V]) document(key K, entry types.Entry[V]) (redisDocument, error) {
+	data, err := b.marshalValue(entry.Value)
+	if err != nil {
+		return redisDocument{}, err
+	}
+	if _, isJSON := b.codec.(JSONCodec); !isJSON || isCompressed(data) {
+		// Wrap the codec's bytes as a base64 JSON string.
+		if data, err = json.Marshal(data); err != nil {
+			return redisDocument{}, fmt.Errorf("failed to marshal value: %w", err)
+		}
+	}
+	return redisDocument{
+		Key:       b.keys.EncodeKey(key),
+		Value:     data,
+		Embedding: redisEmbedding{values: entry.Embedding, half: b.float16},
+		InputText: entry.InputText,
+		CreatedAt: entry.CreatedAt,
+		Metadata:  entry.Metadata,
+		Version:   entry.EmbeddingVersion,
 	}, nil
 }
 
-func (b *RedisBackend[K, V]) keyString(key K) string {
-	return fmt.Sprintf("%s%v", b.prefix, key)
+// decodeValue reverses the value encoding done by document.
+func (b *RedisBackend[K, V]) decodeValue(raw json.RawMessage) (V, error) {
+	var v V
+	if _, isJSON := b.codec.(JSONCodec); isJSON {
+		// A JSON string holds a compressed value if it decodes as one; a
+		// string value that merely looks like one is read as itself.
+		var wrapped []byte
+		if len(raw) > 0 && raw[0] == '"' && json.Unmarshal(raw, &wrapped) == nil && isCompressed(wrapped) {
+			if err := b.unmarshalValue(wrapped, &v); err == nil {
+				return v, nil
+			}
+			v = *new(V)
+		}
+		return v, b.unmarshalValue(raw, &v)
+	}
+	var data []byte
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return v, fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+	return v, b.unmarshalValue(data, &v)
+}
+
+// queueSet issues the write for one entry on c, which may be the client or
+// a pipeline, using the backend's storage layout.
+func (b *RedisBackend[K, V]) queueSet(ctx context.Context, c redis.Cmdable, key K, entry types.Entry[V]) (redis.Cmder, error) {
+	if b.storage == StorageHash {
+		fields, err := b.hashFields(key, entry)
+		if err != nil {
+			return nil, err
+		}
+		return c.HSet(ctx, b.keyString(key), fields...), nil
+	}
+	doc, err := b.document(key, entry)
+	if err != nil {
+		return nil, err
+	}
+	return c.JSONSet(ctx, b.keyString(key), "$", doc), nil
 }
 
 // Set stores a value with its embedding in Redis.
 func (b *RedisBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
-	doc := redisDocument[V]{
-		Key:       fmt.Sprintf("%v", key),
-		Value:     value,
-		Embedding: embedding,
+	return b.SetEntry(ctx, key, types.Entry[V]{Embedding: embedding, Value: value})
+}
+
+// SetEntry stores an entry, including its input text, creation time, and
+// metadata.
+func (b *RedisBackend[K, V]) SetEntry(ctx context.Context, key K, entry types.Entry[V]) error {
+	if b.writes != nil {
+		return b.buffer(ctx, map[K]types.Entry[V]{key: entry})
+	}
+	if d := b.lenDelta(); d != nil {
+		return b.setEntries(ctx, map[K]types.Entry[V]{key: entry}, d, "failed to set entry in Redis")
 	}
-	_, err := b.client.JSONSet(ctx, b.keyString(key), "$", doc).Result()
+	cmd, err := b.queueSet(ctx, b.client, key, entry)
 	if err != nil {
+		return err
+	}
+	if err := cmd.Err(); err != nil {
 		return fmt.Errorf("failed to set entry in Redis: %w", err)
 	}
 	return nil
@@ -151,38 +512,212 @@ func (b *RedisBackend[K, V]) Set(ctx context.Context, key K, embedding []float64
 
 // Get retrieves the value for a key.
 func (b *RedisBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	if e, ok := b.writes.get(key); ok {
+		return e.Value, true, nil
+	}
+	if b.storage == StorageHash {
+		return b.hashGet(ctx, key)
+	}
+	entry, ok, err := b.GetEntry(ctx, key)
+	return entry.Value, ok, err
+}
+
+// GetEntry retrieves the entry for a key, including its input text,
+// creation time, and metadata.
+func (b *RedisBackend[K, V]) GetEntry(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	if e, ok := b.writes.get(key); ok {
+		return e, true, nil
+	}
+	if b.storage == StorageHash {
+		return b.hashEntry(ctx, key)
+	}
 	result, err := b.client.JSONGet(ctx, b.keyString(key), "$").Result()
 	if err == redis.Nil {
-		var zero V
-		return zero, false, nil
+		return types.Entry[V]{}, false, nil
 	}
 	if err != nil {
-		var zero V
-		return zero, false, fmt.Errorf("failed to get entry from Redis: %w", err)
+		return types.Entry[V]{}, false, fmt.Errorf("failed to get entry from Redis: %w", err)
 	}
 
-	var docs []redisDocument[V]
+	var docs []redisDocument
 	if err := json.Unmarshal([]byte(result), &docs); err != nil {
-		var zero V
-		return zero, false, fmt.Errorf("failed to unmarshal entry: %w", err)
+		return types.Entry[V]{}, false, fmt.Errorf("failed to unmarshal entry: %w", err)
 	}
 	if len(docs) == 0 {
-		var zero V
-		return zero, false, nil
+		return types.Entry[V]{}, false, nil
 	}
-	return docs[0].Value, true, nil
+	doc := docs[0]
+	v, err := b.decodeValue(doc.Value)
+	if err != nil {
+		return types.Entry[V]{}, false, err
+	}
+	return types.Entry[V]{
+		Embedding: doc.Embedding.values,
+		Value:     v,
+		InputText: doc.InputText,
+		CreatedAt: doc.CreatedAt,
+		Metadata:  doc.Metadata,
+
+		EmbeddingVersion: doc.Version,
+	}, true, nil
 }
 
 // Delete removes an entry by key.
 func (b *RedisBackend[K, V]) Delete(ctx context.Context, key K) error {
-	if err := b.client.Del(ctx, b.keyString(key)).Err(); err != nil {
+	if err := b.flushWrites(ctx); err != nil {
+		return err
+	}
+	n, err := b.client.Del(ctx, b.keyString(key)).Result()
+	if err != nil {
 		return fmt.Errorf("failed to delete entry from Redis: %w", err)
 	}
-	return nil
+	return b.addLen(ctx, -n)
+}
+
+// SetBatch stores multiple entries, pipelining the writes so the batch
+// costs one round trip.
+func (b *RedisBackend[K, V]) SetBatch(ctx context.Context, entries map[K]types.Entry[V]) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if b.writes != nil {
+		return b.buffer(ctx, entries)
+	}
+	return b.setEntries(ctx, entries, b.lenDelta(), "failed to set entries in Redis")
+}
+
+// setEntries writes entries on one pipeline and counts the keys it
+// creates in d.
+func (b *RedisBackend[K, V]) setEntries(ctx context.Context, entries map[K]types.Entry[V], d *lenDelta, msg string) error {
+	pipe := b.pipeline(d)
+	for key, e := range entries {
+		d.set(ctx, pipe, b.keyString(key))
+		if _, err := b.queueSet(ctx, pipe, key, e); err != nil {
+			return err
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("%s: %w", msg, err)
+	}
+	return b.addLen(ctx, d.n())
+}
+
+// Txn applies ops in a MULTI/EXEC block, so other clients never see a
+// partial update. The queued ops are isolated, not rolled back: Redis runs
+// every command in the block even if one fails at EXEC, for example on a
+// wrong-type key or when out of memory, and Txn then returns the first
+// failure with the other ops applied. Errors found while queueing, such as
+// a value that cannot be encoded, discard the block before MULTI is sent.
+func (b *RedisBackend[K, V]) Txn(ctx context.Context, ops []types.TxnOp[K, V]) error {
+	if len(ops) == 0 {
+		return nil
+	}
+	if err := b.flushWrites(ctx); err != nil {
+		return err
+	}
+	// An error returned from the callback discards the queued commands
+	// before MULTI is sent.
+	d := b.lenDelta()
+	_, err := b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, op := range ops {
+			if op.Delete {
+				d.del(pipe.Del(ctx, b.keyString(op.Key)))
+				continue
+			}
+			d.set(ctx, pipe, b.keyString(op.Key))
+			if _, err := b.queueSet(ctx, pipe, op.Key, op.Entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply transaction in Redis: %w", err)
+	}
+	return b.addLen(ctx, d.n())
+}
+
+// GetBatch retrieves the values for multiple keys with a single JSON.MGET,
+// or a pipeline of HGETs with hash storage. Missing keys are omitted from
+// the result.
+func (b *RedisBackend[K, V]) GetBatch(ctx context.Context, keys []K) (map[K]V, error) {
+	result := make(map[K]V, len(keys))
+	buffered, keys := b.bufferedBatch(keys)
+	for key, e := range buffered {
+		result[key] = e.Value
+	}
+	if len(keys) == 0 {
+		return result, nil
+	}
+	if b.storage == StorageHash {
+		return b.hashGetBatch(ctx, keys, result)
+	}
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = b.keyString(key)
+	}
+
+	raw, err := b.client.JSONMGet(ctx, "$", redisKeys...).Result()
+	if err == redis.Nil {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entries from Redis: %w", err)
+	}
+
+	for i, item := range raw {
+		if i >= len(keys) {
+			break
+		}
+		s, ok := item.(string)
+		if !ok || s == "" {
+			continue
+		}
+		var docs []redisDocument
+		if err := json.Unmarshal([]byte(s), &docs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal entry: %w", err)
+		}
+		if len(docs) > 0 {
+			v, err := b.decodeValue(docs[0].Value)
+			if err != nil {
+				return nil, err
+			}
+			result[keys[i]] = v
+		}
+	}
+	return result, nil
+}
+
+// DeleteBatch removes multiple entries, pipelining the deletes in groups of
+// batchSize keys so large batches do not produce a single huge command.
+func (b *RedisBackend[K, V]) DeleteBatch(ctx context.Context, keys []K) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := b.flushWrites(ctx); err != nil {
+		return err
+	}
+	d := b.lenDelta()
+	pipe := b.client.Pipeline()
+	for start := 0; start < len(keys); start += batchSize {
+		end := min(start+batchSize, len(keys))
+		redisKeys := make([]string, 0, end-start)
+		for _, key := range keys[start:end] {
+			redisKeys = append(redisKeys, b.keyString(key))
+		}
+		d.del(pipe.Del(ctx, redisKeys...))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete entries from Redis: %w", err)
+	}
+	return b.addLen(ctx, d.n())
 }
 
 // Contains checks whether a key exists.
 func (b *RedisBackend[K, V]) Contains(ctx context.Context, key K) (bool, error) {
+	if _, ok := b.writes.get(key); ok {
+		return true, nil
+	}
 	n, err := b.client.Exists(ctx, b.keyString(key)).Result()
 	if err != nil {
 		return false, fmt.Errorf("failed to check key existence in Redis: %w", err)
@@ -193,29 +728,55 @@ func (b *RedisBackend[K, V]) Contains(ctx context.Context, key K) (bool, error)
 // Keys returns all keys stored under the configured prefix.
 func (b *RedisBackend[K, V]) Keys(ctx context.Context) ([]K, error) {
 	var keys []K
-	var cursor uint64
-	for {
-		result, next, err := b.client.Scan(ctx, cursor, b.prefix+"*", 100).Result()
+	for key, err := range b.IterKeys(ctx) {
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan keys from Redis: %w", err)
+			return nil, err
 		}
-		for _, rk := range result {
-			raw := strings.TrimPrefix(rk, b.prefix)
-			var key K
-			if err := json.Unmarshal(fmt.Appendf(nil, "\"%s\"", raw), &key); err == nil {
-				keys = append(keys, key)
-			}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// IterKeys streams the keys stored under the configured prefix, fetching
+// one SCAN page at a time.
+func (b *RedisBackend[K, V]) IterKeys(ctx context.Context) iter.Seq2[K, error] {
+	return func(yield func(K, error) bool) {
+		if err := b.flushWrites(ctx); err != nil {
+			yield(*new(K), err)
+			return
 		}
-		cursor = next
-		if cursor == 0 {
-			break
+		var cursor uint64
+		for {
+			result, next, err := b.client.Scan(ctx, cursor, b.match, batchSize).Result()
+			if err != nil {
+				yield(*new(K), fmt.Errorf("failed to scan keys from Redis: %w", err))
+				return
+			}
+			for _, rk := range result {
+				key, err := b.keys.DecodeKey(strings.TrimPrefix(rk, b.prefix))
+				if err != nil {
+					continue
+				}
+				if !yield(key, nil) {
+					return
+				}
+			}
+			cursor = next
+			if cursor == 0 {
+				return
+			}
 		}
 	}
-	return keys, nil
 }
 
 // GetEmbedding retrieves the embedding vector for a key.
 func (b *RedisBackend[K, V]) GetEmbedding(ctx context.Context, key K) ([]float64, bool, error) {
+	if e, ok := b.writes.get(key); ok {
+		return e.Embedding, true, nil
+	}
+	if b.storage == StorageHash {
+		return b.hashEmbedding(ctx, key)
+	}
 	result, err := b.client.JSONGet(ctx, b.keyString(key), "$").Result()
 	if err == redis.Nil {
 		return nil, false, nil
@@ -223,26 +784,129 @@ func (b *RedisBackend[K, V]) GetEmbedding(ctx context.Context, key K) ([]float64
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to get embedding from Redis: %w", err)
 	}
-	var docs []redisDocument[V]
+	var docs []redisDocument
 	if err := json.Unmarshal([]byte(result), &docs); err != nil {
 		return nil, false, fmt.Errorf("failed to unmarshal embedding: %w", err)
 	}
 	if len(docs) == 0 {
 		return nil, false, nil
 	}
-	return docs[0].Embedding, true, nil
+	return docs[0].Embedding.values, true, nil
+}
+
+// GetEmbeddings retrieves the embeddings for multiple keys with a single
+// JSON.MGET, or one pipelined HGET per key with hash storage.
+func (b *RedisBackend[K, V]) GetEmbeddings(ctx context.Context, keys []K) (map[K][]float64, error) {
+	result := make(map[K][]float64, len(keys))
+	buffered, keys := b.bufferedBatch(keys)
+	for key, e := range buffered {
+		result[key] = e.Embedding
+	}
+	if len(keys) == 0 {
+		return result, nil
+	}
+	if b.storage == StorageHash {
+		return b.hashEmbeddings(ctx, keys, result)
+	}
+	redisKeys := make([]string, len(keys))
+	for i, key := range keys {
+		redisKeys[i] = b.keyString(key)
+	}
+
+	raw, err := b.client.JSONMGet(ctx, "$.embedding", redisKeys...).Result()
+	if err == redis.Nil {
+		return result, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get embeddings from Redis: %w", err)
+	}
+
+	for i, item := range raw {
+		if i >= len(keys) {
+			break
+		}
+		s, ok := item.(string)
+		if !ok || s == "" {
+			continue
+		}
+		var embeddings []redisEmbedding
+		if err := json.Unmarshal([]byte(s), &embeddings); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal embedding: %w", err)
+		}
+		if len(embeddings) > 0 {
+			result[keys[i]] = embeddings[0].values
+		}
+	}
+	return result, nil
+}
+
+// Expire sets a millisecond-precision expiry on key with PEXPIRE. A
+// non-positive ttl deletes the key. It reports whether the key existed.
+func (b *RedisBackend[K, V]) Expire(ctx context.Context, key K, ttl time.Duration) (bool, error) {
+	if err := b.flushWrites(ctx); err != nil {
+		return false, err
+	}
+	k := b.keyString(key)
+	if ttl <= 0 {
+		n, err := b.client.Del(ctx, k).Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to delete entry from Redis: %w", err)
+		}
+		return n > 0, b.addLen(ctx, -n)
+	}
+	ok, err := b.client.PExpire(ctx, k, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to set expiry in Redis: %w", err)
+	}
+	return ok, nil
+}
+
+// Persist removes key's expiry with PERSIST. It reports whether the key
+// existed, whether or not it had an expiry.
+func (b *RedisBackend[K, V]) Persist(ctx context.Context, key K) (bool, error) {
+	if err := b.flushWrites(ctx); err != nil {
+		return false, err
+	}
+	k := b.keyString(key)
+	pipe := b.client.Pipeline()
+	pipe.Persist(ctx, k)
+	exists := pipe.Exists(ctx, k)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("failed to persist entry in Redis: %w", err)
+	}
+	return exists.Val() > 0, nil
+}
+
+// Touch updates key's last access time with TOUCH, which matters under an
+// LRU or LFU maxmemory policy. It does not change the expiry.
+func (b *RedisBackend[K, V]) Touch(ctx context.Context, key K) (bool, error) {
+	if err := b.flushWrites(ctx); err != nil {
+		return false, err
+	}
+	n, err := b.client.Touch(ctx, b.keyString(key)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to touch entry in Redis: %w", err)
+	}
+	return n > 0, nil
 }
 
-// Flush removes all entries with the configured prefix.
+// Flush removes all entries with the configured prefix. Each SCAN page is
+// removed with one UNLINK before the next page is fetched, so memory stays
+// bounded by the page size and the server frees values in the background.
+// The WithLenCounter counter is reset last. An error part way leaves the
+// pages already removed.
 func (b *RedisBackend[K, V]) Flush(ctx context.Context) error {
+	if err := b.flushWrites(ctx); err != nil {
+		return err
+	}
 	var cursor uint64
 	for {
-		result, next, err := b.client.Scan(ctx, cursor, b.prefix+"*", 100).Result()
+		result, next, err := b.client.Scan(ctx, cursor, b.match, batchSize).Result()
 		if err != nil {
 			return fmt.Errorf("failed to scan keys from Redis: %w", err)
 		}
 		if len(result) > 0 {
-			if err := b.client.Del(ctx, result...).Err(); err != nil {
+			if err := b.client.Unlink(ctx, result...).Err(); err != nil {
 				return fmt.Errorf("failed to flush Redis: %w", err)
 			}
 		}
@@ -251,15 +915,25 @@ func (b *RedisBackend[K, V]) Flush(ctx context.Context) error {
 			break
 		}
 	}
+	if b.lenKey != "" {
+		if err := b.client.Del(ctx, b.lenKey).Err(); err != nil {
+			return fmt.Errorf("failed to reset Redis length counter: %w", err)
+		}
+	}
 	return nil
 }
 
-// Len returns the number of entries with the configured prefix.
+// Len returns the number of entries with the configured prefix. It scans
+// every key; see ApproxLen for a cheaper count. With WithLenCounter it
+// also resets the counter to the result.
 func (b *RedisBackend[K, V]) Len(ctx context.Context) (int, error) {
+	if err := b.flushWrites(ctx); err != nil {
+		return 0, err
+	}
 	var count int
 	var cursor uint64
 	for {
-		result, next, err := b.client.Scan(ctx, cursor, b.prefix+"*", 100).Result()
+		result, next, err := b.client.Scan(ctx, cursor, b.match, batchSize).Result()
 		if err != nil {
 			return 0, fmt.Errorf("failed to count keys in Redis: %w", err)
 		}
@@ -269,10 +943,16 @@ func (b *RedisBackend[K, V]) Len(ctx context.Context) (int, error) {
 			break
 		}
 	}
+	if b.lenKey != "" {
+		if err := b.client.Set(ctx, b.lenKey, count, 0).Err(); err != nil {
+			return 0, fmt.Errorf("failed to update entry count in Redis: %w", err)
+		}
+	}
 	return count, nil
 }
 
-// Close closes the Redis connection.
+// Close writes any entries buffered by WithWriteBuffer and closes the
+// Redis connection.
 func (b *RedisBackend[K, V]) Close() error {
-	return b.client.Close()
+	return errors.Join(b.closeWrites(), b.client.Close())
 }
@@ -0,0 +1,200 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// WithWriteBuffer makes Set, SetEntry, and SetBatch return once their
+// entries are buffered in memory, and writes the buffer to Redis on one
+// pipeline every interval, or right away, from the Set that fills it, once
+// it holds maxEntries entries. A maxEntries of 0 leaves only the interval.
+// Reads through the backend see buffered entries, and every other
+// operation that touches Redis writes the buffer first, so the backend
+// keeps read-your-write semantics; other clients see an entry only once it
+// has been flushed. A failed flush keeps its entries for the next one, and
+// Close flushes what is left. A zero interval disables buffering.
+func WithWriteBuffer(interval time.Duration, maxEntries int) RedisOption {
+	return func(c *redisConfig) { c.bufferInterval, c.bufferMax = interval, maxEntries }
+}
+
+// WithWriteBufferErrorHandler sets fn to receive the errors of background
+// flushes started by WithWriteBuffer's interval, which have no caller to
+// return them to. By default they are dropped; the entries are retried
+// either way.
+func WithWriteBufferErrorHandler(fn func(error)) RedisOption {
+	return func(c *redisConfig) { c.bufferOnError = fn }
+}
+
+// writeBuffer holds the entries WithWriteBuffer has not written yet.
+// Entries move from pending to inflight while a flush writes them, so
+// reads still find them. A nil *writeBuffer is disabled.
+type writeBuffer[K comparable, V any] struct {
+	mu       sync.Mutex
+	pending  map[K]types.Entry[V]
+	inflight map[K]types.Entry[V]
+	max      int
+
+	flushMu sync.Mutex // serializes flushes, so a later write never lands first
+	onError func(error)
+	stop    chan struct{}
+	done    chan struct{}
+	closing sync.Once
+}
+
+func newWriteBuffer[K comparable, V any](maxEntries int, onError func(error)) *writeBuffer[K, V] {
+	return &writeBuffer[K, V]{
+		pending: make(map[K]types.Entry[V]),
+		max:     maxEntries,
+		onError: onError,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// add buffers entries and reports whether they should be flushed now:
+// the buffer is full, or Close has stopped the background flusher.
+func (w *writeBuffer[K, V]) add(entries map[K]types.Entry[V]) bool {
+	w.mu.Lock()
+	maps.Copy(w.pending, entries)
+	full := w.max > 0 && len(w.pending) >= w.max
+	w.mu.Unlock()
+	select {
+	case <-w.stop:
+		return true
+	default:
+		return full
+	}
+}
+
+// get returns the newest buffered entry for key.
+func (w *writeBuffer[K, V]) get(key K) (types.Entry[V], bool) {
+	if w == nil {
+		return types.Entry[V]{}, false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if e, ok := w.pending[key]; ok {
+		return e, true
+	}
+	e, ok := w.inflight[key]
+	return e, ok
+}
+
+// take moves the pending entries to inflight and returns them.
+func (w *writeBuffer[K, V]) take() map[K]types.Entry[V] {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	batch := w.pending
+	w.pending = make(map[K]types.Entry[V])
+	w.inflight = batch
+	return batch
+}
+
+// finish ends the flush of batch. After a failure, it puts back the
+// entries no newer write has replaced.
+func (w *writeBuffer[K, V]) finish(batch map[K]types.Entry[V], err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.inflight = nil
+	if err == nil {
+		return
+	}
+	for key, e := range batch {
+		if _, ok := w.pending[key]; !ok {
+			w.pending[key] = e
+		}
+	}
+}
+
+// buffer adds entries to the write buffer, flushing it if that fills it.
+// Values are encoded first, so a value the codec rejects fails the call
+// that wrote it rather than a later flush.
+func (b *RedisBackend[K, V]) buffer(ctx context.Context, entries map[K]types.Entry[V]) error {
+	for _, e := range entries {
+		if _, err := b.codec.Marshal(e.Value); err != nil {
+			return fmt.Errorf("failed to marshal value: %w", err)
+		}
+	}
+	if b.writes.add(entries) {
+		return b.flushWrites(ctx)
+	}
+	return nil
+}
+
+// flushWrites writes the buffered entries to Redis on one pipeline. It is
+// a no-op without WithWriteBuffer.
+func (b *RedisBackend[K, V]) flushWrites(ctx context.Context) error {
+	w := b.writes
+	if w == nil {
+		return nil
+	}
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+	batch := w.take()
+	if len(batch) == 0 {
+		w.finish(batch, nil)
+		return nil
+	}
+	err := b.setEntries(ctx, batch, b.lenDelta(), "failed to flush write buffer to Redis")
+	w.finish(batch, err)
+	return err
+}
+
+// runWriteBuffer flushes the write buffer every interval until Close.
+func (b *RedisBackend[K, V]) runWriteBuffer(interval time.Duration) {
+	w := b.writes
+	defer close(w.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if err := b.flushWrites(context.Background()); err != nil && w.onError != nil {
+				w.onError(err)
+			}
+		}
+	}
+}
+
+// closeWrites stops the background flusher and writes what is left.
+func (b *RedisBackend[K, V]) closeWrites() error {
+	w := b.writes
+	if w == nil {
+		return nil
+	}
+	w.closing.Do(func() { close(w.stop) })
+	<-w.done
+	return b.flushWrites(context.Background())
+}
+
+// Sync writes the entries buffered by WithWriteBuffer to Redis. It is a
+// no-op without it.
+func (b *RedisBackend[K, V]) Sync(ctx context.Context) error {
+	return b.flushWrites(ctx)
+}
+
+// bufferedBatch splits keys into the entries found in the write buffer and
+// the keys left to read from Redis.
+func (b *RedisBackend[K, V]) bufferedBatch(keys []K) (map[K]types.Entry[V], []K) {
+	if b.writes == nil {
+		return nil, keys
+	}
+	found := make(map[K]types.Entry[V])
+	rest := make([]K, 0, len(keys))
+	for _, key := range keys {
+		if e, ok := b.writes.get(key); ok {
+			found[key] = e
+		} else {
+			rest = append(rest, key)
+		}
+	}
+	return found, rest
+}
@@ -0,0 +1,71 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+func TestWriteBuffer(t *testing.T) {
+	w := newWriteBuffer[string, string](2, nil)
+	if w.add(map[string]types.Entry[string]{"a": {Value: "1"}}) {
+		t.Fatal("buffer with one of two entries reported full")
+	}
+	if !w.add(map[string]types.Entry[string]{"b": {Value: "2"}}) {
+		t.Fatal("buffer with two of two entries did not report full")
+	}
+
+	batch := w.take()
+	if len(batch) != 2 {
+		t.Fatalf("expected 2 entries taken, got %d", len(batch))
+	}
+	if e, ok := w.get("a"); !ok || e.Value != "1" {
+		t.Errorf("expected in-flight entry a=1, got %v %v", e.Value, ok)
+	}
+	w.add(map[string]types.Entry[string]{"a": {Value: "3"}})
+	w.finish(batch, errors.New("down"))
+	if e, _ := w.get("a"); e.Value != "3" {
+		t.Errorf("failed flush replaced a newer write: got %q", e.Value)
+	}
+	if e, ok := w.get("b"); !ok || e.Value != "2" {
+		t.Errorf("failed flush dropped b: got %v %v", e.Value, ok)
+	}
+
+	w.finish(w.take(), nil)
+	if _, ok := w.get("a"); ok {
+		t.Error("entry still buffered after a successful flush")
+	}
+}
+
+func TestWriteBufferReads(t *testing.T) {
+	ctx := context.Background()
+	b := &RedisBackend[string, string]{codec: JSONCodec{}, writes: newWriteBuffer[string, string](0, nil)}
+	if err := b.SetEntry(ctx, "k", types.Entry[string]{Embedding: []float64{1, 2}, Value: "v"}); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok, err := b.Get(ctx, "k"); err != nil || !ok || v != "v" {
+		t.Errorf("Get = %q, %v, %v; want the buffered value", v, ok, err)
+	}
+	if ok, err := b.Contains(ctx, "k"); err != nil || !ok {
+		t.Errorf("Contains = %v, %v; want true", ok, err)
+	}
+	embs, err := b.GetEmbeddings(ctx, []string{"k"})
+	if err != nil || len(embs["k"]) != 2 {
+		t.Errorf("GetEmbeddings = %v, %v; want the buffered embedding", embs, err)
+	}
+
+	bad := &RedisBackend[string, func()]{codec: JSONCodec{}, writes: newWriteBuffer[string, func()](0, nil)}
+	if err := bad.Set(ctx, "k", nil, func() {}); err == nil {
+		t.Error("expected Set to report a value the codec rejects")
+	}
+}
+
+func TestWriteBufferOptions(t *testing.T) {
+	for _, opt := range []RedisOption{WithWriteBuffer(-1, 0), WithWriteBuffer(0, -1)} {
+		if _, err := NewRedisBackend[string, string]("localhost:0", opt); !errors.Is(err, ErrInvalidRedisOption) {
+			t.Errorf("expected ErrInvalidRedisOption, got %v", err)
+		}
+	}
+}
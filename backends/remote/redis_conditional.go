@@ -0,0 +1,111 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/botirk38/semanticcache/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// SetIfAbsent stores entry only if key does not exist. The key is watched,
+// so a concurrent write makes the call report false instead of being
+// overwritten.
+func (b *RedisBackend[K, V]) SetIfAbsent(ctx context.Context, key K, entry types.Entry[V]) (bool, error) {
+	return b.setIf(ctx, key, entry, func(tx *redis.Tx, k string) (bool, error) {
+		n, err := tx.Exists(ctx, k).Result()
+		return n == 0, err
+	})
+}
+
+// CompareAndSwap stores entry only if key exists and its value encodes to
+// the same bytes as old under the backend's codec. The key is watched, as
+// in SetIfAbsent.
+func (b *RedisBackend[K, V]) CompareAndSwap(ctx context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	want, err := b.codec.Marshal(old)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal value: %w", err)
+	}
+	return b.setIf(ctx, key, entry, func(tx *redis.Tx, k string) (bool, error) {
+		cur, ok, err := b.watchedValue(ctx, tx, k)
+		if err != nil || !ok {
+			return false, err
+		}
+		got, err := b.codec.Marshal(cur)
+		if err != nil {
+			return false, fmt.Errorf("failed to marshal value: %w", err)
+		}
+		return bytes.Equal(got, want), nil
+	})
+}
+
+// setIf writes entry in a MULTI/EXEC block if cond holds while key is
+// watched.
+func (b *RedisBackend[K, V]) setIf(ctx context.Context, key K, entry types.Entry[V], cond func(tx *redis.Tx, k string) (bool, error)) (bool, error) {
+	if err := b.flushWrites(ctx); err != nil {
+		return false, err
+	}
+	k := b.keyString(key)
+	stored := false
+	d := b.lenDelta()
+	err := b.client.Watch(ctx, func(tx *redis.Tx) error {
+		ok, err := cond(tx, k)
+		if err != nil || !ok {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			d.set(ctx, pipe, k)
+			_, err := b.queueSet(ctx, pipe, key, entry)
+			return err
+		})
+		stored = err == nil
+		return err
+	}, k)
+	if errors.Is(err, redis.TxFailedErr) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to set entry in Redis: %w", err)
+	}
+	if stored {
+		return true, b.addLen(ctx, d.n())
+	}
+	return false, nil
+}
+
+// watchedValue reads the value stored at k inside a WATCH block.
+func (b *RedisBackend[K, V]) watchedValue(ctx context.Context, tx *redis.Tx, k string) (V, bool, error) {
+	var v V
+	if b.storage == StorageHash {
+		data, err := tx.HGet(ctx, k, hashFieldValue).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return v, false, nil
+		}
+		if err != nil {
+			return v, false, err
+		}
+		if err := b.unmarshalValue(data, &v); err != nil {
+			return v, false, err
+		}
+		return v, true, nil
+	}
+	result, err := tx.JSONGet(ctx, k, "$.value").Result()
+	if errors.Is(err, redis.Nil) || (err == nil && result == "") {
+		return v, false, nil
+	}
+	if err != nil {
+		return v, false, err
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal([]byte(result), &values); err != nil {
+		return v, false, fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+	if len(values) == 0 {
+		return v, false, nil
+	}
+	v, err = b.decodeValue(values[0])
+	return v, err == nil, err
+}
@@ -0,0 +1,265 @@
+package remote
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/botirk38/semanticcache/similarity"
+	"github.com/botirk38/semanticcache/types"
+	"github.com/redis/go-redis/v9"
+)
+
+// Storage selects how RedisBackend lays out entries on the server.
+type Storage int
+
+const (
+	// StorageAuto uses StorageJSON when the server answers JSON commands and
+	// StorageHash otherwise. It is the default.
+	StorageAuto Storage = iota
+	// StorageJSON stores each entry as a RedisJSON document.
+	StorageJSON
+	// StorageHash stores each entry as a plain hash, for servers without
+	// RedisJSON such as Valkey and Dragonfly.
+	StorageHash
+)
+
+// String returns the storage mode's name.
+func (s Storage) String() string {
+	switch s {
+	case StorageAuto:
+		return "auto"
+	case StorageJSON:
+		return "json"
+	case StorageHash:
+		return "hash"
+	}
+	return fmt.Sprintf("Storage(%d)", int(s))
+}
+
+// Hash fields used by StorageHash.
+const (
+	hashFieldKey       = "key"
+	hashFieldValue     = "value"
+	hashFieldEmbedding = "embedding"
+	hashFieldInputText = "input_text"
+	hashFieldCreatedAt = "created_at"
+	hashFieldMetadata  = "metadata"
+	hashFieldVersion   = "embedding_version"
+)
+
+// detectStorage probes for RedisJSON with a JSON.GET on a key that should
+// not exist. Servers without the module reply with an unknown command error.
+func detectStorage(client *redis.Client, prefix string) (Storage, error) {
+	err := client.JSONGet(context.Background(), prefix+"__storage_probe__", "$").Err()
+	switch {
+	case err == nil || errors.Is(err, redis.Nil):
+		return StorageJSON, nil
+	case strings.Contains(strings.ToLower(err.Error()), "unknown command"):
+		return StorageHash, nil
+	}
+	return StorageAuto, fmt.Errorf("failed to detect Redis storage: %w", err)
+}
+
+// Storage reports the layout the backend uses, resolved from StorageAuto
+// at construction.
+func (b *RedisBackend[K, V]) Storage() Storage { return b.storage }
+
+// hashFields returns the HSET field/value pairs for an entry. Values are
+// stored as raw codec bytes. Every field is always written, empty when
+// unset, so an overwrite never leaves stale fields behind.
+func (b *RedisBackend[K, V]) hashFields(key K, entry types.Entry[V]) ([]any, error) {
+	data, err := b.marshalValue(entry.Value)
+	if err != nil {
+		return nil, err
+	}
+	var created, meta string
+	if !entry.CreatedAt.IsZero() {
+		created = entry.CreatedAt.Format(time.RFC3339Nano)
+	}
+	if len(entry.Metadata) > 0 {
+		m, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		meta = string(m)
+	}
+	return []any{
+		hashFieldKey, b.keys.EncodeKey(key),
+		hashFieldValue, data,
+		hashFieldEmbedding, b.encodeEmbedding(entry.Embedding),
+		hashFieldInputText, entry.InputText,
+		hashFieldCreatedAt, created,
+		hashFieldMetadata, meta,
+		hashFieldVersion, entry.EmbeddingVersion,
+	}, nil
+}
+
+func (b *RedisBackend[K, V]) hashEntry(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	var entry types.Entry[V]
+	fields, err := b.client.HGetAll(ctx, b.keyString(key)).Result()
+	if err != nil {
+		return entry, false, fmt.Errorf("failed to get entry from Redis: %w", err)
+	}
+	if len(fields) == 0 {
+		return entry, false, nil
+	}
+	if err := b.unmarshalValue([]byte(fields[hashFieldValue]), &entry.Value); err != nil {
+		return entry, false, err
+	}
+	if entry.Embedding, err = decodeEmbedding([]byte(fields[hashFieldEmbedding])); err != nil {
+		return entry, false, err
+	}
+	entry.InputText = fields[hashFieldInputText]
+	entry.EmbeddingVersion = fields[hashFieldVersion]
+	if s := fields[hashFieldCreatedAt]; s != "" {
+		if entry.CreatedAt, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return entry, false, fmt.Errorf("failed to parse creation time: %w", err)
+		}
+	}
+	if s := fields[hashFieldMetadata]; s != "" {
+		if err := json.Unmarshal([]byte(s), &entry.Metadata); err != nil {
+			return entry, false, fmt.Errorf("failed to unmarshal metadata: %w", err)
+		}
+	}
+	return entry, true, nil
+}
+
+func (b *RedisBackend[K, V]) hashGet(ctx context.Context, key K) (V, bool, error) {
+	var v V
+	data, err := b.client.HGet(ctx, b.keyString(key), hashFieldValue).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return v, false, nil
+	}
+	if err != nil {
+		return v, false, fmt.Errorf("failed to get entry from Redis: %w", err)
+	}
+	if err := b.unmarshalValue(data, &v); err != nil {
+		return v, false, err
+	}
+	return v, true, nil
+}
+
+func (b *RedisBackend[K, V]) hashGetBatch(ctx context.Context, keys []K, result map[K]V) (map[K]V, error) {
+	pipe := b.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HGet(ctx, b.keyString(key), hashFieldValue)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get batch from Redis: %w", err)
+	}
+	for i, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get batch from Redis: %w", err)
+		}
+		var v V
+		if err := b.unmarshalValue(data, &v); err != nil {
+			return nil, err
+		}
+		result[keys[i]] = v
+	}
+	return result, nil
+}
+
+func (b *RedisBackend[K, V]) hashEmbedding(ctx context.Context, key K) ([]float64, bool, error) {
+	data, err := b.client.HGet(ctx, b.keyString(key), hashFieldEmbedding).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get embedding from Redis: %w", err)
+	}
+	embedding, err := decodeEmbedding(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return embedding, true, nil
+}
+
+func (b *RedisBackend[K, V]) hashEmbeddings(ctx context.Context, keys []K, result map[K][]float64) (map[K][]float64, error) {
+	pipe := b.client.Pipeline()
+	cmds := make([]*redis.StringCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.HGet(ctx, b.keyString(key), hashFieldEmbedding)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to get embeddings from Redis: %w", err)
+	}
+	for i, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get embeddings from Redis: %w", err)
+		}
+		embedding, err := decodeEmbedding(data)
+		if err != nil {
+			return nil, err
+		}
+		result[keys[i]] = embedding
+	}
+	return result, nil
+}
+
+// encodeEmbedding packs v as little-endian float64s.
+func encodeEmbedding(v []float64) []byte {
+	buf := make([]byte, 8*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint64(buf[8*i:], math.Float64bits(f))
+	}
+	return buf
+}
+
+// float16Tag starts an embedding packed by encodeFloat16Embedding. The tag
+// makes the length odd, so it never parses as float64s.
+const float16Tag = 'h'
+
+// encodeFloat16Embedding packs v as float16Tag followed by little-endian
+// float16s.
+func encodeFloat16Embedding(v []float64) []byte {
+	buf := make([]byte, 1+2*len(v))
+	buf[0] = float16Tag
+	for i, h := range similarity.ToFloat16(v) {
+		binary.LittleEndian.PutUint16(buf[1+2*i:], h)
+	}
+	return buf
+}
+
+// encodeEmbedding packs v in the backend's embedding precision.
+func (b *RedisBackend[K, V]) encodeEmbedding(v []float64) []byte {
+	if b.float16 {
+		return encodeFloat16Embedding(v)
+	}
+	return encodeEmbedding(v)
+}
+
+// decodeEmbedding reverses encodeEmbedding and encodeFloat16Embedding, so
+// entries written with and without WithFloat16 stay readable.
+func decodeEmbedding(data []byte) ([]float64, error) {
+	if len(data)%2 == 1 && data[0] == float16Tag {
+		h := make([]uint16, len(data)/2)
+		for i := range h {
+			h[i] = binary.LittleEndian.Uint16(data[1+2*i:])
+		}
+		return similarity.FromFloat16(h), nil
+	}
+	if len(data)%8 != 0 {
+		return nil, fmt.Errorf("remote: embedding has %d bytes, not a multiple of 8", len(data))
+	}
+	v := make([]float64, len(data)/8)
+	for i := range v {
+		v[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+	}
+	return v, nil
+}
@@ -0,0 +1,57 @@
+package remote
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestEmbeddingEncoding(t *testing.T) {
+	in := []float64{0, -1.5, math.Pi, math.Inf(1), math.SmallestNonzeroFloat64}
+	out, err := decodeEmbedding(encodeEmbedding(in))
+	if err != nil {
+		t.Fatalf("decodeEmbedding: %v", err)
+	}
+	if !slices.Equal(in, out) {
+		t.Errorf("round trip gave %v, want %v", out, in)
+	}
+	if _, err := decodeEmbedding([]byte{1, 2, 3}); err == nil {
+		t.Error("expected error for truncated embedding")
+	}
+}
+
+func TestFloat16Embedding(t *testing.T) {
+	in := []float64{0, -1.5, 0.25, 1024}
+	packed := encodeFloat16Embedding(in)
+	if len(packed) != 1+2*len(in) {
+		t.Fatalf("expected 2 bytes per dimension plus a tag, got %d bytes", len(packed))
+	}
+	out, err := decodeEmbedding(packed)
+	if err != nil || !slices.Equal(in, out) {
+		t.Errorf("round trip gave %v (err=%v), want %v", out, err, in)
+	}
+
+	for _, half := range []bool{false, true} {
+		data, err := json.Marshal(redisDocument{Embedding: redisEmbedding{values: in, half: half}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc redisDocument
+		if err := json.Unmarshal(data, &doc); err != nil || !slices.Equal(doc.Embedding.values, in) || doc.Embedding.half != half {
+			t.Errorf("half=%v: document round trip gave %+v (err=%v) from %s", half, doc.Embedding, err, data)
+		}
+	}
+	var doc redisDocument
+	if err := json.Unmarshal([]byte(`{"embedding":null}`), &doc); err != nil || doc.Embedding.values != nil {
+		t.Errorf("expected a null embedding to decode as nil, got %v (err=%v)", doc.Embedding.values, err)
+	}
+}
+
+func TestStorageString(t *testing.T) {
+	for s, want := range map[Storage]string{StorageAuto: "auto", StorageJSON: "json", StorageHash: "hash", Storage(9): "Storage(9)"} {
+		if got := s.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", int(s), got, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// WithLenCounter keeps the number of entries in the Redis key key, so
+// ApproxLen costs one GET instead of a full SCAN. Every write then checks
+// whether its key already existed, in the same MULTI/EXEC block, and
+// adjusts the counter. key must not start with the backend's prefix.
+func WithLenCounter(key string) RedisOption {
+	return func(c *redisConfig) { c.lenKey = key }
+}
+
+// ApproxLen returns the entry count kept by WithLenCounter. Writes through
+// any backend sharing the prefix and counter key keep it exact, but entries
+// that expire or that Redis evicts are never subtracted, so it can
+// overcount until the next Len, which recounts and resets it. Without
+// WithLenCounter, ApproxLen is Len.
+func (b *RedisBackend[K, V]) ApproxLen(ctx context.Context) (int, error) {
+	if err := b.flushWrites(ctx); err != nil {
+		return 0, err
+	}
+	if b.lenKey == "" {
+		return b.Len(ctx)
+	}
+	n, err := b.client.Get(ctx, b.lenKey).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get entry count from Redis: %w", err)
+	}
+	return max(n, 0), nil
+}
+
+// initLen seeds a missing counter with a full count, so entries written
+// before WithLenCounter was enabled are included.
+func (b *RedisBackend[K, V]) initLen(ctx context.Context) error {
+	n, err := b.client.Exists(ctx, b.lenKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check entry count in Redis: %w", err)
+	}
+	if n == 0 {
+		_, err = b.Len(ctx)
+	}
+	return err
+}
+
+// lenDelta collects the replies that tell how a pipeline of writes changed
+// the number of entries. A nil *lenDelta, for a backend without a counter,
+// queues and collects nothing.
+type lenDelta struct {
+	existed []*redis.IntCmd // EXISTS queued before each write
+	deleted []*redis.IntCmd // DEL replies
+}
+
+func (b *RedisBackend[K, V]) lenDelta() *lenDelta {
+	if b.lenKey == "" {
+		return nil
+	}
+	return &lenDelta{}
+}
+
+// pipeline returns a pipeline for writes, run in MULTI/EXEC when d is set
+// so no other client's write lands between an EXISTS and its write.
+func (b *RedisBackend[K, V]) pipeline(d *lenDelta) redis.Pipeliner {
+	if d == nil {
+		return b.client.Pipeline()
+	}
+	return b.client.TxPipeline()
+}
+
+// set queues an EXISTS on k ahead of a write to it.
+func (d *lenDelta) set(ctx context.Context, pipe redis.Pipeliner, k string) {
+	if d != nil {
+		d.existed = append(d.existed, pipe.Exists(ctx, k))
+	}
+}
+
+// del records the reply of a queued DEL.
+func (d *lenDelta) del(cmd *redis.IntCmd) {
+	if d != nil {
+		d.deleted = append(d.deleted, cmd)
+	}
+}
+
+// n returns the net change in entries once the pipeline has run.
+func (d *lenDelta) n() int64 {
+	if d == nil {
+		return 0
+	}
+	var n int64
+	for _, cmd := range d.existed {
+		if cmd.Val() == 0 {
+			n++
+		}
+	}
+	for _, cmd := range d.deleted {
+		n -= cmd.Val()
+	}
+	return n
+}
+
+// addLen adjusts the counter by n, if there is one.
+func (b *RedisBackend[K, V]) addLen(ctx context.Context, n int64) error {
+	if b.lenKey == "" || n == 0 {
+		return nil
+	}
+	if err := b.client.IncrBy(ctx, b.lenKey, n).Err(); err != nil {
+		return fmt.Errorf("failed to update entry count in Redis: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package remote
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLenCounterUnderPrefix(t *testing.T) {
+	for _, opts := range [][]RedisOption{
+		{WithLenCounter("semanticcache:len")},
+		{WithPrefix("app:"), WithLenCounter("app:count")},
+		{WithPrefix(""), WithLenCounter("count")},
+	} {
+		if _, err := NewRedisBackend[string, string]("localhost:0", opts...); !errors.Is(err, ErrInvalidRedisOption) {
+			t.Errorf("expected ErrInvalidRedisOption for a counter key under the prefix, got %v", err)
+		}
+	}
+}
+
+func TestNegativeTimeoutsReachClient(t *testing.T) {
+	cfg := &redisConfig{readTimeout: -1, writeTimeout: -2}
+	opts, err := redisOptions("localhost:6379", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.ReadTimeout != -1 || opts.WriteTimeout != -2 {
+		t.Errorf("expected timeouts -1 and -2, got %v and %v", opts.ReadTimeout, opts.WriteTimeout)
+	}
+}
+
+func TestNegativeDialTimeout(t *testing.T) {
+	if _, err := NewRedisBackend[string, string]("localhost:0", WithDialTimeout(-1)); !errors.Is(err, ErrInvalidRedisOption) {
+		t.Errorf("expected ErrInvalidRedisOption for a negative dial timeout, got %v", err)
+	}
+}
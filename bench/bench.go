@@ -0,0 +1,287 @@
+// Package bench compares backends on synthetic data of a chosen size and
+// dimension. It stores the same clustered embeddings in a cache per
+// candidate backend, runs the same queries through TopMatches, and reports
+// lookup latency and recall against an exact search, so a backend can be
+// chosen from measurements on the dimension an application actually uses.
+package bench
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/options"
+	"github.com/botirk38/semanticcache/similarity"
+)
+
+// ErrInvalidConfig is returned by Run when a Config value is out of range.
+var ErrInvalidConfig = errors.New("bench: invalid config")
+
+// loadBatch is the number of entries stored per SetBatch call.
+const loadBatch = 1000
+
+// Config sizes a run. Zero fields take the defaults noted on each.
+type Config struct {
+	Entries    int    // entries stored; default 10000
+	Dimensions int    // embedding dimension; default 384
+	Queries    int    // lookups timed; default 200
+	K          int    // results per lookup, and the k of recall@k; default 10
+	Seed       uint64 // seeds the synthetic data, so runs are repeatable
+}
+
+func (c Config) withDefaults() Config {
+	c.Entries = cmp.Or(c.Entries, 10000)
+	c.Dimensions = cmp.Or(c.Dimensions, 384)
+	c.Queries = cmp.Or(c.Queries, 200)
+	c.K = cmp.Or(c.K, 10)
+	return c
+}
+
+func (c Config) validate() error {
+	switch {
+	case c.Entries < 0 || c.Dimensions < 0 || c.Queries < 0 || c.K < 0:
+		return fmt.Errorf("%w: sizes must not be negative", ErrInvalidConfig)
+	case c.K > c.Entries:
+		return fmt.Errorf("%w: k %d exceeds %d entries", ErrInvalidConfig, c.K, c.Entries)
+	}
+	return nil
+}
+
+// Candidate is a backend to measure. Backend is an option such as
+// options.WithHNSWBackend; each run builds a fresh cache from it, so a
+// remote backend should use a prefix or database of its own, since the
+// entries are not removed afterwards.
+type Candidate struct {
+	Name    string
+	Backend options.Option[string, int]
+}
+
+// DefaultCandidates returns the in-memory backends a cache can search:
+// a brute-force scan over an LRU backend, the HNSW graph, and the
+// binary-quantized index. entries sizes the LRU.
+func DefaultCandidates(entries int) []Candidate {
+	return []Candidate{
+		{Name: "brute-force", Backend: options.WithLRUBackend[string, int](max(entries, 1))},
+		{Name: "hnsw", Backend: options.WithHNSWBackend[string, int]()},
+		{Name: "binary", Backend: options.WithBinaryBackend[string, int]()},
+	}
+}
+
+// Result is one candidate's measurements. Latencies are of TopMatches
+// calls, including the synthetic provider's map lookup.
+type Result struct {
+	Name string
+	Load time.Duration // storing every entry with SetBatch
+
+	Mean, P50, P95, P99 time.Duration
+
+	// Recall is the mean share of each query's exact top K that the
+	// candidate returned.
+	Recall float64
+
+	// Err is set when the candidate could not be built, loaded, or
+	// queried; the other fields are then zero.
+	Err error
+}
+
+// Run measures each candidate on the same data. It returns an error only
+// for an invalid cfg; a candidate that fails reports it in its Result.
+func Run(ctx context.Context, cfg Config, candidates ...Candidate) ([]Result, error) {
+	cfg = cfg.withDefaults()
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	for _, c := range candidates {
+		if c.Backend == nil {
+			return nil, fmt.Errorf("%w: candidate %q has no backend", ErrInvalidConfig, c.Name)
+		}
+	}
+	data := generate(cfg)
+	results := make([]Result, 0, len(candidates))
+	for _, c := range candidates {
+		r, err := run(ctx, cfg, data, c)
+		if err != nil {
+			r = Result{Err: err}
+		}
+		r.Name = c.Name
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// run loads data into a cache on c's backend and times the queries.
+func run(ctx context.Context, cfg Config, data *dataset, c Candidate) (Result, error) {
+	cache, err := semanticcache.New(
+		c.Backend,
+		options.WithCustomProvider[string, int](data),
+	)
+	if err != nil {
+		return Result{}, err
+	}
+	defer func() { _ = cache.Close() }()
+
+	var r Result
+	start := time.Now()
+	for i := 0; i < cfg.Entries; i += loadBatch {
+		items := make([]semanticcache.BatchItem[string, int], 0, min(loadBatch, cfg.Entries-i))
+		for j := i; j < min(i+loadBatch, cfg.Entries); j++ {
+			items = append(items, semanticcache.BatchItem[string, int]{Key: entryText(j), InputText: entryText(j), Value: j})
+		}
+		if err := cache.SetBatch(ctx, items); err != nil {
+			return Result{}, err
+		}
+	}
+	r.Load = time.Since(start)
+
+	latencies := make([]time.Duration, cfg.Queries)
+	var recall float64
+	for q := range cfg.Queries {
+		start := time.Now()
+		matches, err := cache.TopMatches(ctx, queryText(q), cfg.K)
+		latencies[q] = time.Since(start)
+		if err != nil {
+			return Result{}, err
+		}
+		recall += data.recall(q, matches)
+	}
+	if cfg.Queries > 0 {
+		r.Recall = recall / float64(cfg.Queries)
+		r.Mean, r.P50, r.P95, r.P99 = summarize(latencies)
+	}
+	return r, nil
+}
+
+// summarize returns the mean and percentiles of latencies, which it sorts.
+func summarize(latencies []time.Duration) (mean, p50, p95, p99 time.Duration) {
+	slices.Sort(latencies)
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+	at := func(p float64) time.Duration {
+		return latencies[min(int(math.Ceil(p*float64(len(latencies))))-1, len(latencies)-1)]
+	}
+	return total / time.Duration(len(latencies)), at(0.50), at(0.95), at(0.99)
+}
+
+// Format writes results as an aligned table.
+func Format(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BACKEND\tLOAD\tMEAN\tP50\tP95\tP99\tRECALL")
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(tw, "%s\terror: %v\n", r.Name, r.Err)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%v\t%v\t%.3f\n", r.Name,
+			r.Load.Round(time.Millisecond), r.Mean.Round(time.Microsecond), r.P50.Round(time.Microsecond),
+			r.P95.Round(time.Microsecond), r.P99.Round(time.Microsecond), r.Recall)
+	}
+	return tw.Flush()
+}
+
+func entryText(i int) string { return "entry-" + strconv.Itoa(i) }
+func queryText(i int) string { return "query-" + strconv.Itoa(i) }
+
+// dataset holds the synthetic embeddings and, for each query, the keys of
+// its exact top K. It is the runs' embedding provider, answering each
+// entry's and query's text with its embedding.
+type dataset struct {
+	vectors map[string][]float64
+	truth   []map[string]bool
+}
+
+// generate draws entries around sqrt(Entries) random cluster centers, as
+// real embeddings cluster by topic, and queries as noisy copies of random
+// entries, then finds each query's exact neighbors by cosine similarity.
+func generate(cfg Config) *dataset {
+	rng := rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x9e3779b97f4a7c15))
+	gaussian := func(center []float64, spread float64) []float64 {
+		v := make([]float64, cfg.Dimensions)
+		for i := range v {
+			v[i] = rng.NormFloat64() * spread
+			if center != nil {
+				v[i] += center[i]
+			}
+		}
+		return v
+	}
+
+	centers := make([][]float64, max(int(math.Sqrt(float64(cfg.Entries))), 1))
+	for i := range centers {
+		centers[i] = gaussian(nil, 1)
+	}
+	d := &dataset{vectors: make(map[string][]float64, cfg.Entries+cfg.Queries)}
+	entries := make([][]float64, cfg.Entries)
+	for i := range entries {
+		entries[i] = gaussian(centers[rng.IntN(len(centers))], 0.5)
+		d.vectors[entryText(i)] = entries[i]
+	}
+
+	d.truth = make([]map[string]bool, cfg.Queries)
+	scores := make([]int, cfg.Entries)
+	for q := range cfg.Queries {
+		query := gaussian(entries[rng.IntN(cfg.Entries)], 0.2)
+		d.vectors[queryText(q)] = query
+		sims := make([]float64, cfg.Entries)
+		for i, e := range entries {
+			sims[i] = similarity.CosineSimilarity(query, e)
+			scores[i] = i
+		}
+		slices.SortFunc(scores, func(a, b int) int { return cmp.Compare(sims[b], sims[a]) })
+		d.truth[q] = make(map[string]bool, cfg.K)
+		for _, i := range scores[:cfg.K] {
+			d.truth[q][entryText(i)] = true
+		}
+	}
+	return d
+}
+
+// recall returns the share of query q's exact neighbors among matches.
+func (d *dataset) recall(q int, matches []semanticcache.Match[int]) float64 {
+	truth := d.truth[q]
+	if len(truth) == 0 {
+		return 1
+	}
+	hits := 0
+	for _, m := range matches {
+		if truth[entryText(m.Value)] {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(truth))
+}
+
+// EmbedText returns text's synthetic embedding.
+func (d *dataset) EmbedText(_ context.Context, text string) ([]float64, error) {
+	v, ok := d.vectors[text]
+	if !ok {
+		return nil, fmt.Errorf("bench: no embedding for %q", text)
+	}
+	return slices.Clone(v), nil
+}
+
+// EmbedBatch returns the synthetic embeddings of texts.
+func (d *dataset) EmbedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	out := make([][]float64, len(texts))
+	for i, text := range texts {
+		v, err := d.EmbedText(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// Close implements types.EmbeddingProvider.
+func (d *dataset) Close() error { return nil }
@@ -0,0 +1,44 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	cfg := Config{Entries: 300, Dimensions: 16, Queries: 20, K: 5, Seed: 7}
+	results, err := Run(context.Background(), cfg, DefaultCandidates(cfg.Entries)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Fatalf("%s: %v", r.Name, r.Err)
+		}
+		if r.P50 <= 0 || r.P50 > r.P99 || r.Recall <= 0 || r.Recall > 1 {
+			t.Errorf("%s: implausible result %+v", r.Name, r)
+		}
+	}
+	if results[0].Name != "brute-force" || results[0].Recall != 1 {
+		t.Errorf("expected an exact brute-force scan, got %+v", results[0])
+	}
+
+	var out strings.Builder
+	if err := Format(&out, results); err != nil || !strings.Contains(out.String(), "hnsw") {
+		t.Errorf("Format = %q, %v", out.String(), err)
+	}
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	ctx := context.Background()
+	for _, cfg := range []Config{{Entries: -1}, {Entries: 5, K: 10}} {
+		if _, err := Run(ctx, cfg); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("Run(%+v) = %v, want ErrInvalidConfig", cfg, err)
+		}
+	}
+	if _, err := Run(ctx, Config{Entries: 10}, Candidate{Name: "none"}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig for a candidate without a backend, got %v", err)
+	}
+}
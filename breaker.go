@@ -0,0 +1,111 @@
+package semanticcache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// breaker is a circuit breaker around the embedding provider. After
+// threshold consecutive failures it opens, failing provider calls with
+// ErrCircuitOpen for cooldown. Then it lets one probe call through at a
+// time: a success closes it and a failure opens it for another cooldown.
+// Calls the caller cancelled count as neither. A nil *breaker is disabled.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	fallback  bool // answer lookups by exact text while open; see options.WithExactFallback
+
+	failures int
+	openedAt time.Time // zero while closed
+	probing  bool      // a half-open probe is in flight
+}
+
+func newBreaker(threshold int, cooldown time.Duration, fallback bool) *breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &breaker{threshold: threshold, cooldown: cooldown, fallback: fallback}
+}
+
+// allow returns ErrCircuitOpen if a provider call may not start now.
+func (b *breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.openedAt.IsZero():
+		return nil
+	case b.probing || time.Since(b.openedAt) < b.cooldown:
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// record notes the outcome of a call allow let through.
+func (b *breaker) record(ctx context.Context, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	switch {
+	case err == nil:
+		b.failures, b.openedAt = 0, time.Time{}
+	case ctx.Err() != nil:
+		// The caller gave up; the provider may be fine.
+	case !b.openedAt.IsZero():
+		b.openedAt = time.Now()
+	default:
+		if b.failures++; b.failures >= b.threshold {
+			b.openedAt = time.Now()
+		}
+	}
+}
+
+// open reports whether the breaker is failing calls.
+func (b *breaker) open() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openedAt.IsZero()
+}
+
+// ProviderAvailable reports whether embedding calls are being attempted:
+// false while the circuit breaker set by options.WithCircuitBreaker is
+// open, true otherwise. It suits health checks.
+func (c *Cache[K, V]) ProviderAvailable() bool {
+	return !c.breaker.open()
+}
+
+// fallbackMatches answers a lookup without embedding inputText, for
+// options.WithExactFallback: it returns the entry stored under
+// TextKey(inputText), as by SetText, or else the one the exact-match index
+// maps inputText to, with a score of 1. It returns no matches when neither
+// exists or cfg skips or excludes the match.
+func (c *Cache[K, V]) fallbackMatches(ctx context.Context, inputText string, cfg lookupConfig) []MatchEx[K, V] {
+	matches := []MatchEx[K, V]{}
+	if cfg.offset > 0 || cfg.threshold > 1 {
+		return matches
+	}
+	if key, err := textKey[K](inputText); err == nil {
+		entry, found, err := c.getEntry(ctx, key)
+		if err == nil && found && accepts(cfg, key, entry.Metadata) {
+			return append(matches, newMatchEx(key, entry, 1))
+		}
+	}
+	if key, ok := c.exact.get(c.namespace, inputText); ok {
+		entry, found, err := c.getEntry(ctx, key)
+		if err == nil && found && entry.InputText == inputText && accepts(cfg, key, entry.Metadata) {
+			return append(matches, newMatchEx(key, entry, 1))
+		}
+	}
+	return matches
+}
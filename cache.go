@@ -1,14 +1,27 @@
 package semanticcache
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
-	"sort"
+	"iter"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/botirk38/semanticcache/backends/composite"
+	"github.com/botirk38/semanticcache/chunker"
 	"github.com/botirk38/semanticcache/options"
+	"github.com/botirk38/semanticcache/preprocess"
 	"github.com/botirk38/semanticcache/similarity"
+	"github.com/botirk38/semanticcache/tokenizer"
 	"github.com/botirk38/semanticcache/types"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 )
 
 // Cache is a synchronous semantic cache that stores values alongside
@@ -17,13 +30,87 @@ type Cache[K comparable, V any] struct {
 	backend    types.Backend[K, V]
 	provider   types.EmbeddingProvider
 	comparator similarity.SimilarityFunc
-	closed     atomic.Bool
+	threshold  float64
+	closed     *atomic.Bool
+	calls      *inflight // shared with namespace views
+	exact      *exactIndex[K]
+	lexical    *lexicalIndex[K]
+
+	searchWorkers int
+	batchWorkers  int                     // concurrent provider calls for a batch without EmbedBatch
+	version       string                  // embedding version stamped on new entries
+	validateKey   options.KeyValidator[K] // nil rejects the zero key
+	refresh       refreshConfig
+	scheduler     *scheduler    // maintenance jobs; nil runs none
+	expiryAge     time.Duration // see options.WithExpirySweep
+	rerank        rerankConfig
+	scoring       scoringConfig
+	normalize     bool            // map scores into [0, 1]; see options.WithNormalizedScores
+	dims          *atomic.Int64   // expected embedding dimension, 0 until learned; nil skips checks
+	chunker       chunker.Chunker // splits long texts before embedding; nil embeds them whole
+	chunkVectors  bool            // store a vector per chunk; see options.WithChunkVectors
+	lateChunking  bool            // pool token embeddings per chunk; see options.WithLateChunking
+	summary       summaryConfig
+	chunkStats    *chunkCounters       // shared with namespace views
+	onChunk       func(chunker.Report) // see options.WithChunkObserver
+	preprocess    preprocess.Func      // applied to texts before embedding; nil leaves them
+	tracer        trace.Tracer         // see options.WithTracerProvider; nil traces nothing
+	logger        *slog.Logger         // see options.WithLogger; never nil
+	breaker       *breaker             // see options.WithCircuitBreaker; nil always calls the provider
+	queries       *embedGroup          // coalesces concurrent embeddings of the same query
+	alerts        *alerts              // see options.OnMissRateAbove; nil fires no hooks
+
+	// base is the unscoped backend and namespace the view's scope; both
+	// are set only on views returned by Namespace.
+	base      types.Backend[K, V]
+	namespace string
+
+	flightMu sync.Mutex
+	flights  map[flightKey]*flight[V]
+
+	loader composite.Loader[K, V] // see options.WithReadThrough; nil loads nothing
+	loadMu sync.Mutex
+	loads  map[K]*flight[V] // in-progress loads; hit reports found
 }
 
 // Match is a single semantic search result.
 type Match[V any] struct {
 	Value V       `json:"value"`
 	Score float64 `json:"score"`
+
+	// Partial is set when a WithBestEffort lookup stopped scanning at its
+	// deadline, so a better match may have gone unseen.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// MatchEx is a Match that also identifies the matched entry, so callers can
+// refresh or delete it. InputText, CreatedAt, and Metadata are set only when
+// the backend implements types.EntryBackend; all in-memory backends do.
+type MatchEx[K comparable, V any] struct {
+	Match[V]
+	Key       K                 `json:"key"`
+	InputText string            `json:"input_text,omitempty"`
+	CreatedAt time.Time         `json:"created_at,omitzero"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+
+	// Vector is the index of the entry's best-matching vector: 0 for its
+	// embedding, i for the ith extra vector stored by SetMulti.
+	Vector int `json:"vector,omitempty"`
+
+	// Chunk is the matched chunk of the entry's InputText, as preprocessed,
+	// when the cache stores chunk vectors; see options.WithChunkVectors. It
+	// is nil for entries stored whole.
+	Chunk *chunker.Chunk `json:"chunk,omitempty"`
+}
+
+func newMatchEx[K comparable, V any](key K, entry types.Entry[V], score float64) MatchEx[K, V] {
+	return MatchEx[K, V]{
+		Match:     Match[V]{Value: entry.Value, Score: score},
+		Key:       key,
+		InputText: entry.InputText,
+		CreatedAt: entry.CreatedAt,
+		Metadata:  entry.Metadata,
+	}
 }
 
 // BatchItem is an input for SetBatch.
@@ -31,6 +118,17 @@ type BatchItem[K comparable, V any] struct {
 	Key       K
 	InputText string
 	Value     V
+	Metadata  map[string]string
+}
+
+// TxnItem is an input for Txn. It sets Key to Value with an embedding
+// computed from InputText, or deletes Key when Delete is true.
+type TxnItem[K comparable, V any] struct {
+	Key       K
+	InputText string
+	Value     V
+	Metadata  map[string]string
+	Delete    bool
 }
 
 // New creates a Cache using functional options.
@@ -42,11 +140,88 @@ func New[K comparable, V any](opts ...options.Option[K, V]) (*Cache[K, V], error
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
-	return &Cache[K, V]{
+	splitter := cfg.Chunker
+	if cfg.ChunkConfig != nil {
+		config := *cfg.ChunkConfig
+		if config.Tokenizer == nil {
+			config.Tokenizer = tokenizer.ForProvider(cfg.Provider)
+		}
+		var err error
+		if splitter, err = chunker.NewChunker(config); err != nil {
+			return nil, err
+		}
+	}
+	c := &Cache[K, V]{
 		backend:    cfg.Backend,
 		provider:   cfg.Provider,
 		comparator: cfg.Comparator,
-	}, nil
+		threshold:  cfg.Threshold,
+		closed:     new(atomic.Bool),
+		calls:      new(inflight),
+		exact:      newExactIndex[K](cfg.ExactMatch),
+		lexical:    newLexicalIndex[K](cfg.LexicalIndex),
+
+		searchWorkers: cfg.SearchConcurrency,
+		batchWorkers:  cfg.BatchConcurrency,
+		version:       cmp.Or(cfg.EmbeddingVersion, providerVersion(cfg.Provider)),
+		validateKey:   cfg.KeyValidator,
+		refresh: refreshConfig{
+			maxAge:    cfg.RefreshMaxAge,
+			batchSize: cfg.RefreshBatchSize,
+			onLookup:  cfg.ReembedOnLookup,
+		},
+		rerank: rerankConfig{
+			reranker: cfg.Reranker,
+			topK:     cfg.RerankTopK,
+			timeout:  cfg.RerankTimeout,
+		},
+		scoring: scoringConfig{
+			halfLife:  cfg.RecencyHalfLife,
+			recency:   cfg.RecencyWeight,
+			frequency: cfg.FrequencyWeight,
+		},
+		normalize: cfg.NormalizeScores,
+		chunker:   splitter,
+
+		chunkVectors: cfg.ChunkVectors,
+		lateChunking: cfg.LateChunking,
+		summary:      newSummaryConfig(cfg.Summarizer, cfg.SummaryWorkers, cfg.SummaryMinTokens),
+		chunkStats:   new(chunkCounters),
+		onChunk:      cfg.OnChunk,
+		preprocess:   cfg.Preprocess,
+		tracer:       newTracer(cfg.TracerProvider),
+		logger:       cmp.Or(cfg.Logger, discardLogger),
+		breaker:      newBreaker(cfg.BreakerFailures, cfg.BreakerCooldown, cfg.ExactFallback),
+		queries:      newEmbedGroup(),
+		loader:       cfg.Loader,
+		alerts: newAlerts(alertConfig{
+			missRate:    cfg.MissRateAlert,
+			missWindow:  cfg.MissRateWindow,
+			minLookups:  cfg.MissRateMinLookups,
+			onMiss:      cfg.OnMissRate,
+			burst:       cfg.ProviderErrorBurst,
+			errorWindow: cfg.ProviderErrorWindow,
+			onErrors:    cfg.OnProviderErrors,
+		}),
+	}
+	if cfg.CheckDimensions {
+		c.dims = new(atomic.Int64)
+		c.dims.Store(int64(cfg.Dimensions))
+	}
+	// Evictions prune the lexical index before reaching the caller's
+	// callback.
+	if n, ok := cfg.Backend.(types.EvictionNotifier[K, V]); ok && (cfg.OnEvict != nil || c.lexical != nil) {
+		n.OnEvict(func(key K, entry types.Entry[V]) {
+			c.pruneEvicted(key, entry)
+			if cfg.OnEvict != nil {
+				cfg.OnEvict(key, entry)
+			}
+		})
+	}
+	c.expiryAge = cfg.ExpiryMaxAge
+	c.scheduler = newScheduler(c.logger, c.maintenanceJobs(cfg.RefreshInterval, cfg.LexicalSweep, cfg.ExpirySweep),
+		cfg.MaintenanceRate, cfg.MaintenanceJitter)
+	return c, nil
 }
 
 // NewSemanticCache creates a Cache from explicit components.
@@ -68,169 +243,893 @@ func NewSemanticCache[K comparable, V any](
 		backend:    backend,
 		provider:   provider,
 		comparator: comparator,
+		threshold:  options.DefaultThreshold,
+		closed:     new(atomic.Bool),
+		calls:      new(inflight),
+		version:    providerVersion(provider),
+		refresh:    refreshConfig{batchSize: options.DefaultRefreshBatchSize},
+		chunkStats: new(chunkCounters),
+		logger:     discardLogger,
+		queries:    newEmbedGroup(),
 	}, nil
 }
 
-func (c *Cache[K, V]) checkClosed() error {
+// discardLogger serves caches without options.WithLogger.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// providerVersion returns the provider's embedding version, if it has one.
+func providerVersion(provider types.EmbeddingProvider) string {
+	if vp, ok := provider.(types.VersionedProvider); ok {
+		return vp.EmbeddingVersion()
+	}
+	return ""
+}
+
+// inflight counts the calls in progress on a cache and its Namespace
+// views, so Shutdown can wait for them.
+type inflight struct {
+	mu sync.RWMutex // held for writing while Shutdown marks the cache closed
+	wg sync.WaitGroup
+}
+
+// begin registers a call, or returns ErrClosed once Shutdown has started.
+// Every successful begin must be paired with end.
+func (c *Cache[K, V]) begin() error {
+	c.calls.mu.RLock()
+	defer c.calls.mu.RUnlock()
 	if c.closed.Load() {
 		return ErrClosed
 	}
+	c.calls.wg.Add(1)
+	return nil
+}
+
+func (c *Cache[K, V]) end() { c.calls.wg.Done() }
+
+// wait blocks until every registered call has ended or ctx ends.
+func (f *inflight) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("in-flight calls: %w", ctx.Err())
+	}
+}
+
+// checkKey applies the key validator set by options.WithKeyValidator, or
+// rejects the zero key when there is none.
+func (c *Cache[K, V]) checkKey(key K) error {
+	if c.validateKey != nil {
+		return c.validateKey(key)
+	}
+	if key == *new(K) {
+		return ErrZeroKey
+	}
 	return nil
 }
 
 // Set stores a value, computing the embedding from inputText.
 func (c *Cache[K, V]) Set(ctx context.Context, key K, inputText string, value V) error {
-	if err := c.checkClosed(); err != nil {
+	return c.SetWithMetadata(ctx, key, inputText, value, nil)
+}
+
+// SetWithMetadata is Set with metadata attached to the entry, which
+// WithFilter can later match on. The backend must implement
+// types.EntryBackend to store non-empty metadata, otherwise
+// ErrMetadataUnsupported is returned.
+func (c *Cache[K, V]) SetWithMetadata(ctx context.Context, key K, inputText string, value V, metadata map[string]string) (err error) {
+	ctx, span := c.startSpan(ctx, "Set")
+	defer func() { endSpan(span, err) }()
+	if err := c.begin(); err != nil {
 		return err
 	}
-	if key == *new(K) {
-		return ErrZeroKey
+	defer c.end()
+	if err := c.checkKey(key); err != nil {
+		return err
+	}
+	if err := c.checkMetadata(metadata); err != nil {
+		return err
 	}
-	embedding, err := c.provider.EmbedText(ctx, inputText)
+	vectors, err := c.embedEntries(ctx, []string{inputText})
 	if err != nil {
 		return err
 	}
-	return c.backend.Set(ctx, key, embedding, value)
+	if err := c.setEntry(ctx, key, c.newChunkedEntry(vectors[0], inputText, value, metadata)); err != nil {
+		return err
+	}
+	c.indexText(inputText, key)
+	return nil
+}
+
+// SetWithEmbedding is Set with an embedding already computed for inputText,
+// such as one reported by WithQueryEmbedding, so a lookup followed by a
+// store embeds the text once. The embedding is stored as the entry's only
+// vector, even with options.WithChunkVectors.
+func (c *Cache[K, V]) SetWithEmbedding(ctx context.Context, key K, inputText string, embedding []float64, value V) (err error) {
+	ctx, span := c.startSpan(ctx, "Set")
+	defer func() { endSpan(span, err) }()
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	if err := c.checkKey(key); err != nil {
+		return err
+	}
+	if err := c.checkDimension(len(embedding)); err != nil {
+		return err
+	}
+	if err := c.setEntry(ctx, key, c.newEntry(embedding, inputText, value, nil)); err != nil {
+		return err
+	}
+	c.indexText(inputText, key)
+	return nil
 }
 
-// Get retrieves the value for key.
-func (c *Cache[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
-	if err := c.checkClosed(); err != nil {
-		var zero V
-		return zero, false, err
+// SetMulti stores value under key with one embedding per input text, such
+// as one per chunk or paraphrase. Lookups score the entry by whichever text
+// matches best and report it in MatchEx.Vector, the index into inputTexts.
+// The first text is the entry's InputText. The backend must implement
+// types.MultiVectorBackend, otherwise ErrMultiVectorUnsupported is
+// returned. Refresh leaves multi-vector entries alone, since only their
+// first text is stored.
+func (c *Cache[K, V]) SetMulti(ctx context.Context, key K, inputTexts []string, value V) error {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	if err := c.checkKey(key); err != nil {
+		return err
 	}
-	return c.backend.Get(ctx, key)
+	if len(inputTexts) == 0 {
+		return ErrNoInputTexts
+	}
+	if _, ok := cmp.Or(c.base, c.backend).(types.MultiVectorBackend[K, V]); !ok {
+		return ErrMultiVectorUnsupported
+	}
+	embeddings, err := c.embedTexts(ctx, inputTexts)
+	if err != nil {
+		return err
+	}
+	entry := c.newEntry(embeddings[0], inputTexts[0], value, nil)
+	entry.Vectors = embeddings[1:]
+	if err := c.setEntry(ctx, key, entry); err != nil {
+		return err
+	}
+	c.indexText(inputTexts[0], key)
+	return nil
+}
+
+// checkMetadata rejects metadata the backend would silently drop.
+func (c *Cache[K, V]) checkMetadata(metadata map[string]string) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+	if _, ok := c.backend.(types.EntryBackend[K, V]); !ok {
+		return ErrMetadataUnsupported
+	}
+	return nil
+}
+
+// Get retrieves the value for key. With options.WithReadThrough, a miss
+// loads, stores, and returns the value.
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (value V, found bool, err error) {
+	ctx, span := c.startSpan(ctx, "Get")
+	defer func() {
+		span.SetAttributes(attrHit.Bool(found))
+		endSpan(span, err)
+	}()
+	if err := c.begin(); err != nil {
+		return value, false, err
+	}
+	defer c.end()
+	value, found, err = c.backend.Get(ctx, key)
+	if err != nil || found || c.loader == nil {
+		return value, found, err
+	}
+	return c.readThrough(ctx, key)
 }
 
 // Contains reports whether key exists.
 func (c *Cache[K, V]) Contains(ctx context.Context, key K) (bool, error) {
-	if err := c.checkClosed(); err != nil {
+	if err := c.begin(); err != nil {
 		return false, err
 	}
+	defer c.end()
 	return c.backend.Contains(ctx, key)
 }
 
 // Delete removes the entry for key.
 func (c *Cache[K, V]) Delete(ctx context.Context, key K) error {
-	if err := c.checkClosed(); err != nil {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	if err := c.backend.Delete(ctx, key); err != nil {
 		return err
 	}
-	return c.backend.Delete(ctx, key)
+	c.lexical.remove(c.namespace, key)
+	return nil
 }
 
 // Flush removes all entries.
 func (c *Cache[K, V]) Flush(ctx context.Context) error {
-	if err := c.checkClosed(); err != nil {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	if err := c.backend.Flush(ctx); err != nil {
 		return err
 	}
-	return c.backend.Flush(ctx)
+	c.lexical.clear(c.namespace, c.base == nil)
+	return nil
 }
 
 // Len returns the number of cached entries.
 func (c *Cache[K, V]) Len(ctx context.Context) (int, error) {
-	if err := c.checkClosed(); err != nil {
+	if err := c.begin(); err != nil {
 		return 0, err
 	}
+	defer c.end()
 	return c.backend.Len(ctx)
 }
 
+// IterKeys yields every cached key. Backends implementing
+// types.KeyIterator stream their keys; others are listed with Keys first.
+func (c *Cache[K, V]) IterKeys(ctx context.Context) iter.Seq2[K, error] {
+	return func(yield func(K, error) bool) {
+		if err := c.begin(); err != nil {
+			yield(*new(K), err)
+			return
+		}
+		defer c.end()
+		c.iterKeys(ctx)(yield)
+	}
+}
+
+// Range calls fn for every cached entry until fn returns false, for
+// inspecting, exporting, or migrating a cache. Entries removed while Range
+// runs are skipped. The entry carries InputText, CreatedAt, and Metadata
+// only when the backend implements types.EntryBackend. Reading an entry
+// counts as an access for the backend's eviction policy.
+func (c *Cache[K, V]) Range(ctx context.Context, fn func(key K, entry types.Entry[V]) bool) error {
+	return c.rangeEntries(ctx, c.getEntry, fn)
+}
+
+// peekRange is Range for maintenance: it reads with peekEntry, so visiting an
+// entry leaves the backend's eviction order and access counts alone.
+func (c *Cache[K, V]) peekRange(ctx context.Context, fn func(key K, entry types.Entry[V]) bool) error {
+	return c.rangeEntries(ctx, c.peekEntry, fn)
+}
+
+// rangeEntries implements Range and peekRange, reading each entry with get.
+func (c *Cache[K, V]) rangeEntries(ctx context.Context, get func(context.Context, K) (types.Entry[V], bool, error), fn func(key K, entry types.Entry[V]) bool) error {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	_, full := c.backend.(types.EntryBackend[K, V])
+	for key, err := range c.iterKeys(ctx) {
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		entry, found, err := get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		if !full {
+			emb, ok, err := c.backend.GetEmbedding(ctx, key)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			entry.Embedding = emb
+		}
+		if !fn(key, entry) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (c *Cache[K, V]) iterKeys(ctx context.Context) iter.Seq2[K, error] {
+	return backendKeys(ctx, c.backend)
+}
+
+// backendKeys streams a backend's keys, using types.KeyIterator when
+// available.
+func backendKeys[K comparable, V any](ctx context.Context, backend types.Backend[K, V]) iter.Seq2[K, error] {
+	if ki, ok := backend.(types.KeyIterator[K, V]); ok {
+		return ki.IterKeys(ctx)
+	}
+	return func(yield func(K, error) bool) {
+		keys, err := backend.Keys(ctx)
+		if err != nil {
+			yield(*new(K), err)
+			return
+		}
+		for _, key := range keys {
+			if !yield(key, nil) {
+				return
+			}
+		}
+	}
+}
+
 // Lookup finds the single best match whose similarity >= threshold.
 // Returns nil when nothing meets the threshold.
-func (c *Cache[K, V]) Lookup(ctx context.Context, inputText string, threshold float64) (*Match[V], error) {
-	if err := c.checkClosed(); err != nil {
+func (c *Cache[K, V]) Lookup(ctx context.Context, inputText string, threshold float64, opts ...LookupOption) (*Match[V], error) {
+	m, err := c.LookupEx(ctx, inputText, threshold, opts...)
+	if m == nil {
+		return nil, err
+	}
+	return &m.Match, err
+}
+
+// LookupEx is Lookup, but the result also carries the matched key and the
+// entry's stored details.
+func (c *Cache[K, V]) LookupEx(ctx context.Context, inputText string, threshold float64, opts ...LookupOption) (*MatchEx[K, V], error) {
+	matches, err := c.LookupWithOptions(ctx, inputText, slices.Concat(opts, []LookupOption{WithThreshold(threshold), WithLimit(1)})...)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+	return &matches[0], nil
+}
+
+// LookupWithOptions returns the entries most similar to inputText, sorted by
+// descending similarity. Without options it returns at most one match at or
+// above the cache's default threshold (see options.WithDefaultThreshold).
+func (c *Cache[K, V]) LookupWithOptions(ctx context.Context, inputText string, opts ...LookupOption) (matches []MatchEx[K, V], err error) {
+	cfg, err := c.newLookupConfig(opts)
+	if err != nil {
 		return nil, err
 	}
-	query, err := c.provider.EmbedText(ctx, inputText)
+	ctx, span := c.startSpan(ctx, "Lookup", attrLimit.Int(cfg.limit))
+	defer func() {
+		if err == nil && !math.IsInf(cfg.threshold, -1) {
+			c.alerts.lookup(len(matches) > 0)
+		}
+		endSearchSpan(span, matches, err)
+	}()
+	if err := c.begin(); err != nil {
+		return nil, err
+	}
+	defer c.end()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if cfg.limit <= 0 {
+		return nil, ErrInvalidN
+	}
+	if cfg.hybrid != nil && c.lexical == nil {
+		return nil, ErrHybridUnsupported
+	}
+	target := c
+	if cfg.namespace != "" {
+		target = c.Namespace(cfg.namespace)
+	}
+	if m, ok := target.exactMatch(ctx, inputText, cfg); ok {
+		c.logger.DebugContext(ctx, "semanticcache: lookup answered by exact-match index")
+		return []MatchEx[K, V]{*m}, nil
+	}
+	query, err := c.embed(ctx, inputText)
+	if errors.Is(err, ErrCircuitOpen) && c.breaker.fallback {
+		c.logger.DebugContext(ctx, "semanticcache: provider circuit open; lookup answered by exact text")
+		return target.fallbackMatches(ctx, inputText, cfg), nil
+	}
 	if err != nil {
 		return nil, err
 	}
+	if cfg.query != nil {
+		*cfg.query = query
+	}
+	if cfg.hybrid != nil {
+		c.logger.DebugContext(ctx, "semanticcache: hybrid lookup", "rrf", cfg.hybrid.rrf > 0)
+		return target.searchHybrid(ctx, inputText, query, cfg)
+	}
+	if c.rerank.reranker != nil {
+		c.logger.DebugContext(ctx, "semanticcache: reranked lookup", "top_k", c.rerank.topK)
+		return target.searchReranked(ctx, inputText, query, cfg)
+	}
+	return target.search(ctx, query, cfg)
+}
 
-	keys, err := c.backend.Keys(ctx)
+// search scores every entry against an embedded query and returns up to
+// cfg.limit accepted matches at or above cfg.threshold, best first, after
+// skipping cfg.offset of them.
+func (c *Cache[K, V]) search(ctx context.Context, query []float64, cfg lookupConfig) ([]MatchEx[K, V], error) {
+	if vs, ok := c.vectorSearcher(cfg); ok {
+		return c.vectorSearch(ctx, vs, query, cfg)
+	}
+	scanCtx, cancel := c.scanContext(ctx, cfg)
+	defer cancel()
+	candidates, err := c.rank(scanCtx, query, cfg)
+	partial := err != nil && err == scanCtx.Err() && ctx.Err() == nil
+	if err != nil && !partial {
+		return nil, err
+	}
+	matches, _, err := c.collect(ctx, candidates, cfg, cfg.offset, cfg.limit)
 	if err != nil {
 		return nil, err
 	}
+	for i := range matches {
+		matches[i].Partial = partial
+	}
+	c.logger.DebugContext(ctx, "semanticcache: lookup answered by scan",
+		"candidates", len(candidates), "matches", len(matches), "threshold", cfg.threshold, "partial", partial)
+	return matches, nil
+}
 
-	var best *Match[V]
-	bestScore := threshold
+// vectorSearcher returns the backend's VectorSearcher when it can answer
+// cfg. VectorSearch scores are cosine similarities, so any other comparator
+// forces a scan, as do recency and frequency boosts, which can reorder
+// results beyond the nearest neighbors.
+func (c *Cache[K, V]) vectorSearcher(cfg lookupConfig) (types.VectorSearcher[K, V], bool) {
+	vs, ok := c.backend.(types.VectorSearcher[K, V])
+	if !ok || cfg.offset+cfg.limit < cfg.limit || c.scoring.enabled() {
+		return nil, false
+	}
+	return vs, sameComparator(cfg.comparator, similarity.CosineSimilarity)
+}
 
-	for _, key := range keys {
-		emb, ok, err := c.backend.GetEmbedding(ctx, key)
-		if err != nil || !ok {
-			continue
+// vectorSearch answers a search with the backend's nearest-neighbor index.
+// It asks for offset+limit neighbors and doubles the request while the
+// filter rejects too many of them.
+func (c *Cache[K, V]) vectorSearch(ctx context.Context, vs types.VectorSearcher[K, V], query []float64, cfg lookupConfig) ([]MatchEx[K, V], error) {
+	n, err := c.backend.Len(ctx)
+	if err != nil {
+		return nil, err
+	}
+	k := min(cfg.offset+cfg.limit, n)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
 		}
-		score := c.comparator(query, emb)
-		if score >= bestScore {
-			val, found, err := c.backend.Get(ctx, key)
-			if err == nil && found {
-				best = &Match[V]{Value: val, Score: score}
-				bestScore = score
+		spanCtx, span := c.startSpan(ctx, "backend.VectorSearch", attrLimit.Int(k), attrDims.Int(len(query)))
+		results, err := vs.VectorSearch(spanCtx, query, k)
+		span.SetAttributes(attrMatches.Int(len(results)))
+		endSpan(span, err)
+		if err != nil {
+			return nil, err
+		}
+		candidates := make([]candidate[K], 0, len(results))
+		for _, r := range results {
+			score := cfg.scaled(r.Score)
+			if score < cfg.threshold {
+				break
 			}
+			candidates = append(candidates, candidate[K]{key: r.Key, score: score})
+		}
+		matches, _, err := c.collect(ctx, candidates, cfg, cfg.offset, cfg.limit)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == cfg.limit || len(candidates) < len(results) || len(results) < k || k >= n {
+			c.logger.DebugContext(ctx, "semanticcache: lookup answered by vector index",
+				"neighbors", k, "candidates", len(candidates), "matches", len(matches), "threshold", cfg.threshold)
+			return matches, nil
+		}
+		k = min(2*k, n)
+	}
+}
+
+// candidate is a scored key awaiting its entry fetch. Stale candidates
+// hold incomparable embeddings and are only kept for re-embedding.
+type candidate[K comparable] struct {
+	key    K
+	score  float64
+	vector int // index of the best-scoring vector; see MatchEx.Vector
+	stale  bool
+}
+
+// collect fetches candidates in order, skipping the first skip accepted
+// matches and stopping after limit. Entries from another embedding version
+// are passed over. It returns the matches and the number of candidates
+// consumed, or ctx's error if ctx ends first.
+func (c *Cache[K, V]) collect(ctx context.Context, candidates []candidate[K], cfg lookupConfig, skip, limit int) ([]MatchEx[K, V], int, error) {
+	matches := []MatchEx[K, V]{}
+	var stale []K
+	i := 0
+	for ; i < len(candidates) && len(matches) < limit; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		cand := candidates[i]
+		entry, found, err := c.getEntry(ctx, cand.key)
+		if err != nil {
+			c.logger.WarnContext(ctx, "semanticcache: skipping match whose entry failed to load", "error", err)
+			continue
+		}
+		if !found || !accepts(cfg, cand.key, entry.Metadata) {
+			continue
+		}
+		if !c.compatible(entry) {
+			stale = append(stale, cand.key)
+			continue
 		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		m := newMatchEx(cand.key, entry, cand.score)
+		m.Vector = cand.vector
+		m.Chunk = c.matchedChunk(entry, cand.vector)
+		matches = append(matches, m)
 	}
-	return best, nil
+	c.reembedStale(ctx, stale)
+	return matches, i, nil
+}
+
+// compatible reports whether entry's embedding came from the cache's
+// model. An entry or cache without a version is assumed to.
+func (c *Cache[K, V]) compatible(entry types.Entry[V]) bool {
+	return entry.EmbeddingVersion == "" || c.version == "" || entry.EmbeddingVersion == c.version
 }
 
 // TopMatches returns up to n entries sorted by descending similarity.
-func (c *Cache[K, V]) TopMatches(ctx context.Context, inputText string, n int) ([]Match[V], error) {
-	if err := c.checkClosed(); err != nil {
+func (c *Cache[K, V]) TopMatches(ctx context.Context, inputText string, n int, opts ...LookupOption) ([]Match[V], error) {
+	ex, err := c.TopMatchesEx(ctx, inputText, n, opts...)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]Match[V], len(ex))
+	for i, m := range ex {
+		matches[i] = m.Match
+	}
+	return matches, nil
+}
+
+// TopMatchesEx is TopMatches, but each result also carries the matched key
+// and the entry's stored details. Unlike Lookup it applies no threshold
+// unless WithThreshold is passed.
+func (c *Cache[K, V]) TopMatchesEx(ctx context.Context, inputText string, n int, opts ...LookupOption) (matches []MatchEx[K, V], err error) {
+	ctx, span := c.startSpan(ctx, "TopMatches", attrLimit.Int(n))
+	defer func() { endSearchSpan(span, matches, err) }()
+	if err := c.begin(); err != nil {
 		return nil, err
 	}
+	defer c.end()
 	if n <= 0 {
 		return nil, ErrInvalidN
 	}
-	query, err := c.provider.EmbedText(ctx, inputText)
-	if err != nil {
-		return nil, err
+	return c.LookupWithOptions(ctx, inputText, slices.Concat([]LookupOption{WithThreshold(math.Inf(-1))}, opts, []LookupOption{WithLimit(n)})...)
+}
+
+// newEntry builds the entry stored for inputText.
+func (c *Cache[K, V]) newEntry(embedding []float64, inputText string, value V, metadata map[string]string) types.Entry[V] {
+	return types.Entry[V]{
+		Embedding: embedding,
+		Value:     value,
+		InputText: inputText,
+		CreatedAt: time.Now(),
+		Metadata:  metadata,
+
+		EmbeddingVersion: c.version,
+	}
+}
+
+// setEntry stores entry, keeping its input text and creation time when the
+// backend implements types.EntryBackend.
+func (c *Cache[K, V]) setEntry(ctx context.Context, key K, entry types.Entry[V]) (err error) {
+	ctx, span := c.startSpan(ctx, "backend.Set", attrDims.Int(len(entry.Embedding)))
+	defer func() { endSpan(span, err) }()
+	if eb, ok := c.backend.(types.EntryBackend[K, V]); ok {
+		return eb.SetEntry(ctx, key, entry)
+	}
+	return c.backend.Set(ctx, key, entry.Embedding, entry.Value)
+}
+
+// getEntry retrieves the entry for key. Backends without
+// types.EntryBackend return only the value.
+func (c *Cache[K, V]) getEntry(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	if eb, ok := c.backend.(types.EntryBackend[K, V]); ok {
+		return eb.GetEntry(ctx, key)
+	}
+	v, ok, err := c.backend.Get(ctx, key)
+	return types.Entry[V]{Value: v}, ok, err
+}
+
+// peekEntry retrieves the entry for key without counting an access when
+// the backend implements types.PeekingBackend, and with getEntry
+// otherwise.
+func (c *Cache[K, V]) peekEntry(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	if pb, ok := c.backend.(types.PeekingBackend[K, V]); ok {
+		return pb.PeekEntry(ctx, key)
 	}
+	return c.getEntry(ctx, key)
+}
 
-	keys, err := c.backend.Keys(ctx)
+// SetBatch stores multiple items. Providers implementing
+// types.BatchEmbeddingProvider embed all inputs in one call, and backends
+// implementing types.BatchBackend store the whole batch at once. When an
+// item's key repeats, the last one wins.
+func (c *Cache[K, V]) SetBatch(ctx context.Context, items []BatchItem[K, V]) (err error) {
+	ctx, span := c.startSpan(ctx, "SetBatch", attrKeys.Int(len(items)))
+	defer func() { endSpan(span, err) }()
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	if len(items) == 0 {
+		return nil
+	}
+	for _, item := range items {
+		if err := c.checkKey(item.Key); err != nil {
+			return err
+		}
+		if err := c.checkMetadata(item.Metadata); err != nil {
+			return err
+		}
+	}
+	texts := make([]string, len(items))
+	for i, item := range items {
+		texts[i] = item.InputText
+	}
+	vectors, err := c.embedEntries(ctx, texts)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	matches := make([]Match[V], 0, len(keys))
-	for _, key := range keys {
-		emb, ok, err := c.backend.GetEmbedding(ctx, key)
-		if err != nil || !ok {
-			continue
+	if bb, ok := c.backend.(types.BatchBackend[K, V]); ok {
+		entries := make(map[K]types.Entry[V], len(items))
+		for i, item := range items {
+			entries[item.Key] = c.newChunkedEntry(vectors[i], item.InputText, item.Value, item.Metadata)
+		}
+		spanCtx, span := c.startSpan(ctx, "backend.SetBatch", attrKeys.Int(len(entries)))
+		err := bb.SetBatch(spanCtx, entries)
+		endSpan(span, err)
+		if err != nil {
+			return err
+		}
+	} else {
+		for i, item := range items {
+			if err := c.setEntry(ctx, item.Key, c.newChunkedEntry(vectors[i], item.InputText, item.Value, item.Metadata)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, item := range items {
+		c.indexText(item.InputText, item.Key)
+	}
+	return nil
+}
+
+// embedTexts embeds texts, preprocessed, splitting long ones when a chunker
+// is set.
+func (c *Cache[K, V]) embedTexts(ctx context.Context, texts []string) ([][]float64, error) {
+	texts = c.prepare(texts)
+	if c.chunker != nil {
+		return c.embedChunked(ctx, texts)
+	}
+	return c.embedBatch(ctx, texts)
+}
+
+// embedBatch embeds texts whole, in one provider call when the provider
+// supports batches and otherwise with up to batchWorkers calls at once.
+func (c *Cache[K, V]) embedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	if bp, ok := c.provider.(types.BatchEmbeddingProvider); ok {
+		if err := c.breaker.allow(); err != nil {
+			return nil, err
+		}
+		spanCtx, span := c.startSpan(ctx, "provider.EmbedBatch", attrTexts.Int(len(texts)))
+		embeddings, err := bp.EmbedBatch(spanCtx, texts)
+		c.providerDone(ctx, err)
+		if err == nil && len(embeddings) > 0 {
+			span.SetAttributes(attrDims.Int(len(embeddings[0])))
+		}
+		endSpan(span, err)
+		if err != nil {
+			return nil, err
+		}
+		if len(embeddings) != len(texts) {
+			return nil, fmt.Errorf("provider returned %d embeddings for %d inputs", len(embeddings), len(texts))
 		}
-		score := c.comparator(query, emb)
-		val, found, err := c.backend.Get(ctx, key)
-		if err == nil && found {
-			matches = append(matches, Match[V]{Value: val, Score: score})
+		for _, emb := range embeddings {
+			if err := c.checkDimension(len(emb)); err != nil {
+				return nil, err
+			}
+		}
+		return embeddings, nil
+	}
+	embeddings := make([][]float64, len(texts))
+	if c.batchWorkers <= 1 || len(texts) == 1 {
+		for i, text := range texts {
+			emb, err := c.embedWhole(ctx, text)
+			if err != nil {
+				return nil, err
+			}
+			embeddings[i] = emb
 		}
+		return embeddings, nil
+	}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.batchWorkers)
+	for i, text := range texts {
+		g.Go(func() error {
+			if err := gctx.Err(); err != nil {
+				return err
+			}
+			emb, err := c.embedWhole(gctx, text)
+			embeddings[i] = emb
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
 	}
+	return embeddings, nil
+}
 
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].Score > matches[j].Score
+// embed embeds text, preprocessed and split first if it is too long for
+// the chunker. Concurrent calls for the same preprocessed text share one
+// embedding.
+func (c *Cache[K, V]) embed(ctx context.Context, text string) ([]float64, error) {
+	if c.preprocess != nil {
+		text = c.preprocess(text)
+	}
+	return c.queries.do(ctx, text, func(ctx context.Context) ([]float64, error) {
+		if c.chunker != nil {
+			embeddings, err := c.embedChunked(ctx, []string{text})
+			if err != nil {
+				return nil, err
+			}
+			return embeddings[0], nil
+		}
+		return c.embedWhole(ctx, text)
 	})
-	if len(matches) > n {
-		matches = matches[:n]
+}
+
+// prepare returns texts with the preprocessor applied.
+func (c *Cache[K, V]) prepare(texts []string) []string {
+	if c.preprocess == nil {
+		return texts
 	}
-	return matches, nil
+	out := make([]string, len(texts))
+	for i, text := range texts {
+		out[i] = c.preprocess(text)
+	}
+	return out
+}
+
+// embedWhole embeds text with the provider and checks its dimension.
+func (c *Cache[K, V]) embedWhole(ctx context.Context, text string) ([]float64, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	spanCtx, span := c.startSpan(ctx, "provider.EmbedText", attrTexts.Int(1))
+	emb, err := c.provider.EmbedText(spanCtx, text)
+	c.providerDone(ctx, err)
+	span.SetAttributes(attrDims.Int(len(emb)))
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkDimension(len(emb)); err != nil {
+		return nil, err
+	}
+	return emb, nil
+}
+
+// checkDimension returns a *DimensionError when dimension checks are on
+// and n differs from the expected dimension. Without an expected dimension
+// the first one checked becomes it.
+func (c *Cache[K, V]) checkDimension(n int) error {
+	if c.dims == nil || c.dims.CompareAndSwap(0, int64(n)) {
+		return nil
+	}
+	if want := int(c.dims.Load()); want != n {
+		return &DimensionError{Expected: want, Actual: n}
+	}
+	return nil
 }
 
-// SetBatch stores multiple items.
-func (c *Cache[K, V]) SetBatch(ctx context.Context, items []BatchItem[K, V]) error {
-	if err := c.checkClosed(); err != nil {
+// Txn applies a mix of sets and deletes in one backend transaction.
+// Embeddings are computed first, so a provider error leaves the backend
+// untouched. Isolation is backend-specific: the in-memory backends apply
+// every op under one lock, while Redis runs them in MULTI/EXEC, which hides
+// a partial update from other clients but does not roll back the ops that
+// succeeded when another fails at EXEC. The backend must implement
+// types.Transactional, otherwise ErrTxnUnsupported is returned.
+func (c *Cache[K, V]) Txn(ctx context.Context, items []TxnItem[K, V]) error {
+	if err := c.begin(); err != nil {
 		return err
 	}
+	defer c.end()
+	tb, ok := c.backend.(types.Transactional[K, V])
+	if !ok {
+		return ErrTxnUnsupported
+	}
+	var texts []string
 	for _, item := range items {
-		if err := c.Set(ctx, item.Key, item.InputText, item.Value); err != nil {
+		if err := c.checkKey(item.Key); err != nil {
+			return err
+		}
+		if err := c.checkMetadata(item.Metadata); err != nil {
+			return err
+		}
+		if !item.Delete {
+			texts = append(texts, item.InputText)
+		}
+	}
+	var embeddings [][]float64
+	if len(texts) > 0 {
+		var err error
+		if embeddings, err = c.embedTexts(ctx, texts); err != nil {
 			return err
 		}
 	}
+
+	ops := make([]types.TxnOp[K, V], len(items))
+	for i, item := range items {
+		ops[i] = types.TxnOp[K, V]{Key: item.Key, Delete: item.Delete}
+		if !item.Delete {
+			ops[i].Entry = c.newEntry(embeddings[0], item.InputText, item.Value, item.Metadata)
+			embeddings = embeddings[1:]
+		}
+	}
+	if err := tb.Txn(ctx, ops); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if !item.Delete {
+			c.indexText(item.InputText, item.Key)
+		}
+	}
 	return nil
 }
 
 // GetBatch retrieves multiple values. Missing keys are omitted.
-func (c *Cache[K, V]) GetBatch(ctx context.Context, keys []K) (map[K]V, error) {
-	if err := c.checkClosed(); err != nil {
+// Backends implementing types.BatchBackend serve the whole batch at once.
+// With options.WithReadThrough, each missing key is then loaded in turn.
+func (c *Cache[K, V]) GetBatch(ctx context.Context, keys []K) (result map[K]V, err error) {
+	ctx, span := c.startSpan(ctx, "GetBatch", attrKeys.Int(len(keys)))
+	defer func() {
+		span.SetAttributes(attrMatches.Int(len(result)))
+		endSpan(span, err)
+	}()
+	if err := c.begin(); err != nil {
 		return nil, err
 	}
-	result := make(map[K]V, len(keys))
+	defer c.end()
+	if bb, ok := c.backend.(types.BatchBackend[K, V]); ok {
+		if result, err = bb.GetBatch(ctx, keys); err != nil || c.loader == nil {
+			return result, err
+		}
+	} else {
+		result = make(map[K]V, len(keys))
+		for _, key := range keys {
+			val, found, err := c.backend.Get(ctx, key)
+			if err != nil {
+				return nil, err
+			}
+			if found {
+				result[key] = val
+			}
+		}
+	}
+	if c.loader == nil {
+		return result, nil
+	}
+	if result == nil {
+		result = make(map[K]V, len(keys))
+	}
 	for _, key := range keys {
-		val, found, err := c.backend.Get(ctx, key)
+		if _, ok := result[key]; ok {
+			continue
+		}
+		val, found, err := c.readThrough(ctx, key)
 		if err != nil {
 			return nil, err
 		}
@@ -242,27 +1141,56 @@ func (c *Cache[K, V]) GetBatch(ctx context.Context, keys []K) (map[K]V, error) {
 }
 
 // DeleteBatch removes multiple entries.
-func (c *Cache[K, V]) DeleteBatch(ctx context.Context, keys []K) error {
-	if err := c.checkClosed(); err != nil {
+// Backends implementing types.BatchBackend delete the whole batch at once.
+func (c *Cache[K, V]) DeleteBatch(ctx context.Context, keys []K) (err error) {
+	ctx, span := c.startSpan(ctx, "DeleteBatch", attrKeys.Int(len(keys)))
+	defer func() { endSpan(span, err) }()
+	if err := c.begin(); err != nil {
 		return err
 	}
-	for _, key := range keys {
-		if err := c.backend.Delete(ctx, key); err != nil {
-			return err
-		}
-	}
-	return nil
+	defer c.end()
+	defer c.lexical.remove(c.namespace, keys...)
+	return deleteKeys(ctx, c.backend, keys)
 }
 
-// Close releases both the provider and backend.
+// Close releases both the provider and backend, waiting for background
+// work to finish. Closing a Namespace view closes the cache it was created
+// from, and every other view.
 func (c *Cache[K, V]) Close() error {
-	if c.closed.Swap(true) {
+	return c.Shutdown(context.Background())
+}
+
+// Shutdown is Close bounded by ctx. It stops accepting work, then waits
+// until ctx ends for the maintenance jobs, the calls already in progress on
+// the cache and its views, and any backend that implements
+// [types.Shutdowner] to finish. It cancels what is left and closes the
+// provider and backend. It returns ctx.Err() joined with any close errors
+// if work was cancelled. Calling it from inside a call on the same cache,
+// such as a Range callback, waits for that call and so blocks until ctx
+// ends.
+func (c *Cache[K, V]) Shutdown(ctx context.Context) error {
+	c.calls.mu.Lock()
+	closed := c.closed.Swap(true)
+	c.calls.mu.Unlock()
+	if closed {
 		return nil
 	}
-	pErr := c.provider.Close()
-	bErr := c.backend.Close()
-	if pErr != nil {
-		return fmt.Errorf("provider close: %w", pErr)
+	var errs []error
+	if c.scheduler != nil {
+		errs = append(errs, c.scheduler.stop(ctx))
+	}
+	errs = append(errs, c.calls.wait(ctx))
+	if err := c.provider.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("provider close: %w", err))
+	}
+	errs = append(errs, shutdown(ctx, c.backend))
+	return errors.Join(errs...)
+}
+
+// shutdown closes b, bounded by ctx when b supports it.
+func shutdown[K comparable, V any](ctx context.Context, b types.Backend[K, V]) error {
+	if s, ok := b.(types.Shutdowner[K, V]); ok {
+		return s.Shutdown(ctx)
 	}
-	return bErr
+	return b.Close()
 }
@@ -1,12 +1,36 @@
 package semanticcache
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/botirk38/semanticcache/backends/inmemory"
+	"github.com/botirk38/semanticcache/chunker"
 	"github.com/botirk38/semanticcache/options"
+	"github.com/botirk38/semanticcache/preprocess"
+	"github.com/botirk38/semanticcache/providers"
+	"github.com/botirk38/semanticcache/providers/local"
 	"github.com/botirk38/semanticcache/similarity"
+	"github.com/botirk38/semanticcache/tokenizer"
 	"github.com/botirk38/semanticcache/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // ---------- mock provider ----------
@@ -126,6 +150,109 @@ func (m *mockBackend[K, V]) GetEmbedding(_ context.Context, key K) ([]float64, b
 	return e.Embedding, true, nil
 }
 
+// ---------- mock batch backend ----------
+
+type mockBatchBackend[K comparable, V any] struct {
+	*mockBackend[K, V]
+	setBatchCalls    int
+	getBatchCalls    int
+	deleteBatchCalls int
+}
+
+func newMockBatchBackend[K comparable, V any]() *mockBatchBackend[K, V] {
+	return &mockBatchBackend[K, V]{mockBackend: newMockBackend[K, V]()}
+}
+
+func (m *mockBatchBackend[K, V]) SetBatch(ctx context.Context, entries map[K]types.Entry[V]) error {
+	m.setBatchCalls++
+	for k, e := range entries {
+		_ = m.Set(ctx, k, e.Embedding, e.Value)
+	}
+	return nil
+}
+
+func (m *mockBatchBackend[K, V]) GetBatch(ctx context.Context, keys []K) (map[K]V, error) {
+	m.getBatchCalls++
+	result := make(map[K]V, len(keys))
+	for _, k := range keys {
+		if v, ok, _ := m.Get(ctx, k); ok {
+			result[k] = v
+		}
+	}
+	return result, nil
+}
+
+func (m *mockBatchBackend[K, V]) DeleteBatch(ctx context.Context, keys []K) error {
+	m.deleteBatchCalls++
+	for _, k := range keys {
+		_ = m.Delete(ctx, k)
+	}
+	return nil
+}
+
+// ---------- mock key-iterating backend ----------
+
+type mockIterBackend[K comparable, V any] struct {
+	*mockBackend[K, V]
+	keysCalls int
+	iterCalls int
+}
+
+func (m *mockIterBackend[K, V]) Keys(ctx context.Context) ([]K, error) {
+	m.keysCalls++
+	return m.mockBackend.Keys(ctx)
+}
+
+func (m *mockIterBackend[K, V]) IterKeys(_ context.Context) iter.Seq2[K, error] {
+	m.iterCalls++
+	return func(yield func(K, error) bool) {
+		for k := range m.data {
+			if !yield(k, nil) {
+				return
+			}
+		}
+	}
+}
+
+// mockSearchBackend answers VectorSearch by scoring every entry, counting
+// calls so tests can check the cache used it instead of scanning keys.
+type mockSearchBackend[K comparable, V any] struct {
+	*mockIterBackend[K, V]
+	searchKs []int
+}
+
+func (m *mockSearchBackend[K, V]) VectorSearch(_ context.Context, query []float64, k int) ([]types.SearchResult[K, V], error) {
+	m.searchKs = append(m.searchKs, k)
+	var results []types.SearchResult[K, V]
+	for key, e := range m.data {
+		results = append(results, types.SearchResult[K, V]{Key: key, Value: e.Value, Score: similarity.CosineSimilarity(query, e.Embedding)})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results[:min(k, len(results))], nil
+}
+
+// mockEmbeddingBatchBackend counts single and bulk embedding reads.
+type mockEmbeddingBatchBackend[K comparable, V any] struct {
+	*mockBackend[K, V]
+	singleCalls, batchCalls int
+}
+
+func (m *mockEmbeddingBatchBackend[K, V]) GetEmbedding(ctx context.Context, key K) ([]float64, bool, error) {
+	m.singleCalls++
+	return m.mockBackend.GetEmbedding(ctx, key)
+}
+
+func (m *mockEmbeddingBatchBackend[K, V]) GetEmbeddings(_ context.Context, keys []K) (map[K][]float64, error) {
+	m.batchCalls++
+	result := make(map[K][]float64, len(keys))
+	for _, key := range keys {
+		if e, ok := m.data[key]; ok {
+			result[key] = e.Embedding
+		}
+	}
+	return result, nil
+}
+
 type testError struct{ msg string }
 
 func (e *testError) Error() string { return e.msg }
@@ -200,6 +327,347 @@ func TestCacheCreation(t *testing.T) {
 	})
 }
 
+func TestEvictionCallback(t *testing.T) {
+	var evicted []string
+	cache, err := New(
+		options.WithEvictionCallback(func(key string, _ types.Entry[string]) {
+			evicted = append(evicted, key)
+		}),
+		options.WithLRUBackend[string, string](1),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+
+	_ = cache.Set(ctx, "a", "first", "A")
+	_ = cache.Set(ctx, "b", "second", "B")
+	if len(evicted) != 1 || evicted[0] != "a" {
+		t.Fatalf("expected [a] evicted, got %v", evicted)
+	}
+}
+
+func TestTxn(t *testing.T) {
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	_ = cache.Set(ctx, "old", "hello", "x")
+
+	err = cache.Txn(ctx, []TxnItem[string, string]{
+		{Key: "entry", InputText: "hello", Value: "v"},
+		{Key: "entry:tags", InputText: "world", Value: "t"},
+		{Key: "old", Delete: true},
+	})
+	if err != nil {
+		t.Fatalf("Txn failed: %v", err)
+	}
+	if v, ok, _ := cache.Get(ctx, "entry:tags"); !ok || v != "t" {
+		t.Errorf("expected t, got %q", v)
+	}
+	if ok, _ := cache.Contains(ctx, "old"); ok {
+		t.Error("expected old to be deleted")
+	}
+
+	if err := cache.Txn(ctx, []TxnItem[string, string]{{Key: "", InputText: "x"}}); err != ErrZeroKey {
+		t.Errorf("expected ErrZeroKey, got %v", err)
+	}
+
+	plain, _ := NewSemanticCache(newMockBackend[string, string](), newMockProvider(), similarity.CosineSimilarity)
+	if err := plain.Txn(ctx, []TxnItem[string, string]{{Key: "k", InputText: "x"}}); err != ErrTxnUnsupported {
+		t.Errorf("expected ErrTxnUnsupported, got %v", err)
+	}
+}
+
+// gatedProvider counts EmbedText calls and holds each until release is
+// closed or its context ends.
+type gatedProvider struct {
+	*mockProvider
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (p *gatedProvider) EmbedText(ctx context.Context, text string) ([]float64, error) {
+	p.calls.Add(1)
+	select {
+	case <-p.release:
+		return p.mockProvider.EmbedText(ctx, text)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestQueryCoalescing(t *testing.T) {
+	provider := &gatedProvider{mockProvider: newMockProvider(), release: make(chan struct{})}
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](provider),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("identical queries share a call", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				view := cache
+				if i%2 == 1 {
+					view = cache.Namespace("tenant")
+				}
+				if _, err := view.Lookup(ctx, "hello", 0.9); err != nil {
+					t.Errorf("Lookup failed: %v", err)
+				}
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(provider.release)
+		wg.Wait()
+		if n := provider.calls.Load(); n != 1 {
+			t.Errorf("expected one provider call, got %d", n)
+		}
+	})
+
+	t.Run("a cancelled first caller", func(t *testing.T) {
+		provider.calls.Store(0)
+		provider.release = make(chan struct{})
+		first, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() {
+			_, err := cache.Lookup(first, "world", 0.9)
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		go func() {
+			_, err := cache.Lookup(ctx, "world", 0.9)
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the first caller to be cancelled, got %v", err)
+		}
+		close(provider.release)
+		if err := <-done; err != nil {
+			t.Errorf("expected the second caller to embed on its own, got %v", err)
+		}
+		if n := provider.calls.Load(); n != 2 {
+			t.Errorf("expected a second provider call, got %d", n)
+		}
+	})
+}
+
+func TestLookupOrCompute(t *testing.T) {
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	compute := func(context.Context) (string, error) {
+		calls.Add(1)
+		<-release
+		return "computed", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, hit, err := cache.LookupOrCompute(ctx, "k", "hello", 0.9, compute)
+			if err != nil || hit || v != "computed" {
+				t.Errorf("expected computed miss, got %q hit=%v err=%v", v, hit, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected a single compute, got %d", n)
+	}
+
+	v, hit, err := cache.LookupOrCompute(ctx, "k2", "similar to hello", 0.9, compute)
+	if err != nil || !hit || v != "computed" {
+		t.Errorf("expected semantic hit, got %q hit=%v err=%v", v, hit, err)
+	}
+	if ok, _ := cache.Contains(ctx, "k2"); ok {
+		t.Error("a hit should not store a new entry")
+	}
+
+	boom := &testError{"boom"}
+	_, _, err = cache.LookupOrCompute(ctx, "k3", "world", 0.9, func(context.Context) (string, error) { return "", boom })
+	if err != boom {
+		t.Errorf("expected compute error, got %v", err)
+	}
+	if ok, _ := cache.Contains(ctx, "k3"); ok {
+		t.Error("failed compute should not store an entry")
+	}
+	if _, _, err := cache.LookupOrCompute(ctx, "", "world", 0.9, compute); err != ErrZeroKey {
+		t.Errorf("expected ErrZeroKey, got %v", err)
+	}
+}
+
+func TestLookupOrComputeFlights(t *testing.T) {
+	ctx := context.Background()
+	newCache := func(t *testing.T, opts ...options.Option[string, string]) *Cache[string, string] {
+		t.Helper()
+		cache, err := New(append([]options.Option[string, string]{
+			options.WithLRUBackend[string, string](10),
+			options.WithCustomProvider[string, string](newMockProvider()),
+		}, opts...)...)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		return cache
+	}
+
+	t.Run("panic", func(t *testing.T) {
+		cache := newCache(t)
+		_, _, err := cache.LookupOrCompute(ctx, "k", "hello", 0.9, func(context.Context) (string, error) { panic("boom") })
+		if !errors.Is(err, ErrComputePanicked) {
+			t.Errorf("expected ErrComputePanicked, got %v", err)
+		}
+		if _, _, err := cache.LookupOrCompute(ctx, "k", "hello", 0.9, func(context.Context) (string, error) { return "v", nil }); err != nil {
+			t.Errorf("expected the flight to be cleared after a panic, got %v", err)
+		}
+	})
+
+	t.Run("caller gives up", func(t *testing.T) {
+		cache := newCache(t)
+		release := make(chan struct{})
+		first, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() {
+			_, _, err := cache.LookupOrCompute(first, "k", "hello", 0.9, func(ctx context.Context) (string, error) {
+				<-release
+				return "computed", ctx.Err()
+			})
+			done <- err
+		}()
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the first caller to be cancelled, got %v", err)
+		}
+		close(release)
+		deadline := time.Now().Add(time.Second)
+		for ok, _ := cache.Contains(ctx, "k"); !ok; ok, _ = cache.Contains(ctx, "k") {
+			if time.Now().After(deadline) {
+				t.Fatal("expected the computation to finish and store after its caller gave up")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+
+	t.Run("threshold", func(t *testing.T) {
+		cache := newCache(t)
+		var calls atomic.Int32
+		release := make(chan struct{})
+		compute := func(context.Context) (string, error) {
+			calls.Add(1)
+			<-release
+			return "computed", nil
+		}
+		var wg sync.WaitGroup
+		for _, threshold := range []float64{0.5, 0.9} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _, _ = cache.LookupOrCompute(ctx, "k", "hello", threshold, compute)
+			}()
+		}
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		if n := calls.Load(); n != 2 {
+			t.Errorf("expected a flight per threshold, got %d computes", n)
+		}
+	})
+
+	t.Run("chunk vectors", func(t *testing.T) {
+		backend, _ := inmemory.NewLRUBackend[string, string](10)
+		cache := newCache(t,
+			options.WithCustomBackend[string, string](backend),
+			options.WithChunker[string, string](wordChunker{max: 1}),
+			options.WithChunkVectors[string, string](),
+		)
+		if _, _, err := cache.LookupOrCompute(ctx, "k", "hello world", 0.99, func(context.Context) (string, error) { return "v", nil }); err != nil {
+			t.Fatal(err)
+		}
+		vectors, _ := backend.GetVectors(ctx, []string{"k"})
+		if len(vectors["k"]) != 2 {
+			t.Errorf("expected a vector per chunk, got %v", vectors["k"])
+		}
+	})
+}
+
+func TestReadThrough(t *testing.T) {
+	loads := 0
+	cache, err := New(
+		options.WithReadThrough(func(_ context.Context, key string) (string, string, bool, error) {
+			loads++
+			return "first", "loaded-" + key, true, nil
+		}),
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		v, ok, err := cache.Get(ctx, "k")
+		if err != nil || !ok || v != "loaded-k" {
+			t.Fatalf("expected loaded value, got %q ok=%v err=%v", v, ok, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("expected 1 load, got %d", loads)
+	}
+
+	match, err := cache.Lookup(ctx, "first", 0.9)
+	if err != nil || match == nil || match.Value != "loaded-k" {
+		t.Errorf("expected loaded entry to be searchable, got %+v err=%v", match, err)
+	}
+
+	// The backend's optional capabilities stay visible, and loaded
+	// entries keep their input text.
+	if err := cache.SetWithMetadata(ctx, "m", "meta", "v", map[string]string{"a": "b"}); err != nil {
+		t.Errorf("SetWithMetadata with a loader: %v", err)
+	}
+	if _, err := cache.Pin(ctx, "k"); err != nil {
+		t.Errorf("Pin with a loader: %v", err)
+	}
+	matches, err := cache.TopMatchesEx(ctx, "first", 1)
+	if err != nil || len(matches) != 1 || matches[0].InputText != "first" {
+		t.Errorf("expected the loaded entry's input text, got %+v err=%v", matches, err)
+	}
+
+	got, err := cache.GetBatch(ctx, []string{"k", "k2"})
+	if err != nil || got["k2"] != "loaded-k2" || loads != 2 {
+		t.Errorf("GetBatch = %v after %d loads, err=%v", got, loads, err)
+	}
+	if _, ok, err := cache.Get(ctx, ""); ok || err != nil || loads != 2 {
+		t.Errorf("zero key should miss without loading, got ok=%v err=%v after %d loads", ok, err, loads)
+	}
+}
+
 func TestCacheOperations(t *testing.T) {
 	cache, err := New(
 		options.WithCustomBackend(newMockBackend[string, string]()),
@@ -351,90 +819,2542 @@ func TestSemanticSearch(t *testing.T) {
 	})
 }
 
-func TestBatchOperations(t *testing.T) {
+func TestMatchEx(t *testing.T) {
 	cache, err := New(
-		options.WithCustomBackend(newMockBackend[string, string]()),
+		options.WithLRUBackend[string, string](10),
 		options.WithCustomProvider[string, string](newMockProvider()),
 	)
 	if err != nil {
 		t.Fatalf("Failed to create cache: %v", err)
 	}
 	ctx := context.Background()
+	before := time.Now()
+	_ = cache.Set(ctx, "key1", "hello", "greeting")
+	_ = cache.Set(ctx, "key2", "world", "planet")
 
-	t.Run("SetBatch", func(t *testing.T) {
-		items := []BatchItem[string, string]{
-			{Key: "b1", InputText: "hello", Value: "g1"},
-			{Key: "b2", InputText: "world", Value: "p1"},
-		}
-		if err := cache.SetBatch(ctx, items); err != nil {
-			t.Fatalf("SetBatch failed: %v", err)
-		}
-		v, found, _ := cache.Get(ctx, "b1")
-		if !found || v != "g1" {
-			t.Errorf("expected g1, got %s", v)
-		}
-	})
-
-	t.Run("GetBatch", func(t *testing.T) {
-		results, err := cache.GetBatch(ctx, []string{"b1", "b2", "missing"})
-		if err != nil {
-			t.Fatalf("GetBatch failed: %v", err)
-		}
-		if len(results) != 2 {
-			t.Errorf("expected 2 results, got %d", len(results))
-		}
-	})
+	m, err := cache.LookupEx(ctx, "similar to hello", 0.5)
+	if err != nil || m == nil {
+		t.Fatalf("expected a match, got %v err=%v", m, err)
+	}
+	if m.Key != "key1" || m.Value != "greeting" || m.InputText != "hello" || m.CreatedAt.Before(before) {
+		t.Errorf("unexpected match %+v", m)
+	}
 
-	t.Run("DeleteBatch", func(t *testing.T) {
-		if err := cache.DeleteBatch(ctx, []string{"b1", "b2"}); err != nil {
-			t.Fatalf("DeleteBatch failed: %v", err)
-		}
-		n, _ := cache.Len(ctx)
-		if n != 0 {
-			t.Errorf("expected 0, got %d", n)
-		}
-	})
+	top, err := cache.TopMatchesEx(ctx, "world", 2)
+	if err != nil || len(top) != 2 {
+		t.Fatalf("expected 2 matches, got %d err=%v", len(top), err)
+	}
+	if top[0].Key != "key2" || top[0].InputText != "world" {
+		t.Errorf("unexpected top match %+v", top[0])
+	}
 
-	t.Run("SetBatchZeroKey", func(t *testing.T) {
-		items := []BatchItem[string, string]{{Key: "", InputText: "hello", Value: "v"}}
-		if err := cache.SetBatch(ctx, items); err == nil {
-			t.Error("expected error for zero-value key")
-		}
-	})
+	plain, _ := NewSemanticCache(newMockBackend[string, string](), newMockProvider(), similarity.CosineSimilarity)
+	_ = plain.Set(ctx, "key1", "hello", "greeting")
+	m, _ = plain.LookupEx(ctx, "hello", 0.5)
+	if m == nil || m.Key != "key1" || m.InputText != "" {
+		t.Errorf("expected key without stored text from a plain backend, got %+v", m)
+	}
 }
 
-func TestErrorHandling(t *testing.T) {
-	t.Run("ProviderError", func(t *testing.T) {
-		cache, _ := New(
-			options.WithCustomBackend(newMockBackend[string, string]()),
-			options.WithCustomProvider[string, string](&mockProvider{shouldErr: true}),
-		)
-		ctx := context.Background()
+func TestMetadataFilter(t *testing.T) {
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	_ = cache.SetWithMetadata(ctx, "a:hello", "hello", "for a", map[string]string{"tenant": "a"})
+	_ = cache.SetWithMetadata(ctx, "b:hello", "similar to hello", "for b", map[string]string{"tenant": "b"})
+	_ = cache.Set(ctx, "plain", "hello", "no tenant")
 
-		if err := cache.Set(ctx, "key", "text", "value"); err == nil {
-			t.Error("expected provider error on Set")
-		}
-		if _, err := cache.Lookup(ctx, "text", 0.5); err == nil {
-			t.Error("expected provider error on Lookup")
-		}
-		if _, err := cache.TopMatches(ctx, "text", 1); err == nil {
-			t.Error("expected provider error on TopMatches")
-		}
-	})
+	tenant := func(id string) LookupOption {
+		return WithFilter(func(meta map[string]string) bool { return meta["tenant"] == id })
+	}
+	m, err := cache.LookupEx(ctx, "hello", 0.5, tenant("b"))
+	if err != nil || m == nil || m.Key != "b:hello" || m.Metadata["tenant"] != "b" {
+		t.Fatalf("expected tenant b entry, got %+v err=%v", m, err)
+	}
+	if m, _ := cache.Lookup(ctx, "hello", 0.5, tenant("c")); m != nil {
+		t.Errorf("expected no match for unknown tenant, got %+v", m)
+	}
+	top, _ := cache.TopMatches(ctx, "hello", 10, tenant("a"))
+	if len(top) != 1 || top[0].Value != "for a" {
+		t.Errorf("expected only tenant a, got %+v", top)
+	}
+	if top, _ := cache.TopMatches(ctx, "hello", 10); len(top) != 3 {
+		t.Errorf("expected all entries without a filter, got %d", len(top))
+	}
 
-	t.Run("BackendError", func(t *testing.T) {
-		backend := &mockBackend[string, string]{
-			data:      make(map[string]types.Entry[string]),
-			shouldErr: true,
-		}
-		cache, _ := NewSemanticCache(backend, newMockProvider(), similarity.CosineSimilarity)
-		ctx := context.Background()
+	plain, _ := NewSemanticCache(newMockBackend[string, string](), newMockProvider(), similarity.CosineSimilarity)
+	if err := plain.SetWithMetadata(ctx, "k", "hello", "v", map[string]string{"tenant": "a"}); err != ErrMetadataUnsupported {
+		t.Errorf("expected ErrMetadataUnsupported, got %v", err)
+	}
+	if err := plain.SetBatch(ctx, []BatchItem[string, string]{{Key: "k", InputText: "x", Metadata: map[string]string{"t": "a"}}}); err != ErrMetadataUnsupported {
+		t.Errorf("expected ErrMetadataUnsupported from SetBatch, got %v", err)
+	}
+	if err := plain.SetWithMetadata(ctx, "k", "hello", "v", nil); err != nil {
+		t.Errorf("empty metadata should be accepted, got %v", err)
+	}
+}
 
-		if err := cache.Set(ctx, "key", "text", "value"); err == nil {
-			t.Error("expected backend error on Set")
-		}
-		if _, _, err := cache.Get(ctx, "key"); err == nil {
-			t.Error("expected backend error on Get")
+func TestLookupWithOptions(t *testing.T) {
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithDefaultThreshold[string, string](0.95),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	_ = cache.Set(ctx, "hello", "hello", "greeting")
+	_ = cache.Set(ctx, "near", "similar to hello", "close")
+	_ = cache.Namespace("ns").Set(ctx, "k", "hello", "scoped")
+
+	matches, err := cache.LookupWithOptions(ctx, "similar to hello")
+	if err != nil || len(matches) != 1 || matches[0].Key != "near" {
+		t.Fatalf("expected default threshold and limit, got %+v err=%v", matches, err)
+	}
+	matches, _ = cache.LookupWithOptions(ctx, "hello", WithThreshold(0.5), WithLimit(5))
+	if len(matches) != 3 || matches[0].Score < matches[len(matches)-1].Score {
+		t.Errorf("expected 3 matches in descending order, got %+v", matches)
+	}
+	matches, _ = cache.LookupWithOptions(ctx, "hello", WithNamespace("ns"), WithLimit(5))
+	if len(matches) != 1 || matches[0].Value != "scoped" {
+		t.Errorf("expected only the namespaced entry, got %+v", matches)
+	}
+	constant := func(a, b []float64) float64 { return 0.1 }
+	if matches, _ := cache.LookupWithOptions(ctx, "hello", WithComparator(constant)); len(matches) != 0 {
+		t.Errorf("expected comparator override to reject all entries, got %+v", matches)
+	}
+	if m, _ := cache.Lookup(ctx, "hello", 0.05, WithComparator(constant)); m == nil || m.Score != 0.1 {
+		t.Errorf("expected Lookup to score with the override, got %+v", m)
+	}
+	if matches, _ := cache.TopMatches(ctx, "hello", 2, WithComparator(constant)); len(matches) != 2 || matches[0].Score != 0.1 {
+		t.Errorf("expected TopMatches to score with the override, got %+v", matches)
+	}
+	if _, err := cache.LookupWithOptions(ctx, "hello", WithLimit(0)); err != ErrInvalidN {
+		t.Errorf("expected ErrInvalidN, got %v", err)
+	}
+	top, _ := cache.TopMatches(ctx, "hello", 10, WithThreshold(0.999))
+	if len(top) != 2 {
+		t.Errorf("expected TopMatches to honor WithThreshold, got %+v", top)
+	}
+}
+
+func TestPageMatches(t *testing.T) {
+	cache, err := New(
+		options.WithLRUBackend[int, int](100),
+		options.WithCustomProvider[int, int](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	texts := []string{"hello", "similar to hello", "world", "test", "other"}
+	for i, text := range texts {
+		_ = cache.Set(ctx, i+1, text, i+1)
+	}
+
+	pager, err := cache.PageMatches(ctx, "hello", 2, WithThreshold(0.5))
+	if err != nil {
+		t.Fatalf("PageMatches: %v", err)
+	}
+	var keys []int
+	for !pager.Done() {
+		page, err := pager.Next(ctx)
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		if len(page) > 2 {
+			t.Fatalf("page larger than page size: %d", len(page))
+		}
+		for _, m := range page {
+			keys = append(keys, m.Key)
+		}
+	}
+	if len(keys) != 3 || keys[0] != 1 || keys[1] != 2 || keys[2] != 5 {
+		t.Errorf("expected keys [1 2 5], got %v", keys)
+	}
+
+	page, _ := cache.TopMatchesEx(ctx, "hello", 2, WithOffset(1))
+	if len(page) != 2 || page[0].Key != 2 {
+		t.Errorf("expected offset to skip the best match, got %+v", page)
+	}
+	pager, _ = cache.PageMatches(ctx, "hello", 10, WithOffset(1), WithLimit(2))
+	if page, _ := pager.Next(ctx); len(page) != 2 || page[0].Key != 2 || !pager.Done() {
+		t.Errorf("expected 2 matches after offset and limit, got %+v", page)
+	}
+	if _, err := cache.PageMatches(ctx, "hello", 0); err != ErrInvalidN {
+		t.Errorf("expected ErrInvalidN, got %v", err)
+	}
+}
+
+func TestTextKeys(t *testing.T) {
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	if TextKey("  Hello\tWORLD ") != TextKey("hello world") {
+		t.Error("expected case and whitespace to be normalized")
+	}
+	key, err := cache.SetText(ctx, "hello", "first")
+	if err != nil || key != TextKey("hello") {
+		t.Fatalf("SetText: key=%q err=%v", key, err)
+	}
+	_, _ = cache.SetText(ctx, "hello", "second")
+	if n, _ := cache.Len(ctx); n != 1 {
+		t.Errorf("expected duplicate text to overwrite, have %d entries", n)
+	}
+	m, err := cache.LookupText(ctx, " HELLO", 0.99)
+	if err != nil || m == nil || m.Value != "second" || m.Score != 1 {
+		t.Errorf("expected exact hit, got %+v err=%v", m, err)
+	}
+	if m, _ := cache.LookupText(ctx, "similar to hello", 0.9); m == nil || m.Key != key {
+		t.Errorf("expected semantic fallback, got %+v", m)
+	}
+
+	ints, _ := New(
+		options.WithLRUBackend[int, string](10),
+		options.WithCustomProvider[int, string](newMockProvider()),
+	)
+	if _, err := ints.SetText(ctx, "hello", "v"); err != ErrTextKeyUnsupported {
+		t.Errorf("expected ErrTextKeyUnsupported, got %v", err)
+	}
+}
+
+func TestExactMatch(t *testing.T) {
+	provider := newMockProvider()
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](provider),
+		options.WithExactMatch[string, string](2),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	_ = cache.Set(ctx, "k1", "hello", "v1")
+	_ = cache.Namespace("ns").Set(ctx, "k2", "world", "v2")
+
+	provider.shouldErr = true
+	m, err := cache.LookupEx(ctx, "hello", 0.9)
+	if err != nil || m == nil || m.Key != "k1" || m.Score != 1 {
+		t.Fatalf("expected exact hit without embedding, got %+v err=%v", m, err)
+	}
+	if _, err := cache.LookupEx(ctx, "  HELLO ", 0.9); err == nil {
+		t.Error("text differing in case or spacing must not hit the fast path")
+	}
+	if _, err := cache.LookupEx(ctx, "hello", 0.9, WithComparator(similarity.EuclideanSimilarity)); err == nil {
+		t.Error("a per-call comparator must not hit the fast path")
+	}
+	if m, err := cache.LookupEx(ctx, "world", 0.9, WithNamespace("ns")); err != nil || m == nil || m.Value != "v2" {
+		t.Errorf("expected namespaced exact hit, got %+v err=%v", m, err)
+	}
+	if _, err := cache.LookupEx(ctx, "world", 0.9); err == nil {
+		t.Error("another namespace's text must not hit the fast path")
+	}
+	v, hit, err := cache.LookupOrCompute(ctx, "k3", "hello", 0.9, func(context.Context) (string, error) {
+		return "", errors.New("compute must not run")
+	})
+	if err != nil || !hit || v != "v1" {
+		t.Errorf("expected LookupOrCompute exact hit, got %q hit=%v err=%v", v, hit, err)
+	}
+
+	_ = cache.Delete(ctx, "k1")
+	if _, err := cache.LookupEx(ctx, "hello", 0.9); err == nil {
+		t.Error("a deleted entry must not hit the fast path")
+	}
+
+	if _, err := New(
+		options.WithCustomBackend[string, string](newMockBackend[string, string]()),
+		options.WithCustomProvider[string, string](provider),
+		options.WithExactMatch[string, string](2),
+	); err != options.ErrExactMatchUnsupported {
+		t.Errorf("expected ErrExactMatchUnsupported, got %v", err)
+	}
+}
+
+func TestVectorSearch(t *testing.T) {
+	backend := &mockSearchBackend[string, string]{mockIterBackend: &mockIterBackend[string, string]{mockBackend: newMockBackend[string, string]()}}
+	cache, err := NewSemanticCache(backend, newMockProvider(), similarity.CosineSimilarity)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	_ = cache.Set(ctx, "hello", "hello", "greeting")
+	_ = cache.Set(ctx, "near", "similar to hello", "close")
+	_ = cache.Set(ctx, "world", "world", "planet")
+
+	m, err := cache.LookupEx(ctx, "hello", 0.9)
+	if err != nil || m == nil || m.Key != "hello" {
+		t.Fatalf("expected best match from VectorSearch, got %+v err=%v", m, err)
+	}
+	top, _ := cache.TopMatches(ctx, "hello", 2, WithOffset(1))
+	if len(top) != 2 || top[0].Value != "close" {
+		t.Errorf("expected offset results from VectorSearch, got %+v", top)
+	}
+	if backend.iterCalls != 0 || backend.keysCalls != 0 {
+		t.Errorf("expected no key scans, got %d IterKeys and %d Keys calls", backend.iterCalls, backend.keysCalls)
+	}
+
+	if m, _ := cache.LookupEx(ctx, "hello", 0.5, WithFilter(func(map[string]string) bool { return false })); m != nil {
+		t.Errorf("expected filter to reject every result, got %+v", m)
+	}
+	if ks := backend.searchKs[len(backend.searchKs)-3:]; !slices.Equal(ks, []int{1, 2, 3}) {
+		t.Errorf("expected the search to widen when the filter rejects results, asked for %v", backend.searchKs)
+	}
+
+	_, _ = cache.LookupEx(ctx, "hello", 0.9, WithComparator(similarity.EuclideanSimilarity))
+	if backend.iterCalls != 1 {
+		t.Errorf("expected a custom comparator to scan, got %d IterKeys calls", backend.iterCalls)
+	}
+}
+
+func TestSearchConcurrency(t *testing.T) {
+	ctx := context.Background()
+	newCache := func(workers int) *Cache[int, int] {
+		c, err := New(
+			options.WithLRUBackend[int, int](1000),
+			options.WithCustomProvider[int, int](newMockProvider()),
+			options.WithSearchConcurrency[int, int](workers),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		texts := []string{"hello", "world", "test", "similar to hello"}
+		for i := 1; i <= 200; i++ {
+			_ = c.Set(ctx, i, texts[i%len(texts)], i)
+		}
+		return c
+	}
+	serial, parallel := newCache(1), newCache(8)
+
+	want, _ := serial.TopMatches(ctx, "hello", 200, WithThreshold(0.5))
+	got, err := parallel.TopMatches(ctx, "hello", 200, WithThreshold(0.5))
+	if err != nil || len(got) != len(want) || len(got) != 100 {
+		t.Fatalf("expected %d matches, got %d err=%v", len(want), len(got), err)
+	}
+	for i := range got {
+		if got[i].Score != want[i].Score {
+			t.Fatalf("match %d: score %v, want %v", i, got[i].Score, want[i].Score)
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	for _, c := range []*Cache[int, int]{serial, parallel} {
+		if _, err := c.TopMatches(cancelled, "hello", 5); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	}
+}
+
+func TestSearch_BatchEmbeddings(t *testing.T) {
+	backend := &mockEmbeddingBatchBackend[int, int]{mockBackend: newMockBackend[int, int]()}
+	cache, err := NewSemanticCache(backend, newMockProvider(), similarity.CosineSimilarity)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	for i := 1; i <= scanBatchSize+1; i++ {
+		_ = cache.Set(ctx, i, "hello", i)
+	}
+	top, err := cache.TopMatches(ctx, "hello", 3)
+	if err != nil || len(top) != 3 {
+		t.Fatalf("expected 3 matches, got %d err=%v", len(top), err)
+	}
+	if backend.batchCalls != 2 || backend.singleCalls != 0 {
+		t.Errorf("expected 2 bulk reads and no single reads, got %d and %d", backend.batchCalls, backend.singleCalls)
+	}
+}
+
+func TestRange(t *testing.T) {
+	ctx := context.Background()
+	full, _ := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	plain, _ := NewSemanticCache(newMockBackend[string, string](), newMockProvider(), similarity.CosineSimilarity)
+	for name, cache := range map[string]*Cache[string, string]{"EntryBackend": full, "Backend": plain} {
+		t.Run(name, func(t *testing.T) {
+			_ = cache.Set(ctx, "a", "hello", "va")
+			_ = cache.Set(ctx, "b", "world", "vb")
+			seen := map[string]types.Entry[string]{}
+			if err := cache.Range(ctx, func(key string, entry types.Entry[string]) bool {
+				seen[key] = entry
+				return true
+			}); err != nil {
+				t.Fatalf("Range: %v", err)
+			}
+			if len(seen) != 2 || seen["a"].Value != "va" || len(seen["b"].Embedding) != 3 {
+				t.Errorf("unexpected entries %+v", seen)
+			}
+			calls := 0
+			_ = cache.Range(ctx, func(string, types.Entry[string]) bool { calls++; return false })
+			if calls != 1 {
+				t.Errorf("expected Range to stop after fn returns false, got %d calls", calls)
+			}
+		})
+	}
+	if seen := 0; full.Namespace("x").Range(ctx, func(string, types.Entry[string]) bool { seen++; return true }) != nil || seen != 0 {
+		t.Errorf("expected an empty namespace to yield nothing, got %d", seen)
+	}
+}
+
+func TestConditionalWrites(t *testing.T) {
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var stored atomic.Int32
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _ := cache.SetIfAbsent(ctx, "k", "hello", "v1"); ok {
+				stored.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := stored.Load(); n != 1 {
+		t.Errorf("expected exactly one SetIfAbsent to win, got %d", n)
+	}
+	if ok, _ := cache.CompareAndSwap(ctx, "k", "stale", "hello", "v2"); ok {
+		t.Error("CompareAndSwap must fail on a mismatched value")
+	}
+	if ok, err := cache.CompareAndSwap(ctx, "k", "v1", "hello", "v2"); err != nil || !ok {
+		t.Errorf("CompareAndSwap = %v, %v", ok, err)
+	}
+	ns := cache.Namespace("a")
+	if ok, _ := ns.SetIfAbsent(ctx, "k", "hello", "scoped"); !ok {
+		t.Error("a namespace must not see the parent's key")
+	}
+	if v, _, _ := ns.Get(ctx, "k"); v != "scoped" {
+		t.Errorf("expected scoped value, got %q", v)
+	}
+
+	plain, _ := NewSemanticCache(newMockBackend[string, string](), newMockProvider(), similarity.CosineSimilarity)
+	if _, err := plain.SetIfAbsent(ctx, "k", "hello", "v"); err != ErrConditionalUnsupported {
+		t.Errorf("expected ErrConditionalUnsupported, got %v", err)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	cache, err := New(
+		options.WithTTLBackend[string, string](10, time.Minute),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	_ = cache.Set(ctx, "k", "hello", "v")
+	if ok, err := cache.Expire(ctx, "k", time.Millisecond); err != nil || !ok {
+		t.Fatalf("Expire = %v, %v", ok, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if ok, _ := cache.Contains(ctx, "k"); ok {
+		t.Error("expected the entry to expire")
+	}
+
+	ns := cache.Namespace("a")
+	_ = ns.Set(ctx, "k", "hello", "v")
+	if ok, err := ns.Persist(ctx, "k"); err != nil || !ok {
+		t.Errorf("Persist through a namespace = %v, %v", ok, err)
+	}
+	if ok, _ := ns.Touch(ctx, "missing"); ok {
+		t.Error("expected Touch to report a missing key")
+	}
+
+	plain, _ := NewSemanticCache(newMockBackend[string, string](), newMockProvider(), similarity.CosineSimilarity)
+	_ = plain.Set(ctx, "k", "hello", "v")
+	if _, err := plain.Expire(ctx, "k", time.Minute); err != ErrExpiryUnsupported {
+		t.Errorf("expected ErrExpiryUnsupported, got %v", err)
+	}
+	if ok, err := plain.Touch(ctx, "k"); err != nil || !ok {
+		t.Errorf("expected Touch to fall back to a read, got %v, %v", ok, err)
+	}
+}
+
+func TestPin(t *testing.T) {
+	ctx := context.Background()
+	cache, err := New(
+		options.WithLRUBackend[string, string](2),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	faq := cache.Namespace("faq")
+	_ = faq.Set(ctx, "reset", "hello", "curated answer")
+	if ok, err := faq.Pin(ctx, "reset"); err != nil || !ok {
+		t.Fatalf("Pin = %v, %v", ok, err)
+	}
+	for _, k := range []string{"a", "b", "c", "d"} {
+		_ = cache.Set(ctx, k, "world", "v")
+	}
+	if v, ok, _ := faq.Get(ctx, "reset"); !ok || v != "curated answer" {
+		t.Errorf("expected the pinned entry to survive, got %q, %v", v, ok)
+	}
+	if ok, err := faq.Unpin(ctx, "reset"); err != nil || !ok {
+		t.Errorf("Unpin = %v, %v", ok, err)
+	}
+
+	plain, _ := NewSemanticCache(newMockBackend[string, string](), newMockProvider(), similarity.CosineSimilarity)
+	if _, err := plain.Pin(ctx, "k"); err != ErrPinUnsupported {
+		t.Errorf("expected ErrPinUnsupported, got %v", err)
+	}
+}
+
+func TestNormalizedScores(t *testing.T) {
+	ctx := context.Background()
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithNormalizedScores[string, string](),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set(ctx, "h", "hello", "vh")
+	_ = cache.Set(ctx, "w", "world", "vw")
+
+	matches, err := cache.TopMatches(ctx, "hello", 2)
+	if err != nil || len(matches) != 2 || matches[0].Score != 1 || matches[1].Score != 0.5 {
+		t.Errorf("expected cosine scores 1 and 0.5, got %+v err=%v", matches, err)
+	}
+	matches, _ = cache.TopMatches(ctx, "hello", 2, WithComparator(similarity.DotProductSimilarity))
+	for _, m := range matches {
+		if m.Score < 0 || m.Score > 1 {
+			t.Errorf("expected dot product scores in [0, 1], got %v", m.Score)
+		}
+	}
+	if m, _ := cache.Lookup(ctx, "world", 0.6); m == nil || m.Value != "vw" {
+		t.Errorf("expected a normalized threshold hit, got %+v", m)
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	ctx := context.Background()
+	for name, backend := range map[string]options.Option[string, string]{
+		"scan": options.WithLRUBackend[string, string](10),
+		"ann":  options.WithHNSWBackend[string, string](),
+	} {
+		t.Run(name, func(t *testing.T) {
+			provider := newMockProvider()
+			provider.embeddings["hi"] = []float64{0.95, 0.05, 0}
+			cache, err := New(backend, options.WithCustomProvider[string, string](provider))
+			if err != nil {
+				t.Fatalf("Failed to create cache: %v", err)
+			}
+			for _, text := range []string{"hello", "similar to hello", "hi", "world", "test"} {
+				_ = cache.Set(ctx, text, text, text)
+			}
+			groups, err := cache.FindDuplicates(ctx, 0.98)
+			if err != nil || len(groups) != 1 {
+				t.Fatalf("expected one group, got %v err=%v", groups, err)
+			}
+			got := slices.Sorted(slices.Values(groups[0]))
+			if !slices.Equal(got, []string{"hello", "hi", "similar to hello"}) {
+				t.Errorf("expected the hello paraphrases grouped, got %v", got)
+			}
+			if groups, _ := cache.FindDuplicates(ctx, 1.01); len(groups) != 0 {
+				t.Errorf("expected no groups above 1, got %v", groups)
+			}
+		})
+	}
+}
+
+func TestSearchFilter(t *testing.T) {
+	ctx := context.Background()
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set(ctx, "u1:h", "hello", "v1h")
+	_ = cache.SetWithMetadata(ctx, "u2:h", "hello", "v2h", map[string]string{"label": "faq"})
+	_ = cache.Set(ctx, "u2:s", "similar to hello", "v2s")
+
+	user2 := WithSearchFilter(func(key string, _ map[string]string) bool {
+		return strings.HasPrefix(key, "u2:")
+	})
+	matches, err := cache.TopMatchesEx(ctx, "hello", 5, user2)
+	if err != nil || len(matches) != 2 || matches[0].Key != "u2:h" || matches[1].Key != "u2:s" {
+		t.Fatalf("expected only user 2's entries, got %+v err=%v", matches, err)
+	}
+	unlabeled := WithSearchFilter(func(_ string, meta map[string]string) bool { return meta["label"] == "" })
+	if m, _ := cache.LookupEx(ctx, "hello", 0.5, user2, unlabeled); m == nil || m.Key != "u2:s" {
+		t.Errorf("expected the lookup to pass over filtered entries, got %+v", m)
+	}
+	wrongKey := WithSearchFilter(func(int, map[string]string) bool { return true })
+	if m, err := cache.Lookup(ctx, "hello", 0.5, wrongKey); m != nil || !errors.Is(err, ErrSearchFilterKeyType) {
+		t.Errorf("expected ErrSearchFilterKeyType for a filter on another key type, got %+v err=%v", m, err)
+	}
+	if _, err := cache.PageMatches(ctx, "hello", 1, wrongKey); !errors.Is(err, ErrSearchFilterKeyType) {
+		t.Errorf("expected PageMatches to reject the filter too, got %v", err)
+	}
+}
+
+func TestScoreBoosts(t *testing.T) {
+	ctx := context.Background()
+	newCache := func(opt options.Option[string, string]) (*Cache[string, string], *inmemory.LFUBackend[string, string]) {
+		backend, _ := inmemory.NewLFUBackend[string, string](10)
+		c, err := New(
+			options.WithCustomBackend[string, string](backend),
+			options.WithCustomProvider[string, string](newMockProvider()),
+			opt,
+		)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		// "old" matches "hello" exactly but is two hours old; "near"
+		// matches slightly less well.
+		_ = backend.SetEntry(ctx, "old", types.Entry[string]{Embedding: []float64{1, 0, 0}, Value: "vold", CreatedAt: time.Now().Add(-2 * time.Hour)})
+		_ = c.Set(ctx, "near", "similar to hello", "vnear")
+		return c, backend
+	}
+
+	plain, _ := newCache(options.WithDefaultThreshold[string, string](0.8))
+	if m, _ := plain.Lookup(ctx, "hello", 0.5); m == nil || m.Value != "vold" {
+		t.Fatalf("expected the closest entry without boosts, got %+v", m)
+	}
+
+	decayed, _ := newCache(options.WithRecencyDecay[string, string](time.Hour, 1))
+	matches, _ := decayed.TopMatches(ctx, "hello", 2)
+	if len(matches) != 2 || matches[0].Value != "vnear" || math.Abs(matches[1].Score-0.25) > 0.01 {
+		t.Errorf("expected the fresh entry first and the old one at a quarter, got %+v", matches)
+	}
+	if m, _ := decayed.Lookup(ctx, "test", 0.5); m != nil {
+		t.Errorf("expected boosts not to create hits, got %+v", m)
+	}
+
+	boosted, backend := newCache(options.WithFrequencyBoost[string, string](1))
+	for range 5 {
+		_, _, _ = backend.Get(ctx, "near")
+	}
+	if m, _ := boosted.Lookup(ctx, "hello", 0.5); m == nil || m.Value != "vnear" {
+		t.Errorf("expected the often-read entry first, got %+v", m)
+	}
+}
+
+func TestHybridLookup(t *testing.T) {
+	ctx := context.Background()
+	// Unknown texts share one embedding, so only the lexical score tells
+	// these entries apart.
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithLexicalIndex[string, string](),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set(ctx, "a", "How do I reset my password?", "va")
+	_ = cache.Set(ctx, "b", "Payment failed with error E1234", "vb")
+	_ = cache.Set(ctx, "c", "Billing question about an invoice", "vc")
+
+	m, err := cache.LookupEx(ctx, "what does e1234 mean", 0.9, WithHybrid(0.5, 0.5))
+	if err != nil || m == nil || m.Key != "b" || math.Abs(m.Score-1) > 1e-9 {
+		t.Fatalf("expected the keyword match with a fused score of 1, got %+v err=%v", m, err)
+	}
+	matches, err := cache.TopMatchesEx(ctx, "e1234", 3, WithHybridRRF(0))
+	if err != nil || len(matches) != 3 || matches[0].Key != "b" || matches[0].Score <= matches[1].Score {
+		t.Fatalf("expected RRF to rank the keyword match first, got %+v err=%v", matches, err)
+	}
+	if max := 2.0 / (DefaultRRFK + 1); matches[0].Score > max {
+		t.Errorf("expected RRF scores of at most %v, got %v", max, matches[0].Score)
+	}
+
+	_ = cache.Delete(ctx, "b")
+	if m, _ := cache.LookupEx(ctx, "e1234", 0.9, WithHybrid(0.5, 0.5)); m != nil {
+		t.Errorf("expected a deleted entry's text to leave the index, got %+v", m)
+	}
+	if m, _ := cache.Namespace("ns").LookupEx(ctx, "password", 0.9, WithHybrid(0.5, 0.5)); m != nil {
+		t.Errorf("expected namespaces to have their own corpus, got %+v", m)
+	}
+
+	plain, _ := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if _, err := plain.Lookup(ctx, "e1234", 0.9, WithHybrid(0.5, 0.5)); err != ErrHybridUnsupported {
+		t.Errorf("expected ErrHybridUnsupported, got %v", err)
+	}
+}
+
+func TestLexicalPruning(t *testing.T) {
+	ctx := context.Background()
+	var evicted []string
+	cache, err := New(
+		options.WithLRUBackend[string, string](2),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithLexicalIndex[string, string](),
+		options.WithEvictionCallback[string, string](func(key string, _ types.Entry[string]) {
+			evicted = append(evicted, key)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	tenant := cache.Namespace("t")
+	_ = tenant.Set(ctx, "a", "error E1234", "va")
+	_ = cache.Set(ctx, "b", "error E5678", "vb")
+	_ = cache.Set(ctx, "c", "billing", "vc")
+
+	if len(evicted) != 1 || evicted[0] != "t:a" {
+		t.Fatalf("expected the caller's callback to see the eviction, got %v", evicted)
+	}
+	if scores := cache.lexical.score("t", "e1234"); len(scores) != 0 {
+		t.Errorf("expected the evicted text to leave the index at once, got %v", scores)
+	}
+	if scores := cache.lexical.score("", "e5678"); len(scores) != 1 {
+		t.Errorf("expected live texts to stay indexed, got %v", scores)
+	}
+
+	backend, _ := inmemory.NewLRUBackend[string, string](10)
+	swept, err := New(
+		options.WithCustomBackend[string, string](backend),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithLexicalIndex[string, string](),
+		options.WithLexicalSweep[string, string](5*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer swept.Close()
+	_ = swept.Set(ctx, "a", "error E1234", "va")
+	_ = swept.Set(ctx, "b", "error E5678", "vb")
+	_ = backend.Delete(ctx, "a") // gone without the cache seeing it
+	deadline := time.Now().Add(time.Second)
+	for len(swept.lexical.score("", "e1234")) != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if scores := swept.lexical.score("", "e1234"); len(scores) != 0 {
+		t.Errorf("expected the sweeper to drop the missing entry's text, got %v", scores)
+	}
+	if scores := swept.lexical.score("", "e5678"); len(scores) != 1 {
+		t.Errorf("expected the sweeper to keep live texts, got %v", scores)
+	}
+}
+
+// failOnceLRU fails its first vector fetch, as a remote backend might on
+// a network blip.
+type failOnceLRU struct {
+	*inmemory.LRUBackend[string, string]
+	failed atomic.Bool
+}
+
+func (b *failOnceLRU) GetVectors(ctx context.Context, keys []string) (map[string][][]float64, error) {
+	if b.failed.CompareAndSwap(false, true) {
+		return nil, errors.New("connection reset")
+	}
+	return b.LRUBackend.GetVectors(ctx, keys)
+}
+
+func TestLexicalPruningKeepsUnloadedEntries(t *testing.T) {
+	ctx := context.Background()
+	lru, _ := inmemory.NewLRUBackend[string, string](10)
+	cache, err := New(
+		options.WithCustomBackend[string, string](&failOnceLRU{LRUBackend: lru}),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithLexicalIndex[string, string](),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+	_ = cache.Set(ctx, "b", "Payment failed with error E1234", "vb")
+
+	if m, err := cache.LookupEx(ctx, "e1234", 0.9, WithHybrid(0.5, 0.5)); err != nil || m != nil {
+		t.Fatalf("expected a miss while the fetch fails, got %+v err=%v", m, err)
+	}
+	if scores := cache.lexical.score("", "e1234"); len(scores) != 1 {
+		t.Errorf("expected the unloaded entry's text to stay indexed, got %v", scores)
+	}
+	if m, err := cache.LookupEx(ctx, "e1234", 0.9, WithHybrid(0.5, 0.5)); err != nil || m == nil || m.Key != "b" {
+		t.Errorf("expected the entry to be found once the fetch succeeds, got %+v err=%v", m, err)
+	}
+}
+
+func TestMemoryUsage(t *testing.T) {
+	ctx := context.Background()
+	newCache := func(opts ...options.Option[string, string]) *Cache[string, string] {
+		t.Helper()
+		cache, err := New(append([]options.Option[string, string]{
+			options.WithLRUBackend[string, string](10),
+			options.WithCustomProvider[string, string](newMockProvider()),
+		}, opts...)...)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		return cache
+	}
+
+	plain := newCache()
+	_ = plain.Set(ctx, "a", "hello", "va")
+	_ = plain.Namespace("t").Set(ctx, "b", "world", "vb")
+	usage, err := plain.MemoryUsage(ctx)
+	if err != nil || usage.Entries != 2 || usage.Embeddings < 2*3*8 {
+		t.Fatalf("MemoryUsage = %+v err=%v", usage, err)
+	}
+	if view, _ := plain.Namespace("t").MemoryUsage(ctx); view != usage {
+		t.Errorf("expected a view to report the shared backend, got %+v", view)
+	}
+
+	indexed := newCache(options.WithLexicalIndex[string, string](), options.WithExactMatch[string, string](10))
+	_ = indexed.Set(ctx, "a", "hello", "va")
+	_ = indexed.Namespace("t").Set(ctx, "b", "world", "vb")
+	withIndexes, _ := indexed.MemoryUsage(ctx)
+	if withIndexes.Entries != 2 || withIndexes.Index <= usage.Index {
+		t.Errorf("expected the text indexes to add to the estimate, got %+v without and %+v with", usage, withIndexes)
+	}
+
+	custom, err := New(
+		options.WithCustomBackend[string, string](newMockBackend[string, string]()),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	if _, err := custom.MemoryUsage(ctx); !errors.Is(err, ErrMemoryUsageUnsupported) {
+		t.Errorf("expected ErrMemoryUsageUnsupported, got %v", err)
+	}
+	_ = plain.Close()
+	if _, err := plain.MemoryUsage(ctx); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+}
+
+func TestLookupFused(t *testing.T) {
+	ctx := context.Background()
+	provider := newMockProvider()
+	provider.embeddings["greeting"] = []float64{0.8, 0.6, 0}
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](provider),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set(ctx, "h", "hello", "vh")
+	_ = cache.Set(ctx, "w", "world", "vw")
+	_ = cache.Set(ctx, "t", "test", "vt")
+
+	// "world" alone ranks w first; fused with two phrasings closer to
+	// hello, h wins by placing high in every ranking.
+	matches, err := cache.LookupFused(ctx, []string{"world", "hello", "greeting"}, 2)
+	if err != nil || len(matches) != 2 || matches[0].Key != "h" || matches[1].Key != "w" {
+		t.Fatalf("expected h then w, got %+v err=%v", matches, err)
+	}
+	if top := 2.0/61 + 1.0/62; matches[0].Score > top+1e-12 || matches[0].Score <= matches[1].Score {
+		t.Errorf("expected descending RRF scores of at most %v, got %+v", top, matches)
+	}
+	if matches, _ := cache.LookupFused(ctx, []string{"world"}, 3, WithThreshold(0.5)); len(matches) != 1 {
+		t.Errorf("expected the threshold to filter each ranking, got %+v", matches)
+	}
+	if _, err := cache.LookupFused(ctx, nil, 2); err != ErrNoInputTexts {
+		t.Errorf("expected ErrNoInputTexts, got %v", err)
+	}
+	if _, err := cache.LookupFused(ctx, []string{"hello"}, 0); err != ErrInvalidN {
+		t.Errorf("expected ErrInvalidN, got %v", err)
+	}
+}
+
+func TestReranker(t *testing.T) {
+	ctx := context.Background()
+	var seen []string
+	// Scores by text length, so the least similar, longest text ranks first.
+	byLength := providers.RerankFunc(func(_ context.Context, query string, texts []string) ([]float64, error) {
+		seen = texts
+		scores := make([]float64, len(texts))
+		for i, text := range texts {
+			scores[i] = float64(len(text))
+		}
+		return scores, nil
+	})
+	newCache := func(r types.Reranker, timeout time.Duration) *Cache[string, string] {
+		c, err := New(
+			options.WithLRUBackend[string, string](10),
+			options.WithCustomProvider[string, string](newMockProvider()),
+			options.WithReranker[string, string](r, 3, timeout),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		_ = c.Set(ctx, "h", "hello", "vh")
+		_ = c.Set(ctx, "s", "similar to hello", "vs")
+		_ = c.Set(ctx, "w", "world", "vw")
+		return c
+	}
+
+	cache := newCache(byLength, 0)
+	m, err := cache.Lookup(ctx, "hello", 0.5)
+	if err != nil || m == nil || m.Value != "vs" || m.Score != 16 {
+		t.Fatalf("expected the reranker's pick, got %+v err=%v", m, err)
+	}
+	if len(seen) != 2 {
+		t.Errorf("expected the 2 matches above the threshold to be reranked, got %v", seen)
+	}
+	matches, _ := cache.TopMatchesEx(ctx, "hello", 1, WithOffset(1))
+	if len(matches) != 1 || matches[0].Key != "h" {
+		t.Errorf("expected offsets to apply after reranking, got %+v", matches)
+	}
+
+	slow := providers.RerankFunc(func(context.Context, string, []string) ([]float64, error) {
+		time.Sleep(time.Second)
+		return []float64{0, 0, 0}, nil
+	})
+	start := time.Now()
+	m, err = newCache(slow, 10*time.Millisecond).Lookup(ctx, "hello", 0.5)
+	if err != nil || m == nil || m.Value != "vh" || m.Score != 1 {
+		t.Errorf("expected the vector ranking after a timeout, got %+v err=%v", m, err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("a reranker ignoring its context must not outlast the timeout")
+	}
+}
+
+func TestTopMatchesMMR(t *testing.T) {
+	ctx := context.Background()
+	provider := newMockProvider()
+	provider.embeddings["a1"] = []float64{0.99, 0.1, 0}
+	provider.embeddings["a2"] = []float64{0.98, 0.12, 0}
+	provider.embeddings["b"] = []float64{0.6, 0, 0.8}
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](provider),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	for _, text := range []string{"a1", "a2", "b"} {
+		_ = cache.Set(ctx, text, text, text)
+	}
+
+	values := func(matches []Match[string]) []string {
+		var vs []string
+		for _, m := range matches {
+			vs = append(vs, m.Value)
+		}
+		return vs
+	}
+	if matches, _ := cache.TopMatches(ctx, "hello", 2); !slices.Equal(values(matches), []string{"a1", "a2"}) {
+		t.Errorf("expected paraphrases from TopMatches, got %v", values(matches))
+	}
+	matches, err := cache.TopMatchesMMR(ctx, "hello", 2, 0.3)
+	if err != nil || !slices.Equal(values(matches), []string{"a1", "b"}) {
+		t.Fatalf("expected a diversified pick, got %v err=%v", values(matches), err)
+	}
+	if matches[1].Score != 0.6 {
+		t.Errorf("expected the relevance score, got %v", matches[1].Score)
+	}
+	if matches, _ := cache.TopMatchesMMR(ctx, "hello", 2, 1); !slices.Equal(values(matches), []string{"a1", "a2"}) {
+		t.Errorf("expected lambda 1 to match TopMatches, got %v", values(matches))
+	}
+	if _, err := cache.TopMatchesMMR(ctx, "hello", 2, 1.5); err != ErrInvalidLambda {
+		t.Errorf("expected ErrInvalidLambda, got %v", err)
+	}
+	if _, err := cache.TopMatchesMMR(ctx, "hello", 0, 0.5); err != ErrInvalidN {
+		t.Errorf("expected ErrInvalidN, got %v", err)
+	}
+}
+
+func TestDimensionCheck(t *testing.T) {
+	ctx := context.Background()
+	backend, _ := inmemory.NewLRUBackend[string, string](10)
+	provider := newMockProvider()
+	provider.embeddings["wide"] = []float64{1, 0, 0, 0}
+	unchecked, _ := New(
+		options.WithCustomBackend[string, string](backend),
+		options.WithCustomProvider[string, string](provider),
+	)
+	_ = unchecked.Set(ctx, "w", "wide", "vw")
+
+	cache, err := New(
+		options.WithCustomBackend[string, string](backend),
+		options.WithCustomProvider[string, string](provider),
+		options.WithDimensionCheck[string, string](0),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_, err = cache.Lookup(ctx, "hello", 0.5)
+	var dimErr *DimensionError
+	if !errors.As(err, &dimErr) || !errors.Is(err, ErrDimensionMismatch) || dimErr.Expected != 3 || dimErr.Actual != 4 {
+		t.Fatalf("expected a 3 vs 4 dimension error on lookup, got %v", err)
+	}
+	if _, err := unchecked.Lookup(ctx, "hello", 0.5); err != nil {
+		t.Errorf("expected unchecked lookups to skip the entry, got %v", err)
+	}
+
+	_ = cache.Delete(ctx, "w")
+	if err := cache.Namespace("ns").Set(ctx, "w", "wide", "vw"); !errors.Is(err, ErrDimensionMismatch) {
+		t.Errorf("expected the learned dimension to reject a write, got %v", err)
+	}
+	if err := cache.Set(ctx, "h", "hello", "vh"); err != nil {
+		t.Errorf("expected a matching write to succeed, got %v", err)
+	}
+}
+
+// wordChunker counts words as tokens and chunks text word by word.
+type wordChunker struct{ max int }
+
+func (w wordChunker) CountTokens(text string) (int, error) { return len(strings.Fields(text)), nil }
+func (w wordChunker) GetMaxTokens() int                    { return w.max }
+func (w wordChunker) ChunkText(text string) ([]chunker.Chunk, error) {
+	var chunks []chunker.Chunk
+	for i, word := range strings.Fields(text) {
+		chunks = append(chunks, chunker.Chunk{Text: word, StartToken: i, EndToken: i + 1, Index: i})
+	}
+	return chunks, nil
+}
+
+// tokenProvider returns each word's mock embedding as its token embedding
+// and counts calls.
+type tokenProvider struct {
+	*mockProvider
+	texts, tokens int
+}
+
+func (p *tokenProvider) EmbedText(ctx context.Context, text string) ([]float64, error) {
+	p.texts++
+	return p.mockProvider.EmbedText(ctx, text)
+}
+
+func (p *tokenProvider) EmbedTokens(ctx context.Context, text string) ([]types.TokenEmbedding, error) {
+	p.tokens++
+	var out []types.TokenEmbedding
+	for start := 0; start < len(text); {
+		end := start + strings.IndexByte(text[start:]+" ", ' ')
+		emb, _ := p.mockProvider.EmbedText(ctx, text[start:end])
+		out = append(out, types.TokenEmbedding{Start: start, End: end, Vector: emb})
+		start = end + 1
+	}
+	return out, nil
+}
+
+func TestNewSemanticCacheChunkingStats(t *testing.T) {
+	backend, _ := inmemory.NewLRUBackend[string, string](10)
+	cache, err := NewSemanticCache[string, string](backend, newMockProvider(), similarity.CosineSimilarity)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	if stats := cache.ChunkingStats(); stats != (ChunkingStats{}) {
+		t.Errorf("expected zero chunking stats, got %+v", stats)
+	}
+}
+
+func TestChunker(t *testing.T) {
+	ctx := context.Background()
+	backend, _ := inmemory.NewLRUBackend[string, string](10)
+	cache, err := New(
+		options.WithCustomBackend[string, string](backend),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithChunker[string, string](wordChunker{max: 1}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set(ctx, "long", "hello world", "v")
+	_ = cache.Set(ctx, "short", "test", "v")
+
+	emb, _, _ := backend.GetEmbedding(ctx, "long")
+	if !slices.Equal(emb, []float64{0.5, 0.5, 0}) {
+		t.Errorf("expected the centroid of the chunk embeddings, got %v", emb)
+	}
+	emb, _, _ = backend.GetEmbedding(ctx, "short")
+	if !slices.Equal(emb, []float64{0, 0, 1}) {
+		t.Errorf("expected a short text embedded whole, got %v", emb)
+	}
+	m, err := cache.LookupEx(ctx, "world hello", 0.99)
+	if err != nil || m == nil || m.Key != "long" {
+		t.Errorf("expected a chunked lookup to match the chunked entry, got %+v, %v", m, err)
+	}
+	if stats, want := cache.ChunkingStats(), (ChunkingStats{Texts: 3, Chunked: 2, Tokens: 5, Chunks: 4}); stats != want {
+		t.Errorf("expected chunking stats %+v, got %+v", want, stats)
+	}
+
+	t.Run("analyze", func(t *testing.T) {
+		a, err := cache.AnalyzeText("hello world")
+		if err != nil || a.Tokens != 2 || a.MaxTokens != 1 || !a.Chunked || len(a.Chunks) != 2 || a.Chunks[1].Text != "world" {
+			t.Errorf("expected two word chunks, got %+v, %v", a, err)
+		}
+		if a, _ := cache.AnalyzeText("test"); a.Chunked || a.Chunks != nil || a.Tokens != 1 {
+			t.Errorf("expected a short text left whole, got %+v", a)
+		}
+		if stats := cache.ChunkingStats(); stats.Texts != 3 {
+			t.Errorf("expected analysis left out of the stats, got %+v", stats)
+		}
+		plain, _ := New(
+			options.WithLRUBackend[string, string](10),
+			options.WithCustomProvider[string, string](newMockProvider()),
+		)
+		if a, err := plain.AnalyzeText("hello world"); err != nil || a.Tokens != 4 || a.Chunked {
+			t.Errorf("expected approximate tokens without a chunker, got %+v, %v", a, err)
+		}
+	})
+
+	t.Run("overlap weights", func(t *testing.T) {
+		chunks := []chunker.Chunk{{StartToken: 0, EndToken: 4}, {StartToken: 2, EndToken: 6}, {StartToken: 4, EndToken: 5}}
+		// Tokens 2, 3, and 4 are each shared by two chunks.
+		if w := overlapWeights(chunks); !slices.Equal(w, []float64{3, 2.5, 0.5}) {
+			t.Errorf("expected every token counted once, got %v", w)
+		}
+		if w := overlapWeights([]chunker.Chunk{{Text: "a"}, {Text: "b"}}); w != nil {
+			t.Errorf("expected equal weights without token positions, got %v", w)
+		}
+	})
+
+	t.Run("truncation", func(t *testing.T) {
+		backend, _ := inmemory.NewLRUBackend[string, string](10)
+		cache, err := New(
+			options.WithCustomBackend[string, string](backend),
+			options.WithCustomProvider[string, string](newMockProvider()),
+			options.WithChunkConfig[string, string](chunker.ChunkConfig{
+				MaxTokens:  1,
+				Strategy:   chunker.TruncateToMaxTokens,
+				Tokenizer:  tokenizer.Approximate{RunesPerToken: 5},
+				Truncation: chunker.KeepHead,
+			}),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		_ = cache.Set(ctx, "k", "test hello world", "v")
+		emb, _, _ := backend.GetEmbedding(ctx, "k")
+		if !slices.Equal(emb, []float64{0, 0, 1}) {
+			t.Errorf("expected one vector of the first token, got %v", emb)
+		}
+		if stats := cache.ChunkingStats(); stats.Truncated != 2 || stats.Chunks != 1 || stats.Oversized != 0 {
+			t.Errorf("expected two truncated tokens, got %+v", stats)
+		}
+	})
+
+	t.Run("observer", func(t *testing.T) {
+		var reports []chunker.Report
+		cache, err := New(
+			options.WithLRUBackend[string, string](10),
+			options.WithCustomProvider[string, string](newMockProvider()),
+			options.WithChunkConfig[string, string](chunker.ChunkConfig{MaxTokens: 2, ChunkSize: 2, Strategy: chunker.Recursive}),
+			options.WithChunkObserver[string, string](func(r chunker.Report) { reports = append(reports, r) }),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		_ = cache.Set(ctx, "k", "hello world", "v")
+		if len(reports) != 1 || reports[0].Strategy != chunker.Recursive || reports[0].Chunks < 2 || reports[0].Oversized != 0 {
+			t.Errorf("expected one report of a recursive split, got %+v", reports)
+		}
+		if stats := cache.ChunkingStats(); stats.Strategy != chunker.Recursive || stats.Texts != 1 {
+			t.Errorf("expected stats for one recursively chunked text, got %+v", stats)
+		}
+	})
+
+	t.Run("chunk vectors", func(t *testing.T) {
+		backend, _ := inmemory.NewLRUBackend[string, string](10)
+		cache, err := New(
+			options.WithCustomBackend[string, string](backend),
+			options.WithCustomProvider[string, string](newMockProvider()),
+			options.WithChunker[string, string](wordChunker{max: 1}),
+			options.WithChunkVectors[string, string](),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		_ = cache.Set(ctx, "long", "hello world", "parent")
+		_ = cache.SetBatch(ctx, []BatchItem[string, string]{{Key: "short", InputText: "test", Value: "v"}})
+
+		m, err := cache.LookupEx(ctx, "world", 0.99)
+		if err != nil || m == nil || m.Key != "long" || m.Value != "parent" {
+			t.Fatalf("expected the chunk to match its parent entry, got %+v, %v", m, err)
+		}
+		if m.Chunk == nil || m.Chunk.Text != "world" || m.Chunk.StartToken != 1 || m.Vector != 1 {
+			t.Errorf("expected the second chunk, got %+v at vector %d", m.Chunk, m.Vector)
+		}
+		if m, _ := cache.LookupEx(ctx, "test", 0.99); m == nil || m.Chunk != nil {
+			t.Errorf("expected a short entry to match without a chunk, got %+v", m)
+		}
+
+		chunks, err := cache.TopChunkMatches(ctx, "world", 5)
+		if err != nil || len(chunks) != 3 {
+			t.Fatalf("expected every chunk, got %+v, %v", chunks, err)
+		}
+		if chunks[0].Key != "long" || chunks[0].Chunk.Text != "world" || chunks[0].Score < 0.99 {
+			t.Errorf("expected the world chunk first, got %+v", chunks[0])
+		}
+		for _, m := range chunks[1:] {
+			if m.Key == "short" && (m.Chunk.Text != "test" || m.Chunk.EndToken != 1) {
+				t.Errorf("expected the short entry as one chunk, got %+v", m)
+			}
+		}
+		if _, err := cache.TopChunkMatches(ctx, "world", 0); !errors.Is(err, ErrInvalidN) {
+			t.Errorf("expected ErrInvalidN, got %v", err)
+		}
+	})
+
+	t.Run("reader", func(t *testing.T) {
+		// Windows must be cut between words: a split word embeds as
+		// {0.5, 0.5, 0.5} and would pull the mean off the axis plane.
+		r := strings.NewReader(strings.Repeat("hello world\n", 20000))
+		if err := cache.SetFromReader(ctx, "file", r, "v"); err != nil {
+			t.Fatalf("SetFromReader failed: %v", err)
+		}
+		emb, _, _ := backend.GetEmbedding(ctx, "file")
+		if len(emb) != 3 || math.Abs(emb[0]-0.5) > 1e-9 || math.Abs(emb[1]-0.5) > 1e-9 || emb[2] != 0 {
+			t.Errorf("expected the mean of the word embeddings, got %v", emb)
+		}
+		if err := cache.SetFromReader(ctx, "empty", strings.NewReader(" \n"), "v"); !errors.Is(err, ErrNoInputTexts) {
+			t.Errorf("expected ErrNoInputTexts, got %v", err)
+		}
+		plain, _ := New(
+			options.WithCustomBackend[string, string](backend),
+			options.WithCustomProvider[string, string](newMockProvider()),
+		)
+		if err := plain.SetFromReader(ctx, "file", strings.NewReader("hello"), "v"); !errors.Is(err, ErrChunkerRequired) {
+			t.Errorf("expected ErrChunkerRequired, got %v", err)
+		}
+	})
+
+	t.Run("summaries", func(t *testing.T) {
+		summarizer := providers.SummarizeFunc(func(_ context.Context, text string) (string, error) {
+			if text == "hello" {
+				return "", errors.New("rate limited")
+			}
+			return "test", nil
+		})
+		newCache := func(minTokens int) (*Cache[string, string], *inmemory.LRUBackend[string, string]) {
+			backend, _ := inmemory.NewLRUBackend[string, string](10)
+			cache, err := New(
+				options.WithCustomBackend[string, string](backend),
+				options.WithCustomProvider[string, string](newMockProvider()),
+				options.WithChunker[string, string](wordChunker{max: 1}),
+				options.WithChunkSummarizer[string, string](summarizer, 1, minTokens),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create cache: %v", err)
+			}
+			return cache, backend
+		}
+
+		cache, backend := newCache(0)
+		_ = cache.Set(ctx, "long", "hello world", "v")
+		emb, _, _ := backend.GetEmbedding(ctx, "long")
+		if !slices.Equal(emb, []float64{0.5, 0, 0.5}) {
+			t.Errorf("expected the summary embedded and a failed chunk kept, got %v", emb)
+		}
+		if m, _ := cache.LookupEx(ctx, "test", 0); m == nil || m.InputText != "hello world" {
+			t.Errorf("expected the original text stored, got %+v", m)
+		}
+
+		cache, backend = newCache(2)
+		_ = cache.Set(ctx, "long", "hello world", "v")
+		if emb, _, _ := backend.GetEmbedding(ctx, "long"); !slices.Equal(emb, []float64{0.5, 0.5, 0}) {
+			t.Errorf("expected chunks under the token minimum embedded as they are, got %v", emb)
+		}
+	})
+
+	t.Run("late chunking", func(t *testing.T) {
+		backend, _ := inmemory.NewLRUBackend[string, string](10)
+		provider := &tokenProvider{mockProvider: newMockProvider()}
+		cache, err := New(
+			options.WithCustomBackend[string, string](backend),
+			options.WithCustomProvider[string, string](provider),
+			options.WithChunker[string, string](wordChunker{max: 1}),
+			options.WithChunkVectors[string, string](),
+			options.WithLateChunking[string, string](),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		_ = cache.Set(ctx, "long", "hello world", "v")
+		if provider.tokens != 1 || provider.texts != 0 {
+			t.Errorf("expected one token embedding call, got %d, and %d text calls", provider.tokens, provider.texts)
+		}
+		vectors, _ := backend.GetVectors(ctx, []string{"long"})
+		if want := [][]float64{{1, 0, 0}, {0, 1, 0}}; !slices.EqualFunc(vectors["long"], want, slices.Equal) {
+			t.Errorf("expected pooled chunk vectors, got %v", vectors["long"])
+		}
+		_ = cache.Set(ctx, "short", "test", "v")
+		if provider.tokens != 1 || provider.texts != 1 {
+			t.Errorf("expected a short text embedded whole, got %d token and %d text calls", provider.tokens, provider.texts)
+		}
+
+		_, err = New(
+			options.WithCustomBackend[string, string](backend),
+			options.WithCustomProvider[string, string](newMockProvider()),
+			options.WithChunker[string, string](wordChunker{max: 1}),
+			options.WithLateChunking[string, string](),
+		)
+		if !errors.Is(err, options.ErrLateChunkingUnsupported) {
+			t.Errorf("expected ErrLateChunkingUnsupported without token embeddings, got %v", err)
+		}
+	})
+
+	_, err = New(
+		options.WithCustomBackend[string, string](backend),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithChunkVectors[string, string](),
+	)
+	if !errors.Is(err, options.ErrChunkVectorsUnsupported) {
+		t.Errorf("expected ErrChunkVectorsUnsupported without a chunker, got %v", err)
+	}
+}
+
+func TestPreprocessor(t *testing.T) {
+	ctx := context.Background()
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithPreprocessor[string, string](),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = cache.Set(ctx, "k", "  HELLO\u00a0", "v")
+	m, err := cache.LookupEx(ctx, "Hello", 0.99)
+	if err != nil || m == nil || m.Key != "k" {
+		t.Fatalf("expected normalized texts to match, got %+v, %v", m, err)
+	}
+	if m.InputText != "  HELLO\u00a0" {
+		t.Errorf("expected the input text stored as given, got %q", m.InputText)
+	}
+
+	custom, _ := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithPreprocessor[string, string](preprocess.Lowercase, preprocess.StripBoilerplate()),
+	)
+	_ = custom.SetBatch(ctx, []BatchItem[string, string]{{Key: "w", InputText: "World", Value: "v"}})
+	if m, _ := custom.LookupEx(ctx, "Hi, WORLD please!", 0.99); m == nil || m.Key != "w" {
+		t.Errorf("expected boilerplate stripped before embedding, got %+v", m)
+	}
+
+	if _, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithPreprocessor[string, string](nil),
+	); !errors.Is(err, options.ErrNilPreprocessor) {
+		t.Errorf("expected ErrNilPreprocessor, got %v", err)
+	}
+}
+
+func TestSetMulti(t *testing.T) {
+	ctx := context.Background()
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	if err := cache.SetMulti(ctx, "k", nil, "v"); err != ErrNoInputTexts {
+		t.Errorf("expected ErrNoInputTexts, got %v", err)
+	}
+	for _, c := range []*Cache[string, string]{cache, cache.Namespace("ns")} {
+		if err := c.SetMulti(ctx, "k", []string{"hello", "world"}, "greeting"); err != nil {
+			t.Fatalf("SetMulti: %v", err)
+		}
+		_ = c.Set(ctx, "other", "test", "other")
+		m, err := c.LookupEx(ctx, "world", 0.99)
+		if err != nil || m == nil || m.Key != "k" || m.Vector != 1 || m.Score < 0.99 {
+			t.Errorf("expected second vector to match, got %+v err=%v", m, err)
+		}
+		m, _ = c.LookupEx(ctx, "similar to hello", 0.9)
+		if m == nil || m.Key != "k" || m.Vector != 0 {
+			t.Errorf("expected first vector to match, got %+v", m)
+		}
+	}
+	if n, err := cache.Refresh(ctx); err != nil || n != 0 {
+		t.Errorf("expected multi-vector entries to be left alone, got %d, %v", n, err)
+	}
+
+	plain, _ := NewSemanticCache(newMockBackend[string, string](), newMockProvider(), similarity.CosineSimilarity)
+	if err := plain.SetMulti(ctx, "k", []string{"hello"}, "v"); err != ErrMultiVectorUnsupported {
+		t.Errorf("expected ErrMultiVectorUnsupported, got %v", err)
+	}
+}
+
+func TestExpirySweep(t *testing.T) {
+	ctx := context.Background()
+	backend, _ := inmemory.NewLRUBackend[string, string](10)
+	cache, err := New(
+		options.WithCustomBackend[string, string](backend),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithLexicalIndex[string, string](),
+		options.WithExpirySweep[string, string](5*time.Millisecond, time.Hour),
+		options.WithMaintenanceJitter[string, string](0.5),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+	_ = cache.Namespace("t").Set(ctx, "old", "error E1234", "v1")
+	_ = cache.Set(ctx, "new", "error E5678", "v2")
+	_ = backend.SetEntry(ctx, "undated", types.Entry[string]{Embedding: []float64{1, 0, 0}, Value: "v3"})
+	entry, _, _ := backend.GetEntry(ctx, "t:old")
+	entry.CreatedAt = time.Now().Add(-2 * time.Hour)
+	_ = backend.SetEntry(ctx, "t:old", entry)
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if ok, _ := backend.Contains(ctx, "t:old"); !ok {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if ok, _ := backend.Contains(ctx, "t:old"); ok {
+		t.Fatal("expected the sweep to delete the old entry")
+	}
+	for _, key := range []string{"new", "undated"} {
+		if ok, _ := backend.Contains(ctx, key); !ok {
+			t.Errorf("expected %q to survive the sweep", key)
+		}
+	}
+	if scores := cache.lexical.score("t", "e1234"); len(scores) != 0 {
+		t.Errorf("expected the deleted entry's text to leave its namespace's index, got %v", scores)
+	}
+}
+
+func TestExpirySweepBatches(t *testing.T) {
+	ctx := context.Background()
+	backend, _ := inmemory.NewLRUBackend[string, string](10)
+	cache, err := New(
+		options.WithCustomBackend[string, string](backend),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithRefreshBatchSize[string, string](2),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+	cache.expiryAge = time.Hour
+	age := func(key string) {
+		entry, _, _ := backend.GetEntry(ctx, key)
+		entry.CreatedAt = time.Now().Add(-2 * time.Hour)
+		_ = backend.SetEntry(ctx, key, entry)
+	}
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		_ = cache.Set(ctx, key, "text "+key, "v")
+		age(key)
+	}
+	_ = cache.Set(ctx, "fresh", "text fresh", "v")
+
+	if err := cache.sweepExpired(ctx, nil); err != nil {
+		t.Fatalf("sweepExpired: %v", err)
+	}
+	if keys, _ := backend.Keys(ctx); len(keys) != 1 || keys[0] != "fresh" {
+		t.Errorf("expected only the fresh entry to survive, got %v", keys)
+	}
+
+	// A key rewritten after the scan saw it is kept.
+	_ = cache.Set(ctx, "x", "text x", "v")
+	age("x")
+	_ = cache.Set(ctx, "y", "text y", "v")
+	age("y")
+	_ = cache.Set(ctx, "x", "text x again", "v2")
+	cutoff := time.Now().Add(-time.Hour)
+	expired := func(entry types.Entry[string]) bool { return entry.CreatedAt.Before(cutoff) }
+	if err := cache.deleteExpired(ctx, nil, []string{"x", "y"}, expired); err != nil {
+		t.Fatalf("deleteExpired: %v", err)
+	}
+	if ok, _ := backend.Contains(ctx, "x"); !ok {
+		t.Error("expected the rewritten entry to survive")
+	}
+	if ok, _ := backend.Contains(ctx, "y"); ok {
+		t.Error("expected the still-expired entry to be deleted")
+	}
+}
+
+// newestFirstLRU lists an LRU backend's keys most recently used first, so
+// a sweep that touched entries in iteration order would reverse recency.
+type newestFirstLRU struct {
+	*inmemory.LRUBackend[string, string]
+}
+
+func (b newestFirstLRU) IterKeys(ctx context.Context) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		keys, err := b.Keys(ctx)
+		if err != nil {
+			yield("", err)
+			return
+		}
+		slices.Reverse(keys)
+		for _, key := range keys {
+			if !yield(key, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestExpirySweepKeepsEvictionOrder(t *testing.T) {
+	ctx := context.Background()
+	lru, _ := inmemory.NewLRUBackend[string, string](3)
+	cache, err := New(
+		options.WithCustomBackend[string, string](newestFirstLRU{lru}),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+	cache.expiryAge = time.Hour
+	for _, key := range []string{"a", "b", "c"} {
+		_ = cache.Set(ctx, key, "text "+key, "v")
+	}
+
+	if err := cache.sweepExpired(ctx, nil); err != nil {
+		t.Fatalf("sweepExpired: %v", err)
+	}
+	_ = cache.Set(ctx, "d", "text d", "v")
+	if keys, _ := lru.Keys(ctx); !slices.Equal(keys, []string{"b", "c", "d"}) {
+		t.Errorf("expected the sweep to leave a least recently used, got %v", keys)
+	}
+}
+
+func TestRefreshKeepsEvictionOrder(t *testing.T) {
+	ctx := context.Background()
+	lru, _ := inmemory.NewLRUBackend[string, string](3)
+	cache, err := New(
+		options.WithCustomBackend[string, string](newestFirstLRU{lru}),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer cache.Close()
+	for _, key := range []string{"a", "b", "c"} {
+		_ = cache.Set(ctx, key, "text "+key, "v")
+	}
+
+	if n, err := cache.Refresh(ctx); err != nil || n != 0 {
+		t.Fatalf("Refresh = %d, %v; want 0, nil", n, err)
+	}
+	_ = cache.Set(ctx, "d", "text d", "v")
+	if keys, _ := lru.Keys(ctx); !slices.Equal(keys, []string{"b", "c", "d"}) {
+		t.Errorf("expected the refresh scan to leave a least recently used, got %v", keys)
+	}
+}
+
+func TestMaintenanceLimiter(t *testing.T) {
+	ctx := context.Background()
+	lim := newLimiter(1000)
+	start := time.Now()
+	for range 3 {
+		if err := lim.wait(ctx, 20); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected 60 entries at 1000/s to take at least 40ms, took %v", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := lim.wait(cancelled, 1000); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected a cancelled wait, got %v", err)
+	}
+	if err := (*limiter)(nil).wait(ctx, 1000); err != nil {
+		t.Errorf("expected a nil limiter not to wait, got %v", err)
+	}
+
+	for range 100 {
+		if d := jittered(time.Second, 0.25); d < 750*time.Millisecond || d > 1250*time.Millisecond {
+			t.Fatalf("jittered interval %v outside ±25%%", d)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	backend, _ := inmemory.NewLRUBackend[string, string](10)
+	old, err := New(
+		options.WithCustomBackend[string, string](backend),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithEmbeddingVersion[string, string]("v1"),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	_ = old.Set(ctx, "a", "hello", "va")
+	_ = old.Set(ctx, "b", "world", "vb")
+
+	upgraded := newMockProvider()
+	upgraded.embeddings["hello"] = []float64{0, 0, 1}
+	cache, err := New(
+		options.WithCustomBackend[string, string](backend),
+		options.WithCustomProvider[string, string](upgraded),
+		options.WithEmbeddingVersion[string, string]("v2"),
+		options.WithRefreshBatchSize[string, string](1),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	if n, err := cache.Refresh(ctx); err != nil || n != 2 {
+		t.Fatalf("Refresh = %d, %v; want 2", n, err)
+	}
+	entry, _, _ := backend.GetEntry(ctx, "a")
+	if entry.EmbeddingVersion != "v2" || !slices.Equal(entry.Embedding, []float64{0, 0, 1}) {
+		t.Errorf("expected a re-embedded entry, got %+v", entry)
+	}
+	if n, _ := cache.Refresh(ctx); n != 0 {
+		t.Errorf("expected nothing stale after a refresh, got %d", n)
+	}
+
+	t.Run("Background", func(t *testing.T) {
+		_ = old.Set(ctx, "a", "hello", "va")
+		bg, err := New(
+			options.WithCustomBackend[string, string](backend),
+			options.WithCustomProvider[string, string](upgraded),
+			options.WithEmbeddingVersion[string, string]("v2"),
+			options.WithRefreshAhead[string, string](time.Millisecond, 0),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		defer bg.Close()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if e, _, _ := backend.GetEntry(ctx, "a"); e.EmbeddingVersion == "v2" {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+		t.Error("background refresher did not re-embed the entry")
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		plain, _ := NewSemanticCache(newMockBackend[string, string](), newMockProvider(), similarity.CosineSimilarity)
+		if _, err := plain.Refresh(ctx); err != ErrRefreshUnsupported {
+			t.Errorf("expected ErrRefreshUnsupported, got %v", err)
+		}
+	})
+}
+
+func TestEmbeddingVersions(t *testing.T) {
+	ctx := context.Background()
+	backend, _ := inmemory.NewLRUBackend[string, string](10)
+	newCache := func(provider types.EmbeddingProvider, opts ...options.Option[string, string]) *Cache[string, string] {
+		t.Helper()
+		cache, err := New(append([]options.Option[string, string]{
+			options.WithCustomBackend[string, string](backend),
+			options.WithCustomProvider[string, string](provider),
+		}, opts...)...)
+		if err != nil {
+			t.Fatalf("Failed to create cache: %v", err)
+		}
+		return cache
+	}
+	old := newCache(newMockProvider(), options.WithEmbeddingVersion[string, string]("v1"))
+	_ = old.Set(ctx, "a", "hello", "va")
+
+	t.Run("SkipsOtherVersions", func(t *testing.T) {
+		cache := newCache(newMockProvider(), options.WithEmbeddingVersion[string, string]("v2"))
+		if m, _ := cache.Lookup(ctx, "hello", 0.9); m != nil {
+			t.Errorf("expected no match across versions, got %+v", m)
+		}
+		if m, _ := old.Lookup(ctx, "hello", 0.9); m == nil {
+			t.Error("expected a match within the same version")
+		}
+	})
+
+	t.Run("SkipsOtherDimensions", func(t *testing.T) {
+		wide := newMockProvider()
+		wide.embeddings["hello"] = []float64{1, 0, 0, 0}
+		cache := newCache(wide)
+		if ms, _ := cache.TopMatches(ctx, "hello", 5); len(ms) != 0 {
+			t.Errorf("expected no matches across dimensions, got %+v", ms)
+		}
+	})
+
+	t.Run("ReembedOnLookup", func(t *testing.T) {
+		cache := newCache(newMockProvider(),
+			options.WithEmbeddingVersion[string, string]("v2"),
+			options.WithReembedOnLookup[string, string](),
+		)
+		if m, _ := cache.Lookup(ctx, "hello", 0.9); m != nil {
+			t.Errorf("expected the first lookup to skip the entry, got %+v", m)
+		}
+		if m, _ := cache.Lookup(ctx, "hello", 0.9); m == nil || m.Value != "va" {
+			t.Errorf("expected the re-embedded entry to match, got %+v", m)
+		}
+	})
+
+	t.Run("ProviderVersion", func(t *testing.T) {
+		cache := newCache(local.New(8))
+		_ = cache.Set(ctx, "b", "world", "vb")
+		if e, _, _ := backend.GetEntry(ctx, "b"); e.EmbeddingVersion != "local/8" {
+			t.Errorf("expected the provider's version, got %q", e.EmbeddingVersion)
+		}
+	})
+}
+
+func TestKeyValidator(t *testing.T) {
+	ctx := context.Background()
+	cache, err := New(
+		options.WithLRUBackend[int, string](10),
+		options.WithCustomProvider[int, string](newMockProvider()),
+		options.WithKeyValidator[int, string](func(key int) error {
+			if key < 0 {
+				return errNegativeKey
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	if err := cache.Set(ctx, 0, "hello", "zero"); err != nil {
+		t.Errorf("expected the zero key to be accepted, got %v", err)
+	}
+	if v, ok, _ := cache.Get(ctx, 0); !ok || v != "zero" {
+		t.Errorf("Get(0) = %q, %v", v, ok)
+	}
+	if err := cache.Set(ctx, -1, "hello", "neg"); err != errNegativeKey {
+		t.Errorf("expected the validator's error from Set, got %v", err)
+	}
+	if err := cache.SetBatch(ctx, []BatchItem[int, string]{{Key: -1, InputText: "hello"}}); err != errNegativeKey {
+		t.Errorf("expected the validator's error from SetBatch, got %v", err)
+	}
+	if _, _, err := cache.LookupOrCompute(ctx, -1, "hello", 0.9, func(context.Context) (string, error) { return "", nil }); err != errNegativeKey {
+		t.Errorf("expected the validator's error from LookupOrCompute, got %v", err)
+	}
+}
+
+var errNegativeKey = errors.New("negative key")
+
+func TestNamespace(t *testing.T) {
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	a, b := cache.Namespace("a"), cache.Namespace("b")
+	_ = a.Set(ctx, "k", "hello", "from a")
+	_ = b.Set(ctx, "k", "world", "from b")
+
+	if v, _, _ := a.Get(ctx, "k"); v != "from a" {
+		t.Errorf("expected a's value, got %q", v)
+	}
+	if m, _ := b.Lookup(ctx, "hello", 0.9); m != nil {
+		t.Errorf("b must not see a's entry, got %+v", m)
+	}
+	if m, _ := a.LookupEx(ctx, "hello", 0.9); m == nil || m.Key != "k" {
+		t.Errorf("expected unprefixed key from a, got %+v", m)
+	}
+	if ok, _ := cache.Contains(ctx, "a:k"); !ok {
+		t.Error("expected string keys to be prefixed in the parent")
+	}
+	if n, _ := a.Len(ctx); n != 1 {
+		t.Errorf("expected 1 entry in a, got %d", n)
+	}
+	if m, _ := a.Namespace("x").Lookup(ctx, "hello", 0.9); m != nil {
+		t.Errorf("nested namespace must not see its parent's entries, got %+v", m)
+	}
+
+	_ = a.Flush(ctx)
+	if n, _ := cache.Len(ctx); n != 1 {
+		t.Errorf("flushing a should leave b's entry, have %d", n)
+	}
+
+	_ = b.Close()
+	if err := cache.Set(ctx, "k", "hello", "v"); err != ErrClosed {
+		t.Errorf("closing a view should close the cache, got %v", err)
+	}
+}
+
+func TestSessionScope(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := New(
+		options.WithLRUBackend[string, string](100),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	_ = cache.Set(ctx, "shared", "world", "global")
+	alice := cache.WithSessionScope("alice")
+	bob := cache.WithSessionScope("bob")
+	if alice.ID() != "alice" {
+		t.Errorf("ID = %q", alice.ID())
+	}
+	_ = alice.Set(ctx, "q1", "hello", "alice answer")
+	_ = alice.Namespace("tools").Set(ctx, "q2", "test", "tool answer")
+	_ = bob.Set(ctx, "q1", "hello", "bob answer")
+
+	if m, _ := bob.Lookup(ctx, "hello", 0.9); m == nil || m.Value != "bob answer" {
+		t.Errorf("expected bob's own entry, got %+v", m)
+	}
+	if err := alice.End(ctx); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+	if n, _ := alice.Len(ctx); n != 0 {
+		t.Errorf("expected an empty session after End, got %d", n)
+	}
+	if _, ok, _ := alice.Namespace("tools").Get(ctx, "q2"); ok {
+		t.Error("expected nested entries removed by End")
+	}
+	if n, _ := cache.Len(ctx); n != 2 {
+		t.Errorf("expected other entries kept, got %d", n)
+	}
+	if err := alice.Set(ctx, "q3", "hello", "again"); err != nil {
+		t.Errorf("expected the session usable after End, got %v", err)
+	}
+}
+
+func TestSessionScope_Reserved(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := New(
+		options.WithLRUBackend[string, string](100),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	alice := cache.WithSessionScope("alice")
+	_ = alice.Set(ctx, "q", "hello", "alice")
+
+	for name, view := range map[string]*Cache[string, string]{
+		"session namespace":        cache.Namespace("session"),
+		"session:alice namespace":  cache.Namespace("session:alice"),
+		"nested session namespace": cache.Namespace("session").Namespace("alice"),
+		"session alice:x":          cache.WithSessionScope("alice:x").Cache,
+		"session alice/x":          cache.WithSessionScope("alice/x").Cache,
+	} {
+		if _, ok, _ := view.Get(ctx, "q"); ok {
+			t.Errorf("%s read alice's entry", name)
+		}
+		if n, _ := view.Len(ctx); n != 0 {
+			t.Errorf("%s sees %d entries", name, n)
+		}
+	}
+	_ = cache.Namespace("session").Flush(ctx)
+	_ = cache.WithSessionScope("alice/x").End(ctx)
+	if _, ok, _ := alice.Get(ctx, "q"); !ok {
+		t.Error("other views must not flush alice's session")
+	}
+}
+
+func TestNamespace_TaggedKeys(t *testing.T) {
+	cache, _ := New(
+		options.WithLRUBackend[int, string](10),
+		options.WithCustomProvider[int, string](newMockProvider()),
+	)
+	ctx := context.Background()
+	a, b := cache.Namespace("a"), cache.Namespace("b")
+	_ = a.SetWithMetadata(ctx, 1, "hello", "from a", map[string]string{"lang": "en"})
+	_ = b.Set(ctx, 2, "hello", "from b")
+
+	if _, ok, _ := b.Get(ctx, 1); ok {
+		t.Error("b must not read a's key")
+	}
+	m, _ := a.LookupEx(ctx, "hello", 0.9)
+	if m == nil || m.Key != 1 || m.Metadata["lang"] != "en" || m.Metadata[NamespaceMetadataKey] != "" {
+		t.Errorf("expected a's entry without the namespace tag, got %+v", m)
+	}
+	var keys []int
+	for k, err := range b.IterKeys(ctx) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) != 1 || keys[0] != 2 {
+		t.Errorf("expected only b's key, got %v", keys)
+	}
+	_ = b.Delete(ctx, 1)
+	if ok, _ := a.Contains(ctx, 1); !ok {
+		t.Error("b must not delete a's key")
+	}
+
+	plain, _ := NewSemanticCache(newMockBackend[int, string](), newMockProvider(), similarity.CosineSimilarity)
+	if err := plain.Namespace("a").Set(ctx, 1, "hello", "v"); err != ErrMetadataUnsupported {
+		t.Errorf("expected ErrMetadataUnsupported, got %v", err)
+	}
+}
+
+func TestNamespace_Isolation(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := New(
+		options.WithLRUBackend[string, string](100),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	acme := cache.Namespace("acme")
+	_ = acme.Set(ctx, "k", "hello", "acme")
+	_ = cache.Namespace("acme:x").Set(ctx, "k", "hello", "sibling")
+	_ = acme.Namespace("x").Set(ctx, "k", "hello", "nested")
+
+	if n, _ := acme.Len(ctx); n != 1 {
+		t.Errorf("expected acme to see only its own entry, got %d", n)
+	}
+	if _, ok, _ := acme.Get(ctx, "x:k"); ok {
+		t.Error("acme must not read a sibling's or nested view's key")
+	}
+	if v, _, _ := acme.Namespace("x").Get(ctx, "k"); v != "nested" {
+		t.Errorf("nested view got %q", v)
+	}
+	if v, _, _ := cache.Namespace("acme:x").Get(ctx, "k"); v != "sibling" {
+		t.Errorf("sibling view got %q", v)
+	}
+	_ = acme.Flush(ctx)
+	if n, _ := cache.Len(ctx); n != 2 {
+		t.Errorf("expected acme's Flush to keep the other views' entries, got %d", n)
+	}
+
+	_ = cache.Namespace("a").Namespace("b:x").Set(ctx, "k", "hello", "v")
+	if _, ok, _ := cache.Namespace("a:b").Namespace("x").Get(ctx, "k"); ok {
+		t.Error(`("a", "b:x") and ("a:b", "x") must not collide`)
+	}
+
+	tagged, _ := New(
+		options.WithLRUBackend[int, string](10),
+		options.WithCustomProvider[int, string](newMockProvider()),
+	)
+	_ = tagged.Namespace("a").Namespace("b:x").Set(ctx, 1, "hello", "v")
+	if _, ok, _ := tagged.Namespace("a:b").Namespace("x").Get(ctx, 1); ok {
+		t.Error("tagged views must not collide either")
+	}
+	if n, _ := tagged.Namespace("a").Len(ctx); n != 0 {
+		t.Errorf("expected a tagged parent not to see nested entries, got %d", n)
+	}
+}
+
+func TestBatchOperations(t *testing.T) {
+	cache, err := New(
+		options.WithCustomBackend(newMockBackend[string, string]()),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("SetBatch", func(t *testing.T) {
+		items := []BatchItem[string, string]{
+			{Key: "b1", InputText: "hello", Value: "g1"},
+			{Key: "b2", InputText: "world", Value: "p1"},
+		}
+		if err := cache.SetBatch(ctx, items); err != nil {
+			t.Fatalf("SetBatch failed: %v", err)
+		}
+		v, found, _ := cache.Get(ctx, "b1")
+		if !found || v != "g1" {
+			t.Errorf("expected g1, got %s", v)
+		}
+	})
+
+	t.Run("GetBatch", func(t *testing.T) {
+		results, err := cache.GetBatch(ctx, []string{"b1", "b2", "missing"})
+		if err != nil {
+			t.Fatalf("GetBatch failed: %v", err)
+		}
+		if len(results) != 2 {
+			t.Errorf("expected 2 results, got %d", len(results))
+		}
+	})
+
+	t.Run("DeleteBatch", func(t *testing.T) {
+		if err := cache.DeleteBatch(ctx, []string{"b1", "b2"}); err != nil {
+			t.Fatalf("DeleteBatch failed: %v", err)
+		}
+		n, _ := cache.Len(ctx)
+		if n != 0 {
+			t.Errorf("expected 0, got %d", n)
+		}
+	})
+
+	t.Run("SetBatchZeroKey", func(t *testing.T) {
+		items := []BatchItem[string, string]{{Key: "", InputText: "hello", Value: "v"}}
+		if err := cache.SetBatch(ctx, items); err == nil {
+			t.Error("expected error for zero-value key")
+		}
+	})
+}
+
+// spanRecorder is a trace.TracerProvider that keeps the spans it ends, so
+// tracing tests need no OpenTelemetry SDK.
+type spanRecorder struct {
+	embedded.TracerProvider
+
+	ids   atomic.Uint64
+	mu    sync.Mutex
+	ended []*recordedSpan
+}
+
+type recordedSpan struct {
+	noop.Span
+	rec    *spanRecorder
+	name   string
+	parent trace.SpanContext
+	sc     trace.SpanContext
+
+	mu     sync.Mutex
+	attrs  []attribute.KeyValue
+	status codes.Code
+}
+
+type recordingTracer struct {
+	embedded.Tracer
+	rec *spanRecorder
+}
+
+func (r *spanRecorder) Tracer(string, ...trace.TracerOption) trace.Tracer {
+	return recordingTracer{rec: r}
+}
+
+func (t recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	var id trace.SpanID
+	binary.BigEndian.PutUint64(id[:], t.rec.ids.Add(1))
+	cfg := trace.NewSpanStartConfig(opts...)
+	s := &recordedSpan{
+		rec:    t.rec,
+		name:   name,
+		parent: trace.SpanContextFromContext(ctx),
+		sc: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{1},
+			SpanID:     id,
+			TraceFlags: trace.FlagsSampled,
+		}),
+		attrs: cfg.Attributes(),
+	}
+	return trace.ContextWithSpan(ctx, s), s
+}
+
+// Ended returns the spans ended since the last call.
+func (r *spanRecorder) Ended() []*recordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ended := r.ended
+	r.ended = nil
+	return ended
+}
+
+func (s *recordedSpan) SpanContext() trace.SpanContext { return s.sc }
+func (s *recordedSpan) IsRecording() bool              { return true }
+
+func (s *recordedSpan) SetAttributes(kv ...attribute.KeyValue) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, kv...)
+}
+
+func (s *recordedSpan) SetStatus(code codes.Code, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = code
+}
+
+func (s *recordedSpan) End(...trace.SpanEndOption) {
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+	s.rec.ended = append(s.rec.ended, s)
+}
+
+// attr returns the last value set for key.
+func (s *recordedSpan) attr(key attribute.Key) attribute.Value {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var v attribute.Value
+	for _, kv := range s.attrs {
+		if kv.Key == key {
+			v = kv.Value
+		}
+	}
+	return v
+}
+
+func TestTracing(t *testing.T) {
+	tp := &spanRecorder{}
+	provider := newMockProvider()
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](provider),
+		options.WithTracerProvider[string, string](tp),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	defer parent.End()
+
+	// spans returns the spans ended since the last call, by name.
+	spans := func() map[string]*recordedSpan {
+		out := make(map[string]*recordedSpan)
+		for _, s := range tp.Ended() {
+			out[s.name] = s
+		}
+		return out
+	}
+
+	if err := cache.Set(ctx, "k1", "hello", "v1"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	got := spans()
+	set, ok := got["semanticcache.Set"]
+	if !ok || set.parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("expected a Set span under the request span, got %v", got)
+	}
+	embed, ok := got["semanticcache.provider.EmbedText"]
+	if !ok || embed.parent.SpanID() != set.SpanContext().SpanID() || embed.attr(attrDims).AsInt64() != 3 {
+		t.Errorf("expected a 3-dimensional EmbedText span under Set, got %v", got)
+	}
+	if _, ok := got["semanticcache.backend.Set"]; !ok {
+		t.Errorf("expected a backend Set span, got %v", got)
+	}
+
+	if _, err := cache.Lookup(ctx, "similar to hello", 0.5); err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	lookup, ok := spans()["semanticcache.Lookup"]
+	if !ok || !lookup.attr(attrHit).AsBool() || lookup.attr(attrScore).AsFloat64() < 0.5 {
+		t.Errorf("expected a Lookup hit with its score, got %+v", lookup)
+	}
+
+	if _, found, _ := cache.Get(ctx, "missing"); found {
+		t.Fatal("expected a miss")
+	}
+	if get := spans()["semanticcache.Get"]; get == nil || get.attr(attrHit).AsBool() {
+		t.Errorf("expected a Get span recording the miss, got %+v", get)
+	}
+
+	if _, err := cache.GetBatch(ctx, []string{"k1", "k2"}); err != nil {
+		t.Fatalf("GetBatch failed: %v", err)
+	}
+	if batch := spans()["semanticcache.GetBatch"]; batch == nil || batch.attr(attrKeys).AsInt64() != 2 || batch.attr(attrMatches).AsInt64() != 1 {
+		t.Errorf("expected a GetBatch span over 2 keys with 1 found, got %+v", batch)
+	}
+
+	provider.shouldErr = true
+	if _, err := cache.TopMatches(ctx, "hello", 3); err == nil {
+		t.Fatal("expected a provider error")
+	}
+	top := spans()["semanticcache.TopMatches"]
+	if top == nil || top.status != codes.Error || top.attr(attrLimit).AsInt64() != 3 {
+		t.Errorf("expected an errored TopMatches span, got %+v", top)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	provider := newMockProvider()
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](provider),
+		options.WithCircuitBreaker[string, string](2, 30*time.Millisecond),
+		options.WithExactFallback[string, string](),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := cache.SetText(ctx, "Hello", "v1"); err != nil {
+		t.Fatalf("SetText failed: %v", err)
+	}
+
+	provider.shouldErr = true
+	for range 2 {
+		if err := cache.Set(ctx, "k", "world", "v2"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected the provider's error, got %v", err)
+		}
+	}
+	if cache.ProviderAvailable() {
+		t.Error("expected the circuit to open after 2 failures")
+	}
+	if err := cache.Set(ctx, "k", "world", "v2"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	m, err := cache.LookupEx(ctx, "  hello ", 0.9)
+	if err != nil || m == nil || m.Value != "v1" || m.Score != 1 {
+		t.Errorf("expected the exact text fallback, got %+v, %v", m, err)
+	}
+	if m, err := cache.Lookup(ctx, "world", 0.9); err != nil || m != nil {
+		t.Errorf("expected a miss without error, got %+v, %v", m, err)
+	}
+
+	// A failed probe reopens the circuit; a successful one closes it.
+	time.Sleep(40 * time.Millisecond)
+	if err := cache.Set(ctx, "k", "world", "v2"); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the probe to reach the provider, got %v", err)
+	}
+	if err := cache.Set(ctx, "k", "world", "v2"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected the failed probe to reopen the circuit, got %v", err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	provider.shouldErr = false
+	if err := cache.Set(ctx, "k", "world", "v2"); err != nil {
+		t.Fatalf("expected the probe to succeed, got %v", err)
+	}
+	if !cache.ProviderAvailable() {
+		t.Error("expected the circuit to close")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	provider.shouldErr = true
+	for range 3 {
+		_ = cache.Set(cancelled, "k", "world", "v2")
+	}
+	if !cache.ProviderAvailable() {
+		t.Error("expected cancelled calls not to count as failures")
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	provider := newMockProvider()
+	backend := newMockBackend[string, string]()
+	cache, err := New(
+		options.WithCustomBackend[string, string](backend),
+		options.WithCustomProvider[string, string](provider),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	report := cache.HealthCheck(ctx)
+	if !report.Ready || !report.Live || report.Provider.Err != nil || report.Backend.Err != nil {
+		t.Fatalf("expected a healthy report, got %+v", report)
+	}
+	if len(backend.data) != 0 {
+		t.Errorf("expected HealthCheck not to write to the backend, got %v", backend.data)
+	}
+
+	provider.shouldErr = true
+	report = cache.HealthCheck(ctx)
+	if report.Ready || !report.Live || report.Provider.Healthy || report.Provider.Error == "" {
+		t.Errorf("expected a live but unready report, got %+v", report)
+	}
+
+	backend.shouldErr = true
+	if report = cache.HealthCheck(ctx); report.Live || report.Backend.Healthy {
+		t.Errorf("expected an unhealthy backend, got %+v", report)
+	}
+
+	_ = cache.Close()
+	if report = cache.HealthCheck(ctx); report.Live || !errors.Is(report.Backend.Err, ErrClosed) {
+		t.Errorf("expected ErrClosed after Close, got %+v", report)
+	}
+}
+
+func TestHealthCheckFullBackend(t *testing.T) {
+	ctx := context.Background()
+	var evicted []string
+	cache, err := New(
+		options.WithLRUBackend[string, string](1),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithEvictionCallback[string, string](func(key string, _ types.Entry[string]) {
+			evicted = append(evicted, key)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	defer func() { _ = cache.Close() }()
+
+	if err := cache.Set(ctx, "k", "hello", "v"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if report := cache.HealthCheck(ctx); !report.Ready {
+		t.Fatalf("expected a healthy report, got %+v", report)
+	}
+	if _, found, _ := cache.Get(ctx, "k"); !found || len(evicted) != 0 {
+		t.Errorf("HealthCheck on a full backend: found=%v, evicted %v", found, evicted)
+	}
+}
+
+func TestAlerts(t *testing.T) {
+	provider := newMockProvider()
+	var missAlerts, errorAlerts []int
+	cache, err := New(
+		options.WithLRUBackend[string, string](10),
+		options.WithCustomProvider[string, string](provider),
+		options.OnMissRateAbove[string, string](0.5, time.Minute, 4, func(rate float64, lookups int) {
+			missAlerts = append(missAlerts, lookups)
+		}),
+		options.OnProviderErrorBurst[string, string](3, time.Minute, func(failures int, last error) {
+			if last == nil {
+				t.Error("expected the last error")
+			}
+			errorAlerts = append(errorAlerts, failures)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	if err := cache.Set(ctx, "k", "hello", "v"); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	// Three misses in three lookups stay under the minimum; the fourth
+	// lookup crosses it, and later misses do not fire again.
+	for range 3 {
+		_, _ = cache.Lookup(ctx, "world", 0.9)
+	}
+	if len(missAlerts) != 0 {
+		t.Fatalf("expected no alert below the minimum lookups, got %v", missAlerts)
+	}
+	for range 3 {
+		_, _ = cache.Lookup(ctx, "world", 0.9)
+	}
+	if len(missAlerts) != 1 || missAlerts[0] != 4 {
+		t.Errorf("expected one alert at 4 lookups, got %v", missAlerts)
+	}
+	if _, err := cache.TopMatches(ctx, "world", 1); err != nil {
+		t.Fatalf("TopMatches failed: %v", err)
+	}
+
+	provider.shouldErr = true
+	for range 5 {
+		_ = cache.Set(ctx, "k2", "test", "v")
+	}
+	if len(errorAlerts) != 1 || errorAlerts[0] != 3 {
+		t.Errorf("expected one alert at the third failure, got %v", errorAlerts)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_ = cache.Set(cancelled, "k2", "test", "v")
+	if len(errorAlerts) != 1 {
+		t.Errorf("expected cancelled calls not to fire, got %v", errorAlerts)
+	}
+}
+
+// flakyBackend fails to load the embedding of key "bad".
+type flakyBackend[V any] struct {
+	*mockBackend[string, V]
+}
+
+func (b flakyBackend[V]) GetEmbedding(ctx context.Context, key string) ([]float64, bool, error) {
+	if key == "bad" {
+		return nil, false, &testError{"disk error"}
+	}
+	return b.mockBackend.GetEmbedding(ctx, key)
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	cache, err := New(
+		options.WithCustomBackend(flakyBackend[string]{newMockBackend[string, string]()}),
+		options.WithCustomProvider[string, string](newMockProvider()),
+		options.WithReranker[string, string](providers.RerankFunc(func(context.Context, string, []string) ([]float64, error) {
+			return nil, errors.New("reranker down")
+		}), 5, 0),
+		options.WithLogger[string, string](logger),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	_ = cache.Set(ctx, "good", "hello", "v1")
+	_ = cache.Set(ctx, "bad", "world", "v2")
+
+	match, err := cache.Lookup(ctx, "hello", 0.5)
+	if err != nil || match == nil || match.Value != "v1" {
+		t.Fatalf("expected the good entry despite the bad one, got %+v, %v", match, err)
+	}
+	logs := buf.String()
+	for _, want := range []string{
+		"level=WARN msg=\"semanticcache: lookup skipped entries whose embeddings failed to load\" entries=1 error=\"disk error\"",
+		"level=WARN msg=\"semanticcache: reranker failed; keeping vector order\" error=\"reranker down\"",
+		"level=DEBUG msg=\"semanticcache: reranked lookup\" top_k=5",
+		"level=DEBUG msg=\"semanticcache: lookup answered by scan\" candidates=1 matches=1",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("expected log line containing %q, got:\n%s", want, logs)
+		}
+	}
+	if strings.Contains(logs, "good") || strings.Contains(logs, "hello") {
+		t.Errorf("expected no keys or texts in logs, got:\n%s", logs)
+	}
+}
+
+// concurrentProvider records the most EmbedText calls in flight at once
+// and fails on "fail".
+type concurrentProvider struct {
+	*mockProvider
+	mu             sync.Mutex
+	active, maxRun int
+}
+
+func (p *concurrentProvider) EmbedText(ctx context.Context, text string) ([]float64, error) {
+	p.mu.Lock()
+	p.active++
+	p.maxRun = max(p.maxRun, p.active)
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.active--
+		p.mu.Unlock()
+	}()
+	time.Sleep(5 * time.Millisecond)
+	if text == "fail" {
+		return nil, &testError{"provider error"}
+	}
+	return p.mockProvider.EmbedText(ctx, text)
+}
+
+func TestBatchConcurrency(t *testing.T) {
+	provider := &concurrentProvider{mockProvider: newMockProvider()}
+	cache, err := New(
+		options.WithLRUBackend[string, string](100),
+		options.WithCustomProvider[string, string](provider),
+		options.WithBatchConcurrency[string, string](3),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+
+	items := make([]BatchItem[string, string], 12)
+	for i := range items {
+		items[i] = BatchItem[string, string]{Key: fmt.Sprintf("k%d", i), InputText: "hello", Value: fmt.Sprint(i)}
+	}
+	if err := cache.SetBatch(ctx, items); err != nil {
+		t.Fatalf("SetBatch failed: %v", err)
+	}
+	if provider.maxRun != 3 {
+		t.Errorf("expected 3 embedding calls at once, got %d", provider.maxRun)
+	}
+	if n, _ := cache.Len(ctx); n != len(items) {
+		t.Errorf("expected %d entries, got %d", len(items), n)
+	}
+
+	items[5].InputText = "fail"
+	items[5].Key = "failed"
+	if err := cache.SetBatch(ctx, items); err == nil || err.Error() != "provider error" {
+		t.Errorf("expected the provider error, got %v", err)
+	}
+	if ok, _ := cache.Contains(ctx, "failed"); ok {
+		t.Error("expected nothing stored from a failed batch")
+	}
+}
+
+func TestBatchOperations_BatchBackend(t *testing.T) {
+	backend := newMockBatchBackend[string, string]()
+	cache, err := NewSemanticCache(backend, newMockProvider(), similarity.CosineSimilarity)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := cache.SetBatch(ctx, []BatchItem[string, string]{
+		{Key: "a", InputText: "hello", Value: "va"},
+		{Key: "b", InputText: "world", Value: "vb"},
+	}); err != nil {
+		t.Fatalf("SetBatch failed: %v", err)
+	}
+	if backend.setBatchCalls != 1 {
+		t.Errorf("expected 1 SetBatch call, got %d", backend.setBatchCalls)
+	}
+
+	results, err := cache.GetBatch(ctx, []string{"a", "b", "missing"})
+	if err != nil {
+		t.Fatalf("GetBatch failed: %v", err)
+	}
+	if len(results) != 2 || results["a"] != "va" {
+		t.Errorf("unexpected results: %v", results)
+	}
+	if backend.getBatchCalls != 1 {
+		t.Errorf("expected 1 GetBatch call, got %d", backend.getBatchCalls)
+	}
+
+	if err := cache.DeleteBatch(ctx, []string{"a", "b"}); err != nil {
+		t.Fatalf("DeleteBatch failed: %v", err)
+	}
+	if backend.deleteBatchCalls != 1 {
+		t.Errorf("expected 1 DeleteBatch call, got %d", backend.deleteBatchCalls)
+	}
+	if n, _ := cache.Len(ctx); n != 0 {
+		t.Errorf("expected 0 entries, got %d", n)
+	}
+}
+
+func TestIterKeys(t *testing.T) {
+	backend := &mockIterBackend[string, string]{mockBackend: newMockBackend[string, string]()}
+	cache, err := NewSemanticCache(backend, newMockProvider(), similarity.CosineSimilarity)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	ctx := context.Background()
+	_ = cache.Set(ctx, "a", "hello", "va")
+	_ = cache.Set(ctx, "b", "world", "vb")
+
+	if m, err := cache.Lookup(ctx, "hello", 0.9); err != nil || m == nil || m.Value != "va" {
+		t.Fatalf("expected va, got %v (err=%v)", m, err)
+	}
+	if _, err := cache.TopMatches(ctx, "hello", 2); err != nil {
+		t.Fatalf("TopMatches failed: %v", err)
+	}
+	if backend.iterCalls != 2 || backend.keysCalls != 0 {
+		t.Errorf("expected searches to stream keys, got %d IterKeys and %d Keys calls", backend.iterCalls, backend.keysCalls)
+	}
+
+	seen := 0
+	for _, err := range cache.IterKeys(ctx) {
+		if err != nil {
+			t.Fatalf("IterKeys failed: %v", err)
+		}
+		seen++
+		break
+	}
+	if seen != 1 {
+		t.Errorf("expected early break after 1 key, got %d", seen)
+	}
+
+	_ = cache.Close()
+	for _, err := range cache.IterKeys(ctx) {
+		if err != ErrClosed {
+			t.Errorf("expected ErrClosed, got %v", err)
+		}
+	}
+}
+
+func TestErrorHandling(t *testing.T) {
+	t.Run("ProviderError", func(t *testing.T) {
+		cache, _ := New(
+			options.WithCustomBackend(newMockBackend[string, string]()),
+			options.WithCustomProvider[string, string](&mockProvider{shouldErr: true}),
+		)
+		ctx := context.Background()
+
+		if err := cache.Set(ctx, "key", "text", "value"); err == nil {
+			t.Error("expected provider error on Set")
+		}
+		if _, err := cache.Lookup(ctx, "text", 0.5); err == nil {
+			t.Error("expected provider error on Lookup")
+		}
+		if _, err := cache.TopMatches(ctx, "text", 1); err == nil {
+			t.Error("expected provider error on TopMatches")
+		}
+	})
+
+	t.Run("BackendError", func(t *testing.T) {
+		backend := &mockBackend[string, string]{
+			data:      make(map[string]types.Entry[string]),
+			shouldErr: true,
+		}
+		cache, _ := NewSemanticCache(backend, newMockProvider(), similarity.CosineSimilarity)
+		ctx := context.Background()
+
+		if err := cache.Set(ctx, "key", "text", "value"); err == nil {
+			t.Error("expected backend error on Set")
+		}
+		if _, _, err := cache.Get(ctx, "key"); err == nil {
+			t.Error("expected backend error on Get")
 		}
 		if _, err := cache.Contains(ctx, "key"); err == nil {
 			t.Error("expected backend error on Contains")
@@ -475,6 +3395,193 @@ func TestClose(t *testing.T) {
 	}
 }
 
+// slowBackend takes 10ms to fetch each embedding.
+type slowBackend struct {
+	*mockBackend[string, string]
+}
+
+func (b slowBackend) GetEmbedding(ctx context.Context, key string) ([]float64, bool, error) {
+	time.Sleep(10 * time.Millisecond)
+	return b.mockBackend.GetEmbedding(ctx, key)
+}
+
+// cancellingBackend calls cancel on the nth embedding it serves.
+type cancellingBackend[V any] struct {
+	*mockBackend[string, V]
+	n, reads int
+	cancel   context.CancelFunc
+}
+
+func (b *cancellingBackend[V]) GetEmbedding(ctx context.Context, key string) ([]float64, bool, error) {
+	if b.reads++; b.reads == b.n {
+		b.cancel()
+	}
+	return b.mockBackend.GetEmbedding(ctx, key)
+}
+
+func TestScanCancellation(t *testing.T) {
+	backend := &cancellingBackend[string]{mockBackend: newMockBackend[string, string](), n: 3}
+	provider := newMockProvider()
+	cache, err := New(
+		options.WithCustomBackend(backend),
+		options.WithCustomProvider[string, string](provider),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	for i := range 20 {
+		if err := cache.Set(context.Background(), fmt.Sprintf("k%d", i), "hello", "v"); err != nil {
+			t.Fatalf("Set failed: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	backend.cancel = cancel
+	if _, err := cache.TopMatches(ctx, "hello", 5); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if backend.reads != 3 {
+		t.Errorf("expected the scan to stop after 3 reads, got %d", backend.reads)
+	}
+
+	provider.shouldErr = true // a cancelled lookup must not reach the provider
+	if _, err := cache.Lookup(ctx, "hello", 0.5); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled before embedding, got %v", err)
+	}
+	if err := cache.Range(ctx, func(string, types.Entry[string]) bool { return true }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected Range to stop with context.Canceled, got %v", err)
+	}
+}
+
+func TestBestEffort(t *testing.T) {
+	backend := slowBackend{newMockBackend[string, string]()}
+	cache, err := NewSemanticCache[string, string](backend, newMockProvider(), similarity.CosineSimilarity)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	for i := range 20 {
+		_ = cache.Set(context.Background(), fmt.Sprint("k", i), "hello", "v")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Millisecond)
+	defer cancel()
+	m, err := cache.Lookup(ctx, "hello", 0.9, WithBestEffort(30*time.Millisecond))
+	if err != nil || m == nil || !m.Partial {
+		t.Fatalf("expected a partial match before the deadline, got %+v err=%v", m, err)
+	}
+	if _, err := cache.Lookup(ctx, "hello", 0.9); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a plain lookup to time out, got %v", err)
+	}
+	if m, _ := cache.Lookup(context.Background(), "hello", 0.9, WithBestEffort(time.Millisecond)); m == nil || m.Partial {
+		t.Errorf("expected a full scan without a deadline, got %+v", m)
+	}
+}
+
+// shutdownBackend records whether the cache shut it down with a context.
+type shutdownBackend struct {
+	*mockBackend[string, string]
+	shutdown, closed bool
+}
+
+func (b *shutdownBackend) Shutdown(ctx context.Context) error {
+	b.shutdown = true
+	return ctx.Err()
+}
+
+func (b *shutdownBackend) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestShutdown(t *testing.T) {
+	backend := &shutdownBackend{mockBackend: newMockBackend[string, string]()}
+	cache, _ := New(
+		options.WithCustomBackend[string, string](backend),
+		options.WithCustomProvider[string, string](newMockProvider()),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cache.Shutdown(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected backend's context error, got %v", err)
+	}
+	if !backend.shutdown || backend.closed {
+		t.Errorf("expected Shutdown instead of Close, got shutdown=%v closed=%v", backend.shutdown, backend.closed)
+	}
+	if err := cache.Set(context.Background(), "k", "t", "v"); err != ErrClosed {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if err := cache.Close(); err != nil {
+		t.Errorf("Close after Shutdown returned error: %v", err)
+	}
+}
+
+// blockingProvider blocks EmbedText until release is closed.
+type blockingProvider struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+	closed  atomic.Bool
+}
+
+func (p *blockingProvider) EmbedText(context.Context, string) ([]float64, error) {
+	p.once.Do(func() { close(p.started) })
+	<-p.release
+	return []float64{1, 0, 0}, nil
+}
+
+func (p *blockingProvider) Close() error {
+	p.closed.Store(true)
+	return nil
+}
+
+func TestShutdownWaitsForCalls(t *testing.T) {
+	ctx := context.Background()
+	// start returns a cache with a Set in progress on one of its views.
+	start := func() (*Cache[string, string], *blockingProvider, chan error) {
+		provider := &blockingProvider{started: make(chan struct{}), release: make(chan struct{})}
+		cache, _ := New(
+			options.WithCustomBackend[string, string](newMockBackend[string, string]()),
+			options.WithCustomProvider[string, string](provider),
+		)
+		setErr := make(chan error, 1)
+		go func() { setErr <- cache.Namespace("t").Set(ctx, "k", "hello", "v") }()
+		<-provider.started
+		return cache, provider, setErr
+	}
+
+	cache, provider, setErr := start()
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- cache.Shutdown(ctx) }()
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned %v before the in-flight Set ended", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	if provider.closed.Load() {
+		t.Error("provider closed while a call was still using it")
+	}
+	if _, _, err := cache.Get(ctx, "k"); err != ErrClosed {
+		t.Errorf("expected new calls to get ErrClosed, got %v", err)
+	}
+	close(provider.release)
+	if err := <-setErr; err != nil {
+		t.Errorf("in-flight Set failed: %v", err)
+	}
+	if err := <-shutdownErr; err != nil || !provider.closed.Load() {
+		t.Errorf("Shutdown = %v, provider closed = %v", err, provider.closed.Load())
+	}
+
+	cache, provider, setErr = start()
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := cache.Shutdown(timeout); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected Shutdown to give up at its deadline, got %v", err)
+	}
+	close(provider.release)
+	<-setErr
+}
+
 func TestWithDifferentTypes(t *testing.T) {
 	t.Run("IntKey", func(t *testing.T) {
 		cache, _ := New(
@@ -1,5 +1,11 @@
 package chunker
 
+import (
+	"fmt"
+
+	"github.com/botirk38/semanticcache/tokenizer"
+)
+
 // Chunker defines the interface for text chunking strategies.
 // Different implementations can provide various chunking approaches
 // (fixed-size with overlap, semantic boundaries, sentence-based, etc.)
@@ -35,6 +41,21 @@ type ChunkConfig struct {
 	// Strategy specifies the chunking algorithm to use.
 	// Default: FixedSizeOverlap
 	Strategy ChunkStrategy
+
+	// Tokenizer counts and splits tokens. It should match the embedding
+	// model; tokenizer.ForProvider picks one for a provider.
+	// Default: tiktoken's cl100k_base (OpenAI embedding models)
+	Tokenizer tokenizer.TextTokenizer
+
+	// Language fixes the language whose sentence rules the Sentence
+	// strategy follows.
+	// Default: detected per text with DetectLanguage
+	Language Language
+
+	// Truncation selects the tokens the TruncateToMaxTokens strategy
+	// keeps.
+	// Default: KeepHead
+	Truncation Truncation
 }
 
 // ChunkStrategy represents the chunking algorithm type.
@@ -44,9 +65,24 @@ const (
 	// FixedSizeOverlap splits text into fixed-size chunks with overlap.
 	FixedSizeOverlap ChunkStrategy = "fixed_overlap"
 
+	// Recursive splits text along a separator hierarchy, like LangChain's
+	// RecursiveCharacterTextSplitter.
+	Recursive ChunkStrategy = "recursive"
+
+	// Markdown splits text along Markdown structure, keeping code blocks
+	// intact and recording heading breadcrumbs.
+	Markdown ChunkStrategy = "markdown"
+
+	// Sentence packs whole sentences into chunks, segmenting them by the
+	// text's language.
+	Sentence ChunkStrategy = "sentence"
+
+	// TruncateToMaxTokens clips text to MaxTokens tokens instead of
+	// splitting it, for a single vector per text.
+	TruncateToMaxTokens ChunkStrategy = "truncate"
+
 	// Future strategies:
 	// SemanticBoundary ChunkStrategy = "semantic"
-	// SentenceBased ChunkStrategy = "sentence"
 	// ParagraphBased ChunkStrategy = "paragraph"
 )
 
@@ -63,6 +99,82 @@ type Chunk struct {
 
 	// Index is the chunk's position in the sequence (0-based)
 	Index int
+
+	// Headings is the breadcrumb of Markdown headings enclosing the chunk,
+	// outermost first. Only MarkdownChunker sets it.
+	Headings []string
+}
+
+// Report describes how a text was chunked before it was embedded.
+type Report struct {
+	// Strategy is the chunker's strategy, empty for chunkers outside this
+	// package.
+	Strategy ChunkStrategy
+
+	// Tokens is the number of tokens in the text.
+	Tokens int
+
+	// Chunks is the number of chunks the text was split into, 0 when it
+	// fit within MaxTokens and was embedded whole.
+	Chunks int
+
+	// Oversized is the number of chunks still longer than MaxTokens, which
+	// embedding providers truncate or reject.
+	Oversized int
+
+	// Truncated is the number of tokens the TruncateToMaxTokens strategy
+	// clipped off the text.
+	Truncated int
+}
+
+// StrategyOf returns the strategy of a chunker from this package, or ""
+// for other implementations.
+func StrategyOf(c Chunker) ChunkStrategy {
+	switch c.(type) {
+	case *FixedOverlapChunker:
+		return FixedSizeOverlap
+	case *RecursiveChunker:
+		return Recursive
+	case *MarkdownChunker:
+		return Markdown
+	case *SentenceChunker:
+		return Sentence
+	case *TruncatingChunker:
+		return TruncateToMaxTokens
+	default:
+		return ""
+	}
+}
+
+// NewChunker returns the chunker for config's Strategy; an empty Strategy
+// means FixedSizeOverlap.
+func NewChunker(config ChunkConfig) (Chunker, error) {
+	switch config.Strategy {
+	case FixedSizeOverlap, "":
+		return NewFixedOverlapChunker(config)
+	case Recursive:
+		return NewRecursiveChunker(config)
+	case Markdown:
+		return NewMarkdownChunker(config)
+	case Sentence:
+		return NewSentenceChunker(config)
+	case TruncateToMaxTokens:
+		return NewTruncatingChunker(config)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStrategy, config.Strategy)
+	}
+}
+
+// textTokenizer returns the configured tokenizer or cl100k_base.
+func (c ChunkConfig) textTokenizer() (tokenizer.TextTokenizer, error) {
+	if c.Tokenizer != nil {
+		return c.Tokenizer, nil
+	}
+	tok, err := tokenizer.NewTiktoken(tokenizer.Cl100kBase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tokenizer: %w", err)
+	}
+	return tok, nil
 }
 
 // DefaultChunkConfig returns the default chunking configuration.
@@ -82,6 +194,16 @@ func (c ChunkConfig) Validate() error {
 		return ErrInvalidMaxTokens
 	}
 
+	// Truncation ignores the chunk settings
+	if c.Strategy == TruncateToMaxTokens {
+		switch c.Truncation {
+		case "", KeepHead, KeepTail, DropMiddle:
+			return nil
+		default:
+			return fmt.Errorf("%w: %q", ErrUnknownTruncation, c.Truncation)
+		}
+	}
+
 	// Validate ChunkSize
 	if c.ChunkSize <= 0 {
 		return ErrInvalidChunkSize
@@ -98,5 +220,11 @@ func (c ChunkConfig) Validate() error {
 		return ErrOverlapTooLarge
 	}
 
+	switch c.Strategy {
+	case "", FixedSizeOverlap, Recursive, Markdown, Sentence:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownStrategy, c.Strategy)
+	}
+
 	return nil
 }
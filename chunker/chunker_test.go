@@ -120,3 +120,20 @@ func TestChunkConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestStrategyOf(t *testing.T) {
+	for _, strategy := range []ChunkStrategy{FixedSizeOverlap, Recursive, Markdown, Sentence, TruncateToMaxTokens} {
+		config := DefaultChunkConfig()
+		config.Strategy = strategy
+		c, err := NewChunker(config)
+		if err != nil {
+			t.Fatalf("NewChunker(%s) failed: %v", strategy, err)
+		}
+		if got := StrategyOf(c); got != strategy {
+			t.Errorf("StrategyOf() = %q, want %q", got, strategy)
+		}
+	}
+	if got := StrategyOf(nil); got != "" {
+		t.Errorf("StrategyOf(nil) = %q, want empty", got)
+	}
+}
@@ -24,4 +24,11 @@ var (
 
 	// ErrTokenizerFailed indicates tokenization failed
 	ErrTokenizerFailed = errors.New("tokenization failed")
+
+	// ErrUnknownStrategy indicates a ChunkStrategy without an implementation
+	ErrUnknownStrategy = errors.New("unknown chunk strategy")
+
+	// ErrUnknownTruncation indicates a Truncation other than KeepHead,
+	// KeepTail, or DropMiddle
+	ErrUnknownTruncation = errors.New("unknown truncation")
 )
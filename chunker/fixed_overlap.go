@@ -2,34 +2,35 @@ package chunker
 
 import (
 	"fmt"
+	"strings"
 
-	"github.com/tiktoken-go/tokenizer"
+	"github.com/botirk38/semanticcache/tokenizer"
 )
 
 // FixedOverlapChunker implements the Chunker interface using a fixed-size
 // chunking strategy with overlap between chunks.
 type FixedOverlapChunker struct {
-	config   ChunkConfig
-	encoding tokenizer.Codec
+	config    ChunkConfig
+	tokenizer tokenizer.TextTokenizer
 }
 
 // NewFixedOverlapChunker creates a new FixedOverlapChunker with the given configuration.
-// It uses tiktoken's cl100k_base encoding (used by OpenAI's text-embedding-3-small).
+// It counts tokens with config.Tokenizer, or tiktoken's cl100k_base encoding
+// (used by OpenAI's text-embedding-3-small) when that is nil.
 func NewFixedOverlapChunker(config ChunkConfig) (*FixedOverlapChunker, error) {
 	// Validate configuration
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid chunk config: %w", err)
 	}
 
-	// Get the cl100k_base encoding (used by OpenAI embeddings)
-	enc, err := tokenizer.Get(tokenizer.Cl100kBase)
+	tok, err := config.textTokenizer()
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize tokenizer: %w", err)
+		return nil, err
 	}
 
 	return &FixedOverlapChunker{
-		config:   config,
-		encoding: enc,
+		config:    config,
+		tokenizer: tok,
 	}, nil
 }
 
@@ -39,12 +40,12 @@ func (c *FixedOverlapChunker) CountTokens(text string) (int, error) {
 		return 0, nil
 	}
 
-	ids, _, err := c.encoding.Encode(text)
+	tokens, err := c.tokenizer.Tokens(text)
 	if err != nil {
 		return 0, fmt.Errorf("%w: %v", ErrTokenizerFailed, err)
 	}
 
-	return len(ids), nil
+	return len(tokens), nil
 }
 
 // ChunkText splits the text into overlapping chunks based on token count.
@@ -54,7 +55,7 @@ func (c *FixedOverlapChunker) ChunkText(text string) ([]Chunk, error) {
 	}
 
 	// Tokenize the entire text
-	tokens, _, err := c.encoding.Encode(text)
+	tokens, err := c.tokenizer.Tokens(text)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrTokenizerFailed, err)
 	}
@@ -86,12 +87,8 @@ func (c *FixedOverlapChunker) ChunkText(text string) ([]Chunk, error) {
 			end = totalTokens
 		}
 
-		// Decode the token slice back to text
-		chunkTokens := tokens[start:end]
-		chunkText, err := c.encoding.Decode(chunkTokens)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode chunk %d: %w", chunkIndex, err)
-		}
+		// Join the token slice back into text
+		chunkText := strings.Join(tokens[start:end], "")
 
 		chunks = append(chunks, Chunk{
 			Text:       chunkText,
@@ -0,0 +1,45 @@
+package chunker
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+// htmlRules rewrite HTML into Markdown-like text, in order.
+var htmlRules = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?is)<(script|style|head|noscript)\b.*?</(script|style|head|noscript)\s*>`), ""},
+	{regexp.MustCompile(`(?s)<!--.*?-->`), ""},
+	{regexp.MustCompile(`(?i)<h1\b[^>]*>`), "\n\n# "},
+	{regexp.MustCompile(`(?i)<h2\b[^>]*>`), "\n\n## "},
+	{regexp.MustCompile(`(?i)<h3\b[^>]*>`), "\n\n### "},
+	{regexp.MustCompile(`(?i)<h4\b[^>]*>`), "\n\n#### "},
+	{regexp.MustCompile(`(?i)<h5\b[^>]*>`), "\n\n##### "},
+	{regexp.MustCompile(`(?i)<h6\b[^>]*>`), "\n\n###### "},
+	{regexp.MustCompile(`(?i)<pre\b[^>]*>`), "\n\n```\n"},
+	{regexp.MustCompile(`(?i)</pre\s*>`), "\n```\n\n"},
+	{regexp.MustCompile(`(?i)<li\b[^>]*>`), "\n- "},
+	{regexp.MustCompile(`(?i)<tr\b[^>]*>`), "\n|"},
+	{regexp.MustCompile(`(?i)<t[dh]\b[^>]*>`), " "},
+	{regexp.MustCompile(`(?i)</t[dh]\s*>`), " |"},
+	{regexp.MustCompile(`(?i)<br\s*/?>`), "\n"},
+	{regexp.MustCompile(`(?i)</?(p|div|section|article|header|footer|ul|ol|table|blockquote|h[1-6])\b[^>]*>`), "\n\n"},
+	{regexp.MustCompile(`<[^>]*>`), ""},
+	{regexp.MustCompile(`[ \t]+\n`), "\n"},
+	{regexp.MustCompile(`\n{3,}`), "\n\n"},
+}
+
+// StripHTML converts HTML to Markdown-like text for MarkdownChunker:
+// headings become # headings, pre blocks code fences, list items - items,
+// and table rows | rows. Scripts, styles, comments, and other tags are
+// dropped and entities decoded. It is a preprocessor for well-formed
+// pages, not a full HTML parser.
+func StripHTML(s string) string {
+	for _, r := range htmlRules {
+		s = r.re.ReplaceAllString(s, r.repl)
+	}
+	return strings.TrimSpace(html.UnescapeString(s))
+}
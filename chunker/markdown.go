@@ -0,0 +1,232 @@
+package chunker
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	mdHeading  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?[ \t#]*$`)
+	mdListItem = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d{1,9}[.)])[ \t]`)
+)
+
+// mdKind is the kind of a Markdown block.
+type mdKind int
+
+const (
+	mdParagraph mdKind = iota
+	mdHeadingLine
+	mdCode
+	mdList
+	mdTable
+)
+
+// mdBlock is a run of text[start:end] forming one Markdown block. Parts are
+// the units an oversized block is split between: list items, table rows,
+// or code lines; other blocks have a single part.
+type mdBlock struct {
+	kind       mdKind
+	start, end int
+	parts      []string
+	headings   []string
+}
+
+// MarkdownChunker implements the Chunker interface for Markdown. It packs
+// whole blocks (paragraphs, code fences, lists, and tables) into chunks of
+// up to ChunkSize tokens and starts a new chunk at every heading, recording
+// the enclosing headings in each chunk's Headings. Code blocks are kept
+// intact up to MaxTokens tokens; other blocks over ChunkSize are split
+// between list items or table rows, then like RecursiveChunker. Use
+// StripHTML to chunk HTML.
+type MarkdownChunker struct {
+	recursive *RecursiveChunker
+}
+
+// NewMarkdownChunker creates a new MarkdownChunker with the given
+// configuration. It counts tokens like NewFixedOverlapChunker.
+func NewMarkdownChunker(config ChunkConfig) (*MarkdownChunker, error) {
+	r, err := NewRecursiveChunker(config)
+	if err != nil {
+		return nil, err
+	}
+	return &MarkdownChunker{recursive: r}, nil
+}
+
+// CountTokens counts the number of tokens in the given text.
+func (c *MarkdownChunker) CountTokens(text string) (int, error) {
+	return c.recursive.CountTokens(text)
+}
+
+// GetMaxTokens returns the maximum token limit for this chunker.
+func (c *MarkdownChunker) GetMaxTokens() int {
+	return c.recursive.config.MaxTokens
+}
+
+// ChunkText splits text that exceeds MaxTokens into chunks along its
+// Markdown structure.
+func (c *MarkdownChunker) ChunkText(text string) ([]Chunk, error) {
+	if text == "" {
+		return nil, ErrEmptyText
+	}
+	total, err := c.CountTokens(text)
+	if err != nil {
+		return nil, err
+	}
+	if total <= c.GetMaxTokens() {
+		return []Chunk{{Text: text, StartToken: 0, EndToken: total, Index: 0}}, nil
+	}
+
+	size := c.recursive.config.ChunkSize
+	var texts []string
+	var headings [][]string
+	start, end := -1, 0
+	var cur []string
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		if t := strings.TrimSpace(text[start:end]); t != "" {
+			texts = append(texts, t)
+			headings = append(headings, cur)
+		}
+		start = -1
+	}
+	for _, b := range parseMarkdown(text) {
+		if b.kind == mdHeadingLine {
+			flush()
+		}
+		n, err := c.CountTokens(text[b.start:b.end])
+		if err != nil {
+			return nil, err
+		}
+		if n > size && (b.kind != mdCode || n > c.GetMaxTokens()) {
+			flush()
+			split, err := c.recursive.pack(b.parts, c.recursive.separators)
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range split {
+				texts = append(texts, t)
+				headings = append(headings, b.headings)
+			}
+			continue
+		}
+		if start >= 0 {
+			if n, err = c.CountTokens(text[start:b.end]); err != nil {
+				return nil, err
+			}
+			if n > size {
+				flush()
+			}
+		}
+		if start < 0 {
+			start, cur = b.start, b.headings
+		}
+		end = b.end
+	}
+	flush()
+
+	chunks, err := locate(text, texts, c.CountTokens)
+	if err != nil {
+		return nil, err
+	}
+	for i := range chunks {
+		chunks[i].Headings = headings[i]
+	}
+	return chunks, nil
+}
+
+// parseMarkdown splits text into blocks, each recording the headings
+// enclosing it.
+func parseMarkdown(text string) []mdBlock {
+	type heading struct {
+		level int
+		title string
+	}
+	var (
+		blocks []mdBlock
+		stack  []heading
+		titles []string
+		cur    *mdBlock
+		fence  string
+	)
+	closeBlock := func() {
+		if cur != nil {
+			blocks = append(blocks, *cur)
+			cur = nil
+		}
+	}
+	open := func(kind mdKind, start int) {
+		closeBlock()
+		cur = &mdBlock{kind: kind, start: start, headings: titles}
+	}
+
+	for pos := 0; pos < len(text); {
+		end := len(text)
+		next := end
+		if i := strings.IndexByte(text[pos:], '\n'); i >= 0 {
+			end, next = pos+i, pos+i+1
+		}
+		line := text[pos:end]
+		raw := text[pos:next] // with its newline, so parts are substrings
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+
+		switch {
+		case fence != "":
+			cur.end = end
+			cur.parts = append(cur.parts, raw)
+			if strings.HasPrefix(trimmed, fence) && strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1])) == "" {
+				fence = ""
+				closeBlock()
+			}
+		case indent <= 3 && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+			open(mdCode, pos)
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+			cur.end = end
+			cur.parts = append(cur.parts, raw)
+		case strings.TrimSpace(line) == "":
+			closeBlock()
+		case mdHeading.MatchString(line):
+			m := mdHeading.FindStringSubmatch(line)
+			level := len(m[1])
+			for len(stack) > 0 && stack[len(stack)-1].level >= level {
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, heading{level, m[2]})
+			titles = make([]string, len(stack))
+			for i, h := range stack {
+				titles[i] = h.title
+			}
+			open(mdHeadingLine, pos)
+			cur.end = end
+			cur.parts = []string{raw}
+			closeBlock()
+		case strings.HasPrefix(trimmed, "|"):
+			if cur == nil || cur.kind != mdTable {
+				open(mdTable, pos)
+			}
+			cur.end = end
+			cur.parts = append(cur.parts, raw)
+		case mdListItem.MatchString(line):
+			if cur == nil || cur.kind != mdList {
+				open(mdList, pos)
+			}
+			cur.end = end
+			cur.parts = append(cur.parts, raw)
+		case cur != nil && cur.kind == mdList && indent > 0:
+			cur.end = end
+			cur.parts[len(cur.parts)-1] += raw
+		default:
+			if cur == nil || cur.kind != mdParagraph {
+				open(mdParagraph, pos)
+				cur.parts = []string{""}
+			}
+			cur.end = end
+			cur.parts[0] += raw
+		}
+		pos = next
+	}
+	closeBlock() // an unclosed fence runs to the end of the text
+	return blocks
+}
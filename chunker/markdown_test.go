@@ -0,0 +1,112 @@
+package chunker
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMarkdownChunker_ChunkText(t *testing.T) {
+	config := ChunkConfig{MaxTokens: 60, ChunkSize: 40, ChunkOverlap: 0, Strategy: Markdown}
+
+	t.Run("invalid config", func(t *testing.T) {
+		if _, err := NewMarkdownChunker(ChunkConfig{MaxTokens: 10, ChunkSize: 20}); err == nil {
+			t.Fatal("expected error for invalid config, got nil")
+		}
+	})
+
+	t.Run("empty text", func(t *testing.T) {
+		chunker, _ := NewMarkdownChunker(config)
+		if _, err := chunker.ChunkText(""); err != ErrEmptyText {
+			t.Errorf("expected ErrEmptyText, got %v", err)
+		}
+	})
+
+	t.Run("headings and code", func(t *testing.T) {
+		chunker, err := NewMarkdownChunker(config)
+		if err != nil {
+			t.Fatalf("failed to create chunker: %v", err)
+		}
+		code := "```go\nfunc main() {\n\tfor i := 0; i < 10; i++ {\n\t\tfmt.Println(\"hello, world\", i, i*i, i*i*i)\n\t}\n\tfmt.Println(\"done with the loop\")\n}\n```"
+		text := strings.Join([]string{
+			"# Guide",
+			"An introduction to the guide.",
+			"## Install",
+			"Run the installer.",
+			code,
+			"## Usage",
+			"- first item\n- second item\n  continued",
+			"| a | b |\n|---|---|\n| 1 | 2 |",
+		}, "\n\n")
+		chunks, err := chunker.ChunkText(text)
+		if err != nil {
+			t.Fatalf("ChunkText() error = %v", err)
+		}
+
+		var sawCode bool
+		for i, chunk := range chunks {
+			if chunk.Index != i {
+				t.Errorf("chunk %d has index %d", i, chunk.Index)
+			}
+			if strings.Contains(chunk.Text, "```") {
+				sawCode = true
+				if !strings.Contains(chunk.Text, code) {
+					t.Errorf("code block split: %q", chunk.Text)
+				}
+				if !slices.Equal(chunk.Headings, []string{"Guide", "Install"}) {
+					t.Errorf("code chunk headings = %q", chunk.Headings)
+				}
+			}
+			if strings.Contains(chunk.Text, "second item") && !slices.Equal(chunk.Headings, []string{"Guide", "Usage"}) {
+				t.Errorf("list chunk headings = %q", chunk.Headings)
+			}
+		}
+		if !sawCode {
+			t.Error("expected a chunk with the code block")
+		}
+		if !slices.Equal(chunks[0].Headings, []string{"Guide"}) || !strings.HasPrefix(chunks[0].Text, "# Guide") {
+			t.Errorf("first chunk = %+v", chunks[0])
+		}
+	})
+
+	t.Run("oversized list", func(t *testing.T) {
+		chunker, _ := NewMarkdownChunker(ChunkConfig{MaxTokens: 40, ChunkSize: 20})
+		var items []string
+		for range 20 {
+			items = append(items, "- a list item with several words")
+		}
+		chunks, err := chunker.ChunkText(strings.Join(items, "\n"))
+		if err != nil {
+			t.Fatalf("ChunkText() error = %v", err)
+		}
+		if len(chunks) < 2 {
+			t.Fatalf("expected multiple chunks, got %d", len(chunks))
+		}
+		for i, chunk := range chunks {
+			if n, _ := chunker.CountTokens(chunk.Text); n > 20 {
+				t.Errorf("chunk %d has %d tokens, want at most 20", i, n)
+			}
+			if !strings.HasPrefix(chunk.Text, "- ") || !strings.HasSuffix(chunk.Text, "words") {
+				t.Errorf("chunk %d splits a list item: %q", i, chunk.Text)
+			}
+		}
+	})
+}
+
+func TestStripHTML(t *testing.T) {
+	got := StripHTML(`<html><head><title>x</title></head><body>
+<h1>Title</h1><p>Fish &amp; chips.</p>
+<script>alert(1)</script>
+<ul><li>one</li><li>two</li></ul>
+<pre><code>x := 1</code></pre>
+<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>2</td></tr></table>
+</body></html>`)
+	for _, want := range []string{"# Title", "Fish & chips.", "- one\n- two", "```\nx := 1\n```", "| a | b |\n| 1 | 2 |"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("StripHTML() = %q, missing %q", got, want)
+		}
+	}
+	if strings.Contains(got, "alert") || strings.Contains(got, "<") {
+		t.Errorf("StripHTML() = %q, want scripts and tags removed", got)
+	}
+}
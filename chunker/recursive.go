@@ -0,0 +1,219 @@
+package chunker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/botirk38/semanticcache/tokenizer"
+)
+
+// DefaultSeparators splits by paragraphs, then lines, sentences, and words.
+// Pieces still too long are split by tokens.
+var DefaultSeparators = []string{"\n\n", "\n", ". ", " "}
+
+// RecursiveChunker implements the Chunker interface like LangChain's
+// RecursiveCharacterTextSplitter, measured in tokens: it splits text on the
+// first separator that occurs in it, merges neighboring pieces into chunks
+// of up to ChunkSize tokens with about ChunkOverlap tokens of overlap, and
+// splits pieces that are still too long with the next separator, falling
+// back to fixed token windows when separators run out.
+type RecursiveChunker struct {
+	config     ChunkConfig
+	separators []string
+	tokenizer  tokenizer.TextTokenizer
+}
+
+// piece is a run of text and its token count.
+type piece struct {
+	text   string
+	tokens int
+}
+
+// NewRecursiveChunker creates a new RecursiveChunker with the given
+// configuration, splitting on separators in order of preference; none
+// uses DefaultSeparators. It counts tokens like NewFixedOverlapChunker.
+func NewRecursiveChunker(config ChunkConfig, separators ...string) (*RecursiveChunker, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid chunk config: %w", err)
+	}
+	if len(separators) == 0 {
+		separators = DefaultSeparators
+	}
+	tok, err := config.textTokenizer()
+	if err != nil {
+		return nil, err
+	}
+	return &RecursiveChunker{
+		config:     config,
+		separators: separators,
+		tokenizer:  tok,
+	}, nil
+}
+
+// CountTokens counts the number of tokens in the given text.
+func (c *RecursiveChunker) CountTokens(text string) (int, error) {
+	if text == "" {
+		return 0, nil
+	}
+	tokens, err := c.tokenizer.Tokens(text)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrTokenizerFailed, err)
+	}
+	return len(tokens), nil
+}
+
+// ChunkText splits text that exceeds MaxTokens into chunks along the
+// separator hierarchy. Chunks are trimmed of surrounding whitespace. Their
+// token positions count the text between chunks piece by piece, so they
+// can differ slightly from a tokenization of the whole text.
+func (c *RecursiveChunker) ChunkText(text string) ([]Chunk, error) {
+	if text == "" {
+		return nil, ErrEmptyText
+	}
+	total, err := c.CountTokens(text)
+	if err != nil {
+		return nil, err
+	}
+	if total <= c.config.MaxTokens {
+		return []Chunk{{Text: text, StartToken: 0, EndToken: total, Index: 0}}, nil
+	}
+
+	texts, err := c.split(text, c.separators)
+	if err != nil {
+		return nil, err
+	}
+	return locate(text, texts, c.CountTokens)
+}
+
+// locate builds chunks from texts, substrings of text in order, finding
+// their token positions with count. Chunks may overlap, so each is
+// searched for from the previous one's start.
+func locate(text string, texts []string, count func(string) (int, error)) ([]Chunk, error) {
+	chunks := make([]Chunk, 0, len(texts))
+	prev, tokensBefore := 0, 0
+	for _, t := range texts {
+		start := prev
+		if i := strings.Index(text[prev:], t); i >= 0 {
+			start = prev + i
+		}
+		skipped, err := count(text[prev:start])
+		if err != nil {
+			return nil, err
+		}
+		n, err := count(t)
+		if err != nil {
+			return nil, err
+		}
+		tokensBefore += skipped
+		chunks = append(chunks, Chunk{
+			Text:       t,
+			StartToken: tokensBefore,
+			EndToken:   tokensBefore + n,
+			Index:      len(chunks),
+		})
+		prev = start
+	}
+	return chunks, nil
+}
+
+// split returns the chunk texts for text, splitting on the first of seps
+// that occurs in it.
+func (c *RecursiveChunker) split(text string, seps []string) ([]string, error) {
+	i := 0
+	for i < len(seps) && !strings.Contains(text, seps[i]) {
+		i++
+	}
+	if i == len(seps) {
+		return c.windows(text)
+	}
+	return c.pack(strings.SplitAfter(text, seps[i]), seps[i+1:])
+}
+
+// pack merges consecutive parts into chunks, splitting parts over
+// ChunkSize tokens on seps.
+func (c *RecursiveChunker) pack(parts, seps []string) ([]string, error) {
+	var chunks, sub []string
+	var good []piece
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		n, err := c.CountTokens(p)
+		if err != nil {
+			return nil, err
+		}
+		if n <= c.config.ChunkSize {
+			good = append(good, piece{p, n})
+			continue
+		}
+		chunks = append(chunks, c.merge(good)...)
+		good = nil
+		if sub, err = c.split(p, seps); err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, sub...)
+	}
+	return append(chunks, c.merge(good)...), nil
+}
+
+// merge packs pieces into chunks of up to ChunkSize tokens, starting each
+// chunk with the previous one's trailing pieces up to ChunkOverlap tokens.
+func (c *RecursiveChunker) merge(pieces []piece) []string {
+	var chunks []string
+	var cur []piece
+	total := 0
+	emit := func() {
+		if t := strings.TrimSpace(join(cur)); t != "" {
+			chunks = append(chunks, t)
+		}
+	}
+	for _, p := range pieces {
+		if len(cur) > 0 && total+p.tokens > c.config.ChunkSize {
+			emit()
+			for len(cur) > 0 && (total > c.config.ChunkOverlap || total+p.tokens > c.config.ChunkSize) {
+				total -= cur[0].tokens
+				cur = cur[1:]
+			}
+		}
+		cur = append(cur, p)
+		total += p.tokens
+	}
+	if len(cur) > 0 {
+		emit()
+	}
+	return chunks
+}
+
+// windows splits text into ChunkSize-token windows with ChunkOverlap
+// tokens of overlap.
+func (c *RecursiveChunker) windows(text string) ([]string, error) {
+	tokens, err := c.tokenizer.Tokens(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenizerFailed, err)
+	}
+	stride := c.config.ChunkSize - c.config.ChunkOverlap
+	var chunks []string
+	for start := 0; start < len(tokens); start += stride {
+		end := min(start+c.config.ChunkSize, len(tokens))
+		if t := strings.TrimSpace(strings.Join(tokens[start:end], "")); t != "" {
+			chunks = append(chunks, t)
+		}
+		if end == len(tokens) {
+			break
+		}
+	}
+	return chunks, nil
+}
+
+// GetMaxTokens returns the maximum token limit for this chunker.
+func (c *RecursiveChunker) GetMaxTokens() int {
+	return c.config.MaxTokens
+}
+
+func join(pieces []piece) string {
+	var b strings.Builder
+	for _, p := range pieces {
+		b.WriteString(p.text)
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewRecursiveChunker(t *testing.T) {
+	t.Run("valid config", func(t *testing.T) {
+		chunker, err := NewRecursiveChunker(DefaultChunkConfig())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if chunker == nil {
+			t.Fatal("expected chunker, got nil")
+		}
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		config := ChunkConfig{MaxTokens: 512, ChunkSize: 1024, ChunkOverlap: 50}
+		if _, err := NewRecursiveChunker(config); err == nil {
+			t.Fatal("expected error for invalid config, got nil")
+		}
+	})
+}
+
+func TestRecursiveChunker_ChunkText(t *testing.T) {
+	config := ChunkConfig{MaxTokens: 60, ChunkSize: 40, ChunkOverlap: 0, Strategy: Recursive}
+
+	t.Run("empty text", func(t *testing.T) {
+		chunker, _ := NewRecursiveChunker(config)
+		if _, err := chunker.ChunkText(""); err != ErrEmptyText {
+			t.Errorf("expected ErrEmptyText, got %v", err)
+		}
+	})
+
+	t.Run("short text", func(t *testing.T) {
+		chunker, _ := NewRecursiveChunker(config)
+		chunks, err := chunker.ChunkText("A short paragraph.")
+		if err != nil {
+			t.Fatalf("ChunkText() error = %v", err)
+		}
+		if len(chunks) != 1 || chunks[0].Text != "A short paragraph." {
+			t.Errorf("expected the text as one chunk, got %+v", chunks)
+		}
+	})
+
+	t.Run("paragraph boundaries", func(t *testing.T) {
+		chunker, _ := NewRecursiveChunker(config)
+		paragraphs := []string{
+			strings.Repeat("The first paragraph talks about apples. ", 3),
+			strings.Repeat("The second paragraph talks about pears. ", 3),
+			strings.Repeat("The third paragraph talks about plums. ", 3),
+		}
+		for i := range paragraphs {
+			paragraphs[i] = strings.TrimSpace(paragraphs[i])
+		}
+		chunks, err := chunker.ChunkText(strings.Join(paragraphs, "\n\n"))
+		if err != nil {
+			t.Fatalf("ChunkText() error = %v", err)
+		}
+		if len(chunks) != len(paragraphs) {
+			t.Fatalf("expected %d chunks, got %d: %+v", len(paragraphs), len(chunks), chunks)
+		}
+		for i, chunk := range chunks {
+			if chunk.Text != paragraphs[i] {
+				t.Errorf("chunk %d = %q, want %q", i, chunk.Text, paragraphs[i])
+			}
+			if chunk.Index != i {
+				t.Errorf("chunk %d has index %d", i, chunk.Index)
+			}
+			if i > 0 && chunk.StartToken < chunks[i-1].EndToken {
+				t.Errorf("chunk %d starts at token %d before chunk %d ends at %d", i, chunk.StartToken, i-1, chunks[i-1].EndToken)
+			}
+		}
+	})
+
+	t.Run("chunk size", func(t *testing.T) {
+		chunker, _ := NewRecursiveChunker(ChunkConfig{MaxTokens: 60, ChunkSize: 20, ChunkOverlap: 5})
+		text := strings.Repeat("Sentences keep the chunks readable. ", 30)
+		chunks, err := chunker.ChunkText(text)
+		if err != nil {
+			t.Fatalf("ChunkText() error = %v", err)
+		}
+		if len(chunks) < 2 {
+			t.Fatalf("expected multiple chunks, got %d", len(chunks))
+		}
+		for i, chunk := range chunks {
+			n, _ := chunker.CountTokens(chunk.Text)
+			if n > 20 {
+				t.Errorf("chunk %d has %d tokens, want at most 20", i, n)
+			}
+			if !strings.HasSuffix(chunk.Text, ".") {
+				t.Errorf("chunk %d = %q, want it to end on a sentence", i, chunk.Text)
+			}
+		}
+	})
+
+	t.Run("no separators", func(t *testing.T) {
+		chunker, _ := NewRecursiveChunker(ChunkConfig{MaxTokens: 60, ChunkSize: 20, ChunkOverlap: 5}, "\n\n")
+		text := strings.Repeat("word ", 100)
+		chunks, err := chunker.ChunkText(text)
+		if err != nil {
+			t.Fatalf("ChunkText() error = %v", err)
+		}
+		if len(chunks) < 5 {
+			t.Fatalf("expected token windows, got %d chunks", len(chunks))
+		}
+		for i, chunk := range chunks {
+			if n, _ := chunker.CountTokens(chunk.Text); n > 20 {
+				t.Errorf("chunk %d has %d tokens, want at most 20", i, n)
+			}
+		}
+	})
+}
@@ -0,0 +1,280 @@
+package chunker
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Language is a BCP 47 language tag, such as "en" or "ja".
+type Language string
+
+// Languages DetectLanguage reports.
+const (
+	English  Language = "en"
+	Chinese  Language = "zh"
+	Japanese Language = "ja"
+	Korean   Language = "ko"
+	Thai     Language = "th"
+	Arabic   Language = "ar"
+	Hindi    Language = "hi"
+	Russian  Language = "ru"
+	Greek    Language = "el"
+	Hebrew   Language = "he"
+)
+
+// scripts maps each script DetectLanguage counts to its language, in order
+// of preference on ties.
+var scripts = []struct {
+	lang  Language
+	table *unicode.RangeTable
+}{
+	{Japanese, unicode.Hiragana},
+	{Japanese, unicode.Katakana},
+	{Chinese, unicode.Han},
+	{Korean, unicode.Hangul},
+	{Thai, unicode.Thai},
+	{Arabic, unicode.Arabic},
+	{Hindi, unicode.Devanagari},
+	{Russian, unicode.Cyrillic},
+	{Greek, unicode.Greek},
+	{Hebrew, unicode.Hebrew},
+	{English, unicode.Latin},
+}
+
+// abbreviations end with a period that does not end a sentence, lowercased
+// and without their final period. Single letters, such as initials, are
+// recognized separately.
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true, "jr": true,
+	"st": true, "vs": true, "cf": true, "al": true, "fig": true, "no": true, "vol": true,
+	"approx": true, "dept": true, "inc": true, "ltd": true, "co": true, "corp": true,
+	"bzw": true, "usw": true, "ca": true, "nr": true, "mme": true,
+}
+
+// clauseSeparators split CJK sentences over ChunkSize, which rarely
+// contain spaces.
+var clauseSeparators = []string{"\n", "；", "，", "、", " "}
+
+// DetectLanguage guesses text's language from its dominant script. Kana
+// marks Japanese even where Han characters outnumber it. Text in the Latin
+// script, or without letters, is reported as English, whose sentence rules
+// suit most Latin-script languages.
+func DetectLanguage(text string) Language {
+	counts := make(map[Language]int)
+	kana := false
+	for _, r := range text {
+		for _, s := range scripts {
+			if unicode.Is(s.table, r) {
+				counts[s.lang]++
+				kana = kana || s.lang == Japanese
+				break
+			}
+		}
+	}
+	if kana {
+		counts[Japanese] += counts[Chinese]
+		counts[Chinese] = 0
+	}
+	best := English
+	for _, s := range scripts {
+		if counts[s.lang] > counts[best] {
+			best = s.lang
+		}
+	}
+	return best
+}
+
+// Sentences splits text into its sentences, trimmed of surrounding
+// whitespace, by the rules of lang; an empty lang detects it.
+func Sentences(text string, lang Language) []string {
+	if lang == "" {
+		lang = DetectLanguage(text)
+	}
+	var out []string
+	for _, s := range segment(text, lang) {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// SentenceChunker implements the Chunker interface by sentences: it packs
+// whole sentences into chunks of up to ChunkSize tokens with about
+// ChunkOverlap tokens of overlap, splitting sentences that are still too
+// long like RecursiveChunker, by clauses for Chinese and Japanese.
+// Sentence boundaries follow the text's language, config.Language or else
+// DetectLanguage's guess: CJK full stops end a sentence without a space
+// after them, abbreviations and initials do not end one, and Thai, which
+// has no sentence punctuation, splits at spaces.
+type SentenceChunker struct {
+	recursive *RecursiveChunker
+	language  Language
+}
+
+// NewSentenceChunker creates a new SentenceChunker with the given
+// configuration. It counts tokens like NewFixedOverlapChunker.
+func NewSentenceChunker(config ChunkConfig) (*SentenceChunker, error) {
+	r, err := NewRecursiveChunker(config)
+	if err != nil {
+		return nil, err
+	}
+	return &SentenceChunker{recursive: r, language: config.Language}, nil
+}
+
+// CountTokens counts the number of tokens in the given text.
+func (c *SentenceChunker) CountTokens(text string) (int, error) {
+	return c.recursive.CountTokens(text)
+}
+
+// GetMaxTokens returns the maximum token limit for this chunker.
+func (c *SentenceChunker) GetMaxTokens() int {
+	return c.recursive.config.MaxTokens
+}
+
+// ChunkText splits text that exceeds MaxTokens into chunks of whole
+// sentences.
+func (c *SentenceChunker) ChunkText(text string) ([]Chunk, error) {
+	if text == "" {
+		return nil, ErrEmptyText
+	}
+	total, err := c.CountTokens(text)
+	if err != nil {
+		return nil, err
+	}
+	if total <= c.GetMaxTokens() {
+		return []Chunk{{Text: text, StartToken: 0, EndToken: total, Index: 0}}, nil
+	}
+
+	lang := c.language
+	if lang == "" {
+		lang = DetectLanguage(text)
+	}
+	seps := c.recursive.separators
+	if lang == Chinese || lang == Japanese {
+		seps = clauseSeparators
+	}
+	texts, err := c.recursive.pack(segment(text, lang), seps)
+	if err != nil {
+		return nil, err
+	}
+	return locate(text, texts, c.CountTokens)
+}
+
+// segment splits text into sentences that concatenate back to it, each
+// with the whitespace that follows it.
+func segment(text string, lang Language) []string {
+	var out []string
+	start := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		end := -1 // where the sentence ends, if it ends at r
+		switch {
+		case isFullStop(r):
+			end = skipSpace(text, closeRun(text, i+size))
+		case isSpacedStop(r, lang):
+			j := closeRun(text, i+size)
+			if j < len(text) && !isSpaceRune(text[j:]) {
+				break // "3.14", "example.com"
+			}
+			next, _ := utf8.DecodeRuneInString(text[skipSpace(text, j):])
+			if unicode.IsLower(next) || r == '.' && abbreviated(text[start:i]) {
+				break
+			}
+			end = skipSpace(text, j)
+		case r == '\n':
+			if j := skipSpace(text, i); strings.Count(text[i:j], "\n") > 1 {
+				end = j // a blank line ends a paragraph
+			}
+		case lang == Thai && unicode.IsSpace(r) && i > 0:
+			prev, _ := utf8.DecodeLastRuneInString(text[:i])
+			j := skipSpace(text, i)
+			next, _ := utf8.DecodeRuneInString(text[j:])
+			if unicode.Is(unicode.Thai, prev) && unicode.Is(unicode.Thai, next) {
+				end = j
+			}
+		}
+		if end < 0 {
+			i += size
+			continue
+		}
+		out = append(out, text[start:end])
+		start, i = end, end
+	}
+	if start < len(text) {
+		out = append(out, text[start:])
+	}
+	return out
+}
+
+// isFullStop reports whether r ends a sentence even without a space after
+// it, as in Chinese, Japanese, and Devanagari text.
+func isFullStop(r rune) bool {
+	switch r {
+	case '。', '！', '？', '｡', '．', '।', '॥', '۔':
+		return true
+	}
+	return false
+}
+
+// isSpacedStop reports whether r ends a sentence in lang when whitespace
+// follows it.
+func isSpacedStop(r rune, lang Language) bool {
+	switch r {
+	case '.', '!', '?', '…', '؟', '\u037e':
+		return true
+	case ';':
+		return lang == Greek // the Greek question mark
+	}
+	return false
+}
+
+// isCloser reports whether r is a closing quote or bracket, which belongs
+// to the sentence it follows.
+func isCloser(r rune) bool {
+	return strings.ContainsRune("\"')]}»”’」』）】〕》〉", r)
+}
+
+// closeRun returns the end of the terminators and closing marks starting
+// at i.
+func closeRun(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !isFullStop(r) && !isSpacedStop(r, "") && !isCloser(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// skipSpace returns the end of the whitespace starting at i.
+func skipSpace(text string, i int) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// isSpaceRune reports whether text starts with a whitespace rune.
+func isSpaceRune(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return unicode.IsSpace(r)
+}
+
+// abbreviated reports whether sentence, which a period follows, ends in an
+// abbreviation or an initial, as in "Dr", "e.g", or "J".
+func abbreviated(sentence string) bool {
+	word := sentence[strings.LastIndexFunc(sentence, unicode.IsSpace)+1:]
+	word = strings.ToLower(strings.TrimLeft(word, "(\"'“‘«"))
+	if abbreviations[word] {
+		return true
+	}
+	last := word[strings.LastIndexByte(word, '.')+1:]
+	return utf8.RuneCountInString(last) == 1 && unicode.IsLetter([]rune(last)[0])
+}
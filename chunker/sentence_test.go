@@ -0,0 +1,141 @@
+package chunker
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want Language
+	}{
+		{"The cache stores embeddings.", English},
+		{"缓存存储嵌入向量。", Chinese},
+		{"東京でキャッシュを使う。", Japanese},
+		{"캐시는 임베딩을 저장합니다.", Korean},
+		{"แคชเก็บเวกเตอร์", Thai},
+		{"Кэш хранит векторы.", Russian},
+		{"कैश वेक्टर संग्रहीत करता है।", Hindi},
+		{"12345", English},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		lang Language
+		want []string
+	}{
+		{
+			name: "english abbreviations",
+			text: "Dr. Smith met J. Doe at 3.14 p.m. today. He left, e.g. early! Did he? Yes.",
+			want: []string{"Dr. Smith met J. Doe at 3.14 p.m. today.", "He left, e.g. early!", "Did he?", "Yes."},
+		},
+		{
+			name: "lowercase continuation",
+			text: `"Really?" she asked. It was.`,
+			want: []string{`"Really?" she asked.`, "It was."},
+		},
+		{
+			name: "chinese",
+			text: "今天天气很好。我们去公园吧！你来吗？“好的。”他说。",
+			want: []string{"今天天气很好。", "我们去公园吧！", "你来吗？", "“好的。”", "他说。"},
+		},
+		{
+			name: "japanese",
+			text: "キャッシュを使います。「速い！」と彼は言った。",
+			want: []string{"キャッシュを使います。", "「速い！」", "と彼は言った。"},
+		},
+		{
+			name: "hindi",
+			text: "यह पहला वाक्य है। यह दूसरा है।",
+			want: []string{"यह पहला वाक्य है।", "यह दूसरा है।"},
+		},
+		{
+			name: "thai",
+			text: "วันนี้อากาศดี เราไปเที่ยวกัน",
+			want: []string{"วันนี้อากาศดี", "เราไปเที่ยวกัน"},
+		},
+		{
+			name: "greek question mark",
+			text: "Τι κάνεις; Καλά.",
+			lang: Greek,
+			want: []string{"Τι κάνεις;", "Καλά."},
+		},
+		{
+			name: "paragraphs",
+			text: "A heading\n\nA paragraph without a stop",
+			want: []string{"A heading", "A paragraph without a stop"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sentences(tt.text, tt.lang); !slices.Equal(got, tt.want) {
+				t.Errorf("Sentences() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSentenceChunker_ChunkText(t *testing.T) {
+	config := ChunkConfig{MaxTokens: 20, ChunkSize: 12, ChunkOverlap: 0, Strategy: Sentence, Tokenizer: runeTokenizer{}}
+
+	t.Run("empty text", func(t *testing.T) {
+		chunker, _ := NewSentenceChunker(config)
+		if _, err := chunker.ChunkText(""); err != ErrEmptyText {
+			t.Errorf("expected ErrEmptyText, got %v", err)
+		}
+	})
+
+	t.Run("chinese", func(t *testing.T) {
+		chunker, err := NewSentenceChunker(config)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		text := "今天天气很好。我们去公园吧！你来吗？我们下午三点在门口见面，然后一起走过去，好不好？"
+		chunks, err := chunker.ChunkText(text)
+		if err != nil {
+			t.Fatalf("ChunkText failed: %v", err)
+		}
+		for _, c := range chunks {
+			if n, _ := chunker.CountTokens(c.Text); n > config.ChunkSize {
+				t.Errorf("chunk %q has %d tokens, over %d", c.Text, n, config.ChunkSize)
+			}
+			if !strings.Contains(text, c.Text) {
+				t.Errorf("chunk %q is not part of the text", c.Text)
+			}
+		}
+		if chunks[0].Text != "今天天气很好。" || chunks[1].Text != "我们去公园吧！你来吗？" {
+			t.Errorf("expected sentences packed whole, got %q and %q", chunks[0].Text, chunks[1].Text)
+		}
+		// The long last sentence is split at its comma, not mid-clause.
+		if last := chunks[len(chunks)-1].Text; last != "然后一起走过去，好不好？" {
+			t.Errorf("expected the last clause pair, got %q", last)
+		}
+	})
+
+	t.Run("fixed language", func(t *testing.T) {
+		config := config
+		config.Language = Greek
+		chunker, _ := NewSentenceChunker(config)
+		chunks, err := chunker.ChunkText("Τι κάνεις; Καλά είμαι.")
+		if err != nil || len(chunks) != 2 || chunks[0].Text != "Τι κάνεις;" {
+			t.Errorf("expected the Greek question mark to end a sentence, got %+v, %v", chunks, err)
+		}
+	})
+}
+
+// runeTokenizer counts each rune as a token.
+type runeTokenizer struct{}
+
+func (runeTokenizer) Tokens(text string) ([]string, error) {
+	return strings.Split(text, ""), nil
+}
@@ -0,0 +1,98 @@
+package chunker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/botirk38/semanticcache/tokenizer"
+)
+
+// Truncation selects which tokens TruncatingChunker keeps.
+type Truncation string
+
+const (
+	// KeepHead keeps the first MaxTokens tokens.
+	KeepHead Truncation = "head"
+
+	// KeepTail keeps the last MaxTokens tokens.
+	KeepTail Truncation = "tail"
+
+	// DropMiddle keeps the first and last MaxTokens/2 tokens, the first
+	// half getting any odd token.
+	DropMiddle Truncation = "middle"
+)
+
+// TruncatingChunker implements the Chunker interface by clipping text to
+// MaxTokens tokens instead of splitting it, so every text embeds as a
+// single vector of a predictable part of it. Which part follows
+// config.Truncation.
+type TruncatingChunker struct {
+	config    ChunkConfig
+	tokenizer tokenizer.TextTokenizer
+}
+
+// NewTruncatingChunker creates a new TruncatingChunker with the given
+// configuration; only MaxTokens, Truncation, and Tokenizer apply. It
+// counts tokens like NewFixedOverlapChunker.
+func NewTruncatingChunker(config ChunkConfig) (*TruncatingChunker, error) {
+	config.Strategy = TruncateToMaxTokens
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid chunk config: %w", err)
+	}
+	tok, err := config.textTokenizer()
+	if err != nil {
+		return nil, err
+	}
+	return &TruncatingChunker{config: config, tokenizer: tok}, nil
+}
+
+// CountTokens counts the number of tokens in the given text.
+func (c *TruncatingChunker) CountTokens(text string) (int, error) {
+	if text == "" {
+		return 0, nil
+	}
+	tokens, err := c.tokenizer.Tokens(text)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", ErrTokenizerFailed, err)
+	}
+	return len(tokens), nil
+}
+
+// GetMaxTokens returns the maximum token limit for this chunker.
+func (c *TruncatingChunker) GetMaxTokens() int {
+	return c.config.MaxTokens
+}
+
+// ChunkText returns text as a single chunk, clipped to MaxTokens tokens.
+// The chunk's StartToken and EndToken span the kept tokens; with
+// DropMiddle they span the whole text, gap included. A multi-byte
+// character cut by a token boundary is dropped.
+func (c *TruncatingChunker) ChunkText(text string) ([]Chunk, error) {
+	if text == "" {
+		return nil, ErrEmptyText
+	}
+	tokens, err := c.tokenizer.Tokens(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenizerFailed, err)
+	}
+	total, limit := len(tokens), c.config.MaxTokens
+	if total <= limit {
+		return []Chunk{{Text: text, StartToken: 0, EndToken: total, Index: 0}}, nil
+	}
+
+	chunk := Chunk{StartToken: 0, EndToken: total}
+	var kept []string
+	switch c.config.Truncation {
+	case KeepTail:
+		chunk.StartToken = total - limit
+		kept = tokens[total-limit:]
+	case DropMiddle:
+		head := limit - limit/2
+		kept = append(tokens[:head:head], tokens[total-limit/2:]...)
+	default:
+		chunk.EndToken = limit
+		kept = tokens[:limit]
+	}
+	chunk.Text = strings.ToValidUTF8(strings.Join(kept, ""), "")
+	return []Chunk{chunk}, nil
+}
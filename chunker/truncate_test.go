@@ -0,0 +1,64 @@
+package chunker
+
+import "testing"
+
+func TestTruncatingChunker_ChunkText(t *testing.T) {
+	text := "abcdefghij"
+	tests := []struct {
+		truncation Truncation
+		want       string
+		start, end int
+	}{
+		{"", "abcd", 0, 4},
+		{KeepHead, "abcd", 0, 4},
+		{KeepTail, "ghij", 6, 10},
+		{DropMiddle, "abij", 0, 10},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.truncation), func(t *testing.T) {
+			c, err := NewChunker(ChunkConfig{MaxTokens: 4, Strategy: TruncateToMaxTokens, Truncation: tt.truncation, Tokenizer: runeTokenizer{}})
+			if err != nil {
+				t.Fatalf("NewChunker failed: %v", err)
+			}
+			chunks, err := c.ChunkText(text)
+			if err != nil || len(chunks) != 1 {
+				t.Fatalf("expected one chunk, got %+v, %v", chunks, err)
+			}
+			if got := chunks[0]; got.Text != tt.want || got.StartToken != tt.start || got.EndToken != tt.end {
+				t.Errorf("got %q [%d, %d), want %q [%d, %d)", got.Text, got.StartToken, got.EndToken, tt.want, tt.start, tt.end)
+			}
+		})
+	}
+
+	t.Run("short text", func(t *testing.T) {
+		c, _ := NewTruncatingChunker(ChunkConfig{MaxTokens: 20, Tokenizer: runeTokenizer{}})
+		if chunks, _ := c.ChunkText(text); len(chunks) != 1 || chunks[0].Text != text {
+			t.Errorf("expected the whole text, got %+v", chunks)
+		}
+	})
+
+	t.Run("split characters", func(t *testing.T) {
+		c, _ := NewTruncatingChunker(ChunkConfig{MaxTokens: 2, Tokenizer: byteTokenizer{}})
+		if chunks, _ := c.ChunkText("aé!"); chunks[0].Text != "a" {
+			t.Errorf("expected the cut character dropped, got %q", chunks[0].Text)
+		}
+	})
+
+	t.Run("unknown truncation", func(t *testing.T) {
+		if _, err := NewTruncatingChunker(ChunkConfig{MaxTokens: 4, Truncation: "both"}); err == nil {
+			t.Error("expected an error for an unknown truncation")
+		}
+	})
+}
+
+// byteTokenizer counts each byte as a token, splitting multi-byte
+// characters like tiktoken can.
+type byteTokenizer struct{}
+
+func (byteTokenizer) Tokens(text string) ([]string, error) {
+	tokens := make([]string, len(text))
+	for i := range len(text) {
+		tokens[i] = text[i : i+1]
+	}
+	return tokens, nil
+}
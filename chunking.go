@@ -0,0 +1,471 @@
+package semanticcache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/botirk38/semanticcache/chunker"
+	"github.com/botirk38/semanticcache/similarity"
+	"github.com/botirk38/semanticcache/tokenizer"
+	"github.com/botirk38/semanticcache/types"
+)
+
+// embedChunked embeds texts, splitting those longer than the chunker's
+// token limit and embedding each as the centroid of its chunks'
+// embeddings, weighted by overlapWeights.
+func (c *Cache[K, V]) embedChunked(ctx context.Context, texts []string) ([][]float64, error) {
+	vectors, weights, err := c.embedChunks(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]float64, len(texts))
+	for i, vs := range vectors {
+		if out[i], err = aggregate(vs, weights[i]); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// aggregate returns the centroid of vectors, weighted by weights unless
+// they are nil.
+func aggregate(vectors [][]float64, weights []float64) ([]float64, error) {
+	switch {
+	case len(vectors) == 1:
+		return vectors[0], nil
+	case weights == nil:
+		return similarity.Centroid(vectors)
+	default:
+		return similarity.WeightedCentroid(vectors, weights)
+	}
+}
+
+// overlapWeights weighs each chunk by its tokens, a token shared by n
+// chunks counting 1/n for each, so that averaging the chunks' embeddings
+// counts every token of the text once rather than favoring the content at
+// overlapping boundaries. It returns nil, equal weights, when a chunk has
+// no token positions.
+func overlapWeights(chunks []chunker.Chunk) []float64 {
+	var points []int
+	for _, chunk := range chunks {
+		if chunk.StartToken < 0 || chunk.EndToken <= chunk.StartToken {
+			return nil
+		}
+		points = append(points, chunk.StartToken, chunk.EndToken)
+	}
+	slices.Sort(points)
+	points = slices.Compact(points)
+
+	// cover[k] counts the chunks spanning points[k] to points[k+1].
+	cover := make([]int, len(points))
+	for _, chunk := range chunks {
+		start, _ := slices.BinarySearch(points, chunk.StartToken)
+		end, _ := slices.BinarySearch(points, chunk.EndToken)
+		cover[start]++
+		cover[end]--
+	}
+	for k := 1; k < len(cover); k++ {
+		cover[k] += cover[k-1]
+	}
+
+	weights := make([]float64, len(chunks))
+	for i, chunk := range chunks {
+		start, _ := slices.BinarySearch(points, chunk.StartToken)
+		for k := start; points[k] < chunk.EndToken; k++ {
+			weights[i] += float64(points[k+1]-points[k]) / float64(cover[k])
+		}
+	}
+	return weights
+}
+
+// embedChunks returns, for each text, the embeddings of its chunks, or of
+// the whole text when it is short enough, and the chunks' overlapWeights.
+// Chunks are summarized first when the cache has a summarizer. All pieces
+// go to the provider in one batch, except chunks pooled by late chunking.
+func (c *Cache[K, V]) embedChunks(ctx context.Context, texts []string) ([][][]float64, [][]float64, error) {
+	var pieces []string
+	var chunked []int // indices of chunks in pieces
+	ends := make([]int, len(texts))
+	out := make([][][]float64, len(texts))
+	weights := make([][]float64, len(texts))
+	for i, text := range texts {
+		chunks, tokens, err := c.chunkText(text)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.recordChunking(c.chunkReport(tokens, chunks))
+		weights[i] = overlapWeights(chunks)
+		switch {
+		case len(chunks) == 0:
+			pieces = append(pieces, text)
+		case c.lateChunking:
+			if out[i], err = c.poolChunks(ctx, text, chunks); err != nil {
+				return nil, nil, err
+			}
+		default:
+			for _, chunk := range chunks {
+				chunked = append(chunked, len(pieces))
+				pieces = append(pieces, chunk.Text)
+			}
+		}
+		ends[i] = len(pieces)
+	}
+	if len(pieces) == 0 {
+		return out, weights, nil
+	}
+	if c.summary.summarizer != nil && len(chunked) > 0 {
+		chunkTexts := make([]string, len(chunked))
+		for j, p := range chunked {
+			chunkTexts[j] = pieces[p]
+		}
+		summaries, err := c.summarize(ctx, chunkTexts)
+		if err != nil {
+			return nil, nil, err
+		}
+		for j, p := range chunked {
+			pieces[p] = summaries[j]
+		}
+	}
+	embeddings, err := c.embedBatch(ctx, pieces)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := 0
+	for i, end := range ends {
+		if out[i] == nil {
+			out[i] = embeddings[start:end]
+		}
+		start = end
+	}
+	return out, weights, nil
+}
+
+// poolChunks embeds text's tokens in one provider call and returns, for
+// each of its chunks, the mean embedding of the tokens overlapping it.
+func (c *Cache[K, V]) poolChunks(ctx context.Context, text string, chunks []chunker.Chunk) ([][]float64, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+	spanCtx, span := c.startSpan(ctx, "provider.EmbedTokens", attrTexts.Int(1))
+	tokens, err := c.provider.(types.TokenEmbeddingProvider).EmbedTokens(spanCtx, text)
+	c.providerDone(ctx, err)
+	if len(tokens) > 0 {
+		span.SetAttributes(attrDims.Int(len(tokens[0].Vector)))
+	}
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]float64, len(chunks))
+	prev := 0
+	for i, chunk := range chunks {
+		// Chunks may overlap, so each is searched for from the previous
+		// one's start.
+		at := strings.Index(text[prev:], chunk.Text)
+		if at < 0 {
+			return nil, fmt.Errorf("late chunking: chunk %d is not part of the text", i)
+		}
+		start := prev + at
+		end := start + len(chunk.Text)
+		prev = start
+
+		var vecs [][]float64
+		for _, tok := range tokens {
+			if tok.Start < end && tok.End > start {
+				vecs = append(vecs, tok.Vector)
+			}
+		}
+		if len(vecs) == 0 {
+			return nil, fmt.Errorf("late chunking: no token embeddings for chunk %d", i)
+		}
+		if out[i], err = similarity.Centroid(vecs); err != nil {
+			return nil, err
+		}
+		if err := c.checkDimension(len(out[i])); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// embedEntries returns the vectors to store for each text: one per chunk
+// when the cache stores chunk vectors, otherwise a single embedding.
+func (c *Cache[K, V]) embedEntries(ctx context.Context, texts []string) ([][][]float64, error) {
+	if c.chunkVectors {
+		vectors, _, err := c.embedChunks(ctx, c.prepare(texts))
+		return vectors, err
+	}
+	embeddings, err := c.embedTexts(ctx, texts)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][][]float64, len(texts))
+	for i, emb := range embeddings {
+		out[i] = [][]float64{emb}
+	}
+	return out, nil
+}
+
+// newChunkedEntry is newEntry with vectors[0] as the embedding and the
+// rest as further vectors.
+func (c *Cache[K, V]) newChunkedEntry(vectors [][]float64, inputText string, value V, metadata map[string]string) types.Entry[V] {
+	entry := c.newEntry(vectors[0], inputText, value, metadata)
+	if len(vectors) > 1 {
+		entry.Vectors = vectors[1:]
+	}
+	return entry
+}
+
+// chunkText returns text's token count and, when it is longer than the
+// chunker's token limit, its chunks.
+func (c *Cache[K, V]) chunkText(text string) ([]chunker.Chunk, int, error) {
+	n, err := c.chunker.CountTokens(text)
+	if err != nil || n <= c.chunker.GetMaxTokens() {
+		return nil, n, err
+	}
+	chunks, err := c.chunker.ChunkText(text)
+	return chunks, n, err
+}
+
+// TextAnalysis describes how the cache would embed a text.
+type TextAnalysis struct {
+	Text      string                `json:"text"`               // the text after preprocessing, as embedded
+	Tokens    int                   `json:"tokens"`             // tokens in Text
+	MaxTokens int                   `json:"max_tokens"`         // the chunker's limit, 0 without a chunker
+	Chunked   bool                  `json:"chunked"`            // whether Text would be chunked or truncated
+	Chunks    []chunker.Chunk       `json:"chunks,omitempty"`   // the chunks, when Chunked
+	Strategy  chunker.ChunkStrategy `json:"strategy,omitempty"` // empty for chunkers outside package chunker
+}
+
+// AnalyzeText reports how Set would chunk and embed text, without calling
+// the provider or touching the backend, for validating inputs before
+// ingestion. Without a chunker, tokens are counted with the tokenizer
+// tokenizer.ForProvider picks for the provider, and nothing is chunked.
+func (c *Cache[K, V]) AnalyzeText(text string) (TextAnalysis, error) {
+	if c.preprocess != nil {
+		text = c.preprocess(text)
+	}
+	a := TextAnalysis{Text: text}
+	if c.chunker == nil {
+		tokens, err := tokenizer.ForProvider(c.provider).Tokens(text)
+		a.Tokens = len(tokens)
+		return a, err
+	}
+	chunks, tokens, err := c.chunkText(text)
+	if err != nil {
+		return TextAnalysis{}, err
+	}
+	a.Tokens, a.MaxTokens = tokens, c.chunker.GetMaxTokens()
+	a.Chunked, a.Chunks = len(chunks) > 0, chunks
+	a.Strategy = chunker.StrategyOf(c.chunker)
+	return a, nil
+}
+
+// ChunkingStats are running totals of the texts the cache's chunker has
+// checked before embedding, on writes and lookups alike. Watch Oversized,
+// and Chunked against Texts, to catch chunking that degrades silently.
+type ChunkingStats struct {
+	Strategy  chunker.ChunkStrategy `json:"strategy,omitempty"` // empty for chunkers outside package chunker
+	Texts     int64                 `json:"texts"`              // texts checked
+	Chunked   int64                 `json:"chunked"`            // texts split into chunks or truncated
+	Tokens    int64                 `json:"tokens"`             // tokens across all texts
+	Chunks    int64                 `json:"chunks"`             // chunks produced
+	Oversized int64                 `json:"oversized"`          // chunks longer than the chunker's token limit
+	Truncated int64                 `json:"truncated"`          // tokens clipped by chunker.TruncateToMaxTokens
+}
+
+// chunkCounters accumulates ChunkingStats.
+type chunkCounters struct {
+	texts, chunked, tokens, chunks, oversized, truncated atomic.Int64
+}
+
+// ChunkingStats returns the cache's chunking totals since it was created.
+// They are zero without a chunker, and namespace views share them.
+func (c *Cache[K, V]) ChunkingStats() ChunkingStats {
+	s := c.chunkStats
+	stats := ChunkingStats{
+		Texts:     s.texts.Load(),
+		Chunked:   s.chunked.Load(),
+		Tokens:    s.tokens.Load(),
+		Chunks:    s.chunks.Load(),
+		Oversized: s.oversized.Load(),
+		Truncated: s.truncated.Load(),
+	}
+	if c.chunker != nil {
+		stats.Strategy = chunker.StrategyOf(c.chunker)
+	}
+	return stats
+}
+
+// chunkReport describes a text of tokens tokens split into chunks.
+func (c *Cache[K, V]) chunkReport(tokens int, chunks []chunker.Chunk) chunker.Report {
+	r := chunker.Report{Strategy: chunker.StrategyOf(c.chunker), Tokens: tokens, Chunks: len(chunks)}
+	if r.Strategy == chunker.TruncateToMaxTokens {
+		if len(chunks) > 0 {
+			r.Truncated = max(0, tokens-c.chunker.GetMaxTokens())
+		}
+		return r
+	}
+	for _, chunk := range chunks {
+		if chunk.EndToken-chunk.StartToken > c.chunker.GetMaxTokens() {
+			r.Oversized++
+		}
+	}
+	return r
+}
+
+// recordChunking adds r to the chunking totals and passes it to the chunk
+// observer.
+func (c *Cache[K, V]) recordChunking(r chunker.Report) {
+	s := c.chunkStats
+	s.texts.Add(1)
+	if r.Chunks > 0 {
+		s.chunked.Add(1)
+	}
+	s.tokens.Add(int64(r.Tokens))
+	s.chunks.Add(int64(r.Chunks))
+	s.oversized.Add(int64(r.Oversized))
+	s.truncated.Add(int64(r.Truncated))
+	if c.onChunk != nil {
+		c.onChunk(r)
+	}
+}
+
+// matchedChunk returns the chunk behind vector of an entry stored with
+// chunk vectors, found by chunking its input text again, or nil. Entries
+// whose vector count differs from their chunk count, such as SetMulti
+// ones, have no chunks.
+func (c *Cache[K, V]) matchedChunk(entry types.Entry[V], vector int) *chunker.Chunk {
+	if !c.chunkVectors || len(entry.Vectors) == 0 {
+		return nil
+	}
+	chunks, _, err := c.chunkText(c.prepare([]string{entry.InputText})[0])
+	if err != nil || len(chunks) != 1+len(entry.Vectors) {
+		return nil
+	}
+	return &chunks[vector]
+}
+
+// ChunkMatch is a chunk of a cached entry's input text that matched a
+// query, for building prompt context from cache hits.
+type ChunkMatch[K comparable] struct {
+	Key   K             `json:"key"`
+	Chunk chunker.Chunk `json:"chunk"`
+	Score float64       `json:"score"`
+}
+
+// TopChunkMatches returns up to n chunks, across all entries, sorted by
+// descending similarity to inputText. Several chunks of one entry may be
+// returned. Entries stored with options.WithChunkVectors contribute each
+// chunk; entries stored whole contribute their input text as one chunk,
+// whose EndToken is set only when the cache has a chunker. SetMulti entries
+// and entries without stored input text are skipped. Like TopMatches it applies no threshold
+// unless WithThreshold is passed; WithFilter, WithSearchFilter,
+// WithNamespace, and WithComparator apply as well.
+func (c *Cache[K, V]) TopChunkMatches(ctx context.Context, inputText string, n int, opts ...LookupOption) ([]ChunkMatch[K], error) {
+	if err := c.begin(); err != nil {
+		return nil, err
+	}
+	defer c.end()
+	if n <= 0 {
+		return nil, ErrInvalidN
+	}
+	cfg, err := c.newLookupConfig(slices.Concat([]LookupOption{WithThreshold(math.Inf(-1))}, opts))
+	if err != nil {
+		return nil, err
+	}
+	target := c
+	if cfg.namespace != "" {
+		target = c.Namespace(cfg.namespace)
+	}
+	query, err := c.embed(ctx, inputText)
+	if err != nil {
+		return nil, err
+	}
+
+	var hits []candidate[K]
+	for batch, err := range target.keyBatches(ctx) {
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			return nil, err
+		}
+		vectors, err := target.vectors(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range batch {
+			for i, emb := range vectors[key] {
+				if len(emb) != len(query) {
+					continue
+				}
+				if score := cfg.score(query, emb); score >= cfg.threshold {
+					hits = append(hits, candidate[K]{key: key, score: score, vector: i})
+				}
+			}
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].score > hits[j].score
+	})
+
+	entries := make(map[K]*types.Entry[V])
+	matches := []ChunkMatch[K]{}
+	for _, hit := range hits {
+		if len(matches) == n {
+			break
+		}
+		entry, seen := entries[hit.key]
+		if !seen {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			e, found, err := target.getEntry(ctx, hit.key)
+			if err == nil && found && e.InputText != "" && accepts(cfg, hit.key, e.Metadata) && target.compatible(e) {
+				entry = &e
+			}
+			entries[hit.key] = entry
+		}
+		if entry == nil {
+			continue
+		}
+		if chunk := target.matchedChunk(*entry, hit.vector); chunk != nil {
+			matches = append(matches, ChunkMatch[K]{Key: hit.key, Chunk: *chunk, Score: hit.score})
+		} else if hit.vector == 0 && len(entry.Vectors) == 0 {
+			matches = append(matches, ChunkMatch[K]{Key: hit.key, Chunk: target.wholeChunk(entry.InputText), Score: hit.score})
+		}
+	}
+	return matches, nil
+}
+
+// vectors fetches each key's embedding followed by its further vectors, in
+// one call when the backend implements types.MultiVectorBackend.
+func (c *Cache[K, V]) vectors(ctx context.Context, keys []K) (map[K][][]float64, error) {
+	if mb, ok := c.backend.(types.MultiVectorBackend[K, V]); ok {
+		return mb.GetVectors(ctx, keys)
+	}
+	embeddings, err := c.embeddings(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	vectors := make(map[K][][]float64, len(embeddings))
+	for key, emb := range embeddings {
+		vectors[key] = [][]float64{emb}
+	}
+	return vectors, nil
+}
+
+// wholeChunk returns text as a single chunk.
+func (c *Cache[K, V]) wholeChunk(text string) chunker.Chunk {
+	chunk := chunker.Chunk{Text: text}
+	if c.chunker != nil {
+		chunk.EndToken, _ = c.chunker.CountTokens(text)
+	}
+	return chunk
+}
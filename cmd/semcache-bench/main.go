@@ -0,0 +1,48 @@
+// Command semcache-bench compares lookup latency and recall of the cache's
+// backends on synthetic embeddings of a given dimension. See package bench.
+//
+//	semcache-bench -entries 50000 -dims 1536 -queries 500 -k 10
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/botirk38/semanticcache/bench"
+)
+
+func main() {
+	var cfg bench.Config
+	flag.IntVar(&cfg.Entries, "entries", 10000, "entries to store")
+	flag.IntVar(&cfg.Dimensions, "dims", 384, "embedding dimension")
+	flag.IntVar(&cfg.Queries, "queries", 200, "lookups to time")
+	flag.IntVar(&cfg.K, "k", 10, "results per lookup, and the k of recall@k")
+	flag.Uint64Var(&cfg.Seed, "seed", 1, "seed for the synthetic data")
+	only := flag.String("backends", "", "comma-separated backends to run (default all)")
+	flag.Parse()
+
+	candidates := bench.DefaultCandidates(cfg.Entries)
+	if *only != "" {
+		names := strings.Split(*only, ",")
+		candidates = slices.DeleteFunc(candidates, func(c bench.Candidate) bool {
+			return !slices.Contains(names, c.Name)
+		})
+		if len(candidates) == 0 {
+			log.Fatalf("no backend named in %q", *only)
+		}
+	}
+
+	fmt.Printf("%d entries, %d dimensions, %d queries, k=%d\n\n", cfg.Entries, cfg.Dimensions, cfg.Queries, cfg.K)
+	results, err := bench.Run(context.Background(), cfg, candidates...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := bench.Format(os.Stdout, results); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/types"
+)
+
+// importBatch is the number of entries import stores per SetBatch call.
+const importBatch = 100
+
+type cache = semanticcache.Cache[string, json.RawMessage]
+
+// env is the I/O a command runs with.
+type env struct {
+	in          io.Reader
+	out, errOut io.Writer
+}
+
+type command func(ctx context.Context, c *cache, e env, args []string) error
+
+var commands = map[string]command{
+	"set":    setCmd,
+	"get":    getCmd,
+	"lookup": lookupCmd,
+	"top":    topCmd,
+	"flush":  flushCmd,
+	"export": exportCmd,
+	"import": importCmd,
+	"stats":  statsCmd,
+}
+
+// usageError reports a command called with the wrong arguments.
+type usageError string
+
+func (u usageError) Error() string { return "usage: " + string(u) }
+
+// errNotFound is returned by get and lookup when nothing matches, so
+// scripts can tell a miss from success by the exit code.
+var errNotFound = errors.New("not found")
+
+// record is one line of export's output and import's input.
+type record struct {
+	Key       string            `json:"key"`
+	InputText string            `json:"input_text"`
+	Value     json.RawMessage   `json:"value"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	CreatedAt time.Time         `json:"created_at,omitzero"`
+}
+
+// flags returns a flag set for the named command that reports errors to e.
+func (e env) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.errOut)
+	return fs
+}
+
+// parse parses args into fs and checks the positional argument count.
+func parse(fs *flag.FlagSet, args []string, want int, usage string) ([]string, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != want {
+		return nil, usageError(usage)
+	}
+	return fs.Args(), nil
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// jsonValue returns s if it is valid JSON and s encoded as a JSON string
+// otherwise.
+func jsonValue(s string) json.RawMessage {
+	if json.Valid([]byte(s)) {
+		return json.RawMessage(s)
+	}
+	b, _ := json.Marshal(s)
+	return b
+}
+
+func setCmd(ctx context.Context, c *cache, e env, args []string) error {
+	fs := e.flags("set")
+	var meta metadataFlag
+	fs.Var(&meta, "meta", "metadata as key=value; repeatable")
+	pos, err := parse(fs, args, 3, "set [-meta k=v]... <key> <text> <value>")
+	if err != nil {
+		return err
+	}
+	return c.SetWithMetadata(ctx, pos[0], pos[1], jsonValue(pos[2]), meta)
+}
+
+func getCmd(ctx context.Context, c *cache, e env, args []string) error {
+	pos, err := parse(e.flags("get"), args, 1, "get <key>")
+	if err != nil {
+		return err
+	}
+	value, found, err := c.Get(ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errNotFound
+	}
+	return printJSON(e.out, value)
+}
+
+func lookupCmd(ctx context.Context, c *cache, e env, args []string) error {
+	fs := e.flags("lookup")
+	threshold := fs.Float64("threshold", 0, "minimum score (default the cache's threshold)")
+	pos, err := parse(fs, args, 1, "lookup [-threshold t] <text>")
+	if err != nil {
+		return err
+	}
+	opts := []semanticcache.LookupOption{semanticcache.WithLimit(1)}
+	if isSet(fs, "threshold") {
+		opts = append(opts, semanticcache.WithThreshold(*threshold))
+	}
+	matches, err := c.LookupWithOptions(ctx, pos[0], opts...)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		return errNotFound
+	}
+	return printJSON(e.out, matches[0])
+}
+
+func topCmd(ctx context.Context, c *cache, e env, args []string) error {
+	fs := e.flags("top")
+	n := fs.Int("n", 5, "number of matches")
+	threshold := fs.Float64("threshold", 0, "minimum score (default none)")
+	pos, err := parse(fs, args, 1, "top [-n N] [-threshold t] <text>")
+	if err != nil {
+		return err
+	}
+	var opts []semanticcache.LookupOption
+	if isSet(fs, "threshold") {
+		opts = append(opts, semanticcache.WithThreshold(*threshold))
+	}
+	matches, err := c.TopMatchesEx(ctx, pos[0], *n, opts...)
+	if err != nil {
+		return err
+	}
+	return printJSON(e.out, matches)
+}
+
+func flushCmd(ctx context.Context, c *cache, e env, args []string) error {
+	fs := e.flags("flush")
+	yes := fs.Bool("yes", false, "confirm deleting every entry")
+	if _, err := parse(fs, args, 0, "flush -yes"); err != nil {
+		return err
+	}
+	if !*yes {
+		return usageError("flush -yes (flush deletes every entry)")
+	}
+	return c.Flush(ctx)
+}
+
+func exportCmd(ctx context.Context, c *cache, e env, args []string) error {
+	fs := e.flags("export")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return usageError("export [file]")
+	}
+	out, f := e.out, (*os.File)(nil)
+	if fs.NArg() == 1 {
+		var err error
+		if f, err = os.Create(fs.Arg(0)); err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		out = f
+	}
+
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	var encErr error
+	err := c.Range(ctx, func(key string, entry types.Entry[json.RawMessage]) bool {
+		encErr = enc.Encode(record{
+			Key:       key,
+			InputText: entry.InputText,
+			Value:     entry.Value,
+			Metadata:  entry.Metadata,
+			CreatedAt: entry.CreatedAt,
+		})
+		return encErr == nil
+	})
+	if err = errors.Join(err, encErr, w.Flush()); err != nil {
+		return err
+	}
+	if f != nil {
+		return f.Close()
+	}
+	return nil
+}
+
+func importCmd(ctx context.Context, c *cache, e env, args []string) error {
+	fs := e.flags("import")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return usageError("import [file]")
+	}
+	r := e.in
+	if fs.NArg() == 1 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	dec := json.NewDecoder(r)
+	items := make([]semanticcache.BatchItem[string, json.RawMessage], 0, importBatch)
+	total := 0
+	flush := func() error {
+		if len(items) == 0 {
+			return nil
+		}
+		if err := c.SetBatch(ctx, items); err != nil {
+			return fmt.Errorf("after %d entries: %w", total, err)
+		}
+		total += len(items)
+		items = items[:0]
+		return nil
+	}
+	for {
+		var rec record
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("record %d: %w", total+len(items)+1, err)
+		}
+		items = append(items, semanticcache.BatchItem[string, json.RawMessage]{
+			Key:       rec.Key,
+			InputText: rec.InputText,
+			Value:     rec.Value,
+			Metadata:  rec.Metadata,
+		})
+		if len(items) == importBatch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.errOut, "imported %d entries\n", total)
+	return nil
+}
+
+// stats is the output of the stats command. Memory is omitted when the
+// backend does not report it.
+type stats struct {
+	Entries  int                         `json:"entries"`
+	Memory   *types.MemoryUsage          `json:"memory,omitempty"`
+	Chunking semanticcache.ChunkingStats `json:"chunking"`
+	Health   semanticcache.HealthReport  `json:"health"`
+}
+
+func statsCmd(ctx context.Context, c *cache, e env, args []string) error {
+	if _, err := parse(e.flags("stats"), args, 0, "stats"); err != nil {
+		return err
+	}
+	n, err := c.Len(ctx)
+	if err != nil {
+		return err
+	}
+	s := stats{Entries: n, Chunking: c.ChunkingStats()}
+	switch usage, err := c.MemoryUsage(ctx); {
+	case err == nil:
+		s.Memory = &usage
+	case !errors.Is(err, semanticcache.ErrMemoryUsageUnsupported):
+		return err
+	}
+	s.Health = c.HealthCheck(ctx)
+	return printJSON(e.out, s)
+}
+
+// metadataFlag collects repeated key=value flags.
+type metadataFlag map[string]string
+
+func (m *metadataFlag) String() string { return fmt.Sprint(map[string]string(*m)) }
+
+func (m *metadataFlag) Set(s string) error {
+	k, v, ok := strings.Cut(s, "=")
+	if !ok || k == "" {
+		return fmt.Errorf("metadata %q is not key=value", s)
+	}
+	if *m == nil {
+		*m = make(metadataFlag)
+	}
+	(*m)[k] = v
+	return nil
+}
+
+// isSet reports whether the flag name was given on the command line.
+func isSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
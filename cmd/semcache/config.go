@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/backends/nats"
+	"github.com/botirk38/semanticcache/backends/remote"
+	"github.com/botirk38/semanticcache/options"
+)
+
+// config selects the cache semcache opens. It is read from the -config
+// JSON file, and flags set on the command line override it.
+type config struct {
+	Backend   string  `json:"backend"`    // redis, nats, or memory
+	Addr      string  `json:"addr"`       // Redis address or NATS URL
+	Prefix    string  `json:"prefix"`     // Redis key prefix
+	Bucket    string  `json:"bucket"`     // NATS KV bucket
+	Provider  string  `json:"provider"`   // openai or local
+	Model     string  `json:"model"`      // OpenAI embedding model
+	APIKey    string  `json:"api_key"`    // OpenAI key; defaults to $OPENAI_API_KEY
+	Dims      int     `json:"dimensions"` // local provider dimension
+	Threshold float64 `json:"threshold"`  // default lookup threshold
+}
+
+func defaultConfig() config {
+	return config{
+		Backend:   "redis",
+		Addr:      "localhost:6379",
+		Prefix:    "semanticcache:",
+		Provider:  "openai",
+		Dims:      128,
+		Threshold: options.DefaultThreshold,
+	}
+}
+
+// bindFlags registers the config flags on fs, writing into cfg.
+func bindFlags(fs *flag.FlagSet, cfg *config) *string {
+	path := fs.String("config", "", "JSON config file; flags override it")
+	fs.StringVar(&cfg.Backend, "backend", cfg.Backend, "backend: redis, nats, or memory")
+	fs.StringVar(&cfg.Addr, "addr", cfg.Addr, "Redis address or NATS URL")
+	fs.StringVar(&cfg.Prefix, "prefix", cfg.Prefix, "Redis key prefix")
+	fs.StringVar(&cfg.Bucket, "bucket", cfg.Bucket, "NATS KV bucket")
+	fs.StringVar(&cfg.Provider, "provider", cfg.Provider, "embedding provider: openai or local")
+	fs.StringVar(&cfg.Model, "model", cfg.Model, "OpenAI embedding model")
+	fs.IntVar(&cfg.Dims, "dims", cfg.Dims, "local provider dimension")
+	fs.Float64Var(&cfg.Threshold, "threshold", cfg.Threshold, "default lookup threshold")
+	return path
+}
+
+// loadConfig parses args into a config: defaults, then the -config file,
+// then the flags that were set. It returns the remaining arguments.
+func loadConfig(fs *flag.FlagSet, args []string) (config, []string, error) {
+	cfg := defaultConfig()
+	path := bindFlags(fs, &cfg)
+	if err := fs.Parse(args); err != nil {
+		return cfg, nil, err
+	}
+	if *path == "" {
+		return cfg, fs.Args(), nil
+	}
+
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		return cfg, nil, err
+	}
+	file := defaultConfig()
+	if err := json.Unmarshal(data, &file); err != nil {
+		return cfg, nil, fmt.Errorf("parse %s: %w", *path, err)
+	}
+	// Flags given on the command line win over the file.
+	override := flag.NewFlagSet("", flag.ContinueOnError)
+	bindFlags(override, &file)
+	var setErr error
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name != "config" && setErr == nil {
+			setErr = override.Set(f.Name, f.Value.String())
+		}
+	})
+	if setErr != nil {
+		return cfg, nil, setErr
+	}
+	return file, fs.Args(), nil
+}
+
+// open builds the cache cfg describes. Values are raw JSON stored with
+// remote.JSONCodec, so the CLI reads entries of any program that stores
+// JSON-encodable values with that codec: the Redis default, and NATS with
+// nats.WithValueCodec.
+func open(cfg config) (*semanticcache.Cache[string, json.RawMessage], error) {
+	type opt = options.Option[string, json.RawMessage]
+	var backend opt
+	switch cfg.Backend {
+	case "redis":
+		backend = options.WithRedisBackend[string, json.RawMessage](cfg.Addr, remote.WithPrefix(cfg.Prefix))
+	case "nats":
+		natsOpts := []nats.Option{nats.WithValueCodec(remote.JSONCodec{})}
+		if cfg.Bucket != "" {
+			natsOpts = append(natsOpts, nats.WithBucket(cfg.Bucket))
+		}
+		backend = func(c *options.Config[string, json.RawMessage]) error {
+			b, err := nats.NewBackend[string, json.RawMessage](cfg.Addr, natsOpts...)
+			if err != nil {
+				return err
+			}
+			c.Backend = b
+			return nil
+		}
+	case "memory":
+		backend = options.WithLRUBackend[string, json.RawMessage](1 << 20)
+	default:
+		return nil, fmt.Errorf("unknown backend %q", cfg.Backend)
+	}
+
+	var provider opt
+	switch cfg.Provider {
+	case "openai":
+		key := cfg.APIKey
+		if key == "" {
+			key = os.Getenv("OPENAI_API_KEY")
+		}
+		if key == "" {
+			return nil, errors.New("the openai provider needs api_key in the config file or $OPENAI_API_KEY")
+		}
+		var model []string
+		if cfg.Model != "" {
+			model = append(model, cfg.Model)
+		}
+		provider = options.WithOpenAIProvider[string, json.RawMessage](key, model...)
+	case "local":
+		provider = options.WithLocalProvider[string, json.RawMessage](cfg.Dims)
+	default:
+		return nil, fmt.Errorf("unknown provider %q", cfg.Provider)
+	}
+	return semanticcache.New(backend, provider, options.WithDefaultThreshold[string, json.RawMessage](cfg.Threshold))
+}
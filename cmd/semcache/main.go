@@ -0,0 +1,93 @@
+// Command semcache inspects and manages a semantic cache from the shell.
+// It opens the backend and provider a JSON config file or flags describe,
+// runs one command, and closes the cache.
+//
+//	semcache [flags] <command> [command flags] [args]
+//
+//	set <key> <text> <value>   store value, embedding text
+//	get <key>                  print the value stored under key
+//	lookup <text>              print the best match above the threshold
+//	top [-n N] <text>          print the N best matches
+//	flush -yes                 delete every entry
+//	export [file]              write every entry as JSON lines
+//	import [file]              store JSON lines written by export
+//	stats                      print entry count, memory, and health
+//
+// Values are JSON; a value argument that is not valid JSON is stored as a
+// JSON string. The config file holds the fields of the flags below, such as
+//
+//	{"backend": "redis", "addr": "localhost:6379", "prefix": "app:",
+//	 "provider": "openai", "model": "text-embedding-3-small"}
+//
+// The OpenAI key is read from the file's "api_key" or $OPENAI_API_KEY.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run executes the command line args and returns the exit code: 0 on
+// success, 1 when the command fails or finds nothing, 2 on bad usage.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("semcache", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: semcache [flags] <command> [args]")
+		fmt.Fprintln(stderr, "\ncommands: set, get, lookup, top, flush, export, import, stats")
+		fmt.Fprintln(stderr, "\nflags:")
+		fs.PrintDefaults()
+	}
+	cfg, rest, err := loadConfig(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "semcache:", err)
+		return 2
+	}
+	if len(rest) == 0 {
+		fs.Usage()
+		return 2
+	}
+	cmd, ok := commands[rest[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "semcache: unknown command %q\n", rest[0])
+		fs.Usage()
+		return 2
+	}
+
+	cache, err := open(cfg)
+	if err != nil {
+		fmt.Fprintln(stderr, "semcache:", err)
+		return 1
+	}
+	defer func() { _ = cache.Close() }()
+
+	err = cmd(ctx, cache, env{in: stdin, out: stdout, errOut: stderr}, rest[1:])
+	var usage usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.As(err, &usage):
+		fmt.Fprintf(stderr, "semcache %s: %v\n", rest[0], err)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "semcache %s: %v\n", rest[0], err)
+		return 1
+	}
+}
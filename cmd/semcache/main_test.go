@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	ctx := context.Background()
+	newCache := func() *cache {
+		t.Helper()
+		c, err := open(config{Backend: "memory", Provider: "local", Dims: 64, Threshold: 0.8})
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		return c
+	}
+	exec := func(c *cache, in string, args ...string) (string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		err := commands[args[0]](ctx, c, env{in: strings.NewReader(in), out: &out, errOut: io.Discard}, args[1:])
+		return out.String(), err
+	}
+
+	c := newCache()
+	if _, err := exec(c, "", "set", "-meta", "lang=en", "greeting", "hello world", `{"text":"hi"}`); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if _, err := exec(c, "", "set", "plain", "plain text", "not json"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if out, err := exec(c, "", "get", "plain"); err != nil || strings.TrimSpace(out) != `"not json"` {
+		t.Errorf("get plain = %q, %v; want the value as a JSON string", out, err)
+	}
+	if _, err := exec(c, "", "get", "missing"); !errors.Is(err, errNotFound) {
+		t.Errorf("get missing error = %v, want errNotFound", err)
+	}
+
+	out, err := exec(c, "", "lookup", "hello world")
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	var match struct {
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal([]byte(out), &match); err != nil || match.Key != "greeting" {
+		t.Errorf("lookup = %s (%v), want the greeting entry", out, err)
+	}
+	if out, err := exec(c, "", "top", "-n", "2", "hello world"); err != nil || strings.Count(out, `"key"`) != 2 {
+		t.Errorf("top = %s, %v; want 2 matches", out, err)
+	}
+
+	exported, err := exec(c, "", "export")
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	if n := strings.Count(exported, "\n"); n != 2 {
+		t.Fatalf("export wrote %d lines, want 2:\n%s", n, exported)
+	}
+	var u usageError
+	if _, err := exec(c, "", "flush"); !errors.As(err, &u) {
+		t.Errorf("flush without -yes error = %v, want a usage error", err)
+	}
+	if _, err := exec(c, "", "flush", "-yes"); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if _, err := exec(c, "", "get", "plain"); !errors.Is(err, errNotFound) {
+		t.Errorf("get after flush error = %v, want errNotFound", err)
+	}
+
+	if _, err := exec(c, exported, "import"); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if out, err := exec(c, "", "get", "greeting"); err != nil || !strings.Contains(out, `"hi"`) {
+		t.Errorf("get after import = %q, %v", out, err)
+	}
+	out, err = exec(c, "", "stats")
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	var s stats
+	if err := json.Unmarshal([]byte(out), &s); err != nil || s.Entries != 2 || !s.Health.Ready {
+		t.Errorf("stats = %s (%v), want 2 entries and a ready cache", out, err)
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	ctx := context.Background()
+	for _, args := range [][]string{
+		{},
+		{"-backend", "memory", "-provider", "local", "bogus"},
+		{"-backend", "memory", "-provider", "local", "get"},
+	} {
+		if code := run(ctx, args, strings.NewReader(""), io.Discard, io.Discard); code != 2 {
+			t.Errorf("run(%q) = %d, want 2", args, code)
+		}
+	}
+	if code := run(ctx, []string{"-backend", "memory", "-provider", "local", "stats"}, nil, io.Discard, io.Discard); code != 0 {
+		t.Errorf("stats exit code = %d, want 0", code)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "semcache.json")
+	if err := os.WriteFile(path, []byte(`{"backend": "nats", "addr": "nats://cache:4222", "threshold": 0.9}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, rest, err := loadConfig(flag.NewFlagSet("", flag.ContinueOnError), []string{"-config", path, "-addr", "nats://other:4222", "stats"})
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	want := defaultConfig()
+	want.Backend, want.Addr, want.Threshold = "nats", "nats://other:4222", 0.9
+	if cfg != want || len(rest) != 1 || rest[0] != "stats" {
+		t.Errorf("loadConfig = %+v, %q; want %+v, [stats]", cfg, rest, want)
+	}
+}
@@ -0,0 +1,67 @@
+package semanticcache
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// embedFlight is an in-progress query embedding shared by concurrent
+// callers.
+type embedFlight struct {
+	done chan struct{}
+	emb  []float64
+	err  error
+}
+
+// embedGroup coalesces concurrent embeddings of the same text, so identical
+// queries arriving together cost one provider call. It is shared by
+// namespace views. A nil *embedGroup embeds every call separately.
+type embedGroup struct {
+	mu      sync.Mutex
+	flights map[string]*embedFlight
+}
+
+func newEmbedGroup() *embedGroup {
+	return &embedGroup{flights: make(map[string]*embedFlight)}
+}
+
+// do returns fn's embedding of text, sharing one call among the callers
+// asking for the same text meanwhile. Callers other than the first receive
+// a copy. A caller whose ctx ends while waiting returns ctx.Err(); one
+// whose call was shared with a caller that gave up tries again itself.
+func (g *embedGroup) do(ctx context.Context, text string, fn func(context.Context) ([]float64, error)) ([]float64, error) {
+	if g == nil {
+		return fn(ctx)
+	}
+	for {
+		g.mu.Lock()
+		f, ok := g.flights[text]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if f.err != nil && (errors.Is(f.err, context.Canceled) || errors.Is(f.err, context.DeadlineExceeded)) && ctx.Err() == nil {
+			continue
+		}
+		return slices.Clone(f.emb), f.err
+	}
+	f := &embedFlight{done: make(chan struct{})}
+	g.flights[text] = f
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.flights, text)
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.emb, f.err = fn(ctx)
+	return f.emb, f.err
+}
@@ -0,0 +1,127 @@
+package semanticcache
+
+import (
+	"context"
+	"fmt"
+)
+
+// flight is an in-progress LookupOrCompute shared by concurrent callers.
+type flight[V any] struct {
+	done  chan struct{}
+	value V
+	hit   bool
+	err   error
+}
+
+// flightKey identifies a LookupOrCompute flight: callers share one only
+// when they ask for the same text at the same threshold.
+type flightKey struct {
+	text      string
+	threshold float64
+}
+
+// LookupOrCompute returns the best match for inputText whose similarity is
+// at least threshold. On a miss it calls compute, stores the result under
+// key like Set, and returns it. hit reports whether the value came from the
+// cache.
+//
+// Concurrent calls with the same inputText and threshold share one lookup
+// and at most one compute call; they all receive the first caller's result,
+// which is stored under the first caller's key. Errors from compute are
+// returned to every waiting caller and nothing is stored; a compute panic
+// is returned as ErrComputePanicked. The shared work runs on the first
+// caller's ctx without its cancellation, so a caller whose ctx ends,
+// including the first, returns ctx.Err() while the computation carries on.
+func (c *Cache[K, V]) LookupOrCompute(ctx context.Context, key K, inputText string, threshold float64, compute func(context.Context) (V, error)) (value V, hit bool, err error) {
+	if err := c.begin(); err != nil {
+		return value, false, err
+	}
+	defer c.end()
+	if err := c.checkKey(key); err != nil {
+		return value, false, err
+	}
+
+	fk := flightKey{text: inputText, threshold: threshold}
+	c.flightMu.Lock()
+	f, ok := c.flights[fk]
+	if !ok {
+		// The flight holds its own in-flight count, so Close waits for it
+		// after every caller has given up.
+		if err := c.begin(); err != nil {
+			c.flightMu.Unlock()
+			return value, false, err
+		}
+		if c.flights == nil {
+			c.flights = make(map[flightKey]*flight[V])
+		}
+		f = &flight[V]{done: make(chan struct{})}
+		c.flights[fk] = f
+		go c.fly(context.WithoutCancel(ctx), fk, f, key, compute)
+	}
+	c.flightMu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.value, f.hit, f.err
+	case <-ctx.Done():
+		return value, false, ctx.Err()
+	}
+}
+
+// fly runs the flight f and releases its waiters, turning a panic into
+// ErrComputePanicked.
+func (c *Cache[K, V]) fly(ctx context.Context, fk flightKey, f *flight[V], key K, compute func(context.Context) (V, error)) {
+	defer c.end()
+	defer func() {
+		if r := recover(); r != nil {
+			f.err = fmt.Errorf("%w: %v", ErrComputePanicked, r)
+		}
+		c.flightMu.Lock()
+		delete(c.flights, fk)
+		c.flightMu.Unlock()
+		close(f.done)
+	}()
+	f.value, f.hit, f.err = c.lookupOrCompute(ctx, key, fk.text, fk.threshold, compute)
+}
+
+func (c *Cache[K, V]) lookupOrCompute(ctx context.Context, key K, inputText string, threshold float64, compute func(context.Context) (V, error)) (V, bool, error) {
+	var zero V
+	cfg, err := c.newLookupConfig([]LookupOption{WithThreshold(threshold)})
+	if err != nil {
+		return zero, false, err
+	}
+	if m, ok := c.exactMatch(ctx, inputText, cfg); ok {
+		return m.Value, true, nil
+	}
+	query, err := c.embed(ctx, inputText)
+	if err != nil {
+		return zero, false, err
+	}
+	matches, err := c.search(ctx, query, cfg)
+	if err != nil {
+		return zero, false, err
+	}
+	if len(matches) > 0 {
+		return matches[0].Value, true, nil
+	}
+
+	value, err := compute(ctx)
+	if err != nil {
+		return zero, false, err
+	}
+	// The query embedding is what Set would store, unless the cache keeps
+	// one vector per chunk.
+	vectors := [][]float64{query}
+	if c.chunkVectors {
+		chunked, err := c.embedEntries(ctx, []string{inputText})
+		if err != nil {
+			return zero, false, err
+		}
+		vectors = chunked[0]
+	}
+	if err := c.setEntry(ctx, key, c.newChunkedEntry(vectors, inputText, value, nil)); err != nil {
+		return zero, false, err
+	}
+	c.indexText(inputText, key)
+	return value, false, nil
+}
@@ -0,0 +1,70 @@
+package semanticcache
+
+import (
+	"context"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// SetIfAbsent stores value under key only if key is not already cached,
+// reporting whether it was stored, so concurrent writers never clobber a
+// freshly cached result. The check and write are atomic in the backend,
+// which must implement types.ConditionalBackend; otherwise
+// ErrConditionalUnsupported is returned. inputText is embedded even when
+// the key turns out to be present.
+func (c *Cache[K, V]) SetIfAbsent(ctx context.Context, key K, inputText string, value V) (bool, error) {
+	if err := c.begin(); err != nil {
+		return false, err
+	}
+	defer c.end()
+	cb, err := c.conditional(key)
+	if err != nil {
+		return false, err
+	}
+	embedding, err := c.embed(ctx, inputText)
+	if err != nil {
+		return false, err
+	}
+	stored, err := cb.SetIfAbsent(ctx, key, c.newEntry(embedding, inputText, value, nil))
+	if stored {
+		c.indexText(inputText, key)
+	}
+	return stored, err
+}
+
+// CompareAndSwap replaces key's value with value, embedded from inputText,
+// only if the current value equals old. It reports whether the swap
+// happened. In-memory backends compare with reflect.DeepEqual and remote
+// backends compare encoded values. The backend must implement
+// types.ConditionalBackend; otherwise ErrConditionalUnsupported is
+// returned.
+func (c *Cache[K, V]) CompareAndSwap(ctx context.Context, key K, old V, inputText string, value V) (bool, error) {
+	if err := c.begin(); err != nil {
+		return false, err
+	}
+	defer c.end()
+	cb, err := c.conditional(key)
+	if err != nil {
+		return false, err
+	}
+	embedding, err := c.embed(ctx, inputText)
+	if err != nil {
+		return false, err
+	}
+	swapped, err := cb.CompareAndSwap(ctx, key, old, c.newEntry(embedding, inputText, value, nil))
+	if swapped {
+		c.indexText(inputText, key)
+	}
+	return swapped, err
+}
+
+func (c *Cache[K, V]) conditional(key K) (types.ConditionalBackend[K, V], error) {
+	if err := c.checkKey(key); err != nil {
+		return nil, err
+	}
+	cb, ok := c.backend.(types.ConditionalBackend[K, V])
+	if !ok {
+		return nil, ErrConditionalUnsupported
+	}
+	return cb, nil
+}
@@ -0,0 +1,112 @@
+package semanticcache
+
+import "context"
+
+// FindDuplicates groups the keys of entries whose embeddings are at least
+// threshold similar, for offline cleanup jobs. Groups are connected: two
+// keys share a group when a chain of such pairs links them. Keys without a
+// near-duplicate are left out. Backends with a vector index find each
+// entry's neighbors through it; others are compared pairwise, which takes
+// time quadratic in the number of entries.
+func (c *Cache[K, V]) FindDuplicates(ctx context.Context, threshold float64) ([][]K, error) {
+	if err := c.begin(); err != nil {
+		return nil, err
+	}
+	defer c.end()
+	var keys []K
+	embeddings := make(map[K][]float64)
+	for batch, err := range c.keyBatches(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		found, err := c.embeddings(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range batch {
+			if emb, ok := found[key]; ok {
+				keys = append(keys, key)
+				embeddings[key] = emb
+			}
+		}
+	}
+
+	index := make(map[K]int, len(keys))
+	for i, key := range keys {
+		index[key] = i
+	}
+	parent := make([]int, len(keys))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	union := func(i, j int) {
+		parent[find(i)] = find(j)
+	}
+
+	cfg, err := c.newLookupConfig(nil)
+	if err != nil {
+		return nil, err
+	}
+	if vs, ok := c.vectorSearcher(cfg); ok {
+		for i, key := range keys {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			for k := 8; ; k *= 2 {
+				results, err := vs.VectorSearch(ctx, embeddings[key], k)
+				if err != nil {
+					return nil, err
+				}
+				below := false
+				for _, r := range results {
+					if cfg.scaled(r.Score) < threshold {
+						below = true
+						break
+					}
+					if j, ok := index[r.Key]; ok && j != i {
+						union(i, j)
+					}
+				}
+				if below || len(results) < k {
+					break
+				}
+			}
+		}
+	} else {
+		for i, a := range keys {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			for j := i + 1; j < len(keys); j++ {
+				b := embeddings[keys[j]]
+				if len(b) == len(embeddings[a]) && cfg.score(embeddings[a], b) >= threshold {
+					union(i, j)
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]K)
+	var roots []int
+	for i, key := range keys {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], key)
+	}
+	var groups [][]K
+	for _, root := range roots {
+		if len(members[root]) > 1 {
+			groups = append(groups, members[root])
+		}
+	}
+	return groups, nil
+}
@@ -1,15 +1,104 @@
 package semanticcache
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Sentinel errors returned by Cache methods.
 var (
 	// ErrClosed is returned when an operation is attempted on a closed cache.
 	ErrClosed = errors.New("semanticcache: cache is closed")
 
-	// ErrZeroKey is returned when a zero-value key is used.
+	// ErrZeroKey is returned when a zero-value key is written and no
+	// options.WithKeyValidator overrides the check.
 	ErrZeroKey = errors.New("semanticcache: key cannot be zero value")
 
 	// ErrInvalidN is returned when n <= 0 is passed to TopMatches.
 	ErrInvalidN = errors.New("semanticcache: n must be positive")
+
+	// ErrTxnUnsupported is returned by Txn when the backend does not
+	// implement types.Transactional.
+	ErrTxnUnsupported = errors.New("semanticcache: backend does not support transactions")
+
+	// ErrMetadataUnsupported is returned when metadata is stored in a
+	// backend that does not implement types.EntryBackend.
+	ErrMetadataUnsupported = errors.New("semanticcache: backend does not store metadata")
+
+	// ErrConditionalUnsupported is returned by SetIfAbsent and
+	// CompareAndSwap when the backend does not implement
+	// types.ConditionalBackend.
+	ErrConditionalUnsupported = errors.New("semanticcache: backend does not support conditional writes")
+
+	// ErrExpiryUnsupported is returned by Expire and Persist when the
+	// backend does not implement types.ExpiringBackend.
+	ErrExpiryUnsupported = errors.New("semanticcache: backend does not support per-key expiry")
+
+	// ErrPinUnsupported is returned by Pin and Unpin when the backend does
+	// not implement types.PinningBackend.
+	ErrPinUnsupported = errors.New("semanticcache: backend does not support pinning")
+
+	// ErrRefreshUnsupported is returned by Refresh when the backend does
+	// not implement types.EntryBackend, so entries' input text is lost.
+	ErrRefreshUnsupported = errors.New("semanticcache: backend does not store input text for re-embedding")
+
+	// ErrMultiVectorUnsupported is returned by SetMulti when the backend
+	// does not implement types.MultiVectorBackend.
+	ErrMultiVectorUnsupported = errors.New("semanticcache: backend does not store multiple vectors per key")
+
+	// ErrNoInputTexts is returned by SetMulti and LookupFused when given
+	// no input texts, and by SetFromReader when its reader yields none.
+	ErrNoInputTexts = errors.New("semanticcache: at least one input text is required")
+
+	// ErrInvalidLambda is returned by TopMatchesMMR when lambda is outside
+	// [0, 1].
+	ErrInvalidLambda = errors.New("semanticcache: lambda must be between 0 and 1")
+
+	// ErrHybridUnsupported is returned by lookups passed WithHybrid or
+	// WithHybridRRF when options.WithLexicalIndex is not set.
+	ErrHybridUnsupported = errors.New("semanticcache: hybrid lookups need a lexical index")
+
+	// ErrSearchFilterKeyType is returned by lookups passed a
+	// WithSearchFilter whose key type is not the cache's.
+	ErrSearchFilterKeyType = errors.New("semanticcache: search filter key type does not match the cache's")
+
+	// ErrDimensionMismatch matches every *DimensionError.
+	ErrDimensionMismatch = errors.New("semanticcache: embedding dimension mismatch")
+
+	// ErrTextKeyUnsupported is returned by SetText and LookupText when the
+	// key type is not of string kind.
+	ErrTextKeyUnsupported = errors.New("semanticcache: text-derived keys need a string key type")
+
+	// ErrChunkerRequired is returned by SetFromReader when the cache has
+	// no chunker; see options.WithChunker.
+	ErrChunkerRequired = errors.New("semanticcache: streaming input needs a chunker")
+
+	// ErrCircuitOpen is returned, with options.WithCircuitBreaker, by calls
+	// that need an embedding while the provider has been failing.
+	ErrCircuitOpen = errors.New("semanticcache: embedding provider circuit is open")
+
+	// ErrComputePanicked is returned by LookupOrCompute when compute
+	// panics. Its message includes the panic value.
+	ErrComputePanicked = errors.New("semanticcache: compute panicked")
+
+	// ErrMemoryUsageUnsupported is returned by MemoryUsage when the backend
+	// does not implement types.MemoryReporter.
+	ErrMemoryUsageUnsupported = errors.New("semanticcache: backend does not report memory usage")
 )
+
+// DimensionError is returned, with options.WithDimensionCheck, when an
+// embedding's length differs from the cache's expected dimension. It
+// matches ErrDimensionMismatch with errors.Is.
+type DimensionError struct {
+	Expected int
+	Actual   int
+}
+
+func (e *DimensionError) Error() string {
+	return fmt.Sprintf("semanticcache: embedding dimension mismatch: expected %d, got %d", e.Expected, e.Actual)
+}
+
+// Is reports whether target is ErrDimensionMismatch.
+func (e *DimensionError) Is(target error) bool {
+	return target == ErrDimensionMismatch
+}
@@ -0,0 +1,100 @@
+package semanticcache
+
+import (
+	"context"
+	"sync"
+)
+
+// exactIndex maps input text, byte for byte, to the key it was last stored
+// under, for the exact-match fast path. It holds at most max texts and
+// forgets the oldest first. Hits are verified against the stored entry, so
+// stale mappings left by deletes, evictions, or overwrites are harmless.
+// A nil *exactIndex is disabled.
+type exactIndex[K comparable] struct {
+	mu    sync.Mutex
+	max   int
+	keys  map[string]K
+	order []string
+}
+
+func newExactIndex[K comparable](max int) *exactIndex[K] {
+	if max <= 0 {
+		return nil
+	}
+	return &exactIndex[K]{max: max, keys: make(map[string]K)}
+}
+
+// slot scopes a text to the namespace of the view that wrote it.
+func (x *exactIndex[K]) slot(namespace, inputText string) string {
+	return namespace + "\x00" + inputText
+}
+
+func (x *exactIndex[K]) add(namespace, inputText string, key K) {
+	if x == nil {
+		return
+	}
+	s := x.slot(namespace, inputText)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.keys[s]; !ok {
+		if len(x.order) == x.max {
+			delete(x.keys, x.order[0])
+			x.order = x.order[1:]
+		}
+		x.order = append(x.order, s)
+	}
+	x.keys[s] = key
+}
+
+func (x *exactIndex[K]) get(namespace, inputText string) (K, bool) {
+	if x == nil {
+		var zero K
+		return zero, false
+	}
+	s := x.slot(namespace, inputText)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	key, ok := x.keys[s]
+	return key, ok
+}
+
+// bytes estimates the memory held by the index.
+func (x *exactIndex[K]) bytes() int64 {
+	if x == nil {
+		return 0
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	size := int64(cap(x.order)) * stringHeaderSize
+	for s := range x.keys {
+		size += mapSlotSize + stringHeaderSize + int64(len(s)) + keySize[K]()
+	}
+	return size
+}
+
+// indexText records that key now holds inputText, in the exact-match and
+// lexical indexes.
+func (c *Cache[K, V]) indexText(inputText string, key K) {
+	c.exact.add(c.namespace, inputText, key)
+	c.lexical.add(c.namespace, key, inputText)
+}
+
+// exactMatch returns the entry stored for the identical text, if the fast
+// path is enabled and cfg can be answered by a single score-1 match under
+// the cache's own comparator. Texts differing only in case or spacing can
+// embed differently, so they take the normal path.
+func (c *Cache[K, V]) exactMatch(ctx context.Context, inputText string, cfg lookupConfig) (*MatchEx[K, V], bool) {
+	if c.exact == nil || cfg.limit != 1 || cfg.offset != 0 || cfg.threshold > 1 || !sameComparator(cfg.comparator, c.comparator) {
+		return nil, false
+	}
+	key, ok := c.exact.get(c.namespace, inputText)
+	if !ok {
+		return nil, false
+	}
+	entry, found, err := c.getEntry(ctx, key)
+	if err != nil || !found || entry.InputText != inputText || !accepts(cfg, key, entry.Metadata) {
+		return nil, false
+	}
+	m := newMatchEx(key, entry, 1)
+	return &m, true
+}
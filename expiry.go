@@ -0,0 +1,63 @@
+package semanticcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// Expire makes key expire after ttl without rewriting or re-embedding it.
+// A non-positive ttl deletes the entry. It reports whether key existed. The
+// backend must implement types.ExpiringBackend; otherwise
+// ErrExpiryUnsupported is returned.
+func (c *Cache[K, V]) Expire(ctx context.Context, key K, ttl time.Duration) (bool, error) {
+	if err := c.begin(); err != nil {
+		return false, err
+	}
+	defer c.end()
+	eb, err := c.expiring()
+	if err != nil {
+		return false, err
+	}
+	return eb.Expire(ctx, key, ttl)
+}
+
+// Persist removes key's expiry so it lives until deleted or evicted. It
+// reports whether key existed. The backend must implement
+// types.ExpiringBackend; otherwise ErrExpiryUnsupported is returned.
+func (c *Cache[K, V]) Persist(ctx context.Context, key K) (bool, error) {
+	if err := c.begin(); err != nil {
+		return false, err
+	}
+	defer c.end()
+	eb, err := c.expiring()
+	if err != nil {
+		return false, err
+	}
+	return eb.Persist(ctx, key)
+}
+
+// Touch marks key as recently used without rewriting it, restarting its
+// lifetime on backends with a default TTL. Backends without
+// types.ExpiringBackend are touched with a read, which refreshes the key's
+// position under LRU and LFU eviction. It reports whether key existed.
+func (c *Cache[K, V]) Touch(ctx context.Context, key K) (bool, error) {
+	if err := c.begin(); err != nil {
+		return false, err
+	}
+	defer c.end()
+	if eb, ok := c.backend.(types.ExpiringBackend[K, V]); ok {
+		return eb.Touch(ctx, key)
+	}
+	_, ok, err := c.backend.Get(ctx, key)
+	return ok, err
+}
+
+func (c *Cache[K, V]) expiring() (types.ExpiringBackend[K, V], error) {
+	eb, ok := c.backend.(types.ExpiringBackend[K, V])
+	if !ok {
+		return nil, ErrExpiryUnsupported
+	}
+	return eb, nil
+}
@@ -0,0 +1,53 @@
+package semanticcache
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sort"
+)
+
+// LookupFused embeds several phrasings of one question, ranks the entries
+// against each, and fuses the rankings by reciprocal rank fusion, so one
+// noisy phrasing cannot decide the result alone. It returns up to n
+// matches, best first; each Score is the sum of 1/(DefaultRRFK+rank) over
+// the rankings the entry appears in. As with TopMatches, no threshold
+// applies unless WithThreshold is passed, in which case it filters each
+// ranking by similarity before fusion. Returns ErrNoInputTexts without
+// queries.
+func (c *Cache[K, V]) LookupFused(ctx context.Context, queries []string, n int, opts ...LookupOption) ([]MatchEx[K, V], error) {
+	if err := c.begin(); err != nil {
+		return nil, err
+	}
+	defer c.end()
+	if n <= 0 {
+		return nil, ErrInvalidN
+	}
+	if len(queries) == 0 {
+		return nil, ErrNoInputTexts
+	}
+	cfg, err := c.newLookupConfig(slices.Concat([]LookupOption{WithThreshold(math.Inf(-1))}, opts))
+	if err != nil {
+		return nil, err
+	}
+	target := c
+	if cfg.namespace != "" {
+		target = c.Namespace(cfg.namespace)
+	}
+	embeddings, err := c.embedTexts(ctx, queries)
+	if err != nil {
+		return nil, err
+	}
+	rankings := make([][]candidate[K], len(embeddings))
+	for i, query := range embeddings {
+		if rankings[i], err = target.rank(ctx, query, cfg); err != nil {
+			return nil, err
+		}
+	}
+	fused := fuseRRF(DefaultRRFK, rankings...)
+	sort.SliceStable(fused, func(i, j int) bool {
+		return fused[i].score > fused[j].score
+	})
+	matches, _, err := target.collect(ctx, fused, cfg, cfg.offset, n)
+	return matches, err
+}
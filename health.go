@@ -0,0 +1,74 @@
+package semanticcache
+
+import (
+	"context"
+	"time"
+)
+
+// healthText is the text HealthCheck embeds to ping the provider.
+const healthText = "semanticcache health check"
+
+// HealthReport is the result of HealthCheck.
+type HealthReport struct {
+	// Ready is set when both the provider and the backend are healthy, so
+	// the cache can serve lookups and writes.
+	Ready bool `json:"ready"`
+
+	// Live is set when the backend is healthy. Reads by key, and lookups
+	// answered by options.WithExactFallback, still work while the provider
+	// is down.
+	Live bool `json:"live"`
+
+	Provider ComponentHealth `json:"provider"`
+	Backend  ComponentHealth `json:"backend"`
+
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// ComponentHealth is the outcome of one HealthCheck probe.
+type ComponentHealth struct {
+	Healthy bool          `json:"healthy"`
+	Latency time.Duration `json:"latency"`
+	Error   string        `json:"error,omitempty"`
+
+	// Err is the probe's error, for errors.Is; nil when Healthy.
+	Err error `json:"-"`
+}
+
+func newComponentHealth(start time.Time, err error) ComponentHealth {
+	h := ComponentHealth{Healthy: err == nil, Latency: time.Since(start), Err: err}
+	if err != nil {
+		h.Error = err.Error()
+	}
+	return h
+}
+
+// HealthCheck probes the provider and the backend for readiness and
+// liveness endpoints. It embeds a short text, which fails at once with
+// ErrCircuitOpen while options.WithCircuitBreaker has the circuit open,
+// and it asks the backend whether it holds the zero key, which Set
+// rejects. That is one key lookup on every backend, such as EXISTS on
+// Redis, never a scan. Neither probe writes to the backend, so a check
+// never evicts an entry or fires an eviction callback. The probes run one
+// after the other under ctx.
+func (c *Cache[K, V]) HealthCheck(ctx context.Context) HealthReport {
+	report := HealthReport{CheckedAt: time.Now()}
+	if err := c.begin(); err != nil {
+		report.Provider = newComponentHealth(report.CheckedAt, err)
+		report.Backend = report.Provider
+		return report
+	}
+	defer c.end()
+
+	start := time.Now()
+	_, err := c.embedWhole(ctx, healthText)
+	report.Provider = newComponentHealth(start, err)
+
+	start = time.Now()
+	_, err = c.backend.Contains(ctx, *new(K))
+	report.Backend = newComponentHealth(start, err)
+
+	report.Live = report.Backend.Healthy
+	report.Ready = report.Live && report.Provider.Healthy
+	return report
+}
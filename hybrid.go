@@ -0,0 +1,140 @@
+package semanticcache
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// DefaultRRFK is the rank offset WithHybridRRF uses when given k <= 0.
+// Larger values flatten the difference between top ranks.
+const DefaultRRFK = 60
+
+// hybridConfig selects how a hybrid lookup fuses vector and BM25 scores.
+type hybridConfig struct {
+	vector, lexical float64 // weights, when rrf is 0
+	rrf             int     // reciprocal-rank-fusion offset; 0 fuses by weight
+}
+
+// WithHybrid fuses the vector score with the BM25 score of the query's
+// terms against stored input texts, for keyword-heavy queries where
+// embeddings underperform. A match's Score becomes
+// vector*similarity + lexical*bm25/best, where best is the top BM25 score
+// of the lookup, and thresholds apply to it. Needs
+// options.WithLexicalIndex; otherwise lookups return ErrHybridUnsupported.
+func WithHybrid(vector, lexical float64) LookupOption {
+	return func(c *lookupConfig) { c.hybrid = &hybridConfig{vector: vector, lexical: lexical} }
+}
+
+// WithHybridRRF is WithHybrid, but fuses the vector and BM25 rankings by
+// reciprocal rank fusion: a match scores 1/(k+rank) in each ranking it
+// appears in, so scores are at most 2/(k+1) and thresholds should be
+// set accordingly. k <= 0 uses DefaultRRFK.
+func WithHybridRRF(k int) LookupOption {
+	if k <= 0 {
+		k = DefaultRRFK
+	}
+	return func(c *lookupConfig) { c.hybrid = &hybridConfig{rrf: k} }
+}
+
+// searchHybrid scores every entry by vector similarity, fuses those scores
+// with BM25 scores of inputText, and returns cfg's page of the matches at or
+// above cfg.threshold.
+func (c *Cache[K, V]) searchHybrid(ctx context.Context, inputText string, query []float64, cfg lookupConfig) ([]MatchEx[K, V], error) {
+	all := cfg
+	all.threshold = math.Inf(-1)
+	ranked, err := c.rank(ctx, query, all)
+	if err != nil {
+		return nil, err
+	}
+	lexical := c.lexical.score(c.namespace, inputText)
+
+	var fused []candidate[K]
+	if cfg.hybrid.rrf > 0 {
+		fused = fuseRRF(cfg.hybrid.rrf, ranked, rankLexical(lexical))
+	} else {
+		best := 0.0
+		for _, s := range lexical {
+			best = max(best, s)
+		}
+		fused = make([]candidate[K], len(ranked))
+		for i, cand := range ranked {
+			cand.score *= cfg.hybrid.vector
+			if best > 0 {
+				cand.score += cfg.hybrid.lexical * lexical[cand.key] / best
+			}
+			fused[i] = cand
+		}
+	}
+	c.pruneLexical(ctx, ranked, lexical)
+
+	matches := fused[:0]
+	for _, cand := range fused {
+		if cand.score >= cfg.threshold {
+			matches = append(matches, cand)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	page, _, err := c.collect(ctx, matches, cfg, cfg.offset, cfg.limit)
+	return page, err
+}
+
+// rankLexical orders the keys of BM25 scores best first.
+func rankLexical[K comparable](scores map[K]float64) []candidate[K] {
+	ranked := make([]candidate[K], 0, len(scores))
+	for key, s := range scores {
+		ranked = append(ranked, candidate[K]{key: key, score: s})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	return ranked
+}
+
+// fuseRRF merges best-first rankings by reciprocal rank fusion: each key
+// scores the sum of 1/(k+rank) over the rankings it appears in, with ranks
+// starting at 1. A key keeps the vector index of its first appearance.
+func fuseRRF[K comparable](k int, rankings ...[]candidate[K]) []candidate[K] {
+	index := make(map[K]int)
+	var fused []candidate[K]
+	for _, ranking := range rankings {
+		for rank, cand := range ranking {
+			i, ok := index[cand.key]
+			if !ok {
+				i = len(fused)
+				index[cand.key] = i
+				fused = append(fused, candidate[K]{key: cand.key, vector: cand.vector})
+			}
+			fused[i].score += 1 / float64(k+rank+1)
+		}
+	}
+	return fused
+}
+
+// pruneLexical removes indexed texts whose entries are gone from the
+// backend, such as evicted or expired ones. A key the scan did not rank may
+// only have failed to load, been filtered out, or be stale, so it is
+// removed only once Contains confirms it is missing. Stale entries about
+// to be re-embedded are missing from the scan too, so nothing is pruned
+// when lookups re-embed.
+func (c *Cache[K, V]) pruneLexical(ctx context.Context, ranked []candidate[K], lexical map[K]float64) {
+	if c.refresh.onLookup || len(lexical) == 0 {
+		return
+	}
+	live := make(map[K]bool, len(ranked))
+	for _, cand := range ranked {
+		live[cand.key] = true
+	}
+	var gone []K
+	for key := range lexical {
+		if live[key] {
+			continue
+		}
+		if ok, err := c.backend.Contains(ctx, key); err == nil && !ok {
+			gone = append(gone, key)
+		}
+	}
+	c.lexical.remove(c.namespace, gone...)
+}
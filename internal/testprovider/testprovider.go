@@ -0,0 +1,45 @@
+// Package testprovider provides embedding providers for tests.
+package testprovider
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"sync/atomic"
+)
+
+// dims is the dimension of Words embeddings.
+const dims = 64
+
+// Words embeds a text as the bag of its lower-cased words, hashed into 64
+// dimensions, so texts sharing no words are orthogonal and rewordings that
+// keep the words score 1. Unlike providers/local, it tells short unrelated
+// texts apart, which is what tests of thresholds need.
+type Words struct{}
+
+// EmbedText implements types.EmbeddingProvider.
+func (Words) EmbedText(_ context.Context, text string) ([]float64, error) {
+	v := make([]float64, dims)
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(w))
+		v[h.Sum32()%dims]++
+	}
+	return v, nil
+}
+
+// Close implements types.EmbeddingProvider.
+func (Words) Close() error { return nil }
+
+// Counting is Words counting its EmbedText calls, for tests that a text is
+// embedded only once.
+type Counting struct {
+	Words
+	Calls atomic.Int32
+}
+
+// EmbedText implements types.EmbeddingProvider.
+func (p *Counting) EmbedText(ctx context.Context, text string) ([]float64, error) {
+	p.Calls.Add(1)
+	return p.Words.EmbedText(ctx, text)
+}
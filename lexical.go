@@ -0,0 +1,276 @@
+package semanticcache
+
+import (
+	"context"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// BM25 parameters: term-frequency saturation and length normalization.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// lexicalIndex is an in-memory BM25 index over the input texts written
+// through the cache, one corpus per namespace, for hybrid lookups. Deletes
+// and flushes through the cache remove texts, and so do evictions by a
+// backend that implements types.EvictionNotifier. Entries that leave the
+// backend otherwise are pruned by the next hybrid lookup that misses them,
+// or by the sweeper set by options.WithLexicalSweep. A nil *lexicalIndex
+// is disabled.
+type lexicalIndex[K comparable] struct {
+	mu     sync.RWMutex
+	spaces map[string]*lexicalSpace[K]
+}
+
+// lexicalSpace is one namespace's corpus.
+type lexicalSpace[K comparable] struct {
+	docs     map[K]lexicalDoc
+	postings map[string]map[K]int // term -> key -> term frequency
+	total    int                  // sum of document lengths
+}
+
+// lexicalDoc records what remove must undo.
+type lexicalDoc struct {
+	terms  []string // distinct
+	length int
+}
+
+func newLexicalIndex[K comparable](enabled bool) *lexicalIndex[K] {
+	if !enabled {
+		return nil
+	}
+	return &lexicalIndex[K]{spaces: make(map[string]*lexicalSpace[K])}
+}
+
+// tokenize lowercases text and splits it into runs of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// add indexes inputText as key's document, replacing any earlier one.
+func (x *lexicalIndex[K]) add(namespace string, key K, inputText string) {
+	if x == nil {
+		return
+	}
+	terms := tokenize(inputText)
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	s := x.spaces[namespace]
+	if s == nil {
+		s = &lexicalSpace[K]{docs: make(map[K]lexicalDoc), postings: make(map[string]map[K]int)}
+		x.spaces[namespace] = s
+	}
+	s.remove(key)
+	doc := lexicalDoc{length: len(terms)}
+	for _, term := range terms {
+		p := s.postings[term]
+		if p == nil {
+			p = make(map[K]int)
+			s.postings[term] = p
+		}
+		if p[key] == 0 {
+			doc.terms = append(doc.terms, term)
+		}
+		p[key]++
+	}
+	s.docs[key] = doc
+	s.total += doc.length
+}
+
+// remove drops the documents of keys.
+func (x *lexicalIndex[K]) remove(namespace string, keys ...K) {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if s := x.spaces[namespace]; s != nil {
+		for _, key := range keys {
+			s.remove(key)
+		}
+	}
+}
+
+// clear drops namespace's corpus, or every corpus when all is set.
+func (x *lexicalIndex[K]) clear(namespace string, all bool) {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if all {
+		clear(x.spaces)
+	} else {
+		delete(x.spaces, namespace)
+	}
+}
+
+// clearNested drops the corpora of the namespaces nested in namespace.
+func (x *lexicalIndex[K]) clearNested(namespace string) {
+	if x == nil {
+		return
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	maps.DeleteFunc(x.spaces, func(ns string, _ *lexicalSpace[K]) bool {
+		return strings.HasPrefix(ns, namespace+"/")
+	})
+}
+
+func (s *lexicalSpace[K]) remove(key K) {
+	doc, ok := s.docs[key]
+	if !ok {
+		return
+	}
+	delete(s.docs, key)
+	s.total -= doc.length
+	for _, term := range doc.terms {
+		p := s.postings[term]
+		delete(p, key)
+		if len(p) == 0 {
+			delete(s.postings, term)
+		}
+	}
+}
+
+// score returns the BM25 score of every document in namespace that shares
+// a term with query.
+func (x *lexicalIndex[K]) score(namespace, query string) map[K]float64 {
+	scores := make(map[K]float64)
+	if x == nil {
+		return scores
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	s := x.spaces[namespace]
+	if s == nil || len(s.docs) == 0 {
+		return scores
+	}
+	n := float64(len(s.docs))
+	avg := float64(s.total) / n
+	seen := make(map[string]bool)
+	for _, term := range tokenize(query) {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		p := s.postings[term]
+		df := float64(len(p))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for key, tf := range p {
+			f := float64(tf)
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(s.docs[key].length)/max(avg, 1))
+			scores[key] += idf * f * (bm25K1 + 1) / (f + norm)
+		}
+	}
+	return scores
+}
+
+// keys returns the keys indexed in each namespace.
+func (x *lexicalIndex[K]) keys() map[string][]K {
+	if x == nil {
+		return nil
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	out := make(map[string][]K, len(x.spaces))
+	for ns, s := range x.spaces {
+		keys := make([]K, 0, len(s.docs))
+		for key := range s.docs {
+			keys = append(keys, key)
+		}
+		out[ns] = keys
+	}
+	return out
+}
+
+// bytes estimates the memory held by the index. Terms are counted once per
+// posting list, since documents share their strings.
+func (x *lexicalIndex[K]) bytes() int64 {
+	if x == nil {
+		return 0
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	var size int64
+	for ns, s := range x.spaces {
+		size += mapSlotSize + int64(len(ns))
+		for _, doc := range s.docs {
+			size += mapSlotSize + keySize[K]() + int64(cap(doc.terms))*stringHeaderSize
+		}
+		for term, postings := range s.postings {
+			size += mapSlotSize + stringHeaderSize + int64(len(term))
+			size += int64(len(postings)) * (mapSlotSize + keySize[K]())
+		}
+	}
+	return size
+}
+
+// namespaces returns the namespaces with a corpus.
+func (x *lexicalIndex[K]) namespaces() []string {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return slices.Collect(maps.Keys(x.spaces))
+}
+
+// pruneEvicted drops the document of an entry the backend evicted, in
+// whichever namespace stored it under key. It runs as the backend's
+// eviction callback, so it must not call the backend.
+func (c *Cache[K, V]) pruneEvicted(key K, entry types.Entry[V]) {
+	if c.lexical == nil {
+		return
+	}
+	tag, tagged := entry.Metadata[NamespaceMetadataKey]
+	for _, ns := range c.lexical.namespaces() {
+		switch nb := newNamespacedBackend[K, V](nil, ns); {
+		case ns == "":
+			if !tagged {
+				c.lexical.remove(ns, key)
+			}
+		case nb.prefix == "":
+			if tag == ns {
+				c.lexical.remove(ns, key)
+			}
+		default:
+			if k, ok := nb.unwrap(key); ok {
+				c.lexical.remove(ns, k)
+			}
+		}
+	}
+}
+
+// sweepLexical drops the documents of entries no longer in the backend,
+// checking every indexed key with Contains, paced by lim.
+func (c *Cache[K, V]) sweepLexical(ctx context.Context, lim *limiter) error {
+	for ns, keys := range c.lexical.keys() {
+		view := c
+		if ns != "" {
+			view = c.scope(ns)
+		}
+		var gone []K
+		for _, key := range keys {
+			if err := lim.wait(ctx, 1); err != nil {
+				return err
+			}
+			ok, err := view.backend.Contains(ctx, key)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				gone = append(gone, key)
+			}
+		}
+		c.lexical.remove(ns, gone...)
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+// Package anthropic caches Anthropic Messages API responses semantically.
+// A Client wraps the anthropic-sdk-go messages service: before each call
+// it looks the conversation's latest user turn up in a
+// semanticcache.Cache, answers a close enough match from the cache, and
+// stores the API's response otherwise, including streamed responses once
+// reassembled.
+//
+//	client := anthropic.NewClient()
+//	cache, _ := semanticcache.New(
+//		options.WithRedisBackend[string, json.RawMessage]("localhost:6379"),
+//		options.WithOpenAIProvider[string, json.RawMessage](apiKey),
+//	)
+//	llm := cacheanthropic.New(&client.Messages, cache, cacheanthropic.WithThreshold(0.95))
+//	message, err := llm.New(ctx, params)
+//
+// Entries are namespaced by the system prompt, model, and the other
+// parameters apart from the messages, so a turn only matches turns asked
+// under the same system prompt and settings. Requests whose last message
+// is not a user turn with content to key on, such as tool results or an
+// assistant prefill, go to the API uncached.
+package anthropic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/anthropics/anthropic-sdk-go/packages/ssestream"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/llmcache"
+)
+
+// Messages is the part of anthropic.MessageService a Client calls; pass
+// &client.Messages.
+type Messages interface {
+	New(ctx context.Context, body anthropic.MessageNewParams, opts ...option.RequestOption) (*anthropic.Message, error)
+	NewStreaming(ctx context.Context, body anthropic.MessageNewParams, opts ...option.RequestOption) *ssestream.Stream[anthropic.MessageStreamEventUnion]
+}
+
+// Option configures a Client.
+type Option func(*config)
+
+type config struct {
+	threshold    *float64
+	ttl          time.Duration
+	conversation bool
+	logger       *slog.Logger
+}
+
+// WithThreshold sets the similarity a stored user turn must reach to
+// answer a new one. Defaults to the cache's threshold.
+func WithThreshold(threshold float64) Option {
+	return func(c *config) { c.threshold = &threshold }
+}
+
+// WithTTL serves stored messages for at most ttl after they were stored.
+// Where the backend supports per-key expiry, entries are also set to
+// expire then. A ttl of 0, the default, keeps them until evicted.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) { c.ttl = ttl }
+}
+
+// WithConversationScope adds the messages before the latest user turn to
+// the namespace, so a turn only matches turns that follow the same
+// conversation exactly. By default a turn matches regardless of the
+// history before it.
+func WithConversationScope() Option {
+	return func(c *config) { c.conversation = true }
+}
+
+// WithLogger logs lookups and writes that fail, which the Client
+// otherwise treats as misses. Without it nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) {
+		if l != nil {
+			c.logger = l
+		}
+	}
+}
+
+// Client is a caching Messages API client. It is safe for concurrent use.
+type Client struct {
+	messages Messages
+	cache    *semanticcache.Cache[string, json.RawMessage]
+	cfg      config
+
+	requests, hits, stores, errs atomic.Uint64
+	savedTokens                  atomic.Int64
+}
+
+// New returns a Client calling messages on a miss and storing the
+// responses, as their JSON, in cache. It does not close cache.
+func New(messages Messages, cache *semanticcache.Cache[string, json.RawMessage], opts ...Option) *Client {
+	c := &Client{messages: messages, cache: cache, cfg: config{logger: slog.New(slog.DiscardHandler)}}
+	for _, o := range opts {
+		o(&c.cfg)
+	}
+	return c
+}
+
+// Stats returns the Client's counters. SavedTokens counts the input and
+// output tokens of the messages served from the cache.
+func (c *Client) Stats() llmcache.Stats {
+	return llmcache.Stats{
+		Requests:    c.requests.Load(),
+		Hits:        c.hits.Load(),
+		Stores:      c.stores.Load(),
+		Errors:      c.errs.Load(),
+		SavedTokens: c.savedTokens.Load(),
+	}
+}
+
+// New returns a cached message for params, or calls the API and stores
+// its response. Responses that hit max_tokens, were refused, or paused
+// are not stored.
+func (c *Client) New(ctx context.Context, params anthropic.MessageNewParams, opts ...option.RequestOption) (*anthropic.Message, error) {
+	c.requests.Add(1)
+	req, ok := c.request(params)
+	if ok {
+		if message, ok := c.lookup(ctx, &req); ok {
+			return message, nil
+		}
+	}
+	message, err := c.messages.New(ctx, params, opts...)
+	if err != nil || !ok {
+		return message, err
+	}
+	c.store(context.WithoutCancel(ctx), req, message)
+	return message, nil
+}
+
+// request is a Messages request's place in the cache.
+type request struct {
+	cache *semanticcache.Cache[string, json.RawMessage]
+	text  string
+	query []float64 // text's embedding, once lookup has computed it
+}
+
+// request returns where params is cached. ok is false for a request
+// without a user turn to key on.
+func (c *Client) request(params anthropic.MessageNewParams) (request, bool) {
+	if len(params.Messages) == 0 {
+		return request{}, false
+	}
+	text, ok := UserTurnText(params.Messages[len(params.Messages)-1])
+	if !ok {
+		return request{}, false
+	}
+	ns, err := c.namespace(params)
+	if err != nil {
+		c.cfg.logger.Warn("anthropic cache: cannot hash request parameters", "error", err)
+		c.errs.Add(1)
+		return request{}, false
+	}
+	return request{cache: c.cache.Namespace("anthropic:" + ns), text: text}, true
+}
+
+// namespace hashes the system prompt's text and the parameters that
+// change the response: all but the messages, streaming, and metadata.
+// With WithConversationScope, the earlier messages are hashed too.
+func (c *Client) namespace(params anthropic.MessageNewParams) (string, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+	for _, name := range []string{"messages", "stream", "metadata", "system"} {
+		delete(fields, name)
+	}
+	// Only the system prompt's text counts, so moving a cache_control
+	// breakpoint keeps the namespace.
+	system := make([]string, len(params.System))
+	for i, block := range params.System {
+		system[i] = block.Text
+	}
+	if fields["system"], err = json.Marshal(strings.Join(system, "\n")); err != nil {
+		return "", err
+	}
+	if c.cfg.conversation {
+		if fields["messages"], err = json.Marshal(params.Messages[:len(params.Messages)-1]); err != nil {
+			return "", err
+		}
+	}
+	if data, err = json.Marshal(fields); err != nil { // map keys marshal sorted
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// lookup returns the stored message best matching req, if fresh, and keeps
+// the query embedding in req for store.
+func (c *Client) lookup(ctx context.Context, req *request) (*anthropic.Message, bool) {
+	opts := []semanticcache.LookupOption{semanticcache.WithLimit(1), semanticcache.WithQueryEmbedding(&req.query)}
+	if c.cfg.threshold != nil {
+		opts = append(opts, semanticcache.WithThreshold(*c.cfg.threshold))
+	}
+	matches, err := req.cache.LookupWithOptions(ctx, req.text, opts...)
+	if err != nil {
+		c.cfg.logger.WarnContext(ctx, "anthropic cache: lookup failed", "error", err)
+		c.errs.Add(1)
+		return nil, false
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+	m := matches[0]
+	if c.cfg.ttl > 0 && !m.CreatedAt.IsZero() && time.Since(m.CreatedAt) > c.cfg.ttl {
+		return nil, false
+	}
+	var message anthropic.Message
+	if err := json.Unmarshal(m.Value, &message); err != nil {
+		c.cfg.logger.WarnContext(ctx, "anthropic cache: stored message is invalid", "error", err)
+		c.errs.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	c.savedTokens.Add(message.Usage.InputTokens + message.Usage.OutputTokens)
+	return &message, true
+}
+
+// store saves message under req if it finished normally.
+func (c *Client) store(ctx context.Context, req request, message *anthropic.Message) {
+	if message == nil {
+		return
+	}
+	switch message.StopReason {
+	case anthropic.StopReasonEndTurn, anthropic.StopReasonStopSequence, anthropic.StopReasonToolUse:
+	default:
+		return
+	}
+	value := json.RawMessage(message.RawJSON())
+	if len(value) == 0 {
+		var err error
+		if value, err = json.Marshal(message); err != nil {
+			c.storeFailed(ctx, err)
+			return
+		}
+	}
+	key := semanticcache.TextKey(req.text)
+	var err error
+	if req.query != nil {
+		err = req.cache.SetWithEmbedding(ctx, key, req.text, req.query, value)
+	} else {
+		_, err = req.cache.SetText(ctx, req.text, value)
+	}
+	if err == nil && c.cfg.ttl > 0 {
+		if _, err = req.cache.Expire(ctx, key, c.cfg.ttl); errors.Is(err, semanticcache.ErrExpiryUnsupported) {
+			err = nil
+		}
+	}
+	if err != nil {
+		c.storeFailed(ctx, err)
+		return
+	}
+	c.stores.Add(1)
+}
+
+func (c *Client) storeFailed(ctx context.Context, err error) {
+	c.cfg.logger.WarnContext(ctx, "anthropic cache: store failed", "error", err)
+	c.errs.Add(1)
+}
+
+// UserTurnText returns the text a Client keys msg on: its text blocks
+// joined, with other blocks, such as images and documents, as their JSON
+// and runs of whitespace collapsed. ok is false unless msg is a user turn
+// with content and without tool results.
+func UserTurnText(msg anthropic.MessageParam) (string, bool) {
+	if msg.Role != anthropic.MessageParamRoleUser {
+		return "", false
+	}
+	parts := make([]string, 0, len(msg.Content))
+	for _, block := range msg.Content {
+		switch {
+		case block.OfToolResult != nil:
+			return "", false
+		case block.OfText != nil:
+			parts = append(parts, block.OfText.Text)
+		default:
+			data, err := json.Marshal(block)
+			if err != nil {
+				return "", false
+			}
+			parts = append(parts, string(data))
+		}
+	}
+	text := strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+	return text, text != ""
+}
@@ -0,0 +1,218 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/internal/testprovider"
+	"github.com/botirk38/semanticcache/llmcache"
+	"github.com/botirk38/semanticcache/options"
+	"github.com/botirk38/semanticcache/types"
+)
+
+// newTestClient returns a Client over a fake API answering every request
+// with "answer N", N counting the API calls.
+func newTestClient(t *testing.T, opts ...Option) (*Client, *atomic.Int32) {
+	t.Helper()
+	return newTestClientWith(t, testprovider.Words{}, opts...)
+}
+
+// newTestClientWith is newTestClient embedding with provider.
+func newTestClientWith(t *testing.T, provider types.EmbeddingProvider, opts ...Option) (*Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		content := fmt.Sprintf("answer %d", n)
+		if !body.Stream {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id":"msg_%d","type":"message","role":"assistant","model":"claude-test",`+
+				`"content":[{"type":"text","text":%q}],"stop_reason":"end_turn","stop_sequence":null,`+
+				`"usage":{"input_tokens":5,"output_tokens":2}}`, n, content)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		event := func(typ, data string) { fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typ, data) }
+		event("message_start", fmt.Sprintf(`{"type":"message_start","message":{"id":"msg_%d","type":"message","role":"assistant",`+
+			`"model":"claude-test","content":[],"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":5,"output_tokens":0}}}`, n))
+		event("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`)
+		for _, part := range strings.SplitAfter(content, " ") {
+			event("content_block_delta", fmt.Sprintf(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":%q}}`, part))
+		}
+		event("content_block_stop", `{"type":"content_block_stop","index":0}`)
+		event("message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn","stop_sequence":null},"usage":{"output_tokens":2}}`)
+		event("message_stop", `{"type":"message_stop"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	cache, err := semanticcache.New(
+		options.WithLRUBackend[string, json.RawMessage](100),
+		options.WithCustomProvider[string, json.RawMessage](provider),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+
+	client := anthropic.NewClient(option.WithBaseURL(srv.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	return New(&client.Messages, cache, opts...), &calls
+}
+
+func params(system string, messages ...anthropic.MessageParam) anthropic.MessageNewParams {
+	p := anthropic.MessageNewParams{Model: "claude-test", MaxTokens: 100, Messages: messages}
+	if system != "" {
+		p.System = []anthropic.TextBlockParam{{Text: system}}
+	}
+	return p
+}
+
+func user(text string) anthropic.MessageParam {
+	return anthropic.NewUserMessage(anthropic.NewTextBlock(text))
+}
+
+func text(t *testing.T, m *anthropic.Message) string {
+	t.Helper()
+	if len(m.Content) == 0 {
+		t.Fatal("message has no content")
+	}
+	return m.Content[0].Text
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	llm, calls := newTestClient(t)
+
+	first, err := llm.New(ctx, params("Be brief.", user("What is Go?")))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	// Only the latest user turn is keyed, so earlier history does not matter.
+	history := params("Be brief.", user("Hi"), anthropic.NewAssistantMessage(anthropic.NewTextBlock("Hello!")), user("what  is go?"))
+	second, err := llm.New(ctx, history)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if calls.Load() != 1 || text(t, second) != text(t, first) {
+		t.Errorf("second call = %q after %d API calls, want the cached %q", text(t, second), calls.Load(), text(t, first))
+	}
+
+	// Another system prompt is another namespace.
+	if _, err := llm.New(ctx, params("Be verbose.", user("What is Go?"))); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("other system prompt made %d API calls in total, want 2", calls.Load())
+	}
+
+	// Tool results are never served from the cache.
+	toolResult := params("Be brief.", anthropic.NewUserMessage(anthropic.NewToolResultBlock("tool_1", "42", false)))
+	if _, err := llm.New(ctx, toolResult); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("tool result made %d API calls in total, want 3", calls.Load())
+	}
+
+	want := llmcache.Stats{Requests: 4, Hits: 1, Stores: 2, SavedTokens: 7}
+	if got := llm.Stats(); got != want {
+		t.Errorf("Stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestClientConversationScope(t *testing.T) {
+	ctx := context.Background()
+	llm, calls := newTestClient(t, WithConversationScope())
+	for _, p := range []anthropic.MessageNewParams{
+		params("", user("What is Go?")),
+		params("", user("Hi"), anthropic.NewAssistantMessage(anthropic.NewTextBlock("Hello!")), user("What is Go?")),
+	} {
+		if _, err := llm.New(ctx, p); err != nil {
+			t.Fatalf("New: %v", err)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("different histories made %d API calls, want 2", calls.Load())
+	}
+}
+
+func TestClientStreaming(t *testing.T) {
+	ctx := context.Background()
+	llm, calls := newTestClient(t)
+	ask := params("Be brief.", user("Tell me about channels"))
+
+	read := func() (message anthropic.Message, deltas string) {
+		t.Helper()
+		stream := llm.NewStreaming(ctx, ask)
+		defer stream.Close()
+		for stream.Next() {
+			ev := stream.Current()
+			if err := message.Accumulate(ev); err != nil {
+				t.Fatalf("Accumulate: %v", err)
+			}
+			if d, ok := ev.AsAny().(anthropic.ContentBlockDeltaEvent); ok {
+				deltas += d.Delta.Text
+			}
+		}
+		if err := stream.Err(); err != nil {
+			t.Fatalf("stream: %v", err)
+		}
+		return message, deltas
+	}
+	first, _ := read()
+	if text(t, &first) != "answer 1" {
+		t.Fatalf("streamed text = %q, want %q", text(t, &first), "answer 1")
+	}
+	second, deltas := read()
+	if text(t, &second) != "answer 1" || deltas != "answer 1" || second.StopReason != anthropic.StopReasonEndTurn || calls.Load() != 1 {
+		t.Errorf("replay = %q (deltas %q, stop %q) after %d API calls, want the cached message", text(t, &second), deltas, second.StopReason, calls.Load())
+	}
+
+	// A streamed response is served to a non-streamed request too.
+	message, err := llm.New(ctx, ask)
+	if err != nil || text(t, message) != "answer 1" || calls.Load() != 1 {
+		t.Errorf("New after streaming = %v, %v after %d API calls", message, err, calls.Load())
+	}
+}
+
+func TestClientEmbedsOnce(t *testing.T) {
+	ctx := context.Background()
+	provider := &testprovider.Counting{}
+	llm, _ := newTestClientWith(t, provider)
+
+	if _, err := llm.New(ctx, params("", user("What is Go?"))); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if n := provider.Calls.Load(); n != 1 {
+		t.Errorf("a miss embedded the user turn %d times, want 1", n)
+	}
+	if got := llm.Stats().Stores; got != 1 {
+		t.Errorf("Stores = %d, want 1", got)
+	}
+}
+
+func TestUserTurnText(t *testing.T) {
+	msg := anthropic.NewUserMessage(anthropic.NewTextBlock("What is"), anthropic.NewTextBlock("  Go?\n"))
+	if got, ok := UserTurnText(msg); !ok || got != "What is Go?" {
+		t.Errorf("UserTurnText = %q, %v", got, ok)
+	}
+	if _, ok := UserTurnText(anthropic.NewAssistantMessage(anthropic.NewTextBlock("Hi"))); ok {
+		t.Error("UserTurnText accepted an assistant message")
+	}
+	if _, ok := UserTurnText(anthropic.NewUserMessage(anthropic.NewTextBlock(" "))); ok {
+		t.Error("UserTurnText accepted a blank message")
+	}
+}
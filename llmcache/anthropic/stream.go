@@ -0,0 +1,171 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/anthropics/anthropic-sdk-go/packages/ssestream"
+)
+
+// NewStreaming is New for streamed messages. A hit is replayed as the
+// events of a stream: each text block as one text delta, and other
+// blocks whole in their start event. On a miss the API's events pass
+// through as they arrive, and once the stream ends without error the
+// accumulated message is stored; a stream closed early is not.
+func (c *Client) NewStreaming(ctx context.Context, params anthropic.MessageNewParams, opts ...option.RequestOption) *ssestream.Stream[anthropic.MessageStreamEventUnion] {
+	c.requests.Add(1)
+	req, ok := c.request(params)
+	if ok {
+		if message, ok := c.lookup(ctx, &req); ok {
+			events, err := replayEvents(message)
+			return ssestream.NewStream[anthropic.MessageStreamEventUnion](&replay{events: events}, err)
+		}
+	}
+	stream := c.messages.NewStreaming(ctx, params, opts...)
+	if !ok {
+		return stream
+	}
+	return ssestream.NewStream[anthropic.MessageStreamEventUnion](&recorder{
+		client: c,
+		ctx:    context.WithoutCancel(ctx),
+		req:    req,
+		stream: stream,
+	}, nil)
+}
+
+// recorder is an ssestream.Decoder passing an upstream stream's events
+// through while accumulating them, and storing the message at its end.
+type recorder struct {
+	client *Client
+	ctx    context.Context
+	req    request
+	stream *ssestream.Stream[anthropic.MessageStreamEventUnion]
+	acc    anthropic.Message
+	event  ssestream.Event
+	err    error
+}
+
+func (r *recorder) Next() bool {
+	if !r.stream.Next() {
+		if r.stream.Err() == nil && r.err == nil {
+			r.client.store(r.ctx, r.req, &r.acc)
+		}
+		return false
+	}
+	ev := r.stream.Current()
+	if r.err = r.acc.Accumulate(ev); r.err != nil {
+		return false
+	}
+	data := []byte(ev.RawJSON())
+	if len(data) == 0 {
+		if data, r.err = json.Marshal(ev); r.err != nil {
+			return false
+		}
+	}
+	r.event = ssestream.Event{Type: ev.Type, Data: data}
+	return true
+}
+
+func (r *recorder) Event() ssestream.Event { return r.event }
+
+func (r *recorder) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.stream.Err()
+}
+
+func (r *recorder) Close() error { return r.stream.Close() }
+
+// replay is an ssestream.Decoder yielding stored events.
+type replay struct {
+	events []ssestream.Event
+	cur    ssestream.Event
+}
+
+func (r *replay) Next() bool {
+	if len(r.events) == 0 {
+		return false
+	}
+	r.cur, r.events = r.events[0], r.events[1:]
+	return true
+}
+
+func (r *replay) Event() ssestream.Event { return r.cur }
+func (r *replay) Err() error             { return nil }
+func (r *replay) Close() error           { return nil }
+
+// replayEvents returns the stream events that reassemble into message.
+func replayEvents(message *anthropic.Message) ([]ssestream.Event, error) {
+	raw := []byte(message.RawJSON())
+	if len(raw) == 0 {
+		var err error
+		if raw, err = json.Marshal(message); err != nil {
+			return nil, err
+		}
+	}
+	var start map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &start); err != nil {
+		return nil, err
+	}
+	var blocks []json.RawMessage
+	if err := json.Unmarshal(start["content"], &blocks); err != nil {
+		return nil, err
+	}
+	start["content"] = json.RawMessage("[]")
+	start["stop_reason"] = json.RawMessage("null")
+	start["stop_sequence"] = json.RawMessage("null")
+
+	var events []ssestream.Event
+	add := func(typ string, v any) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		events = append(events, ssestream.Event{Type: typ, Data: data})
+		return nil
+	}
+	if err := add("message_start", map[string]any{"type": "message_start", "message": start}); err != nil {
+		return nil, err
+	}
+	for i, block := range blocks {
+		var text struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(block, &text); err != nil {
+			return nil, err
+		}
+		var err error
+		if text.Type == "text" {
+			err = add("content_block_start", map[string]any{"type": "content_block_start", "index": i,
+				"content_block": map[string]any{"type": "text", "text": ""}})
+			if err == nil {
+				err = add("content_block_delta", map[string]any{"type": "content_block_delta", "index": i,
+					"delta": map[string]any{"type": "text_delta", "text": text.Text}})
+			}
+		} else {
+			err = add("content_block_start", map[string]any{"type": "content_block_start", "index": i, "content_block": block})
+		}
+		if err == nil {
+			err = add("content_block_stop", map[string]any{"type": "content_block_stop", "index": i})
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	delta := map[string]any{
+		"type":  "message_delta",
+		"delta": map[string]any{"stop_reason": message.StopReason, "stop_sequence": message.StopSequence},
+		"usage": map[string]any{"output_tokens": message.Usage.OutputTokens},
+	}
+	if err := add("message_delta", delta); err != nil {
+		return nil, err
+	}
+	if err := add("message_stop", map[string]any{"type": "message_stop"}); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
@@ -0,0 +1,207 @@
+// Package langchaingo adds semantic caching to LangChainGo models. New
+// wraps an llms.Model the way LangChainGo's own cache.New does, so an app
+// switches by swapping that one constructor:
+//
+//	llm = langchaingo.New(llm, cache) // was cache.New(llm, inmemory.New(...))
+//
+// LangChainGo's cache.Backend interface only sees a hash of the messages,
+// which cannot match paraphrases, so the adapter wraps the model instead:
+// it looks the conversation's text up in a semanticcache.Cache before
+// calling the model and stores the model's response after.
+//
+// Calls are matched only against calls with the same options, such as
+// model, temperature, and tools: the options are hashed into the namespace
+// the entries are stored in.
+package langchaingo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/botirk38/semanticcache"
+)
+
+// Option configures a Model.
+type Option func(*config)
+
+type config struct {
+	threshold *float64
+	ttl       time.Duration
+	logger    *slog.Logger
+}
+
+// WithThreshold sets the similarity a stored conversation must reach to
+// answer a new one. Defaults to the cache's threshold.
+func WithThreshold(threshold float64) Option {
+	return func(c *config) { c.threshold = &threshold }
+}
+
+// WithTTL serves stored responses for at most ttl after they were stored.
+// Where the backend supports per-key expiry, entries are also set to
+// expire then. A ttl of 0, the default, keeps them until evicted.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) { c.ttl = ttl }
+}
+
+// WithLogger logs lookups and writes that fail, which the Model otherwise
+// treats as misses. Without it nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) {
+		if l != nil {
+			c.logger = l
+		}
+	}
+}
+
+// Model is an llms.Model answering calls from a semantic cache when it
+// can. It is safe for concurrent use if the wrapped model is.
+type Model struct {
+	llm   llms.Model
+	cache *semanticcache.Cache[string, json.RawMessage]
+	cfg   config
+}
+
+var _ llms.Model = (*Model)(nil)
+
+// New wraps llm, storing its responses, as their JSON, in cache. It does
+// not close cache.
+func New(llm llms.Model, cache *semanticcache.Cache[string, json.RawMessage], opts ...Option) *Model {
+	m := &Model{llm: llm, cache: cache, cfg: config{logger: slog.New(slog.DiscardHandler)}}
+	for _, o := range opts {
+		o(&m.cfg)
+	}
+	return m
+}
+
+// Call generates a response to a single prompt.
+//
+// Deprecated: as llms.Model's Call; use GenerateContent.
+func (m *Model) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// GenerateContent returns a cached response for messages, or calls the
+// wrapped model and stores its response. A cached response is passed to
+// the call's streaming function, if any, as one chunk of its first
+// choice, as LangChainGo's cache does.
+func (m *Model) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	var opts llms.CallOptions
+	for _, o := range options {
+		o(&opts)
+	}
+	text := MessagesText(messages)
+	ns, err := optionsHash(opts)
+	if strings.TrimSpace(text) == "" || err != nil {
+		return m.llm.GenerateContent(ctx, messages, options...)
+	}
+	cache := m.cache.Namespace("langchaingo:" + ns)
+
+	var query []float64
+	if resp, ok := m.lookup(ctx, cache, text, &query); ok {
+		if opts.StreamingFunc != nil && len(resp.Choices) > 0 {
+			if err := opts.StreamingFunc(ctx, []byte(resp.Choices[0].Content)); err != nil {
+				return nil, err
+			}
+		}
+		return resp, nil
+	}
+
+	resp, err := m.llm.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	m.store(context.WithoutCancel(ctx), cache, text, query, resp)
+	return resp, nil
+}
+
+// lookup returns the stored response best matching text, if fresh, setting
+// *query to text's embedding when it computed one.
+func (m *Model) lookup(ctx context.Context, cache *semanticcache.Cache[string, json.RawMessage], text string, query *[]float64) (*llms.ContentResponse, bool) {
+	opts := []semanticcache.LookupOption{semanticcache.WithLimit(1), semanticcache.WithQueryEmbedding(query)}
+	if m.cfg.threshold != nil {
+		opts = append(opts, semanticcache.WithThreshold(*m.cfg.threshold))
+	}
+	matches, err := cache.LookupWithOptions(ctx, text, opts...)
+	if err != nil {
+		m.cfg.logger.WarnContext(ctx, "langchaingo cache: lookup failed", "error", err)
+		return nil, false
+	}
+	if len(matches) == 0 || m.cfg.ttl > 0 && !matches[0].CreatedAt.IsZero() && time.Since(matches[0].CreatedAt) > m.cfg.ttl {
+		return nil, false
+	}
+	var resp llms.ContentResponse
+	if err := json.Unmarshal(matches[0].Value, &resp); err != nil {
+		m.cfg.logger.WarnContext(ctx, "langchaingo cache: stored response is invalid", "error", err)
+		return nil, false
+	}
+	return &resp, true
+}
+
+// store saves resp under text, reusing the lookup's embedding of text when
+// there is one.
+func (m *Model) store(ctx context.Context, cache *semanticcache.Cache[string, json.RawMessage], text string, query []float64, resp *llms.ContentResponse) {
+	if resp == nil || len(resp.Choices) == 0 {
+		return
+	}
+	value, err := json.Marshal(resp)
+	if err == nil {
+		key := semanticcache.TextKey(text)
+		if query != nil {
+			err = cache.SetWithEmbedding(ctx, key, text, query, value)
+		} else {
+			_, err = cache.SetText(ctx, text, value)
+		}
+		if err == nil && m.cfg.ttl > 0 {
+			if _, err = cache.Expire(ctx, key, m.cfg.ttl); errors.Is(err, semanticcache.ErrExpiryUnsupported) {
+				err = nil
+			}
+		}
+	}
+	if err != nil {
+		m.cfg.logger.WarnContext(ctx, "langchaingo cache: store failed", "error", err)
+	}
+}
+
+// optionsHash returns a hash of the call options; streaming functions are
+// left out, as they do not change the response.
+func optionsHash(opts llms.CallOptions) (string, error) {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// MessagesText renders messages as the Model keys them: one line per
+// message, "role: content", with runs of whitespace collapsed. Text parts
+// are joined; other parts, such as images and tool calls, are rendered as
+// their JSON, so they change the key exactly.
+func MessagesText(messages []llms.MessageContent) string {
+	var b strings.Builder
+	for _, msg := range messages {
+		parts := make([]string, 0, len(msg.Parts))
+		for _, p := range msg.Parts {
+			if t, ok := p.(llms.TextContent); ok {
+				parts = append(parts, t.Text)
+				continue
+			}
+			data, err := json.Marshal(p)
+			if err != nil {
+				data = []byte(fmt.Sprint(p))
+			}
+			parts = append(parts, string(data))
+		}
+		fmt.Fprintf(&b, "%s: %s\n", msg.Role, strings.Join(strings.Fields(strings.Join(parts, " ")), " "))
+	}
+	return b.String()
+}
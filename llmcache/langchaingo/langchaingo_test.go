@@ -0,0 +1,108 @@
+package langchaingo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/internal/testprovider"
+	"github.com/botirk38/semanticcache/options"
+)
+
+// countingModel answers "answer N", N counting its calls.
+type countingModel struct{ calls int }
+
+func (m *countingModel) GenerateContent(_ context.Context, _ []llms.MessageContent, _ ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.calls++
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: fmt.Sprintf("answer %d", m.calls), StopReason: "stop"}}}, nil
+}
+
+func (m *countingModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestModel(t *testing.T) {
+	ctx := context.Background()
+	cache, err := semanticcache.New(
+		options.WithLRUBackend[string, json.RawMessage](100),
+		options.WithCustomProvider[string, json.RawMessage](testprovider.Words{}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+	llm := &countingModel{}
+	model := New(llm, cache)
+
+	ask := func(prompt string, opts ...llms.CallOption) string {
+		t.Helper()
+		resp, err := model.GenerateContent(ctx, []llms.MessageContent{
+			llms.TextParts(llms.ChatMessageTypeSystem, "Be brief."),
+			llms.TextParts(llms.ChatMessageTypeHuman, prompt),
+		}, opts...)
+		if err != nil {
+			t.Fatalf("GenerateContent: %v", err)
+		}
+		return resp.Choices[0].Content
+	}
+
+	first := ask("What is Go?")
+	if got := ask("what  is go?"); got != first || llm.calls != 1 {
+		t.Errorf("second call = %q after %d model calls, want the cached %q", got, llm.calls, first)
+	}
+	if ask("What is Go?", llms.WithTemperature(0.9)); llm.calls != 2 {
+		t.Errorf("other options made %d model calls in total, want 2", llm.calls)
+	}
+
+	var streamed string
+	got := ask("What is Go?", llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		streamed += string(chunk)
+		return nil
+	}))
+	if got != first || streamed != first || llm.calls != 2 {
+		t.Errorf("streamed hit = %q, streamed %q after %d model calls; want %q", got, streamed, llm.calls, first)
+	}
+
+	if out, err := model.Call(ctx, "Tell me a joke"); err != nil || out != "answer 3" {
+		t.Errorf("Call = %q, %v", out, err)
+	}
+}
+
+func TestModelEmbedsOnce(t *testing.T) {
+	ctx := context.Background()
+	provider := &testprovider.Counting{}
+	cache, err := semanticcache.New(
+		options.WithLRUBackend[string, json.RawMessage](100),
+		options.WithCustomProvider[string, json.RawMessage](provider),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+	model := New(&countingModel{}, cache)
+
+	if _, err := model.Call(ctx, "What is Go?"); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if n := provider.Calls.Load(); n != 1 {
+		t.Errorf("a miss embedded the messages %d times, want 1", n)
+	}
+	if out, err := model.Call(ctx, "What is Go?"); err != nil || out != "answer 1" {
+		t.Errorf("second Call = %q, %v; want the stored answer", out, err)
+	}
+}
+
+func TestMessagesText(t *testing.T) {
+	got := MessagesText([]llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "Be\n  brief."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "What is", "Go?"),
+	})
+	want := "system: Be brief.\nhuman: What is Go?\n"
+	if got != want {
+		t.Errorf("MessagesText = %q, want %q", got, want)
+	}
+}
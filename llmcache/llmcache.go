@@ -0,0 +1,341 @@
+// Package llmcache caches OpenAI chat completions semantically. A Client
+// wraps the openai-go chat completions service: before each call it looks
+// the conversation up in a semanticcache.Cache, answers a close enough
+// match from the cache, and stores the API's response otherwise, including
+// streamed responses once reassembled.
+//
+//	client := openai.NewClient()
+//	cache, _ := semanticcache.New(
+//		options.WithRedisBackend[string, json.RawMessage]("localhost:6379"),
+//		options.WithOpenAIProvider[string, json.RawMessage](apiKey),
+//	)
+//	llm := llmcache.New(&client.Chat.Completions, cache, llmcache.WithThreshold(0.95))
+//	completion, err := llm.New(ctx, params)
+//
+// Requests are matched only against requests with the same model and
+// parameters apart from the messages: the other parameters are hashed
+// into the namespace the entries are stored in. The conversation is keyed
+// as its messages rendered one per line, "role: content", with whitespace
+// collapsed.
+package llmcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/option"
+	"github.com/openai/openai-go/v2/packages/ssestream"
+
+	"github.com/botirk38/semanticcache"
+)
+
+// Completions is the part of openai.ChatCompletionService a Client calls;
+// pass &client.Chat.Completions.
+type Completions interface {
+	New(ctx context.Context, body openai.ChatCompletionNewParams, opts ...option.RequestOption) (*openai.ChatCompletion, error)
+	NewStreaming(ctx context.Context, body openai.ChatCompletionNewParams, opts ...option.RequestOption) *ssestream.Stream[openai.ChatCompletionChunk]
+}
+
+// Option configures a Client.
+type Option func(*config)
+
+type config struct {
+	threshold *float64
+	ttl       time.Duration
+	text      func(openai.ChatCompletionNewParams) string
+	logger    *slog.Logger
+}
+
+// WithThreshold sets the similarity a stored conversation must reach to
+// answer a new one. Defaults to the cache's threshold.
+func WithThreshold(threshold float64) Option {
+	return func(c *config) { c.threshold = &threshold }
+}
+
+// WithTTL serves stored completions for at most ttl after they were
+// stored. Where the backend supports per-key expiry, entries are also set
+// to expire then. A ttl of 0, the default, keeps them until evicted.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) { c.ttl = ttl }
+}
+
+// WithKeyText keys requests on the text fn returns instead of the whole
+// conversation, for example only the latest user message. An empty text
+// bypasses the cache.
+func WithKeyText(fn func(openai.ChatCompletionNewParams) string) Option {
+	return func(c *config) {
+		if fn != nil {
+			c.text = fn
+		}
+	}
+}
+
+// WithLogger logs lookups and writes that fail, which the Client
+// otherwise treats as misses. Without it nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) {
+		if l != nil {
+			c.logger = l
+		}
+	}
+}
+
+// Stats counts a Client's cache use since it was created.
+type Stats struct {
+	Requests uint64 `json:"requests"` // calls to New and NewStreaming
+	Hits     uint64 `json:"hits"`     // calls answered from the cache
+	Stores   uint64 `json:"stores"`   // responses stored
+	Errors   uint64 `json:"errors"`   // failed lookups and stores
+
+	// SavedTokens is the total token usage of the completions served
+	// from the cache, as the API reported it when they were stored.
+	SavedTokens int64 `json:"saved_tokens"`
+}
+
+// HitRate returns Hits over Requests, or 0 before any request.
+func (s Stats) HitRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Requests)
+}
+
+// Client is a caching chat completions client. It is safe for concurrent
+// use.
+type Client struct {
+	completions Completions
+	cache       *semanticcache.Cache[string, json.RawMessage]
+	cfg         config
+
+	requests, hits, stores, errs atomic.Uint64
+	savedTokens                  atomic.Int64
+}
+
+// New returns a Client calling completions on a miss and storing the
+// completions, as their JSON, in cache. It does not close cache.
+func New(completions Completions, cache *semanticcache.Cache[string, json.RawMessage], opts ...Option) *Client {
+	c := &Client{completions: completions, cache: cache, cfg: config{
+		text:   func(p openai.ChatCompletionNewParams) string { return ConversationText(p.Messages) },
+		logger: slog.New(slog.DiscardHandler),
+	}}
+	for _, o := range opts {
+		o(&c.cfg)
+	}
+	return c
+}
+
+// Stats returns the Client's counters.
+func (c *Client) Stats() Stats {
+	return Stats{
+		Requests:    c.requests.Load(),
+		Hits:        c.hits.Load(),
+		Stores:      c.stores.Load(),
+		Errors:      c.errs.Load(),
+		SavedTokens: c.savedTokens.Load(),
+	}
+}
+
+// New returns a cached completion for params, or calls the API and stores
+// its response. Responses cut short by the token limit or a content
+// filter are not stored.
+func (c *Client) New(ctx context.Context, params openai.ChatCompletionNewParams, opts ...option.RequestOption) (*openai.ChatCompletion, error) {
+	c.requests.Add(1)
+	req, ok := c.request(params)
+	if ok {
+		if completion, ok := c.lookup(ctx, &req); ok {
+			return completion, nil
+		}
+	}
+	completion, err := c.completions.New(ctx, params, opts...)
+	if err != nil || !ok {
+		return completion, err
+	}
+	c.store(context.WithoutCancel(ctx), req, completion)
+	return completion, nil
+}
+
+// request is a chat request's place in the cache.
+type request struct {
+	cache *semanticcache.Cache[string, json.RawMessage]
+	text  string
+	query []float64 // text's embedding, once lookup has computed it
+}
+
+// request returns where params is cached. ok is false for a request with
+// no key text.
+func (c *Client) request(params openai.ChatCompletionNewParams) (request, bool) {
+	text := c.cfg.text(params)
+	if strings.TrimSpace(text) == "" {
+		return request{}, false
+	}
+	ns, err := paramsHash(params)
+	if err != nil {
+		c.cfg.logger.Warn("llmcache: cannot hash request parameters", "error", err)
+		c.errs.Add(1)
+		return request{}, false
+	}
+	return request{cache: c.cache.Namespace("openai:" + ns), text: text}, true
+}
+
+// lookup returns the stored completion best matching req, if fresh, and
+// keeps the query embedding in req for store.
+func (c *Client) lookup(ctx context.Context, req *request) (*openai.ChatCompletion, bool) {
+	opts := []semanticcache.LookupOption{semanticcache.WithLimit(1), semanticcache.WithQueryEmbedding(&req.query)}
+	if c.cfg.threshold != nil {
+		opts = append(opts, semanticcache.WithThreshold(*c.cfg.threshold))
+	}
+	matches, err := req.cache.LookupWithOptions(ctx, req.text, opts...)
+	if err != nil {
+		c.cfg.logger.WarnContext(ctx, "llmcache: lookup failed", "error", err)
+		c.errs.Add(1)
+		return nil, false
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+	m := matches[0]
+	if c.cfg.ttl > 0 && !m.CreatedAt.IsZero() && time.Since(m.CreatedAt) > c.cfg.ttl {
+		return nil, false
+	}
+	var completion openai.ChatCompletion
+	if err := json.Unmarshal(m.Value, &completion); err != nil {
+		c.cfg.logger.WarnContext(ctx, "llmcache: stored completion is invalid", "error", err)
+		c.errs.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	c.savedTokens.Add(completion.Usage.TotalTokens)
+	return &completion, true
+}
+
+// store saves completion under req if every choice finished normally.
+func (c *Client) store(ctx context.Context, req request, completion *openai.ChatCompletion) {
+	if completion == nil || len(completion.Choices) == 0 {
+		return
+	}
+	for _, choice := range completion.Choices {
+		if choice.FinishReason != "stop" && choice.FinishReason != "tool_calls" {
+			return
+		}
+	}
+	value := json.RawMessage(completion.RawJSON())
+	if len(value) == 0 {
+		var err error
+		if value, err = json.Marshal(completion); err != nil {
+			c.storeFailed(ctx, err)
+			return
+		}
+	}
+	key := semanticcache.TextKey(req.text)
+	var err error
+	if req.query != nil {
+		err = req.cache.SetWithEmbedding(ctx, key, req.text, req.query, value)
+	} else {
+		_, err = req.cache.SetText(ctx, req.text, value)
+	}
+	if err == nil && c.cfg.ttl > 0 {
+		if _, err = req.cache.Expire(ctx, key, c.cfg.ttl); errors.Is(err, semanticcache.ErrExpiryUnsupported) {
+			err = nil
+		}
+	}
+	if err != nil {
+		c.storeFailed(ctx, err)
+		return
+	}
+	c.stores.Add(1)
+}
+
+func (c *Client) storeFailed(ctx context.Context, err error) {
+	c.cfg.logger.WarnContext(ctx, "llmcache: store failed", "error", err)
+	c.errs.Add(1)
+}
+
+// paramsHash returns a hash of params without its messages and the fields
+// that do not change the completion, such as stream options and the end
+// user's ID.
+func paramsHash(params openai.ChatCompletionNewParams) (string, error) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", err
+	}
+	for _, name := range []string{"messages", "stream", "stream_options", "user", "metadata", "store", "safety_identifier", "prompt_cache_key"} {
+		delete(fields, name)
+	}
+	data, err = json.Marshal(fields) // map keys marshal sorted
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// ConversationText renders messages as the Client keys them: one line per
+// message, "role: content", with runs of whitespace collapsed. Text parts
+// of a message are joined; other parts, such as images, and tool calls
+// are rendered as their JSON, so they change the key exactly.
+func ConversationText(messages []openai.ChatCompletionMessageParamUnion) string {
+	var b strings.Builder
+	for _, m := range messages {
+		data, err := json.Marshal(m)
+		if err != nil {
+			continue
+		}
+		var msg struct {
+			Role       string          `json:"role"`
+			Content    json.RawMessage `json:"content"`
+			ToolCalls  json.RawMessage `json:"tool_calls"`
+			ToolCallID string          `json:"tool_call_id"`
+		}
+		if json.Unmarshal(data, &msg) != nil {
+			continue
+		}
+		parts := []string{contentText(msg.Content)}
+		if len(msg.ToolCalls) > 0 {
+			parts = append(parts, string(msg.ToolCalls))
+		}
+		if msg.ToolCallID != "" {
+			parts = append(parts, "("+msg.ToolCallID+")")
+		}
+		fmt.Fprintf(&b, "%s: %s\n", msg.Role, strings.Join(strings.Fields(strings.Join(parts, " ")), " "))
+	}
+	return b.String()
+}
+
+// contentText returns a message content's text: the string, or the text
+// parts of an array joined with other parts as JSON.
+func contentText(content json.RawMessage) string {
+	var s string
+	if json.Unmarshal(content, &s) == nil {
+		return s
+	}
+	var parts []json.RawMessage
+	if json.Unmarshal(content, &parts) != nil {
+		return ""
+	}
+	texts := make([]string, 0, len(parts))
+	for _, p := range parts {
+		var part struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if json.Unmarshal(p, &part) == nil && part.Type == "text" {
+			texts = append(texts, part.Text)
+		} else {
+			texts = append(texts, string(p))
+		}
+	}
+	return strings.Join(texts, " ")
+}
@@ -0,0 +1,181 @@
+package llmcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/option"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/internal/testprovider"
+	"github.com/botirk38/semanticcache/options"
+	"github.com/botirk38/semanticcache/types"
+)
+
+// newTestClient returns a Client over a fake API answering every request
+// with "answer N", N counting the API calls.
+func newTestClient(t *testing.T, opts ...Option) (*Client, *atomic.Int32) {
+	t.Helper()
+	return newTestClientWith(t, testprovider.Words{}, opts...)
+}
+
+// newTestClientWith is newTestClient embedding with provider.
+func newTestClientWith(t *testing.T, provider types.EmbeddingProvider, opts ...Option) (*Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		content := fmt.Sprintf("answer %d", n)
+		if !body.Stream {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"id":"c%d","object":"chat.completion","created":1,"model":"gpt-test",`+
+				`"choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}],`+
+				`"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`, n, content)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range strings.SplitAfter(content, " ") {
+			fmt.Fprintf(w, "data: {\"id\":\"c%d\",\"object\":\"chat.completion.chunk\",\"created\":1,\"model\":\"gpt-test\","+
+				"\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":%q},\"finish_reason\":null}]}\n\n", n, part)
+		}
+		fmt.Fprintf(w, "data: {\"id\":\"c%d\",\"object\":\"chat.completion.chunk\",\"created\":1,\"model\":\"gpt-test\","+
+			"\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n", n)
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(srv.Close)
+
+	cache, err := semanticcache.New(
+		options.WithLRUBackend[string, json.RawMessage](100),
+		options.WithCustomProvider[string, json.RawMessage](provider),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+
+	client := openai.NewClient(option.WithBaseURL(srv.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	return New(&client.Chat.Completions, cache, opts...), &calls
+}
+
+func params(model string, messages ...openai.ChatCompletionMessageParamUnion) openai.ChatCompletionNewParams {
+	return openai.ChatCompletionNewParams{Model: model, Messages: messages}
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	llm, calls := newTestClient(t)
+
+	ask := params("gpt-test", openai.SystemMessage("Be brief."), openai.UserMessage("What is Go?"))
+	first, err := llm.New(ctx, ask)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	again := params("gpt-test", openai.SystemMessage("Be  brief."), openai.UserMessage("what is go?"))
+	second, err := llm.New(ctx, again)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if calls.Load() != 1 || second.Choices[0].Message.Content != first.Choices[0].Message.Content {
+		t.Errorf("second call = %q after %d API calls, want the cached %q", second.Choices[0].Message.Content, calls.Load(), first.Choices[0].Message.Content)
+	}
+
+	// Another model never matches.
+	if _, err := llm.New(ctx, params("gpt-other", openai.SystemMessage("Be brief."), openai.UserMessage("What is Go?"))); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("other model made %d API calls in total, want 2", calls.Load())
+	}
+
+	want := Stats{Requests: 3, Hits: 1, Stores: 2, SavedTokens: 7}
+	if got := llm.Stats(); got != want {
+		t.Errorf("Stats = %+v, want %+v", got, want)
+	}
+	if rate := llm.Stats().HitRate(); rate < 0.33 || rate > 0.34 {
+		t.Errorf("HitRate = %v, want 1/3", rate)
+	}
+}
+
+func TestClientStreaming(t *testing.T) {
+	ctx := context.Background()
+	llm, calls := newTestClient(t)
+	ask := params("gpt-test", openai.UserMessage("Tell me about channels"))
+
+	read := func() string {
+		t.Helper()
+		stream := llm.NewStreaming(ctx, ask)
+		defer stream.Close()
+		var acc openai.ChatCompletionAccumulator
+		for stream.Next() {
+			acc.AddChunk(stream.Current())
+		}
+		if err := stream.Err(); err != nil {
+			t.Fatalf("stream: %v", err)
+		}
+		return acc.Choices[0].Message.Content
+	}
+	first := read()
+	if first != "answer 1" {
+		t.Fatalf("streamed content = %q, want %q", first, "answer 1")
+	}
+	if second := read(); second != first || calls.Load() != 1 {
+		t.Errorf("replayed content = %q after %d API calls, want the cached %q", second, calls.Load(), first)
+	}
+
+	// A streamed response is served to a non-streamed request too.
+	completion, err := llm.New(ctx, ask)
+	if err != nil || completion.Choices[0].Message.Content != first || calls.Load() != 1 {
+		t.Errorf("New after streaming = %v, %v after %d API calls", completion, err, calls.Load())
+	}
+}
+
+func TestClientEmbedsOnce(t *testing.T) {
+	ctx := context.Background()
+	provider := &testprovider.Counting{}
+	llm, _ := newTestClientWith(t, provider)
+
+	if _, err := llm.New(ctx, params("gpt-test", openai.UserMessage("What is Go?"))); err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if n := provider.Calls.Load(); n != 1 {
+		t.Errorf("a miss embedded the conversation %d times, want 1", n)
+	}
+	stream := llm.NewStreaming(ctx, params("gpt-test", openai.UserMessage("What is Rust?")))
+	for stream.Next() {
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if n := provider.Calls.Load(); n != 2 {
+		t.Errorf("a streamed miss embedded the conversation %d times, want 1", n-1)
+	}
+	if got := llm.Stats().Stores; got != 2 {
+		t.Errorf("Stores = %d, want 2", got)
+	}
+}
+
+func TestConversationText(t *testing.T) {
+	got := ConversationText([]openai.ChatCompletionMessageParamUnion{
+		openai.SystemMessage("Be\n  brief."),
+		openai.UserMessage([]openai.ChatCompletionContentPartUnionParam{
+			openai.TextContentPart("What is"),
+			openai.TextContentPart("Go?"),
+		}),
+		openai.AssistantMessage("A language."),
+	})
+	want := "system: Be brief.\nuser: What is Go?\nassistant: A language.\n"
+	if got != want {
+		t.Errorf("ConversationText = %q, want %q", got, want)
+	}
+}
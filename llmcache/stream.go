@@ -0,0 +1,150 @@
+package llmcache
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/openai/openai-go/v2"
+	"github.com/openai/openai-go/v2/option"
+	"github.com/openai/openai-go/v2/packages/ssestream"
+)
+
+// NewStreaming is New for streamed completions. A hit is replayed as one
+// chunk holding the whole completion. On a miss the API's chunks pass
+// through as they arrive, and once the stream ends without error the
+// reassembled completion is stored; a stream closed early is not.
+func (c *Client) NewStreaming(ctx context.Context, params openai.ChatCompletionNewParams, opts ...option.RequestOption) *ssestream.Stream[openai.ChatCompletionChunk] {
+	c.requests.Add(1)
+	req, ok := c.request(params)
+	if ok {
+		if completion, ok := c.lookup(ctx, &req); ok {
+			data, err := replayChunk(completion)
+			return ssestream.NewStream[openai.ChatCompletionChunk](&replay{data: data}, err)
+		}
+	}
+	stream := c.completions.NewStreaming(ctx, params, opts...)
+	if !ok {
+		return stream
+	}
+	return ssestream.NewStream[openai.ChatCompletionChunk](&recorder{
+		client: c,
+		ctx:    context.WithoutCancel(ctx),
+		req:    req,
+		stream: stream,
+	}, nil)
+}
+
+// recorder is an ssestream.Decoder passing an upstream stream's chunks
+// through while accumulating them, and storing the completion at its end.
+type recorder struct {
+	client *Client
+	ctx    context.Context
+	req    request
+	stream *ssestream.Stream[openai.ChatCompletionChunk]
+	acc    openai.ChatCompletionAccumulator
+	event  ssestream.Event
+	err    error
+}
+
+func (r *recorder) Next() bool {
+	if !r.stream.Next() {
+		if r.stream.Err() == nil && r.err == nil {
+			r.client.store(r.ctx, r.req, &r.acc.ChatCompletion)
+		}
+		return false
+	}
+	chunk := r.stream.Current()
+	r.acc.AddChunk(chunk)
+	data := []byte(chunk.RawJSON())
+	if len(data) == 0 {
+		data, r.err = json.Marshal(chunk)
+		if r.err != nil {
+			return false
+		}
+	}
+	r.event = ssestream.Event{Data: data}
+	return true
+}
+
+func (r *recorder) Event() ssestream.Event { return r.event }
+
+func (r *recorder) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.stream.Err()
+}
+
+func (r *recorder) Close() error { return r.stream.Close() }
+
+// replay is an ssestream.Decoder yielding one stored chunk.
+type replay struct {
+	data []byte
+	done bool
+}
+
+func (r *replay) Next() bool {
+	if r.done {
+		return false
+	}
+	r.done = true
+	return true
+}
+
+func (r *replay) Event() ssestream.Event { return ssestream.Event{Data: r.data} }
+func (r *replay) Err() error             { return nil }
+func (r *replay) Close() error           { return nil }
+
+// replayChunk returns completion as the JSON of a single stream chunk
+// whose deltas carry each choice's whole message.
+func replayChunk(completion *openai.ChatCompletion) ([]byte, error) {
+	type delta struct {
+		Role      string            `json:"role"`
+		Content   string            `json:"content,omitempty"`
+		Refusal   string            `json:"refusal,omitempty"`
+		ToolCalls []json.RawMessage `json:"tool_calls,omitempty"`
+	}
+	type choice struct {
+		Index        int64  `json:"index"`
+		Delta        delta  `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	}
+	chunk := struct {
+		ID      string                 `json:"id"`
+		Object  string                 `json:"object"`
+		Created int64                  `json:"created"`
+		Model   string                 `json:"model"`
+		Choices []choice               `json:"choices"`
+		Usage   openai.CompletionUsage `json:"usage"`
+	}{
+		ID:      completion.ID,
+		Object:  "chat.completion.chunk",
+		Created: completion.Created,
+		Model:   completion.Model,
+		Usage:   completion.Usage,
+	}
+	for _, ch := range completion.Choices {
+		d := delta{Role: "assistant", Content: ch.Message.Content, Refusal: ch.Message.Refusal}
+		for i, call := range ch.Message.ToolCalls {
+			raw := []byte(call.RawJSON())
+			if len(raw) == 0 {
+				var err error
+				if raw, err = json.Marshal(call); err != nil {
+					return nil, err
+				}
+			}
+			var fields map[string]any
+			if err := json.Unmarshal(raw, &fields); err != nil {
+				return nil, err
+			}
+			fields["index"] = i
+			data, err := json.Marshal(fields)
+			if err != nil {
+				return nil, err
+			}
+			d.ToolCalls = append(d.ToolCalls, data)
+		}
+		chunk.Choices = append(chunk.Choices, choice{Index: ch.Index, Delta: d, FinishReason: ch.FinishReason})
+	}
+	return json.Marshal(chunk)
+}
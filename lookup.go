@@ -0,0 +1,174 @@
+package semanticcache
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/botirk38/semanticcache/similarity"
+)
+
+// LookupOption configures LookupWithOptions, Lookup, LookupEx, TopMatches,
+// TopMatchesEx, and PageMatches. The threshold and limit arguments of Lookup and
+// TopMatches take precedence over WithThreshold and WithLimit, except that
+// TopMatches honors WithThreshold as a minimum score.
+type LookupOption func(*lookupConfig)
+
+type lookupConfig struct {
+	threshold  float64
+	limit      int
+	offset     int
+	filter     func(metadata map[string]string) bool
+	namespace  string
+	comparator similarity.SimilarityFunc
+	scale      func(score float64) float64 // nil leaves scores as they are
+	hybrid     *hybridConfig               // nil searches by vector alone
+	keyFilters []any                       // func(K, map[string]string) bool; see WithSearchFilter
+	reserve    time.Duration               // see WithBestEffort; 0 scans fully
+	query      *[]float64                  // see WithQueryEmbedding
+}
+
+// newLookupConfig applies opts over the cache's defaults: its threshold,
+// a limit of one, and its comparator. It returns ErrSearchFilterKeyType if
+// a WithSearchFilter does not take the cache's key type.
+func (c *Cache[K, V]) newLookupConfig(opts []LookupOption) (lookupConfig, error) {
+	cfg := lookupConfig{
+		threshold:  c.threshold,
+		limit:      1,
+		comparator: c.comparator,
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	if cfg.comparator == nil {
+		cfg.comparator = c.comparator
+	}
+	if c.normalize {
+		cfg.scale = similarity.Normalizer(cfg.comparator)
+	}
+	for _, f := range cfg.keyFilters {
+		if _, ok := f.(func(K, map[string]string) bool); !ok {
+			return cfg, fmt.Errorf("%w: got %T, want func(%v, map[string]string) bool", ErrSearchFilterKeyType, f, reflect.TypeFor[K]())
+		}
+	}
+	return cfg, nil
+}
+
+// sameComparator reports whether a and b are the same function.
+func sameComparator(a, b similarity.SimilarityFunc) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// score compares a and b with the configured comparator and scale.
+func (c lookupConfig) score(a, b []float64) float64 {
+	return c.scaled(c.comparator(a, b))
+}
+
+// scaled applies the configured scale to a score.
+func (c lookupConfig) scaled(score float64) float64 {
+	if c.scale == nil {
+		return score
+	}
+	return c.scale(score)
+}
+
+// WithThreshold sets the minimum similarity a match must reach. With
+// TopMatches and PageMatches it acts as a minimum score.
+func WithThreshold(threshold float64) LookupOption {
+	return func(c *lookupConfig) { c.threshold = threshold }
+}
+
+// WithLimit sets the maximum number of matches returned. LookupWithOptions
+// returns ErrInvalidN when n is not positive.
+func WithLimit(n int) LookupOption {
+	return func(c *lookupConfig) { c.limit = n }
+}
+
+// WithOffset skips the first n matches, for offset-based pagination.
+// PageMatches is cheaper when walking many pages, as it scores entries once.
+func WithOffset(n int) LookupOption {
+	return func(c *lookupConfig) { c.offset = max(n, 0) }
+}
+
+// WithNamespace searches only the entries of the named namespace, as if the
+// call were made on Namespace(name).
+func WithNamespace(name string) LookupOption {
+	return func(c *lookupConfig) { c.namespace = name }
+}
+
+// WithComparator scores this call with fn instead of the cache's
+// similarity function, for example dot product on normalized vectors or
+// Euclidean for one experiment, without building a second cache. Lookups
+// with another comparator skip the exact-match fast path and any vector
+// index, so they scan. A nil fn keeps the cache's.
+func WithComparator(fn similarity.SimilarityFunc) LookupOption {
+	return func(c *lookupConfig) { c.comparator = fn }
+}
+
+// WithFilter restricts results to entries whose metadata satisfies fn.
+// Entries stored without metadata, or in a backend that does not implement
+// types.EntryBackend, are passed a nil map.
+func WithFilter(fn func(metadata map[string]string) bool) LookupOption {
+	return func(c *lookupConfig) { c.filter = fn }
+}
+
+// WithSearchFilter restricts results to entries for which fn returns
+// true, given the entry's key and metadata, so one cache can serve many
+// users, document sets, or labels. Entries must pass every search filter
+// and any WithFilter; filters apply while candidates are fetched, so a
+// lookup keeps going past rejected entries until it has its limit. K must
+// be the cache's key type; on any other cache the lookup fails with
+// ErrSearchFilterKeyType. A nil fn is ignored.
+func WithSearchFilter[K comparable](fn func(key K, metadata map[string]string) bool) LookupOption {
+	return func(c *lookupConfig) {
+		if fn != nil {
+			c.keyFilters = append(slices.Clip(c.keyFilters), fn)
+		}
+	}
+}
+
+// WithQueryEmbedding sets *dst to the query embedding the lookup computed,
+// so a caller storing a value after a miss can pass it to SetWithEmbedding
+// rather than embed the same text again. *dst is left alone when the lookup
+// did not embed the query, as on an exact-match hit. It applies to
+// LookupWithOptions, Lookup, LookupEx, TopMatches, and TopMatchesEx.
+func WithQueryEmbedding(dst *[]float64) LookupOption {
+	return func(c *lookupConfig) { c.query = dst }
+}
+
+// WithBestEffort stops a scan reserve before ctx's deadline and returns the
+// best matches found so far, marked Partial, instead of failing with
+// context.DeadlineExceeded: latency-sensitive callers often prefer a
+// slightly worse hit to a timeout. reserve is left for fetching the
+// matched entries. It has no effect without a deadline, on lookups
+// answered by a vector index, or on time spent embedding the query, and a
+// reserve of 0 or less disables it.
+func WithBestEffort(reserve time.Duration) LookupOption {
+	return func(c *lookupConfig) { c.reserve = max(reserve, 0) }
+}
+
+// scanContext returns the context a search scans under: ctx, or for
+// best-effort lookups ctx ending reserve before its deadline.
+func (c *Cache[K, V]) scanContext(ctx context.Context, cfg lookupConfig) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if cfg.reserve <= 0 || !ok {
+		return ctx, func() {}
+	}
+	return context.WithDeadline(ctx, deadline.Add(-cfg.reserve))
+}
+
+// accepts reports whether the entry stored under key passes cfg's filters.
+func accepts[K comparable](cfg lookupConfig, key K, metadata map[string]string) bool {
+	if cfg.filter != nil && !cfg.filter(metadata) {
+		return false
+	}
+	for _, f := range cfg.keyFilters {
+		// newLookupConfig has checked the filters' key type.
+		if !f.(func(K, map[string]string) bool)(key, metadata) {
+			return false
+		}
+	}
+	return true
+}
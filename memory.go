@@ -0,0 +1,44 @@
+package semanticcache
+
+import (
+	"cmp"
+	"context"
+	"reflect"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// Rough per-item costs for estimating the cache's own indexes.
+const (
+	mapSlotSize      = 48
+	stringHeaderSize = 16
+)
+
+// keySize returns the inline size of a K.
+func keySize[K comparable]() int64 {
+	return int64(reflect.TypeFor[K]().Size())
+}
+
+// MemoryUsage estimates the bytes held by the backend's entries and
+// indexes, plus the exact-match and lexical indexes, for capacity planning
+// without heap profiling. Namespace views share one backend, so each
+// reports the whole backend. The backend must implement
+// types.MemoryReporter, as the in-memory backends do; otherwise
+// ErrMemoryUsageUnsupported is returned. It walks every entry, so avoid
+// calling it on hot paths.
+func (c *Cache[K, V]) MemoryUsage(ctx context.Context) (types.MemoryUsage, error) {
+	if err := c.begin(); err != nil {
+		return types.MemoryUsage{}, err
+	}
+	defer c.end()
+	mr, ok := cmp.Or(c.base, c.backend).(types.MemoryReporter[K, V])
+	if !ok {
+		return types.MemoryUsage{}, ErrMemoryUsageUnsupported
+	}
+	usage, err := mr.MemoryUsage(ctx)
+	if err != nil {
+		return types.MemoryUsage{}, err
+	}
+	usage.Index += c.exact.bytes() + c.lexical.bytes()
+	return usage, nil
+}
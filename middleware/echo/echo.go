@@ -0,0 +1,70 @@
+// Package echo adapts the semantic caching middleware to Echo. Each route
+// takes its own middleware, so thresholds, TTLs, and key extractors can
+// differ per route:
+//
+//	e.POST("/v1/complete", complete, cacheecho.New(cache, middleware.WithThreshold(0.95)))
+//	e.POST("/v1/search", search, cacheecho.New(cache, cacheecho.KeyFunc(func(c echo.Context, body []byte) (string, bool) {
+//		return c.QueryParam("q"), c.QueryParam("q") != ""
+//	})))
+//
+// Entries are scoped by method and route pattern, such as "POST
+// /users/:id/ask", rather than the request path; pass middleware.WithScope
+// to change that.
+package echo
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/middleware"
+)
+
+type contextKey struct{}
+
+// New returns Echo middleware caching the responses of the handlers it
+// wraps, configured by middleware's options. It does not close cache.
+//
+// A handler error is passed to the Echo instance's HTTPErrorHandler while
+// the response is still being recorded, so the error response, not an
+// empty 200, is what the middleware sees and declines to cache.
+func New(cache *semanticcache.Cache[string, middleware.Response], opts ...middleware.Option) echo.MiddlewareFunc {
+	m := middleware.New(cache, append([]middleware.Option{middleware.WithScope(routeScope)}, opts...)...)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			h := m.Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				c.SetResponse(echo.NewResponse(w, c.Echo()))
+				if err := next(c); err != nil {
+					c.Error(err)
+				}
+			}))
+			h.ServeHTTP(c.Response(), r.WithContext(context.WithValue(r.Context(), contextKey{}, c)))
+			return nil
+		}
+	}
+}
+
+// KeyFunc keys requests on the text fn extracts from the Echo context and
+// the request body, for use in place of middleware.WithField.
+func KeyFunc(fn func(c echo.Context, body []byte) (string, bool)) middleware.Option {
+	return middleware.WithKeyFunc(func(r *http.Request, body []byte) (string, bool) {
+		c, ok := r.Context().Value(contextKey{}).(echo.Context)
+		if !ok {
+			return "", false
+		}
+		return fn(c, body)
+	})
+}
+
+// routeScope scopes a request by its method and route pattern.
+func routeScope(r *http.Request) string {
+	path := r.URL.Path
+	if c, ok := r.Context().Value(contextKey{}).(echo.Context); ok && c.Path() != "" {
+		path = c.Path()
+	}
+	return r.Method + " " + path
+}
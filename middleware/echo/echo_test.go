@@ -0,0 +1,75 @@
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/internal/testprovider"
+	"github.com/botirk38/semanticcache/middleware"
+	"github.com/botirk38/semanticcache/options"
+)
+
+func TestNew(t *testing.T) {
+	cache, err := semanticcache.New(
+		options.WithLRUBackend[string, middleware.Response](100),
+		options.WithCustomProvider[string, middleware.Response](testprovider.Words{}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+
+	calls := 0
+	handler := func(c echo.Context) error {
+		calls++
+		return c.JSON(http.StatusOK, map[string]any{"call": calls, "id": c.Param("id")})
+	}
+	e := echo.New()
+	e.POST("/ask/:id", handler, New(cache))
+	e.POST("/fail", func(echo.Context) error {
+		calls++
+		return echo.NewHTTPError(http.StatusBadGateway, "upstream down")
+	}, New(cache))
+	e.GET("/search", handler, New(cache, KeyFunc(func(c echo.Context, _ []byte) (string, bool) {
+		return c.QueryParam("q"), c.QueryParam("q") != ""
+	})))
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	first := serve("POST", "/ask/1", `{"prompt":"What is Go?"}`)
+	if first.Header().Get(middleware.HeaderStatus) != middleware.StatusMiss || first.Code != http.StatusOK {
+		t.Fatalf("first request = %d %s", first.Code, first.Header().Get(middleware.HeaderStatus))
+	}
+	// Requests share entries by route pattern, not path.
+	second := serve("POST", "/ask/2", `{"prompt":"what is go?"}`)
+	if second.Header().Get(middleware.HeaderStatus) != middleware.StatusHit || second.Body.String() != first.Body.String() || calls != 1 {
+		t.Errorf("second request = %s %q after %d calls, want a HIT with %q", second.Header().Get(middleware.HeaderStatus), second.Body, calls, first.Body)
+	}
+
+	serve("GET", "/search?q=channels", "")
+	if rec := serve("GET", "/search?q=Channels", ""); rec.Header().Get(middleware.HeaderStatus) != middleware.StatusHit || calls != 2 {
+		t.Errorf("KeyFunc route = %s after %d calls, want a HIT", rec.Header().Get(middleware.HeaderStatus), calls)
+	}
+	if rec := serve("GET", "/search", ""); rec.Header().Get(middleware.HeaderStatus) != middleware.StatusBypass || calls != 3 {
+		t.Errorf("request without a key = %s after %d calls, want BYPASS", rec.Header().Get(middleware.HeaderStatus), calls)
+	}
+
+	for i := range 2 {
+		rec := serve("POST", "/fail", `{"prompt":"flaky upstream"}`)
+		if rec.Code != http.StatusBadGateway || rec.Header().Get(middleware.HeaderStatus) != middleware.StatusMiss {
+			t.Errorf("failing request %d = %d %s, want 502 MISS", i, rec.Code, rec.Header().Get(middleware.HeaderStatus))
+		}
+	}
+	if calls != 5 {
+		t.Errorf("handler ran %d times, want 5: handler errors must not be cached", calls)
+	}
+}
@@ -0,0 +1,84 @@
+// Package gin adapts the semantic caching middleware to Gin. Each route
+// takes its own handler, so thresholds, TTLs, and key extractors can
+// differ per route:
+//
+//	r.POST("/v1/complete", cachegin.New(cache, middleware.WithThreshold(0.95)), complete)
+//	r.POST("/v1/search", cachegin.New(cache, cachegin.KeyFunc(func(c *gin.Context, body []byte) (string, bool) {
+//		return c.Query("q"), c.Query("q") != ""
+//	})), search)
+//
+// Entries are scoped by method and route pattern, such as "POST
+// /users/:id/ask", rather than the request path; pass middleware.WithScope
+// to change that.
+package gin
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/middleware"
+)
+
+type contextKey struct{}
+
+// New returns a Gin handler caching the responses of the handlers after
+// it, configured by middleware's options. It does not close cache.
+func New(cache *semanticcache.Cache[string, middleware.Response], opts ...middleware.Option) gin.HandlerFunc {
+	m := middleware.New(cache, append([]middleware.Option{middleware.WithScope(routeScope)}, opts...)...)
+	return func(c *gin.Context) {
+		called := false
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			c.Request = r
+			orig := c.Writer
+			if w != orig {
+				c.Writer = &responseWriter{ResponseWriter: orig, w: w}
+			}
+			c.Next()
+			c.Writer = orig
+		})
+		r := c.Request.WithContext(context.WithValue(c.Request.Context(), contextKey{}, c))
+		m.ServeHTTP(c.Writer, r, next)
+		if !called {
+			c.Abort()
+		}
+	}
+}
+
+// KeyFunc keys requests on the text fn extracts from the Gin context and
+// the request body, for use in place of middleware.WithField.
+func KeyFunc(fn func(c *gin.Context, body []byte) (string, bool)) middleware.Option {
+	return middleware.WithKeyFunc(func(r *http.Request, body []byte) (string, bool) {
+		c, ok := r.Context().Value(contextKey{}).(*gin.Context)
+		if !ok {
+			return "", false
+		}
+		return fn(c, body)
+	})
+}
+
+// routeScope scopes a request by its method and route pattern.
+func routeScope(r *http.Request) string {
+	path := r.URL.Path
+	if c, ok := r.Context().Value(contextKey{}).(*gin.Context); ok && c.FullPath() != "" {
+		path = c.FullPath()
+	}
+	return r.Method + " " + path
+}
+
+// responseWriter sends a handler's output through the middleware's
+// recorder w, keeping Gin's own writer for everything else.
+type responseWriter struct {
+	gin.ResponseWriter
+	w http.ResponseWriter
+}
+
+func (rw *responseWriter) WriteHeader(code int) { rw.w.WriteHeader(code) }
+
+func (rw *responseWriter) Write(data []byte) (int, error) { return rw.w.Write(data) }
+
+func (rw *responseWriter) WriteString(s string) (int, error) { return io.WriteString(rw.w, s) }
@@ -0,0 +1,65 @@
+package gin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/internal/testprovider"
+	"github.com/botirk38/semanticcache/middleware"
+	"github.com/botirk38/semanticcache/options"
+)
+
+func TestNew(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cache, err := semanticcache.New(
+		options.WithLRUBackend[string, middleware.Response](100),
+		options.WithCustomProvider[string, middleware.Response](testprovider.Words{}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+
+	calls := 0
+	handler := func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusOK, gin.H{"call": calls, "id": c.Param("id")})
+	}
+	r := gin.New()
+	r.POST("/ask/:id", New(cache), handler)
+	r.GET("/search", New(cache, KeyFunc(func(c *gin.Context, _ []byte) (string, bool) {
+		return c.Query("q"), c.Query("q") != ""
+	})), handler)
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	first := serve("POST", "/ask/1", `{"prompt":"What is Go?"}`)
+	if first.Header().Get(middleware.HeaderStatus) != middleware.StatusMiss || first.Code != http.StatusOK {
+		t.Fatalf("first request = %d %s", first.Code, first.Header().Get(middleware.HeaderStatus))
+	}
+	// Requests share entries by route pattern, not path.
+	second := serve("POST", "/ask/2", `{"prompt":"what is go?"}`)
+	if second.Header().Get(middleware.HeaderStatus) != middleware.StatusHit || second.Body.String() != first.Body.String() || calls != 1 {
+		t.Errorf("second request = %s %q after %d calls, want a HIT with %q", second.Header().Get(middleware.HeaderStatus), second.Body, calls, first.Body)
+	}
+	if second.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Errorf("hit Content-Type = %q, want %q", second.Header().Get("Content-Type"), first.Header().Get("Content-Type"))
+	}
+
+	serve("GET", "/search?q=channels", "")
+	if rec := serve("GET", "/search?q=Channels", ""); rec.Header().Get(middleware.HeaderStatus) != middleware.StatusHit || calls != 2 {
+		t.Errorf("KeyFunc route = %s after %d calls, want a HIT", rec.Header().Get(middleware.HeaderStatus), calls)
+	}
+	if rec := serve("GET", "/search", ""); rec.Header().Get(middleware.HeaderStatus) != middleware.StatusBypass || calls != 3 {
+		t.Errorf("request without a key = %s after %d calls, want BYPASS", rec.Header().Get(middleware.HeaderStatus), calls)
+	}
+}
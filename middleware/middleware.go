@@ -0,0 +1,381 @@
+// Package middleware caches HTTP responses by the meaning of a request
+// field, such as the "prompt" of a JSON body, so an LLM proxy can answer a
+// paraphrased request from the response to an earlier one without calling
+// the model.
+//
+//	mw := middleware.New(cache, middleware.WithField("messages.-1.content"),
+//		middleware.WithTTL(time.Hour))
+//	http.Handle("POST /v1/complete", mw.Wrap(proxy))
+//
+// Each response carries an X-Semantic-Cache header: HIT, MISS, or BYPASS.
+// A request with "Cache-Control: no-cache" skips the lookup but stores the
+// fresh response; "no-store" skips both. Only 200 responses without
+// Set-Cookie, "Cache-Control: no-store", or "private" are stored. Requests
+// carrying Authorization or Cookie headers are only answered from responses
+// to requests with the same credentials.
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/botirk38/semanticcache"
+)
+
+const (
+	// HeaderStatus is the response header reporting how the middleware
+	// handled the request: StatusHit, StatusMiss, or StatusBypass.
+	HeaderStatus = "X-Semantic-Cache"
+
+	// HeaderScore is the response header carrying a hit's similarity.
+	HeaderScore = "X-Semantic-Cache-Score"
+
+	StatusHit    = "HIT"
+	StatusMiss   = "MISS"
+	StatusBypass = "BYPASS"
+)
+
+const (
+	// DefaultField is the JSON field keyed on without WithField.
+	DefaultField = "prompt"
+
+	// DefaultMaxBodyBytes is the request body limit without
+	// WithMaxBodyBytes.
+	DefaultMaxBodyBytes = 1 << 20
+
+	// DefaultMaxResponseBytes is the response body limit without
+	// WithMaxResponseBytes.
+	DefaultMaxResponseBytes = 4 << 20
+)
+
+// Response is a stored HTTP response.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body"`
+}
+
+// KeyFunc extracts the text a request is cached by from the request and
+// its body. ok is false for a request that should not be cached.
+type KeyFunc func(r *http.Request, body []byte) (text string, ok bool)
+
+// ScopeFunc names the namespace a request's responses are stored in, so
+// requests only match others of the same scope.
+type ScopeFunc func(r *http.Request) string
+
+// Option configures a Middleware.
+type Option func(*config)
+
+type config struct {
+	key         KeyFunc
+	scope       ScopeFunc
+	threshold   *float64
+	ttl         time.Duration
+	maxBody     int64
+	maxResponse int
+	logger      *slog.Logger
+}
+
+// WithField keys requests on a field of their JSON body, named by a dotted
+// path such as "input.text" or "messages.-1.content". Numeric segments
+// index arrays, negative ones from the end. A string field is used as is,
+// any other value as its JSON. Defaults to DefaultField.
+func WithField(path string) Option {
+	return func(c *config) { c.key = FieldKey(path) }
+}
+
+// WithKeyFunc keys requests on the text fn extracts, replacing WithField.
+func WithKeyFunc(fn KeyFunc) Option {
+	return func(c *config) {
+		if fn != nil {
+			c.key = fn
+		}
+	}
+}
+
+// WithScope stores responses in the namespace fn names. Defaults to the
+// request's method and path, so each route has its own entries; a scope
+// of "" shares the cache's root namespace. Either way, credentialed
+// requests get a namespace per credential nested in the scope.
+func WithScope(fn ScopeFunc) Option {
+	return func(c *config) {
+		if fn != nil {
+			c.scope = fn
+		}
+	}
+}
+
+// WithThreshold sets the similarity a stored request must reach to answer
+// a new one. Defaults to the cache's threshold.
+func WithThreshold(threshold float64) Option {
+	return func(c *config) { c.threshold = &threshold }
+}
+
+// WithTTL serves stored responses for at most ttl after they were stored.
+// Where the backend supports per-key expiry, entries are also set to
+// expire then. A ttl of 0, the default, keeps responses until evicted.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *config) { c.ttl = ttl }
+}
+
+// WithMaxBodyBytes passes requests whose body exceeds n bytes through
+// uncached. Defaults to DefaultMaxBodyBytes.
+func WithMaxBodyBytes(n int64) Option {
+	return func(c *config) { c.maxBody = n }
+}
+
+// WithMaxResponseBytes stores only responses of at most n bytes. Defaults
+// to DefaultMaxResponseBytes.
+func WithMaxResponseBytes(n int) Option {
+	return func(c *config) { c.maxResponse = n }
+}
+
+// WithLogger logs lookups and writes that fail, which the middleware
+// otherwise treats as misses. Without it nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) {
+		if l != nil {
+			c.logger = l
+		}
+	}
+}
+
+// Middleware caches the responses of the handlers it wraps.
+type Middleware struct {
+	cache *semanticcache.Cache[string, Response]
+	cfg   config
+}
+
+// New returns a Middleware storing responses in cache. It does not close
+// cache.
+func New(cache *semanticcache.Cache[string, Response], opts ...Option) *Middleware {
+	m := &Middleware{cache: cache, cfg: config{
+		key:         FieldKey(DefaultField),
+		scope:       func(r *http.Request) string { return r.Method + " " + r.URL.Path },
+		maxBody:     DefaultMaxBodyBytes,
+		maxResponse: DefaultMaxResponseBytes,
+		logger:      slog.New(slog.DiscardHandler),
+	}}
+	for _, o := range opts {
+		o(&m.cfg)
+	}
+	return m
+}
+
+// Wrap returns next with response caching.
+func (m *Middleware) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.ServeHTTP(w, r, next)
+	})
+}
+
+// ServeHTTP answers r from the cache, or calls next and stores its
+// response. Framework adapters call it with their own next handler.
+func (m *Middleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	noCache, noStore := requestDirectives(r.Header)
+	text, ok := "", false
+	if !noStore && r.Body != nil {
+		var body []byte
+		body, ok = m.readBody(r)
+		if ok {
+			text, ok = m.cfg.key(r, body)
+		}
+	}
+	if !ok || noStore {
+		w.Header().Set(HeaderStatus, StatusBypass)
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	cache := m.cache
+	if scope := m.cfg.scope(r); scope != "" {
+		cache = cache.Namespace(scope)
+	}
+	if cred := credential(r.Header); cred != "" {
+		cache = cache.Namespace(cred)
+	}
+	ctx := r.Context()
+	var query []float64
+	if !noCache {
+		if match, ok := m.lookup(ctx, cache, text, &query); ok {
+			writeHit(w, match)
+			return
+		}
+	}
+
+	w.Header().Set(HeaderStatus, StatusMiss)
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK, max: m.cfg.maxResponse}
+	next.ServeHTTP(rec, r)
+	if resp, ok := rec.response(); ok {
+		m.store(context.WithoutCancel(ctx), cache, text, query, resp)
+	}
+}
+
+// credential names the namespace of requests with h's Authorization and
+// Cookie headers by a hash of them, so a response to one user is never
+// served to another. It is "" for requests without credentials.
+func credential(h http.Header) string {
+	auth, cookies := h.Values("Authorization"), h.Values("Cookie")
+	if len(auth) == 0 && len(cookies) == 0 {
+		return ""
+	}
+	sum := sha256.New()
+	for _, v := range auth {
+		sum.Write([]byte("authorization\x00" + v + "\x00"))
+	}
+	for _, v := range cookies {
+		sum.Write([]byte("cookie\x00" + v + "\x00"))
+	}
+	return "credential " + hex.EncodeToString(sum.Sum(nil))
+}
+
+// readBody reads r's body and puts it back for the next handler. ok is
+// false if the body is over the limit or cannot be read.
+func (m *Middleware) readBody(r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, m.cfg.maxBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	return body, err == nil && int64(len(body)) <= m.cfg.maxBody
+}
+
+// lookup returns the best fresh match for text in cache, setting *query to
+// text's embedding when it computed one.
+func (m *Middleware) lookup(ctx context.Context, cache *semanticcache.Cache[string, Response], text string, query *[]float64) (semanticcache.MatchEx[string, Response], bool) {
+	opts := []semanticcache.LookupOption{semanticcache.WithLimit(1), semanticcache.WithQueryEmbedding(query)}
+	if m.cfg.threshold != nil {
+		opts = append(opts, semanticcache.WithThreshold(*m.cfg.threshold))
+	}
+	matches, err := cache.LookupWithOptions(ctx, text, opts...)
+	if err != nil {
+		m.cfg.logger.WarnContext(ctx, "semanticcache middleware: lookup failed", "error", err)
+		return semanticcache.MatchEx[string, Response]{}, false
+	}
+	if len(matches) == 0 || m.expired(matches[0].CreatedAt) {
+		return semanticcache.MatchEx[string, Response]{}, false
+	}
+	return matches[0], true
+}
+
+func (m *Middleware) expired(created time.Time) bool {
+	return m.cfg.ttl > 0 && !created.IsZero() && time.Since(created) > m.cfg.ttl
+}
+
+// store saves resp under text, reusing the lookup's embedding of text when
+// there is one, and sets it to expire after the TTL where the backend
+// allows.
+func (m *Middleware) store(ctx context.Context, cache *semanticcache.Cache[string, Response], text string, query []float64, resp Response) {
+	key := semanticcache.TextKey(text)
+	var err error
+	if query != nil {
+		err = cache.SetWithEmbedding(ctx, key, text, query, resp)
+	} else {
+		_, err = cache.SetText(ctx, text, resp)
+	}
+	if err == nil && m.cfg.ttl > 0 {
+		if _, err = cache.Expire(ctx, key, m.cfg.ttl); errors.Is(err, semanticcache.ErrExpiryUnsupported) {
+			err = nil
+		}
+	}
+	if err != nil {
+		m.cfg.logger.WarnContext(ctx, "semanticcache middleware: store failed", "error", err)
+	}
+}
+
+// writeHit replays a stored response.
+func writeHit(w http.ResponseWriter, match semanticcache.MatchEx[string, Response]) {
+	h := w.Header()
+	for name, values := range match.Value.Header {
+		h[name] = values
+	}
+	h.Set(HeaderStatus, StatusHit)
+	h.Set(HeaderScore, strconv.FormatFloat(match.Score, 'f', 4, 64))
+	if !match.CreatedAt.IsZero() {
+		h.Set("Age", strconv.Itoa(int(time.Since(match.CreatedAt).Seconds())))
+	}
+	w.WriteHeader(match.Value.Status)
+	_, _ = w.Write(match.Value.Body)
+}
+
+// requestDirectives reports the request's no-cache and no-store
+// Cache-Control directives.
+func requestDirectives(h http.Header) (noCache, noStore bool) {
+	for _, d := range directives(h) {
+		switch d {
+		case "no-cache":
+			noCache = true
+		case "no-store":
+			noStore = true
+		}
+	}
+	return noCache, noStore
+}
+
+func directives(h http.Header) []string {
+	var out []string
+	for _, v := range h.Values("Cache-Control") {
+		for d := range strings.SplitSeq(v, ",") {
+			d, _, _ = strings.Cut(strings.TrimSpace(d), "=")
+			out = append(out, strings.ToLower(d))
+		}
+	}
+	return out
+}
+
+// FieldKey returns a KeyFunc reading the JSON body field at path, as
+// described at WithField.
+func FieldKey(path string) KeyFunc {
+	segments := strings.Split(path, ".")
+	return func(_ *http.Request, body []byte) (string, bool) {
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			return "", false
+		}
+		for _, s := range segments {
+			switch node := v.(type) {
+			case map[string]any:
+				var ok bool
+				if v, ok = node[s]; !ok {
+					return "", false
+				}
+			case []any:
+				i, err := strconv.Atoi(s)
+				if err != nil {
+					return "", false
+				}
+				if i < 0 {
+					i += len(node)
+				}
+				if i < 0 || i >= len(node) {
+					return "", false
+				}
+				v = node[i]
+			default:
+				return "", false
+			}
+		}
+		switch v := v.(type) {
+		case string:
+			if strings.TrimSpace(v) == "" {
+				return "", false
+			}
+			return v, true
+		case nil:
+			return "", false
+		default:
+			b, err := json.Marshal(v)
+			return string(b), err == nil
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/internal/testprovider"
+	"github.com/botirk38/semanticcache/options"
+)
+
+// newTestHandler wraps a handler that echoes the request body and counts
+// its calls.
+func newTestHandler(t *testing.T, opts ...Option) (http.Handler, *atomic.Int32) {
+	t.Helper()
+	cache, err := semanticcache.New(
+		options.WithLRUBackend[string, Response](100),
+		options.WithCustomProvider[string, Response](testprovider.Words{}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+
+	var calls atomic.Int32
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "fail") {
+			http.Error(w, "upstream failed", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	})
+	return New(cache, opts...).Wrap(next), &calls
+}
+
+func serve(h http.Handler, path, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	h, calls := newTestHandler(t)
+	const body = `{"prompt":"What is Go?","n":1}`
+
+	if rec := serve(h, "/complete", body); rec.Header().Get(HeaderStatus) != StatusMiss || rec.Body.String() != body {
+		t.Fatalf("first request = %s %q, want a MISS echoing the body", rec.Header().Get(HeaderStatus), rec.Body)
+	}
+	rec := serve(h, "/complete", `{"prompt":"What is Go?","n":2}`)
+	if rec.Header().Get(HeaderStatus) != StatusHit || rec.Body.String() != body || calls.Load() != 1 {
+		t.Fatalf("second request = %s %q after %d calls, want a HIT with the first response", rec.Header().Get(HeaderStatus), rec.Body, calls.Load())
+	}
+	if rec.Header().Get("Content-Type") != "application/json" || rec.Header().Get(HeaderScore) == "" {
+		t.Errorf("hit headers = %v", rec.Header())
+	}
+
+	if rec := serve(h, "/other", body); rec.Header().Get(HeaderStatus) != StatusMiss {
+		t.Errorf("other route = %s, want MISS", rec.Header().Get(HeaderStatus))
+	}
+	if rec := serve(h, "/complete", `{"text":"no prompt"}`); rec.Header().Get(HeaderStatus) != StatusBypass {
+		t.Errorf("request without the field = %s, want BYPASS", rec.Header().Get(HeaderStatus))
+	}
+
+	// no-cache refreshes the entry; no-store neither reads nor writes it.
+	if rec := serve(h, "/complete", `{"prompt":"What is Go?","n":3}`, "Cache-Control", "no-cache"); rec.Header().Get(HeaderStatus) != StatusMiss {
+		t.Errorf("no-cache = %s, want MISS", rec.Header().Get(HeaderStatus))
+	}
+	if rec := serve(h, "/complete", `{"prompt":"What is Go?","n":4}`, "Cache-Control", "no-store"); rec.Header().Get(HeaderStatus) != StatusBypass {
+		t.Errorf("no-store = %s, want BYPASS", rec.Header().Get(HeaderStatus))
+	}
+	if rec := serve(h, "/complete", body); !strings.Contains(rec.Body.String(), `"n":3`) {
+		t.Errorf("after no-cache, hit = %q, want the refreshed response", rec.Body)
+	}
+
+	// Failed responses are not stored.
+	serve(h, "/complete", `{"prompt":"fail"}`)
+	if rec := serve(h, "/complete", `{"prompt":"fail"}`); rec.Header().Get(HeaderStatus) != StatusMiss || rec.Code != http.StatusBadGateway {
+		t.Errorf("after a failed response = %s %d, want MISS", rec.Header().Get(HeaderStatus), rec.Code)
+	}
+}
+
+func TestMiddlewareOptions(t *testing.T) {
+	h, _ := newTestHandler(t, WithTTL(time.Nanosecond))
+	serve(h, "/", `{"prompt":"hello"}`)
+	if rec := serve(h, "/", `{"prompt":"hello"}`); rec.Header().Get(HeaderStatus) != StatusMiss {
+		t.Errorf("expired entry = %s, want MISS", rec.Header().Get(HeaderStatus))
+	}
+
+	h, _ = newTestHandler(t, WithMaxBodyBytes(8))
+	if rec := serve(h, "/", `{"prompt":"too long"}`); rec.Header().Get(HeaderStatus) != StatusBypass || rec.Body.String() != `{"prompt":"too long"}` {
+		t.Errorf("oversized body = %s %q, want BYPASS with the body intact", rec.Header().Get(HeaderStatus), rec.Body)
+	}
+
+	h, calls := newTestHandler(t, WithMaxResponseBytes(4))
+	serve(h, "/", `{"prompt":"hello"}`)
+	serve(h, "/", `{"prompt":"hello"}`)
+	if calls.Load() != 2 {
+		t.Errorf("oversized responses stored: %d calls, want 2", calls.Load())
+	}
+
+	h, _ = newTestHandler(t, WithScope(func(*http.Request) string { return "" }), WithField("input.text"))
+	serve(h, "/a", `{"input":{"text":"hello"}}`)
+	if rec := serve(h, "/b", `{"input":{"text":"hello"}}`); rec.Header().Get(HeaderStatus) != StatusHit {
+		t.Errorf("shared scope = %s, want HIT", rec.Header().Get(HeaderStatus))
+	}
+}
+
+func TestMiddlewareCredentials(t *testing.T) {
+	h, calls := newTestHandler(t)
+	const body = `{"prompt":"my account balance"}`
+	serve(h, "/", body, "Authorization", "Bearer alice")
+	if rec := serve(h, "/", body, "Authorization", "Bearer bob"); rec.Header().Get(HeaderStatus) != StatusMiss {
+		t.Errorf("another user's credentials = %s, want MISS", rec.Header().Get(HeaderStatus))
+	}
+	if rec := serve(h, "/", body); rec.Header().Get(HeaderStatus) != StatusMiss {
+		t.Errorf("no credentials = %s, want MISS", rec.Header().Get(HeaderStatus))
+	}
+	if rec := serve(h, "/", body, "Cookie", "session=alice"); rec.Header().Get(HeaderStatus) != StatusMiss {
+		t.Errorf("cookie credentials = %s, want MISS", rec.Header().Get(HeaderStatus))
+	}
+	if rec := serve(h, "/", body, "Authorization", "Bearer alice"); rec.Header().Get(HeaderStatus) != StatusHit {
+		t.Errorf("same credentials = %s, want HIT", rec.Header().Get(HeaderStatus))
+	}
+	if calls.Load() != 4 {
+		t.Errorf("calls = %d, want 4", calls.Load())
+	}
+}
+
+func TestMiddlewareEmbedsOnce(t *testing.T) {
+	provider := &testprovider.Counting{}
+	cache, err := semanticcache.New(
+		options.WithLRUBackend[string, Response](100),
+		options.WithCustomProvider[string, Response](provider),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+	h := New(cache).Wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("answer"))
+	}))
+
+	serve(h, "/", `{"prompt":"What is Go?"}`)
+	if n := provider.Calls.Load(); n != 1 {
+		t.Errorf("a miss embedded the prompt %d times, want 1", n)
+	}
+	if rec := serve(h, "/", `{"prompt":"What is Go?"}`); rec.Header().Get(HeaderStatus) != StatusHit {
+		t.Errorf("second request = %s, want HIT", rec.Header().Get(HeaderStatus))
+	}
+}
+
+func TestFieldKey(t *testing.T) {
+	body := []byte(`{"prompt":"hi","messages":[{"role":"system","content":"be brief"},{"role":"user","content":"What is Go?"}],"n":2,"blank":" "}`)
+	tests := []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{"prompt", "hi", true},
+		{"messages.-1.content", "What is Go?", true},
+		{"messages.0.role", "system", true},
+		{"n", "2", true},
+		{"messages.2.content", "", false},
+		{"messages.x", "", false},
+		{"missing", "", false},
+		{"blank", "", false},
+		{"prompt.deeper", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := FieldKey(tt.path)(nil, body)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("FieldKey(%q) = %q, %v; want %q, %v", tt.path, got, ok, tt.want, tt.ok)
+		}
+	}
+	if _, ok := FieldKey("prompt")(nil, []byte("not json")); ok {
+		t.Error("FieldKey accepted a body that is not JSON")
+	}
+}
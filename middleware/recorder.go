@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+)
+
+// recorder passes a response through to the client while keeping a copy
+// to store, up to max bytes.
+type recorder struct {
+	http.ResponseWriter
+	status   int
+	wrote    bool
+	body     bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wrote {
+		r.status, r.wrote = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.wrote = true
+	if !r.overflow {
+		if r.body.Len()+len(p) > r.max {
+			r.overflow = true
+			r.body = bytes.Buffer{}
+		} else {
+			r.body.Write(p)
+		}
+	}
+	return r.ResponseWriter.Write(p)
+}
+
+// Flush lets streamed responses, such as server-sent events, reach the
+// client as they are written.
+func (r *recorder) Flush() {
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *recorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
+
+// response returns the recorded response if it may be stored.
+func (r *recorder) response() (Response, bool) {
+	h := r.Header()
+	if r.status != http.StatusOK || r.overflow || h.Get("Set-Cookie") != "" {
+		return Response{}, false
+	}
+	d := directives(h)
+	if slices.Contains(d, "no-store") || slices.Contains(d, "private") {
+		return Response{}, false
+	}
+	header := h.Clone()
+	header.Del(HeaderStatus)
+	header.Del(HeaderScore)
+	header.Del("Date")
+	return Response{Status: r.status, Header: header, Body: bytes.Clone(r.body.Bytes())}, true
+}
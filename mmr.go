@@ -0,0 +1,122 @@
+package semanticcache
+
+import (
+	"context"
+	"math"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// mmrPoolFactor is how many candidates per requested result TopMatchesMMR
+// ranks by relevance before diversifying.
+const mmrPoolFactor = 4
+
+// TopMatchesMMR returns up to n entries chosen by maximal marginal
+// relevance, so the results are not n paraphrases of one answer. It takes
+// the 4n entries most similar to inputText and repeatedly picks the one
+// maximizing lambda*relevance - (1-lambda)*redundancy, where redundancy is
+// its highest similarity to an entry already picked. lambda 1 is plain
+// TopMatches and 0 favors diversity alone; values outside [0, 1] return
+// ErrInvalidLambda. Results are in pick order and carry their similarity
+// to inputText as Score. Options are those of TopMatches.
+func (c *Cache[K, V]) TopMatchesMMR(ctx context.Context, inputText string, n int, lambda float64, opts ...LookupOption) ([]Match[V], error) {
+	ex, err := c.TopMatchesMMREx(ctx, inputText, n, lambda, opts...)
+	if err != nil {
+		return nil, err
+	}
+	matches := make([]Match[V], len(ex))
+	for i, m := range ex {
+		matches[i] = m.Match
+	}
+	return matches, nil
+}
+
+// TopMatchesMMREx is TopMatchesMMR, but each result also carries the
+// matched key and the entry's stored details.
+func (c *Cache[K, V]) TopMatchesMMREx(ctx context.Context, inputText string, n int, lambda float64, opts ...LookupOption) ([]MatchEx[K, V], error) {
+	if math.IsNaN(lambda) || lambda < 0 || lambda > 1 {
+		return nil, ErrInvalidLambda
+	}
+	if n <= 0 {
+		return nil, ErrInvalidN
+	}
+	pool, err := c.TopMatchesEx(ctx, inputText, n*mmrPoolFactor, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if len(pool) <= 1 || lambda == 1 {
+		return pool[:min(n, len(pool))], nil
+	}
+
+	cfg, err := c.newLookupConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	target := c
+	if cfg.namespace != "" {
+		target = c.Namespace(cfg.namespace)
+	}
+	keys := make([]K, len(pool))
+	for i, m := range pool {
+		keys[i] = m.Key
+	}
+	embeddings, err := target.embeddings(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	// redundancy[i] is pool[i]'s highest similarity to a picked entry, or
+	// 0 before one with a comparable embedding is picked.
+	redundancy := make([]float64, len(pool))
+	compared := make([]bool, len(pool))
+	picked := make([]bool, len(pool))
+	matches := make([]MatchEx[K, V], 0, min(n, len(pool)))
+	for len(matches) < cap(matches) {
+		best, bestScore := -1, math.Inf(-1)
+		for i, m := range pool {
+			if picked[i] {
+				continue
+			}
+			score := m.Score
+			if len(matches) > 0 {
+				score = lambda*m.Score - (1-lambda)*redundancy[i]
+			}
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		picked[best] = true
+		matches = append(matches, pool[best])
+		chosen, ok := embeddings[pool[best].Key]
+		for i := range pool {
+			emb, found := embeddings[pool[i].Key]
+			if picked[i] || !ok || !found || len(emb) != len(chosen) {
+				continue
+			}
+			if s := cfg.score(emb, chosen); !compared[i] || s > redundancy[i] {
+				redundancy[i], compared[i] = s, true
+			}
+		}
+	}
+	return matches, nil
+}
+
+// embeddings fetches the embeddings stored for keys, in one call when the
+// backend implements types.EmbeddingBatchBackend. Missing keys are absent
+// from the result.
+func (c *Cache[K, V]) embeddings(ctx context.Context, keys []K) (map[K][]float64, error) {
+	if eb, ok := c.backend.(types.EmbeddingBatchBackend[K, V]); ok {
+		return eb.GetEmbeddings(ctx, keys)
+	}
+	embeddings := make(map[K][]float64, len(keys))
+	for _, key := range keys {
+		emb, ok, err := c.backend.GetEmbedding(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			embeddings[key] = emb
+		}
+	}
+	return embeddings, nil
+}
@@ -0,0 +1,602 @@
+package semanticcache
+
+import (
+	"context"
+	"iter"
+	"maps"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// NamespaceMetadataKey is the metadata key that records an entry's
+// namespace when keys cannot be prefixed. It is hidden from MatchEx.
+const NamespaceMetadataKey = "semanticcache.namespace"
+
+// Namespace returns a view of the cache scoped to name. Set, Get, Delete,
+// Lookup, TopMatches, IterKeys, Len, and Flush on the view only see entries
+// written through a view of the same name, so one backend can serve many
+// tenants without cross-tenant semantic hits. Views of views nest, and the
+// view shares the parent's backend, provider, and closed state.
+//
+// Keys of string kind are stored as "name:key", which works with any
+// backend. Other key types are stored as-is and tagged with
+// NamespaceMetadataKey, which requires a types.EntryBackend and means a key
+// must be unique across namespaces; IterKeys, Len, and Flush then read every
+// entry to check its tag. Either way the name is escaped, "%", ":", and "/"
+// becoming "%25", "%3A", and "%2F", and a nested view's scope is its
+// parent's, a "/", and its own escaped name, so no two names or nestings
+// share a prefix or tag: Namespace("a") does not see the entries of
+// Namespace("a:b") or of Namespace("a").Namespace("b").
+func (c *Cache[K, V]) Namespace(name string) *Cache[K, V] {
+	return c.child(namespaceEscaper.Replace(name))
+}
+
+// namespaceEscaper escapes the characters that delimit namespace scopes.
+var namespaceEscaper = strings.NewReplacer("%", "%25", ":", "%3A", "/", "%2F")
+
+// child returns the view nested in c's scope under segment, which must be
+// escaped.
+func (c *Cache[K, V]) child(segment string) *Cache[K, V] {
+	if c.base != nil {
+		return c.scope(c.namespace + "/" + segment)
+	}
+	return c.scope(segment)
+}
+
+// scope returns the view of the escaped scope ns, as stored in the lexical
+// index, over c's unscoped backend.
+func (c *Cache[K, V]) scope(ns string) *Cache[K, V] {
+	base := c.backend
+	if c.base != nil {
+		base = c.base
+	}
+	return &Cache[K, V]{
+		backend:    newNamespacedBackend(base, ns),
+		provider:   c.provider,
+		comparator: c.comparator,
+		threshold:  c.threshold,
+		closed:     c.closed,
+		calls:      c.calls,
+		exact:      c.exact,
+		lexical:    c.lexical,
+		base:       base,
+		namespace:  ns,
+
+		searchWorkers: c.searchWorkers,
+		batchWorkers:  c.batchWorkers,
+		version:       c.version,
+		validateKey:   c.validateKey,
+		refresh:       c.refresh,
+		scheduler:     c.scheduler,
+		expiryAge:     c.expiryAge,
+		rerank:        c.rerank,
+		scoring:       c.scoring,
+		normalize:     c.normalize,
+		dims:          c.dims,
+		chunker:       c.chunker,
+		chunkVectors:  c.chunkVectors,
+		lateChunking:  c.lateChunking,
+		summary:       c.summary,
+		chunkStats:    c.chunkStats,
+		onChunk:       c.onChunk,
+		preprocess:    c.preprocess,
+		tracer:        c.tracer,
+		logger:        c.logger.With("namespace", ns),
+		breaker:       c.breaker,
+		queries:       c.queries,
+		alerts:        c.alerts,
+		loader:        c.loader,
+	}
+}
+
+// flushNested removes the entries of the namespaces nested in the view c,
+// which Flush leaves alone.
+func (c *Cache[K, V]) flushNested(ctx context.Context) error {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	if err := c.backend.(*namespacedBackend[K, V]).flushNested(ctx); err != nil {
+		return err
+	}
+	c.lexical.clearNested(c.namespace)
+	return nil
+}
+
+// namespacedBackend scopes an inner backend to one namespace, either by
+// prefixing string keys or by tagging entries.
+type namespacedBackend[K comparable, V any] struct {
+	inner  types.Backend[K, V]
+	name   string
+	prefix string // name + ":"; empty in tag mode
+}
+
+var (
+	_ types.EntryBackend[string, string]  = (*namespacedBackend[string, string])(nil)
+	_ types.KeyIterator[string, string]   = (*namespacedBackend[string, string])(nil)
+	_ types.Transactional[string, string] = (*namespacedBackend[string, string])(nil)
+
+	_ types.EmbeddingBatchBackend[string, string] = (*namespacedBackend[string, string])(nil)
+	_ types.ConditionalBackend[string, string]    = (*namespacedBackend[string, string])(nil)
+	_ types.ExpiringBackend[string, string]       = (*namespacedBackend[string, string])(nil)
+	_ types.PinningBackend[string, string]        = (*namespacedBackend[string, string])(nil)
+	_ types.Shutdowner[string, string]            = (*namespacedBackend[string, string])(nil)
+	_ types.MultiVectorBackend[string, string]    = (*namespacedBackend[string, string])(nil)
+)
+
+func newNamespacedBackend[K comparable, V any](inner types.Backend[K, V], name string) *namespacedBackend[K, V] {
+	b := &namespacedBackend[K, V]{inner: inner, name: name}
+	if reflect.TypeFor[K]().Kind() == reflect.String {
+		b.prefix = name + ":"
+	}
+	return b
+}
+
+// wrap converts a caller's key to the stored key.
+func (b *namespacedBackend[K, V]) wrap(key K) K {
+	if b.prefix == "" {
+		return key
+	}
+	v := reflect.ValueOf(&key).Elem()
+	v.SetString(b.prefix + v.String())
+	return key
+}
+
+// unwrap reverses wrap, reporting false for keys outside the namespace.
+// In tag mode every key is returned; callers check ownership separately.
+func (b *namespacedBackend[K, V]) unwrap(key K) (K, bool) {
+	if b.prefix == "" {
+		return key, true
+	}
+	v := reflect.ValueOf(&key).Elem()
+	s, ok := strings.CutPrefix(v.String(), b.prefix)
+	if !ok {
+		return key, false
+	}
+	v.SetString(s)
+	return key, true
+}
+
+func (b *namespacedBackend[K, V]) entryBackend() (types.EntryBackend[K, V], error) {
+	eb, ok := b.inner.(types.EntryBackend[K, V])
+	if !ok {
+		return nil, ErrMetadataUnsupported
+	}
+	return eb, nil
+}
+
+// tag returns entry with the namespace recorded in a copy of its metadata.
+func (b *namespacedBackend[K, V]) tag(entry types.Entry[V]) types.Entry[V] {
+	meta := make(map[string]string, len(entry.Metadata)+1)
+	maps.Copy(meta, entry.Metadata)
+	meta[NamespaceMetadataKey] = b.name
+	entry.Metadata = meta
+	return entry
+}
+
+// untag strips the namespace tag, reporting whether entry belongs here.
+func (b *namespacedBackend[K, V]) untag(entry types.Entry[V]) (types.Entry[V], bool) {
+	if entry.Metadata[NamespaceMetadataKey] != b.name {
+		return entry, false
+	}
+	meta := maps.Clone(entry.Metadata)
+	delete(meta, NamespaceMetadataKey)
+	if len(meta) == 0 {
+		meta = nil
+	}
+	entry.Metadata = meta
+	return entry, true
+}
+
+func (b *namespacedBackend[K, V]) Set(ctx context.Context, key K, embedding []float64, value V) error {
+	return b.SetEntry(ctx, key, types.Entry[V]{Embedding: embedding, Value: value})
+}
+
+func (b *namespacedBackend[K, V]) SetEntry(ctx context.Context, key K, entry types.Entry[V]) error {
+	if b.prefix != "" {
+		if eb, ok := b.inner.(types.EntryBackend[K, V]); ok {
+			return eb.SetEntry(ctx, b.wrap(key), entry)
+		}
+		if len(entry.Metadata) > 0 {
+			return ErrMetadataUnsupported
+		}
+		return b.inner.Set(ctx, b.wrap(key), entry.Embedding, entry.Value)
+	}
+	eb, err := b.entryBackend()
+	if err != nil {
+		return err
+	}
+	return eb.SetEntry(ctx, key, b.tag(entry))
+}
+
+func (b *namespacedBackend[K, V]) GetEntry(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	if b.prefix != "" {
+		if eb, ok := b.inner.(types.EntryBackend[K, V]); ok {
+			return eb.GetEntry(ctx, b.wrap(key))
+		}
+		v, ok, err := b.inner.Get(ctx, b.wrap(key))
+		return types.Entry[V]{Value: v}, ok, err
+	}
+	eb, err := b.entryBackend()
+	if err != nil {
+		return types.Entry[V]{}, false, err
+	}
+	entry, ok, err := eb.GetEntry(ctx, key)
+	if err != nil || !ok {
+		return types.Entry[V]{}, false, err
+	}
+	entry, ok = b.untag(entry)
+	if !ok {
+		return types.Entry[V]{}, false, nil
+	}
+	return entry, true, nil
+}
+
+// PeekEntry reads through an inner types.PeekingBackend, falling back to
+// GetEntry otherwise.
+func (b *namespacedBackend[K, V]) PeekEntry(ctx context.Context, key K) (types.Entry[V], bool, error) {
+	pb, ok := b.inner.(types.PeekingBackend[K, V])
+	if !ok {
+		return b.GetEntry(ctx, key)
+	}
+	if b.prefix != "" {
+		return pb.PeekEntry(ctx, b.wrap(key))
+	}
+	entry, ok, err := pb.PeekEntry(ctx, key)
+	if err != nil || !ok {
+		return types.Entry[V]{}, false, err
+	}
+	entry, ok = b.untag(entry)
+	if !ok {
+		return types.Entry[V]{}, false, nil
+	}
+	return entry, true, nil
+}
+
+func (b *namespacedBackend[K, V]) Get(ctx context.Context, key K) (V, bool, error) {
+	entry, ok, err := b.GetEntry(ctx, key)
+	return entry.Value, ok, err
+}
+
+// owns reports whether key is stored in this namespace.
+func (b *namespacedBackend[K, V]) owns(ctx context.Context, key K) (bool, error) {
+	if b.prefix != "" {
+		return b.inner.Contains(ctx, b.wrap(key))
+	}
+	_, ok, err := b.GetEntry(ctx, key)
+	return ok, err
+}
+
+func (b *namespacedBackend[K, V]) Contains(ctx context.Context, key K) (bool, error) {
+	return b.owns(ctx, key)
+}
+
+func (b *namespacedBackend[K, V]) Delete(ctx context.Context, key K) error {
+	if b.prefix == "" {
+		ok, err := b.owns(ctx, key)
+		if err != nil || !ok {
+			return err
+		}
+	}
+	return b.inner.Delete(ctx, b.wrap(key))
+}
+
+func (b *namespacedBackend[K, V]) GetEmbedding(ctx context.Context, key K) ([]float64, bool, error) {
+	if b.prefix == "" {
+		entry, ok, err := b.GetEntry(ctx, key)
+		return entry.Embedding, ok, err
+	}
+	return b.inner.GetEmbedding(ctx, b.wrap(key))
+}
+
+// GetEmbeddings forwards prefixed keys to an inner
+// types.EmbeddingBatchBackend and fetches them one by one otherwise.
+func (b *namespacedBackend[K, V]) GetEmbeddings(ctx context.Context, keys []K) (map[K][]float64, error) {
+	result := make(map[K][]float64, len(keys))
+	if eb, ok := b.inner.(types.EmbeddingBatchBackend[K, V]); ok && b.prefix != "" {
+		wrapped := make([]K, len(keys))
+		for i, key := range keys {
+			wrapped[i] = b.wrap(key)
+		}
+		embeddings, err := eb.GetEmbeddings(ctx, wrapped)
+		if err != nil {
+			return nil, err
+		}
+		for i, key := range wrapped {
+			if emb, ok := embeddings[key]; ok {
+				result[keys[i]] = emb
+			}
+		}
+		return result, nil
+	}
+	for _, key := range keys {
+		emb, ok, err := b.GetEmbedding(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result[key] = emb
+		}
+	}
+	return result, nil
+}
+
+// GetVectors forwards prefixed keys to an inner types.MultiVectorBackend and
+// fetches entries one by one in tag mode. Inner backends that keep a single
+// vector report just the embedding.
+func (b *namespacedBackend[K, V]) GetVectors(ctx context.Context, keys []K) (map[K][][]float64, error) {
+	result := make(map[K][][]float64, len(keys))
+	mb, ok := b.inner.(types.MultiVectorBackend[K, V])
+	if !ok {
+		embeddings, err := b.GetEmbeddings(ctx, keys)
+		for key, emb := range embeddings {
+			result[key] = [][]float64{emb}
+		}
+		return result, err
+	}
+	if b.prefix != "" {
+		wrapped := make([]K, len(keys))
+		for i, key := range keys {
+			wrapped[i] = b.wrap(key)
+		}
+		vectors, err := mb.GetVectors(ctx, wrapped)
+		if err != nil {
+			return nil, err
+		}
+		for i, key := range wrapped {
+			if embs, ok := vectors[key]; ok {
+				result[keys[i]] = embs
+			}
+		}
+		return result, nil
+	}
+	for _, key := range keys {
+		entry, ok, err := b.GetEntry(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result[key] = append([][]float64{entry.Embedding}, entry.Vectors...)
+		}
+	}
+	return result, nil
+}
+
+// EntryStats forwards to an inner types.StatsBackend, and reports nothing
+// otherwise. In tag mode keys are not checked for ownership; callers pass
+// keys the namespace yielded.
+func (b *namespacedBackend[K, V]) EntryStats(ctx context.Context, keys []K) (map[K]types.EntryStats, error) {
+	result := make(map[K]types.EntryStats, len(keys))
+	sb, ok := b.inner.(types.StatsBackend[K, V])
+	if !ok {
+		return result, nil
+	}
+	wrapped := make([]K, len(keys))
+	for i, key := range keys {
+		wrapped[i] = b.wrap(key)
+	}
+	stats, err := sb.EntryStats(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	for i, key := range wrapped {
+		if s, ok := stats[key]; ok {
+			result[keys[i]] = s
+		}
+	}
+	return result, nil
+}
+
+// IterKeys yields the namespace's keys as the caller wrote them.
+func (b *namespacedBackend[K, V]) IterKeys(ctx context.Context) iter.Seq2[K, error] {
+	return func(yield func(K, error) bool) {
+		for key, err := range backendKeys(ctx, b.inner) {
+			if err != nil {
+				yield(key, err)
+				return
+			}
+			key, ok := b.unwrap(key)
+			if !ok {
+				continue
+			}
+			if b.prefix == "" {
+				if ok, err = b.owns(ctx, key); err != nil {
+					yield(key, err)
+					return
+				}
+				if !ok {
+					continue
+				}
+			}
+			if !yield(key, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (b *namespacedBackend[K, V]) Keys(ctx context.Context) ([]K, error) {
+	var keys []K
+	for key, err := range b.IterKeys(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (b *namespacedBackend[K, V]) Len(ctx context.Context) (int, error) {
+	keys, err := b.Keys(ctx)
+	return len(keys), err
+}
+
+// Flush deletes only the namespace's entries.
+func (b *namespacedBackend[K, V]) Flush(ctx context.Context) error {
+	keys, err := b.Keys(ctx)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := b.inner.Delete(ctx, b.wrap(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flushNested deletes the entries of every namespace nested in this one,
+// whose stored keys or tags start with the name and a "/".
+func (b *namespacedBackend[K, V]) flushNested(ctx context.Context) error {
+	nested := b.name + "/"
+	var eb types.EntryBackend[K, V]
+	if b.prefix == "" {
+		var err error
+		if eb, err = b.entryBackend(); err != nil {
+			return err
+		}
+	}
+	var keys []K
+	for key, err := range backendKeys(ctx, b.inner) {
+		if err != nil {
+			return err
+		}
+		if b.prefix != "" {
+			if strings.HasPrefix(reflect.ValueOf(key).String(), nested) {
+				keys = append(keys, key)
+			}
+			continue
+		}
+		entry, ok, err := eb.GetEntry(ctx, key)
+		if err != nil {
+			return err
+		}
+		if ok && strings.HasPrefix(entry.Metadata[NamespaceMetadataKey], nested) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return deleteKeys(ctx, b.inner, keys)
+}
+
+// Txn forwards to the inner backend when it is transactional.
+func (b *namespacedBackend[K, V]) Txn(ctx context.Context, ops []types.TxnOp[K, V]) error {
+	tb, ok := b.inner.(types.Transactional[K, V])
+	if !ok {
+		return ErrTxnUnsupported
+	}
+	if b.prefix == "" {
+		if _, err := b.entryBackend(); err != nil {
+			return err
+		}
+	}
+	scoped := make([]types.TxnOp[K, V], len(ops))
+	for i, op := range ops {
+		scoped[i] = types.TxnOp[K, V]{Key: b.wrap(op.Key), Entry: op.Entry, Delete: op.Delete}
+		if b.prefix == "" && !op.Delete {
+			scoped[i].Entry = b.tag(op.Entry)
+		}
+	}
+	return tb.Txn(ctx, scoped)
+}
+
+// SetIfAbsent forwards to the inner backend when it supports conditional
+// writes. In tag mode a key held by another namespace counts as present.
+func (b *namespacedBackend[K, V]) SetIfAbsent(ctx context.Context, key K, entry types.Entry[V]) (bool, error) {
+	cb, ok := b.inner.(types.ConditionalBackend[K, V])
+	if !ok {
+		return false, ErrConditionalUnsupported
+	}
+	if b.prefix == "" {
+		if _, err := b.entryBackend(); err != nil {
+			return false, err
+		}
+		entry = b.tag(entry)
+	}
+	return cb.SetIfAbsent(ctx, b.wrap(key), entry)
+}
+
+// CompareAndSwap forwards to the inner backend when it supports conditional
+// writes. In tag mode ownership is checked before the swap.
+func (b *namespacedBackend[K, V]) CompareAndSwap(ctx context.Context, key K, old V, entry types.Entry[V]) (bool, error) {
+	cb, ok := b.inner.(types.ConditionalBackend[K, V])
+	if !ok {
+		return false, ErrConditionalUnsupported
+	}
+	if b.prefix == "" {
+		owned, err := b.owns(ctx, key)
+		if err != nil || !owned {
+			return false, err
+		}
+		entry = b.tag(entry)
+	}
+	return cb.CompareAndSwap(ctx, b.wrap(key), old, entry)
+}
+
+// Expire forwards to the inner backend when it supports expiry.
+func (b *namespacedBackend[K, V]) Expire(ctx context.Context, key K, ttl time.Duration) (bool, error) {
+	return forward(ctx, b, key, ErrExpiryUnsupported, func(eb types.ExpiringBackend[K, V], key K) (bool, error) {
+		return eb.Expire(ctx, key, ttl)
+	})
+}
+
+// Persist forwards to the inner backend when it supports expiry.
+func (b *namespacedBackend[K, V]) Persist(ctx context.Context, key K) (bool, error) {
+	return forward(ctx, b, key, ErrExpiryUnsupported, func(eb types.ExpiringBackend[K, V], key K) (bool, error) {
+		return eb.Persist(ctx, key)
+	})
+}
+
+// Touch forwards to the inner backend when it supports expiry, and reads
+// the key otherwise.
+func (b *namespacedBackend[K, V]) Touch(ctx context.Context, key K) (bool, error) {
+	if _, ok := b.inner.(types.ExpiringBackend[K, V]); !ok {
+		_, ok, err := b.GetEntry(ctx, key)
+		return ok, err
+	}
+	return forward(ctx, b, key, ErrExpiryUnsupported, func(eb types.ExpiringBackend[K, V], key K) (bool, error) {
+		return eb.Touch(ctx, key)
+	})
+}
+
+// Pin forwards to the inner backend when it supports pinning.
+func (b *namespacedBackend[K, V]) Pin(ctx context.Context, key K) (bool, error) {
+	return forward(ctx, b, key, ErrPinUnsupported, func(pb types.PinningBackend[K, V], key K) (bool, error) {
+		return pb.Pin(ctx, key)
+	})
+}
+
+// Unpin forwards to the inner backend when it supports pinning.
+func (b *namespacedBackend[K, V]) Unpin(ctx context.Context, key K) (bool, error) {
+	return forward(ctx, b, key, ErrPinUnsupported, func(pb types.PinningBackend[K, V], key K) (bool, error) {
+		return pb.Unpin(ctx, key)
+	})
+}
+
+// forward applies fn to the stored key on b's inner backend as optional
+// interface T, after checking ownership in tag mode. It returns unsupported
+// when the inner backend does not implement T.
+func forward[T any, K comparable, V any](ctx context.Context, b *namespacedBackend[K, V], key K, unsupported error, fn func(T, K) (bool, error)) (bool, error) {
+	inner, ok := b.inner.(T)
+	if !ok {
+		return false, unsupported
+	}
+	if b.prefix == "" {
+		owned, err := b.owns(ctx, key)
+		if err != nil || !owned {
+			return false, err
+		}
+	}
+	return fn(inner, b.wrap(key))
+}
+
+func (b *namespacedBackend[K, V]) Close() error { return b.inner.Close() }
+
+func (b *namespacedBackend[K, V]) Shutdown(ctx context.Context) error {
+	return shutdown(ctx, b.inner)
+}
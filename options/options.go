@@ -3,13 +3,21 @@ package options
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
 
+	"github.com/botirk38/semanticcache/backends/composite"
 	"github.com/botirk38/semanticcache/backends/inmemory"
 	"github.com/botirk38/semanticcache/backends/remote"
+	"github.com/botirk38/semanticcache/chunker"
+	"github.com/botirk38/semanticcache/preprocess"
 	"github.com/botirk38/semanticcache/providers/local"
 	"github.com/botirk38/semanticcache/providers/openai"
 	"github.com/botirk38/semanticcache/similarity"
 	"github.com/botirk38/semanticcache/types"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Sentinel errors for configuration validation.
@@ -22,8 +30,140 @@ var (
 
 	// ErrNilComparator is returned when a nil similarity function is provided.
 	ErrNilComparator = errors.New("options: similarity comparator cannot be nil")
+
+	// ErrNilKeyValidator is returned when a nil key validator is provided.
+	ErrNilKeyValidator = errors.New("options: key validator cannot be nil")
+
+	// ErrInvalidThreshold is returned when the default threshold is NaN.
+	ErrInvalidThreshold = errors.New("options: threshold must be a number")
+
+	// ErrInvalidExactMatchSize is returned when the exact-match index size
+	// is not positive.
+	ErrInvalidExactMatchSize = errors.New("options: exact-match index size must be positive")
+
+	// ErrExactMatchUnsupported is returned when the exact-match fast path is
+	// enabled on a backend that does not implement types.EntryBackend.
+	ErrExactMatchUnsupported = errors.New("options: exact-match fast path needs a backend that stores input text")
+
+	// ErrInvalidSearchConcurrency is returned when the search concurrency
+	// is not positive.
+	ErrInvalidSearchConcurrency = errors.New("options: search concurrency must be positive")
+
+	// ErrInvalidBatchConcurrency is returned when the batch embedding
+	// concurrency is not positive.
+	ErrInvalidBatchConcurrency = errors.New("options: batch concurrency must be positive")
+
+	// ErrInvalidRefreshInterval is returned when the refresh-ahead interval
+	// is not positive or its maximum age is negative.
+	ErrInvalidRefreshInterval = errors.New("options: refresh interval must be positive and max age non-negative")
+
+	// ErrInvalidRefreshBatchSize is returned when the refresh batch size is
+	// not positive.
+	ErrInvalidRefreshBatchSize = errors.New("options: refresh batch size must be positive")
+
+	// ErrRefreshUnsupported is returned when refresh-ahead or re-embedding
+	// on lookup is enabled on a backend that does not implement
+	// types.EntryBackend.
+	ErrRefreshUnsupported = errors.New("options: re-embedding needs a backend that stores input text")
+
+	// ErrEvictionUnsupported is returned when an eviction callback is set
+	// but the backend does not implement types.EvictionNotifier.
+	ErrEvictionUnsupported = errors.New("options: backend does not support eviction callbacks")
+
+	// ErrNilReranker is returned when a nil reranker is provided.
+	ErrNilReranker = errors.New("options: reranker cannot be nil")
+
+	// ErrInvalidRerank is returned when the rerank candidate count is not
+	// positive or its timeout is negative.
+	ErrInvalidRerank = errors.New("options: rerank top-k must be positive and timeout non-negative")
+
+	// ErrInvalidBoost is returned when a recency or frequency weight is
+	// outside [0, 1] or the recency half-life is not positive.
+	ErrInvalidBoost = errors.New("options: boost weight must be in [0, 1] and half-life positive")
+
+	// ErrStatsUnsupported is returned when recency or frequency boosts are
+	// set but the backend does not implement types.StatsBackend.
+	ErrStatsUnsupported = errors.New("options: score boosts need a backend that reports entry stats")
+
+	// ErrInvalidDimensions is returned when the expected embedding
+	// dimension is negative.
+	ErrInvalidDimensions = errors.New("options: embedding dimension must not be negative")
+
+	// ErrNilChunker is returned when a nil chunker is provided.
+	ErrNilChunker = errors.New("options: chunker cannot be nil")
+
+	// ErrChunkVectorsUnsupported is returned when chunk vectors are
+	// enabled without a chunker or on a backend that does not implement
+	// types.MultiVectorBackend.
+	ErrChunkVectorsUnsupported = errors.New("options: chunk vectors need a chunker and a multi-vector backend")
+
+	// ErrNilPreprocessor is returned when a nil preprocessing function is
+	// provided.
+	ErrNilPreprocessor = errors.New("options: preprocessor cannot be nil")
+
+	// ErrLateChunkingUnsupported is returned when late chunking is enabled
+	// without a chunker or with a provider that does not implement
+	// types.TokenEmbeddingProvider.
+	ErrLateChunkingUnsupported = errors.New("options: late chunking needs a chunker and a token embedding provider")
+
+	// ErrNilSummarizer is returned when a nil summarizer is provided.
+	ErrNilSummarizer = errors.New("options: summarizer cannot be nil")
+
+	// ErrInvalidSummarizer is returned when chunk summaries are enabled
+	// without a chunker, or with a non-positive concurrency or a negative
+	// token minimum.
+	ErrInvalidSummarizer = errors.New("options: chunk summaries need a chunker, positive concurrency, and non-negative min tokens")
+
+	// ErrNilTracerProvider is returned when a nil tracer provider is provided.
+	ErrNilTracerProvider = errors.New("options: tracer provider cannot be nil")
+
+	// ErrNilLogger is returned when a nil logger is provided.
+	ErrNilLogger = errors.New("options: logger cannot be nil")
+
+	// ErrInvalidCircuitBreaker is returned when a circuit breaker has a
+	// non-positive failure threshold or cooldown, or when the exact
+	// fallback is enabled without a circuit breaker.
+	ErrInvalidCircuitBreaker = errors.New("options: circuit breaker needs a positive failure threshold and cooldown, and exact fallback needs a circuit breaker")
+
+	// ErrInvalidLexicalSweep is returned when the lexical index sweep
+	// interval is not positive or no lexical index is enabled.
+	ErrInvalidLexicalSweep = errors.New("options: lexical sweep needs a positive interval and a lexical index")
+
+	// ErrInvalidExpirySweep is returned when the expiry sweep interval or
+	// maximum age is not positive.
+	ErrInvalidExpirySweep = errors.New("options: expiry sweep needs a positive interval and max age")
+
+	// ErrExpirySweepUnsupported is returned when the expiry sweep is
+	// enabled on a backend that does not store creation times.
+	ErrExpirySweepUnsupported = errors.New("options: expiry sweep needs a backend that stores creation times")
+
+	// ErrInvalidMaintenanceRate is returned when the background job rate
+	// limit is not a positive number.
+	ErrInvalidMaintenanceRate = errors.New("options: maintenance rate must be positive")
+
+	// ErrInvalidMaintenanceJitter is returned when the background job
+	// jitter is outside [0, 1).
+	ErrInvalidMaintenanceJitter = errors.New("options: maintenance jitter must be in [0, 1)")
+
+	// ErrInvalidAlert is returned when an alert hook is nil, its window is
+	// not positive, or its limit is out of range.
+	ErrInvalidAlert = errors.New("options: alert needs a hook, a positive window, and a limit in range")
 )
 
+const (
+	// DefaultThreshold is the similarity threshold LookupWithOptions uses
+	// when neither WithDefaultThreshold nor a per-call threshold is given.
+	DefaultThreshold = 0.8
+
+	// DefaultRefreshBatchSize is how many entries a refresh re-embeds per
+	// provider call unless WithRefreshBatchSize says otherwise.
+	DefaultRefreshBatchSize = 32
+)
+
+// KeyValidator checks a key before it is written. A non-nil error rejects
+// the write and is returned to the caller.
+type KeyValidator[K comparable] func(key K) error
+
 // Option configures a cache instance.
 type Option[K comparable, V any] func(*Config[K, V]) error
 
@@ -32,12 +172,67 @@ type Config[K comparable, V any] struct {
 	Backend    types.Backend[K, V]
 	Provider   types.EmbeddingProvider
 	Comparator similarity.SimilarityFunc
+	OnEvict    func(key K, entry types.Entry[V])
+	Loader     composite.Loader[K, V]
+	Threshold  float64
+	ExactMatch int
+
+	SearchConcurrency int
+	BatchConcurrency  int
+	EmbeddingVersion  string
+	RefreshInterval   time.Duration
+	RefreshMaxAge     time.Duration
+	RefreshBatchSize  int
+	ReembedOnLookup   bool
+	KeyValidator      KeyValidator[K]
+	NormalizeScores   bool
+	CheckDimensions   bool
+	Dimensions        int
+	LexicalIndex      bool
+	LexicalSweep      time.Duration
+	ExpirySweep       time.Duration
+	ExpiryMaxAge      time.Duration
+	MaintenanceRate   float64
+	MaintenanceJitter float64
+	RecencyHalfLife   time.Duration
+	RecencyWeight     float64
+	FrequencyWeight   float64
+	Reranker          types.Reranker
+	RerankTopK        int
+	RerankTimeout     time.Duration
+	Chunker           chunker.Chunker
+	ChunkConfig       *chunker.ChunkConfig
+	ChunkVectors      bool
+	LateChunking      bool
+	OnChunk           func(chunker.Report)
+	Summarizer        types.Summarizer
+	SummaryWorkers    int
+	SummaryMinTokens  int
+	Preprocess        preprocess.Func
+	TracerProvider    trace.TracerProvider
+	Logger            *slog.Logger
+	BreakerFailures   int
+	BreakerCooldown   time.Duration
+	ExactFallback     bool
+
+	MissRateAlert       float64
+	MissRateWindow      time.Duration
+	MissRateMinLookups  int
+	OnMissRate          func(rate float64, lookups int)
+	ProviderErrorBurst  int
+	ProviderErrorWindow time.Duration
+	OnProviderErrors    func(failures int, last error)
 }
 
 // NewConfig returns a Config with sensible defaults.
 func NewConfig[K comparable, V any]() *Config[K, V] {
 	return &Config[K, V]{
 		Comparator: similarity.CosineSimilarity,
+		Threshold:  DefaultThreshold,
+
+		SearchConcurrency: 1,
+		BatchConcurrency:  1,
+		RefreshBatchSize:  DefaultRefreshBatchSize,
 	}
 }
 
@@ -59,15 +254,59 @@ func (c *Config[K, V]) Validate() error {
 	if c.Provider == nil {
 		return ErrNilProvider
 	}
+	if c.OnEvict != nil {
+		if _, ok := c.Backend.(types.EvictionNotifier[K, V]); !ok {
+			return ErrEvictionUnsupported
+		}
+	}
+	if c.ExactMatch > 0 {
+		if _, ok := c.Backend.(types.EntryBackend[K, V]); !ok {
+			return ErrExactMatchUnsupported
+		}
+	}
+	if c.RecencyWeight > 0 || c.FrequencyWeight > 0 {
+		if _, ok := c.Backend.(types.StatsBackend[K, V]); !ok {
+			return ErrStatsUnsupported
+		}
+	}
+	if c.RefreshInterval > 0 || c.ReembedOnLookup {
+		if _, ok := c.Backend.(types.EntryBackend[K, V]); !ok {
+			return ErrRefreshUnsupported
+		}
+	}
+	if c.ChunkVectors {
+		if _, ok := c.Backend.(types.MultiVectorBackend[K, V]); !ok || c.Chunker == nil && c.ChunkConfig == nil {
+			return ErrChunkVectorsUnsupported
+		}
+	}
+	if c.Summarizer != nil && c.Chunker == nil && c.ChunkConfig == nil {
+		return ErrInvalidSummarizer
+	}
+	if c.LateChunking {
+		if _, ok := c.Provider.(types.TokenEmbeddingProvider); !ok || c.Chunker == nil && c.ChunkConfig == nil {
+			return ErrLateChunkingUnsupported
+		}
+	}
+	if c.LexicalSweep > 0 && !c.LexicalIndex {
+		return ErrInvalidLexicalSweep
+	}
+	if c.ExpirySweep > 0 {
+		if _, ok := c.Backend.(types.EntryBackend[K, V]); !ok {
+			return ErrExpirySweepUnsupported
+		}
+	}
+	if c.ExactFallback && c.BreakerFailures == 0 {
+		return ErrInvalidCircuitBreaker
+	}
 	return nil
 }
 
 // ---------- backend options ----------
 
 // WithLRUBackend sets up an LRU in-memory backend.
-func WithLRUBackend[K comparable, V any](capacity int) Option[K, V] {
+func WithLRUBackend[K comparable, V any](capacity int, opts ...inmemory.Option) Option[K, V] {
 	return func(cfg *Config[K, V]) error {
-		b, err := inmemory.NewLRUBackend[K, V](capacity)
+		b, err := inmemory.NewLRUBackend[K, V](capacity, opts...)
 		if err != nil {
 			return err
 		}
@@ -77,9 +316,9 @@ func WithLRUBackend[K comparable, V any](capacity int) Option[K, V] {
 }
 
 // WithFIFOBackend sets up a FIFO in-memory backend.
-func WithFIFOBackend[K comparable, V any](capacity int) Option[K, V] {
+func WithFIFOBackend[K comparable, V any](capacity int, opts ...inmemory.Option) Option[K, V] {
 	return func(cfg *Config[K, V]) error {
-		b, err := inmemory.NewFIFOBackend[K, V](capacity)
+		b, err := inmemory.NewFIFOBackend[K, V](capacity, opts...)
 		if err != nil {
 			return err
 		}
@@ -89,9 +328,73 @@ func WithFIFOBackend[K comparable, V any](capacity int) Option[K, V] {
 }
 
 // WithLFUBackend sets up an LFU in-memory backend.
-func WithLFUBackend[K comparable, V any](capacity int) Option[K, V] {
+func WithLFUBackend[K comparable, V any](capacity int, opts ...inmemory.Option) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		b, err := inmemory.NewLFUBackend[K, V](capacity, opts...)
+		if err != nil {
+			return err
+		}
+		cfg.Backend = b
+		return nil
+	}
+}
+
+// WithTinyLFUBackend sets up a W-TinyLFU in-memory backend.
+func WithTinyLFUBackend[K comparable, V any](capacity int) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		b, err := inmemory.NewTinyLFUBackend[K, V](capacity)
+		if err != nil {
+			return err
+		}
+		cfg.Backend = b
+		return nil
+	}
+}
+
+// WithTTLBackend sets up an in-memory backend whose entries expire ttl after
+// they are written. capacity <= 0 means unbounded.
+func WithTTLBackend[K comparable, V any](capacity int, ttl time.Duration) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		b, err := inmemory.NewTTLBackend[K, V](capacity, ttl)
+		if err != nil {
+			return err
+		}
+		cfg.Backend = b
+		return nil
+	}
+}
+
+// WithShardedBackend sets up a sharded LRU in-memory backend. shards <= 0
+// uses one shard per available CPU.
+func WithShardedBackend[K comparable, V any](shards, capacity int) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		b, err := inmemory.NewShardedBackend[K, V](shards, capacity)
+		if err != nil {
+			return err
+		}
+		cfg.Backend = b
+		return nil
+	}
+}
+
+// WithHNSWBackend sets up an in-memory backend with an HNSW vector index.
+func WithHNSWBackend[K comparable, V any](opts ...inmemory.Option) Option[K, V] {
 	return func(cfg *Config[K, V]) error {
-		b, err := inmemory.NewLFUBackend[K, V](capacity)
+		b, err := inmemory.NewHNSWBackend[K, V](opts...)
+		if err != nil {
+			return err
+		}
+		cfg.Backend = b
+		return nil
+	}
+}
+
+// WithBinaryBackend sets up an in-memory backend that stores binary-quantized
+// embeddings and searches them by Hamming distance with a full-precision
+// rerank.
+func WithBinaryBackend[K comparable, V any](opts ...inmemory.Option) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		b, err := inmemory.NewBinaryBackend[K, V](opts...)
 		if err != nil {
 			return err
 		}
@@ -113,6 +416,46 @@ func WithRedisBackend[K comparable, V any](addr string, opts ...remote.RedisOpti
 	}
 }
 
+// WithTieredBackend layers an L1 backend (typically in-memory) over a durable
+// L2 backend (typically Redis) with write-through semantics.
+func WithTieredBackend[K comparable, V any](l1, l2 types.Backend[K, V], opts ...composite.TieredOption) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		b, err := composite.NewTieredBackend(l1, l2, opts...)
+		if err != nil {
+			return err
+		}
+		cfg.Backend = b
+		return nil
+	}
+}
+
+// WithWriteBehindBackend acknowledges writes from a fast primary backend and
+// replicates them asynchronously to a durable secondary.
+func WithWriteBehindBackend[K comparable, V any](primary, secondary types.Backend[K, V], opts ...composite.WriteBehindOption) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		b, err := composite.NewWriteBehindBackend(primary, secondary, opts...)
+		if err != nil {
+			return err
+		}
+		cfg.Backend = b
+		return nil
+	}
+}
+
+// WithOffloadBackend stores values larger than the configured threshold in
+// an object store such as S3 or GCS, keeping only a reference and the
+// embedding in inner.
+func WithOffloadBackend[K comparable, V any](inner types.Backend[K, composite.OffloadedValue], store composite.ObjectStore, opts ...composite.OffloadOption) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		b, err := composite.NewOffloadBackend[K, V](inner, store, opts...)
+		if err != nil {
+			return err
+		}
+		cfg.Backend = b
+		return nil
+	}
+}
+
 // WithCustomBackend uses a pre-constructed backend.
 func WithCustomBackend[K comparable, V any](backend types.Backend[K, V]) Option[K, V] {
 	return func(cfg *Config[K, V]) error {
@@ -124,6 +467,208 @@ func WithCustomBackend[K comparable, V any](backend types.Backend[K, V]) Option[
 	}
 }
 
+// WithEvictionCallback registers fn to be called whenever the backend evicts
+// an entry on its own. The backend must implement types.EvictionNotifier;
+// all in-memory backends do. The option may appear before or after the
+// backend option.
+//
+// fn runs while the backend holds its internal lock, as with
+// types.EvictionNotifier, so it must not call back into the cache or the
+// backend: a Get from fn deadlocks the LRU and TTL backends. Hand the key
+// to another goroutine for any follow-up work.
+func WithEvictionCallback[K comparable, V any](fn func(key K, entry types.Entry[V])) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		cfg.OnEvict = fn
+		return nil
+	}
+}
+
+// WithExactMatch enables the exact-match fast path: lookups whose text
+// is identical to a stored entry's text, case and spacing included, return
+// that entry with score 1 without embedding the query or scanning the backend.
+// The cache remembers the keys of the last maxEntries distinct texts it
+// wrote; the backend must implement types.EntryBackend.
+func WithExactMatch[K comparable, V any](maxEntries int) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if maxEntries <= 0 {
+			return ErrInvalidExactMatchSize
+		}
+		cfg.ExactMatch = maxEntries
+		return nil
+	}
+}
+
+// WithLexicalIndex keeps an in-memory BM25 index over the input texts
+// written through the cache, for lookups passed semanticcache.WithHybrid or
+// semanticcache.WithHybridRRF. Texts already in a persistent backend when
+// the cache starts are not indexed.
+func WithLexicalIndex[K comparable, V any]() Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		cfg.LexicalIndex = true
+		return nil
+	}
+}
+
+// WithLexicalSweep checks every interval that each text in the lexical index
+// still has an entry in the backend, and drops those that do not. Backend
+// evictions reported through types.EvictionNotifier are dropped at once, so
+// the sweep matters for entries that expire or are evicted where the cache
+// cannot see it, as with Redis. It calls Contains once per indexed text.
+// Needs WithLexicalIndex.
+func WithLexicalSweep[K comparable, V any](interval time.Duration) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if interval <= 0 {
+			return ErrInvalidLexicalSweep
+		}
+		cfg.LexicalSweep = interval
+		return nil
+	}
+}
+
+// WithExpirySweep deletes entries created more than maxAge ago every
+// interval, for backends that keep entries until they are evicted or
+// deleted. Unlike a TTL backend's expiry, it is checked only at each sweep,
+// which reads every entry, and it deletes pinned entries too. Entries
+// without a creation time are kept. The backend must implement
+// types.EntryBackend.
+func WithExpirySweep[K comparable, V any](interval, maxAge time.Duration) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if interval <= 0 || maxAge <= 0 {
+			return ErrInvalidExpirySweep
+		}
+		cfg.ExpirySweep = interval
+		cfg.ExpiryMaxAge = maxAge
+		return nil
+	}
+}
+
+// WithMaintenanceRate limits the background jobs started by
+// WithRefreshAhead, WithLexicalSweep, and WithExpirySweep to perSecond
+// entries per second between them, counting entries re-embedded, checked,
+// or deleted, so maintenance does not compete with traffic for the
+// provider or backend. Defaults to no limit.
+func WithMaintenanceRate[K comparable, V any](perSecond float64) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if !(perSecond > 0) || math.IsInf(perSecond, 1) {
+			return ErrInvalidMaintenanceRate
+		}
+		cfg.MaintenanceRate = perSecond
+		return nil
+	}
+}
+
+// WithMaintenanceJitter shifts each wait between a background job's runs
+// by a random amount of up to fraction of its interval either way, so
+// instances started together do not sweep in step. fraction must be in
+// [0, 1). Defaults to 0.
+func WithMaintenanceJitter[K comparable, V any](fraction float64) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if !(fraction >= 0 && fraction < 1) {
+			return ErrInvalidMaintenanceJitter
+		}
+		cfg.MaintenanceJitter = fraction
+		return nil
+	}
+}
+
+// WithSearchConcurrency sets how many goroutines fetch and score embeddings
+// when a lookup scans the backend. Defaults to 1 (a serial scan);
+// runtime.GOMAXPROCS(0) suits CPU-bound in-memory backends, and higher
+// values hide round-trip latency on remote ones. Backends answering
+// lookups through types.VectorSearcher do not scan.
+func WithSearchConcurrency[K comparable, V any](n int) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if n <= 0 {
+			return ErrInvalidSearchConcurrency
+		}
+		cfg.SearchConcurrency = n
+		return nil
+	}
+}
+
+// WithBatchConcurrency sets how many texts of a batch are embedded at once
+// when the provider does not implement types.BatchEmbeddingProvider: the
+// texts of SetBatch, SetMulti, Txn, and refreshes, and the chunks of long
+// texts. Defaults to 1 (one call at a time). The first failure cancels the
+// calls still running and is returned.
+func WithBatchConcurrency[K comparable, V any](n int) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if n <= 0 {
+			return ErrInvalidBatchConcurrency
+		}
+		cfg.BatchConcurrency = n
+		return nil
+	}
+}
+
+// WithEmbeddingVersion stamps new entries with version, an identifier for
+// the provider's embedding model. It defaults to the provider's own version
+// when the provider implements types.VersionedProvider. Entries carrying any
+// other version are skipped by lookups and re-embedded by Cache.Refresh and
+// WithRefreshAhead.
+func WithEmbeddingVersion[K comparable, V any](version string) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		cfg.EmbeddingVersion = version
+		return nil
+	}
+}
+
+// WithReembedOnLookup makes lookups re-embed the incompatible entries they
+// skip: those stamped with another embedding version, or whose embedding
+// has a different dimension than the query's. They are rewritten from
+// their input text so later lookups can match them. The backend must
+// implement types.EntryBackend.
+func WithReembedOnLookup[K comparable, V any]() Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		cfg.ReembedOnLookup = true
+		return nil
+	}
+}
+
+// WithRefreshAhead starts a background refresher that runs Cache.Refresh
+// every interval until the cache is closed. Entries created more than maxAge
+// ago, or with an embedding version other than WithEmbeddingVersion's, are
+// re-embedded from their input text; a zero maxAge refreshes on version
+// changes only. The backend must implement types.EntryBackend.
+func WithRefreshAhead[K comparable, V any](interval, maxAge time.Duration) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if interval <= 0 || maxAge < 0 {
+			return ErrInvalidRefreshInterval
+		}
+		cfg.RefreshInterval = interval
+		cfg.RefreshMaxAge = maxAge
+		return nil
+	}
+}
+
+// WithRefreshBatchSize sets how many stale entries a refresh re-embeds per
+// provider call. Defaults to DefaultRefreshBatchSize.
+func WithRefreshBatchSize[K comparable, V any](n int) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if n <= 0 {
+			return ErrInvalidRefreshBatchSize
+		}
+		cfg.RefreshBatchSize = n
+		return nil
+	}
+}
+
+// WithReadThrough makes Get and GetBatch misses call loader. Loaded values
+// are stored with Cache.Set, under the loaded text, and returned, so the
+// cache acts as a read-through semantic store. Unlike wrapping the backend
+// in composite.ReadThroughBackend, this keeps the backend's optional
+// capabilities and stores entries with their input text. The option may
+// appear before or after the backend and provider options.
+func WithReadThrough[K comparable, V any](loader composite.Loader[K, V]) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if loader == nil {
+			return composite.ErrNilLoader
+		}
+		cfg.Loader = loader
+		return nil
+	}
+}
+
 // ---------- provider options ----------
 
 // WithOpenAIProvider sets up an OpenAI embedding provider.
@@ -162,6 +707,149 @@ func WithCustomProvider[K comparable, V any](provider types.EmbeddingProvider) O
 	}
 }
 
+// WithChunker splits texts longer than c's GetMaxTokens before they are
+// embedded, on writes and lookups alike, and embeds them as the mean of
+// their chunks' embeddings, weighted by each chunk's tokens with tokens
+// in overlaps split between the chunks sharing them. Without a chunker, texts go to the provider
+// whole.
+func WithChunker[K comparable, V any](c chunker.Chunker) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if c == nil {
+			return ErrNilChunker
+		}
+		cfg.Chunker = c
+		cfg.ChunkConfig = nil
+		return nil
+	}
+}
+
+// WithChunkConfig is WithChunker with the chunker chunker.NewChunker builds
+// for config's Strategy. Without a config.Tokenizer, tokens are counted
+// with the one tokenizer.ForProvider picks for the cache's provider.
+func WithChunkConfig[K comparable, V any](config chunker.ChunkConfig) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if err := config.Validate(); err != nil {
+			return fmt.Errorf("options: %w", err)
+		}
+		cfg.ChunkConfig = &config
+		cfg.Chunker = nil
+		return nil
+	}
+}
+
+// WithPreprocessor transforms every text with fns, in order, before it is
+// chunked and embedded, on writes and lookups alike, so that variants of
+// one query embed alike. Stored input texts stay as given. With no fns it
+// applies preprocess.Default: Unicode normalization, whitespace collapsing,
+// and lowercasing.
+func WithPreprocessor[K comparable, V any](fns ...preprocess.Func) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if len(fns) == 0 {
+			cfg.Preprocess = preprocess.Default()
+			return nil
+		}
+		for _, fn := range fns {
+			if fn == nil {
+				return ErrNilPreprocessor
+			}
+		}
+		cfg.Preprocess = preprocess.Chain(fns...)
+		return nil
+	}
+}
+
+// WithChunkVectors makes Set, SetWithMetadata, and SetBatch store one
+// vector per chunk of a long text instead of their mean, as with SetMulti,
+// so lookups match the best chunk rather than a diluted average. Matches
+// on such entries return the parent value with the matched chunk in
+// MatchEx.Chunk. Needs WithChunker or WithChunkConfig and a
+// types.MultiVectorBackend.
+func WithChunkVectors[K comparable, V any]() Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		cfg.ChunkVectors = true
+		return nil
+	}
+}
+
+// WithLateChunking embeds a text that needs chunking once, whole, and pools
+// the embeddings of each chunk's tokens into its vector, so every chunk
+// vector carries the context of the full text at the cost of one provider
+// call. The provider must implement types.TokenEmbeddingProvider and
+// accept the whole text; SetFromReader still embeds its chunks separately.
+// Needs WithChunker or WithChunkConfig, and the chunker's chunks must be
+// substrings of the text, as those of package chunker are.
+func WithLateChunking[K comparable, V any]() Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		cfg.LateChunking = true
+		return nil
+	}
+}
+
+// WithChunkObserver registers fn to be called with a chunker.Report for
+// every text the cache's chunker checks before embedding, on writes and
+// lookups alike, so oversized chunks and chunking that stopped happening
+// show up in metrics. fn runs on the calling goroutine and must be fast.
+// Cache.ChunkingStats keeps running totals either way.
+func WithChunkObserver[K comparable, V any](fn func(chunker.Report)) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		cfg.OnChunk = fn
+		return nil
+	}
+}
+
+// WithChunkSummarizer embeds a summary of each chunk by s in place of the
+// chunk, while the entry keeps the original text, so verbose passages match
+// on their content. To bound cost, chunks under minTokens tokens are
+// embedded as they are, and at most concurrency summaries run at once
+// across the cache. A chunk whose summary fails or comes back empty is
+// embedded as it is. Texts short enough not to be chunked and texts pooled
+// by WithLateChunking are not summarized. Needs WithChunker or
+// WithChunkConfig.
+func WithChunkSummarizer[K comparable, V any](s types.Summarizer, concurrency, minTokens int) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if s == nil {
+			return ErrNilSummarizer
+		}
+		if concurrency <= 0 || minTokens < 0 {
+			return ErrInvalidSummarizer
+		}
+		cfg.Summarizer = s
+		cfg.SummaryWorkers = concurrency
+		cfg.SummaryMinTokens = minTokens
+		return nil
+	}
+}
+
+// WithCircuitBreaker stops calling the embedding provider after failures
+// consecutive failed calls, so an outage fails fast instead of piling up
+// timeouts: for cooldown, every call that needs an embedding returns
+// semanticcache.ErrCircuitOpen. Then one call at a time probes the
+// provider; a success closes the circuit and a failure opens it for
+// another cooldown. Calls cancelled by their caller do not count. See
+// WithExactFallback to keep answering lookups meanwhile.
+func WithCircuitBreaker[K comparable, V any](failures int, cooldown time.Duration) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if failures <= 0 || cooldown <= 0 {
+			return ErrInvalidCircuitBreaker
+		}
+		cfg.BreakerFailures = failures
+		cfg.BreakerCooldown = cooldown
+		return nil
+	}
+}
+
+// WithExactFallback lets lookups degrade instead of failing while the
+// circuit breaker is open: a lookup returns the entry stored under the
+// query's semanticcache.TextKey, as SetText stores them, or else the one
+// the WithExactMatch index maps the query to, with a score of 1, and no
+// match otherwise. Needs WithCircuitBreaker.
+func WithExactFallback[K comparable, V any]() Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		cfg.ExactFallback = true
+		return nil
+	}
+}
+
 // ---------- similarity options ----------
 
 // WithSimilarityComparator sets a custom similarity function.
@@ -174,3 +862,200 @@ func WithSimilarityComparator[K comparable, V any](comparator similarity.Similar
 		return nil
 	}
 }
+
+// WithSimilarityName sets the similarity function registered under name
+// with similarity.Register, such as "cosine" or "jaccard", so configuration
+// can choose one by string.
+func WithSimilarityName[K comparable, V any](name string) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		comparator, err := similarity.Get(name)
+		if err != nil {
+			return err
+		}
+		cfg.Comparator = comparator
+		return nil
+	}
+}
+
+// WithDefaultThreshold sets the similarity threshold LookupWithOptions uses
+// when a call does not pass one. Defaults to DefaultThreshold.
+func WithDefaultThreshold[K comparable, V any](threshold float64) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if math.IsNaN(threshold) {
+			return ErrInvalidThreshold
+		}
+		cfg.Threshold = threshold
+		return nil
+	}
+}
+
+// WithNormalizedScores maps every score into [0, 1] with
+// similarity.Normalizer before it is compared with a threshold or returned,
+// including scores from per-call comparators, so a threshold means the same
+// thing whichever comparator is used. Cosine scores map to (s+1)/2.
+func WithNormalizedScores[K comparable, V any]() Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		cfg.NormalizeScores = true
+		return nil
+	}
+}
+
+// WithRecencyDecay ranks fresh entries above stale but similar ones: each
+// score is scaled by 1-weight+weight*0.5^(age/halfLife), so with weight 1
+// an entry one half-life old scores half its similarity. Thresholds still
+// apply to similarity alone, so boosts reorder hits but never create them.
+// Lookups scan instead of using a vector index. The backend must
+// implement types.StatsBackend, as the in-memory LRU, LFU, FIFO, TTL, and
+// sharded backends do.
+func WithRecencyDecay[K comparable, V any](halfLife time.Duration, weight float64) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if halfLife <= 0 || !(weight >= 0 && weight <= 1) {
+			return ErrInvalidBoost
+		}
+		cfg.RecencyHalfLife = halfLife
+		cfg.RecencyWeight = weight
+		return nil
+	}
+}
+
+// WithFrequencyBoost ranks often-used entries above rarely used ones: each
+// score is scaled by 1-weight+weight*log(1+n)/log(1+max), where n is the
+// entry's access count and max the highest among the lookup's candidates.
+// Only backends that count accesses, such as the LFU backend, report n;
+// elsewhere the boost has no effect. Thresholds and scanning behave as
+// with WithRecencyDecay.
+func WithFrequencyBoost[K comparable, V any](weight float64) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if !(weight >= 0 && weight <= 1) {
+			return ErrInvalidBoost
+		}
+		cfg.FrequencyWeight = weight
+		return nil
+	}
+}
+
+// WithDimensionCheck makes the cache return semanticcache.ErrDimensionMismatch,
+// wrapped in a *DimensionError carrying both dimensions, instead of silently
+// scoring 0 when vectors of different lengths meet: on every embedding the
+// provider returns and on every stored embedding a brute-force lookup
+// scans. dims is the expected dimension; 0 adopts the first embedding's.
+func WithDimensionCheck[K comparable, V any](dims int) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if dims < 0 {
+			return ErrInvalidDimensions
+		}
+		cfg.CheckDimensions = true
+		cfg.Dimensions = dims
+		return nil
+	}
+}
+
+// WithReranker rescores the topK best vector matches of every
+// LookupWithOptions, Lookup, LookupEx, TopMatches, and TopMatchesEx call
+// with r before returning them, more when the call asks for more results.
+// Reranked matches carry r's scores and are not compared with the
+// threshold again. If r fails or takes longer than timeout, the vector
+// ranking is returned instead; a timeout of 0 waits as long as the call's
+// context allows.
+func WithReranker[K comparable, V any](r types.Reranker, topK int, timeout time.Duration) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if r == nil {
+			return ErrNilReranker
+		}
+		if topK <= 0 || timeout < 0 {
+			return ErrInvalidRerank
+		}
+		cfg.Reranker = r
+		cfg.RerankTopK = topK
+		cfg.RerankTimeout = timeout
+		return nil
+	}
+}
+
+// ---------- key options ----------
+
+// WithKeyValidator replaces the check applied to keys on every write. By
+// default the cache rejects the zero value of K, so keys like 0 or "" are
+// refused; pass a validator that returns nil to accept every key, or one
+// that enforces your own key format.
+func WithKeyValidator[K comparable, V any](validate KeyValidator[K]) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if validate == nil {
+			return ErrNilKeyValidator
+		}
+		cfg.KeyValidator = validate
+		return nil
+	}
+}
+
+// ---------- observability options ----------
+
+// WithTracerProvider records OpenTelemetry spans from tp for cache
+// operations (Set, Get, Lookup, TopMatches, and the batch methods) and for
+// the provider and backend calls they make, so cache behavior shows up in
+// existing traces. Spans carry counts, dimensions, scores, and whether a
+// lookup hit, but never keys or texts. Without it nothing is traced.
+func WithTracerProvider[K comparable, V any](tp trace.TracerProvider) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if tp == nil {
+			return ErrNilTracerProvider
+		}
+		cfg.TracerProvider = tp
+		return nil
+	}
+}
+
+// WithLogger logs to l what the cache would otherwise swallow: entries a
+// lookup skips because the backend failed to load them, reranker and
+// summarizer failures that fall back, and failed re-embedding on lookup or
+// in the background. At debug level it also logs how each lookup was
+// answered: by the exact-match index, a vector index, or a scan, with
+// candidate and match counts. Keys and texts are not logged. Without it
+// nothing is logged.
+func WithLogger[K comparable, V any](l *slog.Logger) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if l == nil {
+			return ErrNilLogger
+		}
+		cfg.Logger = l
+		return nil
+	}
+}
+
+// OnMissRateAbove calls fn when more than rate of the lookups in the last
+// window found no match, once at least minLookups lookups fall in the
+// window. fn receives the miss rate and lookup count. It fires once when
+// the rate crosses rate and again only after it has dropped back, so it
+// suits alerting or retuning the threshold. Lookups without a threshold,
+// such as TopMatches, and failed lookups are not counted. fn runs on the
+// goroutine of the lookup that crossed the rate and must not block.
+func OnMissRateAbove[K comparable, V any](rate float64, window time.Duration, minLookups int, fn func(rate float64, lookups int)) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if fn == nil || window <= 0 || minLookups < 1 || !(rate >= 0 && rate < 1) {
+			return ErrInvalidAlert
+		}
+		cfg.MissRateAlert = rate
+		cfg.MissRateWindow = window
+		cfg.MissRateMinLookups = minLookups
+		cfg.OnMissRate = fn
+		return nil
+	}
+}
+
+// OnProviderErrorBurst calls fn when failures embedding calls fail within
+// window. fn receives the failure count and the error that completed the
+// burst. It fires once per burst and again only after the count has
+// dropped below failures. Calls the caller cancelled, and calls refused by
+// an open circuit breaker, are not counted. fn runs on the goroutine of the
+// failing call and must not block.
+func OnProviderErrorBurst[K comparable, V any](failures int, window time.Duration, fn func(failures int, last error)) Option[K, V] {
+	return func(cfg *Config[K, V]) error {
+		if fn == nil || window <= 0 || failures < 1 {
+			return ErrInvalidAlert
+		}
+		cfg.ProviderErrorBurst = failures
+		cfg.ProviderErrorWindow = window
+		cfg.OnProviderErrors = fn
+		return nil
+	}
+}
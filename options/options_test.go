@@ -2,10 +2,17 @@ package options
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"math"
 	"testing"
+	"time"
 
+	"github.com/botirk38/semanticcache/chunker"
+	"github.com/botirk38/semanticcache/providers"
 	"github.com/botirk38/semanticcache/similarity"
 	"github.com/botirk38/semanticcache/types"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // ---------- mock provider ----------
@@ -154,6 +161,25 @@ func TestProviderOptions(t *testing.T) {
 			t.Error("expected error for empty API key")
 		}
 	})
+
+	t.Run("Chunker", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		if err := cfg.Apply(WithChunker[string, string](nil)); !errors.Is(err, ErrNilChunker) {
+			t.Errorf("expected ErrNilChunker, got %v", err)
+		}
+		config := chunker.DefaultChunkConfig()
+		config.Strategy = chunker.Recursive
+		if err := cfg.Apply(WithChunkConfig[string, string](config)); err != nil {
+			t.Fatalf("chunk config failed: %v", err)
+		}
+		if cfg.ChunkConfig == nil || cfg.ChunkConfig.Strategy != chunker.Recursive {
+			t.Errorf("expected the chunk config kept, got %+v", cfg.ChunkConfig)
+		}
+		config.Strategy = "semantic"
+		if err := cfg.Apply(WithChunkConfig[string, string](config)); !errors.Is(err, chunker.ErrUnknownStrategy) {
+			t.Errorf("expected ErrUnknownStrategy, got %v", err)
+		}
+	})
 }
 
 func TestSimilarityOptions(t *testing.T) {
@@ -175,6 +201,195 @@ func TestSimilarityOptions(t *testing.T) {
 		}
 	})
 
+	t.Run("SimilarityName", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		if err := cfg.Apply(WithSimilarityName[string, string]("manhattan")); err != nil {
+			t.Fatalf("named similarity failed: %v", err)
+		}
+		if cfg.Comparator([]float64{1, 0}, []float64{1, 0}) != 1 {
+			t.Error("expected manhattan similarity of 1")
+		}
+		if err := cfg.Apply(WithSimilarityName[string, string]("nope")); !errors.Is(err, similarity.ErrUnknownSimilarity) {
+			t.Errorf("expected ErrUnknownSimilarity, got %v", err)
+		}
+	})
+
+	t.Run("NormalizedScores", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		if err := cfg.Apply(WithNormalizedScores[string, string]()); err != nil || !cfg.NormalizeScores {
+			t.Errorf("expected normalized scores, err=%v", err)
+		}
+	})
+
+	t.Run("Reranker", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		r := providers.RerankFunc(func(context.Context, string, []string) ([]float64, error) { return nil, nil })
+		if err := cfg.Apply(WithReranker[string, string](r, 10, time.Second)); err != nil || cfg.Reranker == nil || cfg.RerankTopK != 10 {
+			t.Errorf("expected a reranker over 10 candidates, err=%v", err)
+		}
+		if err := cfg.Apply(WithReranker[string, string](nil, 10, 0)); err != ErrNilReranker {
+			t.Errorf("expected ErrNilReranker, got %v", err)
+		}
+		if err := cfg.Apply(WithReranker[string, string](r, 0, 0)); err != ErrInvalidRerank {
+			t.Errorf("expected ErrInvalidRerank, got %v", err)
+		}
+	})
+
+	t.Run("TracerProvider", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		if err := cfg.Apply(WithTracerProvider[string, string](noop.NewTracerProvider())); err != nil || cfg.TracerProvider == nil {
+			t.Errorf("expected a tracer provider, err=%v", err)
+		}
+		if err := cfg.Apply(WithTracerProvider[string, string](nil)); err != ErrNilTracerProvider {
+			t.Errorf("expected ErrNilTracerProvider, got %v", err)
+		}
+	})
+
+	t.Run("CircuitBreaker", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		if err := cfg.Apply(WithCircuitBreaker[string, string](5, time.Second), WithExactFallback[string, string]()); err != nil || cfg.BreakerFailures != 5 || !cfg.ExactFallback {
+			t.Errorf("expected a breaker after 5 failures with exact fallback, err=%v", err)
+		}
+		if err := cfg.Apply(WithCircuitBreaker[string, string](0, time.Second)); err != ErrInvalidCircuitBreaker {
+			t.Errorf("expected ErrInvalidCircuitBreaker, got %v", err)
+		}
+		if err := cfg.Apply(WithCircuitBreaker[string, string](5, 0)); err != ErrInvalidCircuitBreaker {
+			t.Errorf("expected ErrInvalidCircuitBreaker, got %v", err)
+		}
+		cfg = NewConfig[string, string]()
+		cfg.Backend, cfg.Provider = &mockBackend[string, string]{}, &mockProvider{}
+		if err := cfg.Apply(WithExactFallback[string, string]()); err != nil {
+			t.Fatal(err)
+		}
+		if err := cfg.Validate(); err != ErrInvalidCircuitBreaker {
+			t.Errorf("expected ErrInvalidCircuitBreaker for a fallback without a breaker, got %v", err)
+		}
+	})
+
+	t.Run("LexicalSweep", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		cfg.Backend, cfg.Provider = &mockBackend[string, string]{}, &mockProvider{}
+		if err := cfg.Apply(WithLexicalSweep[string, string](time.Minute)); err != nil || cfg.LexicalSweep != time.Minute {
+			t.Errorf("expected a one-minute sweep, err=%v", err)
+		}
+		if err := cfg.Validate(); err != ErrInvalidLexicalSweep {
+			t.Errorf("expected ErrInvalidLexicalSweep without a lexical index, got %v", err)
+		}
+		if err := cfg.Apply(WithLexicalIndex[string, string]()); err != nil || cfg.Validate() != nil {
+			t.Errorf("expected a valid sweep with a lexical index, err=%v", err)
+		}
+		if err := cfg.Apply(WithLexicalSweep[string, string](0)); err != ErrInvalidLexicalSweep {
+			t.Errorf("expected ErrInvalidLexicalSweep, got %v", err)
+		}
+	})
+
+	t.Run("Maintenance", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		cfg.Backend, cfg.Provider = &mockBackend[string, string]{}, &mockProvider{}
+		if err := cfg.Apply(
+			WithExpirySweep[string, string](time.Minute, time.Hour),
+			WithMaintenanceRate[string, string](50),
+			WithMaintenanceJitter[string, string](0.2),
+		); err != nil || cfg.ExpirySweep != time.Minute || cfg.ExpiryMaxAge != time.Hour || cfg.MaintenanceRate != 50 || cfg.MaintenanceJitter != 0.2 {
+			t.Errorf("unexpected maintenance config %+v, err=%v", cfg, err)
+		}
+		if err := cfg.Validate(); err != ErrExpirySweepUnsupported {
+			t.Errorf("expected ErrExpirySweepUnsupported without creation times, got %v", err)
+		}
+		if err := cfg.Apply(WithExpirySweep[string, string](time.Minute, 0)); err != ErrInvalidExpirySweep {
+			t.Errorf("expected ErrInvalidExpirySweep, got %v", err)
+		}
+		for _, rate := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+			if err := cfg.Apply(WithMaintenanceRate[string, string](rate)); err != ErrInvalidMaintenanceRate {
+				t.Errorf("rate %v: expected ErrInvalidMaintenanceRate, got %v", rate, err)
+			}
+		}
+		for _, jitter := range []float64{-0.1, 1, math.NaN()} {
+			if err := cfg.Apply(WithMaintenanceJitter[string, string](jitter)); err != ErrInvalidMaintenanceJitter {
+				t.Errorf("jitter %v: expected ErrInvalidMaintenanceJitter, got %v", jitter, err)
+			}
+		}
+	})
+
+	t.Run("Alerts", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		onMiss := func(float64, int) {}
+		onErrors := func(int, error) {}
+		if err := cfg.Apply(
+			OnMissRateAbove[string, string](0.3, time.Minute, 100, onMiss),
+			OnProviderErrorBurst[string, string](5, 10*time.Second, onErrors),
+		); err != nil || cfg.MissRateAlert != 0.3 || cfg.MissRateMinLookups != 100 || cfg.ProviderErrorBurst != 5 {
+			t.Errorf("expected both alerts, err=%v", err)
+		}
+		for _, opt := range []Option[string, string]{
+			OnMissRateAbove[string, string](0.3, time.Minute, 100, nil),
+			OnMissRateAbove[string, string](1, time.Minute, 100, onMiss),
+			OnMissRateAbove[string, string](0.3, 0, 100, onMiss),
+			OnMissRateAbove[string, string](0.3, time.Minute, 0, onMiss),
+			OnProviderErrorBurst[string, string](0, time.Minute, onErrors),
+			OnProviderErrorBurst[string, string](5, time.Minute, nil),
+		} {
+			if err := cfg.Apply(opt); err != ErrInvalidAlert {
+				t.Errorf("expected ErrInvalidAlert, got %v", err)
+			}
+		}
+	})
+
+	t.Run("Logger", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		if err := cfg.Apply(WithLogger[string, string](slog.Default())); err != nil || cfg.Logger != slog.Default() {
+			t.Errorf("expected the default logger, err=%v", err)
+		}
+		if err := cfg.Apply(WithLogger[string, string](nil)); err != ErrNilLogger {
+			t.Errorf("expected ErrNilLogger, got %v", err)
+		}
+	})
+
+	t.Run("ChunkSummarizer", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		s := providers.SummarizeFunc(func(context.Context, string) (string, error) { return "", nil })
+		if err := cfg.Apply(WithChunkSummarizer[string, string](s, 4, 100)); err != nil || cfg.SummaryWorkers != 4 || cfg.SummaryMinTokens != 100 {
+			t.Errorf("expected 4 summary workers over 100 tokens, err=%v", err)
+		}
+		if err := cfg.Apply(WithChunkSummarizer[string, string](nil, 4, 0)); err != ErrNilSummarizer {
+			t.Errorf("expected ErrNilSummarizer, got %v", err)
+		}
+		if err := cfg.Apply(WithChunkSummarizer[string, string](s, 0, 0)); err != ErrInvalidSummarizer {
+			t.Errorf("expected ErrInvalidSummarizer, got %v", err)
+		}
+	})
+
+	t.Run("ScoreBoosts", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		if err := cfg.Apply(WithRecencyDecay[string, string](time.Hour, 0.5), WithFrequencyBoost[string, string](0.2)); err != nil ||
+			cfg.RecencyHalfLife != time.Hour || cfg.RecencyWeight != 0.5 || cfg.FrequencyWeight != 0.2 {
+			t.Errorf("expected recency and frequency boosts, err=%v", err)
+		}
+		if err := cfg.Apply(WithRecencyDecay[string, string](0, 0.5)); err != ErrInvalidBoost {
+			t.Errorf("expected ErrInvalidBoost for a zero half-life, got %v", err)
+		}
+		if err := cfg.Apply(WithFrequencyBoost[string, string](1.5)); err != ErrInvalidBoost {
+			t.Errorf("expected ErrInvalidBoost for a weight above 1, got %v", err)
+		}
+		_ = cfg.Apply(
+			WithCustomBackend[string, string](&mockBackend[string, string]{}),
+			WithCustomProvider[string, string](&mockProvider{}),
+		)
+		if err := cfg.Validate(); err != ErrStatsUnsupported {
+			t.Errorf("expected ErrStatsUnsupported, got %v", err)
+		}
+	})
+
+	t.Run("DimensionCheck", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		if err := cfg.Apply(WithDimensionCheck[string, string](384)); err != nil || !cfg.CheckDimensions || cfg.Dimensions != 384 {
+			t.Errorf("expected a 384 dimension check, err=%v", err)
+		}
+		if err := cfg.Apply(WithDimensionCheck[string, string](-1)); err != ErrInvalidDimensions {
+			t.Errorf("expected ErrInvalidDimensions, got %v", err)
+		}
+	})
+
 	t.Run("BuiltinSimilarities", func(t *testing.T) {
 		funcs := map[string]similarity.SimilarityFunc{
 			"Cosine":      similarity.CosineSimilarity,
@@ -194,4 +409,125 @@ func TestSimilarityOptions(t *testing.T) {
 	})
 }
 
+func TestDefaultThresholdOption(t *testing.T) {
+	cfg := NewConfig[string, string]()
+	if cfg.Threshold != DefaultThreshold {
+		t.Errorf("expected default %v, got %v", DefaultThreshold, cfg.Threshold)
+	}
+	if err := cfg.Apply(WithDefaultThreshold[string, string](0.6)); err != nil || cfg.Threshold != 0.6 {
+		t.Errorf("expected 0.6, got %v err=%v", cfg.Threshold, err)
+	}
+	if err := cfg.Apply(WithDefaultThreshold[string, string](math.NaN())); err != ErrInvalidThreshold {
+		t.Errorf("expected ErrInvalidThreshold, got %v", err)
+	}
+}
+
+func TestExactMatchOption(t *testing.T) {
+	cfg := NewConfig[string, string]()
+	if err := cfg.Apply(WithExactMatch[string, string](0)); err != ErrInvalidExactMatchSize {
+		t.Errorf("expected ErrInvalidExactMatchSize, got %v", err)
+	}
+	if err := cfg.Apply(WithExactMatch[string, string](100)); err != nil || cfg.ExactMatch != 100 {
+		t.Errorf("expected 100, got %d err=%v", cfg.ExactMatch, err)
+	}
+}
+
+func TestSearchConcurrencyOption(t *testing.T) {
+	cfg := NewConfig[string, string]()
+	if cfg.SearchConcurrency != 1 {
+		t.Errorf("expected serial default, got %d", cfg.SearchConcurrency)
+	}
+	if err := cfg.Apply(WithSearchConcurrency[string, string](0)); err != ErrInvalidSearchConcurrency {
+		t.Errorf("expected ErrInvalidSearchConcurrency, got %v", err)
+	}
+	if err := cfg.Apply(WithSearchConcurrency[string, string](4)); err != nil || cfg.SearchConcurrency != 4 {
+		t.Errorf("expected 4, got %d err=%v", cfg.SearchConcurrency, err)
+	}
+}
+
+func TestBatchConcurrencyOption(t *testing.T) {
+	cfg := NewConfig[string, string]()
+	if cfg.BatchConcurrency != 1 {
+		t.Errorf("expected serial default, got %d", cfg.BatchConcurrency)
+	}
+	if err := cfg.Apply(WithBatchConcurrency[string, string](0)); err != ErrInvalidBatchConcurrency {
+		t.Errorf("expected ErrInvalidBatchConcurrency, got %v", err)
+	}
+	if err := cfg.Apply(WithBatchConcurrency[string, string](8)); err != nil || cfg.BatchConcurrency != 8 {
+		t.Errorf("expected 8, got %d err=%v", cfg.BatchConcurrency, err)
+	}
+}
+
+func TestRefreshOptions(t *testing.T) {
+	cfg := NewConfig[string, string]()
+	if cfg.RefreshBatchSize != DefaultRefreshBatchSize {
+		t.Errorf("expected default batch size, got %d", cfg.RefreshBatchSize)
+	}
+	if err := cfg.Apply(WithRefreshAhead[string, string](0, time.Hour)); err != ErrInvalidRefreshInterval {
+		t.Errorf("expected ErrInvalidRefreshInterval, got %v", err)
+	}
+	if err := cfg.Apply(WithRefreshBatchSize[string, string](0)); err != ErrInvalidRefreshBatchSize {
+		t.Errorf("expected ErrInvalidRefreshBatchSize, got %v", err)
+	}
+	err := cfg.Apply(
+		WithRefreshAhead[string, string](time.Minute, time.Hour),
+		WithEmbeddingVersion[string, string]("v2"),
+		WithCustomProvider[string, string](&mockProvider{}),
+		WithCustomBackend[string, string](&mockBackend[string, string]{}),
+	)
+	if err != nil {
+		t.Fatalf("apply failed: %v", err)
+	}
+	if cfg.RefreshInterval != time.Minute || cfg.RefreshMaxAge != time.Hour || cfg.EmbeddingVersion != "v2" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if err := cfg.Validate(); err != ErrRefreshUnsupported {
+		t.Errorf("expected ErrRefreshUnsupported, got %v", err)
+	}
+}
+
+func TestKeyValidatorOption(t *testing.T) {
+	cfg := NewConfig[int, string]()
+	if err := cfg.Apply(WithKeyValidator[int, string](nil)); err != ErrNilKeyValidator {
+		t.Errorf("expected ErrNilKeyValidator, got %v", err)
+	}
+	if err := cfg.Apply(WithKeyValidator[int, string](func(int) error { return nil })); err != nil || cfg.KeyValidator == nil {
+		t.Errorf("expected validator set, err=%v", err)
+	}
+}
+
+func TestEvictionCallbackOption(t *testing.T) {
+	fn := func(string, types.Entry[string]) {}
+
+	t.Run("Supported", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		err := cfg.Apply(
+			WithEvictionCallback[string, string](fn),
+			WithLRUBackend[string, string](10),
+			WithCustomProvider[string, string](&mockProvider{}),
+		)
+		if err != nil {
+			t.Fatalf("apply failed: %v", err)
+		}
+		if cfg.OnEvict == nil {
+			t.Fatal("expected callback set")
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("validate failed: %v", err)
+		}
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		cfg := NewConfig[string, string]()
+		_ = cfg.Apply(
+			WithCustomBackend[string, string](&mockBackend[string, string]{}),
+			WithCustomProvider[string, string](&mockProvider{}),
+			WithEvictionCallback[string, string](fn),
+		)
+		if err := cfg.Validate(); err != ErrEvictionUnsupported {
+			t.Fatalf("expected ErrEvictionUnsupported, got %v", err)
+		}
+	})
+}
+
 var _ types.Backend[string, string] = (*mockBackend[string, string])(nil)
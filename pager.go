@@ -0,0 +1,90 @@
+package semanticcache
+
+import (
+	"context"
+	"math"
+	"slices"
+)
+
+// MatchPager walks the matches for one query a page at a time. Entries are
+// scored once when the pager is created; each page fetches only its own
+// entries, so entries deleted since then are skipped and new ones are not
+// seen. A MatchPager is not safe for concurrent use.
+type MatchPager[K comparable, V any] struct {
+	cache      *Cache[K, V]
+	cfg        lookupConfig
+	candidates []candidate[K]
+	pageSize   int
+	skip       int
+	remaining  int
+}
+
+// PageMatches scores every entry against inputText and returns a pager that
+// yields up to pageSize matches per call to Next, best first. Like
+// TopMatches it applies no threshold unless WithThreshold is passed;
+// WithLimit caps the total number of matches and WithOffset skips leading
+// ones.
+func (c *Cache[K, V]) PageMatches(ctx context.Context, inputText string, pageSize int, opts ...LookupOption) (*MatchPager[K, V], error) {
+	if err := c.begin(); err != nil {
+		return nil, err
+	}
+	defer c.end()
+	if pageSize <= 0 {
+		return nil, ErrInvalidN
+	}
+	cfg, err := c.newLookupConfig(slices.Concat([]LookupOption{WithThreshold(math.Inf(-1)), WithLimit(math.MaxInt)}, opts))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.limit <= 0 {
+		return nil, ErrInvalidN
+	}
+	query, err := c.embed(ctx, inputText)
+	if err != nil {
+		return nil, err
+	}
+	target := c
+	if cfg.namespace != "" {
+		target = c.Namespace(cfg.namespace)
+	}
+	candidates, err := target.rank(ctx, query, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &MatchPager[K, V]{
+		cache:      target,
+		cfg:        cfg,
+		candidates: candidates,
+		pageSize:   pageSize,
+		skip:       cfg.offset,
+		remaining:  cfg.limit,
+	}, nil
+}
+
+// Next returns the next page of matches. It returns an empty slice once the
+// pager is exhausted, ErrClosed if the cache was closed, and ctx's error,
+// leaving the pager where it was, if ctx ends while fetching.
+func (p *MatchPager[K, V]) Next(ctx context.Context) ([]MatchEx[K, V], error) {
+	if err := p.cache.begin(); err != nil {
+		return nil, err
+	}
+	defer p.cache.end()
+	matches, consumed, err := p.cache.collect(ctx, p.candidates, p.cfg, p.skip, min(p.pageSize, p.remaining))
+	if err != nil {
+		return nil, err
+	}
+	p.candidates = p.candidates[consumed:]
+	p.skip = 0
+	p.remaining -= len(matches)
+	if p.remaining == 0 {
+		p.candidates = nil
+	}
+	return matches, nil
+}
+
+// Done reports whether the pager has no candidates left. Next can return an
+// empty page before Done is true when the remaining entries were filtered
+// out or deleted.
+func (p *MatchPager[K, V]) Done() bool {
+	return len(p.candidates) == 0
+}
@@ -0,0 +1,44 @@
+package semanticcache
+
+import (
+	"context"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// Pin protects key from eviction, for entries such as curated answers that
+// must survive memory pressure, until Unpin or Delete. It reports whether
+// key existed. The backend must implement types.PinningBackend; otherwise
+// ErrPinUnsupported is returned.
+func (c *Cache[K, V]) Pin(ctx context.Context, key K) (bool, error) {
+	if err := c.begin(); err != nil {
+		return false, err
+	}
+	defer c.end()
+	pb, err := c.pinning()
+	if err != nil {
+		return false, err
+	}
+	return pb.Pin(ctx, key)
+}
+
+// Unpin makes key evictable again. It reports whether key existed.
+func (c *Cache[K, V]) Unpin(ctx context.Context, key K) (bool, error) {
+	if err := c.begin(); err != nil {
+		return false, err
+	}
+	defer c.end()
+	pb, err := c.pinning()
+	if err != nil {
+		return false, err
+	}
+	return pb.Unpin(ctx, key)
+}
+
+func (c *Cache[K, V]) pinning() (types.PinningBackend[K, V], error) {
+	pb, ok := c.backend.(types.PinningBackend[K, V])
+	if !ok {
+		return nil, ErrPinUnsupported
+	}
+	return pb, nil
+}
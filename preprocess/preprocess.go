@@ -0,0 +1,104 @@
+// Package preprocess provides text transformations applied before text is
+// chunked and embedded, so that user-typed variants of one question embed
+// alike.
+package preprocess
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Func transforms text before it is embedded.
+type Func func(text string) string
+
+// Chain returns a Func applying fns in order.
+func Chain(fns ...Func) Func {
+	return func(text string) string {
+		for _, fn := range fns {
+			text = fn(text)
+		}
+		return text
+	}
+}
+
+// Default normalizes Unicode, collapses whitespace, and lowercases: the
+// transformations that help hit rates without removing content.
+func Default() Func {
+	return Chain(NormalizeUnicode, CollapseWhitespace, Lowercase)
+}
+
+// NormalizeUnicode applies NFKC normalization, folding compatibility
+// characters such as full-width letters, ligatures, and non-breaking spaces
+// into their plain forms.
+func NormalizeUnicode(text string) string {
+	return norm.NFKC.String(text)
+}
+
+// CollapseWhitespace trims text and replaces every run of whitespace in it
+// with a single space.
+func CollapseWhitespace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// Lowercase maps text to lower case.
+func Lowercase(text string) string {
+	return strings.ToLower(text)
+}
+
+// DefaultBoilerplate are the conversational openers and closers
+// StripBoilerplate removes when given no phrases.
+var DefaultBoilerplate = []string{
+	"hi", "hello", "hey", "please", "thanks", "thank you", "thanks in advance",
+	"can you tell me", "could you tell me", "can you", "could you",
+	"i was wondering", "quick question",
+}
+
+// StripBoilerplate returns a Func removing phrases, ignoring case, from the
+// start and end of text, along with the punctuation and spaces around
+// them, until none is left. Phrases must match whole words. With no
+// phrases it uses DefaultBoilerplate. Text made only of boilerplate is
+// returned unchanged.
+func StripBoilerplate(phrases ...string) Func {
+	if len(phrases) == 0 {
+		phrases = DefaultBoilerplate
+	}
+	quoted := make([]string, len(phrases))
+	for i, p := range phrases {
+		quoted[i] = regexp.QuoteMeta(p)
+	}
+	alt := strings.Join(quoted, "|")
+	lead := regexp.MustCompile(`(?i)^[\s\p{P}]*(?:` + alt + `)\b[\s\p{P}]*`)
+	trail := regexp.MustCompile(`(?i)[\s,;:!.]*\b(?:` + alt + `)[\s\p{P}]*$`)
+	return func(text string) string {
+		out := text
+		for {
+			next := trail.ReplaceAllString(lead.ReplaceAllString(out, ""), "")
+			if next == out {
+				break
+			}
+			out = next
+		}
+		if strings.IndexFunc(out, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) < 0 {
+			return text
+		}
+		return out
+	}
+}
+
+// Patterns for common personal data, for use with Redact.
+var (
+	EmailPattern = regexp.MustCompile(`[\w.+-]+@[\w-]+(?:\.[\w-]+)+`)
+	PhonePattern = regexp.MustCompile(`\+?\d[\d\s().-]{7,}\d`)
+)
+
+// Redact returns a Func replacing every match of pattern with
+// replacement, a hook for removing personal data before it reaches the
+// embedding provider.
+func Redact(pattern *regexp.Regexp, replacement string) Func {
+	return func(text string) string {
+		return pattern.ReplaceAllString(text, replacement)
+	}
+}
@@ -0,0 +1,36 @@
+package preprocess
+
+import "testing"
+
+func TestDefault(t *testing.T) {
+	got := Default()("  Ｈｅｌｌｏ, \tWORLD\n ﬁne  ")
+	if want := "hello, world fine"; got != want {
+		t.Errorf("Default() = %q, want %q", got, want)
+	}
+}
+
+func TestStripBoilerplate(t *testing.T) {
+	strip := StripBoilerplate()
+	tests := []struct{ in, want string }{
+		{"Hi! Can you tell me how to reset my password? Thanks!", "how to reset my password?"},
+		{"hello, please reset my password", "reset my password"},
+		{"How do I use the hive?", "How do I use the hive?"},
+		{"Thanks!", "Thanks!"},
+	}
+	for _, tt := range tests {
+		if got := strip(tt.in); got != tt.want {
+			t.Errorf("StripBoilerplate()(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := StripBoilerplate("ok so")("OK so what now"); got != "what now" {
+		t.Errorf("custom phrases: got %q", got)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	redact := Chain(Redact(EmailPattern, "[email]"), Redact(PhonePattern, "[phone]"))
+	got := redact("Mail jane.doe+x@example.co.uk or call +1 (555) 123-4567 about order 42.")
+	if want := "Mail [email] or call [phone] about order 42."; got != want {
+		t.Errorf("Redact() = %q, want %q", got, want)
+	}
+}
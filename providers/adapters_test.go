@@ -0,0 +1,45 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/botirk38/semanticcache/similarity"
+)
+
+func TestFromFloat32(t *testing.T) {
+	ctx := context.Background()
+	p := FromFloat32(func(_ context.Context, text string) ([]float32, error) {
+		if text == "" {
+			return nil, errors.New("empty")
+		}
+		return []float32{0.25, 0.75}, nil
+	})
+	v, err := p.EmbedText(ctx, "hello")
+	if err != nil || len(v) != 2 || v[0] != 0.25 || v[1] != 0.75 {
+		t.Errorf("EmbedText = %v, %v", v, err)
+	}
+	if _, err := p.EmbedText(ctx, ""); err == nil {
+		t.Error("expected the embed error")
+	}
+	if err := p.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestFromSparse(t *testing.T) {
+	p := FromSparse(func(_ context.Context, text string) (similarity.SparseVector, error) {
+		if text == "oov" {
+			return similarity.SparseVector{Indices: []int{9}, Values: []float64{1}}, nil
+		}
+		return similarity.SparseVector{Indices: []int{1, 3}, Values: []float64{0.5, 2}}, nil
+	}, 4)
+	v, err := p.EmbedText(context.Background(), "hello")
+	if err != nil || len(v) != 4 || v[1] != 0.5 || v[3] != 2 || v[0] != 0 {
+		t.Errorf("EmbedText = %v, %v", v, err)
+	}
+	if _, err := p.EmbedText(context.Background(), "oov"); err != similarity.ErrSparseIndex {
+		t.Errorf("expected ErrSparseIndex, got %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/botirk38/semanticcache/similarity"
+	"github.com/botirk38/semanticcache/types"
+)
+
+// Float32EmbedFunc embeds text as a float32 vector, the representation most
+// embedding SDKs and model runtimes return.
+type Float32EmbedFunc func(ctx context.Context, text string) ([]float32, error)
+
+// float32Provider adapts a Float32EmbedFunc to types.EmbeddingProvider.
+type float32Provider struct {
+	embed Float32EmbedFunc
+}
+
+// FromFloat32 adapts a float32 embedding function to
+// types.EmbeddingProvider, widening its vectors to []float64, the only
+// vector type the cache, backends, and similarity functions use. Close is a
+// no-op.
+func FromFloat32(embed Float32EmbedFunc) types.EmbeddingProvider {
+	return float32Provider{embed: embed}
+}
+
+func (p float32Provider) EmbedText(ctx context.Context, text string) ([]float64, error) {
+	v, err := p.embed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return similarity.ToFloat64(v), nil
+}
+
+func (p float32Provider) Close() error { return nil }
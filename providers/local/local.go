@@ -13,6 +13,7 @@ import (
 	"context"
 	"hash/fnv"
 	"math"
+	"strconv"
 )
 
 // Provider generates deterministic embeddings by hashing input text.
@@ -66,5 +67,8 @@ func (p *Provider) EmbedBatch(ctx context.Context, texts []string) ([][]float64,
 	return out, nil
 }
 
+// EmbeddingVersion identifies the provider as "local/<dimensions>".
+func (p *Provider) EmbeddingVersion() string { return "local/" + strconv.Itoa(p.dimensions) }
+
 // Close is a no-op.
 func (p *Provider) Close() error { return nil }
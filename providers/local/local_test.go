@@ -81,6 +81,12 @@ func TestProvider_DefaultDimensions(t *testing.T) {
 	}
 }
 
+func TestProvider_EmbeddingVersion(t *testing.T) {
+	if v := New(16).EmbeddingVersion(); v != "local/16" {
+		t.Fatalf("expected local/16, got %s", v)
+	}
+}
+
 func TestProvider_Close(t *testing.T) {
 	p := New(16)
 	if err := p.Close(); err != nil {
@@ -101,5 +101,8 @@ func (p *OpenAIProvider) EmbedBatch(ctx context.Context, texts []string) ([][]fl
 	return embeddings, nil
 }
 
+// EmbeddingVersion identifies the model as "openai/<model>".
+func (p *OpenAIProvider) EmbeddingVersion() string { return "openai/" + p.model }
+
 // Close releases resources held by the provider.
 func (p *OpenAIProvider) Close() error { return nil }
@@ -34,6 +34,9 @@ func TestNewOpenAIProvider(t *testing.T) {
 		if p.model != "text-embedding-ada-002" {
 			t.Errorf("expected custom model, got %s", p.model)
 		}
+		if v := p.EmbeddingVersion(); v != "openai/text-embedding-ada-002" {
+			t.Errorf("expected the model in the version, got %s", v)
+		}
 	})
 }
 
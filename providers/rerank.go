@@ -0,0 +1,12 @@
+package providers
+
+import "context"
+
+// RerankFunc adapts an ordinary function, such as a call to a
+// cross-encoder service, to types.Reranker.
+type RerankFunc func(ctx context.Context, query string, texts []string) ([]float64, error)
+
+// Rerank calls f.
+func (f RerankFunc) Rerank(ctx context.Context, query string, texts []string) ([]float64, error) {
+	return f(ctx, query, texts)
+}
@@ -0,0 +1,35 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/botirk38/semanticcache/similarity"
+	"github.com/botirk38/semanticcache/types"
+)
+
+// SparseEmbedFunc embeds text as a sparse vector, as lexical models such as
+// SPLADE do.
+type SparseEmbedFunc func(ctx context.Context, text string) (similarity.SparseVector, error)
+
+// sparseProvider adapts a SparseEmbedFunc to types.EmbeddingProvider.
+type sparseProvider struct {
+	embed SparseEmbedFunc
+	dim   int
+}
+
+// FromSparse adapts a sparse embedding function to types.EmbeddingProvider,
+// expanding its vectors to dim dimensions, typically the model's vocabulary
+// size. Pair it with similarity.JaccardSimilarity. Close is a no-op.
+func FromSparse(embed SparseEmbedFunc, dim int) types.EmbeddingProvider {
+	return sparseProvider{embed: embed, dim: dim}
+}
+
+func (p sparseProvider) EmbedText(ctx context.Context, text string) ([]float64, error) {
+	v, err := p.embed(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return v.Dense(p.dim)
+}
+
+func (p sparseProvider) Close() error { return nil }
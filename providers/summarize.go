@@ -0,0 +1,12 @@
+package providers
+
+import "context"
+
+// SummarizeFunc adapts an ordinary function, such as an LLM call, to
+// types.Summarizer.
+type SummarizeFunc func(ctx context.Context, text string) (string, error)
+
+// Summarize calls f.
+func (f SummarizeFunc) Summarize(ctx context.Context, text string) (string, error) {
+	return f(ctx, text)
+}
@@ -0,0 +1,146 @@
+package semanticcache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/botirk38/semanticcache/chunker"
+)
+
+// readerWindow is how many bytes SetFromReader reads before chunking what
+// it has.
+const readerWindow = 64 << 10
+
+// readerBreaks are where SetFromReader prefers to cut a window, best first.
+var readerBreaks = []string{"\n\n", "\n", " "}
+
+// SetFromReader stores value under key with an embedding of the text read
+// from r, for whole files or transcripts too large to hold in memory. It
+// reads r a window at a time, cutting each window at a paragraph, line, or
+// word break, chunks it with the cache's chunker, and embeds the chunks
+// before reading on, so only the chunk embeddings are kept. The entry's
+// embedding is their mean, weighted so tokens in overlaps count once, or, with options.WithChunkVectors, one vector
+// per chunk. The text itself is not stored, so the entry has no InputText
+// and is skipped by Refresh, exact matching, and hybrid lookups. It
+// returns ErrChunkerRequired without options.WithChunker and
+// ErrNoInputTexts if r yields no text.
+func (c *Cache[K, V]) SetFromReader(ctx context.Context, key K, r io.Reader, value V) error {
+	if err := c.begin(); err != nil {
+		return err
+	}
+	defer c.end()
+	if err := c.checkKey(key); err != nil {
+		return err
+	}
+	if c.chunker == nil {
+		return ErrChunkerRequired
+	}
+
+	var vectors [][]float64
+	var weights []float64
+	report := c.chunkReport(0, nil)
+	flush := func(text string) error {
+		if c.preprocess != nil {
+			text = c.preprocess(text)
+		}
+		if strings.TrimSpace(text) == "" {
+			return nil
+		}
+		chunks, err := c.chunker.ChunkText(text)
+		if err != nil || len(chunks) == 0 {
+			return err
+		}
+		tokens := chunks[len(chunks)-1].EndToken
+		if report.Strategy == chunker.TruncateToMaxTokens {
+			// Truncated chunks do not tell how long the window was.
+			if tokens, err = c.chunker.CountTokens(text); err != nil {
+				return err
+			}
+		}
+		r := c.chunkReport(tokens, chunks)
+		report.Tokens += r.Tokens
+		report.Chunks += r.Chunks
+		report.Oversized += r.Oversized
+		report.Truncated += r.Truncated
+		w := overlapWeights(chunks)
+		if w == nil {
+			w = slices.Repeat([]float64{1}, len(chunks))
+		}
+		weights = append(weights, w...)
+		texts := make([]string, len(chunks))
+		for i, chunk := range chunks {
+			texts[i] = chunk.Text
+		}
+		if texts, err = c.summarize(ctx, texts); err != nil {
+			return err
+		}
+		embeddings, err := c.embedBatch(ctx, texts)
+		if err != nil {
+			return err
+		}
+		vectors = append(vectors, embeddings...)
+		return nil
+	}
+
+	buf := make([]byte, 0, 2*readerWindow)
+	for eof := false; !eof; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := io.ReadFull(r, buf[len(buf):len(buf)+readerWindow])
+		buf = buf[:len(buf)+n]
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			eof = true
+		} else if err != nil {
+			return err
+		}
+		cut := len(buf)
+		if !eof {
+			cut = windowCut(buf)
+		}
+		if err := flush(string(buf[:cut])); err != nil {
+			return err
+		}
+		buf = buf[:copy(buf, buf[cut:])]
+	}
+	if len(vectors) == 0 {
+		return ErrNoInputTexts
+	}
+	c.recordChunking(report)
+
+	if !c.chunkVectors && len(vectors) > 1 {
+		centroid, err := aggregate(vectors, weights)
+		if err != nil {
+			return err
+		}
+		vectors = [][]float64{centroid}
+	}
+	return c.setEntry(ctx, key, c.newChunkedEntry(vectors, "", value, nil))
+}
+
+// windowCut returns where to end the text taken from buf: after the last
+// break in its final window, so at most a window is carried over, or
+// before a trailing incomplete UTF-8 sequence when there is none.
+func windowCut(buf []byte) int {
+	lo := max(0, len(buf)-readerWindow)
+	for _, sep := range readerBreaks {
+		if i := bytes.LastIndex(buf[lo:], []byte(sep)); i >= 0 && lo+i > 0 {
+			return lo + i + len(sep)
+		}
+	}
+	cut := len(buf)
+	for i := cut - 1; i >= max(0, cut-utf8.UTFMax); i-- {
+		if utf8.RuneStart(buf[i]) {
+			if !utf8.FullRune(buf[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	return cut
+}
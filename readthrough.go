@@ -0,0 +1,60 @@
+package semanticcache
+
+import (
+	"context"
+	"fmt"
+)
+
+// readThrough loads key with the options.WithReadThrough loader after a
+// Get miss and stores it through Set, so the entry keeps its input text and
+// embedding version and goes through preprocessing, chunking, and the
+// circuit breaker like any other write. Concurrent misses for the same key
+// share one load; a caller whose ctx ends while waiting returns ctx.Err()
+// without cancelling it. A key the cache would refuse to store is reported
+// as a miss without calling the loader.
+func (c *Cache[K, V]) readThrough(ctx context.Context, key K) (value V, found bool, err error) {
+	if c.checkKey(key) != nil {
+		return value, false, nil
+	}
+
+	c.loadMu.Lock()
+	if f, ok := c.loads[key]; ok {
+		c.loadMu.Unlock()
+		select {
+		case <-f.done:
+			return f.value, f.hit, f.err
+		case <-ctx.Done():
+			return value, false, ctx.Err()
+		}
+	}
+	if c.loads == nil {
+		c.loads = make(map[K]*flight[V])
+	}
+	f := &flight[V]{done: make(chan struct{})}
+	c.loads[key] = f
+	c.loadMu.Unlock()
+
+	defer func() {
+		c.loadMu.Lock()
+		delete(c.loads, key)
+		c.loadMu.Unlock()
+		close(f.done)
+	}()
+	f.value, f.hit, f.err = c.load(ctx, key)
+	return f.value, f.hit, f.err
+}
+
+func (c *Cache[K, V]) load(ctx context.Context, key K) (V, bool, error) {
+	var zero V
+	text, value, found, err := c.loader(ctx, key)
+	if err != nil {
+		return zero, false, fmt.Errorf("semanticcache: failed to load key: %w", err)
+	}
+	if !found {
+		return zero, false, nil
+	}
+	if err := c.Set(ctx, key, text, value); err != nil {
+		return zero, false, err
+	}
+	return value, true, nil
+}
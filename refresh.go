@@ -0,0 +1,152 @@
+package semanticcache
+
+import (
+	"context"
+	"time"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// refreshConfig decides which entries Refresh re-embeds and how many per
+// provider call.
+type refreshConfig struct {
+	maxAge    time.Duration // zero refreshes on version changes only
+	batchSize int
+	onLookup  bool // re-embed incompatible entries lookups skip
+}
+
+// Refresh re-embeds stale entries from their input text: those created more
+// than the refresh max age ago (see options.WithRefreshAhead), and those
+// whose embedding version differs from the cache's (see
+// options.WithEmbeddingVersion). Entries are embedded in batches and written
+// back with a new creation time, which also restarts their lifetime on TTL
+// backends. On backends implementing types.ConditionalBackend an entry whose
+// value changed meanwhile is left alone. Entries are scanned without
+// counting accesses where the backend implements types.PeekingBackend, so
+// only rewritten entries move in the eviction order. Refresh returns the
+// number of entries rewritten; the backend must implement
+// types.EntryBackend.
+func (c *Cache[K, V]) Refresh(ctx context.Context) (int, error) {
+	return c.refreshStale(ctx, nil)
+}
+
+// refreshStale is Refresh, pacing re-embeds with lim.
+func (c *Cache[K, V]) refreshStale(ctx context.Context, lim *limiter) (int, error) {
+	if err := c.begin(); err != nil {
+		return 0, err
+	}
+	defer c.end()
+	if _, ok := c.backend.(types.EntryBackend[K, V]); !ok {
+		return 0, ErrRefreshUnsupported
+	}
+	now := time.Now()
+	var (
+		keys      []K
+		entries   []types.Entry[V]
+		refreshed int
+		flushErr  error
+	)
+	flush := func() bool {
+		if flushErr = lim.wait(ctx, len(keys)); flushErr != nil {
+			return false
+		}
+		n, err := c.reembed(ctx, keys, entries)
+		refreshed += n
+		keys, entries = keys[:0], entries[:0]
+		flushErr = err
+		return err == nil
+	}
+	err := c.peekRange(ctx, func(key K, entry types.Entry[V]) bool {
+		if !c.stale(entry, now) {
+			return true
+		}
+		keys = append(keys, key)
+		entries = append(entries, entry)
+		return len(keys) < c.refresh.batchSize || flush()
+	})
+	if err != nil {
+		return refreshed, err
+	}
+	if flushErr == nil && len(keys) > 0 {
+		flush()
+	}
+	return refreshed, flushErr
+}
+
+// stale reports whether entry should be re-embedded.
+func (c *Cache[K, V]) stale(entry types.Entry[V], now time.Time) bool {
+	if !reembeddable(entry) {
+		return false
+	}
+	if entry.EmbeddingVersion != c.version {
+		return true
+	}
+	return c.refresh.maxAge > 0 && !entry.CreatedAt.IsZero() && now.Sub(entry.CreatedAt) >= c.refresh.maxAge
+}
+
+// reembed embeds entries' input texts in one batch and writes them back.
+func (c *Cache[K, V]) reembed(ctx context.Context, keys []K, entries []types.Entry[V]) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	texts := make([]string, len(entries))
+	for i, entry := range entries {
+		texts[i] = entry.InputText
+	}
+	embeddings, err := c.embedTexts(ctx, texts)
+	if err != nil {
+		return 0, err
+	}
+	cb, conditional := c.backend.(types.ConditionalBackend[K, V])
+	n := 0
+	for i, key := range keys {
+		entry := entries[i]
+		entry.Embedding = embeddings[i]
+		entry.CreatedAt = time.Now()
+		entry.EmbeddingVersion = c.version
+		if conditional {
+			swapped, err := cb.CompareAndSwap(ctx, key, entries[i].Value, entry)
+			if err != nil {
+				return n, err
+			}
+			if !swapped {
+				continue
+			}
+		} else if err := c.setEntry(ctx, key, entry); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// reembedStale re-embeds the entries at keys when lookups are configured to.
+// It is best effort: lookups never fail because of it.
+func (c *Cache[K, V]) reembedStale(ctx context.Context, keys []K) {
+	if !c.refresh.onLookup || len(keys) == 0 {
+		return
+	}
+	var entries []types.Entry[V]
+	var found []K
+	for _, key := range keys {
+		entry, ok, err := c.peekEntry(ctx, key)
+		if err == nil && ok && reembeddable(entry) {
+			found = append(found, key)
+			entries = append(entries, entry)
+		}
+	}
+	for start := 0; start < len(found); start += c.refresh.batchSize {
+		end := min(start+c.refresh.batchSize, len(found))
+		if _, err := c.reembed(ctx, found[start:end], entries[start:end]); err != nil {
+			c.logger.WarnContext(ctx, "semanticcache: re-embedding on lookup failed", "entries", len(found)-start, "error", err)
+			return
+		}
+	}
+}
+
+// reembeddable reports whether entry keeps the text behind every vector.
+// Entries without input text, and multi-vector entries, whose later texts
+// are not stored, cannot be re-embedded.
+func reembeddable[V any](entry types.Entry[V]) bool {
+	return entry.InputText != "" && len(entry.Vectors) == 0
+}
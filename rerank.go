@@ -0,0 +1,87 @@
+package semanticcache
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// rerankConfig holds the options.WithReranker settings.
+type rerankConfig struct {
+	reranker types.Reranker // nil disables reranking
+	topK     int
+	timeout  time.Duration
+}
+
+// searchReranked runs search for at least rerank.topK matches, rescores
+// them with the reranker, and returns cfg's page of the new order. The
+// vector order stands if the reranker fails, returns the wrong number of
+// scores, or outlasts its timeout.
+func (c *Cache[K, V]) searchReranked(ctx context.Context, inputText string, query []float64, cfg lookupConfig) ([]MatchEx[K, V], error) {
+	wide := cfg
+	wide.offset = 0
+	wide.limit = cfg.offset + cfg.limit
+	if wide.limit < cfg.limit {
+		wide.limit = math.MaxInt
+	}
+	wide.limit = max(wide.limit, c.rerank.topK)
+	matches, err := c.search(ctx, query, wide)
+	if err != nil || len(matches) == 0 {
+		return matches, err
+	}
+
+	if scores, ok := c.rerankScores(ctx, inputText, matches); ok {
+		for i := range matches {
+			matches[i].Score = scores[i]
+		}
+		sort.SliceStable(matches, func(i, j int) bool {
+			return matches[i].Score > matches[j].Score
+		})
+	}
+	matches = matches[min(cfg.offset, len(matches)):]
+	return matches[:min(cfg.limit, len(matches))], nil
+}
+
+// rerankScores asks the reranker to score matches. It runs the reranker in
+// its own goroutine so one that ignores its context still cannot hold the
+// lookup past the timeout.
+func (c *Cache[K, V]) rerankScores(ctx context.Context, inputText string, matches []MatchEx[K, V]) ([]float64, bool) {
+	if c.rerank.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.rerank.timeout)
+		defer cancel()
+	}
+	texts := make([]string, len(matches))
+	for i, m := range matches {
+		texts[i] = m.InputText
+	}
+
+	type result struct {
+		scores []float64
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		scores, err := c.rerank.reranker.Rerank(ctx, inputText, texts)
+		done <- result{scores, err}
+	}()
+	select {
+	case r := <-done:
+		switch {
+		case r.err != nil:
+			c.logger.WarnContext(ctx, "semanticcache: reranker failed; keeping vector order", "error", r.err)
+		case len(r.scores) != len(matches):
+			c.logger.WarnContext(ctx, "semanticcache: reranker returned the wrong number of scores; keeping vector order",
+				"scores", len(r.scores), "matches", len(matches))
+		default:
+			return r.scores, true
+		}
+		return nil, false
+	case <-ctx.Done():
+		c.logger.WarnContext(ctx, "semanticcache: reranker timed out; keeping vector order", "error", ctx.Err())
+		return nil, false
+	}
+}
@@ -0,0 +1,228 @@
+package semanticcache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// job is a maintenance task the scheduler runs every interval. run paces
+// the entries it touches with lim.
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context, lim *limiter) error
+}
+
+// scheduler runs the cache's maintenance jobs in the background until
+// stopped: refresh-ahead, the lexical sweep, and the expiry sweep. Each job
+// waits its interval, shifted by up to jitter of it either way, between
+// runs, and the jobs share one limiter. A nil *scheduler runs nothing.
+type scheduler struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newScheduler(logger *slog.Logger, jobs []job, rate, jitter float64) *scheduler {
+	if len(jobs) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &scheduler{cancel: cancel, done: make(chan struct{})}
+	lim := newLimiter(rate)
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Go(func() { j.loop(ctx, logger, lim, jitter) })
+	}
+	go func() {
+		wg.Wait()
+		close(s.done)
+	}()
+	return s
+}
+
+// loop runs j until ctx ends, logging failures.
+func (j job) loop(ctx context.Context, logger *slog.Logger, lim *limiter, jitter float64) {
+	timer := time.NewTimer(jittered(j.interval, jitter))
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if err := j.run(ctx, lim); err != nil && ctx.Err() == nil && !errors.Is(err, ErrClosed) {
+				logger.WarnContext(ctx, "semanticcache: background "+j.name+" failed", "error", err)
+			}
+			timer.Reset(jittered(j.interval, jitter))
+		}
+	}
+}
+
+// jittered returns interval shifted by a random amount of up to jitter of
+// it either way.
+func jittered(interval time.Duration, jitter float64) time.Duration {
+	if jitter == 0 {
+		return interval
+	}
+	return interval + time.Duration((2*rand.Float64()-1)*jitter*float64(interval))
+}
+
+// stop cancels running jobs and waits for them to exit or for ctx to end.
+func (s *scheduler) stop(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limiter paces maintenance work to a rate of entries per second shared by
+// every job. A nil *limiter does not wait.
+type limiter struct {
+	mu   sync.Mutex
+	per  time.Duration // the time one entry uses up
+	next time.Time     // when the next reservation starts
+}
+
+func newLimiter(rate float64) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &limiter{per: time.Duration(float64(time.Second) / rate)}
+}
+
+// wait reserves n entries of work and blocks until the reservation starts
+// or ctx ends. Work after an idle spell starts at once.
+func (l *limiter) wait(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	start := l.next
+	l.next = start.Add(time.Duration(n) * l.per)
+	l.mu.Unlock()
+
+	d := start.Sub(now)
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// maintenanceJobs returns the jobs the configuration enables.
+func (c *Cache[K, V]) maintenanceJobs(refresh, lexicalSweep, expirySweep time.Duration) []job {
+	var jobs []job
+	if refresh > 0 {
+		jobs = append(jobs, job{"refresh", refresh, func(ctx context.Context, lim *limiter) error {
+			_, err := c.refreshStale(ctx, lim)
+			return err
+		}})
+	}
+	if lexicalSweep > 0 {
+		jobs = append(jobs, job{"lexical sweep", lexicalSweep, c.sweepLexical})
+	}
+	if expirySweep > 0 {
+		jobs = append(jobs, job{"expiry sweep", expirySweep, c.sweepExpired})
+	}
+	return jobs
+}
+
+// sweepExpired deletes the entries created more than options.WithExpirySweep's
+// max age ago and drops their documents from the lexical index in whichever
+// namespace stored them. It deletes as it scans, in batches of the refresh
+// batch size, so memory stays bounded, and re-reads each key just before
+// deleting it, so an entry rewritten while the batch waited on the limiter
+// is kept. Reads go through peekEntry, so the sweep does not disturb the
+// eviction policy.
+func (c *Cache[K, V]) sweepExpired(ctx context.Context, lim *limiter) error {
+	cutoff := time.Now().Add(-c.expiryAge)
+	expired := func(entry types.Entry[V]) bool {
+		return !entry.CreatedAt.IsZero() && entry.CreatedAt.Before(cutoff)
+	}
+	batch := make([]K, 0, c.refresh.batchSize)
+	var deleteErr error
+	err := c.peekRange(ctx, func(key K, entry types.Entry[V]) bool {
+		if !expired(entry) {
+			return true
+		}
+		if batch = append(batch, key); len(batch) < c.refresh.batchSize {
+			return true
+		}
+		deleteErr = c.deleteExpired(ctx, lim, batch, expired)
+		batch = batch[:0]
+		return deleteErr == nil
+	})
+	if err != nil {
+		return err
+	}
+	if deleteErr != nil {
+		return deleteErr
+	}
+	return c.deleteExpired(ctx, lim, batch, expired)
+}
+
+// deleteExpired deletes the keys whose entries are still expired.
+func (c *Cache[K, V]) deleteExpired(ctx context.Context, lim *limiter, keys []K, expired func(types.Entry[V]) bool) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := lim.wait(ctx, len(keys)); err != nil {
+		return err
+	}
+	stale := make([]K, 0, len(keys))
+	metadata := make([]map[string]string, 0, len(keys))
+	for _, key := range keys {
+		entry, found, err := c.peekEntry(ctx, key)
+		if err != nil {
+			return err
+		}
+		if found && expired(entry) {
+			stale = append(stale, key)
+			metadata = append(metadata, entry.Metadata)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	if err := deleteKeys(ctx, c.backend, stale); err != nil {
+		return err
+	}
+	for i, key := range stale {
+		c.pruneEvicted(key, types.Entry[V]{Metadata: metadata[i]})
+	}
+	return nil
+}
+
+// deleteKeys deletes keys from b, in one call when b supports batches.
+func deleteKeys[K comparable, V any](ctx context.Context, b types.Backend[K, V], keys []K) error {
+	if bb, ok := b.(types.BatchBackend[K, V]); ok {
+		return bb.DeleteBatch(ctx, keys)
+	}
+	for _, key := range keys {
+		if err := b.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
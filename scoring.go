@@ -0,0 +1,61 @@
+package semanticcache
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// scoringConfig holds the options.WithRecencyDecay and
+// options.WithFrequencyBoost settings.
+type scoringConfig struct {
+	halfLife  time.Duration
+	recency   float64 // weight of the recency factor; 0 disables it
+	frequency float64 // weight of the frequency factor; 0 disables it
+}
+
+func (s scoringConfig) enabled() bool {
+	return s.recency > 0 || s.frequency > 0
+}
+
+// boostScores scales each candidate's score by its entry's recency and
+// access frequency. A factor f with weight w scales by 1-w+w*f: recency is
+// 0.5^(age/halfLife), and frequency is log(1+accesses) relative to the most
+// accessed candidate. Candidates the backend has no stats for, and all of
+// them if fetching stats fails, keep their scores.
+func (c *Cache[K, V]) boostScores(ctx context.Context, candidates []candidate[K]) {
+	sb, ok := c.backend.(types.StatsBackend[K, V])
+	if !ok || !c.scoring.enabled() || len(candidates) == 0 {
+		return
+	}
+	keys := make([]K, len(candidates))
+	for i, cand := range candidates {
+		keys[i] = cand.key
+	}
+	stats, err := sb.EntryStats(ctx, keys)
+	if err != nil {
+		return
+	}
+	most := 0
+	for _, s := range stats {
+		most = max(most, s.Accesses)
+	}
+	now := time.Now()
+	for i, cand := range candidates {
+		s, ok := stats[cand.key]
+		if !ok {
+			continue
+		}
+		factor := 1.0
+		if w := c.scoring.recency; w > 0 && !s.CreatedAt.IsZero() {
+			decay := math.Exp2(-float64(now.Sub(s.CreatedAt)) / float64(c.scoring.halfLife))
+			factor *= 1 - w + w*min(decay, 1)
+		}
+		if w := c.scoring.frequency; w > 0 && most > 0 {
+			factor *= 1 - w + w*math.Log1p(float64(s.Accesses))/math.Log1p(float64(most))
+		}
+		candidates[i].score *= factor
+	}
+}
@@ -0,0 +1,234 @@
+package semanticcache
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// scanBatchSize is how many keys a scan fetches embeddings for at once when
+// the backend implements types.EmbeddingBatchBackend.
+const scanBatchSize = 256
+
+// rank scores every entry and returns those at or above cfg.threshold,
+// best first after any recency and frequency boosts. Embeddings whose
+// dimension differs from the query's are skipped. With a search
+// concurrency above one, embeddings are fetched and scored by that many
+// workers. Cancelling ctx stops the scan; rank then returns ctx's error
+// along with the candidates ranked so far.
+func (c *Cache[K, V]) rank(ctx context.Context, query []float64, cfg lookupConfig) ([]candidate[K], error) {
+	ctx, span := c.startSpan(ctx, "backend.Scan", attrDims.Int(len(query)))
+	var candidates []candidate[K]
+	var err error
+	defer func() {
+		span.SetAttributes(attrMatches.Int(len(candidates)))
+		endSpan(span, err)
+	}()
+	if c.searchWorkers > 1 {
+		candidates, err = c.scanParallel(ctx, query, cfg)
+	} else {
+		candidates, err = c.scan(ctx, query, cfg)
+	}
+	if err != nil && err != ctx.Err() {
+		return nil, err
+	}
+	var stale []K
+	candidates = slices.DeleteFunc(candidates, func(cand candidate[K]) bool {
+		if cand.stale {
+			stale = append(stale, cand.key)
+		}
+		return cand.stale
+	})
+	c.reembedStale(ctx, stale)
+	c.boostScores(ctx, candidates)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].score > candidates[j].score
+	})
+	return candidates, err
+}
+
+func (c *Cache[K, V]) scan(ctx context.Context, query []float64, cfg lookupConfig) ([]candidate[K], error) {
+	var candidates []candidate[K]
+	for batch, err := range c.keyBatches(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		if err := ctx.Err(); err != nil {
+			return candidates, err
+		}
+		if candidates, err = c.score(ctx, query, cfg, batch, candidates); err != nil {
+			return nil, err
+		}
+	}
+	return candidates, ctx.Err()
+}
+
+func (c *Cache[K, V]) scanParallel(ctx context.Context, query []float64, cfg lookupConfig) ([]candidate[K], error) {
+	batches := make(chan []K)
+	found := make([][]candidate[K], c.searchWorkers)
+	errs := make([]error, c.searchWorkers)
+	var wg sync.WaitGroup
+	for i := range found {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				if errs[i] == nil && ctx.Err() == nil {
+					found[i], errs[i] = c.score(ctx, query, cfg, batch, found[i])
+				}
+			}
+		}()
+	}
+
+	var err error
+	for batch, iterErr := range c.keyBatches(ctx) {
+		if iterErr != nil {
+			err = iterErr
+			break
+		}
+		select {
+		case batches <- batch:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+		if err != nil {
+			break
+		}
+	}
+	close(batches)
+	wg.Wait()
+	if err == nil {
+		err = errors.Join(errs...)
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil && err != ctx.Err() {
+		return nil, err
+	}
+
+	var candidates []candidate[K]
+	for _, f := range found {
+		candidates = append(candidates, f...)
+	}
+	return candidates, err
+}
+
+// keyBatches groups the backend's keys into batches of scanBatchSize, or of
+// one key when the backend cannot fetch embeddings in bulk.
+func (c *Cache[K, V]) keyBatches(ctx context.Context) iter.Seq2[[]K, error] {
+	size := 1
+	switch c.backend.(type) {
+	case types.EmbeddingBatchBackend[K, V], types.MultiVectorBackend[K, V]:
+		size = scanBatchSize
+	}
+	return func(yield func([]K, error) bool) {
+		batch := make([]K, 0, size)
+		for key, err := range c.iterKeys(ctx) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			batch = append(batch, key)
+			if len(batch) == size {
+				if !yield(batch, nil) {
+					return
+				}
+				batch = make([]K, 0, size)
+			}
+		}
+		if len(batch) > 0 {
+			yield(batch, nil)
+		}
+	}
+}
+
+// score compares the embeddings of keys with query and appends those at or
+// above cfg.threshold to candidates. Missing entries and fetch errors are
+// skipped; the only error returned is a *DimensionError.
+func (c *Cache[K, V]) score(ctx context.Context, query []float64, cfg lookupConfig, keys []K, candidates []candidate[K]) ([]candidate[K], error) {
+	var err error
+	if mb, ok := c.backend.(types.MultiVectorBackend[K, V]); ok {
+		vectors, fetchErr := mb.GetVectors(ctx, keys)
+		if fetchErr != nil {
+			c.warnSkipped(ctx, len(keys), fetchErr)
+			return candidates, nil
+		}
+		for _, key := range keys {
+			if embs, ok := vectors[key]; ok {
+				if candidates, err = c.appendCandidate(candidates, cfg, query, key, embs...); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return candidates, nil
+	}
+	if eb, ok := c.backend.(types.EmbeddingBatchBackend[K, V]); ok {
+		embeddings, fetchErr := eb.GetEmbeddings(ctx, keys)
+		if fetchErr != nil {
+			c.warnSkipped(ctx, len(keys), fetchErr)
+			return candidates, nil
+		}
+		for _, key := range keys {
+			if emb, ok := embeddings[key]; ok {
+				if candidates, err = c.appendCandidate(candidates, cfg, query, key, emb); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return candidates, nil
+	}
+	for _, key := range keys {
+		emb, ok, fetchErr := c.backend.GetEmbedding(ctx, key)
+		if fetchErr != nil {
+			c.warnSkipped(ctx, 1, fetchErr)
+		} else if ok {
+			if candidates, err = c.appendCandidate(candidates, cfg, query, key, emb); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// warnSkipped logs that a scan skipped n entries whose embeddings failed
+// to load. Errors from a cancelled scan are expected and not logged.
+func (c *Cache[K, V]) warnSkipped(ctx context.Context, n int, err error) {
+	if ctx.Err() == nil {
+		c.logger.WarnContext(ctx, "semanticcache: lookup skipped entries whose embeddings failed to load", "entries", n, "error", err)
+	}
+}
+
+// appendCandidate scores key by the best of its embeddings against query
+// and appends it if that meets cfg.threshold. A primary embedding of
+// another dimension is appended as stale when lookups re-embed, reported as
+// a *DimensionError when dimension checks are on, and dropped otherwise.
+func (c *Cache[K, V]) appendCandidate(candidates []candidate[K], cfg lookupConfig, query []float64, key K, embs ...[]float64) ([]candidate[K], error) {
+	if len(embs[0]) != len(query) {
+		if c.refresh.onLookup {
+			return append(candidates, candidate[K]{key: key, stale: true}), nil
+		}
+		if c.dims != nil {
+			return nil, &DimensionError{Expected: len(query), Actual: len(embs[0])}
+		}
+		return candidates, nil
+	}
+	best := candidate[K]{key: key, score: cfg.score(query, embs[0])}
+	for i, emb := range embs[1:] {
+		if len(emb) != len(query) {
+			continue
+		}
+		if score := cfg.score(query, emb); score > best.score {
+			best.score, best.vector = score, i+1
+		}
+	}
+	if best.score >= cfg.threshold {
+		candidates = append(candidates, best)
+	}
+	return candidates, nil
+}
@@ -0,0 +1,293 @@
+// Package http serves a semanticcache.Cache over HTTP with JSON bodies, so
+// services outside Go can share one cache instance.
+//
+//	PUT    /entries/{key}  store {"text", "value", "metadata"}
+//	GET    /entries/{key}  fetch the value
+//	DELETE /entries/{key}  delete the entry
+//	POST   /lookup         best match for {"text", "threshold"}
+//	POST   /top-matches    up to "n" matches for {"text", "n"}
+//	POST   /flush          delete every entry
+//	GET    /stats          entry count, provider availability, memory estimate
+//
+// With WithAPIKeys, every request must carry one of the keys as a bearer
+// token or in the X-API-Key header.
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/types"
+)
+
+// DefaultMaxBodyBytes is the request body limit without WithMaxBodyBytes.
+const DefaultMaxBodyBytes = 1 << 20
+
+// Option configures a Handler.
+type Option func(*config)
+
+type config struct {
+	keys    [][]byte
+	maxBody int64
+}
+
+// WithAPIKeys requires every request to present one of keys, as
+// "Authorization: Bearer <key>" or "X-API-Key: <key>". Without it the
+// handler serves anyone who can reach it.
+func WithAPIKeys(keys ...string) Option {
+	return func(c *config) {
+		for _, k := range keys {
+			if k != "" {
+				c.keys = append(c.keys, []byte(k))
+			}
+		}
+	}
+}
+
+// WithMaxBodyBytes limits request bodies to n bytes. Defaults to
+// DefaultMaxBodyBytes.
+func WithMaxBodyBytes(n int64) Option {
+	return func(c *config) { c.maxBody = n }
+}
+
+// Handler serves a cache with string keys. Values are encoded as JSON, so
+// V must round-trip through encoding/json.
+type Handler[V any] struct {
+	cache *semanticcache.Cache[string, V]
+	cfg   config
+	mux   *http.ServeMux
+}
+
+// NewHandler returns a Handler serving cache. It does not close cache.
+func NewHandler[V any](cache *semanticcache.Cache[string, V], opts ...Option) *Handler[V] {
+	h := &Handler[V]{cache: cache, cfg: config{maxBody: DefaultMaxBodyBytes}, mux: http.NewServeMux()}
+	for _, o := range opts {
+		o(&h.cfg)
+	}
+	h.mux.HandleFunc("PUT /entries/{key...}", h.set)
+	h.mux.HandleFunc("GET /entries/{key...}", h.get)
+	h.mux.HandleFunc("DELETE /entries/{key...}", h.delete)
+	h.mux.HandleFunc("POST /lookup", h.lookup)
+	h.mux.HandleFunc("POST /top-matches", h.topMatches)
+	h.mux.HandleFunc("POST /flush", h.flush)
+	h.mux.HandleFunc("GET /stats", h.stats)
+	return h
+}
+
+// ServeHTTP authenticates the request and routes it.
+func (h *Handler[V]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="semanticcache"`)
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid API key"))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, h.cfg.maxBody)
+	h.mux.ServeHTTP(w, r)
+}
+
+// authorized reports whether r presents a configured API key, comparing
+// in constant time.
+func (h *Handler[V]) authorized(r *http.Request) bool {
+	if len(h.cfg.keys) == 0 {
+		return true
+	}
+	got := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); got == "" && len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		got = auth[len("Bearer "):]
+	}
+	ok := 0
+	for _, k := range h.cfg.keys {
+		ok |= subtle.ConstantTimeCompare([]byte(got), k)
+	}
+	return got != "" && ok == 1
+}
+
+// SetRequest is the body of PUT /entries/{key}.
+type SetRequest[V any] struct {
+	Text     string            `json:"text"`
+	Value    V                 `json:"value"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// EntryResponse is the body GET /entries/{key} returns.
+type EntryResponse[V any] struct {
+	Key   string `json:"key"`
+	Value V      `json:"value"`
+}
+
+// LookupRequest is the body of POST /lookup and POST /top-matches.
+// Threshold defaults to the cache's for lookups and is not applied to top
+// matches unless set. N defaults to 1.
+type LookupRequest struct {
+	Text      string   `json:"text"`
+	Threshold *float64 `json:"threshold,omitempty"`
+	N         int      `json:"n,omitempty"`
+}
+
+// LookupResponse is the body POST /lookup returns; Match is null on a
+// miss.
+type LookupResponse[V any] struct {
+	Match *semanticcache.MatchEx[string, V] `json:"match"`
+}
+
+// TopMatchesResponse is the body POST /top-matches returns.
+type TopMatchesResponse[V any] struct {
+	Matches []semanticcache.MatchEx[string, V] `json:"matches"`
+}
+
+// StatsResponse is the body GET /stats returns. Memory is omitted when the
+// backend does not report it.
+type StatsResponse struct {
+	Entries           int                         `json:"entries"`
+	ProviderAvailable bool                        `json:"provider_available"`
+	Chunking          semanticcache.ChunkingStats `json:"chunking"`
+	Memory            *types.MemoryUsage          `json:"memory,omitempty"`
+}
+
+func (h *Handler[V]) set(w http.ResponseWriter, r *http.Request) {
+	var req SetRequest[V]
+	if !decode(w, r, &req) {
+		return
+	}
+	if err := h.cache.SetWithMetadata(r.Context(), r.PathValue("key"), req.Text, req.Value, req.Metadata); err != nil {
+		writeCacheError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler[V]) get(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	value, found, err := h.cache.Get(r.Context(), key)
+	switch {
+	case err != nil:
+		writeCacheError(w, err)
+	case !found:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no entry for key %q", key))
+	default:
+		writeJSON(w, http.StatusOK, EntryResponse[V]{Key: key, Value: value})
+	}
+}
+
+func (h *Handler[V]) delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.cache.Delete(r.Context(), r.PathValue("key")); err != nil {
+		writeCacheError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler[V]) lookup(w http.ResponseWriter, r *http.Request) {
+	var req LookupRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	opts := []semanticcache.LookupOption{semanticcache.WithLimit(1)}
+	if req.Threshold != nil {
+		opts = append(opts, semanticcache.WithThreshold(*req.Threshold))
+	}
+	matches, err := h.cache.LookupWithOptions(r.Context(), req.Text, opts...)
+	if err != nil {
+		writeCacheError(w, err)
+		return
+	}
+	var resp LookupResponse[V]
+	if len(matches) > 0 {
+		resp.Match = &matches[0]
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *Handler[V]) topMatches(w http.ResponseWriter, r *http.Request) {
+	var req LookupRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	var opts []semanticcache.LookupOption
+	if req.Threshold != nil {
+		opts = append(opts, semanticcache.WithThreshold(*req.Threshold))
+	}
+	matches, err := h.cache.TopMatchesEx(r.Context(), req.Text, max(req.N, 1), opts...)
+	if err != nil {
+		writeCacheError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, TopMatchesResponse[V]{Matches: matches})
+}
+
+func (h *Handler[V]) flush(w http.ResponseWriter, r *http.Request) {
+	if err := h.cache.Flush(r.Context()); err != nil {
+		writeCacheError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler[V]) stats(w http.ResponseWriter, r *http.Request) {
+	n, err := h.cache.Len(r.Context())
+	if err != nil {
+		writeCacheError(w, err)
+		return
+	}
+	resp := StatsResponse{Entries: n, ProviderAvailable: h.cache.ProviderAvailable(), Chunking: h.cache.ChunkingStats()}
+	switch usage, err := h.cache.MemoryUsage(r.Context()); {
+	case err == nil:
+		resp.Memory = &usage
+	case !errors.Is(err, semanticcache.ErrMemoryUsageUnsupported):
+		writeCacheError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// decode reads r's JSON body into v, answering 400 or 413 if it cannot.
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+// writeCacheError maps a cache error to a status: 400 for rejected
+// input, 503 while the cache is closed or the provider circuit is open,
+// and 500 otherwise. A dimension mismatch is a provider or configuration
+// fault, not something the client sent, so it stays a 500.
+func writeCacheError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, semanticcache.ErrZeroKey), errors.Is(err, semanticcache.ErrInvalidN),
+		errors.Is(err, semanticcache.ErrMetadataUnsupported):
+		status = http.StatusBadRequest
+	case errors.Is(err, semanticcache.ErrClosed), errors.Is(err, semanticcache.ErrCircuitOpen):
+		status = http.StatusServiceUnavailable
+	}
+	writeError(w, status, err)
+}
+
+// ErrorResponse is the body of every error reply.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
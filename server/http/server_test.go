@@ -0,0 +1,138 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/botirk38/semanticcache"
+	"github.com/botirk38/semanticcache/options"
+)
+
+func newTestServer(t *testing.T, opts ...Option) *httptest.Server {
+	t.Helper()
+	cache, err := semanticcache.New(
+		options.WithLRUBackend[string, string](100),
+		options.WithLocalProvider[string, string](64),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	srv := httptest.NewServer(NewHandler(cache, opts...))
+	t.Cleanup(func() {
+		srv.Close()
+		_ = cache.Close()
+	})
+	return srv
+}
+
+func do(t *testing.T, srv *httptest.Server, method, path, body string, header ...string) (*http.Response, map[string]any) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(context.Background(), method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp, out
+}
+
+func TestHandler(t *testing.T) {
+	srv := newTestServer(t)
+
+	if resp, _ := do(t, srv, "PUT", "/entries/q1", `{"text":"What is Go?","value":"a language"}`); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("PUT status %d", resp.StatusCode)
+	}
+	if resp, body := do(t, srv, "GET", "/entries/q1", ""); resp.StatusCode != http.StatusOK || body["value"] != "a language" {
+		t.Errorf("GET = %d %v", resp.StatusCode, body)
+	}
+
+	_, body := do(t, srv, "POST", "/lookup", `{"text":"What is Go?","threshold":0.9}`)
+	if m, _ := body["match"].(map[string]any); m["key"] != "q1" || m["value"] != "a language" {
+		t.Errorf("lookup = %v", body)
+	}
+	_, body = do(t, srv, "POST", "/top-matches", `{"text":"What is Go?","n":5}`)
+	if matches, _ := body["matches"].([]any); len(matches) != 1 {
+		t.Errorf("top-matches = %v", body)
+	}
+	if _, body = do(t, srv, "GET", "/stats", ""); body["entries"] != 1.0 || body["memory"] == nil {
+		t.Errorf("stats = %v", body)
+	}
+
+	if resp, _ := do(t, srv, "DELETE", "/entries/q1", ""); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("DELETE status %d", resp.StatusCode)
+	}
+	if resp, _ := do(t, srv, "GET", "/entries/q1", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 after DELETE, got %d", resp.StatusCode)
+	}
+	do(t, srv, "PUT", "/entries/q2", `{"text":"x","value":"y"}`)
+	if resp, _ := do(t, srv, "POST", "/flush", ""); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("flush status %d", resp.StatusCode)
+	}
+	if _, body = do(t, srv, "POST", "/lookup", `{"text":"x"}`); body["match"] != nil {
+		t.Errorf("expected a miss after flush, got %v", body)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	srv := newTestServer(t, WithMaxBodyBytes(64))
+	for _, tc := range []struct {
+		method, path, body string
+		status             int
+	}{
+		{"PUT", "/entries/k", `{"text":`, http.StatusBadRequest},
+		{"PUT", "/entries/k", `{"txt":"x"}`, http.StatusBadRequest},
+		{"PUT", "/entries/k", `{"text":"` + strings.Repeat("x", 100) + `"}`, http.StatusRequestEntityTooLarge},
+		{"POST", "/entries/k", `{}`, http.StatusMethodNotAllowed},
+	} {
+		resp, body := do(t, srv, tc.method, tc.path, tc.body)
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s %q = %d %v, want %d", tc.method, tc.path, tc.body, resp.StatusCode, body, tc.status)
+		}
+	}
+}
+
+func TestHandlerDimensionMismatch(t *testing.T) {
+	cache, err := semanticcache.New(
+		options.WithLRUBackend[string, string](100),
+		options.WithLocalProvider[string, string](64),
+		options.WithDimensionCheck[string, string](32),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	srv := httptest.NewServer(NewHandler(cache))
+	defer srv.Close()
+	defer cache.Close()
+
+	if resp, body := do(t, srv, "PUT", "/entries/k", `{"text":"x","value":"y"}`); resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("PUT = %d %v, want 500", resp.StatusCode, body)
+	}
+}
+
+func TestHandlerAPIKeys(t *testing.T) {
+	srv := newTestServer(t, WithAPIKeys("s3cret", "other"))
+	if resp, body := do(t, srv, "GET", "/stats", ""); resp.StatusCode != http.StatusUnauthorized || body["error"] == nil {
+		t.Errorf("expected 401 without a key, got %d %v", resp.StatusCode, body)
+	}
+	if resp, _ := do(t, srv, "GET", "/stats", "", "Authorization", "Bearer wrong"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a wrong key, got %d", resp.StatusCode)
+	}
+	if resp, _ := do(t, srv, "GET", "/stats", "", "Authorization", "Bearer s3cret"); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 for a bearer key, got %d", resp.StatusCode)
+	}
+	if resp, _ := do(t, srv, "GET", "/stats", "", "X-API-Key", "other"); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 for an X-API-Key, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,40 @@
+package semanticcache
+
+import "context"
+
+// sessionScope starts the scope of every session. Namespace escapes "%",
+// so no namespace name maps to it, and sessions stay apart from namespaces
+// of any name.
+const sessionScope = "%session/"
+
+// Session is a view of a cache scoped to one session, such as a chatbot
+// conversation. It supports every Cache method; End discards the session's
+// entries in bulk.
+type Session[K comparable, V any] struct {
+	*Cache[K, V]
+	id string
+}
+
+// WithSessionScope returns a view of the cache scoped to session id, with
+// the same key handling as Namespace. The id is escaped like a namespace
+// name, so any id is safe. Entries written through it are only seen by views
+// of the same session, and End removes them all. Closing the
+// session closes the cache it was created from.
+func (c *Cache[K, V]) WithSessionScope(id string) *Session[K, V] {
+	return &Session[K, V]{Cache: c.child(sessionScope + namespaceEscaper.Replace(id)), id: id}
+}
+
+// ID returns the session's id.
+func (s *Session[K, V]) ID() string {
+	return s.id
+}
+
+// End deletes every entry written through the session, including through
+// namespaces nested in it, which its Flush leaves alone. The session stays usable, and the cache stays
+// open.
+func (s *Session[K, V]) End(ctx context.Context) error {
+	if err := s.Flush(ctx); err != nil {
+		return err
+	}
+	return s.flushNested(ctx)
+}
@@ -0,0 +1,27 @@
+package similarity
+
+import "math"
+
+// AngularSimilarity computes 1 minus the angle between two vectors divided
+// by pi. Unlike cosine similarity, 1 - AngularSimilarity is a true metric,
+// which suits clustering, yet it ranks vectors exactly as cosine does.
+// Returns a value between 0 and 1, where 1 means identical direction.
+func AngularSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	cos := dot / (math.Sqrt(normA) * math.Sqrt(normB))
+	return 1 - math.Acos(max(-1, min(1, cos)))/math.Pi
+}
@@ -0,0 +1,26 @@
+package similarity
+
+import "math/bits"
+
+// Binarize quantizes a vector to one bit per dimension, set when the value
+// is positive, packed 64 dimensions per word. It shrinks a []float64
+// embedding 64 times.
+func Binarize(v []float64) []uint64 {
+	out := make([]uint64, (len(v)+63)/64)
+	for i, x := range v {
+		if x > 0 {
+			out[i/64] |= 1 << (i % 64)
+		}
+	}
+	return out
+}
+
+// HammingDistance counts the differing bits of two vectors packed by
+// Binarize. Words past the shorter vector are ignored.
+func HammingDistance(a, b []uint64) int {
+	d := 0
+	for i := range min(len(a), len(b)) {
+		d += bits.OnesCount64(a[i] ^ b[i])
+	}
+	return d
+}
@@ -0,0 +1,108 @@
+package similarity
+
+import "errors"
+
+var (
+	// ErrNoVectors is returned by Centroid when given no vectors.
+	ErrNoVectors = errors.New("similarity: no vectors")
+
+	// ErrMixedDimensions is returned by Centroid when the vectors have
+	// different lengths.
+	ErrMixedDimensions = errors.New("similarity: vectors have different dimensions")
+
+	// ErrInvalidWeights is returned by WeightedCentroid when the weights
+	// do not match the vectors, are negative, or sum to zero.
+	ErrInvalidWeights = errors.New("similarity: weights must match the vectors, be non-negative, and not all be zero")
+)
+
+// Centroid returns the element-wise mean of vectors, such as the center of
+// a cluster of cached embeddings.
+func Centroid(vectors [][]float64) ([]float64, error) {
+	if len(vectors) == 0 {
+		return nil, ErrNoVectors
+	}
+	c := make([]float64, len(vectors[0]))
+	for _, v := range vectors {
+		if len(v) != len(c) {
+			return nil, ErrMixedDimensions
+		}
+		for i, x := range v {
+			c[i] += x
+		}
+	}
+	for i := range c {
+		c[i] /= float64(len(vectors))
+	}
+	return c, nil
+}
+
+// WeightedCentroid returns the weighted element-wise mean of vectors, with
+// weights[i] the weight of vectors[i].
+func WeightedCentroid(vectors [][]float64, weights []float64) ([]float64, error) {
+	if len(vectors) == 0 {
+		return nil, ErrNoVectors
+	}
+	if len(weights) != len(vectors) {
+		return nil, ErrInvalidWeights
+	}
+	c := make([]float64, len(vectors[0]))
+	total := 0.0
+	for i, v := range vectors {
+		if len(v) != len(c) {
+			return nil, ErrMixedDimensions
+		}
+		if weights[i] < 0 {
+			return nil, ErrInvalidWeights
+		}
+		for j, x := range v {
+			c[j] += weights[i] * x
+		}
+		total += weights[i]
+	}
+	if total == 0 {
+		return nil, ErrInvalidWeights
+	}
+	for j := range c {
+		c[j] /= total
+	}
+	return c, nil
+}
+
+// Matrix returns the pairwise similarities of vectors under fn: element
+// [i][j] is fn(vectors[i], vectors[j]). fn is assumed symmetric, so each
+// pair is scored once.
+func Matrix(vectors [][]float64, fn SimilarityFunc) [][]float64 {
+	m := make([][]float64, len(vectors))
+	for i := range m {
+		m[i] = make([]float64, len(vectors))
+	}
+	for i, a := range vectors {
+		for j := i; j < len(vectors); j++ {
+			s := fn(a, vectors[j])
+			m[i][j], m[j][i] = s, s
+		}
+	}
+	return m
+}
+
+// NearestCentroid returns the index of the centroid most similar to v under
+// fn, and that similarity. It returns -1 and 0 without centroids.
+func NearestCentroid(v []float64, centroids [][]float64, fn SimilarityFunc) (int, float64) {
+	best, bestScore := -1, 0.0
+	for i, c := range centroids {
+		if s := fn(v, c); best < 0 || s > bestScore {
+			best, bestScore = i, s
+		}
+	}
+	return best, bestScore
+}
+
+// Assign returns, for each vector, the index of its nearest centroid under
+// fn, grouping cached embeddings by topic.
+func Assign(vectors, centroids [][]float64, fn SimilarityFunc) []int {
+	assignments := make([]int, len(vectors))
+	for i, v := range vectors {
+		assignments[i], _ = NearestCentroid(v, centroids, fn)
+	}
+	return assignments
+}
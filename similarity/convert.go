@@ -0,0 +1,108 @@
+package similarity
+
+import "math"
+
+// ToFloat64 widens a float32 vector, as returned by most embedding APIs and
+// model runtimes, to the []float64 used throughout this module. It returns
+// nil for a nil vector.
+func ToFloat64(v []float32) []float64 {
+	if v == nil {
+		return nil
+	}
+	out := make([]float64, len(v))
+	for i, x := range v {
+		out[i] = float64(x)
+	}
+	return out
+}
+
+// ToFloat32 narrows a vector for stores and indexes that keep float32, such
+// as FAISS. It returns nil for a nil vector.
+func ToFloat32(v []float64) []float32 {
+	if v == nil {
+		return nil
+	}
+	out := make([]float32, len(v))
+	for i, x := range v {
+		out[i] = float32(x)
+	}
+	return out
+}
+
+// ToFloat16 packs a vector as IEEE 754 half-precision bit patterns, rounding
+// each component to the nearest float16, to halve storage again relative to
+// float32. Values beyond ±65504 become infinities. It returns nil for a nil
+// vector.
+func ToFloat16(v []float64) []uint16 {
+	if v == nil {
+		return nil
+	}
+	out := make([]uint16, len(v))
+	for i, x := range v {
+		out[i] = float16Bits(float32(x))
+	}
+	return out
+}
+
+// FromFloat16 reverses ToFloat16. It returns nil for a nil vector.
+func FromFloat16(h []uint16) []float64 {
+	if h == nil {
+		return nil
+	}
+	out := make([]float64, len(h))
+	for i, x := range h {
+		out[i] = float64(float16Value(x))
+	}
+	return out
+}
+
+// float16Bits converts f to half precision, rounding to nearest even.
+func float16Bits(f float32) uint16 {
+	b := math.Float32bits(f)
+	sign := uint16(b>>16) & 0x8000
+	exp := int(b>>23&0xff) - 127 + 15
+	mant := b & 0x7fffff
+	switch {
+	case b&0x7fffffff > 0x7f800000: // NaN
+		return sign | 0x7e00
+	case exp >= 0x1f: // overflow and infinities
+		return sign | 0x7c00
+	case exp <= 0: // subnormal or zero
+		if exp < -10 {
+			return sign
+		}
+		mant |= 0x800000
+		shift := uint(14 - exp)
+		half := uint16(mant >> shift)
+		rem, mid := mant&(1<<shift-1), uint32(1)<<(shift-1)
+		if rem > mid || rem == mid && half&1 == 1 {
+			half++
+		}
+		return sign | half
+	}
+	half := uint16(exp)<<10 | uint16(mant>>13)
+	// A carry out of the mantissa correctly bumps the exponent, up to
+	// infinity.
+	if rem := mant & 0x1fff; rem > 0x1000 || rem == 0x1000 && half&1 == 1 {
+		half++
+	}
+	return sign | half
+}
+
+// float16Value converts half-precision bits to a float32, exactly.
+func float16Value(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1f
+	mant := uint32(h & 0x3ff)
+	switch exp {
+	case 0x1f: // infinities and NaN
+		return math.Float32frombits(sign | 0x7f800000 | mant<<13)
+	case 0: // subnormal or zero
+		f := float32(mant) * 0x1p-24
+		if sign != 0 {
+			f = -f
+		}
+		return f
+	}
+	return math.Float32frombits(sign | (exp+112)<<23 | mant<<13)
+}
@@ -0,0 +1,20 @@
+package similarity
+
+// HammingSimilarity compares the binarized forms of two vectors: the share
+// of dimensions whose signs agree, treating positive values as 1 and the
+// rest as 0. Returns a value between 0 and 1, where 1 means every sign
+// matches. It suits binary-quantized embeddings; see Binarize.
+func HammingSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	same := 0
+	for i := range a {
+		if (a[i] > 0) == (b[i] > 0) {
+			same++
+		}
+	}
+
+	return float64(same) / float64(len(a))
+}
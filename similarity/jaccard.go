@@ -0,0 +1,25 @@
+package similarity
+
+// JaccardSimilarity computes the weighted Jaccard similarity of two
+// non-negative vectors: the sum of element-wise minimums over the sum of
+// element-wise maximums. For 0/1 vectors this is the Jaccard index of the
+// sets they encode, and it suits sparse lexical embeddings such as SPLADE.
+// Negative values count as zero. Returns a value between 0 and 1.
+func JaccardSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var inter, union float64
+	for i := range a {
+		x, y := max(a[i], 0), max(b[i], 0)
+		inter += min(x, y)
+		union += max(x, y)
+	}
+
+	if union == 0 {
+		return 0
+	}
+
+	return inter / union
+}
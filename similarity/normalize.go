@@ -0,0 +1,70 @@
+package similarity
+
+import (
+	"math"
+	"reflect"
+)
+
+// ranges holds the scores each built-in function can return.
+var ranges = map[uintptr][2]float64{
+	funcKey(CosineSimilarity):             {-1, 1},
+	funcKey(EuclideanSimilarity):          {0, 1},
+	funcKey(DotProductSimilarity):         {math.Inf(-1), math.Inf(1)},
+	funcKey(ManhattanSimilarity):          {0, 1},
+	funcKey(PearsonCorrelationSimilarity): {-1, 1},
+	funcKey(AngularSimilarity):            {0, 1},
+	funcKey(HammingSimilarity):            {0, 1},
+	funcKey(JaccardSimilarity):            {0, 1},
+}
+
+// Every function Normalize returns shares the code pointer registered here.
+func init() {
+	ranges[funcKey(Normalize(DotProductSimilarity))] = [2]float64{0, 1}
+}
+
+func funcKey(fn SimilarityFunc) uintptr {
+	return reflect.ValueOf(fn).Pointer()
+}
+
+// ScoreRange reports the lowest and highest scores fn can return, with
+// infinities for unbounded ends. ok is false for custom functions, whose
+// range is unknown. Functions returned by Normalize report [0, 1].
+func ScoreRange(fn SimilarityFunc) (lo, hi float64, ok bool) {
+	r, ok := ranges[funcKey(fn)]
+	return r[0], r[1], ok
+}
+
+// Normalizer returns the order-preserving map from fn's scores to [0, 1]
+// that Normalize applies: a linear rescale of a bounded range, a logistic
+// curve for an unbounded one, and clamping for custom functions.
+func Normalizer(fn SimilarityFunc) func(score float64) float64 {
+	lo, hi, ok := ScoreRange(fn)
+	switch {
+	case !ok || (lo == 0 && hi == 1):
+		return clamp01
+	case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+		return func(s float64) float64 { return 1 / (1 + math.Exp(-s)) }
+	case math.IsInf(hi, 1):
+		return func(s float64) float64 { return clamp01(1 - math.Exp(lo-s)) }
+	case math.IsInf(lo, -1):
+		return func(s float64) float64 { return clamp01(math.Exp(s - hi)) }
+	default:
+		return func(s float64) float64 { return clamp01((s - lo) / (hi - lo)) }
+	}
+}
+
+// Normalize wraps fn so that its scores fall in [0, 1] and rank entries in
+// the same order, making one threshold mean the same thing whichever
+// comparator is used. Functions already scoring in [0, 1] are returned
+// unchanged.
+func Normalize(fn SimilarityFunc) SimilarityFunc {
+	if lo, hi, ok := ScoreRange(fn); ok && lo == 0 && hi == 1 {
+		return fn
+	}
+	scale := Normalizer(fn)
+	return func(a, b []float64) float64 { return scale(fn(a, b)) }
+}
+
+func clamp01(s float64) float64 {
+	return max(0, min(1, s))
+}
@@ -0,0 +1,71 @@
+package similarity
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrUnknownSimilarity is returned by Get for a name nothing was
+	// registered under.
+	ErrUnknownSimilarity = errors.New("similarity: unknown similarity function")
+
+	// ErrInvalidRegistration is returned by Register for an empty name or
+	// a nil function.
+	ErrInvalidRegistration = errors.New("similarity: registration needs a name and a function")
+)
+
+var registry = struct {
+	sync.RWMutex
+	funcs map[string]SimilarityFunc
+}{funcs: map[string]SimilarityFunc{
+	"cosine":      CosineSimilarity,
+	"euclidean":   EuclideanSimilarity,
+	"dot_product": DotProductSimilarity,
+	"manhattan":   ManhattanSimilarity,
+	"pearson":     PearsonCorrelationSimilarity,
+	"angular":     AngularSimilarity,
+	"hamming":     HammingSimilarity,
+	"jaccard":     JaccardSimilarity,
+}}
+
+// Register makes fn available to Get under name, replacing any function
+// already registered under it. Names are case-insensitive.
+func Register(name string, fn SimilarityFunc) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" || fn == nil {
+		return ErrInvalidRegistration
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.funcs[name] = fn
+	return nil
+}
+
+// Get returns the function registered under name, such as "cosine",
+// "euclidean", "dot_product", "manhattan", "pearson", "angular", "hamming",
+// or "jaccard". Names are case-insensitive.
+func Get(name string) (SimilarityFunc, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+	fn, ok := registry.funcs[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSimilarity, name)
+	}
+	return fn, nil
+}
+
+// Names returns the registered names in sorted order.
+func Names() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	names := make([]string, 0, len(registry.funcs))
+	for name := range registry.funcs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -1,7 +1,10 @@
 package similarity
 
 import (
+	"errors"
 	"math"
+	"reflect"
+	"slices"
 	"testing"
 )
 
@@ -104,3 +107,203 @@ func TestSimilarityFunctions(t *testing.T) {
 		}
 	})
 }
+
+func TestAngular(t *testing.T) {
+	cases := []struct {
+		a, b []float64
+		want float64
+	}{
+		{[]float64{1, 0}, []float64{2, 0}, 1},
+		{[]float64{1, 0}, []float64{0, 1}, 0.5},
+		{[]float64{1, 0}, []float64{-1, 0}, 0},
+		{[]float64{1, 0}, []float64{1, 1}, 0.75},
+		{[]float64{1, 0}, []float64{1}, 0},
+		{[]float64{0, 0}, []float64{1, 0}, 0},
+	}
+	for _, c := range cases {
+		if sim := AngularSimilarity(c.a, c.b); math.Abs(sim-c.want) > 1e-9 {
+			t.Errorf("AngularSimilarity(%v, %v) = %f, want %f", c.a, c.b, sim, c.want)
+		}
+	}
+}
+
+func TestHamming(t *testing.T) {
+	a := []float64{0.3, -0.2, 0.9, -0.1}
+	b := []float64{0.1, 0.4, 0.2, -0.7}
+	if sim := HammingSimilarity(a, b); sim != 0.75 {
+		t.Errorf("Expected 0.75, got %f", sim)
+	}
+	if sim := HammingSimilarity(a, a[:3]); sim != 0 {
+		t.Errorf("Expected 0 for different length vectors, got %f", sim)
+	}
+
+	long := make([]float64, 130)
+	long[0], long[64], long[129] = 1, 1, 1
+	packed := Binarize(long)
+	if len(packed) != 3 || packed[0] != 1 || packed[1] != 1 || packed[2] != 2 {
+		t.Errorf("Binarize = %b", packed)
+	}
+	if d := HammingDistance(Binarize(a), Binarize(b)); d != 1 {
+		t.Errorf("Expected distance 1, got %d", d)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	wide := ToFloat64([]float32{0.5, -1, 2})
+	if len(wide) != 3 || wide[0] != 0.5 || wide[1] != -1 || wide[2] != 2 {
+		t.Errorf("ToFloat64 = %v", wide)
+	}
+	narrow := ToFloat32(wide)
+	if len(narrow) != 3 || narrow[0] != 0.5 || narrow[2] != 2 {
+		t.Errorf("ToFloat32 = %v", narrow)
+	}
+	if ToFloat64(nil) != nil || ToFloat32(nil) != nil {
+		t.Error("expected nil for nil vectors")
+	}
+}
+
+func TestFloat16(t *testing.T) {
+	exact := []float64{0, 1, -2, 0.5, 65504, 0x1p-24, -0x1p-14}
+	if got := FromFloat16(ToFloat16(exact)); !slices.Equal(got, exact) {
+		t.Errorf("expected representable values to round-trip, got %v", got)
+	}
+	if h := ToFloat16([]float64{1 + 0x1p-11, 1 + 3*0x1p-11}); h[0] != 0x3c00 || h[1] != 0x3c02 {
+		t.Errorf("expected ties to round to even, got %#x", h)
+	}
+	special := FromFloat16(ToFloat16([]float64{1e6, math.Inf(-1), math.NaN(), 0x1p-26}))
+	if !math.IsInf(special[0], 1) || !math.IsInf(special[1], -1) || !math.IsNaN(special[2]) || special[3] != 0 {
+		t.Errorf("expected overflow, infinities, NaN, and underflow to survive, got %v", special)
+	}
+	for _, x := range []float64{0.1, -0.3337, 0.00042, 123.456} {
+		if got := FromFloat16(ToFloat16([]float64{x}))[0]; math.Abs(got-x) > math.Abs(x)*0x1p-11 {
+			t.Errorf("%v rounded to %v, beyond half an ulp", x, got)
+		}
+	}
+	if ToFloat16(nil) != nil || FromFloat16(nil) != nil {
+		t.Error("expected nil for nil vectors")
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	a := []float64{1, 1, 0, 0}
+	b := []float64{1, 0, 1, 0}
+	if sim := JaccardSimilarity(a, b); math.Abs(sim-1.0/3) > 1e-9 {
+		t.Errorf("Expected 1/3 for set vectors, got %f", sim)
+	}
+	if sim := JaccardSimilarity([]float64{2, 0.5}, []float64{1, 1}); math.Abs(sim-1.5/3) > 1e-9 {
+		t.Errorf("Expected 0.5 for weighted vectors, got %f", sim)
+	}
+	if sim := JaccardSimilarity([]float64{0, 0}, []float64{0, 0}); sim != 0 {
+		t.Errorf("Expected 0 for zero vectors, got %f", sim)
+	}
+
+	sa, sb := Sparse(a), Sparse(b)
+	if len(sa.Indices) != 2 || sa.Indices[1] != 1 {
+		t.Errorf("Sparse = %+v", sa)
+	}
+	if sim := sa.Jaccard(sb); math.Abs(sim-1.0/3) > 1e-9 {
+		t.Errorf("Expected sparse Jaccard 1/3, got %f", sim)
+	}
+	dense, err := sb.Dense(4)
+	if err != nil || dense[2] != 1 || dense[1] != 0 {
+		t.Errorf("Dense = %v, %v", dense, err)
+	}
+	if _, err := sb.Dense(2); err != ErrSparseIndex {
+		t.Errorf("expected ErrSparseIndex, got %v", err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	fn, err := Get(" Cosine ")
+	if err != nil || fn([]float64{1, 0}, []float64{1, 0}) != 1 {
+		t.Fatalf("Get(cosine) = %v", err)
+	}
+	if _, err := Get("nope"); !errors.Is(err, ErrUnknownSimilarity) {
+		t.Errorf("expected ErrUnknownSimilarity, got %v", err)
+	}
+	if err := Register("", CosineSimilarity); err != ErrInvalidRegistration {
+		t.Errorf("expected ErrInvalidRegistration, got %v", err)
+	}
+	if err := Register("Half", func(a, b []float64) float64 { return 0.5 }); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if fn, err := Get("half"); err != nil || fn(nil, nil) != 0.5 {
+		t.Errorf("Get(half) = %v", err)
+	}
+	if names := Names(); !slices.Contains(names, "half") || !slices.IsSorted(names) {
+		t.Errorf("Names = %v", names)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	a, b := []float64{1, 0}, []float64{-1, 0}
+	if lo, hi, ok := ScoreRange(CosineSimilarity); !ok || lo != -1 || hi != 1 {
+		t.Errorf("ScoreRange(cosine) = %v, %v, %v", lo, hi, ok)
+	}
+	if lo, hi, ok := ScoreRange(DotProductSimilarity); !ok || !math.IsInf(lo, -1) || !math.IsInf(hi, 1) {
+		t.Errorf("ScoreRange(dot) = %v, %v, %v", lo, hi, ok)
+	}
+	if _, _, ok := ScoreRange(func(a, b []float64) float64 { return 2 }); ok {
+		t.Error("expected an unknown range for a custom function")
+	}
+
+	cos := Normalize(CosineSimilarity)
+	if s := cos(a, b); s != 0 {
+		t.Errorf("expected opposite vectors to score 0, got %f", s)
+	}
+	if s := cos(a, a); s != 1 {
+		t.Errorf("expected identical vectors to score 1, got %f", s)
+	}
+	if lo, hi, ok := ScoreRange(cos); !ok || lo != 0 || hi != 1 {
+		t.Errorf("ScoreRange(Normalize(cosine)) = %v, %v, %v", lo, hi, ok)
+	}
+	dot := Normalize(DotProductSimilarity)
+	if s := dot([]float64{3}, []float64{4}); s <= 0.5 || s > 1 {
+		t.Errorf("expected a positive dot product above 0.5, got %f", s)
+	}
+	if s := dot([]float64{0}, []float64{4}); s != 0.5 {
+		t.Errorf("expected a zero dot product at 0.5, got %f", s)
+	}
+	if s := Normalize(func(a, b []float64) float64 { return 2 })(a, b); s != 1 {
+		t.Errorf("expected custom scores clamped to 1, got %f", s)
+	}
+	if reflect.ValueOf(Normalize(JaccardSimilarity)).Pointer() != reflect.ValueOf(JaccardSimilarity).Pointer() {
+		t.Error("expected [0, 1] functions returned unchanged")
+	}
+}
+
+func TestCentroid(t *testing.T) {
+	vectors := [][]float64{{1, 0}, {0.9, 0.1}, {0, 1}}
+	c, err := Centroid(vectors[:2])
+	if err != nil || math.Abs(c[0]-0.95) > 1e-9 || math.Abs(c[1]-0.05) > 1e-9 {
+		t.Errorf("Centroid = %v, %v", c, err)
+	}
+	if _, err := Centroid(nil); err != ErrNoVectors {
+		t.Errorf("expected ErrNoVectors, got %v", err)
+	}
+	if _, err := Centroid([][]float64{{1}, {1, 2}}); err != ErrMixedDimensions {
+		t.Errorf("expected ErrMixedDimensions, got %v", err)
+	}
+	if c, err := WeightedCentroid([][]float64{vectors[0], vectors[2]}, []float64{3, 1}); err != nil || c[0] != 0.75 || c[1] != 0.25 {
+		t.Errorf("WeightedCentroid = %v, %v", c, err)
+	}
+	if _, err := WeightedCentroid(vectors, []float64{0, 0, 0}); err != ErrInvalidWeights {
+		t.Errorf("expected ErrInvalidWeights, got %v", err)
+	}
+
+	m := Matrix(vectors, CosineSimilarity)
+	if len(m) != 3 || math.Abs(m[0][0]-1) > 1e-9 || m[0][2] != 0 || m[1][2] != m[2][1] {
+		t.Errorf("Matrix = %v", m)
+	}
+
+	centroids := [][]float64{c, {0, 1}}
+	if i, s := NearestCentroid([]float64{0.1, 0.9}, centroids, CosineSimilarity); i != 1 || s <= 0.9 {
+		t.Errorf("NearestCentroid = %d, %f", i, s)
+	}
+	if i, _ := NearestCentroid([]float64{1, 0}, nil, CosineSimilarity); i != -1 {
+		t.Errorf("expected -1 without centroids, got %d", i)
+	}
+	if got := Assign(vectors, centroids, CosineSimilarity); !slices.Equal(got, []int{0, 0, 1}) {
+		t.Errorf("Assign = %v", got)
+	}
+}
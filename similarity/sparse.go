@@ -0,0 +1,73 @@
+package similarity
+
+import "errors"
+
+// ErrSparseIndex is returned by SparseVector.Dense when an index is
+// negative or not below the dimension.
+var ErrSparseIndex = errors.New("similarity: sparse index out of range")
+
+// SparseVector holds the non-zero dimensions of a vector, as produced by
+// sparse lexical models such as SPLADE. Indices and Values are parallel;
+// a repeated index adds to the same dimension.
+type SparseVector struct {
+	Indices []int
+	Values  []float64
+}
+
+// Sparse keeps the non-zero dimensions of a dense vector.
+func Sparse(v []float64) SparseVector {
+	var s SparseVector
+	for i, x := range v {
+		if x != 0 {
+			s.Indices = append(s.Indices, i)
+			s.Values = append(s.Values, x)
+		}
+	}
+	return s
+}
+
+// Dense expands s to a []float64 of length dim, the representation the
+// cache, backends, and comparators use.
+func (s SparseVector) Dense(dim int) ([]float64, error) {
+	out := make([]float64, dim)
+	for i, idx := range s.Indices {
+		if idx < 0 || idx >= dim {
+			return nil, ErrSparseIndex
+		}
+		out[idx] += s.Values[i]
+	}
+	return out, nil
+}
+
+// Jaccard is JaccardSimilarity computed over the non-zero dimensions only,
+// without expanding either vector.
+func (s SparseVector) Jaccard(o SparseVector) float64 {
+	a, b := s.weights(), o.weights()
+	var inter, union float64
+	for idx, x := range a {
+		y := b[idx]
+		inter += min(x, y)
+		union += max(x, y)
+	}
+	for idx, y := range b {
+		if _, ok := a[idx]; !ok {
+			union += y
+		}
+	}
+	if union == 0 {
+		return 0
+	}
+	return inter / union
+}
+
+// weights sums s by index, clamping negative totals to zero.
+func (s SparseVector) weights() map[int]float64 {
+	w := make(map[int]float64, len(s.Indices))
+	for i, idx := range s.Indices {
+		w[idx] += s.Values[i]
+	}
+	for idx, x := range w {
+		w[idx] = max(x, 0)
+	}
+	return w
+}
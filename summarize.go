@@ -0,0 +1,70 @@
+package semanticcache
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// summaryConfig holds the chunk summarizer and its cost controls.
+type summaryConfig struct {
+	summarizer types.Summarizer // nil embeds chunks as they are
+	minTokens  int
+	slots      chan struct{} // one per concurrent summary, shared by namespace views
+}
+
+func newSummaryConfig(s types.Summarizer, workers, minTokens int) summaryConfig {
+	if s == nil {
+		return summaryConfig{}
+	}
+	return summaryConfig{summarizer: s, minTokens: minTokens, slots: make(chan struct{}, workers)}
+}
+
+// summarize returns texts with each chunk of at least minTokens tokens
+// replaced by its summary. Chunks whose summary fails or is empty are kept.
+func (c *Cache[K, V]) summarize(ctx context.Context, texts []string) ([]string, error) {
+	s := c.summary
+	if s.summarizer == nil {
+		return texts, nil
+	}
+	out := slices.Clone(texts)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for i, text := range texts {
+		n, err := c.chunker.CountTokens(text)
+		if err != nil {
+			return nil, err
+		}
+		if n < s.minTokens {
+			continue
+		}
+		select {
+		case s.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-s.slots
+				wg.Done()
+			}()
+			summary, err := s.summarizer.Summarize(ctx, text)
+			switch {
+			case err != nil:
+				c.logger.WarnContext(ctx, "semanticcache: chunk summary failed; embedding the chunk as is", "error", err)
+			case summary == "":
+				c.logger.WarnContext(ctx, "semanticcache: chunk summary was empty; embedding the chunk as is")
+			default:
+				out[i] = summary
+			}
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
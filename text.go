@@ -0,0 +1,76 @@
+package semanticcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"strings"
+)
+
+// TextKey returns the key SetText stores inputText under: the hex SHA-256
+// of the text lowercased with surrounding whitespace trimmed and inner runs
+// collapsed to one space. Texts differing only in case or spacing share a
+// key.
+func TextKey(inputText string) string {
+	sum := sha256.Sum256([]byte(normalizeText(inputText)))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeText lowercases inputText and collapses its whitespace.
+func normalizeText(inputText string) string {
+	return strings.Join(strings.Fields(strings.ToLower(inputText)), " ")
+}
+
+// textKey converts TextKey(inputText) to K, which must be of string kind.
+func textKey[K comparable](inputText string) (K, error) {
+	var key K
+	v := reflect.ValueOf(&key).Elem()
+	if v.Kind() != reflect.String {
+		return key, ErrTextKeyUnsupported
+	}
+	v.SetString(TextKey(inputText))
+	return key, nil
+}
+
+// SetText stores value under a key derived from inputText (see TextKey)
+// and returns that key. Setting the same text twice overwrites the entry
+// instead of adding a near-duplicate. K must be of string kind.
+func (c *Cache[K, V]) SetText(ctx context.Context, inputText string, value V) (K, error) {
+	key, err := textKey[K](inputText)
+	if err != nil {
+		return key, err
+	}
+	return key, c.Set(ctx, key, inputText, value)
+}
+
+// LookupText is LookupEx for entries stored with SetText. An entry whose
+// text normalizes to the same key is returned with a score of 1 without
+// embedding the query; otherwise it falls back to a similarity search.
+func (c *Cache[K, V]) LookupText(ctx context.Context, inputText string, threshold float64, opts ...LookupOption) (*MatchEx[K, V], error) {
+	if err := c.begin(); err != nil {
+		return nil, err
+	}
+	defer c.end()
+	key, err := textKey[K](inputText)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := c.newLookupConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	target := c
+	if cfg.namespace != "" {
+		target = c.Namespace(cfg.namespace)
+	}
+	entry, found, err := target.getEntry(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if found && accepts(cfg, key, entry.Metadata) {
+		m := newMatchEx(key, entry, 1)
+		return &m, nil
+	}
+	return c.LookupEx(ctx, inputText, threshold, opts...)
+}
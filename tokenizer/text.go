@@ -0,0 +1,116 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tiktoken-go/tokenizer"
+
+	"github.com/botirk38/semanticcache/types"
+)
+
+// Cl100kBase is the tiktoken encoding of OpenAI's embedding models.
+const Cl100kBase = string(tokenizer.Cl100kBase)
+
+// TextTokenizer splits plain text into tokens locally, for counting and
+// windowing text before it is embedded.
+type TextTokenizer interface {
+	// Tokens splits text into tokens that concatenate back to text.
+	Tokens(text string) ([]string, error)
+}
+
+// Provider is implemented by embedding providers that know their model's
+// tokenizer.
+type Provider interface {
+	TextTokenizer() TextTokenizer
+}
+
+// Tiktoken is a TextTokenizer using a tiktoken encoding.
+type Tiktoken struct {
+	codec tokenizer.Codec
+}
+
+// NewTiktoken creates a Tiktoken for the named encoding, such as
+// Cl100kBase.
+func NewTiktoken(encoding string) (*Tiktoken, error) {
+	codec, err := tokenizer.Get(tokenizer.Encoding(encoding))
+	if err != nil {
+		return nil, err
+	}
+	return &Tiktoken{codec: codec}, nil
+}
+
+// Tokens splits text into its tiktoken tokens. A token may hold part of a
+// multi-byte character.
+func (t *Tiktoken) Tokens(text string) ([]string, error) {
+	_, tokens, err := t.codec.Encode(text)
+	return tokens, err
+}
+
+// Approximate is a TextTokenizer for models without a local tokenizer,
+// such as Gemini, Cohere, and most local models. It splits each word, with
+// the whitespace before it, into pieces of RunesPerToken runes (4 when
+// unset), and punctuation into pieces of its own, which tracks SentencePiece
+// and WordPiece counts on English text to within a few tens of percent.
+type Approximate struct {
+	RunesPerToken int
+}
+
+// Tokens splits text into approximate tokens.
+func (a Approximate) Tokens(text string) ([]string, error) {
+	size := a.RunesPerToken
+	if size <= 0 {
+		size = 4
+	}
+	var tokens []string
+	start, runes := 0, 0 // the pending token is text[start:i], with runes non-space runes
+	cut := func(i int) {
+		if i > start {
+			tokens = append(tokens, text[start:i])
+		}
+		start, runes = i, 0
+	}
+	prevSpace := false
+	for i, r := range text {
+		space := unicode.IsSpace(r)
+		switch {
+		case space:
+			// Whitespace opens a token that the next word joins.
+			if !prevSpace {
+				cut(i)
+			}
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			if runes > 0 {
+				cut(i)
+			}
+			cut(i + utf8.RuneLen(r))
+		default:
+			if runes == size {
+				cut(i)
+			}
+			runes++
+		}
+		prevSpace = space
+	}
+	cut(len(text))
+	return tokens, nil
+}
+
+// ForProvider returns the TextTokenizer matching provider's model: the
+// provider's own when it implements Provider, cl100k_base for OpenAI
+// models, identified by a types.VersionedProvider version starting with
+// "openai/", and Approximate otherwise.
+func ForProvider(provider types.EmbeddingProvider) TextTokenizer {
+	if p, ok := provider.(Provider); ok {
+		if t := p.TextTokenizer(); t != nil {
+			return t
+		}
+	}
+	if vp, ok := provider.(types.VersionedProvider); ok && strings.HasPrefix(vp.EmbeddingVersion(), "openai/") {
+		if t, err := NewTiktoken(Cl100kBase); err == nil {
+			return t
+		}
+	}
+	return Approximate{}
+}
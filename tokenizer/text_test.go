@@ -0,0 +1,57 @@
+package tokenizer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/botirk38/semanticcache/providers/local"
+)
+
+func TestApproximate(t *testing.T) {
+	text := "Hello, world!  Tokenization of longer words\n\tand 你好世界."
+	tokens, err := Approximate{}.Tokens(text)
+	if err != nil {
+		t.Fatalf("Tokens() error = %v", err)
+	}
+	if got := strings.Join(tokens, ""); got != text {
+		t.Errorf("tokens join to %q, want %q", got, text)
+	}
+	if len(tokens) < 10 || len(tokens) > 25 {
+		t.Errorf("got %d tokens %q, want a rough subword count", len(tokens), tokens)
+	}
+	if tokens, _ := (Approximate{}).Tokens(""); len(tokens) != 0 {
+		t.Errorf("expected no tokens for empty text, got %q", tokens)
+	}
+}
+
+type openAIProvider struct{}
+
+func (openAIProvider) EmbedText(context.Context, string) ([]float64, error) { return nil, nil }
+func (openAIProvider) Close() error                                         { return nil }
+func (openAIProvider) EmbeddingVersion() string                             { return "openai/text-embedding-3-small" }
+
+type ownProvider struct{ openAIProvider }
+
+func (ownProvider) TextTokenizer() TextTokenizer { return Approximate{RunesPerToken: 3} }
+
+func TestForProvider(t *testing.T) {
+	if _, ok := ForProvider(openAIProvider{}).(*Tiktoken); !ok {
+		t.Error("expected tiktoken for an OpenAI model")
+	}
+	if _, ok := ForProvider(local.New(8)).(Approximate); !ok {
+		t.Error("expected an approximation for a local model")
+	}
+	if tok, ok := ForProvider(ownProvider{}).(Approximate); !ok || tok.RunesPerToken != 3 {
+		t.Errorf("expected the provider's own tokenizer, got %#v", tok)
+	}
+
+	tok, err := NewTiktoken(Cl100kBase)
+	if err != nil {
+		t.Fatalf("NewTiktoken() error = %v", err)
+	}
+	tokens, _ := tok.Tokens("Hello, world!")
+	if strings.Join(tokens, "") != "Hello, world!" || len(tokens) != 4 {
+		t.Errorf("unexpected tiktoken tokens %q", tokens)
+	}
+}
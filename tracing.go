@@ -0,0 +1,64 @@
+package semanticcache
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName is the instrumentation scope of the cache's spans.
+const tracerName = "github.com/botirk38/semanticcache"
+
+// Span attributes. Keys and texts are never recorded, since they may hold
+// user data.
+const (
+	attrKeys    = attribute.Key("semanticcache.keys")    // keys in a batch operation
+	attrTexts   = attribute.Key("semanticcache.texts")   // texts sent to the provider
+	attrDims    = attribute.Key("semanticcache.dims")    // embedding dimension
+	attrHit     = attribute.Key("semanticcache.hit")     // whether a read found anything
+	attrMatches = attribute.Key("semanticcache.matches") // matches a search returned
+	attrScore   = attribute.Key("semanticcache.score")   // the best match's score
+	attrLimit   = attribute.Key("semanticcache.limit")   // matches a search asked for
+)
+
+// noopTracer serves caches without options.WithTracerProvider.
+var noopTracer = noop.NewTracerProvider().Tracer(tracerName)
+
+// newTracer returns tp's tracer for the cache, or a no-op one for nil.
+func newTracer(tp trace.TracerProvider) trace.Tracer {
+	if tp == nil {
+		return noopTracer
+	}
+	return tp.Tracer(tracerName)
+}
+
+// startSpan starts the span "semanticcache.<name>" as a child of any span
+// in ctx.
+func (c *Cache[K, V]) startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := c.tracer
+	if tracer == nil {
+		tracer = noopTracer
+	}
+	return tracer.Start(ctx, "semanticcache."+name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// endSearchSpan records a search's results on span and ends it.
+func endSearchSpan[K comparable, V any](span trace.Span, matches []MatchEx[K, V], err error) {
+	span.SetAttributes(attrHit.Bool(len(matches) > 0), attrMatches.Int(len(matches)))
+	if len(matches) > 0 {
+		span.SetAttributes(attrScore.Float64(matches[0].Score))
+	}
+	endSpan(span, err)
+}
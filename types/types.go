@@ -1,12 +1,28 @@
 // Package types defines the core interfaces and types for the semantic cache.
 package types
 
-import "context"
+import (
+	"context"
+	"iter"
+	"time"
+)
 
-// Entry holds an embedding vector alongside its cached value.
+// Entry holds an embedding vector alongside its cached value. InputText,
+// CreatedAt, Metadata, and EmbeddingVersion are retained only by backends
+// implementing EntryBackend, and Vectors only by MultiVectorBackend.
 type Entry[V any] struct {
 	Embedding []float64
 	Value     V
+	InputText string
+	CreatedAt time.Time
+	Metadata  map[string]string
+
+	// EmbeddingVersion identifies the model that produced Embedding.
+	EmbeddingVersion string
+
+	// Vectors holds further embeddings of the same value, such as one per
+	// chunk or paraphrase. Lookups score the entry by its best vector.
+	Vectors [][]float64
 }
 
 // Backend is the storage interface that every cache backend must implement.
@@ -39,6 +55,240 @@ type Backend[K comparable, V any] interface {
 	Close() error
 }
 
+// BatchBackend is an optional extension for backends that can write, read,
+// and delete multiple keys in a single round trip.
+type BatchBackend[K comparable, V any] interface {
+	Backend[K, V]
+
+	// SetBatch stores multiple entries.
+	SetBatch(ctx context.Context, entries map[K]Entry[V]) error
+
+	// GetBatch retrieves the values for multiple keys. Missing keys are
+	// omitted from the result.
+	GetBatch(ctx context.Context, keys []K) (map[K]V, error)
+
+	// DeleteBatch removes multiple entries.
+	DeleteBatch(ctx context.Context, keys []K) error
+}
+
+// EmbeddingBatchBackend is an optional extension for backends that can
+// read many embeddings at once, such as in one lock pass or one pipelined
+// round trip. The cache uses it when scanning for similarity matches.
+type EmbeddingBatchBackend[K comparable, V any] interface {
+	Backend[K, V]
+
+	// GetEmbeddings retrieves the embeddings for multiple keys. Missing
+	// keys are omitted from the result.
+	GetEmbeddings(ctx context.Context, keys []K) (map[K][]float64, error)
+}
+
+// ConditionalBackend is an optional extension for backends that can make a
+// write conditional on the current state of a key, atomically with respect
+// to other writers.
+type ConditionalBackend[K comparable, V any] interface {
+	Backend[K, V]
+
+	// SetIfAbsent stores entry only if key is not present, reporting
+	// whether it was stored.
+	SetIfAbsent(ctx context.Context, key K, entry Entry[V]) (bool, error)
+
+	// CompareAndSwap stores entry only if key is present and its value
+	// equals old, reporting whether it was stored. In-memory backends
+	// compare with reflect.DeepEqual; remote backends compare the encoded
+	// values.
+	CompareAndSwap(ctx context.Context, key K, old V, entry Entry[V]) (bool, error)
+}
+
+// ExpiringBackend is an optional extension for backends whose entries can
+// expire. Each method reports whether key existed.
+type ExpiringBackend[K comparable, V any] interface {
+	Backend[K, V]
+
+	// Expire makes key expire after ttl, replacing any current expiry. A
+	// non-positive ttl deletes the entry.
+	Expire(ctx context.Context, key K, ttl time.Duration) (bool, error)
+
+	// Persist removes key's expiry.
+	Persist(ctx context.Context, key K) (bool, error)
+
+	// Touch marks key as used without rewriting it. Backends with a default
+	// lifetime restart it.
+	Touch(ctx context.Context, key K) (bool, error)
+}
+
+// PinningBackend is an optional extension for backends with an eviction
+// policy that can exempt individual keys from it. Each method reports
+// whether key existed.
+type PinningBackend[K comparable, V any] interface {
+	Backend[K, V]
+
+	// Pin protects key from eviction until it is unpinned or deleted.
+	Pin(ctx context.Context, key K) (bool, error)
+
+	// Unpin makes key evictable again.
+	Unpin(ctx context.Context, key K) (bool, error)
+}
+
+// EntryBackend is an optional extension for backends that store whole
+// entries, including the input text, creation time, and metadata, rather
+// than only the embedding and value.
+type EntryBackend[K comparable, V any] interface {
+	Backend[K, V]
+
+	// SetEntry stores entry under key.
+	SetEntry(ctx context.Context, key K, entry Entry[V]) error
+
+	// GetEntry retrieves the entry for a key. It counts as an access in the
+	// same way Get does.
+	GetEntry(ctx context.Context, key K) (Entry[V], bool, error)
+}
+
+// PeekingBackend is an optional extension for entry backends whose reads
+// count as accesses. Maintenance such as the expiry sweep reads through it
+// so that scanning entries leaves eviction order and access counts alone.
+// Backends whose GetEntry has no side effects need not implement it.
+type PeekingBackend[K comparable, V any] interface {
+	EntryBackend[K, V]
+
+	// PeekEntry retrieves the entry for a key without counting an access.
+	PeekEntry(ctx context.Context, key K) (Entry[V], bool, error)
+}
+
+// MultiVectorBackend is an optional extension for entry backends that keep
+// Entry.Vectors and can return them for scoring.
+type MultiVectorBackend[K comparable, V any] interface {
+	EntryBackend[K, V]
+
+	// GetVectors returns, for each stored key, its Embedding followed by
+	// its Vectors. Missing keys are omitted.
+	GetVectors(ctx context.Context, keys []K) (map[K][][]float64, error)
+}
+
+// EntryStats describes an entry's age and use, for ranking.
+type EntryStats struct {
+	CreatedAt time.Time
+	// Accesses counts the entry's reads and writes; 0 when the backend
+	// does not track them.
+	Accesses int
+}
+
+// StatsBackend is an optional extension for backends that can report
+// entry stats without counting the lookup as an access.
+type StatsBackend[K comparable, V any] interface {
+	Backend[K, V]
+
+	// EntryStats returns the stats of each stored key. Missing keys are
+	// omitted.
+	EntryStats(ctx context.Context, keys []K) (map[K]EntryStats, error)
+}
+
+// MemoryUsage estimates the bytes a backend holds, by what holds them.
+// Estimates count headers and backing arrays but not allocator overhead or
+// sharing, so compare them with each other rather than with heap profiles.
+type MemoryUsage struct {
+	Entries    int
+	Embeddings int64 // embedding and extra vector arrays, including index copies
+	Values     int64 // keys, values, input texts, metadata, and entry fields
+	Index      int64 // maps, lists, graphs, and other bookkeeping
+}
+
+// Total returns the estimated bytes across all kinds.
+func (u MemoryUsage) Total() int64 {
+	return u.Embeddings + u.Values + u.Index
+}
+
+// Add returns the sum of u and o.
+func (u MemoryUsage) Add(o MemoryUsage) MemoryUsage {
+	return MemoryUsage{
+		Entries:    u.Entries + o.Entries,
+		Embeddings: u.Embeddings + o.Embeddings,
+		Values:     u.Values + o.Values,
+		Index:      u.Index + o.Index,
+	}
+}
+
+// MemoryReporter is an optional extension for in-process backends that can
+// estimate the memory their entries and indexes hold.
+type MemoryReporter[K comparable, V any] interface {
+	Backend[K, V]
+
+	// MemoryUsage walks every entry, so it costs about as much as Keys.
+	MemoryUsage(ctx context.Context) (MemoryUsage, error)
+}
+
+// SearchResult is a single hit returned by VectorSearcher.
+type SearchResult[K comparable, V any] struct {
+	Key   K
+	Value V
+	Score float64
+}
+
+// VectorSearcher is an optional extension for backends that maintain a
+// vector index and can answer nearest-neighbor queries without scanning
+// every entry.
+type VectorSearcher[K comparable, V any] interface {
+	Backend[K, V]
+
+	// VectorSearch returns up to k entries most similar to query, ordered
+	// by descending Score. Scores are cosine similarities.
+	VectorSearch(ctx context.Context, query []float64, k int) ([]SearchResult[K, V], error)
+}
+
+// KeyIterator is an optional extension for backends that can stream their
+// keys instead of materializing them all, such as a Redis SCAN cursor.
+type KeyIterator[K comparable, V any] interface {
+	Backend[K, V]
+
+	// IterKeys yields every stored key. A non-nil error is yielded at most
+	// once and ends the iteration. Keys written or deleted during the
+	// iteration may or may not be seen.
+	IterKeys(ctx context.Context) iter.Seq2[K, error]
+}
+
+// TxnOp is a single write in a transaction: a Set of Entry, or a Delete
+// when Delete is true.
+type TxnOp[K comparable, V any] struct {
+	Key    K
+	Entry  Entry[V]
+	Delete bool
+}
+
+// Transactional is an optional extension for backends that can apply
+// several writes atomically: other callers observe either none or all of
+// them.
+type Transactional[K comparable, V any] interface {
+	Backend[K, V]
+
+	// Txn applies ops in order as a single atomic unit. If it returns an
+	// error before committing, none of the ops were applied. A backend
+	// whose commit can fail part way, such as Redis, documents what a
+	// failure leaves behind.
+	Txn(ctx context.Context, ops []TxnOp[K, V]) error
+}
+
+// EvictionNotifier is an optional extension for backends that evict entries
+// on their own, for example when a capacity limit is reached or a TTL
+// expires. Explicit Delete, Flush, and overwrites are not evictions.
+type EvictionNotifier[K comparable, V any] interface {
+	Backend[K, V]
+
+	// OnEvict registers fn to be called for every evicted entry, replacing
+	// any previous callback. fn runs while the backend holds its internal
+	// lock and must not call back into the backend.
+	OnEvict(fn func(key K, entry Entry[V]))
+}
+
+// Shutdowner is an optional extension for backends that run background
+// work, such as a replication queue. Shutdown is Close bounded by ctx.
+type Shutdowner[K comparable, V any] interface {
+	Backend[K, V]
+
+	// Shutdown rejects new writes, waits for outstanding background work
+	// until ctx ends, cancels whatever is left, and releases resources. It
+	// returns ctx.Err() if work had to be cancelled.
+	Shutdown(ctx context.Context) error
+}
+
 // EmbeddingProvider turns text into embedding vectors.
 type EmbeddingProvider interface {
 	// EmbedText computes the embedding vector for a single piece of text.
@@ -48,6 +298,33 @@ type EmbeddingProvider interface {
 	Close() error
 }
 
+// Reranker rescores a lookup's best candidates, typically with a
+// cross-encoder or an LLM, when precision matters more than latency.
+type Reranker interface {
+	// Rerank returns one score per text, higher meaning more relevant to
+	// query. texts are the candidates' stored input texts.
+	Rerank(ctx context.Context, query string, texts []string) ([]float64, error)
+}
+
+// Summarizer condenses a chunk of text before it is embedded, typically
+// with an LLM call, so verbose passages such as transcripts embed by what
+// they say rather than how.
+type Summarizer interface {
+	// Summarize returns a summary of text.
+	Summarize(ctx context.Context, text string) (string, error)
+}
+
+// VersionedProvider is an optional extension for providers that can name
+// the model behind their embeddings. A cache stamps entries with the
+// version so it can tell which ones a model change made incomparable.
+type VersionedProvider interface {
+	EmbeddingProvider
+
+	// EmbeddingVersion identifies the provider and model, such as
+	// "openai/text-embedding-3-small".
+	EmbeddingVersion() string
+}
+
 // BatchEmbeddingProvider is an optional extension for providers that
 // support embedding multiple texts in a single API call.
 type BatchEmbeddingProvider interface {
@@ -56,3 +333,22 @@ type BatchEmbeddingProvider interface {
 	// EmbedBatch embeds multiple texts in one operation.
 	EmbedBatch(ctx context.Context, texts []string) ([][]float64, error)
 }
+
+// TokenEmbedding is the contextual embedding of one token of an embedded
+// text, the token spanning text[Start:End] in bytes.
+type TokenEmbedding struct {
+	Start, End int
+	Vector     []float64
+}
+
+// TokenEmbeddingProvider is an optional extension for providers whose
+// models can return an embedding per token, such as Jina v3 or local
+// models exposing their last hidden state. Caches with late chunking embed
+// a long text once and pool these per chunk.
+type TokenEmbeddingProvider interface {
+	EmbeddingProvider
+
+	// EmbedTokens embeds text in one pass and returns its tokens'
+	// embeddings in order.
+	EmbedTokens(ctx context.Context, text string) ([]TokenEmbedding, error)
+}